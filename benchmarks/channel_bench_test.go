@@ -1,7 +1,7 @@
 package benchmarks
 
 import (
-	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/bench"
 	"tasks-service-demo/internal/storage/channel"
 	"testing"
 )
@@ -23,62 +23,17 @@ func BenchmarkWriteZipf_ChannelStore(b *testing.B) {
 func BenchmarkDistributedRead_ChannelStore(b *testing.B) {
 	store := channel.NewChannelStore(4)
 	defer store.Shutdown()
-	PopulateStore(b, store, "ChannelStore Distributed Read")
-
-	b.ResetTimer()
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			// Uniform distribution across all keys
-			targetID := (i % DatasetSize) + 1
-			store.GetByID(targetID)
-			i++
-		}
-	})
+	BenchmarkScenario(b, store, "ChannelStore Distributed Read", bench.DistributedRead)
 }
 
 func BenchmarkDistributedWrite_ChannelStore(b *testing.B) {
 	store := channel.NewChannelStore(4)
 	defer store.Shutdown()
-	PopulateStore(b, store, "ChannelStore Distributed Write")
-
-	b.ResetTimer()
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			targetID := (i % DatasetSize) + 1
-			updatedTask := &entities.Task{
-				Name:   "Distributed Update Task",
-				Status: i % 2,
-			}
-			store.Update(targetID, updatedTask)
-			i++
-		}
-	})
+	BenchmarkScenario(b, store, "ChannelStore Distributed Write", bench.DistributedWrite)
 }
 
 func BenchmarkDistributedMixed_ChannelStore(b *testing.B) {
 	store := channel.NewChannelStore(4)
 	defer store.Shutdown()
-	PopulateStore(b, store, "ChannelStore Distributed Mixed")
-
-	b.ResetTimer()
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			targetID := (i % DatasetSize) + 1
-
-			// 70% reads, 30% writes
-			if i%10 < 7 {
-				store.GetByID(targetID)
-			} else {
-				updatedTask := &entities.Task{
-					Name:   "Mixed Update Task",
-					Status: i % 2,
-				}
-				store.Update(targetID, updatedTask)
-			}
-			i++
-		}
-	})
+	BenchmarkScenario(b, store, "ChannelStore Distributed Mixed", bench.DistributedMixed)
 }
@@ -0,0 +1,87 @@
+package benchmarks
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"tasks-service-demo/internal/compress"
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/storage/badger"
+)
+
+// Storage compression - the cost of compressing large task values in badger
+// with each codec, against storing their JSON as is, and the bytes stored
+// per task.
+
+const compressedDatasetSize = 2000
+
+// largeBenchTask is a task with a 50-item checklist, about 4KB of JSON
+func largeBenchTask() *entities.Task {
+	task := &entities.Task{Name: "Compressed benchmark task"}
+	subtasks := make([]entities.Subtask, 50)
+	for i := range subtasks {
+		subtasks[i] = entities.Subtask{ID: i + 1, Name: fmt.Sprintf("Review section %d of the release notes with the docs team", i+1)}
+	}
+	task.SetSubtasks(subtasks)
+	return task
+}
+
+func openCompressedBadger(b *testing.B, codec compress.Codec) (*badger.BadgerStore, *compress.Compressor) {
+	compressor, err := compress.New(compress.Config{Codec: codec})
+	if err != nil {
+		b.Fatal(err)
+	}
+	store, err := badger.Open(badger.Config{Compressor: compressor})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { store.Close() })
+	return store, compressor
+}
+
+func benchmarkCompressedReads(b *testing.B, codec compress.Codec) {
+	store, _ := openCompressedBadger(b, codec)
+	for i := 0; i < compressedDatasetSize; i++ {
+		store.Create(largeBenchTask())
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		store.GetByID(GetZipfTargetID(i)%compressedDatasetSize + 1)
+	}
+}
+
+func benchmarkCompressedWrites(b *testing.B, codec compress.Codec) {
+	store, compressor := openCompressedBadger(b, codec)
+	value, _ := json.Marshal(largeBenchTask())
+
+	for i := 0; i < b.N; i++ {
+		store.Create(largeBenchTask())
+	}
+	b.ReportMetric(float64(len(compressor.Compress(value))), "stored-bytes/task")
+}
+
+func BenchmarkCompressionRead_Badger(b *testing.B) {
+	benchmarkCompressedReads(b, compress.None)
+}
+
+func BenchmarkCompressionRead_BadgerSnappy(b *testing.B) {
+	benchmarkCompressedReads(b, compress.Snappy)
+}
+
+func BenchmarkCompressionRead_BadgerZstd(b *testing.B) {
+	benchmarkCompressedReads(b, compress.Zstd)
+}
+
+func BenchmarkCompressionWrite_Badger(b *testing.B) {
+	benchmarkCompressedWrites(b, compress.None)
+}
+
+func BenchmarkCompressionWrite_BadgerSnappy(b *testing.B) {
+	benchmarkCompressedWrites(b, compress.Snappy)
+}
+
+func BenchmarkCompressionWrite_BadgerZstd(b *testing.B) {
+	benchmarkCompressedWrites(b, compress.Zstd)
+}
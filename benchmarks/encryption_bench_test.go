@@ -0,0 +1,96 @@
+package benchmarks
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"tasks-service-demo/internal/crypt"
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/badger"
+	"tasks-service-demo/internal/storage/mmap"
+)
+
+// Encryption at rest - the cost of sealing task payloads with AES-256-GCM
+// in the durable backends, against the same backend storing plaintext.
+
+const encryptedDatasetSize = 10000
+
+func benchSealer(b *testing.B, encrypted bool) *crypt.Sealer {
+	if !encrypted {
+		return nil
+	}
+	sealer, err := crypt.New([]crypt.Key{{ID: "bench", Secret: bytes.Repeat([]byte{1}, 32)}})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return sealer
+}
+
+func benchmarkDurableReads(b *testing.B, store storage.Store) {
+	for i := 0; i < encryptedDatasetSize; i++ {
+		store.Create(&entities.Task{Name: "Encrypted benchmark task"})
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		store.GetByID(GetZipfTargetID(i)%encryptedDatasetSize + 1)
+	}
+}
+
+func benchmarkDurableWrites(b *testing.B, store storage.Store) {
+	for i := 0; i < b.N; i++ {
+		store.Create(&entities.Task{Name: "Encrypted benchmark task"})
+	}
+}
+
+func openBadger(b *testing.B, encrypted bool) *badger.BadgerStore {
+	store, err := badger.Open(badger.Config{Sealer: benchSealer(b, encrypted)})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { store.Close() })
+	return store
+}
+
+func openMmap(b *testing.B, encrypted bool) *mmap.MmapStore {
+	store, err := mmap.Open(mmap.Config{Path: filepath.Join(b.TempDir(), "tasks.mmap"), Sealer: benchSealer(b, encrypted)})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { store.Close() })
+	return store
+}
+
+func BenchmarkEncryptionRead_Badger(b *testing.B) {
+	benchmarkDurableReads(b, openBadger(b, false))
+}
+
+func BenchmarkEncryptionRead_BadgerSealed(b *testing.B) {
+	benchmarkDurableReads(b, openBadger(b, true))
+}
+
+func BenchmarkEncryptionWrite_Badger(b *testing.B) {
+	benchmarkDurableWrites(b, openBadger(b, false))
+}
+
+func BenchmarkEncryptionWrite_BadgerSealed(b *testing.B) {
+	benchmarkDurableWrites(b, openBadger(b, true))
+}
+
+func BenchmarkEncryptionRead_Mmap(b *testing.B) {
+	benchmarkDurableReads(b, openMmap(b, false))
+}
+
+func BenchmarkEncryptionRead_MmapSealed(b *testing.B) {
+	benchmarkDurableReads(b, openMmap(b, true))
+}
+
+func BenchmarkEncryptionWrite_Mmap(b *testing.B) {
+	benchmarkDurableWrites(b, openMmap(b, false))
+}
+
+func BenchmarkEncryptionWrite_MmapSealed(b *testing.B) {
+	benchmarkDurableWrites(b, openMmap(b, true))
+}
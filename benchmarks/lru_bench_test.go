@@ -0,0 +1,36 @@
+package benchmarks
+
+import (
+	"tasks-service-demo/internal/storage/lru"
+	"tasks-service-demo/internal/storage/xsync"
+	"testing"
+)
+
+// LRU decorator Benchmarks - GetByID cache in front of XSyncStore
+// Cache holds the 20% hot set so Zipf reads mostly hit; compare against
+// BenchmarkReadZipf_XSyncStore for the raw store.
+
+const cacheSize = DatasetSize / 5
+
+func BenchmarkReadZipf_LRUCache(b *testing.B) {
+	store := lru.NewCachedStore(xsync.NewXSyncStore(), lru.Config{Policy: lru.PolicyLRU, Size: cacheSize})
+	BenchmarkReadZipf(b, store, "LRU XSyncStore")
+	b.ReportMetric(float64(store.Stats().Hits)/float64(b.N), "hit-ratio")
+}
+
+func BenchmarkReadZipf_LFUCache(b *testing.B) {
+	store := lru.NewCachedStore(xsync.NewXSyncStore(), lru.Config{Policy: lru.PolicyLFU, Size: cacheSize})
+	BenchmarkReadZipf(b, store, "LFU XSyncStore")
+	b.ReportMetric(float64(store.Stats().Hits)/float64(b.N), "hit-ratio")
+}
+
+func BenchmarkReadZipf_ARCCache(b *testing.B) {
+	store := lru.NewCachedStore(xsync.NewXSyncStore(), lru.Config{Policy: lru.PolicyARC, Size: cacheSize})
+	BenchmarkReadZipf(b, store, "ARC XSyncStore")
+	b.ReportMetric(float64(store.Stats().Hits)/float64(b.N), "hit-ratio")
+}
+
+func BenchmarkWriteZipf_LRUCache(b *testing.B) {
+	store := lru.NewCachedStore(xsync.NewXSyncStore(), lru.Config{Policy: lru.PolicyLRU, Size: cacheSize})
+	BenchmarkWriteZipf(b, store, "LRU XSyncStore")
+}
@@ -0,0 +1,79 @@
+package benchmarks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"tasks-service-demo/internal/bench"
+	"tasks-service-demo/internal/storage"
+	_ "tasks-service-demo/internal/storage/backends"
+)
+
+// TestBenchmarkRegression runs every scenario of each baseline in
+// baseline/ with the settings it was recorded with and fails on a p95 or
+// allocs/op regression beyond BENCH_P95_THRESHOLD and
+// BENCH_ALLOCS_THRESHOLD. Timings depend on the machine, so it only runs
+// with BENCH_REGRESSION=1 (make bench-regression), on the machine the
+// baselines were recorded on.
+func TestBenchmarkRegression(t *testing.T) {
+	if os.Getenv("BENCH_REGRESSION") != "1" {
+		t.Skip("set BENCH_REGRESSION=1 to check the benchmark baselines")
+	}
+	thresholds := bench.DefaultThresholds
+	thresholds.P95 = envFloat(t, "BENCH_P95_THRESHOLD", thresholds.P95)
+	thresholds.Allocs = envFloat(t, "BENCH_ALLOCS_THRESHOLD", thresholds.Allocs)
+
+	files, _ := filepath.Glob(filepath.Join("baseline", "*.txt"))
+	if len(files) == 0 {
+		t.Fatal("no baselines in baseline/; record them with make bench-baseline")
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".txt")
+		t.Run(name, func(t *testing.T) {
+			baseline, err := bench.LoadBaseline("baseline", name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			backend, ok := storage.Lookup(baseline.Storage)
+			if !ok {
+				t.Fatalf("baseline of unregistered storage %q", baseline.Storage)
+			}
+
+			var results []bench.Result
+			for scenarioName := range baseline.Results {
+				scenario, ok := bench.Lookup(scenarioName)
+				if !ok {
+					t.Errorf("baseline of unknown scenario %q", scenarioName)
+					continue
+				}
+				result, err := bench.RunFresh(context.Background(), backend,
+					storage.Options{ShardCount: baseline.Shards}, scenario, baseline.Config)
+				if err != nil {
+					t.Fatal(err)
+				}
+				t.Log(result)
+				results = append(results, result)
+			}
+			for _, r := range baseline.Check(results, thresholds) {
+				t.Errorf("regression: %s", r)
+			}
+		})
+	}
+}
+
+// envFloat returns the float in the environment variable key, def if unset
+func envFloat(t *testing.T, key string, def float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		t.Fatalf("invalid %s: %v", key, err)
+	}
+	return f
+}
@@ -0,0 +1,50 @@
+package benchmarks
+
+import (
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/storage/shard"
+	"testing"
+)
+
+// Seqlock experiment - SeqShardUnit (lock-free reads) vs ShardUnit (RWMutex)
+// on one hot shard. WriteHeavy is the reverse of the Mixed workload: 70%
+// writes, 30% reads.
+
+const (
+	hotShardSize      = 100000 // Tasks in the benchmarked unit
+	writeHeavyPercent = 70     // Share of operations that are updates
+)
+
+type shardUnit interface {
+	Set(id int, task *entities.Task)
+	Get(id int) (*entities.Task, bool)
+	Update(id int, task *entities.Task) bool
+}
+
+func benchmarkWriteHeavy(b *testing.B, unit shardUnit) {
+	for id := 1; id <= hotShardSize; id++ {
+		unit.Set(id, &entities.Task{ID: id, Name: "WriteHeavy Task"})
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			id := GetZipfTargetID(i)%hotShardSize + 1
+			if i%100 < writeHeavyPercent {
+				unit.Update(id, &entities.Task{ID: id, Name: "WriteHeavy Update", Status: i % 2})
+			} else {
+				unit.Get(id)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkWriteHeavy_ShardUnit(b *testing.B) {
+	benchmarkWriteHeavy(b, shard.NewShardUnit(hotShardSize))
+}
+
+func BenchmarkWriteHeavy_SeqShardUnit(b *testing.B) {
+	benchmarkWriteHeavy(b, shard.NewSeqShardUnit(hotShardSize))
+}
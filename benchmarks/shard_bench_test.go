@@ -2,7 +2,7 @@ package benchmarks
 
 import (
 	"fmt"
-	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/bench"
 	"tasks-service-demo/internal/storage/shard"
 	"testing"
 )
@@ -21,62 +21,17 @@ func BenchmarkWriteZipf_ShardStore(b *testing.B) {
 
 func BenchmarkDistributedRead_ShardStore(b *testing.B) {
 	store := shard.NewShardStore(32)
-	PopulateStore(b, store, "ShardStore Distributed Read")
-
-	b.ResetTimer()
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			// Uniform distribution across all keys
-			targetID := (i % DatasetSize) + 1
-			store.GetByID(targetID)
-			i++
-		}
-	})
+	BenchmarkScenario(b, store, "ShardStore Distributed Read", bench.DistributedRead)
 }
 
 func BenchmarkDistributedWrite_ShardStore(b *testing.B) {
 	store := shard.NewShardStore(32)
-	PopulateStore(b, store, "ShardStore Distributed Write")
-
-	b.ResetTimer()
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			targetID := (i % DatasetSize) + 1
-			updatedTask := &entities.Task{
-				Name:   "Distributed Update Task",
-				Status: i % 2,
-			}
-			store.Update(targetID, updatedTask)
-			i++
-		}
-	})
+	BenchmarkScenario(b, store, "ShardStore Distributed Write", bench.DistributedWrite)
 }
 
 func BenchmarkDistributedMixed_ShardStore(b *testing.B) {
 	store := shard.NewShardStore(32)
-	PopulateStore(b, store, "ShardStore Distributed Mixed")
-
-	b.ResetTimer()
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			targetID := (i % DatasetSize) + 1
-
-			// 70% reads, 30% writes
-			if i%10 < 7 {
-				store.GetByID(targetID)
-			} else {
-				updatedTask := &entities.Task{
-					Name:   "Mixed Update Task",
-					Status: i % 2,
-				}
-				store.Update(targetID, updatedTask)
-			}
-			i++
-		}
-	})
+	BenchmarkScenario(b, store, "ShardStore Distributed Mixed", bench.DistributedMixed)
 }
 
 // ShardStore specific benchmarks
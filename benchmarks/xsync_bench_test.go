@@ -1,6 +1,7 @@
 package benchmarks
 
 import (
+	"tasks-service-demo/internal/bench"
 	"tasks-service-demo/internal/entities"
 	"tasks-service-demo/internal/storage/xsync"
 	"testing"
@@ -20,62 +21,17 @@ func BenchmarkWriteZipf_XSyncStore(b *testing.B) {
 
 func BenchmarkDistributedRead_XSyncStore(b *testing.B) {
 	store := xsync.NewXSyncStore()
-	PopulateStore(b, store, "XSyncStore Distributed Read")
-
-	b.ResetTimer()
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			// Uniform distribution across all keys
-			targetID := (i % DatasetSize) + 1
-			store.GetByID(targetID)
-			i++
-		}
-	})
+	BenchmarkScenario(b, store, "XSyncStore Distributed Read", bench.DistributedRead)
 }
 
 func BenchmarkDistributedWrite_XSyncStore(b *testing.B) {
 	store := xsync.NewXSyncStore()
-	PopulateStore(b, store, "XSyncStore Distributed Write")
-
-	b.ResetTimer()
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			targetID := (i % DatasetSize) + 1
-			updatedTask := &entities.Task{
-				Name:   "Distributed Update Task",
-				Status: i % 2,
-			}
-			store.Update(targetID, updatedTask)
-			i++
-		}
-	})
+	BenchmarkScenario(b, store, "XSyncStore Distributed Write", bench.DistributedWrite)
 }
 
 func BenchmarkDistributedMixed_XSyncStore(b *testing.B) {
 	store := xsync.NewXSyncStore()
-	PopulateStore(b, store, "XSyncStore Distributed Mixed")
-
-	b.ResetTimer()
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			targetID := (i % DatasetSize) + 1
-
-			// 70% reads, 30% writes
-			if i%10 < 7 {
-				store.GetByID(targetID)
-			} else {
-				updatedTask := &entities.Task{
-					Name:   "Mixed Update Task",
-					Status: i % 2,
-				}
-				store.Update(targetID, updatedTask)
-			}
-			i++
-		}
-	})
+	BenchmarkScenario(b, store, "XSyncStore Distributed Mixed", bench.DistributedMixed)
 }
 
 // Additional benchmarks specific to xsync performance characteristics
@@ -160,16 +116,7 @@ func BenchmarkGetAll_XSyncStore(b *testing.B) {
 
 func BenchmarkHighContentionRead_XSyncStore(b *testing.B) {
 	store := xsync.NewXSyncStore()
-	PopulateStore(b, store, "XSyncStore High Contention Read")
-
-	b.ResetTimer()
-	b.RunParallel(func(pb *testing.PB) {
-		for pb.Next() {
-			// All goroutines competing for the same few keys (high contention)
-			targetID := (b.N % 10) + 1
-			store.GetByID(targetID)
-		}
-	})
+	BenchmarkScenario(b, store, "XSyncStore High Contention Read", bench.HotKeyRead)
 }
 
 func BenchmarkHighContentionWrite_XSyncStore(b *testing.B) {
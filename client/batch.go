@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// batch collects the IDs of the GetTask calls made within one window
+type batch struct {
+	ids   []int
+	seen  map[int]bool
+	timer *time.Timer // Sends the batch when the window closes
+	once  sync.Once
+	done  chan struct{} // Closed once tasks and err are set
+
+	tasks map[int]*Task
+	err   error
+}
+
+// join adds id to the batch being collected, starting one if there is none
+func (c *Client) join(id int) *batch {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b := c.pending
+	if b == nil {
+		b = &batch{seen: make(map[int]bool), done: make(chan struct{})}
+		c.pending = b
+		b.timer = time.AfterFunc(c.window, func() { c.send(b) })
+	}
+	if !b.seen[id] {
+		b.seen[id] = true
+		b.ids = append(b.ids, id)
+	}
+	if len(b.ids) == MaxBatchSize {
+		c.pending = nil // Full; later calls start the next batch
+		go c.send(b)
+	}
+	return b
+}
+
+// send looks up the IDs of b, once, and wakes its callers
+func (c *Client) send(b *batch) {
+	b.once.Do(func() {
+		c.mu.Lock()
+		if c.pending == b {
+			c.pending = nil
+		}
+		b.timer.Stop()
+		c.mu.Unlock()
+
+		// The request serves every caller in the batch, so it is not bound
+		// to any one caller's context
+		found, _, err := c.getMany(context.Background(), b.ids)
+		b.tasks = make(map[int]*Task, len(found))
+		for _, task := range found {
+			b.tasks[task.ID] = task
+		}
+		b.err = err
+		close(b.done)
+	})
+}
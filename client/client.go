@@ -0,0 +1,213 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Package client is a Go client for the Task API. GetTask calls made within
+// a short window are coalesced into one POST /tasks/byIds, so a dashboard
+// rendering a page of tasks makes one request instead of one per task. The
+// package only depends on the standard library, so it can be vendored into
+// consumers as is.
+
+const (
+	DefaultBatchWindow = 2 * time.Millisecond // How long GetTask waits for other calls to share its request
+	DefaultTimeout     = 10 * time.Second     // Request timeout of the default HTTP client
+	MaxBatchSize       = 500                  // IDs the server accepts per POST /tasks/byIds
+)
+
+// Task is a task as the API returns it
+type Task struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Status int    `json:"status"` // 0=incomplete, 1=complete
+}
+
+// ErrTaskNotFound is returned for task IDs that don't exist. Errors
+// returned by the API for missing tasks match it with errors.Is.
+var ErrTaskNotFound = errors.New("task not found")
+
+// codeTaskNotFound is the API error code of a missing task
+const codeTaskNotFound = 1001
+
+// Error is an error response of the API; see GET /errors for the codes
+type Error struct {
+	StatusCode int    `json:"-"`
+	Code       int    `json:"code"`
+	Message    string `json:"message"`
+	Retryable  bool   `json:"retryable"` // The same request may succeed if sent again
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("task API error %d (HTTP %d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// Is reports whether e is the API's task-not-found error
+func (e *Error) Is(target error) bool {
+	return target == ErrTaskNotFound && e.Code == codeTaskNotFound
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sends requests with hc instead of a client with DefaultTimeout
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.http = hc }
+}
+
+// WithAPIKey authenticates requests with an API key
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithBatchWindow sets how long GetTask waits for other calls to share its
+// request. A window <= 0 sends every GetTask on its own.
+func WithBatchWindow(d time.Duration) Option {
+	return func(c *Client) { c.window = d }
+}
+
+// Client calls the Task API. It is safe for concurrent use.
+type Client struct {
+	baseURL string
+	http    *http.Client
+	apiKey  string
+	window  time.Duration
+
+	mu      sync.Mutex
+	pending *batch // Collecting GetTask calls; nil when none
+}
+
+// New creates a Client for the API at baseURL, e.g. http://localhost:8080
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    &http.Client{Timeout: DefaultTimeout},
+		window:  DefaultBatchWindow,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// GetTask returns the task with the given ID, or ErrTaskNotFound. Calls
+// made within the batch window share one request; ctx only bounds how long
+// this call waits for it.
+func (c *Client) GetTask(ctx context.Context, id int) (*Task, error) {
+	if c.window <= 0 {
+		var task Task
+		if err := c.do(ctx, http.MethodGet, "/tasks/"+strconv.Itoa(id), nil, &task); err != nil {
+			return nil, err
+		}
+		return &task, nil
+	}
+
+	b := c.join(id)
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if b.err != nil {
+		return nil, b.err
+	}
+	task, ok := b.tasks[id]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	copied := *task // Callers asking for the same ID must not share it
+	return &copied, nil
+}
+
+// GetTasks returns the tasks with the given IDs and the IDs that don't
+// exist, both in the order of ids with repeats dropped. IDs are sent
+// MaxBatchSize at a time.
+func (c *Client) GetTasks(ctx context.Context, ids []int) ([]*Task, []int, error) {
+	ids = unique(ids)
+	tasks := make([]*Task, 0, len(ids))
+	missing := make([]int, 0)
+	for len(ids) > 0 {
+		n := min(len(ids), MaxBatchSize)
+		found, notFound, err := c.getMany(ctx, ids[:n])
+		if err != nil {
+			return nil, nil, err
+		}
+		tasks = append(tasks, found...)
+		missing = append(missing, notFound...)
+		ids = ids[n:]
+	}
+	return tasks, missing, nil
+}
+
+// getMany looks up at most MaxBatchSize unique IDs in one request
+func (c *Client) getMany(ctx context.Context, ids []int) ([]*Task, []int, error) {
+	var resp struct {
+		Tasks   []*Task `json:"tasks"`
+		Missing []int   `json:"missing"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/tasks/byIds", map[string][]int{"ids": ids}, &resp); err != nil {
+		return nil, nil, err
+	}
+	return resp.Tasks, resp.Missing, nil
+}
+
+// do sends a JSON request and decodes the response into out, or an error
+// response into *Error
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		if err := json.NewDecoder(resp.Body).Decode(apiErr); err != nil {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return apiErr
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// unique drops repeated IDs, keeping the first of each
+func unique(ids []int) []int {
+	seen := make(map[int]struct{}, len(ids))
+	result := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			result = append(result, id)
+		}
+	}
+	return result
+}
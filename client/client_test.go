@@ -0,0 +1,178 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeAPI serves tasks 1..n the way the Task API does and counts requests
+type fakeAPI struct {
+	n                int
+	batches, singles atomic.Int64
+	fail             atomic.Bool
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if f.fail.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"code":5008,"message":"Server is overloaded; retry later","retryable":true}`))
+		return
+	}
+
+	if r.URL.Path == "/tasks/byIds" {
+		f.batches.Add(1)
+		var req struct{ IDs []int }
+		json.NewDecoder(r.Body).Decode(&req)
+		if len(req.IDs) > MaxBatchSize {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		resp := struct {
+			Tasks   []Task `json:"tasks"`
+			Missing []int  `json:"missing"`
+		}{Tasks: []Task{}, Missing: []int{}}
+		for _, id := range req.IDs {
+			if id <= f.n {
+				resp.Tasks = append(resp.Tasks, Task{ID: id, Name: "task " + strconv.Itoa(id)})
+			} else {
+				resp.Missing = append(resp.Missing, id)
+			}
+		}
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	f.singles.Add(1)
+	id, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/tasks/"))
+	if id < 1 || id > f.n {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code":1001,"message":"Task not found"}`))
+		return
+	}
+	json.NewEncoder(w).Encode(Task{ID: id, Name: "task " + strconv.Itoa(id)})
+}
+
+func newFakeAPI(t *testing.T, n int) (*fakeAPI, string) {
+	t.Helper()
+	api := &fakeAPI{n: n}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	return api, srv.URL
+}
+
+// getTasks calls GetTask for each ID concurrently and returns the results
+// in the order of ids
+func getTasks(c *Client, ids []int) ([]*Task, []error) {
+	tasks := make([]*Task, len(ids))
+	errs := make([]error, len(ids))
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i, id int) {
+			defer wg.Done()
+			tasks[i], errs[i] = c.GetTask(context.Background(), id)
+		}(i, id)
+	}
+	wg.Wait()
+	return tasks, errs
+}
+
+func TestClient_GetTaskCoalesces(t *testing.T) {
+	api, url := newFakeAPI(t, 400)
+
+	// A batch that fills up is sent without waiting out the window
+	c := New(url, WithBatchWindow(time.Hour))
+	ids := make([]int, MaxBatchSize)
+	for i := range ids {
+		ids[i] = i + 1
+	}
+	tasks, errs := getTasks(c, ids)
+	if api.batches.Load() != 1 || api.singles.Load() != 0 {
+		t.Fatalf("Expected 1 batch request, got %d batches and %d single requests", api.batches.Load(), api.singles.Load())
+	}
+	for i, id := range ids {
+		switch {
+		case id <= 400 && (errs[i] != nil || tasks[i].ID != id):
+			t.Errorf("Expected task %d, got %+v, %v", id, tasks[i], errs[i])
+		case id > 400 && !errors.Is(errs[i], ErrTaskNotFound):
+			t.Errorf("Expected task %d not found, got %v", id, errs[i])
+		}
+	}
+
+	// Otherwise it is sent when the window closes; repeated IDs are asked once
+	c = New(url, WithBatchWindow(time.Millisecond))
+	tasks, errs = getTasks(c, []int{7, 7})
+	if errs[0] != nil || errs[1] != nil || tasks[0] == tasks[1] || tasks[0].ID != 7 {
+		t.Errorf("Expected two copies of task 7, got %+v, %v", tasks, errs)
+	}
+	if api.batches.Load() < 2 {
+		t.Errorf("Expected a second batch request, got %d", api.batches.Load())
+	}
+}
+
+func TestClient_GetTaskUnbatched(t *testing.T) {
+	api, url := newFakeAPI(t, 1)
+	c := New(url, WithBatchWindow(0))
+
+	if task, err := c.GetTask(context.Background(), 1); err != nil || task.ID != 1 {
+		t.Errorf("Expected task 1, got %+v, %v", task, err)
+	}
+	var apiErr *Error
+	if _, err := c.GetTask(context.Background(), 2); !errors.Is(err, ErrTaskNotFound) || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected the API's not-found error, got %v", err)
+	}
+	if api.batches.Load() != 0 || api.singles.Load() != 2 {
+		t.Errorf("Expected 2 single requests, got %d batches and %d single requests", api.batches.Load(), api.singles.Load())
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	api, url := newFakeAPI(t, 10)
+	api.fail.Store(true)
+	c := New(url, WithBatchWindow(time.Millisecond))
+
+	_, errs := getTasks(c, []int{1, 2})
+	for _, err := range errs {
+		var apiErr *Error
+		if !errors.As(err, &apiErr) || apiErr.Code != 5008 || !apiErr.Retryable || apiErr.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("Expected every caller to get the overload error, got %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := New(url, WithBatchWindow(time.Hour)).GetTask(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the caller to stop waiting, got %v", err)
+	}
+}
+
+func TestClient_GetTasks(t *testing.T) {
+	api, url := newFakeAPI(t, 1000)
+	c := New(url)
+
+	ids := make([]int, 0, 1201)
+	for id := 1200; id >= 1; id-- {
+		ids = append(ids, id)
+	}
+	ids = append(ids, 5)
+	tasks, missing, err := c.GetTasks(context.Background(), ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1000 || tasks[0].ID != 1000 || len(missing) != 200 || missing[0] != 1200 {
+		t.Errorf("Expected 1000 tasks and 200 missing IDs in request order, got %d and %d", len(tasks), len(missing))
+	}
+	if api.batches.Load() != 3 {
+		t.Errorf("Expected 3 requests of at most %d IDs, got %d", MaxBatchSize, api.batches.Load())
+	}
+}
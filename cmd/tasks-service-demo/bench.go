@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"tasks-service-demo/internal/bench"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/backends"
+)
+
+// runBench implements `tasks-service-demo bench`: it drives bench scenarios
+// against fresh stores of in-memory backends, without starting the server,
+// and prints one line or JSON object per scenario. With -baseline it checks
+// the results against the baselines in a directory, or with -update writes
+// them there. It returns the process exit code: 1 when a run fails or
+// regresses.
+func runBench(args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	flags.SetOutput(out)
+	scenarios := flags.String("scenario", "all", "comma-separated scenarios to run, or all")
+	storages := flags.String("storage", backends.Default, "comma-separated in-memory storage backends to benchmark, or all")
+	shards := flags.Int("shards", 32, "shard count of sharded backends")
+	dataset := flags.Int("dataset", 100000, "tasks in the store")
+	workers := flags.Int("workers", 0, "concurrent workers; GOMAXPROCS when 0")
+	duration := flags.Duration("duration", bench.DefaultDuration, "how long each scenario runs")
+	ops := flags.Int64("ops", 0, "stop each scenario after this many operations instead")
+	asJSON := flags.Bool("json", false, "print one JSON result per line")
+	benchfmt := flags.Bool("benchfmt", false, "print results in the Go benchmark format, for benchstat")
+	baselineDir := flags.String("baseline", "", "directory of baselines to check the results against")
+	update := flags.Bool("update", false, "write the results as the baselines instead of checking them")
+	p95Threshold := flags.Float64("p95-threshold", bench.DefaultThresholds.P95, "allowed p95 increase over the baseline, as a fraction; 0 disables")
+	allocsThreshold := flags.Float64("allocs-threshold", bench.DefaultThresholds.Allocs, "allowed allocs/op increase over the baseline, as a fraction; 0 disables")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	var selected []bench.Scenario
+	if *scenarios == "all" {
+		selected = bench.Scenarios()
+	} else {
+		for _, name := range strings.Split(*scenarios, ",") {
+			s, ok := bench.Lookup(strings.TrimSpace(name))
+			if !ok {
+				fmt.Fprintf(out, "unknown scenario %q\n", name)
+				return 2
+			}
+			selected = append(selected, s)
+		}
+	}
+	names, ok := benchStorages(*storages, out)
+	if !ok {
+		return 2
+	}
+	if *dataset < 1 {
+		fmt.Fprintln(out, "dataset must be at least 1")
+		return 2
+	}
+	if *update && *baselineDir == "" {
+		fmt.Fprintln(out, "-update needs -baseline")
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	explicit := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	thresholds := bench.Thresholds{P95: *p95Threshold, Allocs: *allocsThreshold}
+	regressed := false
+	for _, name := range names {
+		b := bench.Baseline{
+			Storage: name,
+			Shards:  *shards,
+			Config:  bench.Config{Dataset: *dataset, Workers: *workers, Duration: *duration, Ops: *ops},
+			Results: make(map[string]bench.Result),
+		}
+		var baseline *bench.Baseline
+		if *baselineDir != "" && !*update {
+			loaded, err := bench.LoadBaseline(*baselineDir, name)
+			if err != nil {
+				fmt.Fprintf(out, "%s: no baseline: %v\n", name, err)
+				return 1
+			}
+			// Measure with the settings of the baseline unless told otherwise,
+			// so the numbers are comparable
+			if !explicit["shards"] {
+				b.Shards = loaded.Shards
+			}
+			if !explicit["dataset"] {
+				b.Config.Dataset = loaded.Config.Dataset
+			}
+			if !explicit["workers"] {
+				b.Config.Workers = loaded.Config.Workers
+			}
+			if !explicit["duration"] && !explicit["ops"] {
+				b.Config.Duration, b.Config.Ops = loaded.Config.Duration, loaded.Config.Ops
+			}
+			baseline = &loaded
+		}
+
+		backend, _ := storage.Lookup(name)
+		results := make([]bench.Result, 0, len(selected))
+		for _, scenario := range selected {
+			result, err := bench.RunFresh(ctx, backend, storage.Options{ShardCount: b.Shards}, scenario, b.Config)
+			if err != nil {
+				fmt.Fprintf(out, "%s %s: %v\n", name, scenario.Name(), err)
+				return 1
+			}
+			if ctx.Err() != nil {
+				return 1
+			}
+			results = append(results, result)
+			b.Results[scenario.Name()] = result
+
+			switch {
+			case *asJSON:
+				line, _ := json.Marshal(result)
+				fmt.Fprintln(out, string(line))
+			case *benchfmt:
+				bench.WriteBenchfmt(out, name, []bench.Result{result})
+			default:
+				fmt.Fprintf(out, "%-8s %s\n", name, result.String())
+			}
+		}
+
+		if *update {
+			if err := b.Save(*baselineDir); err != nil {
+				fmt.Fprintf(out, "%s: %v\n", name, err)
+				return 1
+			}
+			fmt.Fprintf(out, "%s: baseline written to %s\n", name, bench.BaselinePath(*baselineDir, name))
+		} else if baseline != nil {
+			for _, r := range baseline.Check(results, thresholds) {
+				fmt.Fprintf(out, "REGRESSION %s\n", r)
+				regressed = true
+			}
+		}
+	}
+	if regressed {
+		return 1
+	}
+	return 0
+}
+
+// benchStorages resolves the -storage flag to registered in-memory
+// backends, reporting the first it rejects
+func benchStorages(list string, out io.Writer) ([]string, bool) {
+	if list == "all" {
+		var names []string
+		for _, name := range storage.Backends() {
+			if backend, _ := storage.Lookup(name); !backend.Durable {
+				names = append(names, name)
+			}
+		}
+		return names, true
+	}
+
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		backend, ok := storage.Lookup(name)
+		if !ok {
+			fmt.Fprintf(out, "unknown storage %q; supported: %s\n", name, strings.Join(storage.Backends(), ", "))
+			return nil, false
+		}
+		if backend.Durable {
+			fmt.Fprintf(out, "storage %q is durable; bench runs in-memory backends only\n", name)
+			return nil, false
+		}
+		names = append(names, name)
+	}
+	return names, true
+}
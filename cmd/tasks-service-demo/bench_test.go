@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"tasks-service-demo/internal/bench"
+)
+
+func TestRunBench(t *testing.T) {
+	var out bytes.Buffer
+	code := runBench([]string{"-storage", "memory", "-scenario", "zipf-read, hotkey-write", "-dataset", "100", "-ops", "500", "-json"}, &out)
+	if code != 0 {
+		t.Fatalf("Expected success, got %d: %s", code, out.String())
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected one result per scenario, got %q", out.String())
+	}
+	var result bench.Result
+	if err := json.Unmarshal([]byte(lines[1]), &result); err != nil || result.Scenario != "hotkey-write" || result.Ops != 500 {
+		t.Errorf("Expected 500 hotkey-write ops, got %+v (%v)", result, err)
+	}
+
+	for _, args := range [][]string{
+		{"-scenario", "nope"},
+		{"-storage", "nope"},
+		{"-storage", "badger"},
+		{"-dataset", "0"},
+		{"-update"},
+	} {
+		out.Reset()
+		if code := runBench(args, &out); code != 2 {
+			t.Errorf("Expected %v to be rejected, got %d: %s", args, code, out.String())
+		}
+	}
+}
+
+func TestRunBenchBaseline(t *testing.T) {
+	dir := t.TempDir()
+	var out bytes.Buffer
+	args := []string{"-storage", "memory,xsync", "-scenario", "hotkey-read", "-dataset", "100", "-ops", "1000", "-baseline", dir}
+	if code := runBench(append(args, "-update"), &out); code != 0 {
+		t.Fatalf("Expected the baselines to be written, got %d: %s", code, out.String())
+	}
+	baseline, err := bench.LoadBaseline(dir, "xsync")
+	if err != nil || baseline.Config.Dataset != 100 || baseline.Results["hotkey-read"].Ops != 1000 {
+		t.Fatalf("Expected the xsync baseline, got %+v (%v)", baseline, err)
+	}
+
+	// Thresholds of zero check nothing, so only the run itself can fail
+	out.Reset()
+	if code := runBench(append(args, "-p95-threshold", "0", "-allocs-threshold", "0"), &out); code != 0 {
+		t.Errorf("Expected the check to pass, got %d: %s", code, out.String())
+	}
+
+	// A baseline of impossibly fast operations makes every run regress
+	baseline.Results["hotkey-read"] = bench.Result{Scenario: "hotkey-read", P95: 1}
+	if err := baseline.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if code := runBench(append(args, "-benchfmt"), &out); code != 1 || !strings.Contains(out.String(), "REGRESSION xsync hotkey-read: p95-ns") {
+		t.Errorf("Expected a p95 regression of xsync, got %d: %s", code, out.String())
+	}
+	if !strings.Contains(out.String(), "BenchmarkScenario/storage=memory/scenario=hotkey-read\t1000\t") {
+		t.Errorf("Expected results in the Go benchmark format, got %s", out.String())
+	}
+}
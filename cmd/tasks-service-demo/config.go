@@ -0,0 +1,510 @@
+package main
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"tasks-service-demo/internal/adaptive"
+	"tasks-service-demo/internal/admission"
+	"tasks-service-demo/internal/archive"
+	"tasks-service-demo/internal/auth"
+	"tasks-service-demo/internal/compress"
+	"tasks-service-demo/internal/config"
+	"tasks-service-demo/internal/crypt"
+	"tasks-service-demo/internal/demo"
+	"tasks-service-demo/internal/dlq"
+	"tasks-service-demo/internal/events"
+	_ "tasks-service-demo/internal/events/drivers"
+	"tasks-service-demo/internal/export"
+	"tasks-service-demo/internal/ipfilter"
+	applog "tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/middleware"
+	"tasks-service-demo/internal/notify"
+	"tasks-service-demo/internal/payloadlog"
+	"tasks-service-demo/internal/plugins"
+	"tasks-service-demo/internal/reconcile"
+	"tasks-service-demo/internal/redact"
+	"tasks-service-demo/internal/retention"
+	"tasks-service-demo/internal/share"
+	"tasks-service-demo/internal/shutdown"
+	"tasks-service-demo/internal/slowlog"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/backends"
+	"tasks-service-demo/internal/storage/badger"
+	"tasks-service-demo/internal/storage/bloom"
+	"tasks-service-demo/internal/storage/lru"
+	"tasks-service-demo/internal/storage/memguard"
+	"tasks-service-demo/internal/storage/mirror"
+	"tasks-service-demo/internal/storage/mmap"
+	"tasks-service-demo/internal/storage/shard"
+	"tasks-service-demo/internal/storage/tiered"
+	"tasks-service-demo/internal/storage/writebehind"
+	"tasks-service-demo/internal/streaming"
+	"tasks-service-demo/internal/usage"
+	"tasks-service-demo/internal/workload"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultConfigFile is loaded at startup and watched for changes
+const defaultConfigFile = ".env"
+
+// appConfig is the fully resolved service configuration
+type appConfig struct {
+	ConfigFile  string
+	ConfigWatch bool
+
+	AppEnv       string
+	DebugErrors  bool
+	LogLevel     zapcore.Level
+	ModuleLevels map[string]zapcore.Level
+	PayloadLog   payloadlog.Config
+	Redact       redact.Rules // Task fields masked in logs and exports
+
+	CORS               middleware.CORSConfig
+	HSTSMaxAge         int
+	CSRFEnabled        bool
+	CSRFCookieSecure   bool
+	MaxInFlight        int // 0 disables load shedding
+	OverloadRetryAfter time.Duration
+	Admission          admission.Config // Zero Capacity disables priority shedding
+	Adaptive           adaptive.Config
+	RouteTimeouts      middleware.RouteTimeouts
+	ReadOnly           bool
+	ListCacheTTL       time.Duration
+	ResponseShape      middleware.ResponseShape // Defaults; Accept profiles override them per request
+
+	StorageType string
+	ShardCount  int
+	LockSample  int                 // Sharded backends only; 0 disables lock timing
+	Expected    int                 // In-memory backends only; 0 keeps default map sizes
+	DataDir     string              // Durable backends only
+	SyncWrites  bool                // Durable backends only
+	GroupCommit storage.GroupCommit // Zero MaxDelay syncs each write on its own
+	TaskTTL     time.Duration       // Expiring backends only; 0 keeps tasks forever
+	GCInterval  time.Duration
+	StorageKeys []crypt.Key     // Empty stores tasks in plaintext
+	Compression compress.Config // Codec None stores tasks uncompressed
+	Verify      bool            // Durable backends only
+	VerifyForce bool            // Serve writes even when the integrity check fails
+	SyncPolicy  reconcile.Policy
+	WriteBehind writebehind.Config // ModeOff disables buffering
+	Tier        tiered.Config      // Zero HotSize disables tiering
+	Bloom       bloom.Config       // Zero FalsePositiveRate disables the filter
+	Cache       lru.Config         // Empty Policy disables the cache
+	Guard       memguard.Config
+	Demo        demo.Config // Also lowers Guard.MaxTasks to Demo.MaxTasks
+
+	AltStorageType string        // Empty disables the storage A/B experiment
+	AltDataDir     string        // Durable alternate backends only
+	AltRouting     mirror.Config // Share of reads the alternate serves, shadow reads
+
+	Auth      auth.Config
+	ShareKeys []share.Key
+	AdminIPs  ipfilter.Rules // Empty lists leave admin routes open to any address
+	Usage     usage.Config   // Zero Window disables accounting
+
+	Projections bool // Read models maintained from the change feed
+
+	Streaming streaming.Config // Slow clients of NDJSON streams
+
+	S3             export.S3Config // Empty Bucket disables exports
+	Export         export.Config
+	ExportInterval time.Duration
+
+	SnapshotDir    string // Empty disables the snapshot job kind
+	SnapshotRetain int
+
+	Retention retention.Config // Zero Window keeps deletes hard
+
+	SlowLog slowlog.Config // Zero Threshold disables the slow log
+
+	Workload workload.Config // Empty File disables workload recording
+
+	Archive archive.Config // Zero After disables archival
+
+	SMTP              notify.SMTPConfig // Empty Addr disables notifications
+	NotifyTemplateDir string
+	NotifyRetry       notify.RetryPolicy
+
+	DLQ dlq.Config
+
+	Events events.Config
+
+	PluginDir        string
+	PluginMiddleware []string
+
+	WarmupPreloadFile string
+	WarmupPrimeKeys   int
+	WarmupTimeout     time.Duration
+
+	ShutdownTimeout time.Duration
+
+	Port string
+}
+
+// loadConfig resolves every setting from env. Invalid values are recorded in
+// env rather than defaulted silently, so one run reports all of them.
+func loadConfig(env *config.Env) appConfig {
+	var cfg appConfig
+
+	cfg.ConfigFile = env.String("CONFIG_FILE", defaultConfigFile)
+	cfg.ConfigWatch = env.Bool("CONFIG_WATCH", true)
+	cfg.AppEnv = env.String("APP_ENV", "development")
+	cfg.DebugErrors = env.Bool("DEBUG_ERRORS", false)
+	// Debug error bodies expose internals, so production refuses to start with them
+	if cfg.DebugErrors && strings.EqualFold(cfg.AppEnv, "production") {
+		env.Invalid("DEBUG_ERRORS", fmt.Errorf("must not be enabled when APP_ENV=production"))
+	}
+	cfg.LogLevel = config.Parse(env, "LOG_LEVEL", zapcore.InfoLevel, applog.ParseLevel)
+	cfg.ModuleLevels = config.Parse(env, "LOG_MODULE_LEVELS", map[string]zapcore.Level(nil), parseModuleLevels)
+
+	cfg.PayloadLog = payloadlog.DefaultConfig()
+	cfg.PayloadLog.Enabled = env.Bool("PAYLOAD_LOG_ENABLED", false)
+	cfg.PayloadLog.SampleRate = env.Float("PAYLOAD_LOG_SAMPLE_RATE", payloadlog.DefaultSampleRate, 0, 1)
+	cfg.PayloadLog.MaxBytes = env.Int("PAYLOAD_LOG_MAX_BYTES", payloadlog.DefaultMaxBytes, 0)
+	for _, field := range strings.Split(env.String("PAYLOAD_LOG_REDACT", ""), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			cfg.PayloadLog.Redact = append(cfg.PayloadLog.Redact, field)
+		}
+	}
+
+	cfg.Redact.Fields = config.Parse(env, "REDACT_TASK_FIELDS", []string(nil), redact.ParseFields)
+
+	// CORS (defaults to any origin, as before)
+	cfg.CORS = middleware.CORSConfig{
+		AllowOrigins:     env.String("CORS_ALLOW_ORIGINS", middleware.DefaultCORSConfig.AllowOrigins),
+		AllowMethods:     env.String("CORS_ALLOW_METHODS", middleware.DefaultCORSConfig.AllowMethods),
+		AllowHeaders:     env.String("CORS_ALLOW_HEADERS", ""),
+		AllowCredentials: env.Bool("CORS_ALLOW_CREDENTIALS", false),
+		MaxAge:           env.Int("CORS_MAX_AGE", 0, 0),
+	}
+	if _, err := middleware.CORS(cfg.CORS); err != nil {
+		env.Invalid("CORS_ALLOW_CREDENTIALS", err)
+	}
+	cfg.HSTSMaxAge = env.Int("HSTS_MAX_AGE", 31536000, 0)
+	cfg.CSRFEnabled = env.Bool("CSRF_ENABLED", false)
+	cfg.CSRFCookieSecure = env.Bool("CSRF_COOKIE_SECURE", false)
+	cfg.MaxInFlight = env.Int("MAX_INFLIGHT_REQUESTS", 0, 0)
+	cfg.OverloadRetryAfter = env.Duration("OVERLOAD_RETRY_AFTER", middleware.DefaultOverloadRetryAfter, false)
+	cfg.Admission = admission.Config{
+		Capacity:      env.Int("ADMISSION_CAPACITY", 0, 0),
+		LatencyTarget: env.Duration("ADMISSION_LATENCY_TARGET", admission.DefaultLatencyTarget, true),
+		RetryAfter:    cfg.OverloadRetryAfter,
+		KeyTiers:      config.Parse(env, "ADMISSION_KEY_TIERS", map[string]admission.Priority(nil), admission.ParseKeyTiers),
+	}
+	cfg.Adaptive = adaptive.Config{
+		Enabled:      env.Bool("ADAPTIVE_LIMIT_ENABLED", false),
+		InitialLimit: env.Int("ADAPTIVE_LIMIT_INITIAL", adaptive.DefaultInitialLimit, 1),
+		MinLimit:     env.Int("ADAPTIVE_LIMIT_MIN", adaptive.DefaultMinLimit, 1),
+		MaxLimit:     env.Int("ADAPTIVE_LIMIT_MAX", adaptive.DefaultMaxLimit, 1),
+		RetryAfter:   cfg.OverloadRetryAfter,
+	}
+	if cfg.Adaptive.MinLimit > cfg.Adaptive.MaxLimit {
+		env.Invalid("ADAPTIVE_LIMIT_MIN", fmt.Errorf("is above ADAPTIVE_LIMIT_MAX"))
+	}
+	cfg.RouteTimeouts = config.Parse(env, "REQUEST_TIMEOUTS", middleware.DefaultRouteTimeouts, middleware.ParseRouteTimeouts)
+	cfg.ReadOnly = env.Bool("READ_ONLY", false)
+	cfg.ListCacheTTL = env.Duration("LIST_CACHE_TTL", 100*time.Millisecond, true)
+	cfg.ResponseShape = middleware.ResponseShape{
+		Envelope: env.Bool("RESPONSE_ENVELOPE", false),
+		Case:     config.Parse(env, "RESPONSE_FIELD_CASE", middleware.CamelCase, middleware.ParseFieldCase),
+	}
+
+	// Unknown storage types used to fall back to xsync; they are now rejected
+	cfg.StorageType = config.Parse(env, "STORAGE_TYPE", backends.Default, parseStorageType)
+	cfg.ShardCount = env.Int("SHARD_COUNT", 32, 1)
+	cfg.LockSample = env.Int("SHARD_LOCK_SAMPLING", shard.DefaultLockSampling, 0)
+	cfg.Expected = env.Int("STORAGE_EXPECTED_TASKS", 0, 0)
+	cfg.DataDir = env.String("DATA_DIR", "")
+	cfg.SyncWrites = env.Bool("STORAGE_SYNC_WRITES", false)
+	cfg.GroupCommit = storage.GroupCommit{
+		MaxDelay: env.Duration("STORAGE_GROUP_COMMIT_DELAY", 0, true),
+		MaxBatch: env.Int("STORAGE_GROUP_COMMIT_MAX_BATCH", mmap.DefaultGroupCommitBatch, 1),
+	}
+	if cfg.GroupCommit.Enabled() && !cfg.SyncWrites {
+		env.Invalid("STORAGE_GROUP_COMMIT_DELAY", fmt.Errorf("batches synced writes, which need STORAGE_SYNC_WRITES=true"))
+	}
+	cfg.TaskTTL = env.Duration("TASK_TTL", 0, true)
+	cfg.GCInterval = env.Duration("STORAGE_GC_INTERVAL", badger.DefaultGCInterval, false)
+	cfg.StorageKeys = config.ParseSecret(env, "STORAGE_ENCRYPTION_KEYS", []crypt.Key(nil), crypt.ParseKeys)
+	cfg.Compression = compress.Config{
+		Codec:     config.Parse(env, "STORAGE_COMPRESSION", compress.None, compress.ParseCodec),
+		Threshold: env.Int("STORAGE_COMPRESSION_THRESHOLD", compress.DefaultThreshold, 1),
+	}
+	cfg.Verify = env.Bool("STORAGE_VERIFY", true)
+	cfg.VerifyForce = env.Bool("STORAGE_VERIFY_FORCE", false)
+	if backend, ok := storage.Lookup(cfg.StorageType); ok && !backend.Expiring && cfg.TaskTTL > 0 {
+		env.Invalid("TASK_TTL", fmt.Errorf("is not supported by STORAGE_TYPE=%s, which keeps tasks forever", cfg.StorageType))
+	}
+	cfg.SyncPolicy = config.Parse(env, "SYNC_CONFLICT_POLICY", reconcile.PolicyLWW, reconcile.ParsePolicy)
+	cfg.WriteBehind = writebehind.Config{
+		Mode:          config.Parse(env, "WRITE_BEHIND_MODE", writebehind.ModeOff, writebehind.ParseMode),
+		FlushInterval: env.Duration("WRITE_BEHIND_FLUSH_INTERVAL", writebehind.DefaultFlushInterval, false),
+		BatchSize:     env.Int("WRITE_BEHIND_BATCH_SIZE", writebehind.DefaultBatchSize, 1),
+	}
+	if backend, ok := storage.Lookup(cfg.StorageType); ok && !backend.Durable && cfg.WriteBehind.Mode != writebehind.ModeOff {
+		env.Invalid("WRITE_BEHIND_MODE", fmt.Errorf("only buffers durable backends, %s is in memory", cfg.StorageType))
+	}
+	cfg.Tier = tiered.Config{
+		HotSize:       env.Int("TIER_HOT_SIZE", 0, 0),
+		MaxAge:        env.Duration("TIER_HOT_MAX_AGE", 0, true),
+		SweepInterval: env.Duration("TIER_SWEEP_INTERVAL", tiered.DefaultSweepInterval, false),
+	}
+	if backend, ok := storage.Lookup(cfg.StorageType); ok && !backend.Durable && cfg.Tier.HotSize > 0 {
+		env.Invalid("TIER_HOT_SIZE", fmt.Errorf("needs a durable STORAGE_TYPE for the cold tier, %s is in memory", cfg.StorageType))
+	}
+	// The ID filter only pays off when a miss costs I/O
+	cfg.Bloom = bloom.Config{
+		FalsePositiveRate: env.Float("BLOOM_FP_RATE", bloom.DefaultFalsePositiveRate, 0, 0.5),
+		ExpectedTasks:     env.Int("BLOOM_EXPECTED_TASKS", bloom.DefaultExpectedTasks, 1),
+	}
+	if backend, ok := storage.Lookup(cfg.StorageType); ok && !backend.Durable {
+		cfg.Bloom.FalsePositiveRate = 0
+	}
+	cfg.Cache = lru.Config{
+		Policy: config.Parse(env, "CACHE_POLICY", lru.Policy(""), lru.ParsePolicy),
+		Size:   env.Int("CACHE_SIZE", lru.DefaultSize, 1),
+		TTL:    env.Duration("CACHE_TTL", 0, true),
+
+		NegativeTTL:  env.Duration("CACHE_NEGATIVE_TTL", 0, true),
+		NegativeSize: env.Int("CACHE_NEGATIVE_SIZE", lru.DefaultNegativeSize, 1),
+	}
+	cfg.Guard = memguard.Config{
+		MaxBytes: config.Parse(env, "STORAGE_MAX_BYTES", int64(0), memguard.ParseSize),
+		MaxTasks: env.Int("STORAGE_MAX_TASKS", 0, 0),
+		Policy:   config.Parse(env, "STORAGE_FULL_POLICY", memguard.PolicyReject, memguard.ParsePolicy),
+	}
+
+	// Public playground: sample tasks reset on an interval, a capped store,
+	// a per-address rate limit and no destructive admin requests
+	cfg.Demo = demo.Config{
+		Enabled:       env.Bool("DEMO_MODE", false),
+		MaxTasks:      env.Int("DEMO_MAX_TASKS", demo.DefaultMaxTasks, len(demo.SampleTasks)),
+		ResetInterval: env.Duration("DEMO_RESET_INTERVAL", demo.DefaultResetInterval, false),
+		RateLimit:     env.Int("DEMO_RATE_LIMIT", demo.DefaultRateLimit, 0),
+	}
+	if cfg.Demo.Enabled && (cfg.Guard.MaxTasks == 0 || cfg.Guard.MaxTasks > cfg.Demo.MaxTasks) {
+		cfg.Guard.MaxTasks = cfg.Demo.MaxTasks
+	}
+
+	// A/B experiment: writes are mirrored to an alternate backend, which
+	// serves the reads of ALT_TRAFFIC_PERCENT of requests and, with
+	// ALT_SHADOW_READS, replays the others to compare results
+	cfg.AltStorageType = config.Parse(env, "ALT_STORAGE_TYPE", "", parseAltStorageType)
+	cfg.AltDataDir = env.String("ALT_DATA_DIR", "")
+	cfg.AltRouting = mirror.Config{
+		Percent:     env.Float("ALT_TRAFFIC_PERCENT", 0, 0, 100),
+		Header:      env.String("ALT_ROUTING_HEADER", mirror.DefaultHeader),
+		Shadow:      env.Bool("ALT_SHADOW_READS", false),
+		ShadowQueue: env.Int("ALT_SHADOW_QUEUE", mirror.DefaultShadowQueue, 1),
+	}
+	if backend, ok := storage.Lookup(cfg.AltStorageType); ok && backend.Durable && (cfg.AltDataDir == "" || cfg.AltDataDir == cfg.DataDir) {
+		env.Invalid("ALT_DATA_DIR", fmt.Errorf("must be set to a directory other than DATA_DIR for ALT_STORAGE_TYPE=%s", cfg.AltStorageType))
+	}
+
+	// Role-based access: API keys and/or HS256 JWTs (both unset keeps the API open)
+	cfg.Auth = auth.Config{
+		APIKeys:   config.ParseSecret(env, "API_KEYS", map[string]auth.Role(nil), auth.ParseAPIKeys),
+		JWTSecret: env.Secret("JWT_SECRET"),
+
+		SigningKeys:     config.ParseSecret(env, "REQUEST_SIGNING_KEYS", map[string]string(nil), auth.ParseSigningKeys),
+		SignatureMaxAge: env.Duration("REQUEST_SIGNATURE_MAX_AGE", auth.DefaultSignatureMaxAge, false),
+	}
+	for key := range cfg.Auth.SigningKeys {
+		if _, ok := cfg.Auth.APIKeys[key]; !ok {
+			env.Invalid("REQUEST_SIGNING_KEYS", fmt.Errorf("names an API key missing from API_KEYS"))
+			break
+		}
+	}
+	cfg.ShareKeys = config.ParseSecret(env, "SHARE_SIGNING_KEYS", []share.Key(nil), share.ParseKeys)
+
+	// Client addresses admin routes accept, checked before credentials
+	cfg.AdminIPs = ipfilter.Rules{
+		Allow: config.Parse(env, "ADMIN_IP_ALLOWLIST", []netip.Prefix(nil), ipfilter.ParsePrefixes),
+		Deny:  config.Parse(env, "ADMIN_IP_DENYLIST", []netip.Prefix(nil), ipfilter.ParsePrefixes),
+	}
+
+	// Requests per API key over USAGE_WINDOW, for GET /admin/usage and the
+	// optional USAGE_MONTHLY_QUOTA
+	cfg.Usage = usage.Config{
+		Window:        env.Duration("USAGE_WINDOW", usage.DefaultWindow, true),
+		MonthlyQuota:  int64(env.Int("USAGE_MONTHLY_QUOTA", 0, 0)),
+		FlushInterval: env.Duration("USAGE_FLUSH_INTERVAL", usage.DefaultFlushInterval, false),
+	}
+	if cfg.Usage.Window == 0 && cfg.Usage.MonthlyQuota > 0 {
+		env.Invalid("USAGE_MONTHLY_QUOTA", fmt.Errorf("needs accounting, which USAGE_WINDOW=0 disables"))
+	}
+	cfg.Projections = env.Bool("PROJECTIONS_ENABLED", false)
+	cfg.Streaming = streaming.Config{
+		WriteTimeout: env.Duration("STREAM_WRITE_TIMEOUT", streaming.DefaultWriteTimeout, true),
+		MaxLag:       env.Int("STREAM_MAX_LAG", 0, 0),
+		Policy:       config.Parse(env, "STREAM_SLOW_POLICY", streaming.Disconnect, streaming.ParsePolicy),
+	}
+
+	cfg.S3 = export.S3Config{
+		Bucket:    env.String("EXPORT_S3_BUCKET", ""),
+		Endpoint:  env.String("EXPORT_S3_ENDPOINT", ""),
+		Region:    env.String("EXPORT_S3_REGION", ""),
+		AccessKey: env.Secret("EXPORT_S3_ACCESS_KEY"),
+		SecretKey: env.Secret("EXPORT_S3_SECRET_KEY"),
+		PathStyle: env.Bool("EXPORT_S3_PATH_STYLE", false),
+	}
+	cfg.Export = export.Config{
+		Prefix: env.Lookup("EXPORT_PREFIX", export.DefaultPrefix), // An explicitly empty prefix writes to the bucket root
+		Retain: env.Int("EXPORT_RETAIN", export.DefaultRetain, 1),
+	}
+	cfg.ExportInterval = env.Duration("EXPORT_INTERVAL", 24*time.Hour, false)
+	cfg.SnapshotDir = env.String("SNAPSHOT_DIR", "")
+	cfg.SnapshotRetain = env.Int("SNAPSHOT_RETAIN", export.DefaultRetain, 1)
+
+	// Soft deletes and revision history, purged once older than RETENTION_WINDOW
+	cfg.Retention = retention.Config{
+		Window:       env.Duration("RETENTION_WINDOW", 0, true),
+		MaxRevisions: env.Int("RETENTION_MAX_REVISIONS", retention.DefaultMaxRevisions, 1),
+		Interval:     env.Duration("RETENTION_PURGE_INTERVAL", retention.DefaultInterval, false),
+		DryRun:       env.Bool("RETENTION_DRY_RUN", false),
+	}
+
+	// Store calls slower than SLOWLOG_THRESHOLD are logged and kept for /admin/slowlog
+	cfg.SlowLog = slowlog.Config{
+		Threshold: env.Duration("SLOWLOG_THRESHOLD", slowlog.DefaultThreshold, true),
+		Size:      env.Int("SLOWLOG_SIZE", slowlog.DefaultSize, 1),
+	}
+
+	// A sampled trace of task operations goes to WORKLOAD_RECORD_FILE, for
+	// `tasks-service-demo replay`
+	cfg.Workload = workload.Config{
+		File:       env.String("WORKLOAD_RECORD_FILE", ""),
+		SampleRate: env.Float("WORKLOAD_RECORD_SAMPLE_RATE", workload.DefaultSampleRate, 0, 1),
+		MaxRecords: env.Int("WORKLOAD_RECORD_MAX", workload.DefaultMaxRecords, 0),
+	}
+
+	// Tasks completed more than ARCHIVE_AFTER_DAYS ago move to the archive
+	cfg.Archive = archive.Config{
+		After:    time.Duration(env.Int("ARCHIVE_AFTER_DAYS", 0, 0)) * 24 * time.Hour,
+		Interval: env.Duration("ARCHIVE_INTERVAL", archive.DefaultInterval, false),
+		Path:     env.String("ARCHIVE_FILE", ""),
+	}
+
+	cfg.SMTP = notify.SMTPConfig{
+		Addr:     env.String("SMTP_ADDR", ""),
+		Username: env.String("SMTP_USERNAME", ""),
+		Password: env.Secret("SMTP_PASSWORD"),
+		From:     env.String("SMTP_FROM", ""),
+	}
+	if cfg.SMTP.Addr != "" {
+		if _, err := notify.NewSMTPSender(cfg.SMTP); err != nil {
+			env.Invalid("SMTP_ADDR", err)
+		}
+	}
+	cfg.NotifyTemplateDir = env.String("NOTIFY_TEMPLATE_DIR", "")
+	cfg.NotifyRetry = notify.DefaultRetryPolicy
+	cfg.NotifyRetry.MaxAttempts = env.Int("NOTIFY_MAX_ATTEMPTS", notify.DefaultRetryPolicy.MaxAttempts, 1)
+	cfg.NotifyRetry.Backoff = env.Duration("NOTIFY_RETRY_BACKOFF", notify.DefaultRetryPolicy.Backoff, false)
+
+	// Deliveries that exhaust their retries; kept in memory unless DLQ_FILE is set
+	cfg.DLQ = dlq.Config{
+		Path:       env.String("DLQ_FILE", ""),
+		MaxEntries: env.Int("DLQ_MAX_ENTRIES", dlq.DefaultMaxEntries, 1),
+	}
+
+	// Task events go to the in-process bus unless an external broker is configured
+	cfg.Events = events.Config{
+		Driver:          config.Parse(env, "EVENT_BUS_DRIVER", events.DefaultDriver, parseEventDriver),
+		URL:             env.Secret("EVENT_BUS_URL"),
+		Group:           env.String("EVENT_BUS_GROUP", events.DefaultGroup),
+		Buffer:          env.Int("EVENT_BUS_BUFFER", events.DefaultBuffer, 1),
+		RedeliveryDelay: env.Duration("EVENT_BUS_REDELIVERY_DELAY", events.DefaultRedeliveryDelay, false),
+	}
+	if driver, ok := events.Lookup(cfg.Events.Driver); ok && driver.AtLeastOnce && cfg.Events.URL == "" {
+		env.Invalid("EVENT_BUS_URL", fmt.Errorf("is required by EVENT_BUS_DRIVER=%s", cfg.Events.Driver))
+	}
+
+	// PLUGIN_DIR is loaded by main before the configuration; it is read here for the dump
+	cfg.PluginDir = env.String("PLUGIN_DIR", "")
+	cfg.PluginMiddleware = config.Parse(env, "PLUGIN_MIDDLEWARE", []string(nil), parsePluginMiddleware)
+
+	cfg.WarmupPreloadFile = env.String("WARMUP_PRELOAD_FILE", "")
+	cfg.WarmupPrimeKeys = env.Int("WARMUP_PRIME_KEYS", 0, 0)
+	cfg.WarmupTimeout = env.Duration("WARMUP_TIMEOUT", 30*time.Second, false)
+
+	cfg.ShutdownTimeout = env.Duration("SHUTDOWN_TIMEOUT", shutdown.DefaultTimeout, false)
+
+	cfg.Port = config.Parse(env, "PORT", "8080", parsePort)
+
+	return cfg
+}
+
+// parseModuleLevels parses LOG_MODULE_LEVELS, e.g. "storage=debug,jobs=warn"
+func parseModuleLevels(s string) (map[string]zapcore.Level, error) {
+	levels := make(map[string]zapcore.Level)
+	for _, pair := range strings.Split(s, ",") {
+		module, levelStr, _ := strings.Cut(strings.TrimSpace(pair), "=")
+		level, err := applog.ParseLevel(levelStr)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(applog.Modules, module) {
+			return nil, fmt.Errorf("unknown log module %q", module)
+		}
+		levels[module] = level
+	}
+	return levels, nil
+}
+
+// parseStorageType checks that STORAGE_TYPE names a registered backend
+func parseStorageType(s string) (string, error) {
+	if _, ok := storage.Lookup(s); !ok {
+		return "", fmt.Errorf("%q is not one of %s", s, strings.Join(storage.Backends(), ", "))
+	}
+	return s, nil
+}
+
+// parseAltStorageType is parseStorageType allowing empty, which disables the experiment
+func parseAltStorageType(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	return parseStorageType(s)
+}
+
+// parseEventDriver checks that EVENT_BUS_DRIVER names a registered driver
+func parseEventDriver(s string) (string, error) {
+	if _, ok := events.Lookup(s); !ok {
+		return "", fmt.Errorf("%q is not one of %s", s, strings.Join(events.Drivers(), ", "))
+	}
+	return s, nil
+}
+
+// parsePluginMiddleware parses PLUGIN_MIDDLEWARE, a comma-separated list of
+// middleware registered by plugins
+func parsePluginMiddleware(s string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if _, ok := plugins.Middleware(name); !ok {
+			registered := strings.Join(plugins.MiddlewareNames(), ", ")
+			if registered == "" {
+				registered = "none, is PLUGIN_DIR set?"
+			}
+			return nil, fmt.Errorf("middleware %q is not registered by any plugin (registered: %s)", name, registered)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// parsePort checks that PORT is a TCP port number
+func parsePort(s string) (string, error) {
+	if n, err := strconv.Atoi(s); err != nil || n < 1 || n > 65535 {
+		return "", fmt.Errorf("%q is not a port number", s)
+	}
+	return s, nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"tasks-service-demo/internal/config"
+	applog "tasks-service-demo/internal/logger"
+)
+
+func envOf(vars map[string]string) *config.Env {
+	return config.New(func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	})
+}
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	env := envOf(nil)
+	cfg := loadConfig(env)
+
+	if err := env.Err(); err != nil {
+		t.Fatalf("Expected defaults to be valid, got %v", err)
+	}
+	if cfg.StorageType != "xsync" || cfg.ShardCount != 32 || cfg.Port != "8080" {
+		t.Errorf("Unexpected defaults %+v", cfg)
+	}
+}
+
+func TestLoadConfig_AggregatesProblems(t *testing.T) {
+	env := envOf(map[string]string{
+		"STORAGE_TYPE":           "rocks",
+		"SHARD_COUNT":            "0",
+		"CORS_ALLOW_CREDENTIALS": "true",
+		"APP_ENV":                "production",
+		"DEBUG_ERRORS":           "true",
+		"LOG_MODULE_LEVELS":      "db=debug",
+		"PORT":                   "http",
+		"TASK_TTL":               "24h",
+		"TIER_HOT_SIZE":          "1000",
+		"WRITE_BEHIND_MODE":      "async",
+		"EVENT_BUS_DRIVER":       "nats",
+		"REDACT_TASK_FIELDS":     "description",
+		"REQUEST_SIGNING_KEYS":   "unknown-key:secret",
+		"ADMIN_IP_ALLOWLIST":     "10.0.0.0/33",
+	})
+	loadConfig(env)
+
+	err := env.Err()
+	if err == nil {
+		t.Fatal("Expected invalid configuration")
+	}
+	for _, key := range []string{"STORAGE_TYPE", "SHARD_COUNT", "CORS_ALLOW_CREDENTIALS", "DEBUG_ERRORS", "LOG_MODULE_LEVELS", "PORT", "TASK_TTL", "TIER_HOT_SIZE", "WRITE_BEHIND_MODE", "EVENT_BUS_URL", "REDACT_TASK_FIELDS", "REQUEST_SIGNING_KEYS", "ADMIN_IP_ALLOWLIST"} {
+		if !strings.Contains(err.Error(), key+": ") {
+			t.Errorf("Expected report to mention %s, got %v", key, err)
+		}
+	}
+}
+
+func TestLoadConfig_MasksSecrets(t *testing.T) {
+	env := envOf(map[string]string{
+		"API_KEYS":             "admin-key:admin",
+		"JWT_SECRET":           "jwt-secret",
+		"EXPORT_S3_SECRET_KEY": "s3-secret",
+		"SMTP_PASSWORD":        "smtp-secret",
+		"SHARE_SIGNING_KEYS":   "k1:share-secret",
+		"REQUEST_SIGNING_KEYS": "admin-key:hmac-secret",
+		"LOG_MODULE_LEVELS":    "storage=debug",
+	})
+	cfg := loadConfig(env)
+
+	if len(cfg.Auth.APIKeys) != 1 || cfg.ModuleLevels[applog.ModuleStorage].String() != "debug" {
+		t.Errorf("Expected parsed settings, got %+v", cfg)
+	}
+	for _, entry := range env.Entries() {
+		if strings.Contains(entry.Value, "secret") || strings.Contains(entry.Value, "admin-key") {
+			t.Errorf("Expected %s to be masked, got %q", entry.Key, entry.Value)
+		}
+	}
+}
+
+func TestLoadConfig_DemoCapsStore(t *testing.T) {
+	for _, tc := range []struct {
+		name, storageMax string
+		wantTasks        int
+	}{
+		{"unset STORAGE_MAX_TASKS", "", 100},
+		{"a higher STORAGE_MAX_TASKS", "1000", 100},
+		{"a lower STORAGE_MAX_TASKS", "20", 20},
+	} {
+		vars := map[string]string{"DEMO_MODE": "true", "DEMO_MAX_TASKS": "100"}
+		if tc.storageMax != "" {
+			vars["STORAGE_MAX_TASKS"] = tc.storageMax
+		}
+		env := envOf(vars)
+		cfg := loadConfig(env)
+		if err := env.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Guard.MaxTasks != tc.wantTasks {
+			t.Errorf("With %s: Expected the store capped at %d, got %d", tc.name, tc.wantTasks, cfg.Guard.MaxTasks)
+		}
+	}
+}
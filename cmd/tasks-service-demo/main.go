@@ -1,30 +1,81 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"os/signal"
-	"strconv"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/joho/godotenv"
 
+	"tasks-service-demo/internal/adaptive"
+	"tasks-service-demo/internal/admission"
+	"tasks-service-demo/internal/archive"
+	"tasks-service-demo/internal/auth"
+	"tasks-service-demo/internal/buildinfo"
+	"tasks-service-demo/internal/changes"
+	"tasks-service-demo/internal/compress"
+	"tasks-service-demo/internal/config"
+	"tasks-service-demo/internal/crypt"
+	"tasks-service-demo/internal/demo"
+	"tasks-service-demo/internal/dlq"
 	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/events"
+	"tasks-service-demo/internal/export"
+	"tasks-service-demo/internal/handlers"
+	"tasks-service-demo/internal/health"
+	"tasks-service-demo/internal/ipfilter"
+	"tasks-service-demo/internal/jobs"
 	applog "tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/metrics"
+	"tasks-service-demo/internal/middleware"
+	"tasks-service-demo/internal/notify"
+	"tasks-service-demo/internal/payloadlog"
+	"tasks-service-demo/internal/plugins"
+	"tasks-service-demo/internal/projection"
+	"tasks-service-demo/internal/reconcile"
+	"tasks-service-demo/internal/redact"
+	"tasks-service-demo/internal/retention"
 	"tasks-service-demo/internal/routes"
+	"tasks-service-demo/internal/schedules"
 	"tasks-service-demo/internal/services"
+	"tasks-service-demo/internal/share"
+	"tasks-service-demo/internal/shutdown"
+	"tasks-service-demo/internal/slowlog"
+	"tasks-service-demo/internal/stats"
 	"tasks-service-demo/internal/storage"
-	"tasks-service-demo/internal/storage/naive"
+	"tasks-service-demo/internal/storage/bloom"
+	"tasks-service-demo/internal/storage/lru"
+	"tasks-service-demo/internal/storage/memguard"
+	"tasks-service-demo/internal/storage/mirror"
 	"tasks-service-demo/internal/storage/shard"
-	"tasks-service-demo/internal/storage/xsync"
+	"tasks-service-demo/internal/storage/tiered"
+	"tasks-service-demo/internal/storage/writebehind"
+	"tasks-service-demo/internal/streaming"
+	"tasks-service-demo/internal/usage"
+	"tasks-service-demo/internal/warmup"
+	"tasks-service-demo/internal/workload"
 )
 
 func main() {
+	// `tasks-service-demo bench` runs the benchmark scenarios instead of the server
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		os.Exit(runBench(os.Args[2:], os.Stdout))
+	}
+	// `tasks-service-demo replay` re-drives a recorded workload against a backend
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		os.Exit(runReplay(os.Args[2:], os.Stdout))
+	}
+
 	// flush zap on exit
 	defer func() {
 		if err := applog.Get().Sync(); err != nil {
@@ -32,104 +83,681 @@ func main() {
 		}
 	}()
 
-	// Load .env file if it exists
-	if err := godotenv.Load(); err != nil {
-		applog.Get().Info("No .env file found, using system environment variables")
+	// Load the config file (CONFIG_FILE, default .env) if it exists; the
+	// process environment wins over it, also when the file is reloaded
+	baseEnv := environ()
+	configFile := os.Getenv("CONFIG_FILE")
+	if configFile == "" {
+		configFile = defaultConfigFile
 	}
+	if err := godotenv.Load(configFile); err != nil {
+		applog.Get().Infof("No %s file found, using system environment variables", configFile)
+	}
+
+	// Plugins register storage backends and middleware, so they load before
+	// the settings selecting them are validated
+	if pluginDir := os.Getenv("PLUGIN_DIR"); pluginDir != "" {
+		infos, err := plugins.LoadDir(pluginDir)
+		if err != nil {
+			applog.Get().Fatalf("Failed to load plugins: %v", err)
+		}
+		for _, info := range infos {
+			applog.Get().Infow("Plugin loaded", "path", info.Path, "stores", info.Stores, "middleware", info.Middleware)
+		}
+	}
+
+	// Resolve and validate every setting before building anything, so one
+	// run reports all invalid settings instead of failing on the first
+	env := config.FromEnviron()
+	cfg := loadConfig(env)
+	if err := env.Err(); err != nil {
+		applog.Get().Fatalw("Invalid configuration", "problems", err.(*config.Error).Problems)
+	}
+
+	// Root and per-module log levels; both can be changed later via PUT /admin/log-level
+	applyLogLevels(cfg)
+	applog.Get().Infow("Resolved configuration", env.Fields()...)
 
+	if cfg.DebugErrors {
+		apperrors.SetDebug(true)
+		applog.Get().Warn("DEBUG_ERRORS enabled: error responses include cause chains and stack frames")
+	}
+
+	// Handlers return *AppError; the ErrorHandler maps codes to HTTP statuses
 	app := fiber.New(fiber.Config{
-		ErrorHandler: func(ctx *fiber.Ctx, err error) error {
-			code := fiber.StatusInternalServerError
-			if e, ok := err.(*fiber.Error); ok {
-				code = e.Code
-			}
-			return ctx.Status(code).JSON(fiber.Map{
-				"code":    apperrors.ErrCodeInternalError,
-				"message": err.Error(),
-			})
-		},
+		ErrorHandler: middleware.ErrorHandler,
 	})
 	app.Use(logger.New())
 	app.Use(recover.New())
-	app.Use(cors.New())
-
-	// Initialize storage with configuration options
-	var store storage.Store
-
-	// Check environment variable for storage type (default: xsync)
-	storageType := os.Getenv("STORAGE_TYPE")
-	if storageType == "" {
-		storageType = "xsync" // Default to lock-free best performance
-	}
-
-	// Configure shard count (default: 32 for M4 Pro optimization)
-	shardCount := 32
-	if shardCountStr := os.Getenv("SHARD_COUNT"); shardCountStr != "" {
-		if sc, err := strconv.Atoi(shardCountStr); err == nil && sc > 0 {
-			shardCount = sc
-		}
-	}
-
-	// Initialize based on configuration
-	switch storageType {
-	case "xsync":
-		store = xsync.NewXSyncStore()
-		applog.Get().Info("XSyncStore initialized (lock-free concurrent map - best performance)")
-	case "gopool":
-		store = shard.NewShardStoreGopool(shardCount)
-		applog.Get().Infof("ShardStoreGopool initialized with %d shards", shardCount)
-	case "shard":
-		store = shard.NewShardStore(shardCount)
-		applog.Get().Infof("ShardStore initialized with dedicated workers and %d shards", shardCount)
-	case "memory":
-		store = naive.NewMemoryStore()
-		applog.Get().Info("MemoryStore initialized (single mutex - not recommended for production)")
-	default:
-		// Default to xsync for best performance
-		store = xsync.NewXSyncStore()
-		applog.Get().Infof("Unknown storage type '%s', defaulting to XSyncStore", storageType)
-		applog.Get().Info("XSyncStore initialized (lock-free concurrent map - best performance)")
+
+	// Live request stats for the admin dashboard, which does not count its own polling
+	app.Use(stats.Middleware(middleware.DashboardPathPrefix))
+
+	// Sampled trace of task operations for `tasks-service-demo replay` (WORKLOAD_RECORD_FILE)
+	var recorder *workload.Recorder
+	if cfg.Workload.File != "" {
+		var err error
+		if recorder, err = workload.Open(cfg.Workload); err != nil {
+			applog.Get().Fatalf("Failed to open workload trace: %v", err)
+		}
+		app.Use(workload.Middleware(recorder))
+		applog.Get().Infof("Recording workload to %s (sample rate %.2f)", cfg.Workload.File, cfg.Workload.SampleRate)
+	}
+
+	// Task fields masked in logs and exports but stored intact (REDACT_TASK_FIELDS)
+	redact.Set(cfg.Redact)
+
+	// Sampled request/response body logging (off by default, toggled via /admin/payload-logging)
+	payloadlog.Set(cfg.PayloadLog)
+	app.Use(payloadlog.Middleware("/health", "/readyz", "/metrics", middleware.DashboardPathPrefix))
+
+	// CORS settings were checked by loadConfig. CORS, load shedding and
+	// read-only mode are swappable so config file reloads can change them.
+	corsHandler, err := middleware.CORS(cfg.CORS)
+	if err != nil {
+		applog.Get().Fatalf("Invalid CORS configuration: %v", err)
+	}
+	corsSwap := middleware.NewSwappable(corsHandler)
+	app.Use(corsSwap.Handler())
+
+	// Security headers; HSTS is only sent on HTTPS requests (0 disables)
+	app.Use(middleware.SecurityHeaders(cfg.HSTSMaxAge))
+
+	// CSRF tokens for cookie-based clients (off by default)
+	if cfg.CSRFEnabled {
+		app.Use(middleware.CSRF(cfg.CSRFCookieSecure))
+		applog.Get().Info("CSRF protection enabled for cookie-based requests")
+	}
+
+	// Shed load beyond MAX_INFLIGHT_REQUESTS concurrent requests (0 disables); probes are never shed
+	shedderSwap := middleware.NewSwappable(loadShedder(cfg))
+	app.Use(shedderSwap.Handler())
+	if cfg.MaxInFlight > 0 {
+		applog.Get().Infof("Load shedding above %d in-flight requests", cfg.MaxInFlight)
+	}
+
+	// Public demo (DEMO_MODE): no destructive admin requests, and a request
+	// budget per client address (DEMO_RATE_LIMIT per minute, 0 disables)
+	if cfg.Demo.Enabled {
+		app.Use(middleware.DemoGuard())
+		if cfg.Demo.RateLimit > 0 {
+			app.Use(middleware.RateLimit(cfg.Demo.RateLimit, shedSkip...))
+		}
+		applog.Get().Warnf("DEMO_MODE enabled: admin writes are disabled and the store resets every %s (maxTasks=%d, rateLimit=%d/min)", cfg.Demo.ResetInterval, cfg.Demo.MaxTasks, cfg.Demo.RateLimit)
+	}
+
+	// Encryption at rest for durable backends, the archive file and local
+	// snapshots (STORAGE_ENCRYPTION_KEYS=kid:base64key,...; first key encrypts)
+	sealer, err := crypt.Load(context.Background(), crypt.StaticKeys(cfg.StorageKeys))
+	if err != nil {
+		applog.Get().Fatalf("Failed to load storage encryption keys: %v", err)
+	}
+	if sealer.Enabled() {
+		applog.Get().Infof("Encryption at rest enabled (%d keys, active: %s)", len(cfg.StorageKeys), sealer.ActiveKey())
+	}
+	// Compression of large tasks in badger (STORAGE_COMPRESSION=snappy|zstd),
+	// before they are encrypted
+	compressor, err := compress.New(cfg.Compression)
+	if err != nil {
+		applog.Get().Fatalf("Invalid storage compression: %v", err)
+	}
+	if compressor.Enabled() {
+		applog.Get().Infof("Storage compression enabled (%s, tasks of %d bytes or more)", compressor.Codec(), cfg.Compression.Threshold)
+	}
+
+	// Initialize the selected backend; STORAGE_TYPE was checked against the registry
+	backend, _ := storage.Lookup(cfg.StorageType)
+	store, err := backend.New(storage.Options{
+		ShardCount: cfg.ShardCount,
+		LockSample: cfg.LockSample,
+		Expected:   cfg.Expected,
+		DataDir:    cfg.DataDir,
+		SyncWrites: cfg.SyncWrites,
+		Group:      cfg.GroupCommit,
+		TaskTTL:    cfg.TaskTTL,
+		GCInterval: cfg.GCInterval,
+		Sealer:     sealer,
+		Compressor: compressor,
+	})
+	if err != nil {
+		applog.Get().Fatalf("Failed to initialize %s storage: %v", cfg.StorageType, err)
+	}
+	if backend.Sharded {
+		applog.Get().Infof("%s initialized with %d shards", backend.Description, cfg.ShardCount)
+		if reporter, ok := store.(shard.Reporter); ok {
+			metrics.Registry().MustRegister(shard.NewCollector(metrics.Namespace, reporter))
+		}
+	} else {
+		applog.Get().Infof("%s initialized", backend.Description)
+	}
+
+	// Integrity check of durable backends, which may have been stopped
+	// uncleanly; on corruption task writes are refused unless forced
+	if verifier, ok := storage.FindVerifier(store); ok && cfg.Verify {
+		start := time.Now()
+		result, err := verifier.Verify(context.Background())
+		corrupt := err != nil || !result.OK()
+		health.Get().Register("integrity", false, health.IntegrityCheck(result, err, !corrupt || cfg.VerifyForce))
+		switch {
+		case !corrupt:
+			applog.Get().Infow("Storage integrity verified", "records", result.Records, "repairs", result.Repairs, "duration", time.Since(start))
+		case cfg.VerifyForce:
+			applog.Get().Errorw("Storage failed its integrity check; serving writes because STORAGE_VERIFY_FORCE is set", "error", err, "badRecords", result.BadRecords, "problems", result.Problems)
+		default:
+			app.Use("/tasks", middleware.CorruptionGuard())
+			applog.Get().Errorw("Storage failed its integrity check; task writes are disabled", "error", err, "badRecords", result.BadRecords, "problems", result.Problems)
+		}
+	}
+
+	// Optional write-behind journal for slow durable backends; below the hot
+	// tier so its write-through to the cold tier is buffered too
+	if cfg.WriteBehind.Mode != writebehind.ModeOff {
+		buffered := writebehind.NewWriteBehindStore(store, cfg.WriteBehind)
+		metrics.Registry().MustRegister(writebehind.NewCollector(metrics.Namespace, buffered))
+		store = buffered
+		applog.Get().Infof("Write-behind enabled (mode=%s, flushInterval=%s, batchSize=%d)", cfg.WriteBehind.Mode, cfg.WriteBehind.FlushInterval, cfg.WriteBehind.BatchSize)
+	}
+
+	// Optional hot in-memory tier in front of a durable backend
+	if cfg.Tier.HotSize > 0 {
+		tier := tiered.NewTieredStore(store, cfg.Tier)
+		metrics.Registry().MustRegister(tiered.NewCollector(metrics.Namespace, tier))
+		store = tier
+		applog.Get().Infof("Storage tiering enabled (hotSize=%d, maxAge=%s)", cfg.Tier.HotSize, cfg.Tier.MaxAge)
+	}
+
+	// Bloom filter of existing IDs so lookups of missing tasks skip the backend
+	if cfg.Bloom.FalsePositiveRate > 0 {
+		filtered := bloom.NewBloomStore(store, cfg.Bloom)
+		metrics.Registry().MustRegister(bloom.NewCollector(metrics.Namespace, filtered))
+		store = filtered
+		applog.Get().Infof("ID bloom filter enabled (fpRate=%g, expectedTasks=%d, tasks=%d)", cfg.Bloom.FalsePositiveRate, cfg.Bloom.ExpectedTasks, filtered.Stats().Items)
+	}
+
+	// Record writes for the /tasks/changes feed; innermost so cache and
+	// guardrail evictions are recorded too
+	store = changes.NewTrackingStore(store, changes.Get())
+
+	// Backend metadata (badger) holding the state that has to outlive
+	// restarts alongside the tasks; nil keeps it in memory
+	meta, _ := storage.FindMeta(store)
+
+	// Soft deletes: deleted tasks and replaced versions are kept until the
+	// retention purge job removes them (RETENTION_WINDOW enables it); they are
+	// saved in the backend when it can hold metadata
+	retention.Init(retention.NewBin(cfg.Retention, meta))
+	if bin := retention.Get(); bin.Enabled() {
+		if err := bin.Load(); err != nil {
+			applog.Get().Fatalf("Failed to load the retention bin: %v", err)
+		}
+		store = retention.NewStore(store, bin)
+		metrics.Registry().MustRegister(retention.NewCollector(metrics.Namespace, bin))
+		applog.Get().Infof("Soft deletes enabled (window=%s, maxRevisions=%d, dryRun=%t, persistent=%t)", cfg.Retention.Window, bin.Config().MaxRevisions, cfg.Retention.DryRun, bin.Persistent())
+	}
+
+	// Archive of long-completed tasks; the store wrapper tracks when tasks are
+	// completed (ARCHIVE_AFTER_DAYS enables archival, ARCHIVE_FILE persists it;
+	// completion times are saved in the backend when it can hold metadata)
+	cfg.Archive.Sealer = sealer
+	cfg.Archive.Meta = meta
+	archived, err := archive.Open(cfg.Archive)
+	if err != nil {
+		applog.Get().Fatalf("Failed to open task archive: %v", err)
+	}
+	archive.Init(archived)
+	if archived.Enabled() {
+		store = archive.NewStore(store, archived)
+		applog.Get().Infof("Task archival enabled (after=%s, interval=%s, file=%q)", cfg.Archive.After, archived.Config().Interval, cfg.Archive.Path)
+	}
+
+	// Optional in-process read cache in front of the selected store
+	if cfg.Cache.Policy != "" {
+		cached := lru.NewCachedStore(store, cfg.Cache)
+		metrics.Registry().MustRegister(lru.NewCollector(metrics.Namespace, cached))
+		store = cached
+		applog.Get().Infof("GetByID cache enabled (policy=%s, size=%d, ttl=%s, negativeTTL=%s)", cfg.Cache.Policy, cfg.Cache.Size, cfg.Cache.TTL, cfg.Cache.NegativeTTL)
+	}
+
+	// Optional memory guardrails; wraps the cache so evictions also invalidate it
+	if cfg.Guard.MaxBytes > 0 || cfg.Guard.MaxTasks > 0 {
+		guarded := memguard.NewGuardedStore(store, cfg.Guard)
+		metrics.Registry().MustRegister(memguard.NewCollector(metrics.Namespace, guarded))
+		store = guarded
+		applog.Get().Infof("Storage guardrails enabled (maxBytes=%d, maxTasks=%d, policy=%s)", cfg.Guard.MaxBytes, cfg.Guard.MaxTasks, guarded.Policy())
+	}
+
+	// Optional storage A/B experiment (ALT_STORAGE_TYPE): the alternate backend
+	// gets a copy of every task and every write, and serves the reads of
+	// ALT_TRAFFIC_PERCENT of requests or of those pinned by ALT_ROUTING_HEADER
+	if cfg.AltStorageType != "" {
+		altBackend, _ := storage.Lookup(cfg.AltStorageType)
+		alternate, err := altBackend.New(storage.Options{
+			ShardCount: cfg.ShardCount,
+			Expected:   cfg.Expected,
+			DataDir:    cfg.AltDataDir,
+			SyncWrites: cfg.SyncWrites,
+			Group:      cfg.GroupCommit,
+			GCInterval: cfg.GCInterval,
+			Sealer:     sealer,
+			Compressor: compressor,
+		})
+		if err != nil {
+			applog.Get().Fatalf("Failed to initialize alternate %s storage: %v", cfg.AltStorageType, err)
+		}
+		mirrored := mirror.New(store, alternate)
+		seeded, err := mirrored.Seed()
+		if err != nil {
+			applog.Get().Fatalf("Failed to copy tasks to the alternate store: %v", err)
+		}
+		router := mirror.NewRouter(mirrored, cfg.AltRouting)
+		metrics.Registry().MustRegister(mirror.NewCollector(metrics.Namespace, router))
+		mirror.Init(router)
+		app.Use(middleware.StorageVariant(router))
+		store = router
+		applog.Get().Infof("Storage A/B experiment enabled (alternate=%s, percent=%g, header=%s, shadowReads=%t, copied %d tasks)", altBackend.Description, cfg.AltRouting.Percent, router.Config().Header, cfg.AltRouting.Shadow, seeded)
+	}
+
+	// Conflict policy for POST /sync (SYNC_CONFLICT_POLICY=lww|field). API
+	// writes are stamped with server time so stale offline edits lose to them;
+	// outermost but for the slow log so every layer sees the writes of POST
+	// /sync, which go below the stamping. The stamps are saved in the backend
+	// when it can hold metadata (badger).
+	reconciler := reconcile.New(cfg.SyncPolicy, nil, meta)
+	if err := reconciler.Load(); err != nil {
+		applog.Get().Fatalf("Failed to load sync stamps: %v", err)
+	}
+	reconcile.Init(reconciler)
+	store = reconcile.NewStampingStore(store, reconciler)
+
+	// Slow store calls (SLOWLOG_THRESHOLD, 0 disables); outermost so the
+	// recorded duration is the one callers see
+	slowlog.Init(slowlog.New(cfg.SlowLog))
+	if slowlog.Get().Enabled() {
+		store = slowlog.NewStore(store, slowlog.Get())
+		app.Use(slowlog.Middleware())
+		applog.Get().Infof("Slow log enabled (threshold=%s, size=%d)", cfg.SlowLog.Threshold, slowlog.Get().Config().Size)
 	}
 
 	storage.InitStore(store)
+	if backend.Sharded {
+		buildinfo.SetStorage(cfg.StorageType, cfg.ShardCount)
+	} else {
+		buildinfo.SetStorage(cfg.StorageType, 0) // Shard count does not apply
+	}
+
+	info := buildinfo.Get()
+	metrics.SetBuildInfo(info)
+	applog.Get().Infow("Build info",
+		"version", info.Version,
+		"gitCommit", info.GitCommit,
+		"buildDate", info.BuildDate,
+		"goVersion", info.GoVersion,
+		"storageType", info.Storage.Type,
+		"shardCount", info.Storage.ShardCount,
+	)
+
+	health.Get().Register("storage", true, health.StoreCheck(cfg.StorageType, 2*time.Second))
+	// Read-only replicas reject every mutating task request
+	readOnlySwap := middleware.NewSwappable(readOnlyGuard(cfg))
+	app.Use("/tasks", readOnlySwap.Handler())
+	if cfg.ReadOnly {
+		applog.Get().Info("READ_ONLY enabled: mutating task endpoints are disabled")
+	}
+
+	auth.Init(auth.New(cfg.Auth))
+	if auth.Get().Enabled() {
+		applog.Get().Infof("Authentication enabled (%d API keys, %d signing writes, JWT: %t)", len(cfg.Auth.APIKeys), len(cfg.Auth.SigningKeys), cfg.Auth.JWTSecret != "")
+	}
+
+	// Priority shedding under overload (ADMISSION_CAPACITY, 0 disables):
+	// listings go first, then reads, then writes
+	admission.Init(admission.New(cfg.Admission))
+	metrics.Registry().MustRegister(admission.NewCollector(metrics.Namespace))
+	if cfg.Admission.Capacity > 0 {
+		applog.Get().Infof("Admission control enabled (capacity=%d, latencyTarget=%s, %d key tiers)", cfg.Admission.Capacity, cfg.Admission.LatencyTarget, len(cfg.Admission.KeyTiers))
+	}
+
+	// Adaptive per-route concurrency limits (ADAPTIVE_LIMIT_ENABLED)
+	adaptive.Init(adaptive.New(cfg.Adaptive))
+	metrics.Registry().MustRegister(adaptive.NewCollector(metrics.Namespace))
+	if cfg.Adaptive.Enabled {
+		applog.Get().Infof("Adaptive concurrency limits enabled (initial=%d, min=%d, max=%d per route)", cfg.Adaptive.InitialLimit, cfg.Adaptive.MinLimit, cfg.Adaptive.MaxLimit)
+	}
+
+	// Read models kept up to date from the change feed (PROJECTIONS_ENABLED)
+	if cfg.Projections {
+		engine := projection.New(changes.Get(), storage.GetStore(), projection.NewStatusCounts())
+		engine.Start()
+		projection.Init(engine)
+		applog.Get().Info("Projections enabled (statusCounts)")
+	}
+	metrics.Registry().MustRegister(projection.NewCollector(metrics.Namespace))
+
+	// Write deadlines and lag limits for slow clients of streamed responses
+	streaming.Init(streaming.New(cfg.Streaming))
+	metrics.Registry().MustRegister(streaming.NewCollector(metrics.Namespace))
+
+	// Admin routes limited to client addresses (ADMIN_IP_ALLOWLIST / ADMIN_IP_DENYLIST)
+	ipfilter.Set(cfg.AdminIPs)
+	metrics.Registry().MustRegister(ipfilter.NewCollector(metrics.Namespace))
+	if cfg.AdminIPs.Enabled() {
+		applog.Get().Infof("Admin IP rules enabled (%d allowed, %d denied ranges)", len(cfg.AdminIPs.Allow), len(cfg.AdminIPs.Deny))
+	}
+
+	// Signed share links (SHARE_SIGNING_KEYS=kid:secret,...; first key signs)
+	// Revocations are saved in the backend when it can hold metadata (badger)
+	if len(cfg.ShareKeys) > 0 {
+		signer := share.New(cfg.ShareKeys, meta)
+		if err := signer.Load(); err != nil {
+			applog.Get().Fatalf("Failed to load share link revocations: %v", err)
+		}
+		share.Init(signer)
+		applog.Get().Infof("Task share links enabled (%d signing keys, persistent revocations: %t)", len(cfg.ShareKeys), signer.Persistent())
+	}
+
+	// Job kinds for schedules created through POST /admin/jobs; the schedules
+	// are saved in the backend when it can hold metadata (badger)
+	scheduler := schedules.New(nil, meta)
+
+	// Requests per API key (USAGE_WINDOW, 0 disables); monthly counts are
+	// saved in the backend like the schedules, so USAGE_MONTHLY_QUOTA holds
+	// across restarts
+	tracker := usage.New(cfg.Usage, meta)
+	if err := tracker.Load(); err != nil {
+		applog.Get().Fatalf("Failed to load API key usage: %v", err)
+	}
+	usage.Init(tracker)
+	if tracker.Enabled() && tracker.Persistent() {
+		if err := jobs.Get().Schedule("usage-flush", cfg.Usage.FlushInterval, tracker.Job()); err != nil {
+			applog.Get().Fatalf("Failed to schedule usage flush: %v", err)
+		}
+	}
+	if reconciler.Persistent() {
+		if err := jobs.Get().Schedule("sync-stamps-flush", reconcile.DefaultSaveInterval, reconciler.Job()); err != nil {
+			applog.Get().Fatalf("Failed to schedule sync stamps flush: %v", err)
+		}
+	}
+	if cfg.Usage.MonthlyQuota > 0 {
+		applog.Get().Infof("API key quotas enabled (%d requests per month, persistent: %t)", cfg.Usage.MonthlyQuota, tracker.Persistent())
+	}
+
+	// Scheduled export of all tasks to S3-compatible storage (EXPORT_S3_BUCKET enables it)
+	if cfg.S3.Bucket != "" {
+		exporter := export.New(export.NewS3Client(cfg.S3, nil), cfg.Export, nil)
+		if err := jobs.Get().Schedule("s3-export", cfg.ExportInterval, exporter.Run); err != nil {
+			applog.Get().Fatalf("Failed to schedule export: %v", err)
+		}
+		scheduler.RegisterKind(schedules.Kind{
+			Name:        "export",
+			Description: "Upload all tasks to the S3 export bucket",
+			New:         schedules.NoParams(exporter.Run),
+		})
+		applog.Get().Infof("S3 export enabled (bucket: %s, every %s, keeping %d)", cfg.S3.Bucket, cfg.ExportInterval, cfg.Export.Retain)
+	}
+
+	// Purge of soft-deleted tasks and old revisions past the retention window
+	if bin := retention.Get(); bin.Enabled() {
+		rc := bin.Config()
+		if err := jobs.Get().Schedule("retention-purge", rc.Interval, bin.Job(rc.Window, rc.DryRun)); err != nil {
+			applog.Get().Fatalf("Failed to schedule retention purge: %v", err)
+		}
+		scheduler.RegisterKind(schedules.Kind{
+			Name:        "cleanup",
+			Description: "Purge soft-deleted tasks and revisions older than olderThanDays (default: the retention window)",
+			New:         bin.CleanupJob,
+		})
+	}
+
+	// Archival of tasks completed more than ARCHIVE_AFTER_DAYS ago
+	if a := archive.Get(); a.Enabled() {
+		if err := jobs.Get().Schedule("task-archival", a.Config().Interval, a.Job(nil)); err != nil {
+			applog.Get().Fatalf("Failed to schedule task archival: %v", err)
+		}
+		scheduler.RegisterKind(schedules.Kind{
+			Name:        "archive",
+			Description: "Move tasks completed more than ARCHIVE_AFTER_DAYS ago to the archive",
+			New:         schedules.NoParams(a.Job(nil)),
+		})
+	}
+
+	// Demo store back to the sample tasks every DEMO_RESET_INTERVAL
+	if cfg.Demo.Enabled {
+		if err := jobs.Get().Schedule("demo-reset", cfg.Demo.ResetInterval, demo.Job()); err != nil {
+			applog.Get().Fatalf("Failed to schedule demo reset: %v", err)
+		}
+	}
+
+	// Local snapshots, run on a schedule created through the admin API (SNAPSHOT_DIR enables it)
+	if cfg.SnapshotDir != "" {
+		dir, err := export.NewDirStore(cfg.SnapshotDir)
+		if err != nil {
+			applog.Get().Fatalf("Failed to create snapshot directory: %v", err)
+		}
+		snapshotter := export.New(dir, export.Config{Retain: cfg.SnapshotRetain, Sealer: sealer}, nil)
+		scheduler.RegisterKind(schedules.Kind{
+			Name:        "snapshot",
+			Description: "Write all tasks to a gzipped JSON lines file in SNAPSHOT_DIR",
+			New:         schedules.NoParams(snapshotter.Run),
+		})
+	}
+
+	// Re-encryption after rotating STORAGE_ENCRYPTION_KEYS, on backends that encrypt tasks at rest
+	if rekeyer, ok := storage.FindRekeyer(store); ok {
+		scheduler.RegisterKind(schedules.Kind{
+			Name:        "rekey",
+			Description: "Re-encrypt stored tasks and the archive file under the active STORAGE_ENCRYPTION_KEYS key",
+			New: schedules.NoParams(func(ctx context.Context, p *jobs.Progress) error {
+				n, err := rekeyer.Rekey(ctx)
+				p.SetMessage(fmt.Sprintf("re-encrypted %d tasks", n))
+				if err != nil {
+					return err
+				}
+				return archive.Get().Save()
+			}),
+		})
+	}
+
+	if err := scheduler.Load(); err != nil {
+		applog.Get().Fatalf("Failed to load job schedules: %v", err)
+	}
+	schedules.Init(scheduler)
+	if !scheduler.Persistent() {
+		applog.Get().Info("Job schedules are kept in memory; use STORAGE_TYPE=badger to keep them across restarts")
+	}
+
+	// Dead-letter queue for deliveries that exhausted their retries
+	deadLetters, err := dlq.Open(cfg.DLQ)
+	if err != nil {
+		applog.Get().Fatalf("Failed to open dead-letter queue: %v", err)
+	}
+	deadLetters.Register(notify.DeadLetterSource, func(ctx context.Context, payload json.RawMessage) (string, error) {
+		return notify.Get().Replay(ctx, payload)
+	})
+	dlq.Init(deadLetters)
+
+	// Email notifications (SMTP_ADDR enables delivery)
+	if cfg.SMTP.Addr != "" {
+		sender, err := notify.NewSMTPSender(cfg.SMTP)
+		if err != nil {
+			applog.Get().Fatalf("Invalid SMTP configuration: %v", err)
+		}
+
+		templates := fs.FS(notify.DefaultTemplates)
+		if cfg.NotifyTemplateDir != "" {
+			templates = os.DirFS(cfg.NotifyTemplateDir)
+		}
+		renderer, err := notify.NewRenderer(templates)
+		if err != nil {
+			applog.Get().Fatalf("Invalid notification templates: %v", err)
+		}
+
+		notify.Init(notify.New(sender, renderer, notify.Config{Retry: cfg.NotifyRetry}))
+		applog.Get().Infof("Email notifications enabled via %s", cfg.SMTP.Addr)
+	}
+
+	// Middleware from plugins, in PLUGIN_MIDDLEWARE order
+	for _, name := range cfg.PluginMiddleware {
+		factory, _ := plugins.Middleware(name)
+		handler, err := factory()
+		if err != nil {
+			applog.Get().Fatalf("Failed to create plugin middleware %s: %v", name, err)
+		}
+		app.Use(handler)
+		applog.Get().Infof("Plugin middleware %s enabled", name)
+	}
+
+	// Per-route deadlines; REQUEST_TIMEOUTS replaces the defaults entirely
+	app.Use(middleware.Timeout(cfg.RouteTimeouts))
+
+	// Writes in flight, so shutdown can wait for them; writes arriving while
+	// draining get a retryable 503
+	writes := shutdown.NewBarrier()
+	app.Use(middleware.WriteBarrier(writes))
+
+	// Envelope and field naming of task responses, and JSON:API documents
+	// for clients asking for application/vnd.api+json
+	app.Use("/tasks", middleware.ShapeResponses(cfg.ResponseShape), middleware.JSONAPI())
+
 	taskService := services.NewTaskService()
-	routes.SetupRoutes(app, taskService)
+
+	// Event bus for task changes (EVENT_BUS_DRIVER=memory|nats|kafka|redis)
+	bus, err := events.Open(cfg.Events)
+	if err != nil {
+		applog.Get().Fatalf("Failed to open %s event bus: %v", cfg.Events.Driver, err)
+	}
+	events.Init(bus)
+	eventDriver, _ := events.Lookup(cfg.Events.Driver)
+	applog.Get().Infof("Event bus: %s", eventDriver.Description)
+
+	// Task events are published from the change feed through an outbox, so
+	// writes of every path reach the bus and none waits for the broker;
+	// events not yet published are saved in the backend like the schedules
+	outbox := events.NewOutbox(changes.Get(), bus, meta, cfg.Events.RedeliveryDelay)
+	if err := outbox.Start(); err != nil {
+		applog.Get().Fatalf("Failed to load pending task events: %v", err)
+	}
+	health.Get().Register("events", false, health.EventBusCheck(cfg.Events.Driver, outbox.Stats, time.Minute))
+
+	routes.SetupRoutes(app, taskService, handlers.WithListCoalescing(cfg.ListCacheTTL, changes.Get()))
+
+	// Warm up before accepting traffic; readiness flips only after this completes
+	warmer := warmup.New()
+	if cfg.WarmupPreloadFile != "" {
+		warmer.Add("preload", warmup.PreloadFile(cfg.WarmupPreloadFile))
+	}
+	if cfg.WarmupPrimeKeys > 0 {
+		warmer.Add("prime-keys", warmup.PrimeKeys(cfg.WarmupPrimeKeys))
+	}
+	if cfg.Demo.Enabled {
+		warmer.Add("demo-seed", demo.SeedHook())
+	}
+	warmer.Add("routes", warmup.WarmRoutes(app, "/health", "/version"))
+
+	warmupTimeout := cfg.WarmupTimeout
+	warmupCtx, cancelWarmup := context.WithTimeout(context.Background(), warmupTimeout)
+	if err := warmer.Run(warmupCtx); err != nil {
+		applog.Get().Fatalf("Startup warm-up failed: %v", err)
+	}
+	cancelWarmup()
+	health.Get().SetReady(true)
+	jobs.Get().Start()
+
+	// Apply safe-to-change settings when the config file changes
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	if cfg.ConfigWatch {
+		r := &reloader{
+			path: cfg.ConfigFile, base: baseEnv,
+			cors: corsSwap, shedder: shedderSwap, readOnly: readOnlySwap,
+			env: env, cfg: cfg,
+		}
+		onErr := func(err error) { applog.Get().Warnf("Config file watch error: %v", err) }
+		if err := config.Watch(watchCtx, cfg.ConfigFile, config.DefaultWatchDebounce, r.Reload, onErr); err != nil {
+			applog.Get().Warnf("Not watching %s for changes: %v", cfg.ConfigFile, err)
+		} else {
+			applog.Get().Infof("Watching %s for configuration changes", cfg.ConfigFile)
+		}
+	}
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 
+	// Shutdown in dependency order within SHUTDOWN_TIMEOUT: intake, in-flight
+	// writes, workers, buffered writes, then storage
+	coordinator := shutdown.New(cfg.ShutdownTimeout)
+	coordinator.Add(shutdown.PhaseIntake, "readiness", func(context.Context) error {
+		health.Get().SetReady(false)
+		stopWatch()
+		return nil
+	})
+	coordinator.Add(shutdown.PhaseIntake, "http", func(ctx context.Context) error {
+		// Requests still running after half the deadline are left to the write
+		// barrier, so the later phases keep their share of it
+		ctx, cancel := context.WithTimeout(ctx, coordinator.Timeout()/2)
+		defer cancel()
+		return app.ShutdownWithContext(ctx)
+	})
+	coordinator.Add(shutdown.PhaseDrain, "writes", writes.Close)
+	coordinator.Add(shutdown.PhaseWorkers, "jobs", func(context.Context) error {
+		// Jobs stop before the event bus and store they use
+		jobs.Get().Stop()
+		return nil
+	})
+	coordinator.Add(shutdown.PhaseWorkers, "projections", projection.Get().Close)
+	coordinator.Add(shutdown.PhaseWorkers, "events", func(ctx context.Context) error {
+		// Publish or save the pending task events, then stop event
+		// subscribers and flush publishers
+		return errors.Join(outbox.Close(ctx), events.Get().Close())
+	})
+	if flusher, ok := storage.FindFlusher(storage.GetStore()); ok {
+		coordinator.Add(shutdown.PhaseFlush, "write-behind", func(context.Context) error {
+			flusher.Flush()
+			return nil
+		})
+	}
+	if tracker.Enabled() {
+		coordinator.Add(shutdown.PhaseFlush, "usage", func(context.Context) error {
+			return tracker.Save()
+		})
+	}
+	if a := archive.Get(); a.Enabled() {
+		coordinator.Add(shutdown.PhaseFlush, "archive-clocks", func(context.Context) error {
+			return a.SaveClocks()
+		})
+	}
+	if bin := retention.Get(); bin.Enabled() {
+		coordinator.Add(shutdown.PhaseFlush, "retention", func(context.Context) error {
+			return bin.Save()
+		})
+	}
+	coordinator.Add(shutdown.PhaseFlush, "sync-stamps", func(context.Context) error {
+		return reconciler.Save()
+	})
+	if recorder != nil {
+		coordinator.Add(shutdown.PhaseFlush, "workload-trace", func(context.Context) error {
+			return recorder.Close()
+		})
+	}
+	coordinator.Add(shutdown.PhaseStorage, "storage", func(context.Context) error {
+		_, err := storage.Close(storage.GetStore())
+		return err
+	})
+
 	var wg sync.WaitGroup
 	wg.Add(1)
 
-	// Graceful shutdown with proper resource cleanup
 	go func() {
 		defer wg.Done()
 
 		<-quit
-		applog.Get().Info("Received shutdown signal...")
-
-		// Gracefully shutdown Fiber after 5 seconds
-		if err := app.ShutdownWithTimeout(5 * time.Second); err != nil {
-			applog.Get().Errorf("Fiber shutdown error: %v", err)
-		} else {
-			applog.Get().Info("Fiber server shutdown complete")
+		applog.Get().Infof("Received shutdown signal, stopping within %s...", coordinator.Timeout())
+		if err := coordinator.Run(context.Background()); err != nil {
+			applog.Get().Errorf("Shutdown incomplete: %v", err)
 		}
-
-		// Close storage resources before shutting down server
-		if store := storage.GetStore(); store != nil {
-			if closer, ok := store.(interface{ Close() error }); ok {
-				if err := closer.Close(); err != nil {
-					applog.Get().Errorf("Error closing storage: %v", err)
-				} else {
-					applog.Get().Info("Storage resources cleaned up")
-				}
-			}
-		}
-
 	}()
 
-	applog.Get().Info("Starting server on :8080")
-	if err := app.Listen(":8080"); err != nil {
+	applog.Get().Infof("Starting server on :%s", cfg.Port)
+	if err := app.Listen(":" + cfg.Port); err != nil {
 		applog.Get().Fatalf("Server failed to start: %v", err)
 	}
 
@@ -7,6 +7,8 @@ import (
 	"os"
 	"testing"
 
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/middleware"
 	"tasks-service-demo/internal/storage"
 	"tasks-service-demo/internal/storage/naive"
 	"tasks-service-demo/internal/storage/shard"
@@ -19,16 +21,7 @@ import (
 func TestAppConfiguration(t *testing.T) {
 	// Test that we can create a Fiber app with the same config as main
 	app := fiber.New(fiber.Config{
-		ErrorHandler: func(ctx *fiber.Ctx, err error) error {
-			code := fiber.StatusInternalServerError
-			if e, ok := err.(*fiber.Error); ok {
-				code = e.Code
-			}
-			return ctx.Status(code).JSON(fiber.Map{
-				"error":   "Internal Server Error",
-				"message": err.Error(),
-			})
-		},
+		ErrorHandler: middleware.ErrorHandler,
 	})
 
 	if app == nil {
@@ -38,16 +31,7 @@ func TestAppConfiguration(t *testing.T) {
 
 func TestErrorHandler(t *testing.T) {
 	app := fiber.New(fiber.Config{
-		ErrorHandler: func(ctx *fiber.Ctx, err error) error {
-			code := fiber.StatusInternalServerError
-			if e, ok := err.(*fiber.Error); ok {
-				code = e.Code
-			}
-			return ctx.Status(code).JSON(fiber.Map{
-				"error":   "Internal Server Error",
-				"message": err.Error(),
-			})
-		},
+		ErrorHandler: middleware.ErrorHandler,
 	})
 
 	// Create a route that returns an error
@@ -69,8 +53,8 @@ func TestErrorHandler(t *testing.T) {
 	var response map[string]interface{}
 	json.Unmarshal(body, &response)
 
-	if response["error"] != "Internal Server Error" {
-		t.Errorf("Expected error 'Internal Server Error', got '%v'", response["error"])
+	if response["code"] != float64(apperrors.ErrCodeBadRequest) {
+		t.Errorf("Expected code %d, got '%v'", apperrors.ErrCodeBadRequest, response["code"])
 	}
 
 	if response["message"] != "Test error" {
@@ -244,16 +228,7 @@ func TestGracefulShutdown(t *testing.T) {
 func TestAppIntegration(t *testing.T) {
 	// Test full app setup without Listen()
 	app := fiber.New(fiber.Config{
-		ErrorHandler: func(ctx *fiber.Ctx, err error) error {
-			code := fiber.StatusInternalServerError
-			if e, ok := err.(*fiber.Error); ok {
-				code = e.Code
-			}
-			return ctx.Status(code).JSON(fiber.Map{
-				"error":   "Internal Server Error",
-				"message": err.Error(),
-			})
-		},
+		ErrorHandler: middleware.ErrorHandler,
 	})
 
 	// Simulate main.go setup
@@ -347,16 +322,7 @@ func parseInteger(s string) (int, error) {
 func BenchmarkAppSetup(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		app := fiber.New(fiber.Config{
-			ErrorHandler: func(ctx *fiber.Ctx, err error) error {
-				code := fiber.StatusInternalServerError
-				if e, ok := err.(*fiber.Error); ok {
-					code = e.Code
-				}
-				return ctx.Status(code).JSON(fiber.Map{
-					"error":   "Internal Server Error",
-					"message": err.Error(),
-				})
-			},
+			ErrorHandler: middleware.ErrorHandler,
 		})
 
 		storage.ResetStore()
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"sync"
+
+	"tasks-service-demo/internal/config"
+	"tasks-service-demo/internal/ipfilter"
+	applog "tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/middleware"
+	"tasks-service-demo/internal/payloadlog"
+	"tasks-service-demo/internal/redact"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/joho/godotenv"
+)
+
+// reloadable lists the settings applied on a config file change; all others,
+// notably the storage settings that would need a new store, need a restart
+var reloadable = map[string]bool{
+	"LOG_LEVEL":               true,
+	"LOG_MODULE_LEVELS":       true,
+	"PAYLOAD_LOG_ENABLED":     true,
+	"PAYLOAD_LOG_SAMPLE_RATE": true,
+	"PAYLOAD_LOG_MAX_BYTES":   true,
+	"PAYLOAD_LOG_REDACT":      true,
+	"REDACT_TASK_FIELDS":      true,
+	"CORS_ALLOW_ORIGINS":      true,
+	"CORS_ALLOW_METHODS":      true,
+	"CORS_ALLOW_HEADERS":      true,
+	"CORS_ALLOW_CREDENTIALS":  true,
+	"CORS_MAX_AGE":            true,
+	"MAX_INFLIGHT_REQUESTS":   true,
+	"OVERLOAD_RETRY_AFTER":    true,
+	"READ_ONLY":               true,
+	"ADMIN_IP_ALLOWLIST":      true,
+	"ADMIN_IP_DENYLIST":       true,
+}
+
+// shedSkip lists the paths the load shedder never rejects
+var shedSkip = []string{"/health", "/readyz", "/metrics"}
+
+// loadShedder returns the load shedding middleware for cfg, or nil when disabled
+func loadShedder(cfg appConfig) fiber.Handler {
+	if cfg.MaxInFlight <= 0 {
+		return nil
+	}
+	return middleware.LoadShedder(cfg.MaxInFlight, cfg.OverloadRetryAfter, shedSkip...)
+}
+
+// readOnlyGuard returns the read-only replica guard for cfg, or nil when disabled
+func readOnlyGuard(cfg appConfig) fiber.Handler {
+	if !cfg.ReadOnly {
+		return nil
+	}
+	return middleware.ReadOnlyGuard()
+}
+
+// applyLogLevels sets the root and module levels of cfg; modules it doesn't
+// name follow the root level
+func applyLogLevels(cfg appConfig) {
+	applog.SetLevel(cfg.LogLevel)
+	for _, module := range applog.Modules {
+		if level, ok := cfg.ModuleLevels[module]; ok {
+			applog.SetModuleLevel(module, &level)
+		} else {
+			applog.SetModuleLevel(module, nil)
+		}
+	}
+}
+
+// reloader re-reads the config file and applies the reloadable settings
+type reloader struct {
+	path string
+	base map[string]string // Process environment at startup; it wins over the file, as with godotenv.Load
+
+	cors, shedder, readOnly *middleware.Swappable
+
+	mu  sync.Mutex
+	env *config.Env // Settings currently in effect
+	cfg appConfig
+}
+
+// environ returns the process environment as a map
+func environ() map[string]string {
+	vars := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			vars[key] = value
+		}
+	}
+	return vars
+}
+
+// lookup resolves keys from the startup environment, then from file
+func (r *reloader) lookup(file map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		if v, ok := r.base[key]; ok {
+			return v, ok
+		}
+		v, ok := file[key]
+		return v, ok
+	}
+}
+
+// Reload applies the config file. Invalid files are rejected as a whole;
+// settings that need a restart are reported and left unchanged.
+func (r *reloader) Reload() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	file, err := godotenv.Read(r.path)
+	if err != nil {
+		applog.Get().Errorw("Config reload failed", "file", r.path, "error", err)
+		return
+	}
+	env := config.New(r.lookup(file))
+	cfg := loadConfig(env)
+	if err := env.Err(); err != nil {
+		applog.Get().Errorw("Config reload rejected, keeping the current configuration", "file", r.path, "problems", err.(*config.Error).Problems)
+		return
+	}
+
+	var applied, restart []string
+	for _, key := range r.env.Changed(env) {
+		if reloadable[key] {
+			applied = append(applied, key)
+		} else {
+			restart = append(restart, key)
+		}
+	}
+	if len(restart) > 0 {
+		applog.Get().Warnw("Config changes need a restart and were not applied", "file", r.path, "settings", restart)
+	}
+	if len(applied) == 0 {
+		return
+	}
+
+	// Keep the restart-only settings as they are in effect
+	next := r.cfg
+	next.LogLevel, next.ModuleLevels = cfg.LogLevel, cfg.ModuleLevels
+	next.PayloadLog = cfg.PayloadLog
+	next.Redact = cfg.Redact
+	next.CORS = cfg.CORS
+	next.MaxInFlight, next.OverloadRetryAfter = cfg.MaxInFlight, cfg.OverloadRetryAfter
+	next.ReadOnly = cfg.ReadOnly
+	next.AdminIPs = cfg.AdminIPs
+
+	// Only touch what changed, so e.g. a CORS edit keeps log levels set via the admin API
+	changed := func(prefixes ...string) bool {
+		for _, key := range applied {
+			for _, prefix := range prefixes {
+				if strings.HasPrefix(key, prefix) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	if changed("CORS_") {
+		corsHandler, err := middleware.CORS(next.CORS)
+		if err != nil {
+			applog.Get().Errorw("Config reload rejected, keeping the current configuration", "file", r.path, "error", err)
+			return
+		}
+		r.cors.Swap(corsHandler)
+	}
+	if changed("LOG_") {
+		applyLogLevels(next)
+	}
+	if changed("PAYLOAD_LOG_") {
+		payloadlog.Set(next.PayloadLog)
+	}
+	if changed("REDACT_") {
+		redact.Set(next.Redact)
+	}
+	if changed("MAX_INFLIGHT_REQUESTS", "OVERLOAD_RETRY_AFTER") {
+		// The new shedder starts with no requests counted; in-flight ones finish in the old one
+		r.shedder.Swap(loadShedder(next))
+	}
+	if changed("READ_ONLY") {
+		r.readOnly.Swap(readOnlyGuard(next))
+	}
+	if changed("ADMIN_IP_") {
+		ipfilter.Set(next.AdminIPs)
+	}
+
+	// Restart-only keys keep their old values so they are reported again next time
+	r.env = r.env.With(env, applied...)
+	r.cfg = next
+	applog.Get().Infow("Configuration reloaded", "file", r.path, "settings", applied)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tasks-service-demo/internal/config"
+	applog "tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/middleware"
+	"tasks-service-demo/internal/payloadlog"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/joho/godotenv"
+	"go.uber.org/zap/zapcore"
+)
+
+func setupReloader(t *testing.T, contents string) (*reloader, *fiber.App, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app.env")
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := godotenv.Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &reloader{path: path, base: map[string]string{}}
+	env := config.New(r.lookup(file))
+	cfg := loadConfig(env)
+	if err := env.Err(); err != nil {
+		t.Fatal(err)
+	}
+	r.env, r.cfg = env, cfg
+
+	corsHandler, _ := middleware.CORS(cfg.CORS)
+	r.cors = middleware.NewSwappable(corsHandler)
+	r.shedder = middleware.NewSwappable(loadShedder(cfg))
+	r.readOnly = middleware.NewSwappable(readOnlyGuard(cfg))
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	app.Use(r.cors.Handler(), r.shedder.Handler())
+	app.Use("/tasks", r.readOnly.Handler())
+	app.Post("/tasks", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusCreated) })
+	return r, app, path
+}
+
+func TestReloader_AppliesSafeSettings(t *testing.T) {
+	defer applog.ResetLevels()
+	defer payloadlog.Reset()
+	r, app, path := setupReloader(t, "LOG_LEVEL=info\nSTORAGE_TYPE=xsync\n")
+
+	os.WriteFile(path, []byte("LOG_LEVEL=debug\nREAD_ONLY=true\nPAYLOAD_LOG_ENABLED=true\nSTORAGE_TYPE=shard\n"), 0o644)
+	r.Reload()
+
+	if applog.Level() != zapcore.DebugLevel {
+		t.Errorf("Expected log level debug, got %v", applog.Level())
+	}
+	if !payloadlog.Get().Enabled {
+		t.Error("Expected payload logging enabled")
+	}
+	resp, _ := app.Test(httptest.NewRequest("POST", "/tasks", nil))
+	if resp.StatusCode != fiber.StatusMethodNotAllowed {
+		t.Errorf("Expected read-only guard after reload, got %d", resp.StatusCode)
+	}
+	if r.cfg.StorageType != "xsync" {
+		t.Errorf("Expected STORAGE_TYPE to need a restart, got %s", r.cfg.StorageType)
+	}
+	for _, entry := range r.env.Entries() {
+		if entry.Key == "STORAGE_TYPE" && entry.Value != "xsync" {
+			t.Errorf("Expected STORAGE_TYPE in effect to stay xsync, got %s", entry.Value)
+		}
+	}
+}
+
+func TestReloader_RejectsInvalidFile(t *testing.T) {
+	defer applog.ResetLevels()
+	r, app, path := setupReloader(t, "LOG_LEVEL=info\n")
+
+	os.WriteFile(path, []byte("LOG_LEVEL=debug\nREAD_ONLY=true\nSHARD_COUNT=zero\n"), 0o644)
+	r.Reload()
+
+	if applog.Level() != zapcore.InfoLevel {
+		t.Errorf("Expected log level to stay info, got %v", applog.Level())
+	}
+	resp, _ := app.Test(httptest.NewRequest("POST", "/tasks", nil))
+	if resp.StatusCode != fiber.StatusCreated {
+		t.Errorf("Expected writes to stay enabled, got %d", resp.StatusCode)
+	}
+}
+
+func TestReloader_KeepsUnchangedGroups(t *testing.T) {
+	defer applog.ResetLevels()
+	r, _, path := setupReloader(t, "LOG_LEVEL=info\n")
+
+	// A level set through the admin API survives a reload that doesn't touch LOG_*
+	applog.SetLevel(zapcore.ErrorLevel)
+	os.WriteFile(path, []byte("LOG_LEVEL=info\nMAX_INFLIGHT_REQUESTS=10\n"), 0o644)
+	r.Reload()
+
+	if applog.Level() != zapcore.ErrorLevel {
+		t.Errorf("Expected admin log level kept, got %v", applog.Level())
+	}
+	if r.cfg.MaxInFlight != 10 {
+		t.Errorf("Expected MAX_INFLIGHT_REQUESTS applied, got %d", r.cfg.MaxInFlight)
+	}
+}
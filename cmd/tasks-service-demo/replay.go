@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"tasks-service-demo/internal/bench"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/backends"
+	"tasks-service-demo/internal/workload"
+)
+
+// runReplay implements `tasks-service-demo replay`: it re-drives a trace
+// recorded with WORKLOAD_RECORD_FILE against a fresh store of each given
+// in-memory backend, at the recorded pace, faster, or as fast as possible,
+// and prints one result per backend. It returns the process exit code.
+func runReplay(args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	flags.SetOutput(out)
+	tracePath := flags.String("trace", "", "trace file recorded with WORKLOAD_RECORD_FILE")
+	storages := flags.String("storage", backends.Default, "comma-separated in-memory storage backends to replay against, or all")
+	shards := flags.Int("shards", 32, "shard count of sharded backends")
+	dataset := flags.Int("dataset", 0, "tasks in the store; the highest recorded task ID when 0")
+	workers := flags.Int("workers", 0, "concurrent workers; GOMAXPROCS when 0")
+	speed := flags.Float64("speed", 1, "replay speed: 1 is the recorded pace, 10 ten times faster, 0 as fast as possible")
+	asJSON := flags.Bool("json", false, "print one JSON result per line")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *tracePath == "" {
+		fmt.Fprintln(out, "-trace is required")
+		return 2
+	}
+	if *speed < 0 || *dataset < 0 {
+		fmt.Fprintln(out, "speed and dataset must not be negative")
+		return 2
+	}
+	names, ok := benchStorages(*storages, out)
+	if !ok {
+		return 2
+	}
+
+	records, err := workload.LoadTrace(*tracePath)
+	if err != nil {
+		fmt.Fprintf(out, "%s: %v\n", *tracePath, err)
+		return 1
+	}
+	trace, err := bench.NewTrace(strings.TrimSuffix(filepath.Base(*tracePath), filepath.Ext(*tracePath)), records)
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return 1
+	}
+	if *dataset == 0 {
+		*dataset = trace.Dataset()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cfg := bench.ReplayConfig{Dataset: *dataset, Workers: *workers, Speed: *speed}
+	for _, name := range names {
+		result, err := replayOn(ctx, name, *shards, trace, cfg)
+		if err != nil {
+			fmt.Fprintf(out, "%s: %v\n", name, err)
+			return 1
+		}
+		if *asJSON {
+			line, _ := json.Marshal(result)
+			fmt.Fprintln(out, string(line))
+		} else {
+			fmt.Fprintf(out, "%-8s %s\n", name, result.String())
+		}
+	}
+	return 0
+}
+
+// replayOn replays trace on a new populated store of the backend name
+func replayOn(ctx context.Context, name string, shards int, trace *bench.Trace, cfg bench.ReplayConfig) (bench.Result, error) {
+	backend, _ := storage.Lookup(name)
+	store, err := backend.New(storage.Options{ShardCount: shards})
+	if err != nil {
+		return bench.Result{}, err
+	}
+	defer bench.Close(store)
+
+	if err := bench.Populate(store, cfg.Dataset, nil); err != nil {
+		return bench.Result{}, err
+	}
+	return bench.Replay(ctx, store, trace, cfg)
+}
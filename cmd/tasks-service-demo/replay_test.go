@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/bench"
+	"tasks-service-demo/internal/workload"
+)
+
+func TestRunReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkout.jsonl")
+	var trace bytes.Buffer
+	start := time.Now()
+	for i, op := range []string{workload.OpCreate, workload.OpGet, workload.OpUpdate, workload.OpGet, workload.OpDelete} {
+		line, _ := json.Marshal(workload.Record{Time: start.Add(time.Duration(i) * time.Millisecond), Op: op, ID: 40 * i})
+		trace.Write(append(line, '\n'))
+	}
+	if err := os.WriteFile(path, trace.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if code := runReplay([]string{"-trace", path, "-storage", "memory,xsync", "-speed", "0", "-json"}, &out); code != 0 {
+		t.Fatalf("Expected success, got %d: %s", code, out.String())
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	var result bench.Result
+	if len(lines) != 2 || json.Unmarshal([]byte(lines[1]), &result) != nil || result.Scenario != "checkout" || result.Ops != 5 || result.Errors != 0 {
+		t.Errorf("Expected one clean replay of 5 operations per backend, got %s", out.String())
+	}
+
+	for _, args := range [][]string{
+		{},
+		{"-trace", path, "-speed", "-1"},
+		{"-trace", path, "-storage", "badger"},
+	} {
+		out.Reset()
+		if code := runReplay(args, &out); code != 2 {
+			t.Errorf("Expected %v to be rejected, got %d: %s", args, code, out.String())
+		}
+	}
+	out.Reset()
+	if code := runReplay([]string{"-trace", filepath.Join(t.TempDir(), "missing.jsonl")}, &out); code != 1 {
+		t.Errorf("Expected a missing trace to fail, got %d: %s", code, out.String())
+	}
+}
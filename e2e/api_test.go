@@ -0,0 +1,240 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// task mirrors the public task representation
+type task struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Status int    `json:"status"`
+}
+
+// errorResponse mirrors errors.ErrorResponse
+type errorResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func do(t *testing.T, method, url string, body interface{}) (*http.Response, []byte) {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		switch b := body.(type) {
+		case string:
+			reader = bytes.NewBufferString(b)
+		default:
+			data, err := json.Marshal(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reader = bytes.NewBuffer(data)
+		}
+	}
+
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, data
+}
+
+func expectStatus(t *testing.T, resp *http.Response, body []byte, want int) {
+	t.Helper()
+	if resp.StatusCode != want {
+		t.Fatalf("%s %s: expected status %d, got %d: %s",
+			resp.Request.Method, resp.Request.URL.Path, want, resp.StatusCode, body)
+	}
+}
+
+// runCRUDSuite exercises the full task lifecycle against baseURL
+func runCRUDSuite(t *testing.T, baseURL string) {
+	resp, body := do(t, "POST", baseURL+"/tasks", map[string]interface{}{"name": "e2e task", "status": 0})
+	expectStatus(t, resp, body, http.StatusCreated)
+
+	var created task
+	if err := json.Unmarshal(body, &created); err != nil {
+		t.Fatal(err)
+	}
+	if created.ID == 0 || created.Name != "e2e task" {
+		t.Fatalf("unexpected created task: %+v", created)
+	}
+
+	taskURL := baseURL + "/tasks/" + strconv.Itoa(created.ID)
+
+	resp, body = do(t, "GET", taskURL, nil)
+	expectStatus(t, resp, body, http.StatusOK)
+
+	resp, body = do(t, "GET", baseURL+"/tasks", nil)
+	expectStatus(t, resp, body, http.StatusOK)
+	var all []task
+	if err := json.Unmarshal(body, &all); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, tk := range all {
+		found = found || tk.ID == created.ID
+	}
+	if !found {
+		t.Fatalf("created task %d missing from list", created.ID)
+	}
+
+	resp, body = do(t, "PUT", taskURL, map[string]interface{}{"name": "e2e updated", "status": 1})
+	expectStatus(t, resp, body, http.StatusOK)
+	var updated task
+	json.Unmarshal(body, &updated)
+	if updated.ID != created.ID || updated.Name != "e2e updated" || updated.Status != 1 {
+		t.Fatalf("unexpected updated task: %+v", updated)
+	}
+
+	resp, body = do(t, "DELETE", taskURL, nil)
+	expectStatus(t, resp, body, http.StatusNoContent)
+
+	resp, body = do(t, "GET", taskURL, nil)
+	expectStatus(t, resp, body, http.StatusNotFound)
+
+	// DELETE is idempotent
+	resp, body = do(t, "DELETE", taskURL, nil)
+	expectStatus(t, resp, body, http.StatusNoContent)
+}
+
+func TestE2E_StorageBackends(t *testing.T) {
+	if externalURL != "" {
+		runCRUDSuite(t, externalURL)
+		return
+	}
+
+	for _, storageType := range []string{"xsync", "shard", "gopool", "memory"} {
+		t.Run(storageType, func(t *testing.T) {
+			s := startServer(t, "STORAGE_TYPE="+storageType, "SHARD_COUNT=8")
+			runCRUDSuite(t, s.BaseURL)
+		})
+	}
+}
+
+func TestE2E_HealthAndVersion(t *testing.T) {
+	baseURL := targetURL(t)
+
+	resp, body := do(t, "GET", baseURL+"/health", nil)
+	expectStatus(t, resp, body, http.StatusOK)
+
+	resp, body = do(t, "GET", baseURL+"/version", nil)
+	expectStatus(t, resp, body, http.StatusOK)
+	var version map[string]interface{}
+	if err := json.Unmarshal(body, &version); err != nil {
+		t.Fatal(err)
+	}
+	if version["version"] == "" || version["version"] == nil {
+		t.Errorf("expected version field, got %s", body)
+	}
+}
+
+func TestE2E_ValidationErrors(t *testing.T) {
+	baseURL := targetURL(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		status int
+	}{
+		{"invalid json", "POST", "/tasks", "{not json", http.StatusBadRequest},
+		{"empty name", "POST", "/tasks", map[string]interface{}{"name": "", "status": 0}, http.StatusBadRequest},
+		{"invalid status", "POST", "/tasks", map[string]interface{}{"name": "x", "status": 2}, http.StatusBadRequest},
+		{"invalid id", "GET", "/tasks/abc", nil, http.StatusBadRequest},
+		{"missing task", "PUT", "/tasks/999999", map[string]interface{}{"name": "x", "status": 0}, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := do(t, tt.method, baseURL+tt.path, tt.body)
+			expectStatus(t, resp, body, tt.status)
+
+			var errResp errorResponse
+			if err := json.Unmarshal(body, &errResp); err != nil {
+				t.Fatalf("expected JSON error body, got %s", body)
+			}
+			if errResp.Code == 0 {
+				t.Errorf("expected error code in %s", body)
+			}
+		})
+	}
+}
+
+func TestE2E_GracefulShutdown(t *testing.T) {
+	if externalURL != "" {
+		t.Skip("shutdown is only tested against a locally started binary")
+	}
+
+	s := startServer(t)
+
+	// Keep clients busy while the signal arrives; every request that gets a
+	// response must be a complete, successful one.
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	failures := 0
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client := &http.Client{Timeout: 2 * time.Second}
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				resp, err := client.Post(s.BaseURL+"/tasks", "application/json",
+					bytes.NewBufferString(`{"name":"drain","status":0}`))
+				if err != nil {
+					return // Server stopped accepting connections
+				}
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusCreated {
+					mu.Lock()
+					failures++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	if err := s.Stop(10 * time.Second); err != nil {
+		t.Fatalf("expected clean exit after SIGTERM, got %v", err)
+	}
+	close(stop)
+	wg.Wait()
+
+	if failures > 0 {
+		t.Errorf("expected in-flight requests to drain successfully, got %d failures", failures)
+	}
+}
@@ -0,0 +1,10 @@
+// Package e2e contains end-to-end tests that boot the real server binary
+// (or target an already running deployment) and exercise the REST API over HTTP.
+//
+// The tests are guarded by the e2e build tag:
+//
+//	go test -tags=e2e -count=1 ./e2e/...
+//
+// Set E2E_BASE_URL (e.g. http://localhost:8080 after `docker compose up`)
+// to run the suite against an external server instead of a local binary.
+package e2e
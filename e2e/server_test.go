@@ -0,0 +1,138 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+var (
+	binaryPath  string // Server binary built once in TestMain
+	externalURL string // Optional external target from E2E_BASE_URL
+)
+
+func TestMain(m *testing.M) {
+	externalURL = os.Getenv("E2E_BASE_URL")
+	if externalURL != "" {
+		os.Exit(m.Run())
+	}
+
+	dir, err := os.MkdirTemp("", "tasks-e2e-*")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "create temp dir: %v\n", err)
+		os.Exit(1)
+	}
+
+	binaryPath = filepath.Join(dir, "tasks-service-demo")
+	build := exec.Command("go", "build", "-o", binaryPath, "../cmd/tasks-service-demo")
+	build.Stdout, build.Stderr = os.Stdout, os.Stderr
+	if err := build.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "build server: %v\n", err)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// server is a running instance of the service binary
+type server struct {
+	BaseURL string
+	cmd     *exec.Cmd
+	done    chan error
+}
+
+// freePort asks the kernel for an unused TCP port
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("find free port: %v", err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// startServer boots the binary on a random port with the given extra env
+// and waits until /health responds. The process is killed on test cleanup.
+func startServer(t *testing.T, env ...string) *server {
+	t.Helper()
+
+	port := freePort(t)
+	cmd := exec.Command(binaryPath)
+	cmd.Env = append(os.Environ(), append([]string{fmt.Sprintf("PORT=%d", port)}, env...)...)
+	if testing.Verbose() {
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start server: %v", err)
+	}
+
+	s := &server{
+		BaseURL: fmt.Sprintf("http://127.0.0.1:%d", port),
+		cmd:     cmd,
+		done:    make(chan error, 1),
+	}
+	go func() { s.done <- cmd.Wait() }()
+
+	t.Cleanup(func() {
+		if cmd.ProcessState == nil {
+			cmd.Process.Kill()
+			<-s.done
+		}
+	})
+
+	waitHealthy(t, s.BaseURL, 10*time.Second)
+	return s
+}
+
+// Stop sends SIGTERM and waits for the process to exit
+func (s *server) Stop(timeout time.Duration) error {
+	if err := s.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		return err
+	}
+	select {
+	case err := <-s.done:
+		return err
+	case <-time.After(timeout):
+		s.cmd.Process.Kill()
+		return fmt.Errorf("server did not exit within %s", timeout)
+	}
+}
+
+// targetURL returns the external URL if configured, otherwise boots a local server
+func targetURL(t *testing.T, env ...string) string {
+	t.Helper()
+	if externalURL != "" {
+		waitHealthy(t, externalURL, 30*time.Second)
+		return externalURL
+	}
+	return startServer(t, env...).BaseURL
+}
+
+func waitHealthy(t *testing.T, baseURL string, timeout time.Duration) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		resp, err := http.Get(baseURL + "/health")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("server at %s not healthy after %s", baseURL, timeout)
+}
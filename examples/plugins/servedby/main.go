@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+
+	"tasks-service-demo/internal/plugins"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Command servedby is an example plugin adding a "served-by" middleware that
+// tags every response with the host name of the replica that served it.
+//
+//	go build -buildmode=plugin -o plugins/servedby.so ./examples/plugins/servedby
+//	PLUGIN_DIR=plugins PLUGIN_MIDDLEWARE=served-by go run ./cmd/tasks-service-demo
+
+// Register is looked up by the server when the plugin is loaded
+func Register(r plugins.Registrar) error {
+	return r.RegisterMiddleware("served-by", func() (fiber.Handler, error) {
+		host, err := os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+		return func(c *fiber.Ctx) error {
+			c.Set("X-Served-By", host)
+			return c.Next()
+		}, nil
+	})
+}
+
+// main is unused; plugins are built with -buildmode=plugin
+func main() {}
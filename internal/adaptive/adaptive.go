@@ -0,0 +1,239 @@
+package adaptive
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Package adaptive limits the concurrent requests of each route to what the
+// service takes without queueing, found from the latency of completed
+// requests as Netflix's gradient limiter does. When the short-term latency
+// rises above the long-term one, requests are waiting somewhere, e.g. in the
+// worker channels of the shard backends, and the limit shrinks; while the
+// two agree it grows by a small allowance. Requests beyond the limit are
+// rejected at once instead of joining the queue.
+
+const (
+	DefaultInitialLimit = 20   // Limit of a route before any request completed
+	DefaultMinLimit     = 4    // The limit never drops below it, so a slow route still serves
+	DefaultMaxLimit     = 1000 // The limit never grows above it
+)
+
+const (
+	tolerance   = 1.5 // Rise of the short-term latency over the long-term one tolerated before the limit shrinks
+	smoothing   = 0.2 // Weight of each new limit against the current one
+	shortWindow = 10  // Samples in the short-term latency average
+	longWindow  = 600 // Samples in the long-term latency average
+	maxRoutes   = 128 // Routes with a limiter of their own; others share OtherRoute
+)
+
+// OtherRoute is the limiter shared by the routes beyond the first 128
+const OtherRoute = "other"
+
+// Config configures the limiters
+type Config struct {
+	Enabled      bool
+	InitialLimit int
+	MinLimit     int
+	MaxLimit     int
+	RetryAfter   time.Duration // Retry-After hint of rejected requests
+}
+
+// Limiter is the adaptive limit of one route
+type Limiter struct {
+	cfg Config
+
+	mu       sync.Mutex
+	limit    float64
+	inFlight int
+	shortRTT float64 // Moving averages, in seconds
+	longRTT  float64
+	samples  uint64
+	rejected uint64
+}
+
+func newLimiter(cfg Config) *Limiter {
+	return &Limiter{cfg: cfg, limit: float64(cfg.InitialLimit)}
+}
+
+// Acquire reports whether another request may start; when it may, Release
+// must be called once it completes
+func (l *Limiter) Acquire() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inFlight >= int(l.limit) {
+		l.rejected++
+		return false
+	}
+	l.inFlight++
+	return true
+}
+
+// Release ends a request that took rtt and adjusts the limit
+func (l *Limiter) Release(rtt time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	inFlight := l.inFlight // Concurrency the request ran at
+	l.inFlight--
+	l.sample(rtt.Seconds(), inFlight)
+}
+
+// sample adds a latency to the averages and applies the gradient
+func (l *Limiter) sample(rtt float64, inFlight int) {
+	l.samples++
+	if l.samples == 1 {
+		l.shortRTT, l.longRTT = rtt, rtt
+		return
+	}
+	l.shortRTT += (rtt - l.shortRTT) * 2 / (shortWindow + 1)
+	l.longRTT += (l.shortRTT - l.longRTT) * 2 / (longWindow + 1)
+	// After latency falls for good, let the long-term average follow it
+	// faster than its window would
+	if l.shortRTT > 0 && l.longRTT/l.shortRTT > 2 {
+		l.longRTT *= 0.95
+	}
+	// Well below the limit, latency says nothing about where it should be
+	if float64(inFlight) < l.limit/2 {
+		return
+	}
+
+	gradient := 1.0
+	if l.shortRTT > 0 {
+		gradient = max(0.5, min(1, tolerance*l.longRTT/l.shortRTT))
+	}
+	next := l.limit*gradient + math.Sqrt(l.limit) // The square root is the queue allowed to build
+	next = l.limit*(1-smoothing) + next*smoothing
+	l.limit = min(max(next, float64(l.cfg.MinLimit)), float64(l.cfg.MaxLimit))
+}
+
+// RouteStats is a snapshot of the limiter of one route
+type RouteStats struct {
+	Route      string  `json:"route"`
+	Limit      int     `json:"limit"`
+	InFlight   int     `json:"inFlight"`
+	ShortRTTMs float64 `json:"shortRttMs"`
+	LongRTTMs  float64 `json:"longRttMs"`
+	Samples    uint64  `json:"samples"`
+	Rejected   uint64  `json:"rejected"`
+}
+
+// Stats returns a snapshot of the limiter
+func (l *Limiter) Stats() RouteStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return RouteStats{
+		Limit:      int(l.limit),
+		InFlight:   l.inFlight,
+		ShortRTTMs: l.shortRTT * 1000,
+		LongRTTMs:  l.longRTT * 1000,
+		Samples:    l.samples,
+		Rejected:   l.rejected,
+	}
+}
+
+// Limiters holds the limiter of each route
+type Limiters struct {
+	cfg Config
+
+	mu     sync.Mutex
+	routes map[string]*Limiter
+}
+
+// New creates the limiters; zero limits in cfg take their defaults
+func New(cfg Config) *Limiters {
+	if cfg.InitialLimit <= 0 {
+		cfg.InitialLimit = DefaultInitialLimit
+	}
+	if cfg.MinLimit <= 0 {
+		cfg.MinLimit = DefaultMinLimit
+	}
+	if cfg.MaxLimit <= 0 {
+		cfg.MaxLimit = DefaultMaxLimit
+	}
+	cfg.InitialLimit = min(max(cfg.InitialLimit, cfg.MinLimit), cfg.MaxLimit)
+	return &Limiters{cfg: cfg, routes: make(map[string]*Limiter)}
+}
+
+// Enabled reports whether requests are limited
+func (ls *Limiters) Enabled() bool {
+	return ls.cfg.Enabled
+}
+
+// Config returns the configuration of the limiters
+func (ls *Limiters) Config() Config {
+	return ls.cfg
+}
+
+// For returns the limiter of route, creating it on first use
+func (ls *Limiters) For(route string) *Limiter {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if l, ok := ls.routes[route]; ok {
+		return l
+	}
+	if len(ls.routes) >= maxRoutes {
+		route = OtherRoute
+		if l, ok := ls.routes[route]; ok {
+			return l
+		}
+	}
+	l := newLimiter(ls.cfg)
+	ls.routes[route] = l
+	return l
+}
+
+// Stats returns a snapshot of every limiter, sorted by route
+func (ls *Limiters) Stats() []RouteStats {
+	ls.mu.Lock()
+	routes := make(map[string]*Limiter, len(ls.routes))
+	for route, l := range ls.routes {
+		routes[route] = l
+	}
+	ls.mu.Unlock()
+
+	stats := make([]RouteStats, 0, len(routes))
+	for route, l := range routes {
+		s := l.Stats()
+		s.Route = route
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Route < stats[j].Route })
+	return stats
+}
+
+// RouteKey names the route of a request by its method and path, with
+// numeric segments such as task IDs replaced by ":id"
+func RouteKey(method, path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if s != "" && strings.Trim(s, "0123456789") == "" {
+			segments[i] = ":id"
+		}
+	}
+	return method + " " + strings.Join(segments, "/")
+}
+
+var current atomic.Pointer[Limiters]
+
+func init() {
+	Reset()
+}
+
+// Get returns the process-wide limiters
+func Get() *Limiters {
+	return current.Load()
+}
+
+// Init replaces the process-wide limiters
+func Init(ls *Limiters) {
+	current.Store(ls)
+}
+
+// Reset installs disabled limiters
+func Reset() {
+	current.Store(New(Config{}))
+}
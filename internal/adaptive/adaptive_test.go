@@ -0,0 +1,139 @@
+package adaptive
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// drive runs rounds of requests at the limit of l, each taking rtt
+func drive(l *Limiter, rounds int, rtt time.Duration) {
+	for i := 0; i < rounds; i++ {
+		n := l.Stats().Limit
+		for j := 0; j < n; j++ {
+			l.Acquire()
+		}
+		for j := 0; j < n; j++ {
+			l.Release(rtt)
+		}
+	}
+}
+
+func TestLimiter_FollowsLatency(t *testing.T) {
+	ls := New(Config{Enabled: true, InitialLimit: 10, MaxLimit: 200})
+	l := ls.For("GET /tasks/:id")
+
+	drive(l, 20, 10*time.Millisecond)
+	grown := l.Stats().Limit
+	if grown <= 10 {
+		t.Fatalf("Expected the limit to grow at steady latency, got %d", grown)
+	}
+
+	// Requests start queueing: latency triples, so the limit shrinks until
+	// the long-term average takes the new latency as normal
+	drive(l, 1, 30*time.Millisecond)
+	if shrunk := l.Stats().Limit; shrunk >= grown {
+		t.Errorf("Expected the limit to shrink below %d as latency rose, got %d", grown, shrunk)
+	}
+	if s := l.Stats(); s.Limit < DefaultMinLimit || s.Limit > 200 {
+		t.Errorf("Expected the limit within its bounds, got %d", s.Limit)
+	}
+}
+
+func TestLimiter_RejectsAboveLimit(t *testing.T) {
+	l := New(Config{Enabled: true, InitialLimit: 4}).For("POST /tasks")
+	for i := 0; i < 4; i++ {
+		if !l.Acquire() {
+			t.Fatalf("Expected request %d admitted", i)
+		}
+	}
+	if l.Acquire() {
+		t.Error("Expected the fifth concurrent request rejected")
+	}
+	l.Release(time.Millisecond)
+	if !l.Acquire() {
+		t.Error("Expected a request admitted once one completed")
+	}
+	if s := l.Stats(); s.Rejected != 1 || s.InFlight != 4 {
+		t.Errorf("Unexpected stats %+v", s)
+	}
+}
+
+func TestLimiters_RouteCap(t *testing.T) {
+	ls := New(Config{Enabled: true})
+	for i := 0; i < maxRoutes+10; i++ {
+		ls.For(fmt.Sprintf("GET /r%d", i))
+	}
+	stats := ls.Stats()
+	if len(stats) != maxRoutes+1 {
+		t.Fatalf("Expected %d limiters including %q, got %d", maxRoutes+1, OtherRoute, len(stats))
+	}
+	if ls.For("GET /another") != ls.For(OtherRoute) {
+		t.Error("Expected routes beyond the cap to share the other limiter")
+	}
+}
+
+func TestRouteKey(t *testing.T) {
+	if got := RouteKey("GET", "/tasks/42/subtasks/7/toggle"); got != "GET /tasks/:id/subtasks/:id/toggle" {
+		t.Errorf("Unexpected route key %q", got)
+	}
+	if got := RouteKey("GET", "/tasks"); got != "GET /tasks" {
+		t.Errorf("Unexpected route key %q", got)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	ls := New(Config{Enabled: true, InitialLimit: 1, MinLimit: 1, RetryAfter: 2 * time.Second})
+	Init(ls)
+	defer Reset()
+
+	release := make(chan struct{})
+	entered := make(chan struct{})
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	app.Use(Middleware("/health"))
+	app.Get("/tasks/:id", func(c *fiber.Ctx) error {
+		if c.Params("id") == "1" {
+			close(entered)
+			<-release
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+	app.Get("/tasks", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	done := make(chan int)
+	go func() {
+		resp, err := app.Test(httptest.NewRequest("GET", "/tasks/1", nil), -1)
+		if err != nil {
+			done <- 0
+			return
+		}
+		done <- resp.StatusCode
+	}()
+	<-entered
+
+	// Same route: over its limit of one
+	resp, err := app.Test(httptest.NewRequest("GET", "/tasks/2", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusServiceUnavailable || resp.Header.Get(fiber.HeaderRetryAfter) != "2" {
+		t.Errorf("Expected 503 with Retry-After 2, got %d %q", resp.StatusCode, resp.Header.Get(fiber.HeaderRetryAfter))
+	}
+	// Another route has a limit of its own
+	if resp, _ := app.Test(httptest.NewRequest("GET", "/tasks", nil)); resp.StatusCode != fiber.StatusOK {
+		t.Errorf("Expected another route served, got %d", resp.StatusCode)
+	}
+
+	close(release)
+	if status := <-done; status != fiber.StatusOK {
+		t.Errorf("Expected the held request to complete, got %d", status)
+	}
+	if s := ls.For("GET /tasks/:id").Stats(); s.Rejected != 1 || s.InFlight != 0 {
+		t.Errorf("Unexpected stats %+v", s)
+	}
+}
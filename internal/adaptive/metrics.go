@@ -0,0 +1,39 @@
+package adaptive
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collector exports the process-wide limiters as Prometheus metrics
+type collector struct {
+	limit, inFlight, rejected *prometheus.Desc
+}
+
+// NewCollector returns a Prometheus collector for the limit, requests in
+// flight and rejections of each route. Register it once.
+func NewCollector(namespace string) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "concurrency", name), help, []string{"route"}, nil)
+	}
+	return &collector{
+		limit:    desc("limit", "Adaptive concurrency limit of the route."),
+		inFlight: desc("in_flight", "Requests of the route in flight."),
+		rejected: desc("rejected_total", "Requests of the route rejected above its limit."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.limit
+	ch <- c.inFlight
+	ch <- c.rejected
+}
+
+// Collect implements prometheus.Collector
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range Get().Stats() {
+		ch <- prometheus.MustNewConstMetric(c.limit, prometheus.GaugeValue, float64(s.Limit), s.Route)
+		ch <- prometheus.MustNewConstMetric(c.inFlight, prometheus.GaugeValue, float64(s.InFlight), s.Route)
+		ch <- prometheus.MustNewConstMetric(c.rejected, prometheus.CounterValue, float64(s.Rejected), s.Route)
+	}
+}
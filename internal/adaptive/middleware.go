@@ -0,0 +1,38 @@
+package adaptive
+
+import (
+	"strings"
+	"time"
+
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Middleware holds each route to the limit of its limiter, and rejects the
+// requests beyond it with 503, Retry-After and the retryable ErrOverloaded.
+// Requests under any of the skip prefixes are never limited.
+func Middleware(skip ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ls := Get()
+		if !ls.Enabled() {
+			return c.Next()
+		}
+		path := c.Path()
+		for _, prefix := range skip {
+			if strings.HasPrefix(path, prefix) {
+				return c.Next()
+			}
+		}
+
+		l := ls.For(RouteKey(c.Method(), path))
+		if !l.Acquire() {
+			middleware.SetRetryAfter(c, ls.Config().RetryAfter)
+			return apperrors.ErrOverloaded
+		}
+		start := time.Now()
+		defer func() { l.Release(time.Since(start)) }()
+		return c.Next()
+	}
+}
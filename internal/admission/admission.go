@@ -0,0 +1,246 @@
+package admission
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Package admission decides which requests to serve when the service is
+// overloaded. Load is the higher of the in-flight requests over a capacity
+// and the recent latency over a target; as it rises, requests are shed by
+// priority, listings and exports first and writes last. Priorities come
+// from the route and may be raised or lowered by the tier of the API key.
+
+// Priority orders requests for shedding; lower ones are shed first
+type Priority int
+
+const (
+	PriorityLow    Priority = iota // Listings, change feeds and admin reads
+	PriorityNormal                 // Reads of single tasks and everything else
+	PriorityHigh                   // Writes
+)
+
+// Priorities lists every priority, lowest first
+var Priorities = []Priority{PriorityLow, PriorityNormal, PriorityHigh}
+
+// shedAt is the load from which requests of each priority are shed
+var shedAt = [...]float64{PriorityLow: 0.5, PriorityNormal: 0.8, PriorityHigh: 1}
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	}
+	return fmt.Sprintf("Priority(%d)", int(p))
+}
+
+// ParsePriority converts a configuration value into a Priority
+func ParsePriority(s string) (Priority, error) {
+	for _, p := range Priorities {
+		if strings.EqualFold(strings.TrimSpace(s), p.String()) {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown tier %q (want low, normal or high)", s)
+}
+
+// ParseKeyTiers parses a comma-separated list of apikeyID:tier pairs. Key
+// IDs are the non-secret identifiers listed by GET /admin/usage.
+func ParseKeyTiers(s string) (map[string]Priority, error) {
+	tiers := make(map[string]Priority)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, tier, ok := strings.Cut(pair, ":")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid key tier entry %q (want keyID:tier)", pair)
+		}
+		p, err := ParsePriority(tier)
+		if err != nil {
+			return nil, err
+		}
+		tiers[key] = p
+	}
+	return tiers, nil
+}
+
+// Adjust applies the tier of an API key to the priority of a route: a high
+// tier raises it one level, a low tier lowers it one level
+func (p Priority) Adjust(tier Priority) Priority {
+	return min(max(p+tier-PriorityNormal, PriorityLow), PriorityHigh)
+}
+
+// DefaultLatencyTarget is the recent latency that counts as full load
+const DefaultLatencyTarget = 500 * time.Millisecond
+
+// latencyAlpha is the weight of each completed request in the latency average
+const latencyAlpha = 0.2
+
+// Config configures a Controller
+type Config struct {
+	Capacity      int                 // Concurrent requests at full load; 0 disables the controller
+	LatencyTarget time.Duration       // Recent latency at full load; 0 only counts requests
+	RetryAfter    time.Duration       // Retry-After hint of shed requests
+	KeyTiers      map[string]Priority // API key ID -> tier; other callers are normal
+}
+
+// Stats is a snapshot of a Controller
+type Stats struct {
+	Enabled         bool               `json:"enabled"`
+	Capacity        int                `json:"capacity"`
+	InFlight        int64              `json:"inFlight"`
+	LatencyMs       float64            `json:"latencyMs"` // Recent average of completed requests
+	LatencyTargetMs float64            `json:"latencyTargetMs,omitempty"`
+	Load            float64            `json:"load"`     // 1 is full load
+	Admitted        map[string]uint64  `json:"admitted"` // By priority
+	Shed            map[string]uint64  `json:"shed"`     // By priority
+	ShedAt          map[string]float64 `json:"shedAt"`   // Load from which each priority is shed
+}
+
+// Controller admits or sheds requests by priority
+type Controller struct {
+	cfg Config
+	now func() time.Time
+
+	inFlight atomic.Int64
+	admitted [len(shedAt)]atomic.Uint64
+	shed     [len(shedAt)]atomic.Uint64
+
+	mu      sync.Mutex
+	latency float64   // Moving average, in seconds
+	lastAt  time.Time // Completion of the last request averaged
+}
+
+// New creates a Controller; a zero Capacity admits every request
+func New(cfg Config) *Controller {
+	return &Controller{cfg: cfg, now: time.Now}
+}
+
+// Enabled reports whether requests may be shed
+func (c *Controller) Enabled() bool {
+	return c.cfg.Capacity > 0
+}
+
+// Config returns the configuration of the controller
+func (c *Controller) Config() Config {
+	return c.cfg
+}
+
+// Tier returns the tier of an API key
+func (c *Controller) Tier(key string) Priority {
+	if tier, ok := c.cfg.KeyTiers[key]; ok {
+		return tier
+	}
+	return PriorityNormal
+}
+
+// staleAfter is how long the latency average lasts without completions
+func (c *Controller) staleAfter() time.Duration {
+	return max(c.cfg.LatencyTarget, time.Second)
+}
+
+// recentLatency returns the latency average, or 0 once no request has
+// completed for a while: with everything shed nothing completes, and a
+// stale average would keep shedding forever
+func (c *Controller) recentLatency() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.lastAt.IsZero() || c.now().Sub(c.lastAt) > c.staleAfter() {
+		return 0
+	}
+	return time.Duration(c.latency * float64(time.Second))
+}
+
+// load returns the load given inFlight requests
+func (c *Controller) load(inFlight int64) float64 {
+	load := float64(inFlight) / float64(c.cfg.Capacity)
+	if c.cfg.LatencyTarget > 0 {
+		load = max(load, float64(c.recentLatency())/float64(c.cfg.LatencyTarget))
+	}
+	return load
+}
+
+// Admit reports whether a request of priority p is served. When it is, done
+// must be called once the request completes.
+func (c *Controller) Admit(p Priority) (done func(), ok bool) {
+	if !c.Enabled() {
+		return func() {}, true
+	}
+	if c.load(c.inFlight.Load()) >= shedAt[p] {
+		c.shed[p].Add(1)
+		return nil, false
+	}
+	c.inFlight.Add(1)
+	c.admitted[p].Add(1)
+	start := c.now()
+	return func() {
+		c.inFlight.Add(-1)
+		c.observe(c.now().Sub(start))
+	}, true
+}
+
+// observe adds the latency of a completed request to the average
+func (c *Controller) observe(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if c.lastAt.IsZero() || now.Sub(c.lastAt) > c.staleAfter() {
+		c.latency = d.Seconds() // Start over after an idle spell
+	} else {
+		c.latency += latencyAlpha * (d.Seconds() - c.latency)
+	}
+	c.lastAt = now
+}
+
+// Stats returns a snapshot of the controller
+func (c *Controller) Stats() Stats {
+	s := Stats{
+		Enabled:         c.Enabled(),
+		Capacity:        c.cfg.Capacity,
+		InFlight:        c.inFlight.Load(),
+		LatencyTargetMs: float64(c.cfg.LatencyTarget) / float64(time.Millisecond),
+		Admitted:        make(map[string]uint64, len(Priorities)),
+		Shed:            make(map[string]uint64, len(Priorities)),
+		ShedAt:          make(map[string]float64, len(Priorities)),
+	}
+	s.LatencyMs = float64(c.recentLatency()) / float64(time.Millisecond)
+	if s.Enabled {
+		s.Load = c.load(s.InFlight)
+	}
+	for _, p := range Priorities {
+		s.Admitted[p.String()] = c.admitted[p].Load()
+		s.Shed[p.String()] = c.shed[p].Load()
+		s.ShedAt[p.String()] = shedAt[p]
+	}
+	return s
+}
+
+var current atomic.Pointer[Controller]
+
+func init() {
+	Reset()
+}
+
+// Get returns the process-wide Controller
+func Get() *Controller {
+	return current.Load()
+}
+
+// Init replaces the process-wide Controller
+func Init(c *Controller) {
+	current.Store(c)
+}
+
+// Reset installs a Controller that admits every request
+func Reset() {
+	current.Store(New(Config{}))
+}
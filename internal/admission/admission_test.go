@@ -0,0 +1,181 @@
+package admission
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newController returns a Controller whose clock reads *now
+func newController(cfg Config, now *time.Time) *Controller {
+	c := New(cfg)
+	c.now = func() time.Time { return *now }
+	return c
+}
+
+func TestController_ShedsByPriority(t *testing.T) {
+	now := time.Now()
+	ctl := newController(Config{Capacity: 10}, &now)
+
+	var done []func()
+	admit := func(p Priority) bool {
+		d, ok := ctl.Admit(p)
+		if ok {
+			done = append(done, d)
+		}
+		return ok
+	}
+
+	for i := 0; i < 5; i++ {
+		if !admit(PriorityLow) {
+			t.Fatalf("Expected low priority request %d admitted below half load", i)
+		}
+	}
+	if admit(PriorityLow) {
+		t.Error("Expected low priority shed at half load")
+	}
+	for i := 0; i < 3; i++ {
+		if !admit(PriorityNormal) {
+			t.Fatalf("Expected normal priority request %d admitted", i)
+		}
+	}
+	if admit(PriorityNormal) {
+		t.Error("Expected normal priority shed at 0.8 load")
+	}
+	for i := 0; i < 2; i++ {
+		if !admit(PriorityHigh) {
+			t.Fatalf("Expected high priority request %d admitted", i)
+		}
+	}
+	if admit(PriorityHigh) {
+		t.Error("Expected high priority shed at full load")
+	}
+
+	s := ctl.Stats()
+	if s.InFlight != 10 || s.Shed["low"] != 1 || s.Shed["normal"] != 1 || s.Shed["high"] != 1 || s.Admitted["low"] != 5 {
+		t.Errorf("Unexpected stats %+v", s)
+	}
+
+	for _, d := range done {
+		d()
+	}
+	if !admit(PriorityLow) {
+		t.Error("Expected low priority admitted once requests completed")
+	}
+}
+
+func TestController_LatencyPressure(t *testing.T) {
+	now := time.Now()
+	ctl := newController(Config{Capacity: 100, LatencyTarget: 100 * time.Millisecond}, &now)
+
+	done, _ := ctl.Admit(PriorityHigh)
+	now = now.Add(90 * time.Millisecond)
+	done()
+
+	if _, ok := ctl.Admit(PriorityNormal); ok {
+		t.Error("Expected normal priority shed with latency at 0.9 of the target")
+	}
+	d, ok := ctl.Admit(PriorityHigh)
+	if !ok {
+		t.Fatal("Expected high priority admitted below the latency target")
+	}
+	d()
+
+	// Without completions the average goes stale, so shedding stops
+	now = now.Add(2 * time.Second)
+	if _, ok := ctl.Admit(PriorityLow); !ok {
+		t.Error("Expected the stale latency average to be ignored")
+	}
+}
+
+func TestParseKeyTiers(t *testing.T) {
+	tiers, err := ParseKeyTiers("9f86d081:high, abcd1234:LOW")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tiers["9f86d081"] != PriorityHigh || tiers["abcd1234"] != PriorityLow {
+		t.Errorf("Unexpected tiers %v", tiers)
+	}
+	if _, err := ParseKeyTiers("9f86d081:gold"); err == nil {
+		t.Error("Expected an unknown tier rejected")
+	}
+	if _, err := ParseKeyTiers("9f86d081"); err == nil {
+		t.Error("Expected an entry without tier rejected")
+	}
+
+	if got := PriorityLow.Adjust(PriorityHigh); got != PriorityNormal {
+		t.Errorf("Expected a high tier to raise low to normal, got %s", got)
+	}
+	if got := PriorityHigh.Adjust(PriorityHigh); got != PriorityHigh {
+		t.Errorf("Expected high to stay high, got %s", got)
+	}
+	if got := PriorityLow.Adjust(PriorityLow); got != PriorityLow {
+		t.Errorf("Expected low to stay low, got %s", got)
+	}
+}
+
+func TestRoutePriority(t *testing.T) {
+	tests := []struct {
+		method, path string
+		want         Priority
+	}{
+		{fiber.MethodGet, "/tasks", PriorityLow},
+		{fiber.MethodGet, "/tasks/changes", PriorityLow},
+		{fiber.MethodGet, "/admin/dashboard/stats", PriorityLow},
+		{fiber.MethodGet, "/tasks/42", PriorityNormal},
+		{fiber.MethodPost, middleware.BatchGetPath, PriorityNormal},
+		{fiber.MethodPost, "/tasks", PriorityHigh},
+		{fiber.MethodDelete, "/tasks", PriorityHigh},
+	}
+	for _, tt := range tests {
+		if got := RoutePriority(tt.method, tt.path); got != tt.want {
+			t.Errorf("%s %s: expected %s, got %s", tt.method, tt.path, tt.want, got)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	now := time.Now()
+	ctl := newController(Config{Capacity: 2, RetryAfter: 3 * time.Second}, &now)
+	Init(ctl)
+	defer Reset()
+
+	// One request in flight is half the capacity: listings are shed
+	hold, _ := ctl.Admit(PriorityHigh)
+	defer hold()
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	app.Use(Middleware("/health"))
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/tasks", ok)
+	app.Get("/tasks/:id", ok)
+	app.Get("/health", ok)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/tasks", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusServiceUnavailable || resp.Header.Get(fiber.HeaderRetryAfter) != "3" {
+		t.Errorf("Expected the listing shed with Retry-After 3, got %d %q", resp.StatusCode, resp.Header.Get(fiber.HeaderRetryAfter))
+	}
+	var errResp apperrors.ErrorResponse
+	json.NewDecoder(resp.Body).Decode(&errResp)
+	if errResp.Code != apperrors.ErrCodeOverloaded || !errResp.Retryable {
+		t.Errorf("Expected retryable error %d, got %+v", apperrors.ErrCodeOverloaded, errResp)
+	}
+	if resp, _ := app.Test(httptest.NewRequest("GET", "/tasks/1", nil)); resp.StatusCode != fiber.StatusOK {
+		t.Errorf("Expected a single read admitted, got %d", resp.StatusCode)
+	}
+	if resp, _ := app.Test(httptest.NewRequest("GET", "/health", nil)); resp.StatusCode != fiber.StatusOK {
+		t.Errorf("Expected probes never shed, got %d", resp.StatusCode)
+	}
+	if s := ctl.Stats(); s.Shed["low"] != 1 {
+		t.Errorf("Expected one low priority request shed, got %+v", s.Shed)
+	}
+}
@@ -0,0 +1,44 @@
+package admission
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collector exports the process-wide Controller as Prometheus metrics
+type collector struct {
+	admitted, shed *prometheus.Desc
+	inFlight, load *prometheus.Desc
+}
+
+// NewCollector returns a Prometheus collector for the requests admitted and
+// shed by priority. Register it once.
+func NewCollector(namespace string) prometheus.Collector {
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "admission", name), help, labels, nil)
+	}
+	return &collector{
+		admitted: desc("admitted_total", "Requests admitted by the admission controller.", "priority"),
+		shed:     desc("shed_total", "Requests shed with 503 by the admission controller.", "priority"),
+		inFlight: desc("in_flight", "Admitted requests not yet completed."),
+		load:     desc("load", "Load seen by the admission controller; 1 is full load."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.admitted
+	ch <- c.shed
+	ch <- c.inFlight
+	ch <- c.load
+}
+
+// Collect implements prometheus.Collector
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	s := Get().Stats()
+	for _, p := range Priorities {
+		ch <- prometheus.MustNewConstMetric(c.admitted, prometheus.CounterValue, float64(s.Admitted[p.String()]), p.String())
+		ch <- prometheus.MustNewConstMetric(c.shed, prometheus.CounterValue, float64(s.Shed[p.String()]), p.String())
+	}
+	ch <- prometheus.MustNewConstMetric(c.inFlight, prometheus.GaugeValue, float64(s.InFlight))
+	ch <- prometheus.MustNewConstMetric(c.load, prometheus.GaugeValue, s.Load)
+}
@@ -0,0 +1,78 @@
+package admission
+
+import (
+	"strings"
+
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// lowPriorityReads are the read paths shed first: listings that scan the
+// store and long polls that hold a request open
+var lowPriorityReads = map[string]bool{
+	"/tasks":             true,
+	"/tasks/archive":     true,
+	"/tasks/changes":     true,
+	"/sync":              true,
+	"/cdc":               true,
+	"/admin/usage":       true,
+	"/admin/projections": true,
+	"/admin/slowlog":     true,
+	"/admin/jobs":        true,
+	"/admin/loadtest":    true,
+}
+
+// RoutePriority returns the priority of a request from its method and path:
+// writes are high, listings and admin reads low, other reads normal
+func RoutePriority(method, path string) Priority {
+	switch method {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+	case fiber.MethodPost:
+		if path == middleware.BatchGetPath {
+			return PriorityNormal // A read, see middleware.BatchGetPath
+		}
+		return PriorityHigh
+	default:
+		return PriorityHigh
+	}
+	if lowPriorityReads[strings.TrimSuffix(path, "/")] || strings.HasPrefix(path, middleware.DashboardPathPrefix) {
+		return PriorityLow
+	}
+	return PriorityNormal
+}
+
+// Middleware admits requests through the process-wide Controller, and
+// rejects those it sheds with 503, Retry-After and the retryable
+// ErrOverloaded. It must run after middleware.Authenticate, which resolves
+// the API key whose tier adjusts the priority of the route. Requests under
+// any of the skip prefixes are never shed.
+func Middleware(skip ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctl := Get()
+		if !ctl.Enabled() {
+			return c.Next()
+		}
+		path := c.Path()
+		for _, prefix := range skip {
+			if strings.HasPrefix(path, prefix) {
+				return c.Next()
+			}
+		}
+
+		priority := RoutePriority(c.Method(), path)
+		if principal, ok := middleware.GetPrincipal(c); ok {
+			if key, ok := principal.APIKeyID(); ok {
+				priority = priority.Adjust(ctl.Tier(key))
+			}
+		}
+		done, ok := ctl.Admit(priority)
+		if !ok {
+			middleware.SetRetryAfter(c, ctl.Config().RetryAfter)
+			return apperrors.ErrOverloaded
+		}
+		defer done()
+		return c.Next()
+	}
+}
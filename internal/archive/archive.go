@@ -0,0 +1,405 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tasks-service-demo/internal/crypt"
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/jobs"
+	"tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/storage"
+)
+
+// Package archive moves tasks that were completed long ago out of the task
+// store into an archive file, so they no longer show up in listings, and
+// brings them back on request.
+
+// DefaultInterval is the archival job period when none is configured
+const DefaultInterval = time.Hour
+
+// clocksKey is the storage.MetaStore record holding the completion clocks
+const clocksKey = "archive-completed"
+
+// ErrNotFound is returned when unarchiving an ID that is not archived
+var ErrNotFound = errors.New("task is not archived")
+
+// Config is the archival policy
+type Config struct {
+	After    time.Duration     // Tasks completed longer ago are archived; 0 disables archival
+	Interval time.Duration     // Period of the archival job
+	Path     string            // JSON file the archive is persisted to; empty keeps it in memory
+	Sealer   *crypt.Sealer     // Encrypts the file; nil writes plain JSON
+	Meta     storage.MetaStore // Saves the completion clocks; nil keeps them in memory
+}
+
+// Entry is an archived task
+type Entry struct {
+	Task        entities.Task `json:"task"`
+	CompletedAt time.Time     `json:"completedAt"`
+	ArchivedAt  time.Time     `json:"archivedAt"`
+}
+
+// Archive holds archived tasks and the completion time of the completed
+// tasks still in the store. Completion times are saved in cfg.Meta by each
+// archival run and at shutdown; tasks completed with no saved time count as
+// completed when first seen.
+type Archive struct {
+	cfg Config
+	now func() time.Time
+
+	mu          sync.Mutex
+	entries     map[int]Entry
+	completed   map[int]time.Time
+	clocksDirty bool // completed changed since it was last saved
+}
+
+// Open creates an Archive, loading the entries saved at cfg.Path if the file exists
+func Open(cfg Config) (*Archive, error) {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	a := &Archive{
+		cfg:       cfg,
+		now:       time.Now,
+		entries:   make(map[int]Entry),
+		completed: make(map[int]time.Time),
+	}
+	if err := a.loadClocks(); err != nil {
+		return nil, err
+	}
+	if cfg.Path == "" {
+		return a, nil
+	}
+
+	data, err := os.ReadFile(cfg.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return a, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if data, err = cfg.Sealer.Open(data, nil); err != nil {
+		return nil, fmt.Errorf("decrypt %s: %w", cfg.Path, err)
+	}
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", cfg.Path, err)
+	}
+	for _, e := range entries {
+		a.entries[e.Task.ID] = e
+	}
+	return a, nil
+}
+
+// New creates an in-memory Archive
+func New(cfg Config) *Archive {
+	cfg.Path = ""
+	a, _ := Open(cfg)
+	return a
+}
+
+// Enabled reports whether completed tasks are archived
+func (a *Archive) Enabled() bool {
+	return a.cfg.After > 0
+}
+
+// Config returns the archival policy
+func (a *Archive) Config() Config {
+	return a.cfg
+}
+
+// observe starts or stops the completion clock of a written task
+func (a *Archive) observe(task *entities.Task) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if task.Status != 1 {
+		a.stopClockLocked(task.ID)
+		return
+	}
+	if _, ok := a.completed[task.ID]; !ok {
+		a.completed[task.ID] = a.now()
+		a.clocksDirty = true
+	}
+}
+
+// forget drops the completion clock of a deleted task
+func (a *Archive) forget(id int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.stopClockLocked(id)
+}
+
+func (a *Archive) stopClockLocked(id int) {
+	if _, ok := a.completed[id]; ok {
+		delete(a.completed, id)
+		a.clocksDirty = true
+	}
+}
+
+// loadClocks restores the completion clocks saved by earlier processes
+func (a *Archive) loadClocks() error {
+	if a.cfg.Meta == nil {
+		return nil
+	}
+	data, ok, err := a.cfg.Meta.GetMeta(clocksKey)
+	if err != nil || !ok {
+		return err
+	}
+	if err := json.Unmarshal(data, &a.completed); err != nil {
+		return fmt.Errorf("parse saved completion times: %w", err)
+	}
+	return nil
+}
+
+// SaveClocks writes the completion clocks to cfg.Meta when they changed
+// since the last save
+func (a *Archive) SaveClocks() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.saveClocksLocked()
+}
+
+func (a *Archive) saveClocksLocked() error {
+	if a.cfg.Meta == nil || !a.clocksDirty {
+		return nil
+	}
+	data, err := json.Marshal(a.completed)
+	if err != nil {
+		return err
+	}
+	if err := a.cfg.Meta.PutMeta(clocksKey, data); err != nil {
+		return err
+	}
+	a.clocksDirty = false
+	return nil
+}
+
+// List returns the archived tasks, most recently archived first
+func (a *Archive) List() []Entry {
+	a.mu.Lock()
+	out := make([]Entry, 0, len(a.entries))
+	for _, e := range a.entries {
+		out = append(out, e)
+	}
+	a.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].ArchivedAt.Equal(out[j].ArchivedAt) {
+			return out[i].ArchivedAt.After(out[j].ArchivedAt)
+		}
+		return out[i].Task.ID < out[j].Task.ID
+	})
+	return out
+}
+
+// Get returns the archived task with id
+func (a *Archive) Get(id int) (Entry, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	e, ok := a.entries[id]
+	return e, ok
+}
+
+// Run archives the tasks of store completed more than After ago: each is
+// written to the archive first and then deleted from store. It returns how
+// many tasks were archived.
+func (a *Archive) Run(ctx context.Context, store storage.Store) (int, error) {
+	tasks := store.GetAll()
+	now := a.now()
+	cutoff := now.Add(-a.cfg.After)
+
+	var due []Entry
+	a.mu.Lock()
+	for _, task := range tasks {
+		if task.Status != 1 {
+			continue
+		}
+		completedAt, ok := a.completed[task.ID]
+		if !ok {
+			a.completed[task.ID] = now
+			a.clocksDirty = true
+			continue
+		}
+		if completedAt.After(cutoff) {
+			continue
+		}
+		e := Entry{Task: *task, CompletedAt: completedAt, ArchivedAt: now}
+		a.entries[task.ID] = e
+		due = append(due, e)
+	}
+	err := a.saveLocked()
+	if clocksErr := a.saveClocksLocked(); err == nil {
+		err = clocksErr
+	}
+	a.mu.Unlock()
+	if err != nil || len(due) == 0 {
+		return 0, err
+	}
+
+	archived := 0
+	var dropped []int
+	for i, e := range due {
+		if ctx.Err() != nil {
+			for _, rest := range due[i:] {
+				dropped = append(dropped, rest.Task.ID)
+			}
+			err = ctx.Err()
+			break
+		}
+		if removeErr := a.remove(store, e.Task); removeErr != nil {
+			logger.For(logger.ModuleJobs).Warnw("Task not archived", "id", e.Task.ID, "error", removeErr)
+			dropped = append(dropped, e.Task.ID)
+			continue
+		}
+		archived++
+	}
+
+	// Tasks that weren't deleted from the store leave the archive again
+	a.mu.Lock()
+	if len(dropped) > 0 {
+		for _, id := range dropped {
+			delete(a.entries, id)
+		}
+		if saveErr := a.saveLocked(); err == nil {
+			err = saveErr
+		}
+	}
+	// The clocks of the archived tasks were dropped by their deletes
+	if saveErr := a.saveClocksLocked(); err == nil {
+		err = saveErr
+	}
+	a.mu.Unlock()
+	return archived, err
+}
+
+// remove deletes the archived task from store, unless any of it, subtasks
+// included, changed since it was copied into the archive
+func (a *Archive) remove(store storage.Store, archived entities.Task) error {
+	current, appErr := store.GetByID(archived.ID)
+	if appErr != nil {
+		return appErr
+	}
+	if !current.Equal(&archived) {
+		return fmt.Errorf("task %d changed while being archived", archived.ID)
+	}
+	if appErr := store.Delete(archived.ID); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// Restorer writes an unarchived task back to the task store and returns it
+// as stored
+type Restorer func(task entities.Task) (*entities.Task, error)
+
+// Unarchive takes task id out of the archive and hands it to restore. The
+// entry is put back if restore fails.
+func (a *Archive) Unarchive(id int, restore Restorer) (*entities.Task, error) {
+	a.mu.Lock()
+	e, ok := a.entries[id]
+	delete(a.entries, id)
+	a.mu.Unlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	task, err := restore(e.Task)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err != nil {
+		a.entries[id] = e
+		return nil, err
+	}
+	if err := a.saveLocked(); err != nil {
+		// The task is back in the store; a stale archive file only means it
+		// would be listed as archived again after a restart
+		logger.For(logger.ModuleStorage).Errorw("Failed to save archive", "path", a.cfg.Path, "error", err)
+	}
+	return task, nil
+}
+
+// Job returns a jobs.Func archiving the tasks of store, or of the global
+// store if nil
+func (a *Archive) Job(store storage.Store) jobs.Func {
+	return func(ctx context.Context, p *jobs.Progress) error {
+		st := store
+		if st == nil {
+			st = storage.GetStore()
+		}
+		n, err := a.Run(ctx, st)
+		p.SetMessage(fmt.Sprintf("archived %d tasks completed more than %s ago", n, a.cfg.After))
+		logger.For(logger.ModuleJobs).Infow("Task archival", "archived", n, "after", a.cfg.After)
+		return err
+	}
+}
+
+// Save rewrites the archive file, sealing it under the active encryption
+// key
+func (a *Archive) Save() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.saveLocked()
+}
+
+// saveLocked writes the entries to cfg.Path via a temporary file, so a
+// crash mid-write leaves the previous version intact
+func (a *Archive) saveLocked() error {
+	if a.cfg.Path == "" {
+		return nil
+	}
+	entries := make([]Entry, 0, len(a.entries))
+	for _, e := range a.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Task.ID < entries[j].Task.ID })
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	data = a.cfg.Sealer.Seal(data, nil)
+	tmp, err := os.CreateTemp(filepath.Dir(a.cfg.Path), ".archive-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), a.cfg.Path)
+}
+
+var current atomic.Pointer[Archive]
+
+func init() {
+	Reset()
+}
+
+// Get returns the process-wide Archive
+func Get() *Archive {
+	return current.Load()
+}
+
+// Init replaces the process-wide Archive
+func Init(a *Archive) {
+	current.Store(a)
+}
+
+// Reset installs an empty, disabled Archive
+func Reset() {
+	current.Store(New(Config{}))
+}
@@ -0,0 +1,217 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/crypt"
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage/storetest"
+)
+
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func newTestArchive(t *testing.T, cfg Config) (*Store, *Archive, *clock) {
+	t.Helper()
+	a, err := Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &clock{t: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)}
+	a.now = c.now
+	inner := storetest.NewFakeStore(&entities.Task{ID: 1, Name: "old", Status: 1}, &entities.Task{ID: 2, Name: "open"})
+	return NewStore(inner, a), a, c
+}
+
+func TestArchive_RunMovesCompletedTasks(t *testing.T) {
+	store, a, clk := newTestArchive(t, Config{After: 24 * time.Hour})
+	ctx := context.Background()
+
+	// Task 1 was completed before startup: its clock starts on the first run
+	if n, err := a.Run(ctx, store); n != 0 || err != nil {
+		t.Errorf("Expected nothing archived on the first run, got %d, %v", n, err)
+	}
+	clk.t = clk.t.Add(12 * time.Hour)
+	store.Create(&entities.Task{Name: "fresh", Status: 1})
+	store.Update(2, &entities.Task{ID: 2, Name: "open"})
+
+	clk.t = clk.t.Add(13 * time.Hour)
+	n, err := a.Run(ctx, store)
+	if n != 1 || err != nil {
+		t.Fatalf("Expected 1 task archived, got %d, %v", n, err)
+	}
+	if _, err := store.GetByID(1); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected archived task to leave the store, got %v", err)
+	}
+	if len(store.GetAll()) != 2 {
+		t.Errorf("Expected the open and recently completed tasks to stay, got %+v", store.GetAll())
+	}
+	entries := a.List()
+	if len(entries) != 1 || entries[0].Task.Name != "old" || !entries[0].ArchivedAt.Equal(clk.t) {
+		t.Errorf("Unexpected archive %+v", entries)
+	}
+}
+
+func TestArchive_ReopenedTaskStaysInStore(t *testing.T) {
+	store, a, clk := newTestArchive(t, Config{After: time.Hour})
+	ctx := context.Background()
+
+	a.Run(ctx, store)
+	store.Update(1, &entities.Task{ID: 1, Name: "old", Status: 0})
+	clk.t = clk.t.Add(2 * time.Hour)
+	store.Update(1, &entities.Task{ID: 1, Name: "old", Status: 1})
+
+	if n, _ := a.Run(ctx, store); n != 0 {
+		t.Errorf("Expected a task completed again to restart its clock, got %d archived", n)
+	}
+}
+
+func TestArchive_SubtaskEditStaysInStore(t *testing.T) {
+	store, a, _ := newTestArchive(t, Config{After: time.Hour})
+	archived, _ := store.GetByID(1)
+
+	// A checklist edit made after the task was copied into the archive
+	edited := *archived
+	edited.SetSubtasks([]entities.Subtask{{ID: 1, Name: "receipt"}})
+	store.Update(1, &edited)
+
+	if err := a.remove(store, *archived); err == nil {
+		t.Error("Expected the edited task not to be removed")
+	}
+	if task, err := store.GetByID(1); err != nil || len(task.Subtasks) != 1 {
+		t.Errorf("Expected the edited task to stay in the store, got %+v, %v", task, err)
+	}
+}
+
+type memMeta struct {
+	records map[string][]byte
+}
+
+func (m *memMeta) GetMeta(key string) ([]byte, bool, error) {
+	value, ok := m.records[key]
+	return value, ok, nil
+}
+
+func (m *memMeta) PutMeta(key string, value []byte) error {
+	m.records[key] = value
+	return nil
+}
+
+func TestArchive_ClocksSurviveRestart(t *testing.T) {
+	meta := &memMeta{records: make(map[string][]byte)}
+	store, a, clk := newTestArchive(t, Config{After: time.Hour, Meta: meta})
+	ctx := context.Background()
+	a.Run(ctx, store) // Starts the clock of task 1
+
+	// After a restart the clock keeps running from the saved time
+	restarted, err := Open(Config{After: time.Hour, Meta: meta})
+	if err != nil {
+		t.Fatal(err)
+	}
+	restarted.now = func() time.Time { return clk.t.Add(2 * time.Hour) }
+	if n, err := restarted.Run(ctx, NewStore(store.Inner(), restarted)); n != 1 || err != nil {
+		t.Errorf("Expected the task completed before the restart archived, got %d, %v", n, err)
+	}
+
+	// The clock of the archived task is dropped from the saved ones
+	again, _ := Open(Config{After: time.Hour, Meta: meta})
+	if len(again.completed) != 0 {
+		t.Errorf("Expected no saved clocks left, got %v", again.completed)
+	}
+}
+
+func TestArchive_Unarchive(t *testing.T) {
+	store, a, clk := newTestArchive(t, Config{After: time.Hour})
+	ctx := context.Background()
+	a.Run(ctx, store)
+	clk.t = clk.t.Add(2 * time.Hour)
+	a.Run(ctx, store)
+
+	restore := func(task entities.Task) (*entities.Task, error) {
+		if err := store.Create(&task); err != nil {
+			return nil, err
+		}
+		return &task, nil
+	}
+
+	if _, err := a.Unarchive(2, restore); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a task that is not archived, got %v", err)
+	}
+
+	failing := func(entities.Task) (*entities.Task, error) { return nil, errors.New("store down") }
+	if _, err := a.Unarchive(1, failing); err == nil {
+		t.Error("Expected the restore error")
+	}
+	if _, ok := a.Get(1); !ok {
+		t.Error("Expected the entry to stay archived after a failed restore")
+	}
+
+	task, err := a.Unarchive(1, restore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Name != "old" || task.Status != 1 {
+		t.Errorf("Unexpected restored task %+v", task)
+	}
+	if _, ok := a.Get(1); ok {
+		t.Error("Expected the entry to leave the archive")
+	}
+
+	// The restored task gets a fresh After window
+	clk.t = clk.t.Add(30 * time.Minute)
+	if n, _ := a.Run(ctx, store); n != 0 {
+		t.Errorf("Expected the restored task to stay, got %d archived", n)
+	}
+}
+
+func TestArchive_PersistsEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.json")
+	store, a, clk := newTestArchive(t, Config{After: time.Hour, Path: path})
+	ctx := context.Background()
+	a.Run(ctx, store)
+	clk.t = clk.t.Add(2 * time.Hour)
+	if n, err := a.Run(ctx, store); n != 1 || err != nil {
+		t.Fatalf("Expected 1 task archived, got %d, %v", n, err)
+	}
+
+	reopened, err := Open(Config{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := reopened.Get(1); !ok || e.Task.Name != "old" {
+		t.Errorf("Expected the archived task after reopening, got %+v, %v", e, ok)
+	}
+}
+
+func TestArchive_EncryptsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.json")
+	sealer, _ := crypt.New([]crypt.Key{{ID: "k1", Secret: bytes.Repeat([]byte{1}, 32)}})
+	store, a, clk := newTestArchive(t, Config{After: time.Hour, Path: path, Sealer: sealer})
+	ctx := context.Background()
+	a.Run(ctx, store)
+	clk.t = clk.t.Add(2 * time.Hour)
+	a.Run(ctx, store)
+
+	data, err := os.ReadFile(path)
+	if err != nil || bytes.Contains(data, []byte("old")) {
+		t.Errorf("Expected an encrypted archive file, got %q, %v", data, err)
+	}
+	if _, err := Open(Config{Path: path}); !errors.Is(err, crypt.ErrNoKeys) {
+		t.Errorf("Expected the file not to open without the key, got %v", err)
+	}
+	reopened, err := Open(Config{Path: path, Sealer: sealer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := reopened.Get(1); !ok || e.Task.Name != "old" {
+		t.Errorf("Expected the archived task after reopening, got %+v, %v", e, ok)
+	}
+}
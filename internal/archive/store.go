@@ -0,0 +1,68 @@
+package archive
+
+import (
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage"
+)
+
+// Store wraps a storage.Store and tells the Archive when tasks are
+// completed, reopened or deleted
+type Store struct {
+	inner   storage.Store
+	archive *Archive
+}
+
+// NewStore wraps inner, reporting writes to archive
+func NewStore(inner storage.Store, archive *Archive) *Store {
+	return &Store{inner: inner, archive: archive}
+}
+
+// Inner returns the wrapped store
+func (s *Store) Inner() storage.Store {
+	return s.inner
+}
+
+// Create writes to the inner store and starts the clock of a completed task
+func (s *Store) Create(task *entities.Task) *apperrors.AppError {
+	if err := s.inner.Create(task); err != nil {
+		return err
+	}
+	s.archive.observe(task)
+	return nil
+}
+
+// GetByID reads from the inner store
+func (s *Store) GetByID(id int) (*entities.Task, *apperrors.AppError) {
+	return s.inner.GetByID(id)
+}
+
+// GetAll reads from the inner store
+func (s *Store) GetAll() []*entities.Task {
+	return s.inner.GetAll()
+}
+
+// GetRange reads from the inner store
+func (s *Store) GetRange(fromID, toID, limit int) []*entities.Task {
+	return s.inner.GetRange(fromID, toID, limit)
+}
+
+// Update writes to the inner store and starts or stops the completion clock
+func (s *Store) Update(id int, task *entities.Task) *apperrors.AppError {
+	if err := s.inner.Update(id, task); err != nil {
+		return err
+	}
+	written := *task
+	written.ID = id
+	s.archive.observe(&written)
+	return nil
+}
+
+// Delete removes the task from the inner store
+func (s *Store) Delete(id int) *apperrors.AppError {
+	if err := s.inner.Delete(id); err != nil {
+		return err
+	}
+	s.archive.forget(id)
+	return nil
+}
@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Package auth resolves API keys and JWT bearer tokens into principals with
+// a role used for route-level access control.
+
+// Role is an access level; each role includes the permissions of those below it
+type Role string
+
+const (
+	RoleReader Role = "reader" // Read tasks
+	RoleWriter Role = "writer" // Read and modify tasks
+	RoleAdmin  Role = "admin"  // Everything, including /admin endpoints
+)
+
+var roleRank = map[Role]int{RoleReader: 1, RoleWriter: 2, RoleAdmin: 3}
+
+// ParseRole converts a configuration or claim value into a Role
+func ParseRole(s string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(s)))
+	if _, ok := roleRank[role]; !ok {
+		return "", fmt.Errorf("unknown role %q (want reader, writer or admin)", s)
+	}
+	return role, nil
+}
+
+// Allows reports whether r grants at least the required role
+func (r Role) Allows(required Role) bool {
+	return roleRank[r] >= roleRank[required]
+}
+
+// Principal is an authenticated caller
+type Principal struct {
+	Subject string `json:"subject"`
+	Role    Role   `json:"role"`
+}
+
+// apiKeySubject prefixes the Subject of principals authenticated by API key
+const apiKeySubject = "apikey:"
+
+// APIKeyID returns the non-secret identifier of the API key the principal
+// authenticated with, and false for JWT and anonymous principals
+func (p Principal) APIKeyID() (string, bool) {
+	return strings.CutPrefix(p.Subject, apiKeySubject)
+}
+
+// Anonymous is the principal used when authentication is disabled; it keeps
+// the service fully open, as before roles were introduced
+var Anonymous = Principal{Subject: "anonymous", Role: RoleAdmin}
+
+// Config configures an Authenticator
+type Config struct {
+	APIKeys   map[string]Role // API key -> role
+	JWTSecret string          // HS256 signing secret; empty disables JWT
+
+	SigningKeys     map[string]string // API key -> HMAC secret; writes with these keys must be signed
+	SignatureMaxAge time.Duration     // Accepted clock skew of signed requests; 0 uses DefaultSignatureMaxAge
+}
+
+// Authenticator validates credentials against the configured keys and secret
+type Authenticator struct {
+	keys      map[[sha256.Size]byte]Principal // Keyed by hash so lookups don't compare raw secrets
+	jwtSecret []byte
+
+	signing map[[sha256.Size]byte][]byte // API key hash -> request signing secret
+	maxAge  time.Duration
+	now     func() time.Time
+	replays replayCache
+}
+
+// New creates an Authenticator from cfg
+func New(cfg Config) *Authenticator {
+	a := &Authenticator{
+		keys:    make(map[[sha256.Size]byte]Principal, len(cfg.APIKeys)),
+		signing: make(map[[sha256.Size]byte][]byte, len(cfg.SigningKeys)),
+		maxAge:  cfg.SignatureMaxAge,
+		now:     time.Now,
+		replays: replayCache{seen: make(map[string]time.Time)},
+	}
+	for key, role := range cfg.APIKeys {
+		a.keys[sha256.Sum256([]byte(key))] = Principal{Subject: apiKeySubject + keyID(key), Role: role}
+	}
+	for key, secret := range cfg.SigningKeys {
+		a.signing[sha256.Sum256([]byte(key))] = []byte(secret)
+	}
+	if a.maxAge <= 0 {
+		a.maxAge = DefaultSignatureMaxAge
+	}
+	if cfg.JWTSecret != "" {
+		a.jwtSecret = []byte(cfg.JWTSecret)
+	}
+	return a
+}
+
+// keyID returns a short non-secret identifier for an API key, for logs
+func keyID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return fmt.Sprintf("%x", sum[:4])
+}
+
+// Enabled reports whether any credential source is configured
+func (a *Authenticator) Enabled() bool {
+	return len(a.keys) > 0 || len(a.jwtSecret) > 0
+}
+
+// AuthenticateAPIKey resolves an API key into its principal
+func (a *Authenticator) AuthenticateAPIKey(key string) (Principal, bool) {
+	p, ok := a.keys[sha256.Sum256([]byte(key))]
+	return p, ok
+}
+
+// AuthenticateToken verifies an HS256 JWT and resolves its sub and role claims
+func (a *Authenticator) AuthenticateToken(token string) (Principal, error) {
+	if len(a.jwtSecret) == 0 {
+		return Principal{}, fmt.Errorf("JWT authentication is not configured")
+	}
+
+	claims, err := verifyHS256(token, a.jwtSecret)
+	if err != nil {
+		return Principal{}, err
+	}
+	role, err := ParseRole(claims.Role)
+	if err != nil {
+		return Principal{}, err
+	}
+	return Principal{Subject: claims.Subject, Role: role}, nil
+}
+
+// ParseAPIKeys parses "key:role" pairs separated by commas, e.g.
+// "k1:admin,k2:reader"
+func ParseAPIKeys(s string) (map[string]Role, error) {
+	keys := make(map[string]Role)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		i := strings.LastIndex(pair, ":")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid API key entry %q (want key:role)", pair)
+		}
+		role, err := ParseRole(pair[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		keys[pair[:i]] = role
+	}
+	return keys, nil
+}
+
+var current atomic.Pointer[Authenticator]
+
+func init() {
+	Reset()
+}
+
+// Get returns the process-wide Authenticator
+func Get() *Authenticator {
+	return current.Load()
+}
+
+// Init replaces the process-wide Authenticator
+func Init(a *Authenticator) {
+	current.Store(a)
+}
+
+// Reset installs an Authenticator with no credentials (authentication disabled)
+func Reset() {
+	current.Store(New(Config{}))
+}
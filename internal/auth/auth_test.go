@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRole_Allows(t *testing.T) {
+	tests := []struct {
+		role     Role
+		required Role
+		expected bool
+	}{
+		{RoleReader, RoleReader, true},
+		{RoleReader, RoleWriter, false},
+		{RoleWriter, RoleReader, true},
+		{RoleWriter, RoleAdmin, false},
+		{RoleAdmin, RoleWriter, true},
+		{Role(""), RoleReader, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.role.Allows(tt.required); got != tt.expected {
+			t.Errorf("Expected %q.Allows(%q) = %t, got %t", tt.role, tt.required, tt.expected, got)
+		}
+	}
+}
+
+func TestParseAPIKeys(t *testing.T) {
+	keys, err := ParseAPIKeys("k1:admin, k2:Writer,with:colon:reader,")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]Role{"k1": RoleAdmin, "k2": RoleWriter, "with:colon": RoleReader}
+	if len(keys) != len(expected) {
+		t.Fatalf("Expected %d keys, got %d", len(expected), len(keys))
+	}
+	for key, role := range expected {
+		if keys[key] != role {
+			t.Errorf("Expected %q -> %q, got %q", key, role, keys[key])
+		}
+	}
+
+	for _, invalid := range []string{"nokey", ":admin", "k1:root"} {
+		if _, err := ParseAPIKeys(invalid); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
+
+func TestAuthenticator_APIKey(t *testing.T) {
+	a := New(Config{APIKeys: map[string]Role{"secret": RoleWriter}})
+	if !a.Enabled() {
+		t.Fatal("Expected authenticator with keys to be enabled")
+	}
+
+	p, ok := a.AuthenticateAPIKey("secret")
+	if !ok || p.Role != RoleWriter {
+		t.Errorf("Expected writer principal, got %+v, %t", p, ok)
+	}
+	if strings.Contains(p.Subject, "secret") {
+		t.Errorf("Expected subject not to leak the key, got %q", p.Subject)
+	}
+	if _, ok := a.AuthenticateAPIKey("wrong"); ok {
+		t.Error("Expected unknown key to fail")
+	}
+}
+
+func TestAuthenticator_Token(t *testing.T) {
+	secret := []byte("jwt-secret")
+	a := New(Config{JWTSecret: string(secret)})
+
+	p, err := a.AuthenticateToken(SignHS256(secret, "alice", RoleReader, time.Minute))
+	if err != nil || p.Subject != "alice" || p.Role != RoleReader {
+		t.Errorf("Expected reader alice, got %+v, %v", p, err)
+	}
+
+	// Reader token with its payload swapped for an admin one
+	reader := strings.Split(SignHS256(secret, "alice", RoleReader, 0), ".")
+	admin := strings.Split(SignHS256(secret, "alice", RoleAdmin, 0), ".")
+	tampered := reader[0] + "." + admin[1] + "." + reader[2]
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"wrong secret", SignHS256([]byte("other"), "alice", RoleAdmin, 0)},
+		{"expired", SignHS256(secret, "alice", RoleAdmin, -time.Minute)},
+		{"tampered payload", tampered},
+		{"malformed", "not.a.jwt"},
+		{"unknown role", SignHS256(secret, "alice", Role("root"), 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := a.AuthenticateToken(tt.token); err == nil {
+				t.Error("Expected token to be rejected")
+			}
+		})
+	}
+}
+
+func TestAuthenticator_Disabled(t *testing.T) {
+	Reset()
+	if Get().Enabled() {
+		t.Error("Expected default authenticator to be disabled")
+	}
+	if _, err := Get().AuthenticateToken(SignHS256([]byte("x"), "a", RoleAdmin, 0)); err == nil {
+		t.Error("Expected JWT to be rejected without a secret")
+	}
+}
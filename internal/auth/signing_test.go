@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerifySignature(t *testing.T) {
+	a := New(Config{
+		APIKeys:     map[string]Role{"svc-key": RoleWriter, "plain-key": RoleWriter},
+		SigningKeys: map[string]string{"svc-key": "hmac-secret"},
+	})
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+
+	if !a.RequiresSignature("svc-key") || a.RequiresSignature("plain-key") {
+		t.Fatal("Expected only svc-key to require signatures")
+	}
+
+	body := []byte(`{"name":"a"}`)
+	sign := func(at time.Time, body []byte) (string, string) {
+		return strconv.FormatInt(at.Unix(), 10), SignRequest([]byte("hmac-secret"), at.Unix(), "POST", "/tasks", body)
+	}
+
+	ts, sig := sign(now.Add(-time.Minute), body)
+	if err := a.VerifySignature("svc-key", ts, sig, "POST", "/tasks", body); err != nil {
+		t.Errorf("Expected a fresh signature to verify, got %v", err)
+	}
+	if err := a.VerifySignature("svc-key", ts, sig, "POST", "/tasks", body); !errors.Is(err, ErrSignatureReplayed) {
+		t.Errorf("Expected a replay to be rejected, got %v", err)
+	}
+
+	ts, sig = sign(now, body)
+	tests := []struct {
+		name            string
+		ts, sig, method string
+		path            string
+		body            []byte
+		expected        error
+	}{
+		{"tampered body", ts, sig, "POST", "/tasks", []byte(`{"name":"b"}`), ErrSignatureInvalid},
+		{"other endpoint", ts, sig, "PUT", "/tasks/1", body, ErrSignatureInvalid},
+		{"missing signature", ts, "", "POST", "/tasks", body, ErrSignatureInvalid},
+		{"bad timestamp", "yesterday", sig, "POST", "/tasks", body, ErrSignatureInvalid},
+		{"stale", strconv.FormatInt(now.Add(-DefaultSignatureMaxAge-time.Second).Unix(), 10), sig, "POST", "/tasks", body, ErrSignatureExpired},
+		{"future", strconv.FormatInt(now.Add(DefaultSignatureMaxAge+time.Second).Unix(), 10), sig, "POST", "/tasks", body, ErrSignatureExpired},
+	}
+	for _, tt := range tests {
+		if err := a.VerifySignature("svc-key", tt.ts, tt.sig, tt.method, tt.path, tt.body); !errors.Is(err, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, err)
+		}
+	}
+
+	// Accepted signatures are forgotten once their timestamp would be stale anyway
+	now = now.Add(2 * DefaultSignatureMaxAge)
+	a.VerifySignature("svc-key", "0", "", "POST", "/tasks", nil)
+	ts, sig = sign(now, body)
+	a.VerifySignature("svc-key", ts, sig, "POST", "/tasks", body)
+	if n := len(a.replays.seen); n != 1 {
+		t.Errorf("Expected expired signatures to be swept, got %d remembered", n)
+	}
+}
+
+func TestParseSigningKeys(t *testing.T) {
+	keys, err := ParseSigningKeys("svc:key:s3cret, other:x")
+	if err != nil || keys["svc:key"] != "s3cret" || keys["other"] != "x" {
+		t.Errorf("Expected two signing keys, got %v, %v", keys, err)
+	}
+	for _, invalid := range []string{"nosecret", ":secret", "key:"} {
+		if _, err := ParseSigningKeys(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
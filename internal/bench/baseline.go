@@ -0,0 +1,273 @@
+package bench
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Baselines are kept in the Go benchmark format, one file per storage
+// backend, so benchstat can compare a baseline with a new run directly:
+//
+//	storage: shard
+//	dataset: 100000
+//	BenchmarkScenario/storage=shard/scenario=zipf-read 2405168 207.9 ns/op 143 p95-ns 0.00 allocs/op
+//
+// Check fails a run whose p95 latency or allocations per operation grew
+// beyond the Thresholds over the baseline.
+
+// Units of the metrics written for each result
+const (
+	unitNsPerOp  = "ns/op"
+	unitP95      = "p95-ns"
+	unitP99      = "p99-ns"
+	unitAllocsOp = "allocs/op"
+)
+
+// allocSlack is how many allocations per operation a result may gain
+// regardless of the threshold, so a baseline of zero tolerates the stray
+// allocations of the runtime during a run
+const allocSlack = 0.1
+
+// Thresholds bound how much worse than its baseline a result may be, as a
+// fraction of the baseline: 0.5 allows 50% more. Zero disables the check
+// of that metric.
+type Thresholds struct {
+	P95    float64
+	Allocs float64
+}
+
+// DefaultThresholds are wide enough for the noise of runs on one machine
+var DefaultThresholds = Thresholds{P95: 0.5, Allocs: 0.1}
+
+// Baseline is the results of a backend and the settings they were
+// measured with
+type Baseline struct {
+	Storage string
+	Shards  int
+	Config  Config
+	Results map[string]Result // By scenario name
+}
+
+// BaselinePath returns the file of the baseline of storage in dir
+func BaselinePath(dir, storage string) string {
+	return filepath.Join(dir, storage+".txt")
+}
+
+// benchName returns the benchmark name of a result, with the storage and
+// scenario as keys benchstat can group by
+func benchName(storage, scenario string) string {
+	return "BenchmarkScenario/storage=" + storage + "/scenario=" + scenario
+}
+
+// WriteBenchfmt writes results of storage in the Go benchmark format, one
+// line per result
+func WriteBenchfmt(w io.Writer, storage string, results []Result) error {
+	for _, r := range results {
+		nsPerOp := 0.0
+		if r.Ops > 0 {
+			nsPerOp = float64(r.Elapsed.Nanoseconds()) / float64(r.Ops)
+		}
+		_, err := fmt.Fprintf(w, "%s\t%d\t%.1f %s\t%d %s\t%d %s\t%.2f %s\n",
+			benchName(storage, r.Scenario), r.Ops,
+			nsPerOp, unitNsPerOp,
+			r.P95.Nanoseconds(), unitP95,
+			r.P99.Nanoseconds(), unitP99,
+			r.AllocsPerOp, unitAllocsOp)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write writes b in the Go benchmark format, with its settings and the
+// machine as configuration lines
+func (b Baseline) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "goos: %s\ngoarch: %s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(bw, "storage: %s\nshards: %d\n", b.Storage, b.Shards)
+	fmt.Fprintf(bw, "dataset: %d\nworkers: %d\nduration: %s\nops: %d\n",
+		b.Config.Dataset, b.Config.Workers, b.Config.Duration, b.Config.Ops)
+
+	names := make([]string, 0, len(b.Results))
+	for name := range b.Results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	results := make([]Result, 0, len(names))
+	for _, name := range names {
+		results = append(results, b.Results[name])
+	}
+	if err := WriteBenchfmt(bw, b.Storage, results); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// ReadBaseline parses a baseline written by Baseline.Write. Lines it does
+// not know, such as the cpu line of go test output, are skipped.
+func ReadBaseline(r io.Reader) (Baseline, error) {
+	b := Baseline{Results: make(map[string]Result)}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(text, "Benchmark") {
+			if err := b.parseResult(text); err != nil {
+				return Baseline{}, fmt.Errorf("line %d: %w", line, err)
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(text, ": ")
+		if !ok {
+			continue
+		}
+		var err error
+		switch key {
+		case "storage":
+			b.Storage = value
+		case "shards":
+			b.Shards, err = strconv.Atoi(value)
+		case "dataset":
+			b.Config.Dataset, err = strconv.Atoi(value)
+		case "workers":
+			b.Config.Workers, err = strconv.Atoi(value)
+		case "duration":
+			b.Config.Duration, err = time.ParseDuration(value)
+		case "ops":
+			b.Config.Ops, err = strconv.ParseInt(value, 10, 64)
+		}
+		if err != nil {
+			return Baseline{}, fmt.Errorf("line %d: invalid %s: %w", line, key, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Baseline{}, err
+	}
+	if b.Storage == "" || b.Config.Dataset < 1 {
+		return Baseline{}, fmt.Errorf("baseline names no storage or dataset")
+	}
+	return b, nil
+}
+
+// parseResult adds the result of a benchmark line of b's storage
+func (b *Baseline) parseResult(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || len(fields)%2 != 0 {
+		return fmt.Errorf("malformed benchmark line")
+	}
+	var scenario string
+	for _, part := range strings.Split(fields[0], "/")[1:] {
+		if name, ok := strings.CutPrefix(part, "scenario="); ok {
+			scenario = name
+		}
+	}
+	if scenario == "" {
+		return fmt.Errorf("benchmark %s names no scenario", fields[0])
+	}
+	ops, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid iteration count: %w", err)
+	}
+
+	r := Result{Scenario: scenario, Ops: ops, Workers: b.Config.Workers}
+	for i := 2; i < len(fields); i += 2 {
+		value, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", fields[i+1], err)
+		}
+		switch fields[i+1] {
+		case unitNsPerOp:
+			r.Elapsed = time.Duration(value * float64(ops))
+		case unitP95:
+			r.P95 = time.Duration(value)
+		case unitP99:
+			r.P99 = time.Duration(value)
+		case unitAllocsOp:
+			r.AllocsPerOp = value
+		}
+	}
+	b.Results[scenario] = r
+	return nil
+}
+
+// LoadBaseline reads the baseline of storage in dir
+func LoadBaseline(dir, storage string) (Baseline, error) {
+	f, err := os.Open(BaselinePath(dir, storage))
+	if err != nil {
+		return Baseline{}, err
+	}
+	defer f.Close()
+	return ReadBaseline(f)
+}
+
+// Save writes b to its file in dir
+func (b Baseline) Save(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(BaselinePath(dir, b.Storage))
+	if err != nil {
+		return err
+	}
+	if err := b.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Regression is a metric of a result beyond its threshold
+type Regression struct {
+	Storage  string
+	Scenario string
+	Metric   string // p95-ns or allocs/op
+	Baseline float64
+	Current  float64
+}
+
+// Change returns how much the metric grew, as a fraction of the baseline
+func (r Regression) Change() float64 {
+	if r.Baseline == 0 {
+		return math.Inf(1)
+	}
+	return r.Current/r.Baseline - 1
+}
+
+func (r Regression) String() string {
+	return fmt.Sprintf("%s %s: %s %.2f -> %.2f (+%.0f%%)",
+		r.Storage, r.Scenario, r.Metric, r.Baseline, r.Current, r.Change()*100)
+}
+
+// Check compares results with the baseline and returns the metrics that
+// grew beyond t. Scenarios missing from the baseline are not checked.
+func (b Baseline) Check(results []Result, t Thresholds) []Regression {
+	var regressions []Regression
+	for _, r := range results {
+		base, ok := b.Results[r.Scenario]
+		if !ok {
+			continue
+		}
+		if t.P95 > 0 && float64(r.P95) > float64(base.P95)*(1+t.P95) {
+			regressions = append(regressions, Regression{
+				Storage: b.Storage, Scenario: r.Scenario, Metric: unitP95,
+				Baseline: float64(base.P95), Current: float64(r.P95),
+			})
+		}
+		if t.Allocs > 0 && r.AllocsPerOp > base.AllocsPerOp*(1+t.Allocs)+allocSlack {
+			regressions = append(regressions, Regression{
+				Storage: b.Storage, Scenario: r.Scenario, Metric: unitAllocsOp,
+				Baseline: base.AllocsPerOp, Current: r.AllocsPerOp,
+			})
+		}
+	}
+	return regressions
+}
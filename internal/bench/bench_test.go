@@ -0,0 +1,221 @@
+package bench
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/storage/storetest"
+	"tasks-service-demo/internal/storage/xsync"
+	"tasks-service-demo/internal/workload"
+)
+
+func TestScenarios(t *testing.T) {
+	if len(Scenarios()) != 7 || Scenarios()[0].Name() != "distributed-mixed" {
+		t.Errorf("Expected the 7 built-in scenarios sorted by name, got %d", len(Scenarios()))
+	}
+	if s, ok := Lookup("zipf-read"); !ok || s != ZipfRead {
+		t.Error("Expected to find zipf-read")
+	}
+
+	hot := 0
+	for i := 0; i < 1000; i++ {
+		op := ZipfRead.Op(i, 100)
+		if op.Kind != OpRead || op.ID < 1 || op.ID > 100 {
+			t.Fatalf("Expected a read of an ID in the dataset, got %+v", op)
+		}
+		if op.ID <= 20 {
+			hot++
+		}
+	}
+	if hot != 800 {
+		t.Errorf("Expected 80%% of reads on the hot IDs, got %d", hot)
+	}
+
+	writes := 0
+	for i := 0; i < 100; i++ {
+		if DistributedMixed.Op(i, 100).Kind == OpUpdate {
+			writes++
+		}
+	}
+	if writes != 30 {
+		t.Errorf("Expected 30%% updates, got %d", writes)
+	}
+	if op := HotKeyWrite.Op(25, 1000); op.Kind != OpUpdate || op.ID != 6 {
+		t.Errorf("Expected an update of hot key 6, got %+v", op)
+	}
+	if op := ZipfWrite.Op(3, 2); op.ID < 1 || op.ID > 2 {
+		t.Errorf("Expected tiny datasets to stay in range, got %+v", op)
+	}
+}
+
+func TestPopulate(t *testing.T) {
+	bulk := xsync.NewXSyncStore()
+	if err := Populate(bulk, 250000, nil); err != nil {
+		t.Fatal(err)
+	}
+	created := storetest.NewFakeStore()
+	var progress []int
+	if err := Populate(created, 400000, func(done int) { progress = append(progress, done) }); err != nil {
+		t.Fatal(err)
+	}
+
+	if bulk.Len() != 250000 {
+		t.Errorf("Expected 250000 bulk-loaded tasks, got %d", bulk.Len())
+	}
+	if task, err := created.GetByID(400000); err != nil || task.Status != 0 {
+		t.Errorf("Expected task 400000, got %+v, %v", task, err)
+	}
+	if len(progress) != 2 || progress[1] != 400000 {
+		t.Errorf("Expected progress every 200000 tasks, got %v", progress)
+	}
+}
+
+func TestRun(t *testing.T) {
+	store := xsync.NewXSyncStore()
+	Populate(store, 1000, nil)
+
+	r, err := Run(context.Background(), store, DistributedMixed, Config{Dataset: 1000, Workers: 4, Ops: 10000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Ops != 10000 || r.Errors != 0 || r.Workers != 4 || r.Scenario != "distributed-mixed" {
+		t.Errorf("Expected 10000 clean operations, got %+v", r)
+	}
+	if r.P50 <= 0 || r.P50 > r.P99 || r.P99 > r.Max || r.OpsPerSec <= 0 {
+		t.Errorf("Expected ordered latencies and a rate, got %+v", r)
+	}
+
+	r, _ = Run(context.Background(), store, ZipfRead, Config{Dataset: 1000, Duration: 20 * time.Millisecond})
+	if r.Ops == 0 || r.Elapsed < 20*time.Millisecond {
+		t.Errorf("Expected a timed run, got %+v", r)
+	}
+
+	if _, err := Run(context.Background(), store, ZipfRead, Config{}); err == nil {
+		t.Error("Expected an empty dataset to be rejected")
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	for _, d := range []time.Duration{0, 7, 8, 15, 16, 100, 1023, 1024, time.Millisecond, time.Hour} {
+		b := bucketOf(d)
+		if upperBound(b) < d || (b > 0 && upperBound(b-1) >= d) {
+			t.Errorf("Expected %s in bucket %d up to %s", d, b, upperBound(b))
+		}
+	}
+
+	var h histogram
+	for i := 1; i <= 100; i++ {
+		h.record(time.Duration(i) * time.Microsecond)
+	}
+	if p50 := h.quantile(0.5); p50 < 50*time.Microsecond || p50 > 57*time.Microsecond {
+		t.Errorf("Expected p50 within 1/8 of 50µs, got %s", p50)
+	}
+	if h.quantile(1) != 100*time.Microsecond {
+		t.Errorf("Expected p100 capped at the max, got %s", h.quantile(1))
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	b := Baseline{
+		Storage: "shard",
+		Shards:  8,
+		Config:  Config{Dataset: 1000, Workers: 4, Duration: time.Second},
+		Results: map[string]Result{
+			"zipf-read":  {Scenario: "zipf-read", Ops: 4000, Elapsed: 800 * time.Microsecond, P95: 150, P99: 200, AllocsPerOp: 0},
+			"zipf-write": {Scenario: "zipf-write", Ops: 2000, Elapsed: time.Millisecond, P95: 400, P99: 600, AllocsPerOp: 1},
+		},
+	}
+	dir := t.TempDir()
+	if err := b.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadBaseline(dir, "shard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Storage != "shard" || loaded.Shards != 8 || loaded.Config != b.Config || len(loaded.Results) != 2 {
+		t.Fatalf("Expected the saved baseline, got %+v", loaded)
+	}
+	if r := loaded.Results["zipf-write"]; r.Ops != 2000 || r.P95 != 400 || r.P99 != 600 || r.AllocsPerOp != 1 || r.Elapsed != time.Millisecond {
+		t.Errorf("Expected the saved zipf-write result, got %+v", r)
+	}
+
+	current := []Result{
+		{Scenario: "zipf-read", P95: 220, AllocsPerOp: 0.05}, // Within 50% and the alloc slack
+		{Scenario: "zipf-write", P95: 700, AllocsPerOp: 2},   // Both beyond
+		{Scenario: "hotkey-read", P95: 1000},                 // Not in the baseline
+	}
+	regressions := loaded.Check(current, DefaultThresholds)
+	if len(regressions) != 2 || regressions[0].Metric != "p95-ns" || regressions[1].Metric != "allocs/op" {
+		t.Fatalf("Expected the p95 and allocs of zipf-write to regress, got %v", regressions)
+	}
+	if change := regressions[0].Change(); change != 0.75 {
+		t.Errorf("Expected a 75%% p95 increase, got %v", change)
+	}
+	if regressions := loaded.Check(current, Thresholds{}); len(regressions) != 0 {
+		t.Errorf("Expected zero thresholds to disable the checks, got %v", regressions)
+	}
+
+	if _, err := ReadBaseline(strings.NewReader("BenchmarkScenario/storage=shard 10 1 ns/op\n")); err == nil {
+		t.Error("Expected a result without a scenario to be rejected")
+	}
+	if _, err := ReadBaseline(strings.NewReader("goos: linux\n")); err == nil {
+		t.Error("Expected a baseline without a storage to be rejected")
+	}
+}
+
+func TestTrace(t *testing.T) {
+	start := time.Now()
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+	records := []workload.Record{
+		{Time: at(0), Op: workload.OpCreate, RequestBytes: 60},
+		{Time: at(10), Op: workload.OpGet, ID: 150},
+		{Time: at(20), Op: workload.OpUpdate, ID: 20, RequestBytes: 30},
+		{Time: at(30), Op: workload.OpList},
+		{Time: at(40), Op: workload.OpDelete, ID: 20},
+		{Time: at(50), Op: workload.OpGet, ID: 20},
+	}
+	trace, err := NewTrace("recorded", records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trace.Len() != 6 || trace.Span() != 50*time.Millisecond || trace.Dataset() != 150 {
+		t.Errorf("Expected 6 operations over 50ms of up to ID 150, got %d, %s, %d", trace.Len(), trace.Span(), trace.Dataset())
+	}
+	if op := trace.Op(1, 100); op.Kind != OpRead || op.ID != 50 {
+		t.Errorf("Expected ID 150 mapped into a dataset of 100, got %+v", op)
+	}
+	if op := trace.Op(6, 100); op.Kind != OpCreate || op.Size != 60 {
+		t.Errorf("Expected the trace to loop, got %+v", op)
+	}
+	if len(nameOfSize(60, "")) != 60-taskBodyOverhead || len(nameOfSize(5000, "")) != 100 || nameOfSize(0, "def") != "def" {
+		t.Error("Expected names sized to the recorded request bodies")
+	}
+
+	store := storetest.NewFakeStore()
+	Populate(store, 150, nil)
+	r, err := Replay(context.Background(), store, trace, ReplayConfig{Dataset: 150, Workers: 1, Speed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Ops != 6 || r.Errors != 0 || r.Elapsed < 50*time.Millisecond || r.Scenario != "recorded" {
+		t.Errorf("Expected 6 operations paced over 50ms, got %+v", r)
+	}
+	if store.Len() != 150 {
+		t.Errorf("Expected one create and one delete, got %d tasks", store.Len())
+	}
+	if _, err := store.GetByID(20); err == nil {
+		t.Error("Expected the recorded delete to be replayed")
+	}
+
+	r, _ = Replay(context.Background(), store, trace, ReplayConfig{Dataset: 150, Workers: 2})
+	if r.Ops != 6 || r.Elapsed >= 50*time.Millisecond {
+		t.Errorf("Expected an unpaced replay, got %+v", r)
+	}
+
+	if _, err := NewTrace("empty", nil); err == nil {
+		t.Error("Expected an empty trace to be rejected")
+	}
+}
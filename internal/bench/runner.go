@@ -0,0 +1,238 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage"
+)
+
+// DefaultDuration is how long Run drives a scenario when neither a
+// duration nor an operation count is given
+const DefaultDuration = 5 * time.Second
+
+// Config configures a Run
+type Config struct {
+	Dataset  int           // IDs 1..Dataset the scenario picks from; the store must hold them
+	Workers  int           // Concurrent workers; GOMAXPROCS when not positive
+	Duration time.Duration // How long to run; DefaultDuration when neither it nor Ops is set
+	Ops      int64         // Stop after this many operations in total; 0 runs for Duration
+}
+
+// Result is the outcome of a Run
+type Result struct {
+	Scenario  string        `json:"scenario"`
+	Workers   int           `json:"workers"`
+	Ops       int64         `json:"ops"`
+	Errors    int64         `json:"errors"` // Failed operations other than not-found reads
+	Elapsed   time.Duration `json:"elapsedNs"`
+	OpsPerSec float64       `json:"opsPerSec"`
+	P50       time.Duration `json:"p50Ns"`
+	P95       time.Duration `json:"p95Ns"`
+	P99       time.Duration `json:"p99Ns"`
+	Max       time.Duration `json:"maxNs"`
+
+	// AllocsPerOp is the heap allocations of the process during the run per
+	// operation, so it includes the little else the process allocated
+	AllocsPerOp float64 `json:"allocsPerOp"`
+
+	// MaxLag is how late the operation furthest behind the recorded pace
+	// started, in paced replays; a large one means the store could not keep up
+	MaxLag time.Duration `json:"maxLagNs,omitempty"`
+}
+
+// String summarizes r on one line
+func (r Result) String() string {
+	line := fmt.Sprintf("%s: %d ops in %s (%.0f ops/s, %d errors), p50 %s, p95 %s, p99 %s, max %s, %.2f allocs/op",
+		r.Scenario, r.Ops, r.Elapsed.Round(time.Millisecond), r.OpsPerSec, r.Errors, r.P50, r.P95, r.P99, r.Max, r.AllocsPerOp)
+	if r.MaxLag > 0 {
+		line += fmt.Sprintf(", max lag %s", r.MaxLag.Round(time.Microsecond))
+	}
+	return line
+}
+
+// Run drives scenario against store with cfg.Workers workers until the
+// duration elapses, cfg.Ops operations are done or ctx is cancelled, timing
+// every operation.
+func Run(ctx context.Context, store storage.Store, scenario Scenario, cfg Config) (Result, error) {
+	if cfg.Dataset < 1 {
+		return Result{}, fmt.Errorf("dataset must hold at least one task")
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = runtime.GOMAXPROCS(0)
+	}
+	if cfg.Duration <= 0 && cfg.Ops <= 0 {
+		cfg.Duration = DefaultDuration
+	}
+	if cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+
+	var (
+		wg      sync.WaitGroup
+		claimed atomic.Int64 // Operations handed out, when cfg.Ops limits them
+		mu      sync.Mutex
+		total   histogram
+		errs    int64
+	)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for w := 0; w < cfg.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var hist histogram
+			var failed int64
+			for i := 0; ; i++ {
+				// Checking ctx costs more than a fast operation, so only every 64th
+				if i%64 == 0 && ctx.Err() != nil {
+					break
+				}
+				if cfg.Ops > 0 && claimed.Add(1) > cfg.Ops {
+					break
+				}
+				op := scenario.Op(i, cfg.Dataset)
+				opStart := time.Now()
+				err := Exec(store, op, i)
+				hist.record(time.Since(opStart))
+				if err != nil && err.Code != apperrors.ErrCodeTaskNotFound {
+					failed++
+				}
+			}
+			mu.Lock()
+			total.merge(&hist)
+			errs += failed
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+	return newResult(scenario.Name(), cfg.Workers, &total, errs, elapsed, after.Mallocs-before.Mallocs), nil
+}
+
+// newResult summarizes a run of workers that took elapsed, recorded the
+// latencies in hist and made mallocs heap allocations
+func newResult(scenario string, workers int, hist *histogram, errs int64, elapsed time.Duration, mallocs uint64) Result {
+	r := Result{
+		Scenario: scenario,
+		Workers:  workers,
+		Ops:      hist.count,
+		Errors:   errs,
+		Elapsed:  elapsed,
+		P50:      hist.quantile(0.50),
+		P95:      hist.quantile(0.95),
+		P99:      hist.quantile(0.99),
+		Max:      hist.max,
+	}
+	if elapsed > 0 {
+		r.OpsPerSec = float64(r.Ops) / elapsed.Seconds()
+	}
+	if r.Ops > 0 {
+		r.AllocsPerOp = float64(mallocs) / float64(r.Ops)
+	}
+	return r
+}
+
+// RunFresh runs scenario on a new store of backend holding cfg.Dataset
+// tasks, so the writes of one run do not skew the next, and closes the
+// store afterwards
+func RunFresh(ctx context.Context, backend storage.Backend, opts storage.Options, scenario Scenario, cfg Config) (Result, error) {
+	store, err := backend.New(opts)
+	if err != nil {
+		return Result{}, err
+	}
+	defer Close(store)
+
+	if err := Populate(store, cfg.Dataset, nil); err != nil {
+		return Result{}, err
+	}
+	return Run(ctx, store, scenario, cfg)
+}
+
+// subBuckets splits each power of two of the histogram, bounding the
+// error of a quantile to 1/subBuckets
+const subBuckets = 8
+
+// histogram counts latencies in log-linear buckets: one range per power of
+// two of nanoseconds, split into subBuckets equal parts
+type histogram struct {
+	counts [64 * subBuckets]int64
+	count  int64
+	max    time.Duration
+}
+
+// bucketOf returns the bucket of d
+func bucketOf(d time.Duration) int {
+	ns := uint64(max(d, 0))
+	if ns < subBuckets {
+		return int(ns)
+	}
+	exp := bits.Len64(ns) - 1 // ns lies in [2^exp, 2^(exp+1))
+	sub := (ns >> (exp - 3)) & (subBuckets - 1)
+	return (exp-2)*subBuckets + int(sub)
+}
+
+// upperBound returns the largest latency bucket b holds
+func upperBound(b int) time.Duration {
+	if b < subBuckets {
+		return time.Duration(b)
+	}
+	exp := b/subBuckets + 2
+	sub := uint64(b % subBuckets)
+	return time.Duration((uint64(1)<<exp + (sub+1)<<(exp-3)) - 1)
+}
+
+func (h *histogram) record(d time.Duration) {
+	h.counts[bucketOf(d)]++
+	h.count++
+	h.max = max(h.max, d)
+}
+
+func (h *histogram) merge(o *histogram) {
+	for i, n := range o.counts {
+		h.counts[i] += n
+	}
+	h.count += o.count
+	h.max = max(h.max, o.max)
+}
+
+// quantile returns the upper bound of the bucket holding quantile q, capped
+// at the largest latency seen
+func (h *histogram) quantile(q float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	target := max(int64(q*float64(h.count)+0.5), 1)
+	var cumulative int64
+	for b, n := range h.counts {
+		cumulative += n
+		if cumulative >= target {
+			return min(upperBound(b), h.max)
+		}
+	}
+	return h.max
+}
+
+// Close releases the workers and pools of a store built for a run. The
+// in-memory backends stop them with Shutdown or a Close returning an
+// AppError, which storage.Close does not look for.
+func Close(store storage.Store) {
+	switch s := store.(type) {
+	case interface{ Shutdown() }:
+		s.Shutdown()
+	case interface{ Close() *apperrors.AppError }:
+		s.Close()
+	default:
+		storage.Close(store)
+	}
+}
@@ -0,0 +1,207 @@
+package bench
+
+import (
+	"sort"
+	"strings"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage"
+)
+
+// Package bench defines the store workloads shared by the Go benchmarks,
+// the loadtest endpoint and the bench subcommand, so all three measure the
+// same thing. A Scenario says which operation each iteration performs on a
+// store populated with IDs 1..dataset; Run drives one with a pool of
+// workers and reports throughput and latency.
+
+// HotKeyRatio is the percentage of IDs that get 80% of the traffic of the
+// Zipf scenarios
+const HotKeyRatio = 20
+
+// HotKeys is the number of IDs all traffic of the hot-key scenarios goes to
+const HotKeys = 10
+
+// OpKind is the kind of store operation
+type OpKind int
+
+const (
+	OpRead   OpKind = iota // GetByID
+	OpUpdate               // Update
+	OpCreate               // Create
+	OpDelete               // Delete
+	OpList                 // GetAll
+)
+
+// Op is one store operation of a workload
+type Op struct {
+	Kind OpKind
+	ID   int // Unused by OpCreate and OpList
+	Size int // Request body bytes of a create or update; 0 for the default task
+}
+
+// Scenario is a workload definition. Op returns the operation of iteration
+// i of a worker; each worker counts its own iterations from 0, like
+// testing.PB does.
+type Scenario interface {
+	Name() string
+	Description() string
+	Op(i, dataset int) Op
+}
+
+// KeyFunc picks the task ID of iteration i among IDs 1..dataset
+type KeyFunc func(i, dataset int) int
+
+// ZipfKey sends 80% of iterations to the first HotKeyRatio percent of IDs
+// and the rest to the other IDs
+func ZipfKey(i, dataset int) int {
+	hot := dataset * HotKeyRatio / 100
+	if hot < 1 || hot >= dataset {
+		return UniformKey(i, dataset)
+	}
+	if i%10 < 8 {
+		return i%hot + 1
+	}
+	return i%(dataset-hot) + hot + 1
+}
+
+// UniformKey cycles through all IDs
+func UniformKey(i, dataset int) int {
+	return i%dataset + 1
+}
+
+// HotKey cycles through the first HotKeys IDs
+func HotKey(i, dataset int) int {
+	return i%min(HotKeys, dataset) + 1
+}
+
+// keyed is a Scenario that updates in writes of every 10 iterations and
+// reads in the others
+type keyed struct {
+	name, description string
+	key               KeyFunc
+	writes            int // Of every 10 iterations
+}
+
+// New returns a Scenario that picks IDs with key and makes writes of every
+// 10 iterations updates, the last ones of each 10, and the others reads
+func New(name, description string, key KeyFunc, writes int) Scenario {
+	return &keyed{name: name, description: description, key: key, writes: writes}
+}
+
+func (s *keyed) Name() string        { return s.name }
+func (s *keyed) Description() string { return s.description }
+
+// Op implements Scenario
+func (s *keyed) Op(i, dataset int) Op {
+	op := Op{Kind: OpRead, ID: s.key(i, dataset)}
+	if i%10 >= 10-s.writes {
+		op.Kind = OpUpdate
+	}
+	return op
+}
+
+// Built-in scenarios
+var (
+	ZipfRead         = New("zipf-read", "Reads, 80% of them of the hottest 20% of IDs", ZipfKey, 0)
+	ZipfWrite        = New("zipf-write", "Updates, 80% of them of the hottest 20% of IDs", ZipfKey, 10)
+	DistributedRead  = New("distributed-read", "Reads spread evenly over all IDs", UniformKey, 0)
+	DistributedWrite = New("distributed-write", "Updates spread evenly over all IDs", UniformKey, 10)
+	DistributedMixed = New("distributed-mixed", "70% reads and 30% updates spread evenly over all IDs", UniformKey, 3)
+	HotKeyRead       = New("hotkey-read", "Reads of the same 10 IDs by every worker", HotKey, 0)
+	HotKeyWrite      = New("hotkey-write", "Updates of the same 10 IDs by every worker", HotKey, 10)
+)
+
+var scenarios = make(map[string]Scenario)
+
+func init() {
+	for _, s := range []Scenario{ZipfRead, ZipfWrite, DistributedRead, DistributedWrite, DistributedMixed, HotKeyRead, HotKeyWrite} {
+		scenarios[s.Name()] = s
+	}
+}
+
+// Lookup returns the built-in scenario called name
+func Lookup(name string) (Scenario, bool) {
+	s, ok := scenarios[name]
+	return s, ok
+}
+
+// Scenarios returns the built-in scenarios, sorted by name
+func Scenarios() []Scenario {
+	all := make([]Scenario, 0, len(scenarios))
+	for _, s := range scenarios {
+		all = append(all, s)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name() < all[j].Name() })
+	return all
+}
+
+// Exec performs op, iteration i of a worker, on store
+func Exec(store storage.Store, op Op, i int) *apperrors.AppError {
+	switch op.Kind {
+	case OpUpdate:
+		return store.Update(op.ID, &entities.Task{Name: nameOfSize(op.Size, "Bench update"), Status: i % 2})
+	case OpCreate:
+		return store.Create(&entities.Task{Name: nameOfSize(op.Size, "Bench create"), Status: i % 2})
+	case OpDelete:
+		return store.Delete(op.ID)
+	case OpList:
+		store.GetAll()
+		return nil
+	default:
+		_, err := store.GetByID(op.ID)
+		return err
+	}
+}
+
+// Populate fills an empty store with dataset tasks, IDs 1..dataset. It calls
+// progress, if set, after every 200000 tasks.
+func Populate(store storage.Store, dataset int, progress func(done int)) *apperrors.AppError {
+	const step = 200000
+	if loader, ok := store.(storage.BulkLoader); ok {
+		for from := 1; from <= dataset; from += step {
+			to := min(from+step-1, dataset)
+			tasks := make([]*entities.Task, 0, to-from+1)
+			for id := from; id <= to; id++ {
+				tasks = append(tasks, populated(id))
+			}
+			if err := loader.BulkLoad(tasks); err != nil {
+				return err
+			}
+			if progress != nil && to%step == 0 {
+				progress(to)
+			}
+		}
+		return nil
+	}
+
+	for id := 1; id <= dataset; id++ {
+		if err := store.Create(populated(id)); err != nil {
+			return err
+		}
+		if progress != nil && id%step == 0 {
+			progress(id)
+		}
+	}
+	return nil
+}
+
+// taskBodyOverhead is the size of a task request body with an empty name
+const taskBodyOverhead = len(`{"name":"","status":0}`)
+
+// longName is sliced for names of a given size, up to the longest valid one
+var longName = strings.Repeat("x", 100)
+
+// nameOfSize returns a task name giving a request body of about size bytes,
+// or def when size is 0
+func nameOfSize(size int, def string) string {
+	if size <= 0 {
+		return def
+	}
+	return longName[:min(max(size-taskBodyOverhead, 1), len(longName))]
+}
+
+// populated returns the task Populate stores under id
+func populated(id int) *entities.Task {
+	return &entities.Task{ID: id, Name: "Bench task", Status: id % 2}
+}
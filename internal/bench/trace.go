@@ -0,0 +1,185 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/workload"
+)
+
+// Trace is a workload recorded by the workload package. As a Scenario it
+// loops over the recorded operations as fast as Run drives it; Replay plays
+// it once, at the recorded pace or faster.
+type Trace struct {
+	name    string
+	ops     []Op
+	offsets []time.Duration // Since the first record
+	maxID   int
+}
+
+// opKinds maps recorded operations to store operations
+var opKinds = map[string]OpKind{
+	workload.OpGet:    OpRead,
+	workload.OpUpdate: OpUpdate,
+	workload.OpCreate: OpCreate,
+	workload.OpDelete: OpDelete,
+	workload.OpList:   OpList,
+}
+
+// NewTrace returns the Trace of records, ordered by time as ReadTrace
+// returns them
+func NewTrace(name string, records []workload.Record) (*Trace, error) {
+	if len(records) == 0 {
+		return nil, fmt.Errorf("trace %s has no records", name)
+	}
+	t := &Trace{
+		name:    name,
+		ops:     make([]Op, len(records)),
+		offsets: make([]time.Duration, len(records)),
+		maxID:   1,
+	}
+	first := records[0].Time
+	for i, rec := range records {
+		kind, ok := opKinds[rec.Op]
+		if !ok {
+			return nil, fmt.Errorf("trace %s: unknown op %q", name, rec.Op)
+		}
+		t.ops[i] = Op{Kind: kind, ID: rec.ID, Size: rec.RequestBytes}
+		t.offsets[i] = rec.Time.Sub(first)
+		t.maxID = max(t.maxID, rec.ID)
+	}
+	return t, nil
+}
+
+// Name implements Scenario
+func (t *Trace) Name() string { return t.name }
+
+// Description implements Scenario
+func (t *Trace) Description() string {
+	return fmt.Sprintf("Replay of %d recorded operations over %s", len(t.ops), t.Span().Round(time.Millisecond))
+}
+
+// Op implements Scenario: iteration i performs recorded operation i, from
+// the start again past the last, with its task ID mapped into 1..dataset
+func (t *Trace) Op(i, dataset int) Op {
+	op := t.ops[i%len(t.ops)]
+	if op.ID > 0 {
+		op.ID = (op.ID-1)%dataset + 1
+	}
+	return op
+}
+
+// Len returns the number of recorded operations
+func (t *Trace) Len() int {
+	return len(t.ops)
+}
+
+// Span returns the time between the first and the last recorded operation
+func (t *Trace) Span() time.Duration {
+	return t.offsets[len(t.offsets)-1]
+}
+
+// Dataset returns the number of tasks that holds every recorded task ID
+func (t *Trace) Dataset() int {
+	return t.maxID
+}
+
+// ReplayConfig configures a Replay
+type ReplayConfig struct {
+	Dataset int     // Tasks the store holds; recorded IDs are mapped into 1..Dataset
+	Workers int     // Concurrent workers; GOMAXPROCS when not positive
+	Speed   float64 // 1 replays at the recorded pace, 2 twice as fast; 0 as fast as possible
+}
+
+// Replay performs every operation of trace once on store, which must hold
+// cfg.Dataset tasks, starting each no earlier than its recorded offset
+// divided by cfg.Speed. A sampled trace holds a fraction of the traffic, so
+// replaying it at the pace of the original traffic takes a speed of one
+// over the sample rate.
+func Replay(ctx context.Context, store storage.Store, trace *Trace, cfg ReplayConfig) (Result, error) {
+	if cfg.Dataset < 1 {
+		return Result{}, fmt.Errorf("dataset must hold at least one task")
+	}
+	if cfg.Speed < 0 {
+		return Result{}, fmt.Errorf("speed must not be negative")
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = runtime.GOMAXPROCS(0)
+	}
+
+	var (
+		wg     sync.WaitGroup
+		next   = make(chan int, cfg.Workers*64)
+		mu     sync.Mutex
+		total  histogram
+		errs   int64
+		maxLag time.Duration
+	)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+
+	// The dispatcher hands out operations when they are due; workers that
+	// cannot keep up make it send late, which MaxLag reports
+	go func() {
+		defer close(next)
+		for i, offset := range trace.offsets {
+			if cfg.Speed > 0 {
+				due := start.Add(time.Duration(float64(offset) / cfg.Speed))
+				if wait := time.Until(due); wait > 0 {
+					select {
+					case <-time.After(wait):
+					case <-ctx.Done():
+						return
+					}
+				}
+				select {
+				case next <- i:
+				case <-ctx.Done():
+					return
+				}
+				maxLag = max(maxLag, time.Since(due))
+				continue
+			}
+			select {
+			case next <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for w := 0; w < cfg.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var hist histogram
+			var failed int64
+			for i := range next {
+				op := trace.Op(i, cfg.Dataset)
+				opStart := time.Now()
+				err := Exec(store, op, i)
+				hist.record(time.Since(opStart))
+				if err != nil && err.Code != apperrors.ErrCodeTaskNotFound {
+					failed++
+				}
+			}
+			mu.Lock()
+			total.merge(&hist)
+			errs += failed
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	r := newResult(trace.Name(), cfg.Workers, &total, errs, elapsed, after.Mallocs-before.Mallocs)
+	r.MaxLag = maxLag
+	return r, ctx.Err()
+}
@@ -0,0 +1,90 @@
+package buildinfo
+
+import (
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// Package buildinfo exposes build metadata injected at link time, e.g.
+//
+//	go build -ldflags "-X tasks-service-demo/internal/buildinfo.GitCommit=$(git rev-parse --short HEAD)"
+//
+// When the ldflags are not set, the VCS stamp recorded by the Go toolchain is used instead.
+
+// Link-time variables, overridable with -ldflags "-X ...".
+var (
+	Version   = ""        // Semantic version; APP_VERSION takes precedence at runtime
+	GitCommit = "unknown" // Git commit hash the binary was built from
+	BuildDate = "unknown" // Build timestamp (RFC3339)
+)
+
+// DefaultVersion is reported when neither APP_VERSION nor the Version ldflag is set
+const DefaultVersion = "1.0.0"
+
+// StorageConfig describes the active storage configuration
+type StorageConfig struct {
+	Type       string `json:"type"`
+	ShardCount int    `json:"shardCount,omitempty"`
+}
+
+// Info is the full build and runtime metadata of the service
+type Info struct {
+	Version   string        `json:"version"`
+	GitCommit string        `json:"gitCommit"`
+	BuildDate string        `json:"buildDate"`
+	GoVersion string        `json:"goVersion"`
+	Storage   StorageConfig `json:"storage"`
+}
+
+var (
+	mu      sync.RWMutex
+	storage StorageConfig
+)
+
+// SetStorage records the active storage configuration once it is resolved at startup
+func SetStorage(storageType string, shardCount int) {
+	mu.Lock()
+	storage = StorageConfig{Type: storageType, ShardCount: shardCount}
+	mu.Unlock()
+}
+
+// Get returns the current build metadata and active storage configuration
+func Get() Info {
+	mu.RLock()
+	st := storage
+	mu.RUnlock()
+
+	info := Info{
+		Version:   resolveVersion(),
+		GitCommit: GitCommit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		Storage:   st,
+	}
+
+	// Fall back to the VCS stamp embedded by `go build`
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range bi.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.GitCommit == "unknown":
+				info.GitCommit = setting.Value
+			case setting.Key == "vcs.time" && info.BuildDate == "unknown":
+				info.BuildDate = setting.Value
+			}
+		}
+	}
+
+	return info
+}
+
+func resolveVersion() string {
+	if version := os.Getenv("APP_VERSION"); version != "" {
+		return version
+	}
+	if Version != "" {
+		return Version
+	}
+	return DefaultVersion
+}
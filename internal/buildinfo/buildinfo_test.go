@@ -0,0 +1,62 @@
+package buildinfo
+
+import (
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestGet_DefaultVersion(t *testing.T) {
+	os.Unsetenv("APP_VERSION")
+	original := Version
+	Version = ""
+	defer func() { Version = original }()
+
+	if info := Get(); info.Version != DefaultVersion {
+		t.Errorf("Expected version '%s', got '%s'", DefaultVersion, info.Version)
+	}
+}
+
+func TestGet_VersionPrecedence(t *testing.T) {
+	original := Version
+	Version = "3.0.0"
+	defer func() { Version = original }()
+
+	os.Unsetenv("APP_VERSION")
+	if info := Get(); info.Version != "3.0.0" {
+		t.Errorf("Expected ldflags version '3.0.0', got '%s'", info.Version)
+	}
+
+	os.Setenv("APP_VERSION", "4.0.0")
+	defer os.Unsetenv("APP_VERSION")
+	if info := Get(); info.Version != "4.0.0" {
+		t.Errorf("Expected APP_VERSION to win, got '%s'", info.Version)
+	}
+}
+
+func TestGet_LdflagsAndRuntime(t *testing.T) {
+	originalCommit, originalDate := GitCommit, BuildDate
+	GitCommit, BuildDate = "abc1234", "2024-01-01T00:00:00Z"
+	defer func() { GitCommit, BuildDate = originalCommit, originalDate }()
+
+	info := Get()
+	if info.GitCommit != "abc1234" {
+		t.Errorf("Expected git commit 'abc1234', got '%s'", info.GitCommit)
+	}
+	if info.BuildDate != "2024-01-01T00:00:00Z" {
+		t.Errorf("Expected build date from ldflags, got '%s'", info.BuildDate)
+	}
+	if info.GoVersion != runtime.Version() {
+		t.Errorf("Expected Go version '%s', got '%s'", runtime.Version(), info.GoVersion)
+	}
+}
+
+func TestSetStorage(t *testing.T) {
+	SetStorage("shard", 32)
+	defer SetStorage("", 0)
+
+	info := Get()
+	if info.Storage.Type != "shard" || info.Storage.ShardCount != 32 {
+		t.Errorf("Expected shard storage with 32 shards, got %+v", info.Storage)
+	}
+}
@@ -0,0 +1,97 @@
+package changes
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// cursorPrefix versions the cursor format so it can change without breaking clients silently
+const cursorPrefix = "v2:"
+
+// ErrInvalidCursor is returned for cursors not produced by EncodeCursor
+var ErrInvalidCursor = errors.New("invalid sync cursor")
+
+// EncodeCursor returns the opaque sync cursor for a revision of the feed
+// with epoch
+func EncodeCursor(epoch string, rev uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + epoch + ":" + strconv.FormatUint(rev, 10)))
+}
+
+// DecodeCursor returns the feed epoch and revision of a cursor; the empty
+// cursor is revision 0 of no epoch. Cursors of the previous format, which
+// carried no epoch, decode with an empty one.
+func DecodeCursor(cursor string) (epoch string, rev uint64, err error) {
+	if cursor == "" {
+		return "", 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, ErrInvalidCursor
+	}
+	s := string(raw)
+	switch {
+	case strings.HasPrefix(s, cursorPrefix):
+		epoch, s, _ = strings.Cut(strings.TrimPrefix(s, cursorPrefix), ":")
+		if epoch == "" {
+			return "", 0, ErrInvalidCursor
+		}
+	case strings.HasPrefix(s, "v1:"):
+		s = strings.TrimPrefix(s, "v1:")
+	default:
+		return "", 0, ErrInvalidCursor
+	}
+	rev, err = strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return "", 0, ErrInvalidCursor
+	}
+	return epoch, rev, nil
+}
+
+// Delta is the net effect of a run of changes, one entry per task
+type Delta struct {
+	Created []int `json:"created"`
+	Updated []int `json:"updated"`
+	Deleted []int `json:"deleted"`
+}
+
+// Compact folds changes (in revision order) into their net effect per task.
+// A task created and deleted within the run is omitted; created then updated
+// stays created; anything ending in a delete is deleted. IDs are listed in
+// order of their latest change.
+func Compact(list []Change) Delta {
+	type span struct {
+		first, last Change
+	}
+	spans := make(map[int]*span, len(list))
+	for _, ch := range list {
+		if sp, ok := spans[ch.TaskID]; ok {
+			sp.last = ch
+		} else {
+			spans[ch.TaskID] = &span{first: ch, last: ch}
+		}
+	}
+
+	delta := Delta{Created: []int{}, Updated: []int{}, Deleted: []int{}}
+	for _, ch := range list {
+		sp := spans[ch.TaskID]
+		if sp.last.Revision != ch.Revision {
+			continue
+		}
+
+		createdInRun := sp.first.Op == OpCreated
+		switch {
+		case sp.last.Op == OpDeleted && createdInRun:
+			// Never seen by the client; nothing to sync
+		case sp.last.Op == OpDeleted:
+			delta.Deleted = append(delta.Deleted, ch.TaskID)
+		case createdInRun:
+			delta.Created = append(delta.Created, ch.TaskID)
+		default:
+			delta.Updated = append(delta.Updated, ch.TaskID)
+		}
+	}
+	return delta
+}
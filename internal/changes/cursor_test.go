@@ -0,0 +1,55 @@
+package changes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	for _, rev := range []uint64{0, 1, 42, 1 << 40} {
+		epoch, got, err := DecodeCursor(EncodeCursor("e1", rev))
+		if err != nil || got != rev || epoch != "e1" {
+			t.Errorf("Expected %d of e1, got %d of %q, %v", rev, got, epoch, err)
+		}
+	}
+
+	if epoch, rev, err := DecodeCursor(""); rev != 0 || epoch != "" || err != nil {
+		t.Errorf("Expected empty cursor to be revision 0, got %d, %v", rev, err)
+	}
+	// Cursors from before epochs decode without one
+	if epoch, rev, err := DecodeCursor("djE6NDI"); rev != 42 || epoch != "" || err != nil {
+		t.Errorf("Expected a v1 cursor to be revision 42 of no epoch, got %d of %q, %v", rev, epoch, err)
+	}
+	for _, invalid := range []string{"42", "!!!", EncodeCursor("e1", 1)[:3], "djI6MQ", "djk6MQ"} {
+		if _, _, err := DecodeCursor(invalid); err != ErrInvalidCursor {
+			t.Errorf("Expected ErrInvalidCursor for %q, got %v", invalid, err)
+		}
+	}
+}
+
+func TestCompact(t *testing.T) {
+	list := []Change{
+		{1, 1, OpCreated},
+		{2, 2, OpUpdated},
+		{3, 3, OpCreated},
+		{4, 1, OpUpdated}, // created then updated -> created
+		{5, 3, OpDeleted}, // created then deleted -> omitted
+		{6, 4, OpUpdated},
+		{7, 4, OpDeleted}, // updated then deleted -> deleted
+		{8, 5, OpDeleted},
+	}
+
+	expected := Delta{
+		Created: []int{1},
+		Updated: []int{2},
+		Deleted: []int{4, 5},
+	}
+	if got := Compact(list); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+
+	empty := Compact(nil)
+	if empty.Created == nil || empty.Updated == nil || empty.Deleted == nil {
+		t.Error("Expected empty slices, not nil, so JSON renders []")
+	}
+}
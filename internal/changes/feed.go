@@ -0,0 +1,214 @@
+package changes
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+)
+
+// Package changes keeps a global revision counter for task writes and a bounded
+// history of recent changes, so clients can poll for what changed since a
+// revision they have already seen.
+
+// DefaultHistory is the number of changes retained when none is configured
+const DefaultHistory = 10000
+
+// Op is the kind of write that produced a change
+type Op string
+
+const (
+	OpCreated Op = "created"
+	OpUpdated Op = "updated"
+	OpDeleted Op = "deleted"
+)
+
+// Change is a single recorded write
+type Change struct {
+	Revision uint64 `json:"revision"`
+	TaskID   int    `json:"id"`
+	Op       Op     `json:"op"`
+}
+
+// Record is a change with the time of the write and the task as the write
+// left it; Task is nil for deletes and for changes recorded without state
+type Record struct {
+	Change
+	At   time.Time
+	Task *entities.Task
+}
+
+// Feed records changes under a monotonically increasing revision and wakes
+// waiters when the revision advances. Revisions start over with every Feed,
+// so each has a random epoch telling its revisions apart from those of an
+// earlier process.
+type Feed struct {
+	epoch string
+
+	mu      sync.Mutex
+	rev     uint64
+	history []Record // Ring buffer of the most recent changes
+	next    int      // Ring position of the next write
+	size    int      // Number of valid entries in history
+	notify  chan struct{}
+}
+
+// NewFeed creates a Feed retaining up to history changes
+func NewFeed(history int) *Feed {
+	if history <= 0 {
+		history = DefaultHistory
+	}
+	return &Feed{
+		epoch:   newEpoch(),
+		history: make([]Record, history),
+		notify:  make(chan struct{}),
+	}
+}
+
+// newEpoch returns a random feed epoch
+func newEpoch() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("changes: reading random bytes: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
+
+// Epoch returns the random ID of the feed, different in every process
+func (f *Feed) Epoch() string {
+	return f.epoch
+}
+
+// Cursor returns the sync cursor of revision rev of the feed
+func (f *Feed) Cursor(rev uint64) string {
+	return EncodeCursor(f.epoch, rev)
+}
+
+// SinceCursor is Since for a sync cursor. A cursor of another epoch counts
+// revisions of an earlier feed, so its changes are reported as not
+// complete. The empty cursor starts at the beginning of the feed.
+func (f *Feed) SinceCursor(cursor string) (changes []Change, current uint64, complete bool, err error) {
+	epoch, rev, err := DecodeCursor(cursor)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	changes, current, complete = f.Since(rev)
+	if cursor != "" && epoch != f.epoch {
+		return nil, current, false, nil
+	}
+	return changes, current, complete, nil
+}
+
+// Record appends a change, advances the revision and wakes all waiters
+func (f *Feed) Record(taskID int, op Op) uint64 {
+	return f.RecordTask(taskID, op, nil)
+}
+
+// RecordTask is Record keeping task, the state the write left, with the
+// change. The feed keeps task as is, so callers pass a copy.
+func (f *Feed) RecordTask(taskID int, op Op, task *entities.Task) uint64 {
+	at := time.Now()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.rev++
+	f.history[f.next] = Record{Change: Change{Revision: f.rev, TaskID: taskID, Op: op}, At: at, Task: task}
+	f.next = (f.next + 1) % len(f.history)
+	f.size = min(f.size+1, len(f.history))
+
+	close(f.notify)
+	f.notify = make(chan struct{})
+	return f.rev
+}
+
+// Revision returns the current revision (0 before any write)
+func (f *Feed) Revision() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rev
+}
+
+// Since returns changes after rev in revision order with the current revision.
+// complete is false when changes after rev were already dropped from the
+// history, in which case the caller must resync from a full listing.
+func (f *Feed) Since(rev uint64) (changes []Change, current uint64, complete bool) {
+	records, current, complete := f.Records(rev, 0)
+	if len(records) == 0 {
+		return nil, current, complete
+	}
+	changes = make([]Change, len(records))
+	for i, r := range records {
+		changes[i] = r.Change
+	}
+	return changes, current, complete
+}
+
+// Records is Since returning the records of at most limit changes (0 is no
+// limit), oldest first
+func (f *Feed) Records(rev uint64, limit int) (records []Record, current uint64, complete bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if rev >= f.rev {
+		return nil, f.rev, true
+	}
+
+	oldest := f.rev - uint64(f.size) + 1
+	complete = rev+1 >= oldest
+	start := max(rev+1, oldest)
+	end := f.rev
+	if limit > 0 && end-start+1 > uint64(limit) {
+		end = start + uint64(limit) - 1
+	}
+
+	records = make([]Record, 0, end-start+1)
+	for r := start; r <= end; r++ {
+		// Revision r sits (f.rev - r + 1) slots behind the next write position
+		back := int(f.rev - r + 1)
+		records = append(records, f.history[(f.next-back+len(f.history))%len(f.history)])
+	}
+	return records, f.rev, complete
+}
+
+// Wait blocks until the revision advances past rev or ctx is done, and returns
+// the current revision
+func (f *Feed) Wait(ctx context.Context, rev uint64) (uint64, error) {
+	for {
+		f.mu.Lock()
+		current, notify := f.rev, f.notify
+		f.mu.Unlock()
+
+		if current > rev {
+			return current, nil
+		}
+
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return current, ctx.Err()
+		}
+	}
+}
+
+var (
+	instance *Feed
+	once     sync.Once
+)
+
+// Get returns the process-wide Feed
+func Get() *Feed {
+	once.Do(func() {
+		instance = NewFeed(DefaultHistory)
+	})
+	return instance
+}
+
+// Reset replaces the process-wide Feed with an empty one
+func Reset() {
+	once = sync.Once{}
+	instance = nil
+}
@@ -0,0 +1,136 @@
+package changes
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/storetest"
+)
+
+func TestFeed_Since(t *testing.T) {
+	f := NewFeed(10)
+	f.Record(1, OpCreated)
+	f.Record(2, OpCreated)
+	f.Record(1, OpUpdated)
+
+	list, current, complete := f.Since(1)
+	if current != 3 || !complete {
+		t.Fatalf("Expected revision 3 and complete history, got %d, %t", current, complete)
+	}
+	expected := []Change{{2, 2, OpCreated}, {3, 1, OpUpdated}}
+	if len(list) != len(expected) {
+		t.Fatalf("Expected %d changes, got %v", len(expected), list)
+	}
+	for i := range expected {
+		if list[i] != expected[i] {
+			t.Errorf("Expected change %+v, got %+v", expected[i], list[i])
+		}
+	}
+
+	if list, _, _ := f.Since(3); len(list) != 0 {
+		t.Errorf("Expected no changes at the current revision, got %v", list)
+	}
+}
+
+func TestFeed_HistoryWraps(t *testing.T) {
+	f := NewFeed(3)
+	for id := 1; id <= 5; id++ {
+		f.Record(id, OpCreated)
+	}
+
+	list, current, complete := f.Since(0)
+	if current != 5 || complete {
+		t.Errorf("Expected revision 5 with incomplete history, got %d, %t", current, complete)
+	}
+	if len(list) != 3 || list[0].TaskID != 3 || list[2].TaskID != 5 {
+		t.Errorf("Expected the 3 most recent changes, got %v", list)
+	}
+
+	// Exactly the oldest retained revision is still complete
+	if list, _, complete := f.Since(2); !complete || len(list) != 3 {
+		t.Errorf("Expected complete history since 2, got %v, %t", list, complete)
+	}
+}
+
+func TestFeed_Records(t *testing.T) {
+	f := NewFeed(10)
+	f.RecordTask(1, OpCreated, &entities.Task{ID: 1, Name: "a"})
+	f.Record(2, OpCreated)
+	f.RecordTask(1, OpDeleted, nil)
+
+	records, current, complete := f.Records(0, 2)
+	if current != 3 || !complete || len(records) != 2 {
+		t.Fatalf("Expected the first 2 of 3 records, got %v, %d, %t", records, current, complete)
+	}
+	if records[0].Task == nil || records[0].Task.Name != "a" || records[0].At.IsZero() {
+		t.Errorf("Expected the recorded task and time, got %+v", records[0])
+	}
+	if records[1].Revision != 2 || records[1].Task != nil {
+		t.Errorf("Expected revision 2 without a task, got %+v", records[1])
+	}
+}
+
+func TestFeed_Wait(t *testing.T) {
+	f := NewFeed(10)
+
+	done := make(chan uint64)
+	go func() {
+		rev, _ := f.Wait(context.Background(), 0)
+		done <- rev
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	f.Record(1, OpCreated)
+
+	select {
+	case rev := <-done:
+		if rev != 1 {
+			t.Errorf("Expected revision 1, got %d", rev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected Wait to return after Record")
+	}
+
+	// Already past the revision: returns immediately
+	if rev, err := f.Wait(context.Background(), 0); rev != 1 || err != nil {
+		t.Errorf("Expected immediate return, got %d, %v", rev, err)
+	}
+}
+
+func TestFeed_WaitTimeout(t *testing.T) {
+	f := NewFeed(10)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	rev, err := f.Wait(ctx, 0)
+	if rev != 0 || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected timeout at revision 0, got %d, %v", rev, err)
+	}
+}
+
+func TestTrackingStore(t *testing.T) {
+	f := NewFeed(10)
+	var store storage.Store = NewTrackingStore(storetest.NewFakeStore(), f)
+
+	task := &entities.Task{Name: "task"}
+	store.Create(task)
+	store.Update(task.ID, &entities.Task{Name: "updated"})
+	store.Update(99, &entities.Task{Name: "missing"})
+	store.Delete(task.ID)
+	store.Delete(task.ID)
+
+	list, current, _ := f.Since(0)
+	if current != 3 {
+		t.Fatalf("Expected only successful writes to be recorded, got revision %d", current)
+	}
+	ops := []Op{OpCreated, OpUpdated, OpDeleted}
+	for i, op := range ops {
+		if list[i].Op != op || list[i].TaskID != task.ID {
+			t.Errorf("Expected %s of task %d, got %+v", op, task.ID, list[i])
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package changes
+
+import (
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage"
+)
+
+// TrackingStore wraps a storage.Store and records every successful write in a Feed
+type TrackingStore struct {
+	inner storage.Store
+	feed  *Feed
+}
+
+// NewTrackingStore wraps inner, recording its writes in feed
+func NewTrackingStore(inner storage.Store, feed *Feed) *TrackingStore {
+	return &TrackingStore{inner: inner, feed: feed}
+}
+
+// Inner returns the wrapped store
+func (s *TrackingStore) Inner() storage.Store {
+	return s.inner
+}
+
+// Create stores the task and records the change
+func (s *TrackingStore) Create(task *entities.Task) *apperrors.AppError {
+	if err := s.inner.Create(task); err != nil {
+		return err
+	}
+	s.feed.RecordTask(task.ID, OpCreated, snapshot(task, task.ID))
+	return nil
+}
+
+// GetByID reads from the inner store
+func (s *TrackingStore) GetByID(id int) (*entities.Task, *apperrors.AppError) {
+	return s.inner.GetByID(id)
+}
+
+// GetAll reads from the inner store
+func (s *TrackingStore) GetAll() []*entities.Task {
+	return s.inner.GetAll()
+}
+
+// GetRange reads from the inner store
+func (s *TrackingStore) GetRange(fromID, toID, limit int) []*entities.Task {
+	return s.inner.GetRange(fromID, toID, limit)
+}
+
+// Update modifies the task and records the change
+func (s *TrackingStore) Update(id int, task *entities.Task) *apperrors.AppError {
+	if err := s.inner.Update(id, task); err != nil {
+		return err
+	}
+	s.feed.RecordTask(id, OpUpdated, snapshot(task, id))
+	return nil
+}
+
+// Delete removes the task and records the change
+func (s *TrackingStore) Delete(id int) *apperrors.AppError {
+	if err := s.inner.Delete(id); err != nil {
+		return err
+	}
+	s.feed.Record(id, OpDeleted)
+	return nil
+}
+
+// DeletedWhere records the deletes of a storage.BulkDeleter below the store
+func (s *TrackingStore) DeletedWhere(ids []int) {
+	for _, id := range ids {
+		s.feed.Record(id, OpDeleted)
+	}
+}
+
+// snapshot copies the task a write stored, for the feed to keep. Tasks share
+// their checklists when copied and nothing edits one in place, so a shallow
+// copy holds.
+func snapshot(task *entities.Task, id int) *entities.Task {
+	t := *task
+	t.ID = id
+	return &t
+}
+
+// Close closes the inner store if it supports closing
+func (s *TrackingStore) Close() error {
+	if closer, ok := s.inner.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package coalesce
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Package coalesce provides a short-lived shared response cache with
+// singleflight, so many concurrent identical requests are served by a single
+// computation of the response body.
+
+// Loader produces the response body to be shared
+type Loader func() ([]byte, error)
+
+// Stats counts how requests were served
+type Stats struct {
+	Hits   uint64 `json:"hits"`   // Served from the cached body
+	Shared uint64 `json:"shared"` // Joined an in-flight computation
+	Misses uint64 `json:"misses"` // Computed the body
+}
+
+// Group caches one response body for ttl and coalesces concurrent loads.
+// Invalidate drops the cached body; loads started before an invalidation are
+// neither cached nor shared with callers arriving after it.
+type Group struct {
+	ttl time.Duration
+	sf  singleflight.Group
+
+	mu         sync.RWMutex
+	body       []byte
+	expires    time.Time
+	generation uint64 // Bumped on every invalidation
+
+	hits, shared, misses atomic.Uint64
+}
+
+// New creates a Group whose cached body lives for ttl
+func New(ttl time.Duration) *Group {
+	return &Group{ttl: ttl}
+}
+
+// Get returns the cached body if fresh, otherwise loads it once for all
+// concurrent callers of the current generation.
+func (g *Group) Get(load Loader) ([]byte, error) {
+	g.mu.RLock()
+	body, expires, generation := g.body, g.expires, g.generation
+	g.mu.RUnlock()
+
+	if body != nil && time.Now().Before(expires) {
+		g.hits.Add(1)
+		return body, nil
+	}
+
+	key := strconv.FormatUint(generation, 10)
+	leader := false
+	v, err, _ := g.sf.Do(key, func() (interface{}, error) {
+		leader = true
+		g.misses.Add(1)
+		body, err := load()
+		if err != nil {
+			return nil, err
+		}
+
+		g.mu.Lock()
+		if g.generation == generation {
+			g.body = body
+			g.expires = time.Now().Add(g.ttl)
+		}
+		g.mu.Unlock()
+		return body, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !leader {
+		g.shared.Add(1)
+	}
+	return v.([]byte), nil
+}
+
+// Invalidate drops the cached body, e.g. after a write
+func (g *Group) Invalidate() {
+	g.mu.Lock()
+	g.generation++
+	g.body = nil
+	g.mu.Unlock()
+}
+
+// Stats returns a snapshot of the hit/shared/miss counters
+func (g *Group) Stats() Stats {
+	return Stats{
+		Hits:   g.hits.Load(),
+		Shared: g.shared.Load(),
+		Misses: g.misses.Load(),
+	}
+}
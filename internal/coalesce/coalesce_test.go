@@ -0,0 +1,131 @@
+package coalesce
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroup_CachesWithinTTL(t *testing.T) {
+	g := New(time.Minute)
+	var loads atomic.Int32
+	load := func() ([]byte, error) {
+		loads.Add(1)
+		return []byte("[]"), nil
+	}
+
+	for i := 0; i < 5; i++ {
+		body, err := g.Get(load)
+		if err != nil || string(body) != "[]" {
+			t.Fatalf("Unexpected result %q, %v", body, err)
+		}
+	}
+
+	if loads.Load() != 1 {
+		t.Errorf("Expected 1 load, got %d", loads.Load())
+	}
+	if stats := g.Stats(); stats.Hits != 4 || stats.Misses != 1 {
+		t.Errorf("Expected 4 hits and 1 miss, got %+v", stats)
+	}
+}
+
+func TestGroup_ExpiresAfterTTL(t *testing.T) {
+	g := New(10 * time.Millisecond)
+	var loads atomic.Int32
+	load := func() ([]byte, error) {
+		loads.Add(1)
+		return []byte("[]"), nil
+	}
+
+	g.Get(load)
+	time.Sleep(20 * time.Millisecond)
+	g.Get(load)
+
+	if loads.Load() != 2 {
+		t.Errorf("Expected reload after TTL, got %d loads", loads.Load())
+	}
+}
+
+func TestGroup_CoalescesConcurrentLoads(t *testing.T) {
+	g := New(time.Minute)
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func() ([]byte, error) {
+		loads.Add(1)
+		<-release
+		return []byte("[]"), nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.Get(load)
+		}()
+	}
+
+	// Give goroutines time to pile up on the in-flight load
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if loads.Load() != 1 {
+		t.Errorf("Expected a single load for concurrent callers, got %d", loads.Load())
+	}
+	if stats := g.Stats(); stats.Misses+stats.Shared+stats.Hits != 100 {
+		t.Errorf("Expected 100 accounted calls, got %+v", stats)
+	}
+}
+
+func TestGroup_Invalidate(t *testing.T) {
+	g := New(time.Minute)
+	version := "v1"
+	load := func() ([]byte, error) { return []byte(version), nil }
+
+	g.Get(load)
+	version = "v2"
+	if body, _ := g.Get(load); string(body) != "v1" {
+		t.Fatalf("Expected cached v1, got %s", body)
+	}
+
+	g.Invalidate()
+	if body, _ := g.Get(load); string(body) != "v2" {
+		t.Errorf("Expected v2 after invalidation, got %s", body)
+	}
+}
+
+func TestGroup_InvalidateDuringLoadIsNotCached(t *testing.T) {
+	g := New(time.Minute)
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	go g.Get(func() ([]byte, error) {
+		close(started)
+		<-release
+		return []byte("stale"), nil
+	})
+
+	<-started
+	g.Invalidate()
+	close(release)
+
+	body, _ := g.Get(func() ([]byte, error) { return []byte("fresh"), nil })
+	if string(body) != "fresh" {
+		t.Errorf("Expected fresh body after invalidation during load, got %s", body)
+	}
+}
+
+func TestGroup_ErrorsAreNotCached(t *testing.T) {
+	g := New(time.Minute)
+
+	if _, err := g.Get(func() ([]byte, error) { return nil, errors.New("boom") }); err == nil {
+		t.Fatal("Expected load error to propagate")
+	}
+	body, err := g.Get(func() ([]byte, error) { return []byte("ok"), nil })
+	if err != nil || string(body) != "ok" {
+		t.Errorf("Expected successful reload after error, got %q, %v", body, err)
+	}
+}
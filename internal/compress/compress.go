@@ -0,0 +1,186 @@
+package compress
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Package compress compresses task payloads before durable backends persist
+// them. Payloads at least as large as a threshold are compressed with snappy
+// or zstd and tagged with the codec, so payloads written under another codec,
+// or before compression was enabled, stay readable. Compression runs before
+// encryption (see crypt), which leaves nothing to compress.
+
+// Compressed payload layout: magic u8 codec u8 data
+const (
+	magic      = 0xC5 // Starts neither JSON nor a sealed payload, so compressed data can't be mistaken for either
+	headerSize = 2
+
+	// MaxSize bounds a decompressed payload, so a corrupt length can't make
+	// a read allocate without limit
+	MaxSize = 16 << 20
+
+	// DefaultThreshold is the smallest payload compressed when none is
+	// configured; below it the codecs rarely save more than the header
+	DefaultThreshold = 256
+)
+
+var ErrCorrupt = errors.New("compressed payload is corrupt")
+
+// Codec names a compression algorithm. Its value is stored in the payload
+// header, so values must not change.
+type Codec uint8
+
+const (
+	None   Codec = 0
+	Snappy Codec = 1 // Fast, modest ratio
+	Zstd   Codec = 2 // Slower, better ratio
+)
+
+var codecNames = map[Codec]string{None: "none", Snappy: "snappy", Zstd: "zstd"}
+
+// String returns the name of the codec, as ParseCodec takes it
+func (c Codec) String() string {
+	if name, ok := codecNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("codec(%d)", uint8(c))
+}
+
+// ParseCodec parses "none", "snappy" or "zstd"
+func ParseCodec(s string) (Codec, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for c, name := range codecNames {
+		if name == s {
+			return c, nil
+		}
+	}
+	return None, fmt.Errorf("invalid compression codec %q (want none, snappy or zstd)", s)
+}
+
+// Config configures a Compressor
+type Config struct {
+	Codec     Codec
+	Threshold int // Smallest payload compressed, in bytes; 0 uses DefaultThreshold
+}
+
+// Compressor compresses payloads with one codec. A nil *Compressor is valid
+// and leaves payloads as they are, so stores can call it unconditionally.
+type Compressor struct {
+	codec     Codec
+	threshold int
+}
+
+// New creates a Compressor. It returns nil, leaving payloads uncompressed,
+// for None.
+func New(cfg Config) (*Compressor, error) {
+	if _, ok := codecNames[cfg.Codec]; !ok {
+		return nil, fmt.Errorf("unknown compression codec %s", cfg.Codec)
+	}
+	if cfg.Threshold < 0 {
+		return nil, fmt.Errorf("compression threshold must not be negative")
+	}
+	if cfg.Codec == None {
+		return nil, nil
+	}
+	if cfg.Threshold == 0 {
+		cfg.Threshold = DefaultThreshold
+	}
+	return &Compressor{codec: cfg.Codec, threshold: cfg.Threshold}, nil
+}
+
+// Enabled reports whether payloads are compressed
+func (c *Compressor) Enabled() bool {
+	return c != nil
+}
+
+// Codec returns the codec compressing new payloads
+func (c *Compressor) Codec() Codec {
+	if c == nil {
+		return None
+	}
+	return c.codec
+}
+
+// Compress compresses data when it reaches the threshold and the codec
+// makes it smaller; otherwise, and for a nil Compressor, data is returned as
+// is
+func (c *Compressor) Compress(data []byte) []byte {
+	if c == nil || len(data) < c.threshold || len(data) > MaxSize {
+		return data
+	}
+	out := make([]byte, headerSize, headerSize+len(data))
+	out[0], out[1] = magic, byte(c.codec)
+	switch c.codec {
+	case Snappy:
+		out = append(out, snappy.Encode(nil, data)...)
+	case Zstd:
+		out = zstdEncoder().EncodeAll(data, out)
+	}
+	if len(out) >= len(data) {
+		return data // Incompressible
+	}
+	return out
+}
+
+// Decompress returns the payload data was compressed from, with whichever
+// codec compressed it. Data that isn't compressed is returned as is, so it
+// needs no Compressor: stores stay readable after compression is turned off.
+func Decompress(data []byte) ([]byte, error) {
+	codec, ok := CodecOf(data)
+	if !ok {
+		return data, nil
+	}
+	body := data[headerSize:]
+	switch codec {
+	case Snappy:
+		if n, err := snappy.DecodedLen(body); err != nil || n > MaxSize {
+			return nil, ErrCorrupt
+		}
+		out, err := snappy.Decode(nil, body)
+		if err != nil {
+			return nil, ErrCorrupt
+		}
+		return out, nil
+	case Zstd:
+		out, err := zstdDecoder().DecodeAll(body, nil)
+		if err != nil {
+			return nil, ErrCorrupt
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("%w: unknown %s", ErrCorrupt, codec)
+}
+
+// CodecOf returns the codec data was compressed with; ok is false for data
+// that isn't compressed
+func CodecOf(data []byte) (codec Codec, ok bool) {
+	if len(data) < headerSize || data[0] != magic || data[1] == byte(None) {
+		return None, false
+	}
+	return Codec(data[1]), true
+}
+
+// The zstd encoder and decoder hold sizeable state and are safe for
+// concurrent EncodeAll and DecodeAll, so one of each serves the process
+var (
+	zstdEncoder = sync.OnceValue(func() *zstd.Encoder {
+		enc, err := zstd.NewWriter(nil)
+		if err != nil {
+			panic(err) // Only invalid options fail
+		}
+		return enc
+	})
+	zstdDecoder = sync.OnceValue(func() *zstd.Decoder {
+		dec, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(MaxSize))
+		if err != nil {
+			panic(err) // Only invalid options fail
+		}
+		return dec
+	})
+)
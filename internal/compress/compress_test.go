@@ -0,0 +1,118 @@
+package compress
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"tasks-service-demo/internal/entities"
+)
+
+// largeTask is the JSON of a task with a long checklist, the kind of entry
+// compression is for
+func largeTask(subtasks int) []byte {
+	task := &entities.Task{ID: 42, Name: "Prepare the quarterly release and its announcement", Status: 1}
+	list := make([]entities.Subtask, subtasks)
+	for i := range list {
+		list[i] = entities.Subtask{ID: i + 1, Name: fmt.Sprintf("Review section %d of the release notes with the docs team", i+1), Done: i%3 == 0}
+	}
+	task.SetSubtasks(list)
+	data, _ := json.Marshal(task)
+	return data
+}
+
+func TestCompressor_RoundTrip(t *testing.T) {
+	data := largeTask(20)
+	for _, codec := range []Codec{Snappy, Zstd} {
+		c, err := New(Config{Codec: codec})
+		if err != nil {
+			t.Fatal(err)
+		}
+		packed := c.Compress(data)
+		if got, ok := CodecOf(packed); !ok || got != codec || len(packed) >= len(data)/2 {
+			t.Errorf("%s: expected %d bytes compressed by at least half, got %d bytes tagged %s", codec, len(data), len(packed), got)
+		}
+		if got, err := Decompress(packed); err != nil || !bytes.Equal(got, data) {
+			t.Errorf("%s: expected the payload back, got %v", codec, err)
+		}
+
+		if _, err := Decompress(packed[:len(packed)-8]); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("%s: expected a truncated payload to fail, got %v", codec, err)
+		}
+	}
+}
+
+func TestCompressor_LeavesPayloads(t *testing.T) {
+	c, _ := New(Config{Codec: Zstd, Threshold: 64})
+	small := []byte(`{"id":1,"name":"short"}`)
+	random := make([]byte, 1024)
+	rand.Read(random)
+	for name, data := range map[string][]byte{"below the threshold": small, "incompressible": random} {
+		if got := c.Compress(data); !bytes.Equal(got, data) {
+			t.Errorf("Expected the payload %s left as is", name)
+		}
+	}
+
+	// Payloads written before compression was enabled, or after it was
+	// turned off, read without a Compressor
+	var off *Compressor
+	data := largeTask(5)
+	if got := off.Compress(data); !bytes.Equal(got, data) || off.Enabled() || off.Codec() != None {
+		t.Error("Expected a nil Compressor to leave payloads as they are")
+	}
+	if got, err := Decompress(data); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("Expected uncompressed data as is, got %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if c, err := New(Config{}); c != nil || err != nil {
+		t.Errorf("Expected no Compressor for None, got %v, %v", c, err)
+	}
+	for _, cfg := range []Config{{Codec: 9}, {Codec: Snappy, Threshold: -1}} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("Expected %+v rejected", cfg)
+		}
+	}
+}
+
+func TestParseCodec(t *testing.T) {
+	for s, expected := range map[string]Codec{"none": None, "snappy": Snappy, " ZSTD ": Zstd} {
+		if got, err := ParseCodec(s); err != nil || got != expected {
+			t.Errorf("ParseCodec(%q) = %s, %v", s, got, err)
+		}
+	}
+	if _, err := ParseCodec("gzip"); err == nil || !strings.Contains(err.Error(), "gzip") {
+		t.Errorf("Expected gzip rejected, got %v", err)
+	}
+}
+
+// BenchmarkCodecs compresses and decompresses the JSON of a task with 50
+// subtasks (about 4KB) with each codec, reporting the compressed size
+func BenchmarkCodecs(b *testing.B) {
+	data := largeTask(50)
+	for _, codec := range []Codec{Snappy, Zstd} {
+		c, _ := New(Config{Codec: codec})
+		packed := c.Compress(data)
+
+		b.Run(codec.String()+"/compress", func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				c.Compress(data)
+			}
+			b.ReportMetric(float64(len(packed)), "stored-bytes")
+		})
+		b.Run(codec.String()+"/decompress", func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				if _, err := Decompress(packed); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
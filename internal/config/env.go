@@ -0,0 +1,259 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Package config reads settings from the environment, recording the resolved
+// value of every setting for the startup dump and every invalid one for an
+// aggregated validation report.
+
+// Masked replaces the value of secret settings in Entries and Fields
+const Masked = "****"
+
+// Entry is one resolved setting
+type Entry struct {
+	Key     string
+	Value   string
+	Default bool // Not set in the environment
+	Secret  bool
+}
+
+// Env resolves settings from a lookup function, usually os.LookupEnv
+type Env struct {
+	lookup   func(string) (string, bool)
+	entries  map[string]Entry
+	problems []string
+}
+
+// New creates an Env reading from lookup
+func New(lookup func(string) (string, bool)) *Env {
+	return &Env{lookup: lookup, entries: make(map[string]Entry)}
+}
+
+// FromEnviron creates an Env reading the process environment
+func FromEnviron() *Env {
+	return New(os.LookupEnv)
+}
+
+// get returns the raw value of key; empty values count as unset
+func (e *Env) get(key string) (string, bool) {
+	s, ok := e.lookup(key)
+	if !ok || s == "" {
+		return "", false
+	}
+	return s, true
+}
+
+func (e *Env) record(key, value string, isDefault, secret bool) {
+	e.entries[key] = Entry{Key: key, Value: value, Default: isDefault, Secret: secret}
+}
+
+// Invalid records a problem with key. It is also used for settings that are
+// only checked once they are combined, such as CORS origins and credentials.
+func (e *Env) Invalid(key string, err error) {
+	e.problems = append(e.problems, fmt.Sprintf("%s: %v", key, err))
+}
+
+// String returns key, or def when unset
+func (e *Env) String(key, def string) string {
+	s, ok := e.get(key)
+	if !ok {
+		s = def
+	}
+	e.record(key, s, !ok, false)
+	return s
+}
+
+// Lookup is String for settings where an explicitly empty value differs from unset
+func (e *Env) Lookup(key, def string) string {
+	s, ok := e.lookup(key)
+	if !ok {
+		s = def
+	}
+	e.record(key, s, !ok, false)
+	return s
+}
+
+// Secret returns key, or "" when unset; the value is masked in the dump
+func (e *Env) Secret(key string) string {
+	s, ok := e.get(key)
+	e.record(key, s, !ok, true)
+	return s
+}
+
+// OneOf returns key, which must be one of allowed, or def when unset
+func (e *Env) OneOf(key, def string, allowed ...string) string {
+	s := e.String(key, def)
+	for _, a := range allowed {
+		if s == a {
+			return s
+		}
+	}
+	e.Invalid(key, fmt.Errorf("%q is not one of %s", s, strings.Join(allowed, ", ")))
+	return def
+}
+
+// Bool returns key parsed with strconv.ParseBool, or def when unset
+func (e *Env) Bool(key string, def bool) bool {
+	return Parse(e, key, def, strconv.ParseBool)
+}
+
+// Int returns key, which must be at least min, or def when unset
+func (e *Env) Int(key string, def, min int) int {
+	return Parse(e, key, def, func(s string) (int, error) {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not an integer", s)
+		}
+		if n < min {
+			return 0, fmt.Errorf("%d is below the minimum of %d", n, min)
+		}
+		return n, nil
+	})
+}
+
+// Float returns key, which must lie within [min, max], or def when unset
+func (e *Env) Float(key string, def, min, max float64) float64 {
+	return Parse(e, key, def, func(s string) (float64, error) {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", s)
+		}
+		if f < min || f > max {
+			return 0, fmt.Errorf("%g is outside [%g, %g]", f, min, max)
+		}
+		return f, nil
+	})
+}
+
+// Duration returns key, which must be positive (or zero when allowZero), or def when unset
+func (e *Env) Duration(key string, def time.Duration, allowZero bool) time.Duration {
+	return Parse(e, key, def, func(s string) (time.Duration, error) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a duration", s)
+		}
+		if d < 0 || (d == 0 && !allowZero) {
+			return 0, fmt.Errorf("%s must be positive", d)
+		}
+		return d, nil
+	})
+}
+
+// Parse returns key converted by parse, or def when unset. Invalid values are
+// recorded and def is returned so loading can continue and report them all.
+func Parse[T any](e *Env, key string, def T, parse func(string) (T, error)) T {
+	s, ok := e.get(key)
+	if !ok {
+		e.record(key, display(def), true, false)
+		return def
+	}
+	e.record(key, s, false, false)
+
+	v, err := parse(s)
+	if err != nil {
+		e.Invalid(key, err)
+		return def
+	}
+	return v
+}
+
+// display formats a default value; empty maps and slices show as unset
+func display(v interface{}) string {
+	if rv := reflect.ValueOf(v); (rv.Kind() == reflect.Map || rv.Kind() == reflect.Slice) && rv.Len() == 0 {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// ParseSecret is Parse for settings whose value is masked in the dump
+func ParseSecret[T any](e *Env, key string, def T, parse func(string) (T, error)) T {
+	v := Parse(e, key, def, parse)
+	entry := e.entries[key]
+	entry.Secret = true
+	if entry.Default {
+		entry.Value = ""
+	}
+	e.entries[key] = entry
+	return v
+}
+
+// Error is the aggregated validation report
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d invalid settings:\n  %s", len(e.Problems), strings.Join(e.Problems, "\n  "))
+}
+
+// Err returns every problem found so far, or nil
+func (e *Env) Err() error {
+	if len(e.problems) == 0 {
+		return nil
+	}
+	return &Error{Problems: append([]string(nil), e.problems...)}
+}
+
+// Entries returns the resolved settings sorted by key, with secrets masked
+func (e *Env) Entries() []Entry {
+	entries := make([]Entry, 0, len(e.entries))
+	for _, entry := range e.entries {
+		if entry.Secret && entry.Value != "" {
+			entry.Value = Masked
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries
+}
+
+// Changed returns the keys whose resolved value differs between e and next, sorted
+func (e *Env) Changed(next *Env) []string {
+	var keys []string
+	for key, entry := range next.entries {
+		if prev, ok := e.entries[key]; !ok || prev.Value != entry.Value {
+			keys = append(keys, key)
+		}
+	}
+	for key := range e.entries {
+		if _, ok := next.entries[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// With returns a copy of e with the entries of keys taken from next
+func (e *Env) With(next *Env, keys ...string) *Env {
+	merged := New(e.lookup)
+	for key, entry := range e.entries {
+		merged.entries[key] = entry
+	}
+	for _, key := range keys {
+		if entry, ok := next.entries[key]; ok {
+			merged.entries[key] = entry
+		} else {
+			delete(merged.entries, key)
+		}
+	}
+	return merged
+}
+
+// Fields returns the resolved settings as zap key/value pairs, with secrets masked
+func (e *Env) Fields() []interface{} {
+	entries := e.Entries()
+	fields := make([]interface{}, 0, 2*len(entries))
+	for _, entry := range entries {
+		fields = append(fields, entry.Key, entry.Value)
+	}
+	return fields
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func newEnv(vars map[string]string) *Env {
+	return New(func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	})
+}
+
+func TestEnv_Defaults(t *testing.T) {
+	env := newEnv(nil)
+
+	if got := env.String("S", "def"); got != "def" {
+		t.Errorf("Expected def, got %s", got)
+	}
+	if got := env.Int("I", 3, 1); got != 3 {
+		t.Errorf("Expected 3, got %d", got)
+	}
+	if got := env.Duration("D", time.Second, false); got != time.Second {
+		t.Errorf("Expected 1s, got %s", got)
+	}
+	if err := env.Err(); err != nil {
+		t.Errorf("Expected no problems, got %v", err)
+	}
+
+	for _, entry := range env.Entries() {
+		if !entry.Default {
+			t.Errorf("Expected %s to be a default", entry.Key)
+		}
+	}
+}
+
+func TestEnv_AggregatesProblems(t *testing.T) {
+	env := newEnv(map[string]string{
+		"I":    "x",
+		"MIN":  "0",
+		"F":    "2",
+		"D":    "-1s",
+		"B":    "maybe",
+		"ONE":  "c",
+		"GOOD": "7",
+	})
+
+	env.Int("I", 1, 0)
+	env.Int("MIN", 1, 1)
+	env.Float("F", 0.5, 0, 1)
+	env.Duration("D", time.Second, true)
+	env.Bool("B", false)
+	if got := env.OneOf("ONE", "a", "a", "b"); got != "a" {
+		t.Errorf("Expected fallback to default, got %s", got)
+	}
+	if got := env.Int("GOOD", 1, 0); got != 7 {
+		t.Errorf("Expected 7, got %d", got)
+	}
+
+	err := env.Err()
+	cfgErr, ok := err.(*Error)
+	if !ok || len(cfgErr.Problems) != 6 {
+		t.Fatalf("Expected 6 problems, got %v", err)
+	}
+	for _, key := range []string{"I", "MIN", "F", "D", "B", "ONE"} {
+		if !strings.Contains(err.Error(), key+": ") {
+			t.Errorf("Expected report to mention %s, got %v", key, err)
+		}
+	}
+}
+
+func TestEnv_MasksSecrets(t *testing.T) {
+	env := newEnv(map[string]string{"TOKEN": "hunter2", "KEYS": "k:v"})
+	env.Secret("TOKEN")
+	env.Secret("UNSET")
+	ParseSecret(env, "KEYS", "", func(s string) (string, error) { return s, nil })
+	env.String("NAME", "svc")
+
+	fields := env.Fields()
+	expected := []interface{}{"KEYS", Masked, "NAME", "svc", "TOKEN", Masked, "UNSET", ""}
+	if len(fields) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, fields)
+	}
+	for i := range expected {
+		if fields[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, fields)
+			break
+		}
+	}
+}
+
+func TestEnv_Lookup(t *testing.T) {
+	env := newEnv(map[string]string{"PREFIX": ""})
+	if got := env.Lookup("PREFIX", "exports/"); got != "" {
+		t.Errorf("Expected explicit empty value, got %q", got)
+	}
+	if got := env.Lookup("OTHER", "exports/"); got != "exports/" {
+		t.Errorf("Expected default, got %q", got)
+	}
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultWatchDebounce coalesces the burst of events of a single save
+const DefaultWatchDebounce = 200 * time.Millisecond
+
+// Watch calls onChange after path is written or replaced, until ctx is done.
+// The parent directory is watched, since editors and config management often
+// save by renaming a new file over the old one. Events within debounce of each
+// other trigger one call. onErr receives watcher errors; it may be nil.
+func Watch(ctx context.Context, path string, debounce time.Duration, onChange func(), onErr func(error)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	path = filepath.Clean(path)
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		var timer *time.Timer
+		var fire <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			case ev, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(ev.Name) != path || !ev.Has(fsnotify.Write) && !ev.Has(fsnotify.Create) {
+					continue
+				}
+				if timer == nil {
+					timer = time.NewTimer(debounce)
+				} else {
+					timer.Reset(debounce)
+				}
+				fire = timer.C
+			case <-fire:
+				fire = nil
+				onChange()
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				if onErr != nil {
+					onErr(err)
+				}
+			}
+		}
+	}()
+	return nil
+}
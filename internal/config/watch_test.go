@@ -0,0 +1,54 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.env")
+	if err := os.WriteFile(path, []byte("LOG_LEVEL=info\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changed := make(chan struct{}, 10)
+	if err := Watch(ctx, path, 20*time.Millisecond, func() { changed <- struct{}{} }, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Other files in the directory are ignored
+	os.WriteFile(filepath.Join(dir, "other.env"), []byte("X=1\n"), 0o644)
+
+	// Several writes in quick succession trigger one call
+	for i := 0; i < 3; i++ {
+		os.WriteFile(path, []byte("LOG_LEVEL=debug\n"), 0o644)
+	}
+	select {
+	case <-changed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a change notification")
+	}
+	select {
+	case <-changed:
+		t.Error("Expected writes to be debounced into one call")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Replacing the file by rename is also a change
+	tmp := filepath.Join(dir, "app.env.tmp")
+	os.WriteFile(tmp, []byte("LOG_LEVEL=warn\n"), 0o644)
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a notification for a replaced file")
+	}
+}
@@ -0,0 +1,223 @@
+package crypt
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Package crypt encrypts task payloads before durable backends and files
+// persist them, with AES-GCM under named keys. Every sealed payload records
+// the ID of the key that sealed it, so keys can be rotated: the first key
+// seals new payloads and every configured key opens old ones.
+
+// Sealed payload layout: magic u8 idLen u8 keyID[idLen] nonce[12] ciphertext+tag
+const (
+	magic       = 0xEC // Starts neither JSON nor gzip, so sealed data can't be mistaken for plaintext
+	MaxKeyIDLen = 32
+	nonceSize   = 12
+)
+
+var (
+	ErrNoKeys      = errors.New("payload is encrypted but no encryption keys are configured")
+	ErrUnknownKey  = errors.New("payload is encrypted with a key that is not configured")
+	ErrCorrupt     = errors.New("encrypted payload is corrupt or was tampered with")
+	ErrKeyRequired = errors.New("at least one encryption key is required")
+)
+
+// Key is a named AES key of 16, 24 or 32 bytes
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// KeyProvider supplies the encryption keys, the first one sealing new
+// payloads. Implement it to fetch or unwrap keys from a KMS; StaticKeys
+// serves keys read from configuration.
+type KeyProvider interface {
+	Keys(ctx context.Context) ([]Key, error)
+}
+
+// StaticKeys is a KeyProvider of fixed keys
+type StaticKeys []Key
+
+// Keys returns k
+func (k StaticKeys) Keys(context.Context) ([]Key, error) {
+	return k, nil
+}
+
+// ParseKeys parses comma-separated "kid:base64key" pairs
+func ParseKeys(s string) ([]Key, error) {
+	var keys []Key
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(pair, ":")
+		if !ok || id == "" || encoded == "" {
+			return nil, fmt.Errorf("invalid encryption key entry %q (want kid:base64key)", pair)
+		}
+		secret, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q is not valid base64", id)
+		}
+		keys = append(keys, Key{ID: id, Secret: secret})
+	}
+	if len(keys) > 0 {
+		if _, err := New(keys); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// Sealer encrypts and decrypts payloads. A nil *Sealer is valid and leaves
+// payloads in plaintext, so stores can call it unconditionally.
+type Sealer struct {
+	active string
+	aeads  map[string]cipher.AEAD
+}
+
+// New creates a Sealer from keys, the first being the active sealing key
+func New(keys []Key) (*Sealer, error) {
+	if len(keys) == 0 {
+		return nil, ErrKeyRequired
+	}
+	s := &Sealer{active: keys[0].ID, aeads: make(map[string]cipher.AEAD, len(keys))}
+	for _, k := range keys {
+		if k.ID == "" || len(k.ID) > MaxKeyIDLen {
+			return nil, fmt.Errorf("encryption key ID %q must be 1 to %d bytes", k.ID, MaxKeyIDLen)
+		}
+		if _, dup := s.aeads[k.ID]; dup {
+			return nil, fmt.Errorf("encryption key %q is configured twice", k.ID)
+		}
+		block, err := aes.NewCipher(k.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q: %w", k.ID, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		s.aeads[k.ID] = aead
+	}
+	return s, nil
+}
+
+// Load creates a Sealer from the keys of p. It returns nil, leaving
+// payloads in plaintext, when p has no keys.
+func Load(ctx context.Context, p KeyProvider) (*Sealer, error) {
+	keys, err := p.Keys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load encryption keys: %w", err)
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	return New(keys)
+}
+
+// Enabled reports whether payloads are encrypted
+func (s *Sealer) Enabled() bool {
+	return s != nil
+}
+
+// ActiveKey returns the ID of the key sealing new payloads
+func (s *Sealer) ActiveKey() string {
+	if s == nil {
+		return ""
+	}
+	return s.active
+}
+
+// Overhead is how many bytes Seal adds to a payload
+func (s *Sealer) Overhead() int {
+	if s == nil {
+		return 0
+	}
+	return 2 + len(s.active) + nonceSize + s.aeads[s.active].Overhead()
+}
+
+// Seal encrypts plaintext under the active key. aad is authenticated but not
+// stored; pass what identifies the payload, such as its record key, so a
+// sealed payload copied to another record fails to open. A nil Sealer
+// returns plaintext as is.
+func (s *Sealer) Seal(plaintext, aad []byte) []byte {
+	if s == nil {
+		return plaintext
+	}
+	out := make([]byte, 2+len(s.active)+nonceSize, s.Overhead()+len(plaintext))
+	out[0] = magic
+	out[1] = byte(len(s.active))
+	copy(out[2:], s.active)
+	nonce := out[2+len(s.active):]
+	if _, err := rand.Read(nonce); err != nil {
+		panic(err) // crypto/rand does not fail on supported platforms
+	}
+	return s.aeads[s.active].Seal(out, nonce, plaintext, aad)
+}
+
+// Open decrypts a payload sealed with any configured key. Data that isn't
+// sealed is returned as is, so stores written before encryption was enabled
+// stay readable.
+func (s *Sealer) Open(data, aad []byte) ([]byte, error) {
+	keyID, ok := KeyID(data)
+	if !ok {
+		return data, nil
+	}
+	if err := s.CanOpen(data); err != nil {
+		return nil, err
+	}
+	aead := s.aeads[keyID]
+	body := data[2+len(keyID):]
+	if len(body) < nonceSize+aead.Overhead() {
+		return nil, ErrCorrupt
+	}
+	plaintext, err := aead.Open(nil, body[:nonceSize], body[nonceSize:], aad)
+	if err != nil {
+		return nil, ErrCorrupt
+	}
+	return plaintext, nil
+}
+
+// CanOpen reports why Open would fail for lack of a key, without
+// decrypting data, so stores can refuse to start with a missing key instead
+// of failing every read
+func (s *Sealer) CanOpen(data []byte) error {
+	keyID, sealed := KeyID(data)
+	switch {
+	case !sealed:
+		return nil
+	case s == nil:
+		return ErrNoKeys
+	case s.aeads[keyID] == nil:
+		return fmt.Errorf("%w: %q", ErrUnknownKey, keyID)
+	}
+	return nil
+}
+
+// Current reports whether data is sealed under the active key, or is
+// plaintext while encryption is disabled; otherwise it should be rewritten
+// when rotating keys
+func (s *Sealer) Current(data []byte) bool {
+	keyID, sealed := KeyID(data)
+	if s == nil {
+		return !sealed
+	}
+	return sealed && keyID == s.active
+}
+
+// KeyID returns the ID of the key data was sealed with; ok is false for
+// plaintext
+func KeyID(data []byte) (id string, ok bool) {
+	if len(data) < 2 || data[0] != magic || len(data) < 2+int(data[1]) || data[1] == 0 {
+		return "", false
+	}
+	return string(data[2 : 2+int(data[1])]), true
+}
@@ -0,0 +1,90 @@
+package crypt
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func testKey(id string, b byte) Key {
+	return Key{ID: id, Secret: bytes.Repeat([]byte{b}, 32)}
+}
+
+func TestSealer_RoundTrip(t *testing.T) {
+	s, err := New([]Key{testKey("k1", 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := []byte(`{"id":1,"name":"secret"}`)
+	sealed := s.Seal(plaintext, []byte("task/1"))
+	if bytes.Contains(sealed, []byte("secret")) || len(sealed) != len(plaintext)+s.Overhead() {
+		t.Errorf("Expected %d sealed bytes without the plaintext, got %q", len(plaintext)+s.Overhead(), sealed)
+	}
+	if id, ok := KeyID(sealed); !ok || id != "k1" {
+		t.Errorf("Expected key ID k1, got %q, %v", id, ok)
+	}
+
+	if got, err := s.Open(sealed, []byte("task/1")); err != nil || !bytes.Equal(got, plaintext) {
+		t.Errorf("Expected the plaintext back, got %q, %v", got, err)
+	}
+	if _, err := s.Open(sealed, []byte("task/2")); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected a payload moved to another record to fail, got %v", err)
+	}
+	sealed[len(sealed)-1] ^= 1
+	if _, err := s.Open(sealed, []byte("task/1")); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected a tampered payload to fail, got %v", err)
+	}
+
+	// Plaintext written before encryption was enabled still reads
+	if got, err := s.Open(plaintext, nil); err != nil || !bytes.Equal(got, plaintext) {
+		t.Errorf("Expected plaintext as is, got %q, %v", got, err)
+	}
+}
+
+func TestSealer_Rotation(t *testing.T) {
+	old, _ := New([]Key{testKey("k1", 1)})
+	rotated, _ := New([]Key{testKey("k2", 2), testKey("k1", 1)})
+	retired, _ := New([]Key{testKey("k2", 2)})
+
+	sealed := old.Seal([]byte("task"), nil)
+	if got, err := rotated.Open(sealed, nil); err != nil || string(got) != "task" {
+		t.Errorf("Expected an old key to keep opening payloads, got %q, %v", got, err)
+	}
+	if rotated.Current(sealed) || !rotated.Current(rotated.Seal([]byte("task"), nil)) {
+		t.Error("Expected only payloads sealed under k2 to be current")
+	}
+	if _, err := retired.Open(sealed, nil); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Expected ErrUnknownKey once k1 is removed, got %v", err)
+	}
+
+	var disabled *Sealer
+	if got := disabled.Seal([]byte("task"), nil); string(got) != "task" || disabled.Overhead() != 0 {
+		t.Errorf("Expected a nil Sealer to leave payloads in plaintext, got %q", got)
+	}
+	if _, err := disabled.Open(sealed, nil); !errors.Is(err, ErrNoKeys) {
+		t.Errorf("Expected ErrNoKeys, got %v", err)
+	}
+	if disabled.Current(sealed) || !disabled.Current([]byte("task")) {
+		t.Error("Expected sealed payloads to be stale once encryption is off")
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 16))
+	keys, err := ParseKeys(" new:" + key + ", old:" + key + ",")
+	if err != nil || len(keys) != 2 || keys[0].ID != "new" || len(keys[1].Secret) != 16 {
+		t.Errorf("Expected two keys, got %+v, %v", keys, err)
+	}
+
+	for _, invalid := range []string{"new", "new:not-base64!", "new:" + base64.StdEncoding.EncodeToString([]byte("short")), "a:" + key + ",a:" + key} {
+		if _, err := ParseKeys(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+
+	if s, err := Load(context.Background(), StaticKeys(nil)); s != nil || err != nil {
+		t.Errorf("Expected no Sealer without keys, got %v, %v", s, err)
+	}
+}
@@ -0,0 +1,25 @@
+package dashboard
+
+import (
+	"embed"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/filesystem"
+)
+
+// Package dashboard serves the embedded single-page admin dashboard. The page
+// polls the stats endpoint next to it and draws its charts client-side,
+// without external assets.
+
+//go:embed static
+var static embed.FS
+
+// Handler serves the dashboard page and its assets; mount it with app.Use
+func Handler() fiber.Handler {
+	return filesystem.New(filesystem.Config{
+		Root:       http.FS(static),
+		PathPrefix: "static",
+		Index:      "index.html",
+	})
+}
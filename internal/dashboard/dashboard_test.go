@@ -0,0 +1,52 @@
+package dashboard
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestHandler(t *testing.T) {
+	app := fiber.New()
+	app.Use("/admin/dashboard", Handler())
+
+	tests := []struct {
+		path        string
+		contentType string
+		contains    string
+	}{
+		{"/admin/dashboard", "text/html", `<script src="app.js">`},
+		{"/admin/dashboard/app.js", "javascript", "STATS_URL"},
+		{"/admin/dashboard/style.css", "text/css", "canvas"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", tt.path, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != fiber.StatusOK {
+				t.Fatalf("Expected status %d, got %d", fiber.StatusOK, resp.StatusCode)
+			}
+			if got := resp.Header.Get("Content-Type"); !strings.Contains(got, tt.contentType) {
+				t.Errorf("Expected content type %s, got %s", tt.contentType, got)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if !strings.Contains(string(body), tt.contains) {
+				t.Errorf("Expected body to contain %q", tt.contains)
+			}
+		})
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/admin/dashboard/missing.js", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", fiber.StatusNotFound, resp.StatusCode)
+	}
+}
@@ -0,0 +1,117 @@
+// Polls the stats endpoint and redraws the dashboard. No dependencies.
+(function () {
+  "use strict";
+
+  var STATS_URL = "stats";
+  var POLL_MS = 2000;
+
+  function $(id) { return document.getElementById(id); }
+
+  function fmtMs(ms) { return ms >= 1 ? ms.toFixed(0) + " ms" : (ms * 1000).toFixed(0) + " µs"; }
+
+  function fmtUptime(s) {
+    var h = Math.floor(s / 3600), m = Math.floor((s % 3600) / 60);
+    return h > 0 ? h + "h " + m + "m" : m + "m " + (s % 60) + "s";
+  }
+
+  // prepare clears the canvas and returns its context with the plot area
+  function prepare(canvas) {
+    var ctx = canvas.getContext("2d");
+    ctx.clearRect(0, 0, canvas.width, canvas.height);
+    ctx.font = "11px system-ui, sans-serif";
+    ctx.fillStyle = "#888";
+    return { ctx: ctx, left: 40, top: 10, width: canvas.width - 50, height: canvas.height - 30 };
+  }
+
+  function axis(p, max) {
+    p.ctx.strokeStyle = "#e3e5e8";
+    p.ctx.beginPath();
+    p.ctx.moveTo(p.left, p.top);
+    p.ctx.lineTo(p.left, p.top + p.height);
+    p.ctx.lineTo(p.left + p.width, p.top + p.height);
+    p.ctx.stroke();
+    p.ctx.fillText(String(+max.toFixed(2)), 2, p.top + 8);
+    p.ctx.fillText("0", 2, p.top + p.height);
+  }
+
+  function lineChart(canvas, values, color) {
+    var p = prepare(canvas);
+    var max = Math.max.apply(null, values.concat([1]));
+    axis(p, max);
+    p.ctx.strokeStyle = color;
+    p.ctx.lineWidth = 2;
+    p.ctx.beginPath();
+    values.forEach(function (v, i) {
+      var x = p.left + (i / Math.max(values.length - 1, 1)) * p.width;
+      var y = p.top + p.height - (v / max) * p.height;
+      if (i === 0) { p.ctx.moveTo(x, y); } else { p.ctx.lineTo(x, y); }
+    });
+    p.ctx.stroke();
+    p.ctx.lineWidth = 1;
+  }
+
+  function barChart(canvas, values, color) {
+    var p = prepare(canvas);
+    var max = Math.max.apply(null, values.concat([1]));
+    axis(p, max);
+    var w = p.width / values.length;
+    p.ctx.fillStyle = color;
+    values.forEach(function (v, i) {
+      var h = (v / max) * p.height;
+      p.ctx.fillRect(p.left + i * w + 1, p.top + p.height - h, Math.max(w - 2, 1), h);
+    });
+  }
+
+  function render(s) {
+    $("rps").textContent = s.rps.toFixed(1);
+    $("p50").textContent = fmtMs(s.latencyMs.p50);
+    $("p95").textContent = fmtMs(s.latencyMs.p95);
+    $("p99").textContent = fmtMs(s.latencyMs.p99);
+    $("tasks").textContent = s.storage.tasks;
+    $("uptime").textContent = fmtUptime(s.uptimeSeconds);
+    $("storageType").textContent = "(" + s.storage.type + ")";
+
+    lineChart($("rpsChart"), s.series.map(function (p) { return p.requests; }), "#2f6feb");
+    lineChart($("latencyChart"), s.series.map(function (p) { return p.avgLatencyMs; }), "#d4a72c");
+
+    var shards = s.storage.shards || [];
+    $("shardChart").hidden = shards.length === 0;
+    $("noShards").hidden = shards.length > 0;
+    if (shards.length > 0) { barChart($("shardChart"), shards, "#1a7f37"); }
+
+    var rows = $("hotKeys");
+    rows.textContent = "";
+    if (s.hotKeys.length === 0) {
+      var empty = rows.insertRow();
+      var cell = empty.insertCell();
+      cell.colSpan = 2;
+      cell.className = "muted";
+      cell.textContent = "No traffic yet";
+    }
+    s.hotKeys.forEach(function (k) {
+      var row = rows.insertRow();
+      row.insertCell().textContent = k.key;
+      row.insertCell().textContent = k.hits;
+    });
+  }
+
+  function poll() {
+    fetch(STATS_URL, { credentials: "same-origin", cache: "no-store" })
+      .then(function (resp) {
+        if (!resp.ok) { throw new Error("HTTP " + resp.status); }
+        return resp.json();
+      })
+      .then(function (s) {
+        render(s);
+        $("status").textContent = "updated " + new Date().toLocaleTimeString();
+        $("status").className = "status";
+      })
+      .catch(function (err) {
+        $("status").textContent = "stats unavailable: " + err.message;
+        $("status").className = "status error";
+      })
+      .then(function () { setTimeout(poll, POLL_MS); });
+  }
+
+  poll();
+})();
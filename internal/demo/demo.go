@@ -0,0 +1,107 @@
+package demo
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/jobs"
+	"tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/storage"
+)
+
+// Package demo runs the service as a public playground: the store starts
+// with sample tasks and is reset to them on an interval, so whatever
+// visitors do is short-lived. Capping the store, rate limiting and refusing
+// destructive admin requests are left to memguard and the middleware
+// package, which main configures from Config.
+
+const (
+	DefaultMaxTasks      = 500       // Stored tasks at most, unless STORAGE_MAX_TASKS is lower
+	DefaultResetInterval = time.Hour // Period of the reset job
+	DefaultRateLimit     = 60        // Requests per minute per client address
+)
+
+// Config configures demo mode
+type Config struct {
+	Enabled       bool
+	MaxTasks      int
+	ResetInterval time.Duration
+	RateLimit     int // Requests per minute per client address; 0 disables the limit
+}
+
+// SampleTasks are the tasks a demo store starts with and is reset to
+var SampleTasks = []entities.Task{
+	{Name: "Read the API docs", Status: 1},
+	{Name: "Create your first task", Status: 1},
+	{Name: "Mark a task as complete"},
+	{Name: "Add subtasks to a task", Subtasks: []entities.Subtask{
+		{ID: 1, Name: "Open a task", Done: true},
+		{ID: 2, Name: "POST to /tasks/{id}/subtasks"},
+	}},
+	{Name: "Browse the dashboard"},
+	{Name: "Delete a task you no longer need"},
+}
+
+// Seed creates the sample tasks in store and returns how many it created
+func Seed(store storage.Store) (int, error) {
+	for i := range SampleTasks {
+		task := SampleTasks[i]
+		// Copy the checklist, so the stored task does not share the sample's
+		task.SetSubtasks(append([]entities.Subtask(nil), task.Subtasks...))
+		if err := store.Create(&task); err != nil {
+			return i, fmt.Errorf("seed task %d: %w", i, err)
+		}
+	}
+	return len(SampleTasks), nil
+}
+
+// Reset deletes every task in store and seeds it again. It returns how many
+// tasks it deleted.
+func Reset(ctx context.Context, store storage.Store) (int, error) {
+	deleted := 0
+	for _, task := range storage.Snapshot(store) {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+		if err := store.Delete(task.ID); err == nil {
+			deleted++
+		}
+	}
+	if _, err := Seed(store); err != nil {
+		return deleted, err
+	}
+	return deleted, nil
+}
+
+// Job returns the reset job for the global store
+func Job() jobs.Func {
+	return func(ctx context.Context, p *jobs.Progress) error {
+		deleted, err := Reset(ctx, storage.GetStore())
+		p.SetMessage(fmt.Sprintf("deleted %d tasks, seeded %d", deleted, len(SampleTasks)))
+		if err != nil {
+			return err
+		}
+		logger.For(logger.ModuleJobs).Infof("Demo store reset: deleted %d tasks", deleted)
+		return nil
+	}
+}
+
+// SeedHook is a warm-up hook that seeds the global store when it is empty,
+// so a restart of a durable demo does not add the samples twice
+func SeedHook() func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		store := storage.GetStore()
+		if len(store.GetRange(1, math.MaxInt, 1)) > 0 {
+			return nil
+		}
+		n, err := Seed(store)
+		if err != nil {
+			return err
+		}
+		logger.For(logger.ModuleStorage).Infof("Seeded %d demo tasks", n)
+		return nil
+	}
+}
@@ -0,0 +1,45 @@
+package demo
+
+import (
+	"context"
+	"testing"
+
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/storage/xsync"
+)
+
+func TestReset(t *testing.T) {
+	store := xsync.NewXSyncStore()
+	if n, err := Seed(store); err != nil || n != len(SampleTasks) {
+		t.Fatalf("Expected %d tasks seeded, got %d, %v", len(SampleTasks), n, err)
+	}
+	store.Create(&entities.Task{Name: "Visitor task"})
+
+	var withSubtasks *entities.Task
+	for _, task := range store.GetAll() {
+		if len(task.Subtasks) > 0 {
+			withSubtasks = task
+		}
+	}
+	if withSubtasks == nil || withSubtasks.Progress == nil || *withSubtasks.Progress != 50 {
+		t.Fatalf("Expected a seeded checklist with its progress, got %+v", withSubtasks)
+	}
+	withSubtasks.Subtasks[0].Name = "Edited"
+	if SampleTasks[3].Subtasks[0].Name == "Edited" {
+		t.Error("Expected seeded tasks not to share the samples' checklist")
+	}
+
+	deleted, err := Reset(context.Background(), store)
+	if err != nil || deleted != len(SampleTasks)+1 {
+		t.Fatalf("Expected every task deleted, got %d, %v", deleted, err)
+	}
+	tasks := store.GetAll()
+	if len(tasks) != len(SampleTasks) {
+		t.Fatalf("Expected only the samples after a reset, got %d tasks", len(tasks))
+	}
+	for _, task := range tasks {
+		if task.Name == "Visitor task" {
+			t.Error("Expected the visitor's task gone after a reset")
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package dlq
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tasks-service-demo/internal/logger"
+)
+
+// Package dlq keeps deliveries that exhausted their retries, such as emails
+// the SMTP relay kept refusing, so operators can inspect them and replay
+// them once the downstream system is back.
+
+// DefaultMaxEntries bounds the queue; the oldest entries are dropped beyond it
+const DefaultMaxEntries = 10000
+
+var (
+	// ErrNotFound is returned for unknown entry IDs
+	ErrNotFound = errors.New("dead-letter entry not found")
+	// ErrNoReplayer is returned when no replayer is registered for an entry's source
+	ErrNoReplayer = errors.New("no replayer registered for this source")
+)
+
+// Entry is a delivery that failed for good
+type Entry struct {
+	ID       string          `json:"id"`
+	Source   string          `json:"source"`  // Kind of delivery, selects the replayer
+	Payload  json.RawMessage `json:"payload"` // What the replayer delivers again
+	Error    string          `json:"error"`   // Last delivery error
+	Attempts int             `json:"attempts"`
+	FailedAt time.Time       `json:"failedAt"`
+}
+
+// Replayer redelivers the payload of a dead-letter entry. It returns a
+// reference to the new delivery (such as a job ID) once it is under way;
+// if that delivery fails too, it lands in the queue again as a new entry.
+type Replayer func(ctx context.Context, payload json.RawMessage) (string, error)
+
+// Config tunes a Queue
+type Config struct {
+	Path       string // JSON file the queue is persisted to; empty keeps it in memory
+	MaxEntries int
+}
+
+// Queue is a bounded list of dead-letter entries, oldest first
+type Queue struct {
+	cfg Config
+
+	mu        sync.Mutex
+	entries   []Entry
+	replayers map[string]Replayer
+	dropped   int64
+}
+
+// Open creates a Queue, loading the entries saved at cfg.Path if the file exists
+func Open(cfg Config) (*Queue, error) {
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = DefaultMaxEntries
+	}
+	q := &Queue{cfg: cfg, replayers: make(map[string]Replayer)}
+	if cfg.Path == "" {
+		return q, nil
+	}
+
+	data, err := os.ReadFile(cfg.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &q.entries); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", cfg.Path, err)
+	}
+	return q, nil
+}
+
+// New creates an in-memory Queue holding at most maxEntries
+func New(maxEntries int) *Queue {
+	q, _ := Open(Config{MaxEntries: maxEntries})
+	return q
+}
+
+// Register sets the replayer for entries from source
+func (q *Queue) Register(source string, r Replayer) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.replayers[source] = r
+}
+
+// Add records a failed delivery. payload is stored as JSON.
+func (q *Queue) Add(source string, payload any, attempts int, cause error) (Entry, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Entry{}, err
+	}
+	e := Entry{
+		ID:       newID(),
+		Source:   source,
+		Payload:  data,
+		Attempts: attempts,
+		FailedAt: time.Now(),
+	}
+	if cause != nil {
+		e.Error = cause.Error()
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.entries = append(q.entries, e)
+	if over := len(q.entries) - q.cfg.MaxEntries; over > 0 {
+		q.entries = append([]Entry(nil), q.entries[over:]...)
+		q.dropped += int64(over)
+	}
+	logger.For(logger.ModuleJobs).Warnw("Delivery moved to the dead-letter queue", "id", e.ID, "source", source, "attempts", attempts, "error", e.Error)
+	return e, q.saveLocked()
+}
+
+// List returns the entries, oldest first
+func (q *Queue) List() []Entry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]Entry(nil), q.entries...)
+}
+
+// Dropped returns how many entries were discarded to stay within MaxEntries
+func (q *Queue) Dropped() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.dropped
+}
+
+// Get returns the entry with id
+func (q *Queue) Get(id string) (Entry, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if i := q.indexLocked(id); i >= 0 {
+		return q.entries[i], true
+	}
+	return Entry{}, false
+}
+
+// Replay hands entry id to its source's replayer and removes it once the
+// replayer accepted it. A rejected replay keeps the entry.
+func (q *Queue) Replay(ctx context.Context, id string) (string, error) {
+	q.mu.Lock()
+	i := q.indexLocked(id)
+	if i < 0 {
+		q.mu.Unlock()
+		return "", ErrNotFound
+	}
+	e := q.entries[i]
+	replay, ok := q.replayers[e.Source]
+	q.mu.Unlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNoReplayer, e.Source)
+	}
+
+	ref, err := replay(ctx, e.Payload)
+	if err != nil {
+		return "", err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	// Another replay may have removed it meanwhile
+	if i := q.indexLocked(id); i >= 0 {
+		q.entries = append(q.entries[:i], q.entries[i+1:]...)
+	}
+	return ref, q.saveLocked()
+}
+
+func newID() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		panic(fmt.Sprintf("dlq: reading random bytes: %v", err))
+	}
+	return hex.EncodeToString(id)
+}
+
+func (q *Queue) indexLocked(id string) int {
+	for i, e := range q.entries {
+		if e.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// saveLocked writes the entries to cfg.Path via a temporary file, so a
+// crash mid-write leaves the previous version intact
+func (q *Queue) saveLocked() error {
+	if q.cfg.Path == "" {
+		return nil
+	}
+	data, err := json.Marshal(q.entries)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(q.cfg.Path), ".dlq-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), q.cfg.Path)
+}
+
+var current atomic.Pointer[Queue]
+
+func init() {
+	Reset()
+}
+
+// Get returns the process-wide Queue
+func Get() *Queue {
+	return current.Load()
+}
+
+// Init replaces the process-wide Queue
+func Init(q *Queue) {
+	current.Store(q)
+}
+
+// Reset installs an empty in-memory Queue
+func Reset() {
+	current.Store(New(DefaultMaxEntries))
+}
@@ -0,0 +1,99 @@
+package dlq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestQueue_AddBounded(t *testing.T) {
+	q := New(2)
+	for i := 0; i < 3; i++ {
+		if _, err := q.Add("email", map[string]int{"n": i}, 3, errors.New("refused")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries := q.List()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	if string(entries[0].Payload) != `{"n":1}` || string(entries[1].Payload) != `{"n":2}` {
+		t.Errorf("Expected the newest entries, got %s and %s", entries[0].Payload, entries[1].Payload)
+	}
+	if entries[0].Error != "refused" || entries[0].Attempts != 3 {
+		t.Errorf("Unexpected entry %+v", entries[0])
+	}
+	if q.Dropped() != 1 {
+		t.Errorf("Expected 1 dropped, got %d", q.Dropped())
+	}
+	if _, ok := q.Get(entries[1].ID); !ok {
+		t.Error("Expected entry to be found")
+	}
+}
+
+func TestQueue_Replay(t *testing.T) {
+	q := New(10)
+	fail := true
+	var replayed []string
+	q.Register("email", func(ctx context.Context, payload json.RawMessage) (string, error) {
+		if fail {
+			return "", errors.New("still down")
+		}
+		replayed = append(replayed, string(payload))
+		return "job-1", nil
+	})
+	e, _ := q.Add("email", "hello", 1, nil)
+
+	if _, err := q.Replay(context.Background(), e.ID); err == nil {
+		t.Error("Expected replay error")
+	}
+	if _, ok := q.Get(e.ID); !ok {
+		t.Error("Expected entry to be kept after a failed replay")
+	}
+
+	fail = false
+	ref, err := q.Replay(context.Background(), e.ID)
+	if err != nil || ref != "job-1" {
+		t.Fatalf("Expected job-1, got %q, %v", ref, err)
+	}
+	if len(replayed) != 1 || replayed[0] != `"hello"` {
+		t.Errorf("Unexpected replayed payloads %v", replayed)
+	}
+	if _, ok := q.Get(e.ID); ok {
+		t.Error("Expected entry to be removed after replay")
+	}
+
+	if _, err := q.Replay(context.Background(), e.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	other, _ := q.Add("webhook", "x", 1, nil)
+	if _, err := q.Replay(context.Background(), other.ID); !errors.Is(err, ErrNoReplayer) {
+		t.Errorf("Expected ErrNoReplayer, got %v", err)
+	}
+}
+
+func TestOpen_Persists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dlq.json")
+	q, err := Open(Config{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, _ := q.Add("email", "a", 2, errors.New("timeout"))
+	gone, _ := q.Add("email", "b", 2, nil)
+	q.Register("email", func(context.Context, json.RawMessage) (string, error) { return "ok", nil })
+	if _, err := q.Replay(context.Background(), gone.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := Open(Config{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := reopened.List()
+	if len(entries) != 1 || entries[0].ID != kept.ID || entries[0].Error != "timeout" {
+		t.Errorf("Expected only %s after reopening, got %+v", kept.ID, entries)
+	}
+}
@@ -0,0 +1,21 @@
+package entities
+
+// Entity is a stored resource with an integer ID assigned by its store. It
+// is the constraint of the generic collections in the storage packages, so
+// new resource types reuse the sharded and lock-free maps that hold tasks.
+// Entities are pointers, so the zero value, nil, means "not found".
+type Entity interface {
+	comparable
+	GetID() int
+	SetID(id int)
+}
+
+// GetID returns the ID of the task
+func (t *Task) GetID() int {
+	return t.ID
+}
+
+// SetID sets the ID of the task
+func (t *Task) SetID(id int) {
+	t.ID = id
+}
@@ -0,0 +1,184 @@
+package entities
+
+import (
+	"fmt"
+	"math/bits"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// TaskFields selects fields of the JSON form of a Task, for ?fields=
+type TaskFields uint8
+
+const (
+	FieldID TaskFields = 1 << iota
+	FieldName
+	FieldStatus
+	FieldSubtasks
+	FieldProgress
+
+	AllTaskFields = FieldID | FieldName | FieldStatus | FieldSubtasks | FieldProgress
+)
+
+// taskFieldNames are the JSON names of the fields, in the order of Task
+var taskFieldNames = []struct {
+	name  string
+	field TaskFields
+}{
+	{"id", FieldID},
+	{"name", FieldName},
+	{"status", FieldStatus},
+	{"subtasks", FieldSubtasks},
+	{"progress", FieldProgress},
+}
+
+// ParseTaskFields parses a comma-separated list of JSON field names, e.g.
+// "id,name,status"
+func ParseTaskFields(s string) (TaskFields, error) {
+	var fields TaskFields
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		found := false
+		for _, f := range taskFieldNames {
+			if f.name == name {
+				fields |= f.field
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown task field %q", name)
+		}
+	}
+	if fields == 0 {
+		return 0, fmt.Errorf("no task fields selected")
+	}
+	return fields, nil
+}
+
+// AppendJSON appends the JSON form of the task with only the selected
+// fields to dst. With AllTaskFields it matches json.Marshal, without its
+// reflection, so large listings cost about the bytes they write.
+func (t *Task) AppendJSON(dst []byte, fields TaskFields) []byte {
+	dst = append(dst, '{')
+	sep := false
+	field := func(name string) {
+		if sep {
+			dst = append(dst, ',')
+		}
+		sep = true
+		dst = append(dst, '"')
+		dst = append(dst, name...)
+		dst = append(dst, '"', ':')
+	}
+
+	if fields&FieldID != 0 {
+		field("id")
+		dst = strconv.AppendInt(dst, int64(t.ID), 10)
+	}
+	if fields&FieldName != 0 {
+		field("name")
+		dst = appendJSONString(dst, t.Name)
+	}
+	if fields&FieldStatus != 0 {
+		field("status")
+		dst = strconv.AppendInt(dst, int64(t.Status), 10)
+	}
+	if fields&FieldSubtasks != 0 && len(t.Subtasks) > 0 {
+		field("subtasks")
+		dst = append(dst, '[')
+		for i, st := range t.Subtasks {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = append(dst, `{"id":`...)
+			dst = strconv.AppendInt(dst, int64(st.ID), 10)
+			dst = append(dst, `,"name":`...)
+			dst = appendJSONString(dst, st.Name)
+			dst = append(dst, `,"done":`...)
+			dst = strconv.AppendBool(dst, st.Done)
+			dst = append(dst, '}')
+		}
+		dst = append(dst, ']')
+	}
+	if fields&FieldProgress != 0 && t.Progress != nil {
+		field("progress")
+		dst = strconv.AppendInt(dst, int64(*t.Progress), 10)
+	}
+	return append(dst, '}')
+}
+
+// AppendTasksJSON appends tasks as a JSON array of AppendJSON objects
+func AppendTasksJSON(dst []byte, tasks []*Task, fields TaskFields) []byte {
+	// About 20 bytes per field, so large listings grow dst once
+	dst = slices.Grow(dst, 2+len(tasks)*(1+20*bits.OnesCount8(uint8(fields))))
+	dst = append(dst, '[')
+	for i, task := range tasks {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = task.AppendJSON(dst, fields)
+	}
+	return append(dst, ']')
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString appends s as a JSON string escaped like encoding/json:
+// HTML characters and U+2028/U+2029 are escaped, invalid UTF-8 is replaced
+// with U+FFFD
+func appendJSONString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch b {
+			case '"', '\\':
+				dst = append(dst, '\\', b)
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
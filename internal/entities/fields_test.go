@@ -0,0 +1,110 @@
+package entities
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+)
+
+func TestTask_AppendJSONMatchesMarshal(t *testing.T) {
+	names := []string{"", "plain", `quote " and \ backslash`, "<b>&amp;</b>", "tab\tnew\nline\r\b\f\x01\x1f", "héllo 世界", "line\u2028para\u2029", "bad \xff utf8"}
+	for i, name := range names {
+		task := &Task{ID: i + 1, Name: name, Status: i % 2}
+		if i%3 == 0 {
+			task.SetSubtasks([]Subtask{{ID: 1, Name: name, Done: true}, {ID: 2, Name: "second"}})
+		}
+
+		want, _ := json.Marshal(task)
+		if got := task.AppendJSON(nil, AllTaskFields); string(got) != string(want) {
+			t.Errorf("AppendJSON(%q) = %s, expected %s", name, got, want)
+		}
+	}
+
+	tasks := []*Task{{ID: 1, Name: "a"}, {ID: 2, Name: "b", Status: 1}}
+	want, _ := json.Marshal(tasks)
+	if got := AppendTasksJSON(nil, tasks, AllTaskFields); string(got) != string(want) {
+		t.Errorf("AppendTasksJSON = %s, expected %s", got, want)
+	}
+	if got := AppendTasksJSON(nil, []*Task{}, FieldID); string(got) != "[]" {
+		t.Errorf("Expected an empty array, got %s", got)
+	}
+}
+
+func TestTask_AppendJSONFields(t *testing.T) {
+	task := &Task{ID: 7, Name: "write docs", Status: 1}
+	task.SetSubtasks([]Subtask{{ID: 1, Name: "draft", Done: true}})
+
+	tests := []struct {
+		fields   string
+		expected string
+	}{
+		{"id,name,status", `{"id":7,"name":"write docs","status":1}`},
+		{"status, id", `{"id":7,"status":1}`},
+		{"progress", `{"progress":100}`},
+		{"subtasks", `{"subtasks":[{"id":1,"name":"draft","done":true}]}`},
+	}
+	for _, tt := range tests {
+		fields, err := ParseTaskFields(tt.fields)
+		if err != nil {
+			t.Fatalf("ParseTaskFields(%q): %v", tt.fields, err)
+		}
+		if got := string(task.AppendJSON(nil, fields)); got != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.fields, tt.expected, got)
+		}
+	}
+
+	// Omitted like the omitempty fields of json.Marshal
+	if got := string((&Task{ID: 1}).AppendJSON(nil, FieldSubtasks|FieldProgress)); got != "{}" {
+		t.Errorf("Expected empty subtasks and progress left out, got %s", got)
+	}
+}
+
+func TestParseTaskFields_Invalid(t *testing.T) {
+	for _, s := range []string{"", " , ", "id,owner", "ID"} {
+		if _, err := ParseTaskFields(s); err == nil {
+			t.Errorf("Expected an error for %q", s)
+		}
+	}
+}
+
+// benchmarkTasks builds a listing like GET /tasks on a store of n tasks
+func benchmarkTasks(n int) []*Task {
+	tasks := make([]*Task, n)
+	for i := range tasks {
+		tasks[i] = &Task{ID: i + 1, Name: "Task number " + strconv.Itoa(i+1) + " with a longer description", Status: i % 2}
+		if i%10 == 0 {
+			tasks[i].SetSubtasks([]Subtask{{ID: 1, Name: "first step", Done: true}, {ID: 2, Name: "second step"}})
+		}
+	}
+	return tasks
+}
+
+// BenchmarkMarshalTasks compares json.Marshal of a 100K-task listing with
+// AppendTasksJSON of every field and of ?fields=id,status
+func BenchmarkMarshalTasks(b *testing.B) {
+	tasks := benchmarkTasks(100_000)
+	idStatus, _ := ParseTaskFields("id,status")
+
+	b.Run("json.Marshal", func(b *testing.B) {
+		var size int
+		for i := 0; i < b.N; i++ {
+			body, _ := json.Marshal(tasks)
+			size = len(body)
+		}
+		b.ReportMetric(float64(size), "body-bytes")
+	})
+	b.Run("AppendJSON/all", func(b *testing.B) {
+		var size int
+		for i := 0; i < b.N; i++ {
+			size = len(AppendTasksJSON(nil, tasks, AllTaskFields))
+		}
+		b.ReportMetric(float64(size), "body-bytes")
+	})
+	b.Run("AppendJSON/id,status", func(b *testing.B) {
+		var size int
+		for i := 0; i < b.N; i++ {
+			size = len(AppendTasksJSON(nil, tasks, idStatus))
+		}
+		b.ReportMetric(float64(size), "body-bytes")
+	})
+}
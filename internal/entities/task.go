@@ -1,8 +1,64 @@
 package entities
 
+// MaxSubtasks bounds the checklist of one task
+const MaxSubtasks = 50
+
 // Task represents a task entity with ID, name, and status.
 type Task struct {
-	ID     int    `json:"id"`                                     // Unique identifier for the task
-	Name   string `json:"name" validate:"required,min=1,max=100"` // Task name (required, 1-100 chars)
-	Status int    `json:"status" validate:"oneof=0 1"`            // Task status (0=incomplete, 1=complete)
+	ID       int       `json:"id"`                                     // Unique identifier for the task
+	Name     string    `json:"name" validate:"required,min=1,max=100"` // Task name (required, 1-100 chars)
+	Status   int       `json:"status" validate:"oneof=0 1"`            // Task status (0=incomplete, 1=complete)
+	Subtasks []Subtask `json:"subtasks,omitempty"`                     // Checklist, in the order items were added
+	Progress *int      `json:"progress,omitempty"`                     // Percentage of subtasks done; set with SetSubtasks
+}
+
+// Subtask is an item of a task's checklist
+type Subtask struct {
+	ID   int    `json:"id"` // Unique within its task
+	Name string `json:"name"`
+	Done bool   `json:"done"`
+}
+
+// SetSubtasks replaces the checklist of the task and recomputes its
+// progress. Tasks share their subtasks when copied, so callers pass a new
+// slice rather than editing the current one.
+func (t *Task) SetSubtasks(subtasks []Subtask) {
+	if len(subtasks) == 0 {
+		t.Subtasks, t.Progress = nil, nil
+		return
+	}
+	done := 0
+	for _, st := range subtasks {
+		if st.Done {
+			done++
+		}
+	}
+	progress := done * 100 / len(subtasks)
+	t.Subtasks, t.Progress = subtasks, &progress
+}
+
+// NextSubtaskID returns the ID for a new subtask of the task, one past the
+// highest in use
+func (t *Task) NextSubtaskID() int {
+	next := 1
+	for _, st := range t.Subtasks {
+		next = max(next, st.ID+1)
+	}
+	return next
+}
+
+// Equal reports whether t and other hold the same fields and checklist
+func (t *Task) Equal(other *Task) bool {
+	if t.ID != other.ID || t.Name != other.Name || t.Status != other.Status || len(t.Subtasks) != len(other.Subtasks) {
+		return false
+	}
+	if (t.Progress == nil) != (other.Progress == nil) || (t.Progress != nil && *t.Progress != *other.Progress) {
+		return false
+	}
+	for i := range t.Subtasks {
+		if t.Subtasks[i] != other.Subtasks[i] {
+			return false
+		}
+	}
+	return true
 }
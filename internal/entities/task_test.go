@@ -107,3 +107,31 @@ func TestTask_NameValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestTask_Subtasks(t *testing.T) {
+	task := &Task{ID: 1, Name: "Checklist"}
+	if task.NextSubtaskID() != 1 {
+		t.Errorf("Expected the first subtask ID to be 1, got %d", task.NextSubtaskID())
+	}
+
+	task.SetSubtasks([]Subtask{{ID: 1, Name: "a", Done: true}, {ID: 4, Name: "b"}, {ID: 2, Name: "c"}})
+	if task.Progress == nil || *task.Progress != 33 || task.NextSubtaskID() != 5 {
+		t.Errorf("Expected 33%% progress and next ID 5, got %v, %d", task.Progress, task.NextSubtaskID())
+	}
+
+	data, _ := json.Marshal(task)
+	var decoded Task
+	if err := json.Unmarshal(data, &decoded); err != nil || !decoded.Equal(task) {
+		t.Errorf("Expected the checklist to round-trip through JSON, got %+v, %v", decoded, err)
+	}
+	decoded.Subtasks[1].Done = true
+	if decoded.Equal(task) {
+		t.Error("Expected a changed subtask to make the tasks differ")
+	}
+
+	task.SetSubtasks(nil)
+	data, _ = json.Marshal(task)
+	if task.Progress != nil || string(data) != `{"id":1,"name":"Checklist","status":0}` {
+		t.Errorf("Expected no progress without subtasks, got %s", data)
+	}
+}
@@ -4,10 +4,13 @@ package errors
 
 // AppError represents a structured application error with error code
 type AppError struct {
-	Code    int    `json:"code"`    // Error code for API responses
-	Message string `json:"message"` // Human-readable error message
-	Type    string `json:"type"`    // Error type for categorization
-	Cause   error  `json:"-"`       // Original error, not serialized
+	Code      int    `json:"code"`                // Error code for API responses
+	Message   string `json:"message"`             // Human-readable error message
+	Type      string `json:"type"`                // Error type for categorization
+	Cause     error  `json:"-"`                   // Original error, not serialized
+	Retryable bool   `json:"retryable,omitempty"` // Transient; the same request may succeed later
+
+	stack []uintptr // Where the cause was attached; only recorded in debug mode
 }
 
 // Error implements the error interface for AppError.
@@ -21,10 +24,24 @@ func (e *AppError) Error() string {
 // WithCause adds the underlying cause to the error and returns a new AppError.
 func (e *AppError) WithCause(cause error) *AppError {
 	return &AppError{
-		Code:    e.Code,
-		Message: e.Message,
-		Type:    e.Type,
-		Cause:   cause,
+		Code:      e.Code,
+		Message:   e.Message,
+		Type:      e.Type,
+		Cause:     cause,
+		Retryable: e.Retryable,
+		stack:     callers(),
+	}
+}
+
+// WithMessage returns a copy of the error with a more specific message.
+func (e *AppError) WithMessage(message string) *AppError {
+	return &AppError{
+		Code:      e.Code,
+		Message:   message,
+		Type:      e.Type,
+		Cause:     e.Cause,
+		Retryable: e.Retryable,
+		stack:     e.stack,
 	}
 }
 
@@ -57,6 +74,224 @@ var (
 		Message: "task cannot be nil",
 		Type:    "VALIDATION_ERROR",
 	}
+	// ErrTaskNameRequired is returned when a task has no name
+	ErrTaskNameRequired = &AppError{
+		Code:    ErrCodeTaskNameRequired,
+		Message: "Task name is required",
+		Type:    "VALIDATION_ERROR",
+	}
+	// ErrTaskNameTooLong is returned when a task name exceeds 100 characters
+	ErrTaskNameTooLong = &AppError{
+		Code:    ErrCodeTaskNameTooLong,
+		Message: "Task name must be at most 100 characters long",
+		Type:    "VALIDATION_ERROR",
+	}
+	// ErrTaskInvalidStatus is returned when a task status is not 0 or 1
+	ErrTaskInvalidStatus = &AppError{
+		Code:    ErrCodeTaskInvalidStatus,
+		Message: "status must be 0 (incomplete) or 1 (complete)",
+		Type:    "VALIDATION_ERROR",
+	}
+	// ErrSubtaskNotFound is returned when a task has no subtask with the requested ID
+	ErrSubtaskNotFound = &AppError{
+		Code:    ErrCodeSubtaskNotFound,
+		Message: "Subtask not found",
+		Type:    "NOT_FOUND",
+	}
+	// ErrSubtaskLimit is returned when adding a subtask to a task that has the maximum
+	ErrSubtaskLimit = &AppError{
+		Code:    ErrCodeSubtaskLimit,
+		Message: "Task already has the maximum of 50 subtasks",
+		Type:    "VALIDATION_ERROR",
+	}
+	// ErrTaskTooLarge is returned when a task does not fit the record of a fixed-size backend
+	ErrTaskTooLarge = &AppError{
+		Code:    ErrCodeTaskTooLarge,
+		Message: "Task is too large for the storage backend",
+		Type:    "VALIDATION_ERROR",
+	}
+	// ErrInvalidJSON is returned when a request body cannot be parsed
+	ErrInvalidJSON = &AppError{
+		Code:    ErrCodeInvalidJSON,
+		Message: "Invalid JSON format",
+		Type:    "VALIDATION_ERROR",
+	}
+	// ErrInvalidID is returned when a path ID is not a positive integer
+	ErrInvalidID = &AppError{
+		Code:    ErrCodeInvalidID,
+		Message: "ID must be a valid integer",
+		Type:    "VALIDATION_ERROR",
+	}
+	// ErrMissingFields is returned when required request fields are missing
+	ErrMissingFields = &AppError{
+		Code:    ErrCodeMissingFields,
+		Message: "Required fields are missing",
+		Type:    "VALIDATION_ERROR",
+	}
+	// ErrInvalidQuery is returned for malformed query parameters
+	ErrInvalidQuery = &AppError{
+		Code:    ErrCodeInvalidQuery,
+		Message: "Invalid query parameter",
+		Type:    "VALIDATION_ERROR",
+	}
+	// ErrRouteNotFound is returned for requests that match no route
+	ErrRouteNotFound = &AppError{
+		Code:    ErrCodeRouteNotFound,
+		Message: "Route not found",
+		Type:    "NOT_FOUND",
+	}
+	// ErrBadRequest is returned for requests the framework rejects before a handler runs
+	ErrBadRequest = &AppError{
+		Code:    ErrCodeBadRequest,
+		Message: "Request could not be processed",
+		Type:    "BAD_REQUEST",
+	}
+	// ErrReadOnly is returned when a write is sent to a read-only instance
+	ErrReadOnly = &AppError{
+		Code:    ErrCodeReadOnly,
+		Message: "Server is read-only; writes are not allowed",
+		Type:    "READ_ONLY",
+	}
+	// ErrCSRFTokenInvalid is returned when a cookie-authenticated write lacks a valid CSRF token
+	ErrCSRFTokenInvalid = &AppError{
+		Code:    ErrCodeCSRFInvalid,
+		Message: "Missing or invalid CSRF token",
+		Type:    "FORBIDDEN",
+	}
+	// ErrUnauthorized is returned when credentials are missing or invalid
+	ErrUnauthorized = &AppError{
+		Code:    ErrCodeUnauthorized,
+		Message: "Missing or invalid credentials",
+		Type:    "UNAUTHORIZED",
+	}
+	// ErrForbidden is returned when the caller's role does not allow the request
+	ErrForbidden = &AppError{
+		Code:    ErrCodeForbidden,
+		Message: "Insufficient role for this operation",
+		Type:    "FORBIDDEN",
+	}
+	// ErrShareLinkInvalid is returned for share tokens that are invalid, expired or revoked
+	ErrShareLinkInvalid = &AppError{
+		Code:    ErrCodeShareInvalid,
+		Message: "Share link is invalid, expired or revoked",
+		Type:    "NOT_FOUND",
+	}
+	// ErrJobNotFound is returned when a background job ID is unknown
+	ErrJobNotFound = &AppError{
+		Code:    ErrCodeJobNotFound,
+		Message: "Job not found",
+		Type:    "NOT_FOUND",
+	}
+	// ErrDeadLetterNotFound is returned when a dead-letter entry ID is unknown
+	ErrDeadLetterNotFound = &AppError{
+		Code:    ErrCodeDeadLetterNotFound,
+		Message: "Dead-letter entry not found",
+		Type:    "NOT_FOUND",
+	}
+	// ErrScheduleInvalid is returned for job schedules with a bad name, kind, cron expression or params
+	ErrScheduleInvalid = &AppError{
+		Code:    ErrCodeScheduleInvalid,
+		Message: "Job schedule is invalid",
+		Type:    "VALIDATION_ERROR",
+	}
+	// ErrJobExists is returned when a job schedule uses the name of an existing job
+	ErrJobExists = &AppError{
+		Code:    ErrCodeJobExists,
+		Message: "A job with this name already exists",
+		Type:    "CONFLICT",
+	}
+	// ErrNotArchived is returned when unarchiving a task ID that is not in the archive
+	ErrNotArchived = &AppError{
+		Code:    ErrCodeNotArchived,
+		Message: "Task is not archived",
+		Type:    "NOT_FOUND",
+	}
+	// ErrInvalidConsistency is returned for an unknown X-Consistency level
+	ErrInvalidConsistency = &AppError{
+		Code:    ErrCodeInvalidConsistency,
+		Message: "Consistency must be strong or eventual",
+		Type:    "VALIDATION_ERROR",
+	}
+	// ErrSignatureInvalid is returned when a write by a signing API key has a
+	// missing or wrong signature
+	ErrSignatureInvalid = &AppError{
+		Code:    ErrCodeSignatureInvalid,
+		Message: "Missing or invalid request signature",
+		Type:    "UNAUTHORIZED",
+	}
+	// ErrSignatureExpired is returned when a signature's timestamp is outside
+	// the accepted window
+	ErrSignatureExpired = &AppError{
+		Code:    ErrCodeSignatureExpired,
+		Message: "Request signature timestamp is too old or in the future",
+		Type:    "UNAUTHORIZED",
+	}
+	// ErrSignatureReplayed is returned when a signed request is sent again
+	ErrSignatureReplayed = &AppError{
+		Code:    ErrCodeSignatureReplayed,
+		Message: "Request signature was already used",
+		Type:    "CONFLICT",
+	}
+	// ErrAddressDenied is returned when the client address is not allowed on
+	// an admin route
+	ErrAddressDenied = &AppError{
+		Code:    ErrCodeAddressDenied,
+		Message: "Client address is not allowed",
+		Type:    "FORBIDDEN",
+	}
+	// ErrCompactionRunning is returned when a storage compaction is requested
+	// while one is running
+	ErrCompactionRunning = &AppError{
+		Code:    ErrCodeCompactionRunning,
+		Message: "A storage compaction is already running",
+		Type:    "CONFLICT",
+	}
+	// ErrLoadTestInvalid is returned for unknown load test scenarios or
+	// storage types a load test cannot run on
+	ErrLoadTestInvalid = &AppError{
+		Code:    ErrCodeLoadTestInvalid,
+		Message: "Invalid load test",
+		Type:    "VALIDATION_ERROR",
+	}
+	// ErrLoadTestRunning is returned when a load test is requested while one
+	// is running
+	ErrLoadTestRunning = &AppError{
+		Code:    ErrCodeLoadTestRunning,
+		Message: "A load test is already running",
+		Type:    "CONFLICT",
+	}
+	// ErrDemoDisabled is returned for destructive requests to a public demo
+	ErrDemoDisabled = &AppError{
+		Code:    ErrCodeDemoDisabled,
+		Message: "Not available in demo mode",
+		Type:    "FORBIDDEN",
+	}
+	// ErrRateLimited is returned when a client exceeds its request rate
+	ErrRateLimited = &AppError{
+		Code:      ErrCodeRateLimited,
+		Message:   "Too many requests; retry later",
+		Type:      "RATE_LIMITED",
+		Retryable: true,
+	}
+	// ErrQuotaExceeded is returned when an API key has used its monthly quota
+	ErrQuotaExceeded = &AppError{
+		Code:    ErrCodeQuotaExceeded,
+		Message: "Monthly request quota of this API key is used up",
+		Type:    "RATE_LIMITED",
+	}
+	// ErrOffsetExpired is returned when a CDC offset is older than the change
+	// history
+	ErrOffsetExpired = &AppError{
+		Code:    ErrCodeOffsetExpired,
+		Message: "Offset is older than the change history; re-export the tasks and continue from the current offset",
+		Type:    "GONE",
+	}
+	// ErrImportInvalid is returned for unknown import sources or unparseable import payloads
+	ErrImportInvalid = &AppError{
+		Code:    ErrCodeImportInvalid,
+		Message: "Import payload is invalid",
+		Type:    "VALIDATION_ERROR",
+	}
 	// ErrInternalError is returned for internal server errors
 	ErrInternalError = &AppError{
 		Code:    ErrCodeInternalError,
@@ -69,4 +304,83 @@ var (
 		Message: "storage operation error",
 		Type:    "STORAGE_ERROR",
 	}
+	// ErrStorageFull is returned when a write would exceed the storage memory limit
+	ErrStorageFull = &AppError{
+		Code:    ErrCodeStorageFull,
+		Message: "Storage limit reached; write rejected",
+		Type:    "STORAGE_FULL",
+	}
+	// ErrShareDisabled is returned when share links are requested without signing keys
+	ErrShareDisabled = &AppError{
+		Code:    ErrCodeShareDisabled,
+		Message: "Task sharing is not configured",
+		Type:    "UNAVAILABLE",
+	}
+	// ErrNotifyDisabled is returned when notifications are requested without an SMTP relay
+	ErrNotifyDisabled = &AppError{
+		Code:    ErrCodeNotifyDisabled,
+		Message: "Email notifications are not configured",
+		Type:    "UNAVAILABLE",
+	}
+	// ErrTimeout is returned when a request exceeds its deadline
+	ErrTimeout = &AppError{
+		Code:      ErrCodeTimeout,
+		Message:   "Request deadline exceeded",
+		Type:      "TIMEOUT",
+		Retryable: true,
+	}
+	// ErrOverloaded is returned when the server sheds load
+	ErrOverloaded = &AppError{
+		Code:      ErrCodeOverloaded,
+		Message:   "Server is overloaded; retry later",
+		Type:      "UNAVAILABLE",
+		Retryable: true,
+	}
+	// ErrRetentionOff is returned by the retention endpoints when deletes are not soft
+	ErrRetentionOff = &AppError{
+		Code:    ErrCodeRetentionOff,
+		Message: "Soft deletes are not configured",
+		Type:    "UNAVAILABLE",
+	}
+	// ErrNoKeyIndex is returned by upserts when the storage backend keeps no external keys
+	ErrNoKeyIndex = &AppError{
+		Code:    ErrCodeNoKeyIndex,
+		Message: "Storage backend does not support upserts by external key",
+		Type:    "UNAVAILABLE",
+	}
+	// ErrShuttingDown is returned for writes that arrive while the server drains
+	ErrShuttingDown = &AppError{
+		Code:      ErrCodeShuttingDown,
+		Message:   "Server is shutting down; retry later",
+		Type:      "UNAVAILABLE",
+		Retryable: true,
+	}
+	// ErrStorageCorrupt is returned for writes while the storage backend
+	// has failed its startup integrity check
+	ErrStorageCorrupt = &AppError{
+		Code:    ErrCodeStorageCorrupt,
+		Message: "Storage failed its integrity check; writes are disabled",
+		Type:    "UNAVAILABLE",
+	}
+	// ErrNoCompaction is returned by compaction requests when the storage
+	// backend has no files to compact
+	ErrNoCompaction = &AppError{
+		Code:    ErrCodeNoCompaction,
+		Message: "Storage backend does not support compaction",
+		Type:    "UNAVAILABLE",
+	}
+	// ErrProjectionsOff is returned by projection requests when no
+	// projection is maintained
+	ErrProjectionsOff = &AppError{
+		Code:    ErrCodeProjectionsOff,
+		Message: "Projections are not enabled",
+		Type:    "UNAVAILABLE",
+	}
+	// ErrMaintenance is returned when a request is rejected by maintenance mode
+	ErrMaintenance = &AppError{
+		Code:      ErrCodeMaintenance,
+		Message:   "Service is under maintenance",
+		Type:      "UNAVAILABLE",
+		Retryable: true,
+	}
 )
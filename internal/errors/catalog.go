@@ -0,0 +1,122 @@
+package errors
+
+import (
+	"net/http"
+	"sort"
+)
+
+// CatalogEntry describes one error code for clients generating error handling
+type CatalogEntry struct {
+	Code       int    `json:"code"`
+	Name       string `json:"name"`     // Constant name without the ErrCode prefix
+	Category   string `json:"category"` // task, request or system, from the code range
+	Type       string `json:"type"`
+	HTTPStatus int    `json:"httpStatus"`
+	Message    string `json:"message"` // Default message; validation errors may be more specific
+	Retryable  bool   `json:"retryable"`
+}
+
+// catalog lists every error code with the status it is served with. Adding a
+// code to codes.go without an entry here fails TestCatalog_CoversAllCodes.
+var catalog = []struct {
+	name   string
+	status int
+	err    *AppError
+}{
+	{"TaskNotFound", http.StatusNotFound, ErrTaskNotFound},
+	{"TaskInvalidInput", http.StatusBadRequest, ErrTaskInvalidInput},
+	{"TaskNameRequired", http.StatusBadRequest, ErrTaskNameRequired},
+	{"TaskNameTooLong", http.StatusBadRequest, ErrTaskNameTooLong},
+	{"TaskInvalidStatus", http.StatusBadRequest, ErrTaskInvalidStatus},
+	{"SubtaskNotFound", http.StatusNotFound, ErrSubtaskNotFound},
+	{"SubtaskLimit", http.StatusBadRequest, ErrSubtaskLimit},
+	{"TaskTooLarge", http.StatusBadRequest, ErrTaskTooLarge},
+
+	{"InvalidJSON", http.StatusBadRequest, ErrInvalidJSON},
+	{"InvalidID", http.StatusBadRequest, ErrInvalidID},
+	{"MissingFields", http.StatusBadRequest, ErrMissingFields},
+	{"ReadOnly", http.StatusMethodNotAllowed, ErrReadOnly},
+	{"CSRFInvalid", http.StatusForbidden, ErrCSRFTokenInvalid},
+	{"Unauthorized", http.StatusUnauthorized, ErrUnauthorized},
+	{"Forbidden", http.StatusForbidden, ErrForbidden},
+	{"ShareInvalid", http.StatusNotFound, ErrShareLinkInvalid},
+	{"InvalidQuery", http.StatusBadRequest, ErrInvalidQuery},
+	{"JobNotFound", http.StatusNotFound, ErrJobNotFound},
+	{"ImportInvalid", http.StatusBadRequest, ErrImportInvalid},
+	{"RouteNotFound", http.StatusNotFound, ErrRouteNotFound},
+	{"BadRequest", http.StatusBadRequest, ErrBadRequest},
+	{"DeadLetterNotFound", http.StatusNotFound, ErrDeadLetterNotFound},
+	{"ScheduleInvalid", http.StatusBadRequest, ErrScheduleInvalid},
+	{"JobExists", http.StatusConflict, ErrJobExists},
+	{"NotArchived", http.StatusNotFound, ErrNotArchived},
+	{"InvalidConsistency", http.StatusBadRequest, ErrInvalidConsistency},
+	{"SignatureInvalid", http.StatusUnauthorized, ErrSignatureInvalid},
+	{"SignatureExpired", http.StatusUnauthorized, ErrSignatureExpired},
+	{"SignatureReplayed", http.StatusConflict, ErrSignatureReplayed},
+	{"AddressDenied", http.StatusForbidden, ErrAddressDenied},
+	{"CompactionRunning", http.StatusConflict, ErrCompactionRunning},
+	{"LoadTestInvalid", http.StatusBadRequest, ErrLoadTestInvalid},
+	{"LoadTestRunning", http.StatusConflict, ErrLoadTestRunning},
+	{"DemoDisabled", http.StatusForbidden, ErrDemoDisabled},
+	{"RateLimited", http.StatusTooManyRequests, ErrRateLimited},
+	{"QuotaExceeded", http.StatusTooManyRequests, ErrQuotaExceeded},
+	{"OffsetExpired", http.StatusGone, ErrOffsetExpired},
+
+	{"InternalError", http.StatusInternalServerError, ErrInternalError},
+	{"StorageError", http.StatusInternalServerError, ErrStorageError},
+	{"Maintenance", http.StatusServiceUnavailable, ErrMaintenance},
+	{"StorageFull", http.StatusInsufficientStorage, ErrStorageFull},
+	{"ShareDisabled", http.StatusServiceUnavailable, ErrShareDisabled},
+	{"NotifyDisabled", http.StatusServiceUnavailable, ErrNotifyDisabled},
+	{"Timeout", http.StatusGatewayTimeout, ErrTimeout},
+	{"Overloaded", http.StatusServiceUnavailable, ErrOverloaded},
+	{"RetentionOff", http.StatusServiceUnavailable, ErrRetentionOff},
+	{"NoKeyIndex", http.StatusNotImplemented, ErrNoKeyIndex},
+	{"ShuttingDown", http.StatusServiceUnavailable, ErrShuttingDown},
+	{"StorageCorrupt", http.StatusServiceUnavailable, ErrStorageCorrupt},
+	{"NoCompaction", http.StatusNotImplemented, ErrNoCompaction},
+	{"ProjectionsOff", http.StatusServiceUnavailable, ErrProjectionsOff},
+}
+
+// Catalog returns every error code, sorted by code
+func Catalog() []CatalogEntry {
+	entries := make([]CatalogEntry, len(catalog))
+	for i, c := range catalog {
+		entries[i] = CatalogEntry{
+			Code:       c.err.Code,
+			Name:       c.name,
+			Category:   Category(c.err.Code),
+			Type:       c.err.Type,
+			HTTPStatus: c.status,
+			Message:    c.err.Message,
+			Retryable:  c.err.Retryable,
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Code < entries[j].Code })
+	return entries
+}
+
+// Category returns the range an error code belongs to
+func Category(code int) string {
+	switch {
+	case code >= 1000 && code < 2000:
+		return "task"
+	case code >= 2000 && code < 3000:
+		return "request"
+	case code >= 5000 && code < 6000:
+		return "system"
+	default:
+		return "unknown"
+	}
+}
+
+// HTTPStatus returns the status an error code is served with, or 500 for
+// unknown codes
+func HTTPStatus(code int) int {
+	for _, c := range catalog {
+		if c.err.Code == code {
+			return c.status
+		}
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,79 @@
+package errors
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// codeConsts parses codes.go and returns the ErrCode constants by name
+func codeConsts(t *testing.T) map[string]bool {
+	t.Helper()
+
+	file, err := parser.ParseFile(token.NewFileSet(), "codes.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if spec, ok := n.(*ast.ValueSpec); ok {
+			for _, ident := range spec.Names {
+				if strings.HasPrefix(ident.Name, "ErrCode") {
+					names[strings.TrimPrefix(ident.Name, "ErrCode")] = true
+				}
+			}
+		}
+		return true
+	})
+	return names
+}
+
+func TestCatalog_CoversAllCodes(t *testing.T) {
+	consts := codeConsts(t)
+	entries := Catalog()
+
+	if len(entries) != len(consts) {
+		t.Errorf("Expected %d catalog entries, got %d", len(consts), len(entries))
+	}
+
+	seen := make(map[int]bool)
+	for i, e := range entries {
+		if !consts[e.Name] {
+			t.Errorf("Catalog entry %s does not match an ErrCode constant", e.Name)
+		}
+		if seen[e.Code] {
+			t.Errorf("Duplicate catalog code %d", e.Code)
+		}
+		seen[e.Code] = true
+		if i > 0 && entries[i-1].Code > e.Code {
+			t.Errorf("Expected catalog sorted by code, %d before %d", entries[i-1].Code, e.Code)
+		}
+		if e.Message == "" || e.Type == "" || e.Category == "unknown" {
+			t.Errorf("Incomplete catalog entry %+v", e)
+		}
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	tests := []struct {
+		code     int
+		expected int
+	}{
+		{ErrCodeTaskNotFound, http.StatusNotFound},
+		{ErrCodeUnauthorized, http.StatusUnauthorized},
+		{ErrCodeJobNotFound, http.StatusNotFound},
+		{ErrCodeStorageFull, http.StatusInsufficientStorage},
+		{ErrCodeTimeout, http.StatusGatewayTimeout},
+		{9999, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		if got := HTTPStatus(tt.code); got != tt.expected {
+			t.Errorf("Expected status %d for code %d, got %d", tt.expected, tt.code, got)
+		}
+	}
+}
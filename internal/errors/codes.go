@@ -8,13 +8,54 @@ const (
 	ErrCodeTaskNameRequired  = 1003
 	ErrCodeTaskNameTooLong   = 1004
 	ErrCodeTaskInvalidStatus = 1005
+	ErrCodeSubtaskNotFound   = 1006
+	ErrCodeSubtaskLimit      = 1007
+	ErrCodeTaskTooLarge      = 1008
 
 	// Request related errors (2000-2999)
-	ErrCodeInvalidJSON   = 2001
-	ErrCodeInvalidID     = 2002
-	ErrCodeMissingFields = 2003
+	ErrCodeInvalidJSON        = 2001
+	ErrCodeInvalidID          = 2002
+	ErrCodeMissingFields      = 2003
+	ErrCodeReadOnly           = 2004
+	ErrCodeCSRFInvalid        = 2005
+	ErrCodeUnauthorized       = 2006
+	ErrCodeForbidden          = 2007
+	ErrCodeShareInvalid       = 2008
+	ErrCodeInvalidQuery       = 2009
+	ErrCodeJobNotFound        = 2010
+	ErrCodeImportInvalid      = 2011
+	ErrCodeRouteNotFound      = 2012
+	ErrCodeBadRequest         = 2013
+	ErrCodeDeadLetterNotFound = 2014
+	ErrCodeScheduleInvalid    = 2015
+	ErrCodeJobExists          = 2016
+	ErrCodeNotArchived        = 2017
+	ErrCodeInvalidConsistency = 2018
+	ErrCodeSignatureInvalid   = 2019
+	ErrCodeSignatureExpired   = 2020
+	ErrCodeSignatureReplayed  = 2021
+	ErrCodeAddressDenied      = 2022
+	ErrCodeCompactionRunning  = 2023
+	ErrCodeLoadTestInvalid    = 2024
+	ErrCodeLoadTestRunning    = 2025
+	ErrCodeDemoDisabled       = 2026
+	ErrCodeRateLimited        = 2027
+	ErrCodeQuotaExceeded      = 2028
+	ErrCodeOffsetExpired      = 2029
 
 	// System related errors (5000-5999)
-	ErrCodeInternalError = 5001
-	ErrCodeStorageError  = 5002
+	ErrCodeInternalError  = 5001
+	ErrCodeStorageError   = 5002
+	ErrCodeMaintenance    = 5003
+	ErrCodeStorageFull    = 5004
+	ErrCodeShareDisabled  = 5005
+	ErrCodeNotifyDisabled = 5006
+	ErrCodeTimeout        = 5007
+	ErrCodeOverloaded     = 5008
+	ErrCodeRetentionOff   = 5009
+	ErrCodeNoKeyIndex     = 5010
+	ErrCodeShuttingDown   = 5011
+	ErrCodeStorageCorrupt = 5012
+	ErrCodeNoCompaction   = 5013
+	ErrCodeProjectionsOff = 5014
 )
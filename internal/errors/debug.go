@@ -0,0 +1,117 @@
+package errors
+
+import (
+	"errors"
+	"path"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"unicode"
+)
+
+// Limits keep debug bodies small and bounded, however deep the chain
+const (
+	MaxDebugCauses   = 10
+	MaxDebugFrames   = 16
+	MaxDebugMsgRunes = 300
+)
+
+var debug atomic.Bool
+
+// SetDebug turns debug error bodies on or off. While on, WithCause records
+// the caller's stack and ToResponse includes the cause chain; this is for
+// development only, never for production.
+func SetDebug(enabled bool) {
+	debug.Store(enabled)
+}
+
+// Debug reports whether debug error bodies are enabled
+func Debug() bool {
+	return debug.Load()
+}
+
+// Frame is one sanitized stack frame
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// DebugInfo is the development-only part of an error response
+type DebugInfo struct {
+	Causes []string `json:"causes"`          // Outermost first
+	Stack  []Frame  `json:"stack,omitempty"` // Where the cause was attached
+}
+
+// Unwrap returns the underlying cause, so errors.Is and errors.As see through AppError.
+func (e *AppError) Unwrap() error {
+	return e.Cause
+}
+
+// callers records the stack above WithCause when debugging is enabled
+func callers() []uintptr {
+	if !Debug() {
+		return nil
+	}
+	pcs := make([]uintptr, MaxDebugFrames)
+	return pcs[:runtime.Callers(3, pcs)]
+}
+
+// debugInfo builds the sanitized cause chain and stack of e, or nil if there
+// is nothing to report
+func debugInfo(e *AppError) *DebugInfo {
+	if e.Cause == nil {
+		return nil
+	}
+
+	// Report the innermost recorded stack, closest to where things went wrong
+	info := &DebugInfo{Causes: []string{}}
+	stack := e.stack
+	for err := e.Cause; err != nil && len(info.Causes) < MaxDebugCauses; err = errors.Unwrap(err) {
+		info.Causes = append(info.Causes, sanitizeMessage(err.Error()))
+		if appErr, ok := err.(*AppError); ok && len(appErr.stack) > 0 {
+			stack = appErr.stack
+		}
+	}
+
+	if len(stack) > 0 {
+		frames := runtime.CallersFrames(stack)
+		for {
+			f, more := frames.Next()
+			if !strings.HasPrefix(f.Function, "runtime.") {
+				info.Stack = append(info.Stack, Frame{Function: f.Function, File: sanitizeFile(f.File), Line: f.Line})
+			}
+			if !more {
+				break
+			}
+		}
+	}
+	return info
+}
+
+// sanitizeMessage strips control characters and truncates long messages
+func sanitizeMessage(msg string) string {
+	msg = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, msg)
+	if runes := []rune(msg); len(runes) > MaxDebugMsgRunes {
+		msg = string(runes[:MaxDebugMsgRunes]) + "…"
+	}
+	return msg
+}
+
+// sanitizeFile hides the build machine's directory layout: module files keep
+// their path inside the module, everything else keeps its last two elements
+func sanitizeFile(file string) string {
+	if i := strings.Index(file, "/internal/"); i >= 0 {
+		return file[i+1:]
+	}
+	if i := strings.Index(file, "/cmd/"); i >= 0 {
+		return file[i+1:]
+	}
+	dir, base := path.Split(file)
+	return path.Join(path.Base(dir), base)
+}
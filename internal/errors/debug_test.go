@@ -0,0 +1,73 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestToResponse_Debug(t *testing.T) {
+	root := errors.New("disk on fire\nsecond line")
+	appErr := ErrStorageError.WithCause(fmt.Errorf("write failed: %w", root))
+
+	if resp := ToResponse(appErr); resp.Debug != nil {
+		t.Fatalf("Expected no debug info while disabled, got %+v", resp.Debug)
+	}
+
+	SetDebug(true)
+	defer SetDebug(false)
+
+	appErr = ErrStorageError.WithCause(fmt.Errorf("write failed: %w", root))
+	resp := ToResponse(appErr)
+	if resp.Debug == nil {
+		t.Fatal("Expected debug info while enabled")
+	}
+
+	expected := []string{"write failed: disk on fire second line", "disk on fire second line"}
+	if len(resp.Debug.Causes) != len(expected) {
+		t.Fatalf("Expected causes %q, got %q", expected, resp.Debug.Causes)
+	}
+	for i, cause := range expected {
+		if resp.Debug.Causes[i] != cause {
+			t.Errorf("Expected cause %q, got %q", cause, resp.Debug.Causes[i])
+		}
+	}
+
+	if len(resp.Debug.Stack) == 0 {
+		t.Fatal("Expected stack frames")
+	}
+	top := resp.Debug.Stack[0]
+	if !strings.HasSuffix(top.Function, "TestToResponse_Debug") || top.File != "internal/errors/debug_test.go" {
+		t.Errorf("Expected top frame in this test with a module-relative file, got %+v", top)
+	}
+
+	if !errors.Is(appErr, root) {
+		t.Error("Expected errors.Is to see through AppError")
+	}
+}
+
+func TestInternalErrorResponse(t *testing.T) {
+	cause := ErrStorageError.WithCause(errors.New("closed"))
+	if resp := InternalErrorResponse(cause); resp != ErrInternalErrorResponse {
+		t.Errorf("Expected the shared internal error response while disabled, got %+v", resp)
+	}
+
+	SetDebug(true)
+	defer SetDebug(false)
+
+	resp := InternalErrorResponse(ErrStorageError.WithCause(errors.New("closed")))
+	if resp.Code != ErrCodeInternalError || resp.Message != ErrInternalError.Message {
+		t.Errorf("Expected masked internal error, got %+v", resp)
+	}
+	if resp.Debug == nil || len(resp.Debug.Causes) != 2 || resp.Debug.Causes[1] != "closed" {
+		t.Errorf("Expected cause chain ending in closed, got %+v", resp.Debug)
+	}
+}
+
+func TestSanitizeMessage_Truncates(t *testing.T) {
+	msg := sanitizeMessage(strings.Repeat("x", MaxDebugMsgRunes+50))
+	if got := len([]rune(msg)); got != MaxDebugMsgRunes+1 {
+		t.Errorf("Expected %d runes including the ellipsis, got %d", MaxDebugMsgRunes+1, got)
+	}
+}
@@ -17,11 +17,52 @@ func TestErrorCodes(t *testing.T) {
 		{"TaskNameRequired", ErrCodeTaskNameRequired, "task", 1000, 1999},
 		{"TaskNameTooLong", ErrCodeTaskNameTooLong, "task", 1000, 1999},
 		{"TaskInvalidStatus", ErrCodeTaskInvalidStatus, "task", 1000, 1999},
+		{"SubtaskNotFound", ErrCodeSubtaskNotFound, "task", 1000, 1999},
+		{"SubtaskLimit", ErrCodeSubtaskLimit, "task", 1000, 1999},
+		{"TaskTooLarge", ErrCodeTaskTooLarge, "task", 1000, 1999},
 		{"InvalidJSON", ErrCodeInvalidJSON, "request", 2000, 2999},
 		{"InvalidID", ErrCodeInvalidID, "request", 2000, 2999},
 		{"MissingFields", ErrCodeMissingFields, "request", 2000, 2999},
+		{"ReadOnly", ErrCodeReadOnly, "request", 2000, 2999},
+		{"CSRFInvalid", ErrCodeCSRFInvalid, "request", 2000, 2999},
+		{"Unauthorized", ErrCodeUnauthorized, "request", 2000, 2999},
+		{"Forbidden", ErrCodeForbidden, "request", 2000, 2999},
+		{"ShareInvalid", ErrCodeShareInvalid, "request", 2000, 2999},
+		{"InvalidQuery", ErrCodeInvalidQuery, "request", 2000, 2999},
+		{"JobNotFound", ErrCodeJobNotFound, "request", 2000, 2999},
+		{"ImportInvalid", ErrCodeImportInvalid, "request", 2000, 2999},
+		{"RouteNotFound", ErrCodeRouteNotFound, "request", 2000, 2999},
+		{"BadRequest", ErrCodeBadRequest, "request", 2000, 2999},
+		{"DeadLetterNotFound", ErrCodeDeadLetterNotFound, "request", 2000, 2999},
+		{"ScheduleInvalid", ErrCodeScheduleInvalid, "request", 2000, 2999},
+		{"JobExists", ErrCodeJobExists, "request", 2000, 2999},
+		{"NotArchived", ErrCodeNotArchived, "request", 2000, 2999},
+		{"InvalidConsistency", ErrCodeInvalidConsistency, "request", 2000, 2999},
+		{"SignatureInvalid", ErrCodeSignatureInvalid, "request", 2000, 2999},
+		{"SignatureExpired", ErrCodeSignatureExpired, "request", 2000, 2999},
+		{"SignatureReplayed", ErrCodeSignatureReplayed, "request", 2000, 2999},
+		{"AddressDenied", ErrCodeAddressDenied, "request", 2000, 2999},
+		{"CompactionRunning", ErrCodeCompactionRunning, "request", 2000, 2999},
+		{"LoadTestInvalid", ErrCodeLoadTestInvalid, "request", 2000, 2999},
+		{"LoadTestRunning", ErrCodeLoadTestRunning, "request", 2000, 2999},
+		{"DemoDisabled", ErrCodeDemoDisabled, "request", 2000, 2999},
+		{"RateLimited", ErrCodeRateLimited, "request", 2000, 2999},
+		{"QuotaExceeded", ErrCodeQuotaExceeded, "request", 2000, 2999},
+		{"OffsetExpired", ErrCodeOffsetExpired, "request", 2000, 2999},
 		{"InternalError", ErrCodeInternalError, "system", 5000, 5999},
 		{"StorageError", ErrCodeStorageError, "system", 5000, 5999},
+		{"Maintenance", ErrCodeMaintenance, "system", 5000, 5999},
+		{"StorageFull", ErrCodeStorageFull, "system", 5000, 5999},
+		{"ShareDisabled", ErrCodeShareDisabled, "system", 5000, 5999},
+		{"NotifyDisabled", ErrCodeNotifyDisabled, "system", 5000, 5999},
+		{"Timeout", ErrCodeTimeout, "system", 5000, 5999},
+		{"Overloaded", ErrCodeOverloaded, "system", 5000, 5999},
+		{"RetentionOff", ErrCodeRetentionOff, "system", 5000, 5999},
+		{"NoKeyIndex", ErrCodeNoKeyIndex, "system", 5000, 5999},
+		{"ShuttingDown", ErrCodeShuttingDown, "system", 5000, 5999},
+		{"StorageCorrupt", ErrCodeStorageCorrupt, "system", 5000, 5999},
+		{"NoCompaction", ErrCodeNoCompaction, "system", 5000, 5999},
+		{"ProjectionsOff", ErrCodeProjectionsOff, "system", 5000, 5999},
 	}
 
 	for _, tt := range tests {
@@ -41,11 +82,52 @@ func TestErrorCodeUniqueness(t *testing.T) {
 		ErrCodeTaskNameRequired,
 		ErrCodeTaskNameTooLong,
 		ErrCodeTaskInvalidStatus,
+		ErrCodeSubtaskNotFound,
+		ErrCodeSubtaskLimit,
+		ErrCodeTaskTooLarge,
 		ErrCodeInvalidJSON,
 		ErrCodeInvalidID,
 		ErrCodeMissingFields,
+		ErrCodeReadOnly,
+		ErrCodeCSRFInvalid,
+		ErrCodeUnauthorized,
+		ErrCodeForbidden,
+		ErrCodeShareInvalid,
+		ErrCodeInvalidQuery,
+		ErrCodeJobNotFound,
+		ErrCodeImportInvalid,
+		ErrCodeRouteNotFound,
+		ErrCodeBadRequest,
+		ErrCodeDeadLetterNotFound,
+		ErrCodeScheduleInvalid,
+		ErrCodeJobExists,
+		ErrCodeNotArchived,
+		ErrCodeInvalidConsistency,
+		ErrCodeSignatureInvalid,
+		ErrCodeSignatureExpired,
+		ErrCodeSignatureReplayed,
+		ErrCodeAddressDenied,
+		ErrCodeCompactionRunning,
+		ErrCodeLoadTestInvalid,
+		ErrCodeLoadTestRunning,
+		ErrCodeDemoDisabled,
+		ErrCodeRateLimited,
+		ErrCodeQuotaExceeded,
+		ErrCodeOffsetExpired,
 		ErrCodeInternalError,
 		ErrCodeStorageError,
+		ErrCodeMaintenance,
+		ErrCodeStorageFull,
+		ErrCodeShareDisabled,
+		ErrCodeNotifyDisabled,
+		ErrCodeTimeout,
+		ErrCodeOverloaded,
+		ErrCodeRetentionOff,
+		ErrCodeNoKeyIndex,
+		ErrCodeShuttingDown,
+		ErrCodeStorageCorrupt,
+		ErrCodeNoCompaction,
+		ErrCodeProjectionsOff,
 	}
 
 	seen := make(map[int]bool)
@@ -88,3 +170,12 @@ func TestAppError_Error(t *testing.T) {
 		t.Errorf("Expected code %d, got %d", ErrCodeTaskInvalidInput, appErr.Code)
 	}
 }
+
+func TestToResponse_Retryable(t *testing.T) {
+	if resp := ToResponse(ErrMaintenance.WithCause(nil)); !resp.Retryable {
+		t.Error("Expected maintenance errors to be retryable")
+	}
+	if resp := ToResponse(ErrTaskNotFound); resp.Retryable {
+		t.Error("Expected not-found errors not to be retryable")
+	}
+}
@@ -4,16 +4,25 @@ package errors
 
 // ErrorResponse represents a standardized API error response
 type ErrorResponse struct {
-	Code    int    `json:"code"`
-	Message string `json:"message,omitempty"`
+	Code      int    `json:"code"`
+	Message   string `json:"message,omitempty"`
+	Retryable bool   `json:"retryable,omitempty"` // The same request may succeed later; see Retry-After
+
+	Debug *DebugInfo `json:"debug,omitempty"` // Cause chain and stack; only when SetDebug(true)
 }
 
-// ToResponse creates an error response from AppError
+// ToResponse creates an error response from AppError. In debug mode the
+// response also carries the sanitized cause chain.
 func ToResponse(appErr *AppError) ErrorResponse {
-	return ErrorResponse{
-		Code:    appErr.Code,
-		Message: appErr.Message,
+	resp := ErrorResponse{
+		Code:      appErr.Code,
+		Message:   appErr.Message,
+		Retryable: appErr.Retryable,
+	}
+	if Debug() {
+		resp.Debug = debugInfo(appErr)
 	}
+	return resp
 }
 
 var (
@@ -23,3 +32,13 @@ var (
 		Message: ErrInternalError.Message,
 	}
 )
+
+// InternalErrorResponse hides err behind ErrInternalErrorResponse. In debug
+// mode err is reported as the response's cause chain.
+func InternalErrorResponse(err error) *ErrorResponse {
+	if !Debug() || err == nil {
+		return ErrInternalErrorResponse
+	}
+	resp := ToResponse(ErrInternalError.WithCause(err))
+	return &resp
+}
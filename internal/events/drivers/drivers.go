@@ -0,0 +1,39 @@
+package drivers
+
+import (
+	"tasks-service-demo/internal/events"
+	"tasks-service-demo/internal/events/kafkabus"
+	"tasks-service-demo/internal/events/natsbus"
+	"tasks-service-demo/internal/events/redisbus"
+)
+
+// Package drivers registers the external event bus drivers with the events
+// registry. Like storage/backends it keeps the broker clients out of the
+// events package; import it for its side effect.
+
+func init() {
+	for _, d := range []events.Driver{
+		{
+			Name:        "nats",
+			Description: "NATS JetStream (durable consumer per group, at-least-once)",
+			AtLeastOnce: true,
+			Open:        func(cfg events.Config) (events.Bus, error) { return natsbus.Open(cfg) },
+		},
+		{
+			Name:        "kafka",
+			Description: "Kafka (consumer group, offsets committed after handling, at-least-once)",
+			AtLeastOnce: true,
+			Open:        func(cfg events.Config) (events.Bus, error) { return kafkabus.Open(cfg) },
+		},
+		{
+			Name:        "redis",
+			Description: "Redis Streams (consumer group, pending messages reclaimed, at-least-once)",
+			AtLeastOnce: true,
+			Open:        func(cfg events.Config) (events.Bus, error) { return redisbus.Open(cfg) },
+		},
+	} {
+		if err := events.Register(d); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Package events is the event bus between the task service and anything
+// reacting to task changes. Publishers and subscribers only see the Bus
+// interface; the driver behind it is chosen by configuration: the built-in
+// in-process fan-out, or an external broker registered by the drivers
+// package.
+
+// Message is one event on a topic
+type Message struct {
+	ID    string // Unique per published message; redeliveries keep it
+	Topic string
+	Data  []byte
+	Time  time.Time // When the message was published
+}
+
+// Handler processes one delivered message. With at-least-once drivers a
+// non-nil error leaves the message unacknowledged, so it is delivered again;
+// handlers must therefore tolerate duplicates.
+type Handler func(ctx context.Context, msg Message) error
+
+// Publisher sends messages to a topic
+type Publisher interface {
+	Publish(ctx context.Context, topic string, data []byte) error
+}
+
+// Subscription is a registered Handler
+type Subscription interface {
+	// Unsubscribe stops deliveries and waits for a running handler to return
+	Unsubscribe() error
+}
+
+// Subscriber registers handlers for a topic
+type Subscriber interface {
+	Subscribe(topic string, h Handler) (Subscription, error)
+}
+
+// Bus is a Publisher and Subscriber backed by one driver
+type Bus interface {
+	Publisher
+	Subscriber
+	// Close stops every subscription and releases the driver's connections
+	Close() error
+}
+
+// Config selects and configures a driver
+type Config struct {
+	Driver          string
+	URL             string        // Broker address for external drivers
+	Group           string        // Consumer group sharing deliveries across service instances
+	Buffer          int           // Per-subscription queue of the memory driver
+	RedeliveryDelay time.Duration // Wait before an external driver redelivers a failed message
+}
+
+// Defaults for Config fields left zero
+const (
+	DefaultDriver          = "memory"
+	DefaultGroup           = "tasks-service"
+	DefaultBuffer          = 1024
+	DefaultRedeliveryDelay = 5 * time.Second
+)
+
+func (c Config) withDefaults() Config {
+	if c.Driver == "" {
+		c.Driver = DefaultDriver
+	}
+	if c.Group == "" {
+		c.Group = DefaultGroup
+	}
+	if c.Buffer <= 0 {
+		c.Buffer = DefaultBuffer
+	}
+	if c.RedeliveryDelay <= 0 {
+		c.RedeliveryDelay = DefaultRedeliveryDelay
+	}
+	return c
+}
+
+// Driver describes a bus implementation selectable with EVENT_BUS_DRIVER
+type Driver struct {
+	Name        string
+	Description string // Logged when the driver is selected
+	AtLeastOnce bool   // Failed and unacknowledged messages are redelivered; needs Config.URL
+	Open        func(cfg Config) (Bus, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	drivers    = make(map[string]Driver)
+)
+
+func init() {
+	if err := Register(Driver{
+		Name:        DefaultDriver,
+		Description: "in-process fan-out (at-most-once, lost on restart)",
+		Open:        func(cfg Config) (Bus, error) { return NewMemoryBus(cfg.Buffer), nil },
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// Register adds a driver. Names are unique.
+func Register(d Driver) error {
+	if d.Name == "" || d.Open == nil {
+		return fmt.Errorf("event bus driver needs a name and an Open function")
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, exists := drivers[d.Name]; exists {
+		return fmt.Errorf("event bus driver %q is already registered", d.Name)
+	}
+	drivers[d.Name] = d
+	return nil
+}
+
+// Lookup returns the driver registered as name
+func Lookup(name string) (Driver, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	d, ok := drivers[name]
+	return d, ok
+}
+
+// Drivers returns the registered driver names, sorted
+func Drivers() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Open creates a Bus with the driver named in cfg
+func Open(cfg Config) (Bus, error) {
+	cfg = cfg.withDefaults()
+	d, ok := Lookup(cfg.Driver)
+	if !ok {
+		return nil, fmt.Errorf("unknown event bus driver %q", cfg.Driver)
+	}
+	if d.AtLeastOnce && cfg.URL == "" {
+		return nil, fmt.Errorf("the %s event bus driver needs a URL", d.Name)
+	}
+	return d.Open(cfg)
+}
+
+// NewMessageID returns a random message ID
+func NewMessageID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		panic(fmt.Sprintf("events: reading random bytes: %v", err))
+	}
+	return hex.EncodeToString(id)
+}
+
+var current atomic.Pointer[Bus]
+
+func init() {
+	Reset()
+}
+
+// Get returns the process-wide Bus
+func Get() Bus {
+	return *current.Load()
+}
+
+// Init replaces the process-wide Bus
+func Init(b Bus) {
+	current.Store(&b)
+}
+
+// Reset installs an in-process Bus with the default buffer
+func Reset() {
+	Init(NewMemoryBus(DefaultBuffer))
+}
@@ -0,0 +1,140 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collect gathers delivered messages
+type collect struct {
+	mu   sync.Mutex
+	msgs []Message
+	got  chan struct{}
+}
+
+func newCollect() *collect {
+	return &collect{got: make(chan struct{}, 100)}
+}
+
+func (c *collect) handle(_ context.Context, msg Message) error {
+	c.mu.Lock()
+	c.msgs = append(c.msgs, msg)
+	c.mu.Unlock()
+	c.got <- struct{}{}
+	return nil
+}
+
+func (c *collect) wait(t *testing.T, n int) []Message {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-c.got:
+		case <-time.After(time.Second):
+			t.Fatalf("Expected %d messages, got %d", n, i)
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Message(nil), c.msgs...)
+}
+
+func TestMemoryBus_FanOut(t *testing.T) {
+	bus := NewMemoryBus(10)
+	defer bus.Close()
+
+	a, b, other := newCollect(), newCollect(), newCollect()
+	bus.Subscribe("tasks", a.handle)
+	bus.Subscribe("tasks", b.handle)
+	bus.Subscribe("other", other.handle)
+
+	ctx := context.Background()
+	bus.Publish(ctx, "tasks", []byte("1"))
+	bus.Publish(ctx, "tasks", []byte("2"))
+
+	for _, c := range []*collect{a, b} {
+		msgs := c.wait(t, 2)
+		if string(msgs[0].Data) != "1" || string(msgs[1].Data) != "2" || msgs[0].Topic != "tasks" {
+			t.Errorf("Expected both messages in order, got %+v", msgs)
+		}
+		if msgs[0].ID == "" || msgs[0].ID == msgs[1].ID || msgs[0].Time.IsZero() {
+			t.Errorf("Expected unique IDs and a publish time, got %+v", msgs)
+		}
+	}
+	if len(other.msgs) != 0 {
+		t.Errorf("Expected no messages on another topic, got %+v", other.msgs)
+	}
+}
+
+func TestMemoryBus_DropsForSlowSubscriber(t *testing.T) {
+	bus := NewMemoryBus(1)
+	defer bus.Close()
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 10)
+	bus.Subscribe("tasks", func(context.Context, Message) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	})
+
+	ctx := context.Background()
+	bus.Publish(ctx, "tasks", nil)
+	<-started // The handler holds the first message, the queue is empty again
+	for i := 0; i < 3; i++ {
+		if err := bus.Publish(ctx, "tasks", nil); err != nil {
+			t.Fatalf("Expected publish never to block or fail, got %v", err)
+		}
+	}
+	close(release)
+
+	if stats := bus.Stats(); stats.Published != 4 || stats.Dropped != 2 {
+		t.Errorf("Expected 4 published and 2 dropped, got %+v", stats)
+	}
+}
+
+func TestMemoryBus_UnsubscribeAndClose(t *testing.T) {
+	bus := NewMemoryBus(10)
+	c := newCollect()
+	sub, _ := bus.Subscribe("tasks", c.handle)
+
+	sub.Unsubscribe()
+	bus.Publish(context.Background(), "tasks", nil)
+	time.Sleep(10 * time.Millisecond)
+	if len(c.msgs) != 0 {
+		t.Errorf("Expected no deliveries after Unsubscribe, got %+v", c.msgs)
+	}
+
+	bus.Close()
+	if err := bus.Publish(context.Background(), "tasks", nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+	if _, err := bus.Subscribe("tasks", c.handle); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	bus, err := Open(Config{})
+	if err != nil {
+		t.Fatalf("Expected the memory driver by default, got %v", err)
+	}
+	if _, ok := bus.(*MemoryBus); !ok {
+		t.Errorf("Expected *MemoryBus, got %T", bus)
+	}
+	bus.Close()
+
+	if _, err := Open(Config{Driver: "carrier-pigeon"}); err == nil {
+		t.Error("Expected unknown driver to be rejected")
+	}
+
+	Register(Driver{Name: "test-broker", AtLeastOnce: true, Open: func(Config) (Bus, error) { return NewMemoryBus(1), nil }})
+	if _, err := Open(Config{Driver: "test-broker"}); err == nil {
+		t.Error("Expected at-least-once driver without URL to be rejected")
+	}
+	if err := Register(Driver{Name: "test-broker", Open: func(Config) (Bus, error) { return nil, nil }}); err == nil {
+		t.Error("Expected duplicate driver to be rejected")
+	}
+}
@@ -0,0 +1,173 @@
+package kafkabus
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"tasks-service-demo/internal/events"
+	"tasks-service-demo/internal/logger"
+)
+
+// Package kafkabus is an events.Bus on Kafka. Each topic maps to a Kafka
+// topic read by a consumer group, and offsets are committed only after the
+// handler succeeded, so a message whose handler failed or whose instance
+// crashed is delivered again.
+
+// idHeader carries the events.Message ID
+const idHeader = "event-id"
+
+// Bus writes to and reads from Kafka topics
+type Bus struct {
+	cfg     events.Config
+	brokers []string
+	writer  *kafka.Writer
+
+	mu   sync.Mutex
+	subs map[*subscription]struct{}
+}
+
+// Open creates a Bus for the comma-separated broker list in cfg.URL; a
+// kafka:// prefix is allowed. Brokers are contacted on first use.
+func Open(cfg events.Config) (*Bus, error) {
+	brokers := ParseBrokers(cfg.URL)
+	if len(brokers) == 0 {
+		return nil, errors.New("no Kafka brokers in event bus URL")
+	}
+	return &Bus{
+		cfg:     cfg,
+		brokers: brokers,
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+		},
+		subs: make(map[*subscription]struct{}),
+	}, nil
+}
+
+// ParseBrokers splits a broker list such as kafka://a:9092,b:9092
+func ParseBrokers(url string) []string {
+	url = strings.TrimPrefix(url, "kafka://")
+	var brokers []string
+	for _, b := range strings.Split(url, ",") {
+		if b = strings.TrimSpace(b); b != "" {
+			brokers = append(brokers, b)
+		}
+	}
+	return brokers
+}
+
+// Publish writes data to topic and waits for all in-sync replicas
+func (b *Bus) Publish(ctx context.Context, topic string, data []byte) error {
+	return b.writer.WriteMessages(ctx, kafka.Message{
+		Topic:   topic,
+		Value:   data,
+		Headers: []kafka.Header{{Key: idHeader, Value: []byte(events.NewMessageID())}},
+	})
+}
+
+// Subscribe reads topic as a member of the cfg.Group consumer group. A
+// failed message is retried every cfg.RedeliveryDelay before the partition
+// moves on, which keeps per-partition order.
+func (b *Bus) Subscribe(topic string, h events.Handler) (events.Subscription, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := &subscription{
+		bus: b,
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers:     b.brokers,
+			GroupID:     b.cfg.Group,
+			Topic:       topic,
+			StartOffset: kafka.FirstOffset,
+		}),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	go sub.run(ctx, h, b.cfg.RedeliveryDelay)
+	return sub, nil
+}
+
+// Close stops every subscription and the writer
+func (b *Bus) Close() error {
+	b.mu.Lock()
+	subs := make([]*subscription, 0, len(b.subs))
+	for sub := range b.subs {
+		subs = append(subs, sub)
+	}
+	b.mu.Unlock()
+
+	errs := make([]error, 0, len(subs)+1)
+	for _, sub := range subs {
+		errs = append(errs, sub.Unsubscribe())
+	}
+	errs = append(errs, b.writer.Close())
+	return errors.Join(errs...)
+}
+
+type subscription struct {
+	bus    *Bus
+	reader *kafka.Reader
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+	err    error
+}
+
+func (s *subscription) run(ctx context.Context, h events.Handler, retryDelay time.Duration) {
+	defer close(s.done)
+	for {
+		m, err := s.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Get().Errorw("Kafka fetch failed", "topic", s.reader.Config().Topic, "error", err)
+			}
+			return
+		}
+
+		msg := events.Message{Topic: m.Topic, Data: m.Value, Time: m.Time}
+		for _, header := range m.Headers {
+			if header.Key == idHeader {
+				msg.ID = string(header.Value)
+			}
+		}
+
+		for {
+			err := h(ctx, msg)
+			if err == nil {
+				break
+			}
+			logger.Get().Warnw("Event handler failed, retrying", "topic", msg.Topic, "id", msg.ID, "error", err)
+			select {
+			case <-ctx.Done():
+				return // Not committed: the group redelivers it
+			case <-time.After(retryDelay):
+			}
+		}
+
+		if err := s.reader.CommitMessages(ctx, m); err != nil && ctx.Err() == nil {
+			logger.Get().Errorw("Kafka commit failed", "topic", msg.Topic, "id", msg.ID, "error", err)
+		}
+	}
+}
+
+func (s *subscription) Unsubscribe() error {
+	s.once.Do(func() {
+		s.bus.mu.Lock()
+		delete(s.bus.subs, s)
+		s.bus.mu.Unlock()
+
+		s.cancel()
+		<-s.done
+		s.err = s.reader.Close()
+	})
+	return s.err
+}
@@ -0,0 +1,25 @@
+package kafkabus
+
+import (
+	"reflect"
+	"testing"
+
+	"tasks-service-demo/internal/events"
+)
+
+func TestParseBrokers(t *testing.T) {
+	cases := map[string][]string{
+		"kafka://a:9092,b:9092": {"a:9092", "b:9092"},
+		" a:9092 , ":            {"a:9092"},
+		"":                      nil,
+	}
+	for url, expected := range cases {
+		if got := ParseBrokers(url); !reflect.DeepEqual(got, expected) {
+			t.Errorf("Expected %v for %q, got %v", expected, url, got)
+		}
+	}
+
+	if _, err := Open(events.Config{URL: "kafka://"}); err == nil {
+		t.Error("Expected a URL without brokers to be rejected")
+	}
+}
@@ -0,0 +1,155 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tasks-service-demo/internal/logger"
+)
+
+// ErrClosed is returned when publishing to or subscribing on a closed Bus
+var ErrClosed = errors.New("event bus is closed")
+
+// MemoryBus fans messages out to in-process subscribers. Each subscription
+// has its own bounded queue and goroutine, so a slow subscriber never blocks
+// publishers or other subscribers: once its queue is full, further messages
+// for it are dropped and counted. Delivery is at-most-once and failed
+// handlers are not retried.
+type MemoryBus struct {
+	buffer int
+
+	mu     sync.RWMutex
+	subs   map[string]map[*memorySub]struct{}
+	closed bool
+
+	published atomic.Int64
+	dropped   atomic.Int64
+}
+
+// MemoryStats counts messages handled by a MemoryBus
+type MemoryStats struct {
+	Published int64 // Messages passed to Publish
+	Dropped   int64 // Deliveries skipped because a subscriber's queue was full
+}
+
+// NewMemoryBus creates a MemoryBus with a queue of buffer messages per
+// subscription
+func NewMemoryBus(buffer int) *MemoryBus {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	return &MemoryBus{buffer: buffer, subs: make(map[string]map[*memorySub]struct{})}
+}
+
+// Publish queues data for every current subscriber of topic
+func (b *MemoryBus) Publish(ctx context.Context, topic string, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	msg := Message{ID: NewMessageID(), Topic: topic, Data: data, Time: time.Now()}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return ErrClosed
+	}
+	b.published.Add(1)
+	for sub := range b.subs[topic] {
+		select {
+		case sub.queue <- msg:
+		default:
+			b.dropped.Add(1)
+		}
+	}
+	return nil
+}
+
+// Subscribe starts delivering messages published to topic from now on
+func (b *MemoryBus) Subscribe(topic string, h Handler) (Subscription, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := &memorySub{bus: b, topic: topic, queue: make(chan Message, b.buffer), cancel: cancel, done: make(chan struct{})}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		cancel()
+		return nil, ErrClosed
+	}
+	if b.subs[topic] == nil {
+		b.subs[topic] = make(map[*memorySub]struct{})
+	}
+	b.subs[topic][sub] = struct{}{}
+	b.mu.Unlock()
+
+	go sub.run(ctx, h)
+	return sub, nil
+}
+
+// Stats returns the message counters
+func (b *MemoryBus) Stats() MemoryStats {
+	return MemoryStats{Published: b.published.Load(), Dropped: b.dropped.Load()}
+}
+
+// Close stops every subscription; queued messages are discarded
+func (b *MemoryBus) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	var subs []*memorySub
+	for _, set := range b.subs {
+		for sub := range set {
+			subs = append(subs, sub)
+		}
+	}
+	b.subs = nil
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.stop()
+	}
+	return nil
+}
+
+type memorySub struct {
+	bus    *MemoryBus
+	topic  string
+	queue  chan Message
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+func (s *memorySub) run(ctx context.Context, h Handler) {
+	defer close(s.done)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-s.queue:
+			if err := h(ctx, msg); err != nil {
+				logger.Get().Warnw("Event handler failed", "topic", msg.Topic, "id", msg.ID, "error", err)
+			}
+		}
+	}
+}
+
+func (s *memorySub) stop() {
+	s.once.Do(s.cancel)
+	<-s.done
+}
+
+// Unsubscribe removes the subscription and waits for a running handler
+func (s *memorySub) Unsubscribe() error {
+	s.bus.mu.Lock()
+	delete(s.bus.subs[s.topic], s)
+	s.bus.mu.Unlock()
+
+	s.stop()
+	return nil
+}
@@ -0,0 +1,143 @@
+package natsbus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"tasks-service-demo/internal/events"
+)
+
+// Package natsbus is an events.Bus on NATS JetStream. Every topic is stored
+// in its own stream and read through a durable consumer per group, so
+// messages published while no instance is running are still delivered, and
+// a message is redelivered until a handler acknowledges it.
+
+// setupTimeout bounds stream and consumer creation
+const setupTimeout = 10 * time.Second
+
+// Bus publishes to and consumes from JetStream streams
+type Bus struct {
+	cfg events.Config
+	nc  *nats.Conn
+	js  jetstream.JetStream
+
+	streams sync.Map // Topics whose stream is known to exist
+}
+
+// Open connects to the NATS server at cfg.URL
+func Open(cfg events.Config) (*Bus, error) {
+	nc, err := nats.Connect(cfg.URL, nats.Name(cfg.Group), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("connecting to NATS: %w", err)
+	}
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	return &Bus{cfg: cfg, nc: nc, js: js}, nil
+}
+
+// streamName maps a topic to a valid stream name
+func streamName(topic string) string {
+	return "EVENTS_" + strings.ToUpper(sanitize(topic))
+}
+
+// sanitize replaces characters NATS forbids in stream and consumer names
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '*', '>', ' ', '\t', '/', '\\':
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// ensureStream creates the topic's stream the first time it is used
+func (b *Bus) ensureStream(ctx context.Context, topic string) error {
+	if _, ok := b.streams.Load(topic); ok {
+		return nil
+	}
+	_, err := b.js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     streamName(topic),
+		Subjects: []string{topic},
+		Storage:  jetstream.FileStorage,
+	})
+	if err == nil {
+		b.streams.Store(topic, struct{}{})
+	}
+	return err
+}
+
+// Publish stores data in the topic's stream. The message ID is also the
+// JetStream deduplication ID, so the client's retries after a lost
+// acknowledgement are stored once.
+func (b *Bus) Publish(ctx context.Context, topic string, data []byte) error {
+	if err := b.ensureStream(ctx, topic); err != nil {
+		return fmt.Errorf("creating stream for %s: %w", topic, err)
+	}
+	_, err := b.js.Publish(ctx, topic, data, jetstream.WithMsgID(events.NewMessageID()))
+	return err
+}
+
+// Subscribe consumes topic with the group's durable consumer. A handler
+// error negatively acknowledges the message, which JetStream redelivers
+// after cfg.RedeliveryDelay.
+func (b *Bus) Subscribe(topic string, h events.Handler) (events.Subscription, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), setupTimeout)
+	defer cancel()
+
+	if err := b.ensureStream(ctx, topic); err != nil {
+		return nil, fmt.Errorf("creating stream for %s: %w", topic, err)
+	}
+	consumer, err := b.js.CreateOrUpdateConsumer(ctx, streamName(topic), jetstream.ConsumerConfig{
+		Durable:       sanitize(b.cfg.Group),
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		FilterSubject: topic,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating consumer for %s: %w", topic, err)
+	}
+
+	handlerCtx, stop := context.WithCancel(context.Background())
+	cc, err := consumer.Consume(func(m jetstream.Msg) {
+		msg := events.Message{ID: m.Headers().Get(jetstream.MsgIDHeader), Topic: topic, Data: m.Data()}
+		if meta, err := m.Metadata(); err == nil {
+			msg.Time = meta.Timestamp
+		}
+		if err := h(handlerCtx, msg); err != nil {
+			m.NakWithDelay(b.cfg.RedeliveryDelay)
+			return
+		}
+		m.Ack()
+	})
+	if err != nil {
+		stop()
+		return nil, err
+	}
+	return &subscription{cc: cc, stop: stop}, nil
+}
+
+// Close drains the connection, letting running handlers acknowledge
+func (b *Bus) Close() error {
+	return b.nc.Drain()
+}
+
+type subscription struct {
+	cc   jetstream.ConsumeContext
+	stop context.CancelFunc
+}
+
+func (s *subscription) Unsubscribe() error {
+	s.cc.Stop()
+	s.stop()
+	<-s.cc.Closed()
+	return nil
+}
@@ -0,0 +1,177 @@
+package natsbus
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+
+	"tasks-service-demo/internal/events"
+)
+
+// startServer runs an embedded NATS server with JetStream on a random port
+func startServer(t *testing.T) *server.Server {
+	t.Helper()
+	s, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, JetStream: true, StoreDir: t.TempDir(), NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Start()
+	if !s.ReadyForConnections(5 * time.Second) {
+		t.Fatal("Expected the NATS server to start")
+	}
+	t.Cleanup(s.Shutdown)
+	return s
+}
+
+func openBus(t *testing.T, url, group string) *Bus {
+	t.Helper()
+	bus, err := Open(events.Config{URL: url, Group: group, RedeliveryDelay: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { bus.Close() })
+	return bus
+}
+
+// received collects the data of delivered messages
+type received struct {
+	mu   sync.Mutex
+	data []string
+	got  chan struct{}
+}
+
+func newReceived() *received {
+	return &received{got: make(chan struct{}, 100)}
+}
+
+func (r *received) add(msg events.Message) {
+	r.mu.Lock()
+	r.data = append(r.data, string(msg.Data))
+	r.mu.Unlock()
+	r.got <- struct{}{}
+}
+
+func (r *received) wait(t *testing.T, n int) []string {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-r.got:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected %d messages, got %d", n, i)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.data...)
+}
+
+func TestBus_Delivers(t *testing.T) {
+	bus := openBus(t, startServer(t).ClientURL(), "tasks-service")
+	ctx := context.Background()
+
+	r := newReceived()
+	sub, err := bus.Subscribe("tasks", func(_ context.Context, msg events.Message) error {
+		if msg.ID == "" || msg.Topic != "tasks" || msg.Time.IsZero() {
+			t.Errorf("Expected the message ID, topic and time, got %+v", msg)
+		}
+		r.add(msg)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	for _, data := range []string{"a", "b", "c"} {
+		if err := bus.Publish(ctx, "tasks", []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := r.wait(t, 3); got[0] != "a" || got[1] != "b" || got[2] != "c" {
+		t.Errorf("Expected the messages in order, got %v", got)
+	}
+}
+
+func TestBus_RedeliversFailedMessages(t *testing.T) {
+	bus := openBus(t, startServer(t).ClientURL(), "tasks-service")
+
+	r := newReceived()
+	failures := 2
+	sub, err := bus.Subscribe("tasks", func(_ context.Context, msg events.Message) error {
+		r.add(msg)
+		if failures > 0 {
+			failures--
+			return errors.New("handler failed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	bus.Publish(context.Background(), "tasks", []byte("a"))
+	if got := r.wait(t, 3); len(got) != 3 || got[2] != "a" {
+		t.Errorf("Expected the message delivered until its handler succeeded, got %v", got)
+	}
+	select {
+	case <-r.got:
+		t.Error("Expected no delivery after the acknowledgement")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestBus_DurableAcrossSubscribers(t *testing.T) {
+	url := startServer(t).ClientURL()
+	ctx := context.Background()
+
+	// Messages published while no instance of the group is subscribed are
+	// kept for the next one
+	publisher := openBus(t, url, "tasks-service")
+	publisher.Publish(ctx, "tasks", []byte("before"))
+
+	r := newReceived()
+	handler := func(_ context.Context, msg events.Message) error {
+		r.add(msg)
+		return nil
+	}
+	first := openBus(t, url, "tasks-service")
+	sub, err := first.Subscribe("tasks", handler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.wait(t, 1)
+	sub.Unsubscribe()
+	first.Close()
+
+	publisher.Publish(ctx, "tasks", []byte("while down"))
+
+	// Instances of one group share the deliveries: each message goes to
+	// one of them, once
+	second := openBus(t, url, "tasks-service")
+	third := openBus(t, url, "tasks-service")
+	for _, bus := range []*Bus{second, third} {
+		sub, err := bus.Subscribe("tasks", handler)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sub.Unsubscribe()
+	}
+	publisher.Publish(ctx, "tasks", []byte("after"))
+
+	got := r.wait(t, 2)
+	sort.Strings(got[1:])
+	if len(got) != 3 || got[0] != "before" || got[1] != "after" || got[2] != "while down" {
+		t.Errorf("Expected every message once, got %v", got)
+	}
+	select {
+	case <-r.got:
+		t.Error("Expected no duplicate delivery")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
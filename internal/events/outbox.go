@@ -0,0 +1,297 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"tasks-service-demo/internal/changes"
+	"tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/storage"
+)
+
+const (
+	// outboxBatch is the number of changes moved from the feed per lock
+	outboxBatch = 500
+	// outboxKey is the storage meta record of the events not yet published
+	outboxKey = "event-outbox"
+	// minRetryDelay is the first wait after a failed publish; it doubles up
+	// to the retry delay of the Outbox
+	minRetryDelay = 100 * time.Millisecond
+)
+
+// OutboxStats reports how far publishing trails the writes
+type OutboxStats struct {
+	Pending   int        `json:"pending"`          // Events waiting to be published
+	Oldest    *time.Time `json:"oldest,omitempty"` // Write time of the oldest pending event
+	Published uint64     `json:"published"`
+	Failures  uint64     `json:"failures"` // Failed publish attempts
+	Lost      uint64     `json:"lost"`     // Changes dropped from the feed before the outbox read them
+	LastError string     `json:"lastError,omitempty"`
+}
+
+// Outbox publishes a TaskEvent on TopicTasks for every change in the feed.
+// The feed is filled by changes.TrackingStore, so writes made past the task
+// service (sync, imports, archiving, demo resets, evictions) are
+// published too. Writes never wait for the bus: events queue in the outbox
+// and are published in order, each retried with backoff until the bus takes
+// it, so delivery is at-least-once. Events still pending when a publish
+// fails and at Close are saved to storage meta, when the backend holds it,
+// and published after a restart.
+type Outbox struct {
+	feed       *changes.Feed
+	pub        Publisher
+	meta       storage.MetaStore // Nil keeps pending events in memory only
+	retryDelay time.Duration
+
+	mu        sync.Mutex // Guards the fields below
+	read      uint64     // Last feed revision queued
+	pending   []TaskEvent
+	saved     bool // meta holds pending events
+	published uint64
+	failures  uint64
+	lost      uint64
+	lastErr   error
+
+	wake   chan struct{}
+	cancel context.CancelFunc
+	done   sync.WaitGroup
+}
+
+// NewOutbox creates an Outbox publishing feed to pub, saving pending events
+// in meta when it is not nil and waiting at most retryDelay between
+// attempts
+func NewOutbox(feed *changes.Feed, pub Publisher, meta storage.MetaStore, retryDelay time.Duration) *Outbox {
+	if retryDelay < minRetryDelay {
+		retryDelay = minRetryDelay
+	}
+	return &Outbox{feed: feed, pub: pub, meta: meta, retryDelay: retryDelay, wake: make(chan struct{}, 1)}
+}
+
+// Start loads the events saved by a previous process and publishes every
+// change recorded from now on until Close
+func (o *Outbox) Start() error {
+	if o.meta != nil {
+		data, ok, err := o.meta.GetMeta(outboxKey)
+		if err != nil {
+			return err
+		}
+		if ok {
+			var saved []TaskEvent
+			if err := json.Unmarshal(data, &saved); err != nil {
+				return fmt.Errorf("parse saved events: %w", err)
+			}
+			o.mu.Lock()
+			o.pending = append(saved, o.pending...)
+			o.saved = len(saved) > 0
+			o.mu.Unlock()
+		}
+	}
+
+	o.mu.Lock()
+	o.read = o.feed.Revision()
+	o.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	o.cancel = cancel
+	o.done.Add(2)
+	go o.follow(ctx)
+	go o.deliver(ctx)
+	o.notify()
+	return nil
+}
+
+// follow queues the changes of the feed as they are recorded
+func (o *Outbox) follow(ctx context.Context) {
+	defer o.done.Done()
+	for {
+		read := o.collect()
+		if _, err := o.feed.Wait(ctx, read); err != nil {
+			return
+		}
+	}
+}
+
+// collect queues the changes recorded since the last call and returns the
+// last revision queued
+func (o *Outbox) collect() uint64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for {
+		records, current, complete := o.feed.Records(o.read, outboxBatch)
+		if len(records) == 0 {
+			return o.read
+		}
+		if !complete {
+			missed := records[0].Revision - o.read - 1
+			o.lost += missed
+			logger.Get().Errorw("Change feed dropped changes before they were published", "changes", missed)
+		}
+		for _, r := range records {
+			o.pending = append(o.pending, taskEvent(r))
+		}
+		o.read = records[len(records)-1].Revision
+		o.notify()
+		if o.read >= current {
+			return o.read
+		}
+	}
+}
+
+// notify wakes deliver without blocking
+func (o *Outbox) notify() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// deliver publishes pending events oldest first, retrying the oldest until
+// it is published
+func (o *Outbox) deliver(ctx context.Context) {
+	defer o.done.Done()
+	delay := minRetryDelay
+	for {
+		select {
+		case <-o.wake:
+		case <-ctx.Done():
+			return
+		}
+		for {
+			more, err := o.publishNext(ctx)
+			if err == nil {
+				delay = minRetryDelay
+				if more {
+					continue
+				}
+				o.clearSaved()
+				break
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			o.save()
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return
+			}
+			delay = min(delay*2, o.retryDelay)
+		}
+	}
+}
+
+// publishNext publishes the oldest pending event, if any, and reports
+// whether more are pending
+func (o *Outbox) publishNext(ctx context.Context) (more bool, err error) {
+	o.mu.Lock()
+	if len(o.pending) == 0 {
+		o.mu.Unlock()
+		return false, nil
+	}
+	ev := o.pending[0]
+	o.mu.Unlock()
+
+	data, err := json.Marshal(ev)
+	if err == nil {
+		ctx, cancel := context.WithTimeout(ctx, PublishTimeout)
+		err = o.pub.Publish(ctx, TopicTasks, data)
+		cancel()
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err != nil {
+		if o.lastErr == nil {
+			logger.Get().Warnw("Failed to publish task event, retrying", "type", ev.Type, "id", ev.ID, "error", err)
+		}
+		o.failures++
+		o.lastErr = err
+		return true, err
+	}
+	o.pending = o.pending[1:]
+	o.published++
+	o.lastErr = nil
+	return len(o.pending) > 0, nil
+}
+
+// save writes the pending events to meta, so they outlive the process
+func (o *Outbox) save() {
+	if o.meta == nil {
+		return
+	}
+	o.mu.Lock()
+	data, err := json.Marshal(o.pending)
+	o.mu.Unlock()
+	if err == nil {
+		err = o.meta.PutMeta(outboxKey, data)
+	}
+	if err != nil {
+		logger.Get().Errorw("Failed to save pending task events", "error", err)
+		return
+	}
+	o.mu.Lock()
+	o.saved = true
+	o.mu.Unlock()
+}
+
+// clearSaved empties the saved events once everything is published
+func (o *Outbox) clearSaved() {
+	o.mu.Lock()
+	saved := o.saved && len(o.pending) == 0
+	o.mu.Unlock()
+	if !saved {
+		return
+	}
+	if err := o.meta.PutMeta(outboxKey, []byte("[]")); err != nil {
+		logger.Get().Errorw("Failed to clear saved task events", "error", err)
+		return
+	}
+	o.mu.Lock()
+	o.saved = false
+	o.mu.Unlock()
+}
+
+// Close stops following the feed, publishes what is pending until the bus
+// fails or ctx is done, and saves the rest; it has the signature of a
+// shutdown step
+func (o *Outbox) Close(ctx context.Context) error {
+	if o.cancel == nil {
+		return nil
+	}
+	o.cancel()
+	o.done.Wait()
+
+	o.collect()
+	for {
+		more, err := o.publishNext(ctx)
+		if err != nil {
+			break
+		}
+		if !more {
+			o.clearSaved()
+			return nil
+		}
+	}
+	o.save()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return fmt.Errorf("%d task events not published: %w", len(o.pending), o.lastErr)
+}
+
+// Stats returns the backlog of the outbox
+func (o *Outbox) Stats() OutboxStats {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	s := OutboxStats{Pending: len(o.pending), Published: o.published, Failures: o.failures, Lost: o.lost}
+	if len(o.pending) > 0 {
+		oldest := o.pending[0].Time
+		s.Oldest = &oldest
+	}
+	if o.lastErr != nil {
+		s.LastError = o.lastErr.Error()
+	}
+	return s
+}
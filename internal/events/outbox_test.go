@@ -0,0 +1,189 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/changes"
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/storage/storetest"
+)
+
+// decode returns the task events in msgs
+func decode(t *testing.T, msgs []Message) []TaskEvent {
+	t.Helper()
+	var got []TaskEvent
+	for _, msg := range msgs {
+		var ev TaskEvent
+		if err := json.Unmarshal(msg.Data, &ev); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, ev)
+	}
+	return got
+}
+
+func TestOutbox_PublishesStoreWrites(t *testing.T) {
+	bus := NewMemoryBus(10)
+	defer bus.Close()
+	c := newCollect()
+	bus.Subscribe(TopicTasks, c.handle)
+
+	// Writes made on the store, past any service, are published
+	feed := changes.NewFeed(100)
+	store := changes.NewTrackingStore(storetest.NewFakeStore(), feed)
+	outbox := NewOutbox(feed, bus, nil, time.Second)
+	outbox.Start()
+	defer outbox.Close(context.Background())
+
+	task := &entities.Task{Name: "a"}
+	store.Create(task)
+	store.Update(task.ID, &entities.Task{Name: "b", Status: 1})
+	store.Update(999, &entities.Task{Name: "missing"})
+	store.Delete(task.ID)
+
+	got := decode(t, c.wait(t, 3))
+	if got[0].Type != TaskCreated || got[0].ID != task.ID || got[0].Task.Name != "a" || got[0].Revision != 1 {
+		t.Errorf("Unexpected create event %+v", got[0])
+	}
+	if got[1].Type != TaskUpdated || got[1].Task.Name != "b" || got[1].Task.Status != 1 {
+		t.Errorf("Unexpected update event %+v", got[1])
+	}
+	if got[2].Type != TaskDeleted || got[2].ID != task.ID || got[2].Task != nil || got[2].Revision != 3 {
+		t.Errorf("Unexpected delete event %+v", got[2])
+	}
+}
+
+// flakyPublisher fails until up is set, then forwards to pub
+type flakyPublisher struct {
+	pub Publisher
+
+	mu       sync.Mutex
+	up       bool
+	attempts int
+}
+
+func (p *flakyPublisher) Publish(ctx context.Context, topic string, data []byte) error {
+	p.mu.Lock()
+	p.attempts++
+	up := p.up
+	p.mu.Unlock()
+	if !up {
+		return errors.New("broker unreachable")
+	}
+	return p.pub.Publish(ctx, topic, data)
+}
+
+func (p *flakyPublisher) setUp(up bool) {
+	p.mu.Lock()
+	p.up = up
+	p.mu.Unlock()
+}
+
+func TestOutbox_RetriesUntilPublished(t *testing.T) {
+	bus := NewMemoryBus(10)
+	defer bus.Close()
+	c := newCollect()
+	bus.Subscribe(TopicTasks, c.handle)
+
+	feed := changes.NewFeed(100)
+	store := changes.NewTrackingStore(storetest.NewFakeStore(), feed)
+	pub := &flakyPublisher{pub: bus}
+	outbox := NewOutbox(feed, pub, nil, 200*time.Millisecond)
+	outbox.Start()
+	defer outbox.Close(context.Background())
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := store.Create(&entities.Task{Name: "t"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Expected writes not to wait for the broker, took %s", elapsed)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	stats := outbox.Stats()
+	if stats.Pending != 3 || stats.Failures < 2 || stats.LastError == "" || stats.Oldest == nil {
+		t.Errorf("Expected 3 events pending after repeated failures, got %+v", stats)
+	}
+
+	pub.setUp(true)
+	got := decode(t, c.wait(t, 3))
+	for i, ev := range got {
+		if ev.ID != i+1 {
+			t.Errorf("Expected events in write order, got %+v", got)
+			break
+		}
+	}
+	if stats := outbox.Stats(); stats.Pending != 0 || stats.Published != 3 || stats.LastError != "" {
+		t.Errorf("Expected every event published, got %+v", stats)
+	}
+}
+
+type memMeta struct {
+	mu      sync.Mutex
+	records map[string][]byte
+}
+
+func (m *memMeta) GetMeta(key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.records[key]
+	return value, ok, nil
+}
+
+func (m *memMeta) PutMeta(key string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records[key] = value
+	return nil
+}
+
+func TestOutbox_SavesPendingEvents(t *testing.T) {
+	meta := &memMeta{records: make(map[string][]byte)}
+
+	feed := changes.NewFeed(100)
+	store := changes.NewTrackingStore(storetest.NewFakeStore(), feed)
+	outbox := NewOutbox(feed, &flakyPublisher{}, meta, time.Second)
+	outbox.Start()
+	store.Create(&entities.Task{Name: "a"})
+	store.Create(&entities.Task{Name: "b"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := outbox.Close(ctx); err == nil {
+		t.Error("Expected Close to report the events it could not publish")
+	}
+
+	// The next process publishes them
+	bus := NewMemoryBus(10)
+	defer bus.Close()
+	c := newCollect()
+	bus.Subscribe(TopicTasks, c.handle)
+	restarted := NewOutbox(changes.NewFeed(100), bus, meta, time.Second)
+	if err := restarted.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer restarted.Close(context.Background())
+
+	got := decode(t, c.wait(t, 2))
+	if got[0].Task.Name != "a" || got[1].Task.Name != "b" {
+		t.Errorf("Expected the saved events in order, got %+v", got)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		if value, _, _ := meta.GetMeta(outboxKey); string(value) == "[]" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the saved events cleared once published")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,195 @@
+package redisbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"tasks-service-demo/internal/events"
+	"tasks-service-demo/internal/logger"
+)
+
+// Package redisbus is an events.Bus on Redis Streams. Each topic is a
+// stream read through a consumer group. Messages stay pending until a
+// handler acknowledges them; pending messages idle for longer than the
+// redelivery delay, including those of crashed instances, are claimed and
+// delivered again.
+
+const (
+	readCount = 16          // Messages fetched per XREADGROUP / XAUTOCLAIM call
+	readBlock = time.Second // XREADGROUP wait, so Unsubscribe is noticed promptly
+)
+
+// Bus appends to and reads from Redis streams
+type Bus struct {
+	cfg      events.Config
+	client   *redis.Client
+	consumer string // Unique consumer name of this instance within the group
+
+	mu   sync.Mutex
+	subs map[*subscription]struct{}
+}
+
+// Open connects to the Redis server at cfg.URL (redis://[user:pass@]host:port/db)
+func Open(cfg events.Config) (*Bus, error) {
+	opts, err := redis.ParseURL(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	host, _ := os.Hostname()
+	return &Bus{
+		cfg:      cfg,
+		client:   redis.NewClient(opts),
+		consumer: fmt.Sprintf("%s-%d-%s", host, os.Getpid(), events.NewMessageID()[:8]),
+		subs:     make(map[*subscription]struct{}),
+	}, nil
+}
+
+// Publish appends data to the topic's stream
+func (b *Bus) Publish(ctx context.Context, topic string, data []byte) error {
+	return b.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: topic,
+		Values: map[string]any{"id": events.NewMessageID(), "data": data},
+	}).Err()
+}
+
+// Subscribe reads topic through the cfg.Group consumer group, creating the
+// group at the start of the stream if needed
+func (b *Bus) Subscribe(topic string, h events.Handler) (events.Subscription, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	err := b.client.XGroupCreateMkStream(ctx, topic, b.cfg.Group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		cancel()
+		return nil, fmt.Errorf("creating consumer group for %s: %w", topic, err)
+	}
+
+	sub := &subscription{bus: b, topic: topic, cancel: cancel, done: make(chan struct{})}
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	go sub.run(ctx, h)
+	return sub, nil
+}
+
+// Close stops every subscription and closes the connection pool
+func (b *Bus) Close() error {
+	b.mu.Lock()
+	subs := make([]*subscription, 0, len(b.subs))
+	for sub := range b.subs {
+		subs = append(subs, sub)
+	}
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.Unsubscribe()
+	}
+	return b.client.Close()
+}
+
+type subscription struct {
+	bus    *Bus
+	topic  string
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+func (s *subscription) run(ctx context.Context, h events.Handler) {
+	defer close(s.done)
+	b := s.bus
+
+	claimStart := "0-0"
+	for ctx.Err() == nil {
+		// Failed or abandoned messages first, once they've been idle long enough
+		claimed, next, err := b.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   s.topic,
+			Group:    b.cfg.Group,
+			Consumer: b.consumer,
+			MinIdle:  b.cfg.RedeliveryDelay,
+			Start:    claimStart,
+			Count:    readCount,
+		}).Result()
+		if err == nil {
+			claimStart = next
+			s.handle(ctx, h, claimed)
+		} else if ctx.Err() == nil {
+			s.backoff(ctx, "claim", err)
+			continue
+		}
+
+		streams, err := b.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    b.cfg.Group,
+			Consumer: b.consumer,
+			Streams:  []string{s.topic, ">"},
+			Count:    readCount,
+			Block:    readBlock,
+		}).Result()
+		switch {
+		case err == nil:
+			for _, stream := range streams {
+				s.handle(ctx, h, stream.Messages)
+			}
+		case errors.Is(err, redis.Nil) || ctx.Err() != nil:
+			// Nothing new within readBlock, or unsubscribed
+		default:
+			s.backoff(ctx, "read", err)
+		}
+	}
+}
+
+// handle runs h on each message and acknowledges the ones it accepted
+func (s *subscription) handle(ctx context.Context, h events.Handler, msgs []redis.XMessage) {
+	for _, m := range msgs {
+		msg := events.Message{Topic: s.topic, Time: streamTime(m.ID)}
+		msg.ID, _ = m.Values["id"].(string)
+		data, _ := m.Values["data"].(string)
+		msg.Data = []byte(data)
+
+		if err := h(ctx, msg); err != nil {
+			// Left pending; XAUTOCLAIM picks it up after the redelivery delay
+			logger.Get().Warnw("Event handler failed, will be redelivered", "topic", s.topic, "id", msg.ID, "error", err)
+			continue
+		}
+		if err := s.bus.client.XAck(ctx, s.topic, s.bus.cfg.Group, m.ID).Err(); err != nil && ctx.Err() == nil {
+			logger.Get().Errorw("Redis XACK failed", "topic", s.topic, "id", msg.ID, "error", err)
+		}
+	}
+}
+
+func (s *subscription) backoff(ctx context.Context, op string, err error) {
+	logger.Get().Errorw("Redis stream "+op+" failed", "topic", s.topic, "error", err)
+	select {
+	case <-ctx.Done():
+	case <-time.After(s.bus.cfg.RedeliveryDelay):
+	}
+}
+
+// streamTime extracts the publish time from a stream entry ID (<ms>-<seq>)
+func streamTime(id string) time.Time {
+	ms, _, _ := strings.Cut(id, "-")
+	n, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(n)
+}
+
+func (s *subscription) Unsubscribe() error {
+	s.once.Do(func() {
+		s.bus.mu.Lock()
+		delete(s.bus.subs, s)
+		s.bus.mu.Unlock()
+
+		s.cancel()
+		<-s.done
+	})
+	return nil
+}
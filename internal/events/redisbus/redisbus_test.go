@@ -0,0 +1,23 @@
+package redisbus
+
+import (
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/events"
+)
+
+func TestStreamTime(t *testing.T) {
+	if got := streamTime("1760600000123-4"); !got.Equal(time.UnixMilli(1760600000123)) {
+		t.Errorf("Expected the millisecond part of the entry ID, got %v", got)
+	}
+	if got := streamTime("bogus"); !got.IsZero() {
+		t.Errorf("Expected zero time for an invalid ID, got %v", got)
+	}
+}
+
+func TestOpen_InvalidURL(t *testing.T) {
+	if _, err := Open(events.Config{URL: "http://localhost"}); err == nil {
+		t.Error("Expected a non-redis URL to be rejected")
+	}
+}
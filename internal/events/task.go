@@ -0,0 +1,46 @@
+package events
+
+import (
+	"time"
+
+	"tasks-service-demo/internal/changes"
+	"tasks-service-demo/internal/entities"
+)
+
+// TopicTasks carries a TaskEvent for every task created, updated or deleted
+// through the store, whichever path made the write
+const TopicTasks = "tasks"
+
+// PublishTimeout bounds one attempt of the outbox to publish an event
+const PublishTimeout = 2 * time.Second
+
+// Task event types
+const (
+	TaskCreated = "task.created"
+	TaskUpdated = "task.updated"
+	TaskDeleted = "task.deleted"
+)
+
+// TaskEvent is the payload published on TopicTasks
+type TaskEvent struct {
+	Type     string         `json:"type"`
+	ID       int            `json:"id"`
+	Task     *entities.Task `json:"task,omitempty"` // Nil for deletes
+	Time     time.Time      `json:"time"`           // When the write happened
+	Revision uint64         `json:"revision"`       // Of the change feed; consumers can drop duplicates by it
+}
+
+var eventTypes = map[changes.Op]string{
+	changes.OpCreated: TaskCreated,
+	changes.OpUpdated: TaskUpdated,
+	changes.OpDeleted: TaskDeleted,
+}
+
+// taskEvent returns the event of a recorded change
+func taskEvent(r changes.Record) TaskEvent {
+	ev := TaskEvent{Type: eventTypes[r.Op], ID: r.TaskID, Time: r.At, Revision: r.Revision}
+	if r.Op != changes.OpDeleted {
+		ev.Task = r.Task
+	}
+	return ev
+}
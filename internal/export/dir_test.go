@@ -0,0 +1,45 @@
+package export
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/jobs"
+	"tasks-service-demo/internal/storage/storetest"
+)
+
+func TestDirStore_Snapshots(t *testing.T) {
+	dir := t.TempDir()
+	objects, err := NewDirStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New(objects, Config{Retain: 2}, storetest.NewFakeStore(&entities.Task{ID: 1, Name: "a"}))
+
+	start := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		e.now = func() time.Time { return start.Add(time.Duration(i) * time.Hour) }
+		if err := e.Run(context.Background(), &jobs.Progress{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(files) != 2 || filepath.Base(files[0]) != "tasks-20261016T130000Z.jsonl.gz" {
+		t.Errorf("Expected the 2 newest snapshots, got %v", files)
+	}
+
+	if err := objects.Delete(context.Background(), "missing.jsonl.gz"); err != nil {
+		t.Errorf("Expected deleting a missing key to succeed, got %v", err)
+	}
+	if err := objects.Put(context.Background(), "../escape", nil, ""); err == nil {
+		t.Error("Expected keys outside the directory to be rejected")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "escape")); err == nil {
+		t.Error("Expected nothing written outside the directory")
+	}
+}
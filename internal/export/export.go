@@ -0,0 +1,154 @@
+package export
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"tasks-service-demo/internal/crypt"
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/jobs"
+	"tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/redact"
+	"tasks-service-demo/internal/storage"
+)
+
+// Package export writes snapshots of the task set to object storage as
+// gzipped JSON lines and prunes old snapshots. Fields named by the redact
+// rules are masked in every export. With a Sealer the gzip file
+// is encrypted whole and stored with a .enc suffix.
+
+const (
+	DefaultPrefix = "exports/" // Key prefix when none is configured
+	DefaultRetain = 7          // Exports kept when none is configured
+
+	keyPrefix   = "tasks-"
+	keySuffix   = ".jsonl.gz"
+	sealedExt   = ".enc"
+	keyTimeFmt  = "20060102T150405Z"
+	contentType = "application/gzip"
+)
+
+// Object describes a stored object
+type Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// ObjectStore is the subset of an S3-compatible API the exporter needs
+type ObjectStore interface {
+	Put(ctx context.Context, key string, body []byte, contentType string) error
+	List(ctx context.Context, prefix string) ([]Object, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Config controls where exports go and how many are kept
+type Config struct {
+	Prefix string        // Key prefix, e.g. "exports/"
+	Retain int           // Newest exports to keep; older ones are deleted
+	Sealer *crypt.Sealer // Encrypts exports; nil uploads plain gzip
+}
+
+// Exporter snapshots a store into an ObjectStore
+type Exporter struct {
+	objects ObjectStore
+	cfg     Config
+	st      storage.Store // Explicit store; nil falls back to the global singleton
+	now     func() time.Time
+}
+
+// New creates an Exporter; a nil store uses the global store
+func New(objects ObjectStore, cfg Config, store storage.Store) *Exporter {
+	if cfg.Retain <= 0 {
+		cfg.Retain = DefaultRetain
+	}
+	return &Exporter{objects: objects, cfg: cfg, st: store, now: time.Now}
+}
+
+func (e *Exporter) store() storage.Store {
+	if e.st != nil {
+		return e.st
+	}
+	return storage.GetStore()
+}
+
+// Run exports all tasks and prunes old exports. It has the jobs.Func
+// signature so it can be scheduled directly.
+func (e *Exporter) Run(ctx context.Context, p *jobs.Progress) error {
+	tasks := storage.Snapshot(e.store())
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	tasks = redact.Get().Tasks(tasks)
+	p.SetTotal(int64(len(tasks)))
+
+	var buf bytes.Buffer
+	if err := WriteJSONLines(&buf, tasks, p); err != nil {
+		return err
+	}
+
+	key := e.cfg.Prefix + keyPrefix + e.now().UTC().Format(keyTimeFmt) + keySuffix
+	body, ctype := buf.Bytes(), contentType
+	if e.cfg.Sealer.Enabled() {
+		key += sealedExt
+		body, ctype = e.cfg.Sealer.Seal(body, nil), "application/octet-stream"
+	}
+	p.SetMessage("uploading " + key)
+	if err := e.objects.Put(ctx, key, body, ctype); err != nil {
+		return fmt.Errorf("upload %s: %w", key, err)
+	}
+	logger.For(logger.ModuleJobs).Infow("Exported tasks", "key", key, "tasks", len(tasks), "bytes", len(body))
+
+	deleted, err := e.prune(ctx)
+	if err != nil {
+		return fmt.Errorf("prune old exports: %w", err)
+	}
+	p.SetMessage(fmt.Sprintf("exported %d tasks to %s, pruned %d", len(tasks), key, deleted))
+	return nil
+}
+
+// prune deletes all but the newest Retain exports. Keys embed the export
+// time, so lexical order is chronological.
+func (e *Exporter) prune(ctx context.Context) (int, error) {
+	objects, err := e.objects.List(ctx, e.cfg.Prefix+keyPrefix)
+	if err != nil {
+		return 0, err
+	}
+
+	var keys []string
+	for _, obj := range objects {
+		if strings.HasSuffix(obj.Key, keySuffix) || strings.HasSuffix(obj.Key, keySuffix+sealedExt) {
+			keys = append(keys, obj.Key)
+		}
+	}
+	if len(keys) <= e.cfg.Retain {
+		return 0, nil
+	}
+
+	sort.Strings(keys)
+	stale := keys[:len(keys)-e.cfg.Retain]
+	for _, key := range stale {
+		if err := e.objects.Delete(ctx, key); err != nil {
+			return 0, fmt.Errorf("delete %s: %w", key, err)
+		}
+	}
+	return len(stale), nil
+}
+
+// WriteJSONLines writes tasks gzipped, one JSON object per line
+func WriteJSONLines(w io.Writer, tasks []*entities.Task, p *jobs.Progress) error {
+	gz := gzip.NewWriter(w)
+	enc := json.NewEncoder(gz)
+	for _, task := range tasks {
+		if err := enc.Encode(task); err != nil {
+			return err
+		}
+		p.Add(1)
+	}
+	return gz.Close()
+}
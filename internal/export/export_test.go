@@ -0,0 +1,145 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"sort"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/crypt"
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/jobs"
+	"tasks-service-demo/internal/redact"
+	"tasks-service-demo/internal/storage/storetest"
+)
+
+type memObjects struct {
+	objects map[string][]byte
+}
+
+func (m *memObjects) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	m.objects[key] = body
+	return nil
+}
+
+func (m *memObjects) List(ctx context.Context, prefix string) ([]Object, error) {
+	var list []Object
+	for key, body := range m.objects {
+		if len(key) >= len(prefix) && key[:len(prefix)] == prefix {
+			list = append(list, Object{Key: key, Size: int64(len(body))})
+		}
+	}
+	return list, nil
+}
+
+func (m *memObjects) Delete(ctx context.Context, key string) error {
+	delete(m.objects, key)
+	return nil
+}
+
+func (m *memObjects) keys() []string {
+	var keys []string
+	for key := range m.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestExporter_Run(t *testing.T) {
+	store := storetest.NewFakeStore(
+		&entities.Task{ID: 2, Name: "b", Status: 1},
+		&entities.Task{ID: 1, Name: "a"},
+	)
+	objects := &memObjects{objects: map[string][]byte{"exports/unrelated.txt": nil}}
+	e := New(objects, Config{Prefix: "exports/", Retain: 2}, store)
+
+	start := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		e.now = func() time.Time { return start.Add(time.Duration(i) * time.Hour) }
+		var p jobs.Progress
+		if err := e.Run(context.Background(), &p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := []string{
+		"exports/tasks-20261016T130000Z.jsonl.gz",
+		"exports/tasks-20261016T140000Z.jsonl.gz",
+		"exports/unrelated.txt",
+	}
+	if got := objects.keys(); len(got) != 3 || got[0] != expected[0] || got[1] != expected[1] || got[2] != expected[2] {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(objects.objects[expected[1]]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		var task entities.Task
+		if err := json.Unmarshal(scanner.Bytes(), &task); err != nil {
+			t.Fatalf("Expected JSON line, got %q: %v", scanner.Text(), err)
+		}
+		names = append(names, task.Name)
+	}
+	if len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Errorf("Expected tasks ordered by ID, got %v", names)
+	}
+}
+
+func TestExporter_RunEncrypted(t *testing.T) {
+	sealer, _ := crypt.New([]crypt.Key{{ID: "k1", Secret: bytes.Repeat([]byte{1}, 32)}})
+	objects := &memObjects{objects: map[string][]byte{}}
+	e := New(objects, Config{Retain: 1, Sealer: sealer}, storetest.NewFakeStore(&entities.Task{ID: 1, Name: "a"}))
+
+	start := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		e.now = func() time.Time { return start.Add(time.Duration(i) * time.Hour) }
+		if err := e.Run(context.Background(), &jobs.Progress{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	key := "tasks-20261016T130000Z.jsonl.gz.enc"
+	if got := objects.keys(); len(got) != 1 || got[0] != key {
+		t.Fatalf("Expected only %s after pruning, got %v", key, got)
+	}
+	body, err := sealer.Open(objects.objects[key], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gzip.NewReader(bytes.NewReader(body)); err != nil {
+		t.Errorf("Expected a gzip file once decrypted, got %v", err)
+	}
+}
+
+func TestExporter_RunRedactsTaskFields(t *testing.T) {
+	redact.Set(redact.Rules{Fields: []string{"name"}})
+	defer redact.Reset()
+
+	task := &entities.Task{ID: 1, Name: "Call Dr. Smith"}
+	objects := &memObjects{objects: map[string][]byte{}}
+	if err := New(objects, Config{}, storetest.NewFakeStore(task)).Run(context.Background(), &jobs.Progress{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, body := range objects.objects {
+		gz, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var exported entities.Task
+		if err := json.NewDecoder(gz).Decode(&exported); err != nil || exported.Name != redact.Mask || exported.ID != 1 {
+			t.Errorf("Expected the exported name masked, got %+v, %v", exported, err)
+		}
+	}
+	if task.Name != "Call Dr. Smith" {
+		t.Errorf("Expected the stored task intact, got %+v", task)
+	}
+}
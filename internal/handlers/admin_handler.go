@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/jobs"
+	"tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/maintenance"
+	"tasks-service-demo/internal/middleware"
+	"tasks-service-demo/internal/payloadlog"
+	"tasks-service-demo/internal/requests"
+	"tasks-service-demo/internal/schedules"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Package handlers provides HTTP handlers for the Task API.
+
+// GetMaintenance handles GET /admin/maintenance and returns the active maintenance state.
+func GetMaintenance(c *fiber.Ctx) error {
+	return c.JSON(maintenance.Get())
+}
+
+// SetMaintenance handles POST /admin/maintenance and switches the maintenance mode.
+func SetMaintenance(c *fiber.Ctx) error {
+	req := middleware.GetValidatedRequest[requests.MaintenanceRequest](c)
+
+	maintenance.Set(maintenance.State{
+		Mode:              maintenance.Mode(req.Mode),
+		RetryAfterSeconds: req.RetryAfterSeconds,
+		Reason:            req.Reason,
+	})
+
+	state := maintenance.Get()
+	logger.Get().Infow("Maintenance mode changed", "mode", state.Mode, "reason", state.Reason)
+
+	return c.JSON(state)
+}
+
+// GetPayloadLogging handles GET /admin/payload-logging and returns the payload logging settings.
+func GetPayloadLogging(c *fiber.Ctx) error {
+	return c.JSON(payloadlog.Get())
+}
+
+// SetPayloadLogging handles POST /admin/payload-logging and toggles payload logging.
+// Redaction fields are configuration-only so they can't be loosened at runtime.
+func SetPayloadLogging(c *fiber.Ctx) error {
+	req := middleware.GetValidatedRequest[requests.PayloadLoggingRequest](c)
+
+	cfg := payloadlog.Get()
+	cfg.Enabled = req.Enabled
+	if req.SampleRate != nil {
+		cfg.SampleRate = *req.SampleRate
+	}
+	if req.MaxBytes != nil {
+		cfg.MaxBytes = *req.MaxBytes
+	}
+	payloadlog.Set(cfg)
+
+	logger.Get().Infow("Payload logging changed", "enabled", cfg.Enabled, "sampleRate", cfg.SampleRate, "maxBytes", cfg.MaxBytes)
+
+	return c.JSON(cfg)
+}
+
+// LogLevelResponse reports the root log level and each module's level,
+// or "inherit" for modules following the root level
+type LogLevelResponse struct {
+	Level   string            `json:"level"`
+	Modules map[string]string `json:"modules"`
+}
+
+func logLevels() LogLevelResponse {
+	resp := LogLevelResponse{Level: logger.Level().String(), Modules: make(map[string]string, len(logger.Modules))}
+	for _, module := range logger.Modules {
+		resp.Modules[module] = "inherit"
+		if l, overridden := logger.ModuleLevel(module); overridden {
+			resp.Modules[module] = l.String()
+		}
+	}
+	return resp
+}
+
+// GetLogLevel handles GET /admin/log-level and returns the active log levels.
+func GetLogLevel(c *fiber.Ctx) error {
+	return c.JSON(logLevels())
+}
+
+// SetLogLevel handles PUT /admin/log-level and changes log levels without a restart.
+func SetLogLevel(c *fiber.Ctx) error {
+	req := middleware.GetValidatedRequest[requests.LogLevelRequest](c)
+
+	// Values are validated, so parsing can't fail
+	if req.Level != "" {
+		l, _ := logger.ParseLevel(req.Level)
+		logger.SetLevel(l)
+	}
+	for module, value := range req.Modules {
+		if value == "inherit" {
+			logger.SetModuleLevel(module, nil)
+			continue
+		}
+		l, _ := logger.ParseLevel(value)
+		logger.SetModuleLevel(module, &l)
+	}
+
+	resp := logLevels()
+	logger.Get().Infow("Log level changed", "level", resp.Level, "modules", resp.Modules)
+
+	return c.JSON(resp)
+}
+
+// ListJobs handles GET /admin/jobs and returns the status of all background
+// jobs, the schedules created through the API and the kinds they can run.
+func ListJobs(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"jobs":      jobs.Get().List(),
+		"schedules": schedules.Get().List(),
+		"kinds":     schedules.Get().Kinds(),
+	})
+}
+
+// GetJob handles GET /admin/jobs/:id and returns the status of one background job.
+func GetJob(c *fiber.Ctx) error {
+	status, ok := jobs.Get().Status(c.Params("id"))
+	if !ok {
+		return apperrors.ErrJobNotFound
+	}
+	return c.JSON(status)
+}
+
+// ScheduledJob is the response of POST /admin/jobs
+type ScheduledJob struct {
+	schedules.Definition
+	NextRunAt time.Time `json:"nextRunAt"`
+}
+
+// ScheduleJob handles POST /admin/jobs and schedules a recurring job of one of
+// the available kinds on a cron expression.
+func ScheduleJob(c *fiber.Ctx) error {
+	req := middleware.GetValidatedRequest[requests.ScheduleJobRequest](c)
+
+	def, err := schedules.Get().Create(schedules.Definition{
+		Name:   req.Name,
+		Kind:   req.Kind,
+		Cron:   req.Cron,
+		Params: req.Params,
+	})
+	switch {
+	case errors.Is(err, schedules.ErrInvalid):
+		return apperrors.ErrScheduleInvalid.WithMessage(err.Error())
+	case errors.Is(err, jobs.ErrExists):
+		return apperrors.ErrJobExists
+	case err != nil:
+		logger.Get().Errorw("Failed to save job schedule", "name", req.Name, "error", err)
+		return apperrors.ErrStorageError.WithCause(err)
+	}
+
+	resp := ScheduledJob{Definition: def}
+	if sched, err := jobs.ParseCron(def.Cron); err == nil {
+		resp.NextRunAt = sched.Next(time.Now()).UTC()
+	}
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+// DeleteJob handles DELETE /admin/jobs/:id and removes a schedule created
+// through POST /admin/jobs. Built-in jobs can't be removed.
+func DeleteJob(c *fiber.Ctx) error {
+	err := schedules.Get().Delete(c.Params("id"))
+	switch {
+	case errors.Is(err, schedules.ErrNotFound):
+		return apperrors.ErrJobNotFound
+	case err != nil:
+		logger.Get().Errorw("Failed to delete job schedule", "name", c.Params("id"), "error", err)
+		return apperrors.ErrStorageError.WithCause(err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetJobRuns handles GET /admin/jobs/:id/runs and returns the recent runs of
+// a job, newest first.
+func GetJobRuns(c *fiber.Ctx) error {
+	id := c.Params("id")
+	runs, ok := jobs.Get().History(id)
+	if !ok {
+		return apperrors.ErrJobNotFound
+	}
+	return c.JSON(fiber.Map{"id": id, "runs": runs})
+}
@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/health"
+	"tasks-service-demo/internal/jobs"
+	"tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/maintenance"
+	"tasks-service-demo/internal/middleware"
+	"tasks-service-demo/internal/payloadlog"
+	"tasks-service-demo/internal/requests"
+	"tasks-service-demo/internal/schedules"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap/zapcore"
+)
+
+func setupAdminApp() *fiber.App {
+	app := newTestApp()
+	app.Get("/admin/maintenance", GetMaintenance)
+	app.Post("/admin/maintenance", middleware.ValidateRequest[requests.MaintenanceRequest](), SetMaintenance)
+	app.Get("/admin/jobs", ListJobs)
+	app.Post("/admin/jobs", middleware.ValidateRequest[requests.ScheduleJobRequest](), ScheduleJob)
+	app.Get("/admin/jobs/:id", GetJob)
+	app.Delete("/admin/jobs/:id", DeleteJob)
+	app.Get("/admin/jobs/:id/runs", GetJobRuns)
+	app.Get("/readyz", ReadinessCheck)
+	return app
+}
+
+func postMaintenance(t *testing.T, app *fiber.App, body string) *maintenance.State {
+	t.Helper()
+
+	req := httptest.NewRequest("POST", "/admin/maintenance", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		return nil
+	}
+
+	data, _ := io.ReadAll(resp.Body)
+	var state maintenance.State
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatal(err)
+	}
+	return &state
+}
+
+func TestSetMaintenance(t *testing.T) {
+	defer maintenance.Reset()
+	app := setupAdminApp()
+
+	state := postMaintenance(t, app, `{"mode":"read_only","retryAfterSeconds":30,"reason":"migration"}`)
+	if state == nil || state.Mode != maintenance.ModeReadOnly || state.Reason != "migration" {
+		t.Fatalf("Expected read-only state, got %+v", state)
+	}
+
+	resp, _ := app.Test(httptest.NewRequest("GET", "/admin/maintenance", nil))
+	data, _ := io.ReadAll(resp.Body)
+	var current maintenance.State
+	json.Unmarshal(data, &current)
+	if current.Mode != maintenance.ModeReadOnly {
+		t.Errorf("Expected GET to reflect read-only mode, got %+v", current)
+	}
+
+	if state := postMaintenance(t, app, `{"mode":"off"}`); state == nil || state.Mode != maintenance.ModeOff {
+		t.Errorf("Expected maintenance off, got %+v", state)
+	}
+}
+
+func TestSetMaintenance_InvalidMode(t *testing.T) {
+	defer maintenance.Reset()
+	app := setupAdminApp()
+
+	for _, body := range []string{`{"mode":"sleep"}`, `{}`, `{"mode":"full","retryAfterSeconds":-1}`} {
+		if state := postMaintenance(t, app, body); state != nil {
+			t.Errorf("Expected %s to be rejected, got %+v", body, state)
+		}
+	}
+}
+
+func TestReadinessCheck_FullMaintenance(t *testing.T) {
+	health.Reset()
+	defer health.Reset()
+	defer maintenance.Reset()
+	health.Get().SetReady(true)
+	app := setupAdminApp()
+
+	postMaintenance(t, app, `{"mode":"full"}`)
+	resp, err := app.Test(httptest.NewRequest("GET", "/readyz", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusServiceUnavailable {
+		t.Errorf("Expected status %d in full maintenance, got %d", fiber.StatusServiceUnavailable, resp.StatusCode)
+	}
+
+	postMaintenance(t, app, `{"mode":"read_only"}`)
+	resp, _ = app.Test(httptest.NewRequest("GET", "/readyz", nil))
+	if resp.StatusCode != fiber.StatusOK {
+		t.Errorf("Expected status %d in read-only maintenance, got %d", fiber.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestListJobs(t *testing.T) {
+	jobs.Reset()
+	defer jobs.Reset()
+	jobs.Get().Schedule("s3-export", time.Hour, func(ctx context.Context, p *jobs.Progress) error { return nil })
+
+	resp, err := setupAdminApp().Test(httptest.NewRequest("GET", "/admin/jobs", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body struct {
+		Jobs []jobs.Status `json:"jobs"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	if len(body.Jobs) != 1 || body.Jobs[0].ID != "s3-export" || body.Jobs[0].State != jobs.StateIdle {
+		t.Errorf("Expected idle s3-export job, got %+v", body.Jobs)
+	}
+}
+
+func TestScheduleJob(t *testing.T) {
+	jobs.Reset()
+	defer jobs.Reset()
+	defer schedules.Reset()
+	schedules.Get().RegisterKind(schedules.Kind{
+		Name: "snapshot",
+		New:  schedules.NoParams(func(ctx context.Context, p *jobs.Progress) error { return nil }),
+	})
+	app := setupAdminApp()
+
+	status, result := sendJSON(t, app, "POST", "/admin/jobs", `{"name":"nightly","kind":"snapshot","cron":"0 3 * * *"}`)
+	if status != fiber.StatusCreated || result["kind"] != "snapshot" || result["nextRunAt"] == nil {
+		t.Errorf("Expected created schedule, got %d %v", status, result)
+	}
+	if s, ok := jobs.Get().Status("nightly"); !ok || s.Cron != "0 3 * * *" {
+		t.Errorf("Expected registered cron job, got %+v", s)
+	}
+
+	status, result = sendJSON(t, app, "POST", "/admin/jobs", `{"name":"nightly","kind":"snapshot","cron":"@daily"}`)
+	if status != fiber.StatusConflict || result["code"] != float64(apperrors.ErrCodeJobExists) {
+		t.Errorf("Expected conflict, got %d %v", status, result)
+	}
+	status, result = sendJSON(t, app, "POST", "/admin/jobs", `{"name":"other","kind":"snapshot","cron":"daily"}`)
+	if status != fiber.StatusBadRequest || result["code"] != float64(apperrors.ErrCodeScheduleInvalid) {
+		t.Errorf("Expected invalid schedule, got %d %v", status, result)
+	}
+
+	status, result = sendJSON(t, app, "GET", "/admin/jobs/nightly/runs", "")
+	if runs, ok := result["runs"].([]interface{}); status != fiber.StatusOK || !ok || len(runs) != 0 {
+		t.Errorf("Expected empty run history, got %d %v", status, result)
+	}
+
+	if status, _ := sendJSON(t, app, "DELETE", "/admin/jobs/nightly", ""); status != fiber.StatusNoContent {
+		t.Errorf("Expected 204, got %d", status)
+	}
+	if status, _ := sendJSON(t, app, "DELETE", "/admin/jobs/nightly", ""); status != fiber.StatusNotFound {
+		t.Errorf("Expected 404 for a deleted schedule, got %d", status)
+	}
+	if status, _ := sendJSON(t, app, "GET", "/admin/jobs/nightly/runs", ""); status != fiber.StatusNotFound {
+		t.Errorf("Expected 404 for runs of a deleted job, got %d", status)
+	}
+}
+
+func TestGetJobRuns(t *testing.T) {
+	jobs.Reset()
+	defer jobs.Reset()
+	id := jobs.Get().Submit("import", func(ctx context.Context, p *jobs.Progress) error {
+		p.SetMessage("imported 2")
+		return nil
+	})
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if runs, _ := jobs.Get().History(id); len(runs) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected job to finish")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	var body struct {
+		Runs []jobs.Run `json:"runs"`
+	}
+	resp, err := setupAdminApp().Test(httptest.NewRequest("GET", "/admin/jobs/"+id+"/runs", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	if len(body.Runs) != 1 || body.Runs[0].State != jobs.StateSucceeded || body.Runs[0].Message != "imported 2" {
+		t.Errorf("Unexpected runs %+v", body.Runs)
+	}
+}
+
+func TestSetPayloadLogging(t *testing.T) {
+	defer payloadlog.Reset()
+	app := newTestApp()
+	app.Get("/admin/payload-logging", GetPayloadLogging)
+	app.Post("/admin/payload-logging", middleware.ValidateRequest[requests.PayloadLoggingRequest](), SetPayloadLogging)
+
+	post := func(body string) int {
+		req := httptest.NewRequest("POST", "/admin/payload-logging", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+
+	if status := post(`{"enabled":true,"sampleRate":0.5}`); status != fiber.StatusOK {
+		t.Fatalf("Expected status %d, got %d", fiber.StatusOK, status)
+	}
+	cfg := payloadlog.Get()
+	if !cfg.Enabled || cfg.SampleRate != 0.5 || cfg.MaxBytes != payloadlog.DefaultMaxBytes {
+		t.Errorf("Expected enabled at 0.5 with default max bytes, got %+v", cfg)
+	}
+
+	for _, body := range []string{`{"enabled":true,"sampleRate":1.5}`, `{"enabled":true,"maxBytes":-1}`} {
+		if status := post(body); status != fiber.StatusBadRequest {
+			t.Errorf("Expected %s to be rejected, got %d", body, status)
+		}
+	}
+
+	resp, _ := app.Test(httptest.NewRequest("GET", "/admin/payload-logging", nil))
+	var current payloadlog.Config
+	json.NewDecoder(resp.Body).Decode(&current)
+	if !current.Enabled || len(current.Redact) == 0 {
+		t.Errorf("Expected GET to reflect enabled config with redaction, got %+v", current)
+	}
+}
+
+func TestSetLogLevel(t *testing.T) {
+	defer logger.ResetLevels()
+	app := newTestApp()
+	app.Get("/admin/log-level", GetLogLevel)
+	app.Put("/admin/log-level", middleware.ValidateRequest[requests.LogLevelRequest](), SetLogLevel)
+
+	put := func(body string) (int, LogLevelResponse) {
+		req := httptest.NewRequest("PUT", "/admin/log-level", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out LogLevelResponse
+		json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+
+	status, out := put(`{"level":"warn","modules":{"storage":"debug"}}`)
+	if status != fiber.StatusOK {
+		t.Fatalf("Expected status %d, got %d", fiber.StatusOK, status)
+	}
+	if out.Level != "warn" || out.Modules["storage"] != "debug" || out.Modules["http"] != "inherit" {
+		t.Errorf("Unexpected levels %+v", out)
+	}
+	if logger.Level() != zapcore.WarnLevel {
+		t.Errorf("Expected root level warn, got %v", logger.Level())
+	}
+
+	if _, out := put(`{"modules":{"storage":"inherit"}}`); out.Level != "warn" || out.Modules["storage"] != "inherit" {
+		t.Errorf("Expected storage to inherit warn, got %+v", out)
+	}
+
+	for _, body := range []string{`{"level":"trace"}`, `{"modules":{"db":"info"}}`, `{"modules":{"http":"loud"}}`} {
+		if status, _ := put(body); status != fiber.StatusBadRequest {
+			t.Errorf("Expected %s to be rejected, got %d", body, status)
+		}
+	}
+
+	resp, _ := app.Test(httptest.NewRequest("GET", "/admin/log-level", nil))
+	var current LogLevelResponse
+	json.NewDecoder(resp.Body).Decode(&current)
+	if current.Level != "warn" || len(current.Modules) != len(logger.Modules) {
+		t.Errorf("Expected GET to reflect levels, got %+v", current)
+	}
+}
@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"tasks-service-demo/internal/admission"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetAdmission handles GET /admin/admission and returns the load seen by
+// the admission controller and the requests it admitted and shed by priority
+func GetAdmission(c *fiber.Ctx) error {
+	return c.JSON(admission.Get().Stats())
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+
+	"tasks-service-demo/internal/archive"
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/middleware"
+	"tasks-service-demo/internal/requests"
+	"tasks-service-demo/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ArchiveHandler handles HTTP requests for archived tasks.
+type ArchiveHandler struct {
+	service *services.TaskService
+}
+
+// NewArchiveHandler creates a new ArchiveHandler with the given TaskService.
+func NewArchiveHandler(service *services.TaskService) *ArchiveHandler {
+	return &ArchiveHandler{service: service}
+}
+
+// ListArchivedTasks handles GET /tasks/archive and returns the archived
+// tasks, most recently archived first.
+func (h *ArchiveHandler) ListArchivedTasks(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"tasks": archive.Get().List()})
+}
+
+// UnarchiveTask handles POST /tasks/:id/unarchive. The task is created
+// again through the task service, so it gets a new ID; archivedId is the
+// one it had before.
+func (h *ArchiveHandler) UnarchiveTask(c *fiber.Ctx) error {
+	id := middleware.GetValidatedID(c)
+	ctx := c.UserContext()
+
+	task, err := archive.Get().Unarchive(id, func(archived entities.Task) (*entities.Task, error) {
+		req := &requests.CreateTaskRequest{Name: archived.Name, Status: archived.Status}
+		created, appErr := h.service.CreateTaskContext(ctx, req)
+		if appErr != nil {
+			return nil, appErr
+		}
+		return created, nil
+	})
+	if errors.Is(err, archive.ErrNotFound) {
+		return apperrors.ErrNotArchived
+	}
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	if err != nil {
+		return apperrors.ErrStorageError.WithCause(err)
+	}
+
+	logger.Get().Infow("Task unarchived", "archivedId", id, "id", task.ID)
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"task": task, "archivedId": id})
+}
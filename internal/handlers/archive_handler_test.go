@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/archive"
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/middleware"
+	"tasks-service-demo/internal/services"
+	"tasks-service-demo/internal/storage/storetest"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestArchive_ListAndUnarchive(t *testing.T) {
+	a := archive.New(archive.Config{After: time.Nanosecond})
+	archive.Init(a)
+	defer archive.Reset()
+	store := archive.NewStore(storetest.NewFakeStore(&entities.Task{ID: 1, Name: "done", Status: 1}), a)
+
+	// The first run starts the clock, the second archives
+	a.Run(context.Background(), store)
+	time.Sleep(time.Millisecond)
+	if n, err := a.Run(context.Background(), store); n != 1 || err != nil {
+		t.Fatalf("Expected 1 task archived, got %d, %v", n, err)
+	}
+
+	handler := NewArchiveHandler(services.NewTaskServiceWithStore(store))
+	app := newTestApp()
+	app.Get("/tasks/archive", handler.ListArchivedTasks)
+	app.Post("/tasks/:id/unarchive", middleware.ValidatePathID(), handler.UnarchiveTask)
+
+	status, result := sendJSON(t, app, "GET", "/tasks/archive", "")
+	if tasks, ok := result["tasks"].([]interface{}); status != fiber.StatusOK || !ok || len(tasks) != 1 {
+		t.Errorf("Expected 1 archived task, got %d %v", status, result)
+	}
+
+	status, result = sendJSON(t, app, "POST", "/tasks/1/unarchive", "")
+	task, _ := result["task"].(map[string]interface{})
+	if status != fiber.StatusCreated || result["archivedId"] != float64(1) || task["name"] != "done" {
+		t.Errorf("Expected the task to be restored, got %d %v", status, result)
+	}
+	if len(store.GetAll()) != 1 {
+		t.Errorf("Expected the task back in the store, got %+v", store.GetAll())
+	}
+
+	status, result = sendJSON(t, app, "POST", "/tasks/1/unarchive", "")
+	if status != fiber.StatusNotFound || result["code"] != float64(apperrors.ErrCodeNotArchived) {
+		t.Errorf("Expected not archived, got %d %v", status, result)
+	}
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"tasks-service-demo/internal/changes"
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/streaming"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	CDCSchema        = "tasks-service-demo.task" // Schema of every CDC record
+	CDCSchemaVersion = 1                         // Bumped when the record or task layout changes incompatibly
+	MaxCDCFollow     = 60 * time.Second          // Upper bound for ?follow
+	cdcBatch         = 500                       // Records read from the feed per chunk
+)
+
+// CDCContentType is the media type of the stream: one JSON record per line
+const CDCContentType = NDJSONContentType
+
+// CDCHandler streams the change feed for downstream consumers.
+type CDCHandler struct {
+	feed *changes.Feed
+}
+
+// NewCDCHandler creates a new CDCHandler reading from feed.
+func NewCDCHandler(feed *changes.Feed) *CDCHandler {
+	return &CDCHandler{feed: feed}
+}
+
+// CDCRecord is one line of the stream. Offset is the revision of the
+// change; consumers resume with ?from set to the last offset they applied.
+// Task is the task as the write left it, null for deletes.
+type CDCRecord struct {
+	Schema  string         `json:"schema"`
+	Version int            `json:"version"`
+	Offset  uint64         `json:"offset"`
+	Op      changes.Op     `json:"op"`
+	Key     int            `json:"key"` // Task ID
+	At      time.Time      `json:"at"`
+	Task    *entities.Task `json:"task"`
+	Dropped uint64         `json:"dropped,omitempty"` // Changes skipped, on OpGap records
+}
+
+// OpGap marks the changes a slow consumer skipped under the drop-oldest
+// policy (see streaming.DropOldest). Its offset is the last change skipped;
+// the consumer must resync the tasks those changes touched from a full export.
+const OpGap changes.Op = "gap"
+
+// newCDCRecord converts a change of the feed
+func newCDCRecord(r changes.Record) CDCRecord {
+	rec := CDCRecord{
+		Schema:  CDCSchema,
+		Version: CDCSchemaVersion,
+		Offset:  r.Revision,
+		Op:      r.Op,
+		Key:     r.TaskID,
+		At:      r.At.UTC(),
+	}
+	if r.Op != changes.OpDeleted {
+		rec.Task = r.Task
+	}
+	return rec
+}
+
+// Stream handles GET /cdc?from=<offset>&follow=<seconds>. It streams the
+// changes after from as chunked NDJSON, oldest first. With follow it keeps
+// the stream open that long, sending changes as they are written. An offset
+// older than the change history is answered with 410 Gone: the consumer
+// must rebuild from a full export and resume from the X-Revision header.
+func (h *CDCHandler) Stream(c *fiber.Ctx) error {
+	from, err := strconv.ParseUint(c.Query("from", "0"), 10, 64)
+	if err != nil {
+		return apperrors.ErrInvalidQuery.WithMessage("from must be a non-negative integer offset")
+	}
+
+	var follow time.Duration
+	if followStr := c.Query("follow"); followStr != "" {
+		seconds, err := strconv.Atoi(followStr)
+		if err != nil || seconds < 0 {
+			return apperrors.ErrInvalidQuery.WithMessage("follow must be a non-negative number of seconds")
+		}
+		follow = min(time.Duration(seconds)*time.Second, MaxCDCFollow)
+	}
+
+	_, current, complete := h.feed.Records(from, 1)
+	c.Set(RevisionHeader, strconv.FormatUint(current, 10))
+	if from > current {
+		return apperrors.ErrInvalidQuery.WithMessage("from is beyond the current offset " + strconv.FormatUint(current, 10))
+	}
+	guard := streaming.Get()
+	if guard.Lagging(current-from, complete) && guard.Config().Policy != streaming.DropOldest {
+		return apperrors.ErrOffsetExpired
+	}
+
+	// The request context is cancelled on server shutdown, ending the stream
+	ctx := c.Context()
+	conn := c.Context().Conn()
+	deadline := time.Now().Add(follow)
+	c.Set(fiber.HeaderContentType, CDCContentType)
+	c.Context().SetBodyStreamWriter(func(bw *bufio.Writer) {
+		w := guard.Writer(StreamCDC, bw, conn)
+		defer w.Close()
+		h.stream(ctx, guard, w, from, deadline)
+	})
+	return nil
+}
+
+// stream writes the records after from until the history is drained and
+// deadline has passed. It stops early when the client goes away or a write
+// times out. A consumer that falls too far behind (see streaming.Guard) is
+// disconnected, and resuming gets 410; under the drop-oldest policy it gets
+// an OpGap record instead and carries on from the changes kept.
+func (h *CDCHandler) stream(ctx context.Context, guard *streaming.Guard, w *streaming.Writer, from uint64, deadline time.Time) {
+	enc := json.NewEncoder(w)
+	for {
+		records, current, complete := h.feed.Records(from, cdcBatch)
+		if guard.Lagging(current-from, complete) {
+			if guard.Config().Policy != streaming.DropOldest {
+				guard.Disconnected(StreamCDC, streaming.ReasonLag)
+				return
+			}
+			skipTo := from
+			if !complete && len(records) > 0 {
+				skipTo = records[0].Revision - 1 // Oldest change the feed holds
+			}
+			if maxLag := uint64(guard.Config().MaxLag); maxLag > 0 && current-skipTo > maxLag {
+				skipTo = current - maxLag
+			}
+			gap := CDCRecord{Schema: CDCSchema, Version: CDCSchemaVersion, Offset: skipTo, Op: OpGap, At: time.Now().UTC(), Dropped: skipTo - from}
+			if err := enc.Encode(gap); err != nil {
+				return
+			}
+			guard.Dropped(StreamCDC, skipTo-from)
+			from = skipTo
+			continue
+		}
+		for _, r := range records {
+			if err := enc.Encode(newCDCRecord(r)); err != nil {
+				return
+			}
+			from = r.Revision
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+		if from < current {
+			continue
+		}
+
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return
+		}
+		waitCtx, cancel := context.WithTimeout(ctx, wait)
+		_, err := h.feed.Wait(waitCtx, from)
+		cancel()
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/changes"
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage/storetest"
+	"tasks-service-demo/internal/streaming"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func setupCDCApp(history int) (*fiber.App, *changes.TrackingStore) {
+	feed := changes.NewFeed(history)
+	store := changes.NewTrackingStore(storetest.NewFakeStore(), feed)
+
+	app := newTestApp()
+	app.Get("/cdc", NewCDCHandler(feed).Stream)
+	return app, store
+}
+
+// readCDC decodes every record of a stream
+func readCDC(t *testing.T, resp *http.Response) []CDCRecord {
+	t.Helper()
+	var records []CDCRecord
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var rec CDCRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("Invalid record %q: %v", scanner.Text(), err)
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestCDC_Stream(t *testing.T) {
+	app, store := setupCDCApp(100)
+
+	a := &entities.Task{Name: "a"}
+	store.Create(a)
+	store.Update(a.ID, &entities.Task{Name: "a2", Status: 1})
+	store.Delete(a.ID)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/cdc", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK || resp.Header.Get(fiber.HeaderContentType) != CDCContentType {
+		t.Fatalf("Expected an NDJSON stream, got %d %q", resp.StatusCode, resp.Header.Get(fiber.HeaderContentType))
+	}
+	records := readCDC(t, resp)
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 records, got %+v", records)
+	}
+	for i, op := range []changes.Op{changes.OpCreated, changes.OpUpdated, changes.OpDeleted} {
+		rec := records[i]
+		if rec.Offset != uint64(i+1) || rec.Op != op || rec.Key != a.ID || rec.Schema != CDCSchema || rec.Version != CDCSchemaVersion || rec.At.IsZero() {
+			t.Errorf("Unexpected record %d: %+v", i, rec)
+		}
+	}
+	// Each record carries the task as that write left it
+	if records[0].Task == nil || records[0].Task.Name != "a" {
+		t.Errorf("Expected the created task, got %+v", records[0].Task)
+	}
+	if records[1].Task == nil || records[1].Task.Name != "a2" || records[1].Task.ID != a.ID {
+		t.Errorf("Expected the updated task, got %+v", records[1].Task)
+	}
+	if records[2].Task != nil {
+		t.Errorf("Expected no task on a delete, got %+v", records[2].Task)
+	}
+
+	resp, _ = app.Test(httptest.NewRequest("GET", "/cdc?from=2", nil))
+	if records := readCDC(t, resp); len(records) != 1 || records[0].Offset != 3 {
+		t.Errorf("Expected the record after offset 2, got %+v", records)
+	}
+}
+
+func TestCDC_OffsetExpired(t *testing.T) {
+	app, store := setupCDCApp(2)
+	for i := 0; i < 4; i++ {
+		store.Create(&entities.Task{Name: "t"})
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/cdc?from=1", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusGone || resp.Header.Get(RevisionHeader) != "4" {
+		t.Errorf("Expected 410 at revision 4, got %d %q", resp.StatusCode, resp.Header.Get(RevisionHeader))
+	}
+	var errResp apperrors.ErrorResponse
+	json.NewDecoder(resp.Body).Decode(&errResp)
+	if errResp.Code != apperrors.ErrCodeOffsetExpired {
+		t.Errorf("Expected error %d, got %+v", apperrors.ErrCodeOffsetExpired, errResp)
+	}
+
+	if resp, _ := app.Test(httptest.NewRequest("GET", "/cdc?from=2", nil)); resp.StatusCode != fiber.StatusOK {
+		t.Errorf("Expected an offset inside the history streamed, got %d", resp.StatusCode)
+	}
+}
+
+func TestCDC_SlowConsumer(t *testing.T) {
+	defer streaming.Reset()
+	app, store := setupCDCApp(4)
+	for i := 0; i < 5; i++ {
+		store.Create(&entities.Task{Name: "t"})
+	}
+
+	streaming.Init(streaming.New(streaming.Config{MaxLag: 2}))
+	resp, err := app.Test(httptest.NewRequest("GET", "/cdc?from=1", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusGone {
+		t.Errorf("Expected a consumer past MaxLag disconnected, got %d", resp.StatusCode)
+	}
+
+	guard := streaming.New(streaming.Config{MaxLag: 2, Policy: streaming.DropOldest})
+	streaming.Init(guard)
+	for _, from := range []string{"0", "2"} { // Behind the history, and only MaxLag
+		resp, err = app.Test(httptest.NewRequest("GET", "/cdc?from="+from, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		records := readCDC(t, resp)
+		if len(records) != 3 || records[0].Op != OpGap || records[0].Offset != 3 || records[1].Offset != 4 || records[2].Offset != 5 {
+			t.Fatalf("from=%s: expected a gap up to offset 3 then the rest, got %+v", from, records)
+		}
+		if expected := 3 - records[0].Dropped; from != strconv.FormatUint(expected, 10) {
+			t.Errorf("from=%s: unexpected dropped count %d", from, records[0].Dropped)
+		}
+	}
+	if s := guard.Stats()[StreamCDC]; s.Dropped != 4 || s.Active != 0 {
+		t.Errorf("Expected the dropped changes counted, got %+v", s)
+	}
+}
+
+func TestCDC_Follow(t *testing.T) {
+	app, store := setupCDCApp(100)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		store.Create(&entities.Task{Name: "late"})
+	}()
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/cdc?follow=1", nil), 5000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := readCDC(t, resp)
+	if len(records) != 1 || records[0].Task == nil || records[0].Task.Name != "late" {
+		t.Errorf("Expected the write made while following, got %+v", records)
+	}
+}
+
+func TestCDC_InvalidQuery(t *testing.T) {
+	app, _ := setupCDCApp(100)
+	for _, query := range []string{"from=-1", "from=x", "follow=-1", "from=5"} {
+		resp, err := app.Test(httptest.NewRequest("GET", "/cdc?"+query, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query, fiber.StatusBadRequest, resp.StatusCode)
+		}
+	}
+}
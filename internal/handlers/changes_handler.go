@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"tasks-service-demo/internal/changes"
+	apperrors "tasks-service-demo/internal/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	DefaultChangesWait = 30 * time.Second // Long-poll duration when ?wait is omitted
+	MaxChangesWait     = 60 * time.Second // Upper bound for ?wait
+)
+
+// RevisionHeader carries the current revision on /tasks/changes responses
+const RevisionHeader = "X-Revision"
+
+// ChangesHandler serves the long-poll change feed.
+type ChangesHandler struct {
+	feed *changes.Feed
+}
+
+// NewChangesHandler creates a new ChangesHandler reading from feed.
+func NewChangesHandler(feed *changes.Feed) *ChangesHandler {
+	return &ChangesHandler{feed: feed}
+}
+
+// ChangesResponse lists the tasks changed after the requested revision.
+// When Complete is false the history no longer covers the requested revision
+// and the client should refetch GET /tasks.
+type ChangesResponse struct {
+	Revision uint64 `json:"revision"`
+	Changed  []int  `json:"changed"`
+	Deleted  []int  `json:"deleted"`
+	Complete bool   `json:"complete"`
+}
+
+// GetChanges handles GET /tasks/changes?since=<revision>&wait=<seconds>.
+// It blocks until the revision advances past since or wait elapses, then
+// returns the changed task IDs, or 304 Not Modified when nothing changed.
+func (h *ChangesHandler) GetChanges(c *fiber.Ctx) error {
+	since, err := strconv.ParseUint(c.Query("since", "0"), 10, 64)
+	if err != nil {
+		return apperrors.ErrInvalidQuery.WithMessage("since must be a non-negative integer revision")
+	}
+
+	wait := DefaultChangesWait
+	if waitStr := c.Query("wait"); waitStr != "" {
+		seconds, err := strconv.Atoi(waitStr)
+		if err != nil || seconds < 0 {
+			return apperrors.ErrInvalidQuery.WithMessage("wait must be a non-negative number of seconds")
+		}
+		wait = min(time.Duration(seconds)*time.Second, MaxChangesWait)
+	}
+
+	// The request context is cancelled on server shutdown, releasing waiters
+	ctx, cancel := context.WithTimeout(c.Context(), wait)
+	defer cancel()
+	current, _ := h.feed.Wait(ctx, since)
+
+	c.Set(RevisionHeader, strconv.FormatUint(current, 10))
+	if current <= since {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	list, current, complete := h.feed.Since(since)
+	c.Set(RevisionHeader, strconv.FormatUint(current, 10))
+	return c.JSON(summarize(list, current, complete))
+}
+
+// summarize reduces changes to the distinct task IDs whose latest change was a
+// write (Changed) or a delete (Deleted), in order of their latest change
+func summarize(list []changes.Change, current uint64, complete bool) ChangesResponse {
+	latest := make(map[int]uint64, len(list))
+	for _, ch := range list {
+		latest[ch.TaskID] = ch.Revision
+	}
+
+	resp := ChangesResponse{Revision: current, Changed: []int{}, Deleted: []int{}, Complete: complete}
+	for _, ch := range list {
+		if latest[ch.TaskID] != ch.Revision {
+			continue
+		}
+		if ch.Op == changes.OpDeleted {
+			resp.Deleted = append(resp.Deleted, ch.TaskID)
+		} else {
+			resp.Changed = append(resp.Changed, ch.TaskID)
+		}
+	}
+	return resp
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/changes"
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/storage/storetest"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func setupChangesApp() (*fiber.App, *changes.TrackingStore) {
+	feed := changes.NewFeed(100)
+	store := changes.NewTrackingStore(storetest.NewFakeStore(), feed)
+
+	app := newTestApp()
+	app.Get("/tasks/changes", NewChangesHandler(feed).GetChanges)
+	return app, store
+}
+
+func TestGetChanges_Delta(t *testing.T) {
+	app, store := setupChangesApp()
+
+	a, b := &entities.Task{Name: "a"}, &entities.Task{Name: "b"}
+	store.Create(a)
+	store.Create(b)
+	store.Update(a.ID, &entities.Task{Name: "a2"})
+	store.Delete(b.ID)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/tasks/changes?since=0", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status %d, got %d", fiber.StatusOK, resp.StatusCode)
+	}
+	if resp.Header.Get(RevisionHeader) != "4" {
+		t.Errorf("Expected %s 4, got %q", RevisionHeader, resp.Header.Get(RevisionHeader))
+	}
+
+	var body ChangesResponse
+	json.NewDecoder(resp.Body).Decode(&body)
+	if body.Revision != 4 || !body.Complete {
+		t.Errorf("Expected complete delta at revision 4, got %+v", body)
+	}
+	if len(body.Changed) != 1 || body.Changed[0] != a.ID {
+		t.Errorf("Expected changed [%d], got %v", a.ID, body.Changed)
+	}
+	if len(body.Deleted) != 1 || body.Deleted[0] != b.ID {
+		t.Errorf("Expected deleted [%d], got %v", b.ID, body.Deleted)
+	}
+}
+
+func TestGetChanges_NotModified(t *testing.T) {
+	app, _ := setupChangesApp()
+
+	start := time.Now()
+	resp, err := app.Test(httptest.NewRequest("GET", "/tasks/changes?since=0&wait=0", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusNotModified {
+		t.Errorf("Expected status %d, got %d", fiber.StatusNotModified, resp.StatusCode)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected wait=0 to return immediately, took %s", elapsed)
+	}
+}
+
+func TestGetChanges_BlocksUntilWrite(t *testing.T) {
+	app, store := setupChangesApp()
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		store.Create(&entities.Task{Name: "late"})
+	}()
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/tasks/changes?since=0&wait=5", nil), 5000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status %d, got %d", fiber.StatusOK, resp.StatusCode)
+	}
+
+	var body ChangesResponse
+	json.NewDecoder(resp.Body).Decode(&body)
+	if body.Revision != 1 || len(body.Changed) != 1 {
+		t.Errorf("Expected the late write, got %+v", body)
+	}
+}
+
+func TestGetChanges_InvalidQuery(t *testing.T) {
+	app, _ := setupChangesApp()
+
+	for _, query := range []string{"since=-1", "since=abc", "wait=-5", "wait=soon"} {
+		t.Run(query, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", "/tasks/changes?"+query, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != fiber.StatusBadRequest {
+				t.Errorf("Expected status %d, got %d", fiber.StatusBadRequest, resp.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/jobs"
+	"tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/middleware"
+	"tasks-service-demo/internal/requests"
+	"tasks-service-demo/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// compacting is set while a compaction runs, in a job or a request
+var compacting atomic.Bool
+
+// CompactStorage handles POST /admin/storage/compact and reclaims the space
+// of updated and deleted tasks from a durable backend. It runs in a
+// background job; poll the returned job with GET /admin/jobs/:id for its
+// progress. With wait it runs within the request, and the response holds
+// the reclaimed bytes.
+func CompactStorage(c *fiber.Ctx) error {
+	compactor, ok := storage.FindCompactor(storage.GetStore())
+	if !ok {
+		return apperrors.ErrNoCompaction
+	}
+	req := middleware.GetValidatedRequest[requests.CompactStorageRequest](c)
+
+	opts := storage.CompactOptions{Pause: storage.DefaultCompactPause}
+	if req.PauseMs != nil {
+		opts.Pause = time.Duration(*req.PauseMs) * time.Millisecond
+	}
+	if !compacting.CompareAndSwap(false, true) {
+		return apperrors.ErrCompactionRunning
+	}
+
+	if req.Wait {
+		defer compacting.Store(false)
+		stats, err := compactor.CompactOnline(c.UserContext(), opts)
+		if err != nil {
+			return apperrors.ErrStorageError.WithCause(err)
+		}
+		logger.Get().Infow("Storage compacted", "reclaimedBytes", stats.ReclaimedBytes, "steps", stats.Steps)
+		return c.JSON(stats)
+	}
+
+	jobID := jobs.Get().Submit("storage-compaction", func(ctx context.Context, p *jobs.Progress) error {
+		defer compacting.Store(false)
+		var reported int64
+		opts.Progress = func(done, total int64) {
+			p.SetTotal(total)
+			p.Add(done - reported)
+			reported = done
+		}
+		stats, err := compactor.CompactOnline(ctx, opts)
+		p.SetMessage(fmt.Sprintf("reclaimed %d of %d bytes in %d steps", stats.ReclaimedBytes, stats.SizeBefore, stats.Steps))
+		return err
+	})
+	logger.Get().Infow("Storage compaction started", "job", jobID, "pause", opts.Pause)
+
+	c.Location("/admin/jobs/" + jobID)
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"jobId": jobID})
+}
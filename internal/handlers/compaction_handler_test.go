@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/jobs"
+	"tasks-service-demo/internal/middleware"
+	"tasks-service-demo/internal/requests"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/storetest"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// compactingStore reclaims 100 bytes in two steps
+type compactingStore struct {
+	*storetest.FakeStore
+	pause time.Duration
+}
+
+func (s *compactingStore) CompactOnline(ctx context.Context, opts storage.CompactOptions) (storage.CompactStats, error) {
+	s.pause = opts.Pause
+	for done := int64(1); done <= 2; done++ {
+		if opts.Progress != nil {
+			opts.Progress(done, 2)
+		}
+	}
+	return storage.CompactStats{SizeBefore: 300, SizeAfter: 200, ReclaimedBytes: 100, Steps: 2}, nil
+}
+
+func setupCompactionApp(store storage.Store) *fiber.App {
+	storage.ResetStore()
+	storage.InitStore(store)
+	app := newTestApp()
+	app.Post("/admin/storage/compact", middleware.ValidateRequest[requests.CompactStorageRequest](), CompactStorage)
+	app.Get("/admin/jobs/:id", GetJob)
+	return app
+}
+
+func TestCompactStorage(t *testing.T) {
+	jobs.Reset()
+	defer func() {
+		jobs.Get().Stop()
+		jobs.Reset()
+		storage.ResetStore()
+	}()
+
+	app := setupCompactionApp(storetest.NewFakeStore())
+	status, result := sendJSON(t, app, "POST", "/admin/storage/compact", `{}`)
+	if status != fiber.StatusNotImplemented || result["code"] != float64(apperrors.ErrCodeNoCompaction) {
+		t.Errorf("Expected no compaction for an in-memory store, got %d %v", status, result)
+	}
+
+	store := &compactingStore{FakeStore: storetest.NewFakeStore()}
+	app = setupCompactionApp(store)
+	status, result = sendJSON(t, app, "POST", "/admin/storage/compact", `{"wait": true, "pauseMs": 5}`)
+	if status != fiber.StatusOK || result["reclaimedBytes"] != float64(100) || store.pause != 5*time.Millisecond {
+		t.Errorf("Expected the stats of a compaction paced at 5ms, got %d %v (pause %s)", status, result, store.pause)
+	}
+
+	compacting.Store(true)
+	status, result = sendJSON(t, app, "POST", "/admin/storage/compact", `{}`)
+	compacting.Store(false)
+	if status != fiber.StatusConflict || result["code"] != float64(apperrors.ErrCodeCompactionRunning) {
+		t.Errorf("Expected a conflict while compacting, got %d %v", status, result)
+	}
+
+	status, result = sendJSON(t, app, "POST", "/admin/storage/compact", `{}`)
+	jobID, _ := result["jobId"].(string)
+	if status != fiber.StatusAccepted || jobID == "" {
+		t.Fatalf("Expected a compaction job, got %d %v", status, result)
+	}
+	var job jobs.Status
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		resp, _ := app.Test(httptest.NewRequest("GET", "/admin/jobs/"+jobID, nil))
+		json.NewDecoder(resp.Body).Decode(&job)
+		if job.State == jobs.StateSucceeded {
+			break
+		}
+	}
+	if job.State != jobs.StateSucceeded || job.Done != 2 || job.Total != 2 || !strings.Contains(job.Message, "reclaimed 100") {
+		t.Errorf("Expected a finished job with its progress and stats, got %+v", job)
+	}
+	if compacting.Load() {
+		t.Error("Expected the job to allow the next compaction")
+	}
+}
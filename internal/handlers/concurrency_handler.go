@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"tasks-service-demo/internal/adaptive"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ConcurrencyResponse is the response of GET /admin/concurrency
+type ConcurrencyResponse struct {
+	Enabled bool                  `json:"enabled"`
+	Routes  []adaptive.RouteStats `json:"routes"`
+}
+
+// GetConcurrency handles GET /admin/concurrency and returns the adaptive
+// concurrency limit of each route seen so far
+func GetConcurrency(c *fiber.Ctx) error {
+	ls := adaptive.Get()
+	return c.JSON(ConcurrencyResponse{Enabled: ls.Enabled(), Routes: ls.Stats()})
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"time"
+
+	"tasks-service-demo/internal/buildinfo"
+	"tasks-service-demo/internal/stats"
+	"tasks-service-demo/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DashboardHotKeys is the number of hot keys reported to the dashboard
+const DashboardHotKeys = 10
+
+// DashboardStorage describes the store as shown on the dashboard
+type DashboardStorage struct {
+	Type   string `json:"type"`
+	Tasks  int    `json:"tasks"`
+	Shards []int  `json:"shards"` // Tasks per shard; null when the store is not sharded
+}
+
+// DashboardStats is the payload polled by the admin dashboard
+type DashboardStats struct {
+	stats.Snapshot
+	Storage DashboardStorage `json:"storage"`
+}
+
+// GetDashboardStats handles GET /admin/dashboard/stats and returns request
+// rate, latency percentiles, hot keys and the shard distribution.
+func GetDashboardStats(c *fiber.Ctx) error {
+	store := storage.GetStore()
+	resp := DashboardStats{
+		Snapshot: stats.Get().Snapshot(time.Now(), DashboardHotKeys),
+		Storage:  DashboardStorage{Type: buildinfo.Get().Storage.Type},
+	}
+
+	if resp.Storage.Shards = shardCounts(store); resp.Storage.Shards != nil {
+		for _, n := range resp.Storage.Shards {
+			resp.Storage.Tasks += n
+		}
+	} else if store != nil {
+		resp.Storage.Tasks = len(store.GetAll())
+	}
+	return c.JSON(resp)
+}
+
+// shardCounts unwraps store decorators until it finds a sharded backend
+func shardCounts(store storage.Store) []int {
+	for store != nil {
+		if sharded, ok := store.(interface{ ShardCounts() []int }); ok {
+			return sharded.ShardCounts()
+		}
+		wrapper, ok := store.(interface{ Inner() storage.Store })
+		if !ok {
+			return nil
+		}
+		store = wrapper.Inner()
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/changes"
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/stats"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/storetest"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// shardedFakeStore reports a fixed shard distribution
+type shardedFakeStore struct {
+	*storetest.FakeStore
+	counts []int
+}
+
+func (s *shardedFakeStore) ShardCounts() []int {
+	return s.counts
+}
+
+func getDashboardStats(t *testing.T) DashboardStats {
+	t.Helper()
+
+	app := newTestApp()
+	app.Get("/admin/dashboard/stats", GetDashboardStats)
+	resp, err := app.Test(httptest.NewRequest("GET", "/admin/dashboard/stats", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status %d, got %d", fiber.StatusOK, resp.StatusCode)
+	}
+
+	var body DashboardStats
+	json.NewDecoder(resp.Body).Decode(&body)
+	return body
+}
+
+func TestGetDashboardStats(t *testing.T) {
+	stats.Reset()
+	defer stats.Reset()
+	storage.ResetStore()
+	defer storage.ResetStore()
+
+	sharded := &shardedFakeStore{FakeStore: storetest.NewFakeStore(), counts: []int{2, 0, 1}}
+	storage.InitStore(changes.NewTrackingStore(sharded, changes.NewFeed(10)))
+
+	stats.Get().Record(time.Now(), time.Millisecond, false, "7")
+	stats.Get().Record(time.Now(), time.Millisecond, false, "7")
+
+	body := getDashboardStats(t)
+	if !reflect.DeepEqual(body.Storage.Shards, []int{2, 0, 1}) || body.Storage.Tasks != 3 {
+		t.Errorf("Expected shard counts [2 0 1] totalling 3, got %+v", body.Storage)
+	}
+	if body.Requests != 2 {
+		t.Errorf("Expected 2 requests, got %d", body.Requests)
+	}
+	if len(body.HotKeys) != 1 || body.HotKeys[0].Key != "7" || body.HotKeys[0].Hits != 2 {
+		t.Errorf("Expected hot key 7 with 2 hits, got %+v", body.HotKeys)
+	}
+}
+
+func TestGetDashboardStats_Unsharded(t *testing.T) {
+	storage.ResetStore()
+	defer storage.ResetStore()
+	storage.InitStore(storetest.NewFakeStore(&entities.Task{ID: 1, Name: "a"}))
+
+	body := getDashboardStats(t)
+	if body.Storage.Shards != nil || body.Storage.Tasks != 1 {
+		t.Errorf("Expected 1 task and no shards, got %+v", body.Storage)
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"fmt"
+
+	"tasks-service-demo/internal/buildinfo"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/shard"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DebugShard is the load and lock contention of one shard. Lock times are
+// measured on a sample of the acquisitions.
+type DebugShard struct {
+	Shard         int     `json:"shard"`
+	Tasks         int     `json:"tasks"`
+	Acquisitions  int64   `json:"acquisitions"`
+	Sampled       int64   `json:"sampled"`
+	AvgWaitMicros float64 `json:"avgWaitMicros"`
+	AvgHoldMicros float64 `json:"avgHoldMicros"`
+	MaxWaitMicros int64   `json:"maxWaitMicros"`
+	MaxHoldMicros int64   `json:"maxHoldMicros"`
+}
+
+// DebugStoreResponse is the response of GET /debug/store
+type DebugStoreResponse struct {
+	Type   string       `json:"type"`
+	Chain  []string     `json:"chain"`  // Decorators from the outermost down to the backend
+	Shards []DebugShard `json:"shards"` // Null when the backend is not sharded
+}
+
+// GetStoreDebug handles GET /debug/store and describes the decorator chain
+// of the store and, on sharded backends, the load and lock contention of
+// each shard.
+func GetStoreDebug(c *fiber.Ctx) error {
+	store := storage.GetStore()
+	resp := DebugStoreResponse{Type: buildinfo.Get().Storage.Type, Chain: storeChain(store)}
+
+	if reporter := shardReporter(store); reporter != nil {
+		for _, s := range reporter.ShardStats() {
+			stats := DebugShard{
+				Shard:         s.Shard,
+				Tasks:         s.Tasks,
+				Acquisitions:  s.Acquisitions,
+				Sampled:       s.Sampled,
+				MaxWaitMicros: s.MaxWait.Microseconds(),
+				MaxHoldMicros: s.MaxHold.Microseconds(),
+			}
+			if s.Sampled > 0 {
+				stats.AvgWaitMicros = float64(s.Wait.Nanoseconds()) / float64(s.Sampled) / 1000
+				stats.AvgHoldMicros = float64(s.Hold.Nanoseconds()) / float64(s.Sampled) / 1000
+			}
+			resp.Shards = append(resp.Shards, stats)
+		}
+	}
+	return c.JSON(resp)
+}
+
+// shardReporter unwraps store decorators until it finds a sharded backend
+// reporting per-shard stats
+func shardReporter(store storage.Store) shard.Reporter {
+	for store != nil {
+		if reporter, ok := store.(shard.Reporter); ok {
+			return reporter
+		}
+		wrapper, ok := store.(interface{ Inner() storage.Store })
+		if !ok {
+			return nil
+		}
+		store = wrapper.Inner()
+	}
+	return nil
+}
+
+// storeChain returns the type names of store and the stores it wraps
+func storeChain(store storage.Store) []string {
+	chain := make([]string, 0)
+	for store != nil {
+		chain = append(chain, fmt.Sprintf("%T", store))
+		wrapper, ok := store.(interface{ Inner() storage.Store })
+		if !ok {
+			break
+		}
+		store = wrapper.Inner()
+	}
+	return chain
+}
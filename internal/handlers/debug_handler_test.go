@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/slowlog"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/shard"
+	"tasks-service-demo/internal/storage/storetest"
+)
+
+func getStoreDebug(t *testing.T, store storage.Store) DebugStoreResponse {
+	t.Helper()
+	storage.ResetStore()
+	storage.InitStore(store)
+	app := newTestApp()
+	app.Get("/debug/store", GetStoreDebug)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/debug/store", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body DebugStoreResponse
+	json.NewDecoder(resp.Body).Decode(&body)
+	return body
+}
+
+func TestGetStoreDebug(t *testing.T) {
+	defer storage.ResetStore()
+
+	body := getStoreDebug(t, storetest.NewFakeStore())
+	if len(body.Chain) != 1 || body.Chain[0] != "*storetest.FakeStore" || body.Shards != nil {
+		t.Errorf("Expected an unsharded store without shard stats, got %+v", body)
+	}
+
+	sharded := shard.NewShardStore(2)
+	sharded.SampleLocks(1)
+	for i := 0; i < 3; i++ {
+		sharded.Create(&entities.Task{Name: "task"})
+	}
+	body = getStoreDebug(t, slowlog.NewStore(sharded, slowlog.New(slowlog.Config{})))
+	if len(body.Chain) != 2 || body.Chain[0] != "*slowlog.Store" || body.Chain[1] != "*shard.ShardStore" {
+		t.Errorf("Expected the decorator chain down to the backend, got %v", body.Chain)
+	}
+	if len(body.Shards) != 2 || body.Shards[1].Tasks != 2 || body.Shards[1].Sampled != 2 {
+		t.Errorf("Expected the stats of both shards, got %+v", body.Shards)
+	}
+}
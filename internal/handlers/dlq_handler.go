@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"errors"
+
+	"tasks-service-demo/internal/dlq"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/notify"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DeadLetterList is the response of GET /admin/dlq
+type DeadLetterList struct {
+	Entries []dlq.Entry `json:"entries"`
+	Dropped int64       `json:"dropped"` // Entries discarded because the queue was full
+}
+
+// ListDeadLetters handles GET /admin/dlq and returns the failed deliveries, oldest first.
+func ListDeadLetters(c *fiber.Ctx) error {
+	q := dlq.Get()
+	return c.JSON(DeadLetterList{Entries: q.List(), Dropped: q.Dropped()})
+}
+
+// ReplayDeadLetter handles POST /admin/dlq/:id/replay. The entry is queued
+// for delivery again and removed from the dead-letter queue; the response
+// carries the job ID of the new delivery.
+func ReplayDeadLetter(c *fiber.Ctx) error {
+	id := c.Params("id")
+	ref, err := dlq.Get().Replay(c.UserContext(), id)
+	switch {
+	case errors.Is(err, dlq.ErrNotFound):
+		return apperrors.ErrDeadLetterNotFound
+	case errors.Is(err, notify.ErrDisabled):
+		return apperrors.ErrNotifyDisabled
+	case err != nil:
+		logger.Get().Errorw("Dead-letter replay failed", "id", id, "error", err)
+		return apperrors.ErrInternalError.WithCause(err)
+	}
+
+	logger.Get().Infow("Dead letter replayed", "id", id, "jobId", ref)
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"id": id, "jobId": ref})
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"tasks-service-demo/internal/dlq"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/notify"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func setupDLQApp(t *testing.T, replay dlq.Replayer) (*fiber.App, *dlq.Queue) {
+	t.Helper()
+	q := dlq.New(10)
+	q.Register("email", replay)
+	dlq.Init(q)
+	t.Cleanup(dlq.Reset)
+
+	app := newTestApp()
+	app.Get("/admin/dlq", ListDeadLetters)
+	app.Post("/admin/dlq/:id/replay", ReplayDeadLetter)
+	return app, q
+}
+
+func TestListDeadLetters(t *testing.T) {
+	app, q := setupDLQApp(t, nil)
+	e, _ := q.Add("email", map[string]string{"to": "a@example.com"}, 3, errors.New("connection refused"))
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/admin/dlq", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body DeadLetterList
+	json.NewDecoder(resp.Body).Decode(&body)
+	if len(body.Entries) != 1 || body.Entries[0].ID != e.ID || body.Entries[0].Error != "connection refused" {
+		t.Errorf("Expected entry %s, got %+v", e.ID, body.Entries)
+	}
+}
+
+func TestReplayDeadLetter(t *testing.T) {
+	app, q := setupDLQApp(t, func(ctx context.Context, payload json.RawMessage) (string, error) {
+		return "job-7", nil
+	})
+	e, _ := q.Add("email", "hello", 3, nil)
+
+	status, result := sendJSON(t, app, "POST", "/admin/dlq/"+e.ID+"/replay", "")
+	if status != fiber.StatusAccepted || result["jobId"] != "job-7" {
+		t.Errorf("Expected accepted replay, got %d %v", status, result)
+	}
+	if len(q.List()) != 0 {
+		t.Error("Expected entry to be removed")
+	}
+
+	status, result = sendJSON(t, app, "POST", "/admin/dlq/"+e.ID+"/replay", "")
+	if status != fiber.StatusNotFound || result["code"] != float64(apperrors.ErrCodeDeadLetterNotFound) {
+		t.Errorf("Expected dead letter not found, got %d %v", status, result)
+	}
+}
+
+func TestReplayDeadLetter_NotifyDisabled(t *testing.T) {
+	app, q := setupDLQApp(t, func(ctx context.Context, payload json.RawMessage) (string, error) {
+		return "", notify.ErrDisabled
+	})
+	e, _ := q.Add("email", "hello", 3, nil)
+
+	status, result := sendJSON(t, app, "POST", "/admin/dlq/"+e.ID+"/replay", "")
+	if status != fiber.StatusServiceUnavailable || result["code"] != float64(apperrors.ErrCodeNotifyDisabled) {
+		t.Errorf("Expected notifications disabled, got %d %v", status, result)
+	}
+	if len(q.List()) != 1 {
+		t.Error("Expected entry to be kept")
+	}
+}
@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	apperrors "tasks-service-demo/internal/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrorCatalogResponse lists every error code the API can return
+type ErrorCatalogResponse struct {
+	Errors []apperrors.CatalogEntry `json:"errors"`
+}
+
+// ErrorCatalog handles GET /errors and returns the machine-readable error catalog.
+func ErrorCatalog(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=3600")
+	return c.JSON(ErrorCatalogResponse{Errors: apperrors.Catalog()})
+}
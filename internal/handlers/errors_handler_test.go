@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	apperrors "tasks-service-demo/internal/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestErrorCatalog(t *testing.T) {
+	app := newTestApp()
+	app.Get("/errors", ErrorCatalog)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/errors", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status %d, got %d", fiber.StatusOK, resp.StatusCode)
+	}
+
+	var body ErrorCatalogResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Errors) != len(apperrors.Catalog()) {
+		t.Fatalf("Expected %d entries, got %d", len(apperrors.Catalog()), len(body.Errors))
+	}
+
+	first := body.Errors[0]
+	if first.Code != apperrors.ErrCodeTaskNotFound || first.Name != "TaskNotFound" || first.Type != "NOT_FOUND" || first.HTTPStatus != fiber.StatusNotFound {
+		t.Errorf("Unexpected first entry %+v", first)
+	}
+}
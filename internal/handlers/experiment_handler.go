@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"tasks-service-demo/internal/storage/mirror"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetStorageExperiment handles GET /admin/storage/experiment and compares
+// the latency and errors of the primary and alternate stores side by side.
+func GetStorageExperiment(c *fiber.Ctx) error {
+	return c.JSON(mirror.Get().Report())
+}
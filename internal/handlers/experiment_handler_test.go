@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"testing"
+
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/storage/mirror"
+	"tasks-service-demo/internal/storage/naive"
+	"tasks-service-demo/internal/storage/storetest"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestGetStorageExperiment(t *testing.T) {
+	app := newTestApp()
+	app.Get("/admin/storage/experiment", GetStorageExperiment)
+
+	mirror.Reset()
+	status, result := sendJSON(t, app, "GET", "/admin/storage/experiment", "")
+	if status != fiber.StatusOK || result["enabled"] != false {
+		t.Errorf("Expected no experiment, got %d %v", status, result)
+	}
+
+	m := mirror.New(storetest.NewFakeStore(&entities.Task{ID: 1, Name: "a"}), naive.NewMemoryStore())
+	if _, err := m.Seed(); err != nil {
+		t.Fatal(err)
+	}
+	router := mirror.NewRouter(m, mirror.Config{Percent: 5})
+	mirror.Init(router)
+	defer mirror.Reset()
+	router.GetByID(1)
+
+	status, result = sendJSON(t, app, "GET", "/admin/storage/experiment", "")
+	variants, _ := result["variants"].(map[string]interface{})
+	primary, _ := variants["primary"].(map[string]interface{})
+	get, _ := primary["get"].(map[string]interface{})
+	if status != fiber.StatusOK || result["percent"] != float64(5) || get["count"] != float64(1) {
+		t.Errorf("Expected the primary get in the report, got %d %v", status, result)
+	}
+}
@@ -1,15 +1,48 @@
 package handlers
 
 import (
+	"tasks-service-demo/internal/health"
+	"tasks-service-demo/internal/maintenance"
+
 	"github.com/gofiber/fiber/v2"
 )
 
 // Package handlers provides HTTP handlers for the Task API.
 
-// HealthCheck handles GET /health and returns the health status of the API.
+// HealthCheck handles GET /health and returns the aggregated health report.
+// It responds 503 when a critical dependency is unhealthy, 200 otherwise.
 func HealthCheck(c *fiber.Ctx) error {
+	report := health.Get().Run()
+
+	status := fiber.StatusOK
+	if report.Status == health.StatusUnhealthy {
+		status = fiber.StatusServiceUnavailable
+	}
+
+	return c.Status(status).JSON(report)
+}
+
+// ReadinessCheck handles GET /readyz. It responds 200 once startup warm-up has
+// completed and 503 before that, while shutting down, or during full maintenance.
+func ReadinessCheck(c *fiber.Ctx) error {
+	mode := maintenance.Get().Mode
+
+	if !health.Get().Ready() {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"status":      "not_ready",
+			"maintenance": mode,
+		})
+	}
+
+	if mode == maintenance.ModeFull {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"status":      "maintenance",
+			"maintenance": mode,
+		})
+	}
+
 	return c.JSON(fiber.Map{
-		"status":  "ok",
-		"message": "Task API is running",
+		"status":      "ready",
+		"maintenance": mode,
 	})
 }
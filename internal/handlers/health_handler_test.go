@@ -6,11 +6,13 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"tasks-service-demo/internal/health"
+
 	"github.com/gofiber/fiber/v2"
 )
 
 func TestHealthCheck(t *testing.T) {
-	app := fiber.New()
+	app := newTestApp()
 	app.Get("/health", HealthCheck)
 
 	req := httptest.NewRequest("GET", "/health", nil)
@@ -42,7 +44,7 @@ func TestHealthCheck(t *testing.T) {
 }
 
 func TestHealthCheck_ResponseFormat(t *testing.T) {
-	app := fiber.New()
+	app := newTestApp()
 	app.Get("/health", HealthCheck)
 
 	req := httptest.NewRequest("GET", "/health", nil)
@@ -73,3 +75,77 @@ func TestHealthCheck_ResponseFormat(t *testing.T) {
 		}
 	}
 }
+
+func TestHealthCheck_Unhealthy(t *testing.T) {
+	health.Reset()
+	defer health.Reset()
+	health.Get().Register("storage", true, func() health.CheckResult {
+		return health.CheckResult{Status: health.StatusUnhealthy, Message: "down"}
+	})
+
+	app := newTestApp()
+	app.Get("/health", HealthCheck)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/health", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != fiber.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", fiber.StatusServiceUnavailable, resp.StatusCode)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	var report health.Report
+	if err := json.Unmarshal(body, &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Checks["storage"].Message != "down" {
+		t.Errorf("Expected per-check detail in response, got %s", body)
+	}
+}
+
+func TestHealthCheck_Degraded(t *testing.T) {
+	health.Reset()
+	defer health.Reset()
+	health.Get().Register("events", false, func() health.CheckResult {
+		return health.CheckResult{Status: health.StatusUnhealthy}
+	})
+
+	app := newTestApp()
+	app.Get("/health", HealthCheck)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/health", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != fiber.StatusOK {
+		t.Errorf("Expected status %d for degraded service, got %d", fiber.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestReadinessCheck(t *testing.T) {
+	health.Reset()
+	defer health.Reset()
+
+	app := newTestApp()
+	app.Get("/readyz", ReadinessCheck)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/readyz", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusServiceUnavailable {
+		t.Errorf("Expected status %d before warm-up, got %d", fiber.StatusServiceUnavailable, resp.StatusCode)
+	}
+
+	health.Get().SetReady(true)
+	resp, err = app.Test(httptest.NewRequest("GET", "/readyz", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Errorf("Expected status %d after warm-up, got %d", fiber.StatusOK, resp.StatusCode)
+	}
+}
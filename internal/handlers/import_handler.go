@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"strings"
+
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/importer"
+	"tasks-service-demo/internal/jobs"
+	"tasks-service-demo/internal/logger"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CreateImport handles POST /admin/imports/:source. The body is the source's
+// export file; it is parsed up front so format errors are reported
+// immediately, then imported in a background job. Poll the returned job
+// with GET /admin/jobs/:id.
+func CreateImport(c *fiber.Ctx) error {
+	source := c.Params("source")
+	connector, ok := importer.Lookup(source)
+	if !ok {
+		return apperrors.ErrImportInvalid.WithMessage("unknown import source; supported: " + strings.Join(importer.Sources(), ", "))
+	}
+
+	items, err := connector.Parse(c.Body())
+	if err != nil {
+		return apperrors.ErrImportInvalid.WithMessage("invalid " + source + " payload: " + err.Error())
+	}
+
+	// Size in-memory maps for the whole import rather than rehashing midway
+	importer.Get().Preallocate(len(items))
+
+	jobID := jobs.Get().Submit("import-"+source, importer.Get().Job(source, items))
+	logger.Get().Infow("Import started", "job", jobID, "source", source, "items", len(items))
+
+	c.Location("/admin/jobs/" + jobID)
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"jobId":  jobID,
+		"source": source,
+		"items":  len(items),
+	})
+}
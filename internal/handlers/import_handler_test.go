@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/importer"
+	"tasks-service-demo/internal/jobs"
+	"tasks-service-demo/internal/storage/storetest"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCreateImport(t *testing.T) {
+	jobs.Reset()
+	store := storetest.NewFakeStore()
+	importer.Init(importer.New(store))
+	defer func() {
+		jobs.Get().Stop()
+		jobs.Reset()
+		importer.Reset()
+	}()
+
+	app := newTestApp()
+	app.Post("/admin/imports/:source", CreateImport)
+	app.Get("/admin/jobs/:id", GetJob)
+
+	payload := `[{"id": 1, "title": "Imported", "state": "closed"}]`
+	resp, err := app.Test(httptest.NewRequest("POST", "/admin/imports/github", strings.NewReader(payload)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusAccepted {
+		t.Fatalf("Expected status %d, got %d", fiber.StatusAccepted, resp.StatusCode)
+	}
+
+	var created struct {
+		JobID string `json:"jobId"`
+		Items int    `json:"items"`
+	}
+	json.NewDecoder(resp.Body).Decode(&created)
+	if created.Items != 1 || resp.Header.Get("Location") != "/admin/jobs/"+created.JobID {
+		t.Errorf("Unexpected response %+v, Location %q", created, resp.Header.Get("Location"))
+	}
+
+	var status jobs.Status
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		resp, _ := app.Test(httptest.NewRequest("GET", "/admin/jobs/"+created.JobID, nil))
+		json.NewDecoder(resp.Body).Decode(&status)
+		if status.State == jobs.StateSucceeded {
+			break
+		}
+	}
+	if status.State != jobs.StateSucceeded || status.Done != 1 {
+		t.Fatalf("Expected finished import, got %+v", status)
+	}
+	if task, err := store.GetByID(1); err != nil || task.Name != "Imported" || task.Status != 1 {
+		t.Errorf("Expected imported task, got %+v, %v", task, err)
+	}
+}
+
+func TestCreateImport_Invalid(t *testing.T) {
+	app := newTestApp()
+	app.Post("/admin/imports/:source", CreateImport)
+	app.Get("/admin/jobs/:id", GetJob)
+
+	for path, body := range map[string]string{
+		"/admin/imports/trello":  `[]`,
+		"/admin/imports/github":  `not json`,
+		"/admin/imports/todoist": `{}`,
+	} {
+		resp, err := app.Test(httptest.NewRequest("POST", path, strings.NewReader(body)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", fiber.StatusBadRequest, path, resp.StatusCode)
+		}
+	}
+
+	resp, _ := app.Test(httptest.NewRequest("GET", "/admin/jobs/missing", nil))
+	if resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("Expected status %d for unknown job, got %d", fiber.StatusNotFound, resp.StatusCode)
+	}
+}
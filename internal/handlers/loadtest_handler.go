@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"tasks-service-demo/internal/bench"
+	"tasks-service-demo/internal/buildinfo"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/jobs"
+	"tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/middleware"
+	"tasks-service-demo/internal/requests"
+	"tasks-service-demo/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultLoadTestDataset is the number of tasks a load test store holds
+// when the request does not say
+const DefaultLoadTestDataset = 100000
+
+// loadTesting is set while a load test runs, in a job or a request
+var loadTesting atomic.Bool
+
+// LoadTestScenario describes a scenario in GET /admin/loadtest
+type LoadTestScenario struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// ListLoadTestScenarios handles GET /admin/loadtest and lists the scenarios
+// a load test can run.
+func ListLoadTestScenarios(c *fiber.Ctx) error {
+	scenarios := make([]LoadTestScenario, 0)
+	for _, s := range bench.Scenarios() {
+		scenarios = append(scenarios, LoadTestScenario{Name: s.Name(), Description: s.Description()})
+	}
+	return c.JSON(fiber.Map{"scenarios": scenarios})
+}
+
+// RunLoadTest handles POST /admin/loadtest. It creates a scratch store of
+// an in-memory backend, populates it and drives a bench scenario against
+// it, so the live store is never touched; the numbers measure the backend,
+// not the HTTP stack. It runs in a background job; poll the returned job
+// with GET /admin/jobs/:id. With wait it runs within the request, and the
+// response holds the result.
+func RunLoadTest(c *fiber.Ctx) error {
+	req := middleware.GetValidatedRequest[requests.LoadTestRequest](c)
+
+	scenario, ok := bench.Lookup(req.Scenario)
+	if !ok {
+		names := make([]string, 0)
+		for _, s := range bench.Scenarios() {
+			names = append(names, s.Name())
+		}
+		return apperrors.ErrLoadTestInvalid.WithMessage("unknown scenario; supported: " + strings.Join(names, ", "))
+	}
+	storageType := req.Storage
+	if storageType == "" {
+		storageType = buildinfo.Get().Storage.Type
+	}
+	backend, ok := storage.Lookup(storageType)
+	if !ok {
+		return apperrors.ErrLoadTestInvalid.WithMessage("unknown storage; supported: " + strings.Join(storage.Backends(), ", "))
+	}
+	if backend.Durable {
+		return apperrors.ErrLoadTestInvalid.WithMessage("load tests run on a scratch in-memory store; " + storageType + " is durable")
+	}
+
+	dataset := req.Dataset
+	if dataset == 0 {
+		dataset = DefaultLoadTestDataset
+	}
+	cfg := bench.Config{
+		Dataset:  dataset,
+		Workers:  req.Workers,
+		Duration: time.Duration(req.DurationMs) * time.Millisecond,
+	}
+	if !loadTesting.CompareAndSwap(false, true) {
+		return apperrors.ErrLoadTestRunning
+	}
+
+	run := func(ctx context.Context, p *jobs.Progress) (bench.Result, error) {
+		defer loadTesting.Store(false)
+		store, err := backend.New(storage.Options{ShardCount: buildinfo.Get().Storage.ShardCount})
+		if err != nil {
+			return bench.Result{}, err
+		}
+		defer bench.Close(store)
+
+		p.SetTotal(int64(dataset))
+		var populated int
+		if err := bench.Populate(store, dataset, func(done int) {
+			p.Add(int64(done - populated))
+			populated = done
+		}); err != nil {
+			return bench.Result{}, err
+		}
+		p.Add(int64(dataset - populated))
+
+		result, err := bench.Run(ctx, store, scenario, cfg)
+		if err == nil {
+			logger.Get().Infow("Load test finished", "storage", storageType, "result", result.String())
+		}
+		return result, err
+	}
+
+	if req.Wait {
+		result, err := run(c.UserContext(), &jobs.Progress{})
+		if err != nil {
+			return apperrors.ErrStorageError.WithCause(err)
+		}
+		return c.JSON(result)
+	}
+
+	jobID := jobs.Get().Submit("loadtest-"+scenario.Name(), func(ctx context.Context, p *jobs.Progress) error {
+		result, err := run(ctx, p)
+		if err == nil {
+			p.SetMessage(storageType + " " + result.String())
+		}
+		return err
+	})
+	logger.Get().Infow("Load test started", "job", jobID, "scenario", scenario.Name(), "storage", storageType, "dataset", dataset)
+
+	c.Location("/admin/jobs/" + jobID)
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"jobId": jobID})
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/jobs"
+	"tasks-service-demo/internal/middleware"
+	"tasks-service-demo/internal/requests"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/storetest"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func init() {
+	storage.Register(storage.Backend{
+		Name: "loadtest-fake",
+		New:  func(storage.Options) (storage.Store, error) { return storetest.NewFakeStore(), nil },
+	})
+	storage.Register(storage.Backend{
+		Name:    "loadtest-durable",
+		Durable: true,
+		New:     func(storage.Options) (storage.Store, error) { return storetest.NewFakeStore(), nil },
+	})
+}
+
+func setupLoadTestApp() *fiber.App {
+	app := newTestApp()
+	app.Get("/admin/loadtest", ListLoadTestScenarios)
+	app.Post("/admin/loadtest", middleware.ValidateRequest[requests.LoadTestRequest](), RunLoadTest)
+	app.Get("/admin/jobs/:id", GetJob)
+	return app
+}
+
+func TestListLoadTestScenarios(t *testing.T) {
+	app := setupLoadTestApp()
+	status, result := sendJSON(t, app, "GET", "/admin/loadtest", "")
+	scenarios, _ := result["scenarios"].([]interface{})
+	if status != fiber.StatusOK || len(scenarios) == 0 {
+		t.Fatalf("Expected the scenarios, got %d %v", status, result)
+	}
+	first, _ := scenarios[0].(map[string]interface{})
+	if first["name"] == "" || first["description"] == "" {
+		t.Errorf("Expected a named and described scenario, got %v", first)
+	}
+}
+
+func TestRunLoadTest(t *testing.T) {
+	jobs.Reset()
+	defer func() {
+		jobs.Get().Stop()
+		jobs.Reset()
+	}()
+	app := setupLoadTestApp()
+
+	for _, body := range []string{
+		`{"scenario": "nope", "storage": "loadtest-fake"}`,
+		`{"scenario": "zipf-read", "storage": "nope"}`,
+		`{"scenario": "zipf-read", "storage": "loadtest-durable"}`,
+	} {
+		status, result := sendJSON(t, app, "POST", "/admin/loadtest", body)
+		if status != fiber.StatusBadRequest || result["code"] != float64(apperrors.ErrCodeLoadTestInvalid) {
+			t.Errorf("Expected %s to be rejected, got %d %v", body, status, result)
+		}
+	}
+	status, _ := sendJSON(t, app, "POST", "/admin/loadtest", `{"scenario": "zipf-read", "workers": 1000}`)
+	if status != fiber.StatusBadRequest {
+		t.Errorf("Expected too many workers to be rejected, got %d", status)
+	}
+
+	status, result := sendJSON(t, app, "POST", "/admin/loadtest",
+		`{"scenario": "distributed-mixed", "storage": "loadtest-fake", "dataset": 100, "durationMs": 20, "workers": 2, "wait": true}`)
+	if status != fiber.StatusOK || result["scenario"] != "distributed-mixed" || result["ops"].(float64) == 0 || result["errors"] != float64(0) {
+		t.Errorf("Expected the result of the run, got %d %v", status, result)
+	}
+
+	loadTesting.Store(true)
+	status, result = sendJSON(t, app, "POST", "/admin/loadtest", `{"scenario": "zipf-read", "storage": "loadtest-fake"}`)
+	loadTesting.Store(false)
+	if status != fiber.StatusConflict || result["code"] != float64(apperrors.ErrCodeLoadTestRunning) {
+		t.Errorf("Expected a conflict while a load test runs, got %d %v", status, result)
+	}
+
+	status, result = sendJSON(t, app, "POST", "/admin/loadtest",
+		`{"scenario": "hotkey-write", "storage": "loadtest-fake", "dataset": 50, "durationMs": 20}`)
+	jobID, _ := result["jobId"].(string)
+	if status != fiber.StatusAccepted || jobID == "" {
+		t.Fatalf("Expected a load test job, got %d %v", status, result)
+	}
+	var job jobs.Status
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		resp, _ := app.Test(httptest.NewRequest("GET", "/admin/jobs/"+jobID, nil))
+		json.NewDecoder(resp.Body).Decode(&job)
+		if job.State == jobs.StateSucceeded {
+			break
+		}
+	}
+	if job.State != jobs.StateSucceeded || job.Done != 50 || !strings.Contains(job.Message, "hotkey-write") {
+		t.Errorf("Expected a finished job with the populated tasks and the result, got %+v", job)
+	}
+	if loadTesting.Load() {
+		t.Error("Expected the job to allow the next load test")
+	}
+}
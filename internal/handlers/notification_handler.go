@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/middleware"
+	"tasks-service-demo/internal/notify"
+	"tasks-service-demo/internal/requests"
+	"tasks-service-demo/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NotificationHandler handles HTTP requests for email notifications.
+type NotificationHandler struct {
+	service *services.TaskService
+}
+
+// NewNotificationHandler creates a new NotificationHandler with the given TaskService.
+func NewNotificationHandler(service *services.TaskService) *NotificationHandler {
+	return &NotificationHandler{service: service}
+}
+
+// SendNotification handles POST /admin/notifications and queues a reminder
+// or assignment email about a task. Delivery happens in a background job.
+func (h *NotificationHandler) SendNotification(c *fiber.Ctx) error {
+	req := middleware.GetValidatedRequest[requests.NotificationRequest](c)
+
+	task, appErr := h.service.GetTaskByID(req.TaskID)
+	if appErr != nil {
+		return appErr
+	}
+
+	jobID, err := notify.Get().Send(notify.Message{
+		Kind: notify.Kind(req.Kind),
+		User: req.User,
+		To:   req.Email,
+		Task: task,
+		Note: req.Note,
+	})
+	switch {
+	case errors.Is(err, notify.ErrDisabled):
+		return apperrors.ErrNotifyDisabled
+	case errors.Is(err, notify.ErrOptedOut):
+		return c.JSON(fiber.Map{"queued": false, "reason": err.Error()})
+	case err != nil:
+		logger.Get().Errorf("Failed to queue notification: %v", err)
+		return err
+	}
+
+	c.Location("/admin/jobs/" + jobID)
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"queued": true, "jobId": jobID})
+}
+
+// GetNotificationPreferences handles GET /me/notifications.
+func (h *NotificationHandler) GetNotificationPreferences(c *fiber.Ctx) error {
+	principal, _ := middleware.GetPrincipal(c)
+	return c.JSON(fiber.Map{
+		"user":  principal.Subject,
+		"email": !notify.Get().OptedOut(principal.Subject),
+	})
+}
+
+// SetNotificationPreferences handles PUT /me/notifications and lets the
+// caller opt out of (or back into) email notifications.
+func (h *NotificationHandler) SetNotificationPreferences(c *fiber.Ctx) error {
+	req := middleware.GetValidatedRequest[requests.NotificationPreferencesRequest](c)
+	principal, _ := middleware.GetPrincipal(c)
+
+	notify.Get().SetOptOut(principal.Subject, !*req.Email)
+	return c.JSON(fiber.Map{
+		"user":  principal.Subject,
+		"email": *req.Email,
+	})
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"tasks-service-demo/internal/auth"
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/jobs"
+	"tasks-service-demo/internal/middleware"
+	"tasks-service-demo/internal/notify"
+	"tasks-service-demo/internal/requests"
+	"tasks-service-demo/internal/services"
+	"tasks-service-demo/internal/storage/storetest"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type nopSender struct{}
+
+func (nopSender) Send(ctx context.Context, email notify.Email) error { return nil }
+
+func setupNotificationApp(t *testing.T, sender notify.Sender) *fiber.App {
+	t.Helper()
+	renderer, err := notify.NewRenderer(notify.DefaultTemplates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notify.Init(notify.New(sender, renderer, notify.Config{}))
+	jobs.Reset()
+	t.Cleanup(func() {
+		jobs.Get().Stop()
+		jobs.Reset()
+		notify.Reset()
+	})
+
+	store := storetest.NewFakeStore(&entities.Task{ID: 1, Name: "Review"})
+	handler := NewNotificationHandler(services.NewTaskServiceWithStore(store))
+
+	app := newTestApp()
+	app.Use(middleware.Authenticate())
+	app.Post("/admin/notifications",
+		middleware.ValidateRequest[requests.NotificationRequest](),
+		handler.SendNotification,
+	)
+	app.Get("/me/notifications", handler.GetNotificationPreferences)
+	app.Put("/me/notifications",
+		middleware.ValidateRequest[requests.NotificationPreferencesRequest](),
+		handler.SetNotificationPreferences,
+	)
+	return app
+}
+
+func sendJSON(t *testing.T, app *fiber.App, method, path, body string) (int, map[string]interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+	return resp.StatusCode, result
+}
+
+func TestSendNotification(t *testing.T) {
+	app := setupNotificationApp(t, nopSender{})
+	body := `{"kind":"reminder","taskId":1,"user":"anonymous","email":"a@example.com"}`
+
+	status, result := sendJSON(t, app, "POST", "/admin/notifications", body)
+	if status != fiber.StatusAccepted || result["queued"] != true || result["jobId"] == "" {
+		t.Errorf("Expected queued notification, got %d %v", status, result)
+	}
+
+	// The caller (anonymous, as auth is disabled) opts out
+	status, result = sendJSON(t, app, "PUT", "/me/notifications", `{"email":false}`)
+	if status != fiber.StatusOK || result["email"] != false || result["user"] != auth.Anonymous.Subject {
+		t.Errorf("Expected opt-out, got %d %v", status, result)
+	}
+	if _, result = sendJSON(t, app, "GET", "/me/notifications", ""); result["email"] != false {
+		t.Errorf("Expected opt-out to persist, got %v", result)
+	}
+
+	status, result = sendJSON(t, app, "POST", "/admin/notifications", body)
+	if status != fiber.StatusOK || result["queued"] != false {
+		t.Errorf("Expected skipped notification, got %d %v", status, result)
+	}
+}
+
+func TestSendNotification_Errors(t *testing.T) {
+	app := setupNotificationApp(t, nopSender{})
+
+	tests := []struct {
+		body     string
+		expected int
+	}{
+		{`{"kind":"digest","taskId":1,"user":"u","email":"a@example.com"}`, fiber.StatusBadRequest},
+		{`{"kind":"reminder","taskId":1,"user":"u","email":"not-an-email"}`, fiber.StatusBadRequest},
+		{`{"kind":"reminder","taskId":99,"user":"u","email":"a@example.com"}`, fiber.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if status, _ := sendJSON(t, app, "POST", "/admin/notifications", tt.body); status != tt.expected {
+			t.Errorf("Expected status %d for %s, got %d", tt.expected, tt.body, status)
+		}
+	}
+	if status, _ := sendJSON(t, app, "PUT", "/me/notifications", `{}`); status != fiber.StatusBadRequest {
+		t.Errorf("Expected status %d for missing email preference, got %d", fiber.StatusBadRequest, status)
+	}
+
+	disabled := setupNotificationApp(t, nil)
+	status, _ := sendJSON(t, disabled, "POST", "/admin/notifications", `{"kind":"reminder","taskId":1,"user":"u","email":"a@example.com"}`)
+	if status != fiber.StatusServiceUnavailable {
+		t.Errorf("Expected status %d without SMTP, got %d", fiber.StatusServiceUnavailable, status)
+	}
+}
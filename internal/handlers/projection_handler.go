@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/projection"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RebuildProjectionsResponse is the response of POST /admin/projections/rebuild
+type RebuildProjectionsResponse struct {
+	Tasks int              `json:"tasks"` // Read from the store
+	Stats projection.Stats `json:"stats"`
+}
+
+// GetProjections handles GET /admin/projections and returns the derived
+// read models with how far they trail the writes
+func GetProjections(c *fiber.Ctx) error {
+	return c.JSON(projection.Get().Stats(true))
+}
+
+// RebuildProjections handles POST /admin/projections/rebuild and rebuilds
+// the read models from the store within the request
+func RebuildProjections(c *fiber.Ctx) error {
+	e := projection.Get()
+	if !e.Enabled() {
+		return apperrors.ErrProjectionsOff
+	}
+	n := e.Rebuild()
+	e.CatchUp()
+	return c.JSON(RebuildProjectionsResponse{Tasks: n, Stats: e.Stats(true)})
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"time"
+
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/middleware"
+	"tasks-service-demo/internal/requests"
+	"tasks-service-demo/internal/retention"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RetentionStatus is the response of GET /admin/retention
+type RetentionStatus struct {
+	Enabled      bool            `json:"enabled"`
+	Window       string          `json:"window"`
+	MaxRevisions int             `json:"maxRevisions"`
+	Interval     string          `json:"interval"`
+	DryRun       bool            `json:"dryRun"`
+	Stats        retention.Stats `json:"stats"`
+}
+
+// GetRetention handles GET /admin/retention and returns the retention policy
+// and how many records are retained and purged.
+func GetRetention(c *fiber.Ctx) error {
+	bin := retention.Get()
+	cfg := bin.Config()
+	return c.JSON(RetentionStatus{
+		Enabled:      bin.Enabled(),
+		Window:       cfg.Window.String(),
+		MaxRevisions: cfg.MaxRevisions,
+		Interval:     cfg.Interval.String(),
+		DryRun:       cfg.DryRun,
+		Stats:        bin.Stats(),
+	})
+}
+
+// PurgeRetention handles POST /admin/retention/purge and purges retained
+// records right away, or with dryRun only reports what would be purged.
+func PurgeRetention(c *fiber.Ctx) error {
+	bin := retention.Get()
+	if !bin.Enabled() {
+		return apperrors.ErrRetentionOff
+	}
+	req := middleware.GetValidatedRequest[requests.RetentionPurgeRequest](c)
+
+	olderThan := bin.Config().Window
+	if req.OlderThanDays != nil {
+		olderThan = time.Duration(*req.OlderThanDays) * 24 * time.Hour
+	}
+	res := bin.Purge(olderThan, req.DryRun)
+	logger.Get().Infow("Retention purge triggered", "dryRun", res.DryRun, "deleted", res.Deleted, "revisions", res.Revisions, "cutoff", res.Cutoff)
+	return c.JSON(res)
+}
+
+// ListDeletedTasks handles GET /admin/retention/deleted and returns the
+// soft-deleted tasks, most recently deleted first.
+func ListDeletedTasks(c *fiber.Ctx) error {
+	bin := retention.Get()
+	if !bin.Enabled() {
+		return apperrors.ErrRetentionOff
+	}
+	return c.JSON(fiber.Map{"tasks": bin.Deleted()})
+}
+
+// GetTaskRevisions handles GET /admin/retention/revisions/:id and returns
+// the retained earlier versions of a task, newest first.
+func GetTaskRevisions(c *fiber.Ctx) error {
+	bin := retention.Get()
+	if !bin.Enabled() {
+		return apperrors.ErrRetentionOff
+	}
+	id := middleware.GetValidatedID(c)
+	return c.JSON(fiber.Map{"id": id, "revisions": bin.Revisions(id)})
+}
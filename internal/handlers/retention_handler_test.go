@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/middleware"
+	"tasks-service-demo/internal/requests"
+	"tasks-service-demo/internal/retention"
+	"tasks-service-demo/internal/storage/storetest"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func setupRetentionApp() *fiber.App {
+	app := newTestApp()
+	app.Get("/admin/retention", GetRetention)
+	app.Post("/admin/retention/purge", middleware.ValidateRequest[requests.RetentionPurgeRequest](), PurgeRetention)
+	app.Get("/admin/retention/deleted", ListDeletedTasks)
+	app.Get("/admin/retention/revisions/:id", middleware.ValidatePathID(), GetTaskRevisions)
+	return app
+}
+
+func TestRetention_Disabled(t *testing.T) {
+	retention.Reset()
+	app := setupRetentionApp()
+
+	status, result := sendJSON(t, app, "GET", "/admin/retention", "")
+	if status != fiber.StatusOK || result["enabled"] != false {
+		t.Errorf("Expected disabled retention, got %d %v", status, result)
+	}
+	status, result = sendJSON(t, app, "POST", "/admin/retention/purge", `{}`)
+	if status != fiber.StatusServiceUnavailable || result["code"] != float64(apperrors.ErrCodeRetentionOff) {
+		t.Errorf("Expected retention off, got %d %v", status, result)
+	}
+}
+
+func TestRetention_PurgeAndList(t *testing.T) {
+	bin := retention.NewBin(retention.Config{Window: time.Hour}, nil)
+	retention.Init(bin)
+	defer retention.Reset()
+	store := retention.NewStore(storetest.NewFakeStore(&entities.Task{ID: 1, Name: "a"}, &entities.Task{ID: 2, Name: "b"}), bin)
+	store.Update(1, &entities.Task{ID: 1, Name: "a2"})
+	store.Delete(2)
+	app := setupRetentionApp()
+
+	status, result := sendJSON(t, app, "GET", "/admin/retention/deleted", "")
+	if tasks, ok := result["tasks"].([]interface{}); status != fiber.StatusOK || !ok || len(tasks) != 1 {
+		t.Errorf("Expected one soft-deleted task, got %d %v", status, result)
+	}
+	status, result = sendJSON(t, app, "GET", "/admin/retention/revisions/1", "")
+	if revs, ok := result["revisions"].([]interface{}); status != fiber.StatusOK || !ok || len(revs) != 1 {
+		t.Errorf("Expected one revision, got %d %v", status, result)
+	}
+
+	// Nothing is older than the window yet
+	status, result = sendJSON(t, app, "POST", "/admin/retention/purge", `{"dryRun": true}`)
+	if status != fiber.StatusOK || result["dryRun"] != true || result["deleted"] != float64(0) {
+		t.Errorf("Expected empty dry run, got %d %v", status, result)
+	}
+
+	status, result = sendJSON(t, app, "POST", "/admin/retention/purge", `{"olderThanDays": 0}`)
+	if status != fiber.StatusOK || result["deleted"] != float64(1) || result["revisions"] != float64(1) {
+		t.Errorf("Expected everything purged, got %d %v", status, result)
+	}
+	if s := bin.Stats(); s.Deleted != 0 || s.Revisions != 0 || s.PurgedDeleted != 1 {
+		t.Errorf("Unexpected stats after purge %+v", s)
+	}
+
+	if status, _ := sendJSON(t, app, "POST", "/admin/retention/purge", `{"olderThanDays": -1}`); status != fiber.StatusBadRequest {
+		t.Errorf("Expected 400 for a negative age, got %d", status)
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/middleware"
+	"tasks-service-demo/internal/requests"
+	"tasks-service-demo/internal/services"
+	"tasks-service-demo/internal/share"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ShareHandler handles HTTP requests for shareable task links.
+type ShareHandler struct {
+	service *services.TaskService
+}
+
+// NewShareHandler creates a new ShareHandler with the given TaskService.
+func NewShareHandler(service *services.TaskService) *ShareHandler {
+	return &ShareHandler{service: service}
+}
+
+// CreateShareLink handles POST /tasks/:id/share and issues a signed read-only link.
+func (h *ShareHandler) CreateShareLink(c *fiber.Ctx) error {
+	id := middleware.GetValidatedID(c)
+	req := middleware.GetValidatedRequest[requests.CreateShareLinkRequest](c)
+
+	signer := share.Get()
+	if !signer.Enabled() {
+		return apperrors.ErrShareDisabled
+	}
+
+	if _, err := h.service.GetTaskByID(id); err != nil {
+		return err
+	}
+
+	link, err := signer.Issue(id, time.Duration(req.TTLSeconds)*time.Second)
+	if err != nil {
+		logger.Get().Errorf("Failed to issue share link: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"id":        link.ID,
+		"taskId":    link.TaskID,
+		"token":     link.Token,
+		"url":       c.BaseURL() + "/share/" + link.Token,
+		"expiresAt": link.ExpiresAt,
+	})
+}
+
+// GetSharedTask handles GET /share/:token and returns the task the link
+// grants access to, with the fields of ?fields= if set.
+func (h *ShareHandler) GetSharedTask(c *fiber.Ctx) error {
+	fields, fieldsErr := queryFields(c)
+	if fieldsErr != nil {
+		return fieldsErr
+	}
+	link, err := share.Get().Verify(c.Params("token"))
+	if err != nil {
+		return apperrors.ErrShareLinkInvalid
+	}
+
+	task, appErr := h.service.GetTaskByID(link.TaskID)
+	if appErr != nil {
+		if appErr.Code == apperrors.ErrCodeTaskNotFound {
+			// The task was deleted after the link was issued
+			return apperrors.ErrShareLinkInvalid
+		}
+		return appErr
+	}
+
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	return sendTask(c, task, fields)
+}
+
+// RevokeShareLink handles DELETE /share/:token and revokes the link before it expires.
+func (h *ShareHandler) RevokeShareLink(c *fiber.Ctx) error {
+	if err := share.Get().Revoke(c.Params("token")); err != nil {
+		if errors.Is(err, share.ErrDisabled) || errors.Is(err, share.ErrInvalidToken) || errors.Is(err, share.ErrExpired) || errors.Is(err, share.ErrRevoked) {
+			return apperrors.ErrShareLinkInvalid
+		}
+		return apperrors.ErrStorageError.WithCause(err)
+	}
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/middleware"
+	"tasks-service-demo/internal/requests"
+	"tasks-service-demo/internal/services"
+	"tasks-service-demo/internal/share"
+	"tasks-service-demo/internal/storage/storetest"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func setupShareApp(t *testing.T) (*fiber.App, *storetest.FakeStore) {
+	t.Helper()
+	share.Init(share.New([]share.Key{{ID: "k1", Secret: "secret"}}, nil))
+	t.Cleanup(share.Reset)
+
+	store := storetest.NewFakeStore(&entities.Task{ID: 1, Name: "Shared Task"})
+	handler := NewShareHandler(services.NewTaskServiceWithStore(store))
+
+	app := newTestApp()
+	app.Post("/tasks/:id/share",
+		middleware.ValidatePathID(),
+		middleware.ValidateRequest[requests.CreateShareLinkRequest](),
+		handler.CreateShareLink,
+	)
+	app.Get("/share/:token", handler.GetSharedTask)
+	app.Delete("/share/:token", handler.RevokeShareLink)
+	return app, store
+}
+
+func createShareLink(t *testing.T, app *fiber.App, path, body string) (int, map[string]interface{}) {
+	t.Helper()
+	req := httptest.NewRequest("POST", path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+	return resp.StatusCode, result
+}
+
+func TestShareLink_Lifecycle(t *testing.T) {
+	app, _ := setupShareApp(t)
+
+	status, link := createShareLink(t, app, "/tasks/1/share", `{"ttlSeconds":60}`)
+	if status != fiber.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", fiber.StatusCreated, status)
+	}
+	token, _ := link["token"].(string)
+	if token == "" || link["url"] != "http://example.com/share/"+token {
+		t.Fatalf("Unexpected link response %v", link)
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/share/"+token, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status %d, got %d", fiber.StatusOK, resp.StatusCode)
+	}
+	var task entities.Task
+	json.NewDecoder(resp.Body).Decode(&task)
+	if task.ID != 1 || task.Name != "Shared Task" {
+		t.Errorf("Unexpected shared task %+v", task)
+	}
+
+	resp, _ = app.Test(httptest.NewRequest("DELETE", "/share/"+token, nil))
+	if resp.StatusCode != fiber.StatusNoContent {
+		t.Errorf("Expected status %d on revoke, got %d", fiber.StatusNoContent, resp.StatusCode)
+	}
+
+	resp, _ = app.Test(httptest.NewRequest("GET", "/share/"+token, nil))
+	if resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("Expected revoked link to return %d, got %d", fiber.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func TestShareLink_Errors(t *testing.T) {
+	app, store := setupShareApp(t)
+
+	if status, _ := createShareLink(t, app, "/tasks/99/share", `{}`); status != fiber.StatusNotFound {
+		t.Errorf("Expected status %d for missing task, got %d", fiber.StatusNotFound, status)
+	}
+	if status, _ := createShareLink(t, app, "/tasks/1/share", `{"ttlSeconds":-1}`); status != fiber.StatusBadRequest {
+		t.Errorf("Expected status %d for invalid ttl, got %d", fiber.StatusBadRequest, status)
+	}
+
+	resp, _ := app.Test(httptest.NewRequest("GET", "/share/forged.token", nil))
+	if resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("Expected status %d for forged token, got %d", fiber.StatusNotFound, resp.StatusCode)
+	}
+	var errResp apperrors.ErrorResponse
+	json.NewDecoder(resp.Body).Decode(&errResp)
+	if errResp.Code != apperrors.ErrCodeShareInvalid {
+		t.Errorf("Expected error code %d, got %d", apperrors.ErrCodeShareInvalid, errResp.Code)
+	}
+
+	// Deleting the task invalidates its links
+	_, link := createShareLink(t, app, "/tasks/1/share", `{}`)
+	store.Delete(1)
+	resp, _ = app.Test(httptest.NewRequest("GET", "/share/"+link["token"].(string), nil))
+	if resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("Expected status %d for deleted task, got %d", fiber.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func TestShareLink_Disabled(t *testing.T) {
+	app, _ := setupShareApp(t)
+	share.Reset()
+
+	status, result := createShareLink(t, app, "/tasks/1/share", `{}`)
+	if status != fiber.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", fiber.StatusServiceUnavailable, status)
+	}
+	if code, _ := result["code"].(float64); int(code) != apperrors.ErrCodeShareDisabled {
+		t.Errorf("Expected error code %d, got %v", apperrors.ErrCodeShareDisabled, result["code"])
+	}
+}
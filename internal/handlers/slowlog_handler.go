@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"strconv"
+
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/slowlog"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SlowLogResponse is the response of GET /admin/slowlog
+type SlowLogResponse struct {
+	Enabled     bool            `json:"enabled"`
+	ThresholdMs float64         `json:"thresholdMs"`
+	Size        int             `json:"size"`    // Ring capacity
+	Dropped     int64           `json:"dropped"` // Entries overwritten or reset away
+	Entries     []slowlog.Entry `json:"entries"`
+}
+
+// GetSlowLog handles GET /admin/slowlog?limit=<n> and returns the latest
+// store calls slower than the threshold, newest first.
+func GetSlowLog(c *fiber.Ctx) error {
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		n, err := strconv.Atoi(limitStr)
+		if err != nil || n < 1 {
+			return apperrors.ErrInvalidQuery.WithMessage("limit must be a positive integer")
+		}
+		limit = n
+	}
+
+	log := slowlog.Get()
+	cfg := log.Config()
+	_, dropped := log.Stats()
+	return c.JSON(SlowLogResponse{
+		Enabled:     log.Enabled(),
+		ThresholdMs: float64(cfg.Threshold.Microseconds()) / 1000,
+		Size:        cfg.Size,
+		Dropped:     dropped,
+		Entries:     log.Entries(limit),
+	})
+}
+
+// ResetSlowLog handles DELETE /admin/slowlog and empties the slow log.
+func ResetSlowLog(c *fiber.Ctx) error {
+	held, _ := slowlog.Get().Stats()
+	slowlog.Get().Reset()
+	logger.Get().Infow("Slow log reset", "entries", held)
+	return c.SendStatus(fiber.StatusNoContent)
+}
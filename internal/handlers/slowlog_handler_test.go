@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/slowlog"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSlowLog(t *testing.T) {
+	log := slowlog.New(slowlog.Config{Threshold: 5 * time.Millisecond, Size: 4})
+	slowlog.Init(log)
+	defer slowlog.Reset()
+	for id := 1; id <= 3; id++ {
+		log.Observe(slowlog.Entry{Op: slowlog.OpGet, TaskID: id}, 10*time.Millisecond)
+	}
+
+	app := newTestApp()
+	app.Get("/admin/slowlog", GetSlowLog)
+	app.Delete("/admin/slowlog", ResetSlowLog)
+
+	resp, _ := app.Test(httptest.NewRequest("GET", "/admin/slowlog?limit=2", nil))
+	var body SlowLogResponse
+	json.NewDecoder(resp.Body).Decode(&body)
+	if resp.StatusCode != fiber.StatusOK || !body.Enabled || body.ThresholdMs != 5 || body.Size != 4 {
+		t.Errorf("Expected the slow log settings, got %d %+v", resp.StatusCode, body)
+	}
+	if len(body.Entries) != 2 || body.Entries[0].TaskID != 3 || body.Entries[0].DurationMicros != 10000 {
+		t.Errorf("Expected the 2 newest entries, got %+v", body.Entries)
+	}
+
+	status, result := sendJSON(t, app, "GET", "/admin/slowlog?limit=0", "")
+	if status != fiber.StatusBadRequest || result["code"] != float64(apperrors.ErrCodeInvalidQuery) {
+		t.Errorf("Expected an invalid limit to be rejected, got %d %v", status, result)
+	}
+
+	resp, _ = app.Test(httptest.NewRequest("DELETE", "/admin/slowlog", nil))
+	if resp.StatusCode != fiber.StatusNoContent {
+		t.Errorf("Expected 204 on reset, got %d", resp.StatusCode)
+	}
+	if held, dropped := log.Stats(); held != 0 || dropped != 3 {
+		t.Errorf("Expected an empty slow log, got %d held, %d dropped", held, dropped)
+	}
+}
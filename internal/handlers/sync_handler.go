@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"strconv"
+
+	"tasks-service-demo/internal/changes"
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/middleware"
+	"tasks-service-demo/internal/reconcile"
+	"tasks-service-demo/internal/requests"
+	"tasks-service-demo/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	DefaultSyncLimit = 1000  // Changes consumed per page when ?limit is omitted
+	MaxSyncLimit     = 10000 // Upper bound for ?limit
+)
+
+// SyncHandler serves cursor-based delta sync for offline clients.
+type SyncHandler struct {
+	service *services.TaskService
+	feed    *changes.Feed
+}
+
+// NewSyncHandler creates a new SyncHandler reading from feed.
+func NewSyncHandler(service *services.TaskService, feed *changes.Feed) *SyncHandler {
+	return &SyncHandler{service: service, feed: feed}
+}
+
+// SyncResponse is one page of changes since a cursor.
+// Reset is true when the cursor is older than the change journal, or was
+// issued before the service restarted; the client must then replace its copy
+// with GET /tasks and continue from Cursor.
+type SyncResponse struct {
+	changes.Delta
+	Tasks   []*entities.Task `json:"tasks,omitempty"`
+	Cursor  string           `json:"cursor"`
+	HasMore bool             `json:"hasMore"`
+	Reset   bool             `json:"reset"`
+}
+
+// GetSync handles GET /sync?cursor=<cursor>&limit=<n>&include=tasks and returns
+// the created, updated and deleted task IDs since cursor. An empty cursor
+// starts from the beginning of the journal.
+func (h *SyncHandler) GetSync(c *fiber.Ctx) error {
+	limit := DefaultSyncLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		n, err := strconv.Atoi(limitStr)
+		if err != nil || n < 1 {
+			return apperrors.ErrInvalidQuery.WithMessage("limit must be a positive integer")
+		}
+		limit = min(n, MaxSyncLimit)
+	}
+
+	list, current, complete, err := h.feed.SinceCursor(c.Query("cursor"))
+	if err != nil {
+		return apperrors.ErrInvalidQuery.WithMessage(err.Error())
+	}
+	resp := SyncResponse{Reset: !complete}
+	if !complete {
+		// Missed changes cannot be reconstructed: resync from a full listing
+		resp.Delta = changes.Compact(nil)
+		resp.Cursor = h.feed.Cursor(current)
+		return c.JSON(resp)
+	}
+
+	next := current
+	if len(list) > limit {
+		list = list[:limit]
+		next = list[len(list)-1].Revision
+		resp.HasMore = true
+	}
+	resp.Delta = changes.Compact(list)
+	resp.Cursor = h.feed.Cursor(next)
+
+	if c.Query("include") == "tasks" {
+		resp.Tasks = make([]*entities.Task, 0, len(resp.Created)+len(resp.Updated))
+		for _, ids := range [][]int{resp.Created, resp.Updated} {
+			for _, id := range ids {
+				// Tasks deleted after the page was cut show up as deletes on the next page
+				if task, err := h.service.GetTaskByID(id); err == nil {
+					resp.Tasks = append(resp.Tasks, task)
+				}
+			}
+		}
+	}
+
+	return c.JSON(resp)
+}
+
+// PushSyncResponse reports the outcome of each pushed mutation, in request
+// order, and the cursor to pull from next.
+type PushSyncResponse struct {
+	Results []reconcile.Result `json:"results"`
+	Cursor  string             `json:"cursor"`
+}
+
+// PostSync handles POST /sync and applies a batch of offline mutations.
+// Conflicts are reported per mutation rather than failing the batch, so the
+// response is 200 even when some mutations lost.
+func (h *SyncHandler) PostSync(c *fiber.Ctx) error {
+	req := middleware.GetValidatedRequest[requests.SyncRequest](c)
+
+	results := reconcile.Get().Apply(req.Mutations)
+	return c.JSON(PushSyncResponse{
+		Results: results,
+		Cursor:  h.feed.Cursor(h.feed.Revision()),
+	})
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"tasks-service-demo/internal/changes"
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/middleware"
+	"tasks-service-demo/internal/reconcile"
+	"tasks-service-demo/internal/requests"
+	"tasks-service-demo/internal/services"
+	"tasks-service-demo/internal/storage/storetest"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func setupSyncApp(history int) (*fiber.App, *changes.TrackingStore, *changes.Feed) {
+	feed := changes.NewFeed(history)
+	store := changes.NewTrackingStore(storetest.NewFakeStore(), feed)
+
+	app := newTestApp()
+	app.Get("/sync", NewSyncHandler(services.NewTaskServiceWithStore(store), feed).GetSync)
+	return app, store, feed
+}
+
+func getSync(t *testing.T, app *fiber.App, query string) (int, SyncResponse) {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest("GET", "/sync"+query, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body SyncResponse
+	json.NewDecoder(resp.Body).Decode(&body)
+	return resp.StatusCode, body
+}
+
+func TestGetSync_Incremental(t *testing.T) {
+	app, store, _ := setupSyncApp(100)
+
+	a, b := &entities.Task{Name: "a"}, &entities.Task{Name: "b"}
+	store.Create(a)
+	store.Create(b)
+
+	status, first := getSync(t, app, "")
+	if status != fiber.StatusOK {
+		t.Fatalf("Expected status %d, got %d", fiber.StatusOK, status)
+	}
+	if !reflect.DeepEqual(first.Created, []int{a.ID, b.ID}) || first.Reset || first.HasMore {
+		t.Errorf("Unexpected initial sync %+v", first)
+	}
+
+	store.Update(a.ID, &entities.Task{Name: "a2"})
+	store.Delete(b.ID)
+
+	_, second := getSync(t, app, "?include=tasks&cursor="+first.Cursor)
+	if !reflect.DeepEqual(second.Updated, []int{a.ID}) || !reflect.DeepEqual(second.Deleted, []int{b.ID}) {
+		t.Errorf("Unexpected delta %+v", second)
+	}
+	if len(second.Tasks) != 1 || second.Tasks[0].Name != "a2" {
+		t.Errorf("Expected updated task body, got %+v", second.Tasks)
+	}
+
+	_, third := getSync(t, app, "?cursor="+second.Cursor)
+	if len(third.Created)+len(third.Updated)+len(third.Deleted) != 0 || third.Cursor != second.Cursor {
+		t.Errorf("Expected empty delta with unchanged cursor, got %+v", third)
+	}
+}
+
+func TestGetSync_Paging(t *testing.T) {
+	app, store, _ := setupSyncApp(100)
+	for i := 0; i < 5; i++ {
+		store.Create(&entities.Task{Name: "task"})
+	}
+
+	var ids []int
+	cursor := ""
+	for page := 0; page < 5; page++ {
+		_, body := getSync(t, app, "?limit=2&cursor="+cursor)
+		ids = append(ids, body.Created...)
+		cursor = body.Cursor
+		if !body.HasMore {
+			break
+		}
+	}
+
+	if !reflect.DeepEqual(ids, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("Expected all 5 tasks across pages, got %v", ids)
+	}
+}
+
+func TestGetSync_ResetWhenJournalWrapped(t *testing.T) {
+	app, store, feed := setupSyncApp(2)
+	for i := 0; i < 5; i++ {
+		store.Create(&entities.Task{Name: "task"})
+	}
+
+	_, body := getSync(t, app, "?cursor="+feed.Cursor(1))
+	if !body.Reset || body.Cursor != feed.Cursor(5) {
+		t.Errorf("Expected reset with cursor at revision 5, got %+v", body)
+	}
+}
+
+func TestGetSync_ResetAfterRestart(t *testing.T) {
+	app, store, feed := setupSyncApp(10)
+	store.Create(&entities.Task{Name: "task"})
+
+	// Cursors of the previous process count revisions of its feed, whether
+	// behind or ahead of the new one
+	for _, cursor := range []string{changes.NewFeed(10).Cursor(0), changes.NewFeed(10).Cursor(7), "djE6MA"} {
+		_, body := getSync(t, app, "?cursor="+cursor)
+		if !body.Reset || body.Cursor != feed.Cursor(1) || len(body.Created) != 0 {
+			t.Errorf("Expected reset for cursor %s, got %+v", cursor, body)
+		}
+	}
+}
+
+func TestGetSync_InvalidQuery(t *testing.T) {
+	app, _, _ := setupSyncApp(10)
+
+	for _, query := range []string{"?cursor=bogus", "?limit=0", "?limit=x"} {
+		if status, _ := getSync(t, app, query); status != fiber.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", fiber.StatusBadRequest, query, status)
+		}
+	}
+}
+
+func TestPostSync(t *testing.T) {
+	feed := changes.NewFeed(100)
+	store := changes.NewTrackingStore(storetest.NewFakeStore(), feed)
+	reconcile.Init(reconcile.New(reconcile.PolicyLWW, store, nil))
+	defer reconcile.Reset()
+
+	existing := &entities.Task{Name: "server"}
+	store.Create(existing)
+
+	app := newTestApp()
+	app.Post("/sync",
+		middleware.ValidateRequest[requests.SyncRequest](),
+		NewSyncHandler(services.NewTaskServiceWithStore(store), feed).PostSync,
+	)
+
+	body := `{"mutations":[
+		{"op":"create","tempId":"t1","name":"offline","timestamp":1},
+		{"op":"update","id":1,"status":1,"timestamp":1}
+	]}`
+	req := httptest.NewRequest("POST", "/sync", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status %d, got %d", fiber.StatusOK, resp.StatusCode)
+	}
+
+	var out PushSyncResponse
+	json.NewDecoder(resp.Body).Decode(&out)
+	if len(out.Results) != 2 {
+		t.Fatalf("Expected 2 results, got %+v", out.Results)
+	}
+	if out.Results[0].Status != reconcile.StatusApplied || out.Results[0].TempID != "t1" || out.Results[0].ID != 2 {
+		t.Errorf("Expected create mapped to ID 2, got %+v", out.Results[0])
+	}
+	if out.Results[1].Status != reconcile.StatusApplied {
+		t.Errorf("Expected unstamped task to accept the update, got %+v", out.Results[1])
+	}
+	if out.Cursor != feed.Cursor(3) {
+		t.Errorf("Expected cursor at revision 3, got %s", out.Cursor)
+	}
+}
+
+func TestPostSync_InvalidBody(t *testing.T) {
+	app := newTestApp()
+	app.Post("/sync",
+		middleware.ValidateRequest[requests.SyncRequest](),
+		NewSyncHandler(nil, changes.NewFeed(10)).PostSync,
+	)
+
+	for _, body := range []string{
+		`{"mutations":[]}`,
+		`{"mutations":[{"op":"move","id":1,"timestamp":1}]}`,
+		`{"mutations":[{"op":"update","name":"x","timestamp":1}]}`,
+		`{"mutations":[{"op":"delete","id":1}]}`,
+	} {
+		req := httptest.NewRequest("POST", "/sync", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", fiber.StatusBadRequest, body, resp.StatusCode)
+		}
+	}
+}
@@ -1,57 +1,389 @@
 package handlers
 
 import (
+	"bufio"
+	"encoding/json"
+	"math"
+	"mime"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"tasks-service-demo/internal/changes"
+	"tasks-service-demo/internal/coalesce"
+	"tasks-service-demo/internal/entities"
 	apperrors "tasks-service-demo/internal/errors"
 	"tasks-service-demo/internal/middleware"
+	"tasks-service-demo/internal/reconcile"
 	"tasks-service-demo/internal/requests"
 	"tasks-service-demo/internal/services"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/streaming"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 // Package handlers provides HTTP handlers for the Task API.
 
+const (
+	DefaultRangeLimit = 1000  // Tasks returned per ID range query when ?limit is omitted
+	MaxRangeLimit     = 10000 // Upper bound for ?limit on ID range queries
+)
+
+// NDJSONContentType is the media type of newline-delimited JSON: one value
+// per line
+const NDJSONContentType = "application/x-ndjson"
+
+// ndjsonBatch is the number of tasks read from the store per chunk of a
+// GET /tasks stream
+const ndjsonBatch = 1000
+
+// Stream kinds of the streaming metrics
+const (
+	StreamTasks = "tasks" // GET /tasks as NDJSON
+	StreamCDC   = "cdc"   // GET /cdc
+)
+
 // TaskHandler handles HTTP requests for task operations.
 type TaskHandler struct {
-	service *services.TaskService
+	service   *services.TaskService
+	listCache *coalesce.Group // Shared GET /tasks response; nil when disabled
+	listFeed  *changes.Feed   // Writes to the store; nil when only handler writes invalidate listCache
+
+	listMu  sync.Mutex // Guards listRev
+	listRev uint64     // Feed revision listCache was last checked against
+}
+
+// TaskHandlerOption configures optional TaskHandler behaviour.
+type TaskHandlerOption func(*TaskHandler)
+
+// WithListCoalescing serves GET /tasks from a response body shared for ttl,
+// computed once for all concurrent requests and dropped on every write.
+// Writes through the handler drop it, and so does any change recorded in
+// feed, which sees the writes made past the handler (sync, imports, jobs,
+// evictions); a nil feed leaves those hidden until ttl expires. A ttl <= 0
+// disables coalescing.
+func WithListCoalescing(ttl time.Duration, feed *changes.Feed) TaskHandlerOption {
+	return func(h *TaskHandler) {
+		if ttl > 0 {
+			h.listCache = coalesce.New(ttl)
+			h.listFeed = feed
+			if feed != nil {
+				h.listRev = feed.Revision()
+			}
+		}
+	}
 }
 
 // NewTaskHandler creates a new TaskHandler with the given TaskService.
-func NewTaskHandler(service *services.TaskService) *TaskHandler {
-	return &TaskHandler{service: service}
+func NewTaskHandler(service *services.TaskService, opts ...TaskHandlerOption) *TaskHandler {
+	h := &TaskHandler{service: service}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
-// GetAllTasks handles GET /tasks and returns all tasks.
+// GetAllTasks handles GET /tasks and returns all tasks. With ?from= or ?to=
+// it returns the tasks in that inclusive ID range instead; see getTaskRange.
+// Every form takes ?fields=; see queryFields.
 func (h *TaskHandler) GetAllTasks(c *fiber.Ctx) error {
-	tasks := h.service.GetAllTasks()
-	return c.JSON(tasks)
+	fields, appErr := queryFields(c)
+	if appErr != nil {
+		return appErr
+	}
+	if c.Query("from") != "" || c.Query("to") != "" {
+		return h.getTaskRange(c, fields)
+	}
+	if c.Query("cursor") != "" || c.Query("limit") != "" {
+		return h.getTaskPage(c, fields)
+	}
+	if acceptsNDJSON(c) {
+		return h.streamTasks(c, fields)
+	}
+
+	// Strong reads must not get a response computed before a write, and the
+	// shared response holds every field
+	if h.listCache == nil || storage.ConsistencyFrom(c.UserContext()) == storage.Strong || fields != entities.AllTaskFields {
+		tasks, err := h.service.GetAllTasksContext(c.UserContext())
+		if err != nil {
+			return err
+		}
+		return sendTasks(c, tasks, fields)
+	}
+
+	// The shared load serves every waiter, so it is not bound to this request's deadline
+	h.syncList()
+	body, err := h.listCache.Get(func() ([]byte, error) {
+		return json.Marshal(h.service.GetAllTasks())
+	})
+	if err != nil {
+		return err
+	}
+	if err := storage.ContextError(c.UserContext()); err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(body)
+}
+
+// getTaskPage handles GET /tasks?cursor=<cursor>&limit=<n> and returns a
+// page of tasks. Sharded stores list one shard at a time, so the order is
+// not the order of IDs, but a page costs about its own size however many
+// tasks are stored. The cursor of the next page is in the X-Next-Cursor
+// header, which is absent on the last page.
+func (h *TaskHandler) getTaskPage(c *fiber.Ctx, fields entities.TaskFields) error {
+	cursor, err := storage.DecodePageCursor(c.Query("cursor"))
+	if err != nil {
+		return apperrors.ErrInvalidQuery.WithMessage(err.Error())
+	}
+	limit, appErr := queryLimit(c)
+	if appErr != nil {
+		return appErr
+	}
+
+	tasks, next, more, appErr := h.service.GetTaskPageContext(c.UserContext(), cursor, limit)
+	if appErr != nil {
+		return appErr
+	}
+	if more {
+		c.Set(middleware.NextCursorHeader, next.Encode())
+	}
+	return sendTasks(c, tasks, fields)
+}
+
+// streamTasks handles GET /tasks with Accept: application/x-ndjson. It
+// writes one task per line while paging through the store (see
+// storage.GetPage), one shard at a time on sharded stores, so neither side
+// holds the whole listing, and a slow client slows the stream down. Like a
+// paged listing, it sees writes made while it runs only if they land past
+// its position. The stream is not bound to the route timeout; it ends early
+// on shutdown, when the client goes away or when a chunk takes longer than
+// the write timeout (see streaming.Guard).
+func (h *TaskHandler) streamTasks(c *fiber.Ctx, fields entities.TaskFields) error {
+	// Cancelled on server shutdown
+	ctx := c.Context()
+	conn := c.Context().Conn()
+	c.Set(fiber.HeaderContentType, NDJSONContentType)
+	c.Context().SetBodyStreamWriter(func(bw *bufio.Writer) {
+		w := streaming.Get().Writer(StreamTasks, bw, conn)
+		defer w.Close()
+
+		var cursor storage.PageCursor
+		var buf []byte
+		for {
+			tasks, next, more, appErr := h.service.GetTaskPageContext(ctx, cursor, ndjsonBatch)
+			if appErr != nil {
+				return
+			}
+			buf = appendNDJSON(buf[:0], tasks, fields)
+			if _, err := w.Write(buf); err != nil {
+				return
+			}
+			if err := w.Flush(); err != nil || !more {
+				return
+			}
+			cursor = next
+		}
+	})
+	return nil
+}
+
+// acceptsNDJSON reports whether the request asks for NDJSON by name
+func acceptsNDJSON(c *fiber.Ctx) bool {
+	for _, mediaRange := range strings.Split(c.Get(fiber.HeaderAccept), ",") {
+		if mediaType, _, err := mime.ParseMediaType(mediaRange); err == nil && mediaType == NDJSONContentType {
+			return true
+		}
+	}
+	return false
+}
+
+// appendNDJSON appends tasks to dst one per line
+func appendNDJSON(dst []byte, tasks []*entities.Task, fields entities.TaskFields) []byte {
+	for _, task := range tasks {
+		dst = task.AppendJSON(dst, fields)
+		dst = append(dst, '\n')
+	}
+	return dst
+}
+
+// queryFields returns the task fields selected by ?fields=id,name,status,
+// every field when it is omitted
+func queryFields(c *fiber.Ctx) (entities.TaskFields, *apperrors.AppError) {
+	if !c.Request().URI().QueryArgs().Has("fields") {
+		return entities.AllTaskFields, nil
+	}
+	fields, err := entities.ParseTaskFields(c.Query("fields"))
+	if err != nil {
+		return 0, apperrors.ErrInvalidQuery.WithMessage("fields: " + err.Error())
+	}
+	return fields, nil
+}
+
+// sendTasks writes tasks with only the selected fields, as NDJSON when the
+// request accepts it. Selections are written by Task.AppendJSON, which is
+// cheaper than c.JSON on large listings; full tasks keep going through
+// c.JSON.
+func sendTasks(c *fiber.Ctx, tasks []*entities.Task, fields entities.TaskFields) error {
+	if acceptsNDJSON(c) {
+		c.Set(fiber.HeaderContentType, NDJSONContentType)
+		return c.Send(appendNDJSON(nil, tasks, fields))
+	}
+	if fields == entities.AllTaskFields {
+		return c.JSON(tasks)
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(entities.AppendTasksJSON(nil, tasks, fields))
+}
+
+// sendTask writes one task like sendTasks
+func sendTask(c *fiber.Ctx, task *entities.Task, fields entities.TaskFields) error {
+	if fields == entities.AllTaskFields {
+		return c.JSON(task)
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(task.AppendJSON(nil, fields))
+}
+
+// queryLimit returns ?limit of a range or page query, DefaultRangeLimit when
+// it is omitted and at most MaxRangeLimit
+func queryLimit(c *fiber.Ctx) (int, *apperrors.AppError) {
+	limit := DefaultRangeLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		n, err := strconv.Atoi(limitStr)
+		if err != nil || n < 1 {
+			return 0, apperrors.ErrInvalidQuery.WithMessage("limit must be a positive integer")
+		}
+		limit = min(n, MaxRangeLimit)
+	}
+	return limit, nil
+}
+
+// getTaskRange handles GET /tasks?from=<id>&to=<id>&limit=<n> and returns
+// tasks with from <= ID <= to in ID order. Crawlers page through the store by
+// repeating the query with from set to the last returned ID + 1.
+func (h *TaskHandler) getTaskRange(c *fiber.Ctx, fields entities.TaskFields) error {
+	fromID, err := queryID(c, "from", 1)
+	if err != nil {
+		return err
+	}
+	toID, err := queryID(c, "to", math.MaxInt)
+	if err != nil {
+		return err
+	}
+	if fromID > toID {
+		return apperrors.ErrInvalidQuery.WithMessage("from must not be greater than to")
+	}
+
+	limit, appErr := queryLimit(c)
+	if appErr != nil {
+		return appErr
+	}
+
+	tasks, appErr := h.service.GetTaskRangeContext(c.UserContext(), fromID, toID, limit)
+	if appErr != nil {
+		return appErr
+	}
+	return sendTasks(c, tasks, fields)
+}
+
+// queryID parses an optional positive task ID query parameter.
+func queryID(c *fiber.Ctx, key string, def int) (int, *apperrors.AppError) {
+	s := c.Query(key)
+	if s == "" {
+		return def, nil
+	}
+	id, err := strconv.Atoi(s)
+	if err != nil || id < 1 {
+		return 0, apperrors.ErrInvalidQuery.WithMessage(key + " must be a positive task ID")
+	}
+	return id, nil
+}
+
+// parseTime parses an RFC 3339 time, or a date as midnight UTC
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, s)
+}
+
+// GetThroughput handles GET /tasks/stats/throughput and returns create,
+// update and delete totals and per-minute rates.
+func (h *TaskHandler) GetThroughput(c *fiber.Ctx) error {
+	return c.JSON(h.service.Throughput())
+}
+
+// invalidateList drops the shared GET /tasks response after a write.
+func (h *TaskHandler) invalidateList() {
+	if h.listCache != nil {
+		h.listCache.Invalidate()
+	}
+}
+
+// syncList drops the shared GET /tasks response when the store changed since
+// it was last checked, whichever path made the write
+func (h *TaskHandler) syncList() {
+	if h.listFeed == nil {
+		return
+	}
+	h.listMu.Lock()
+	defer h.listMu.Unlock()
+	if rev := h.listFeed.Revision(); rev != h.listRev {
+		h.listRev = rev
+		h.listCache.Invalidate()
+	}
 }
 
 // GetTaskByID handles GET /tasks/:id and returns a task by its ID.
 func (h *TaskHandler) GetTaskByID(c *fiber.Ctx) error {
 	id := middleware.GetValidatedID(c)
+	fields, appErr := queryFields(c)
+	if appErr != nil {
+		return appErr
+	}
 
-	task, err := h.service.GetTaskByID(id)
+	task, err := h.service.GetTaskByIDContext(c.UserContext(), id)
 	if err != nil {
-		switch err.Code {
-		case apperrors.ErrCodeTaskNotFound:
-			return c.Status(fiber.StatusBadRequest).JSON(apperrors.ToResponse(err))
-		default:
-			return c.Status(fiber.StatusInternalServerError).JSON(apperrors.ErrInternalErrorResponse)
-		}
+		return err
 	}
 
-	return c.JSON(task)
+	return sendTask(c, task, fields)
+}
+
+// GetTasksByIDsResponse is the result of a batch lookup. Tasks and Missing
+// follow the order of the requested IDs.
+type GetTasksByIDsResponse struct {
+	Tasks   []*entities.Task `json:"tasks"`
+	Missing []int            `json:"missing"`
+}
+
+// GetTasksByIDs handles POST /tasks/byIds and returns the requested tasks
+// that exist and the IDs that don't, in one round trip.
+func (h *TaskHandler) GetTasksByIDs(c *fiber.Ctx) error {
+	req := middleware.GetValidatedRequest[requests.GetTasksByIDsRequest](c)
+
+	tasks, missing, err := h.service.GetTasksByIDsContext(c.UserContext(), req.IDs)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(GetTasksByIDsResponse{Tasks: tasks, Missing: missing})
 }
 
 // CreateTask handles POST /tasks and creates a new task.
 func (h *TaskHandler) CreateTask(c *fiber.Ctx) error {
 	req := middleware.GetValidatedRequest[requests.CreateTaskRequest](c)
 
-	task, err := h.service.CreateTask(&req)
+	task, err := h.service.CreateTaskContext(c.UserContext(), &req)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(apperrors.ErrInternalErrorResponse)
+		return err
 	}
+	h.invalidateList()
 
 	return c.Status(fiber.StatusCreated).JSON(task)
 }
@@ -61,16 +393,88 @@ func (h *TaskHandler) UpdateTask(c *fiber.Ctx) error {
 	id := middleware.GetValidatedID(c)
 	req := middleware.GetValidatedRequest[requests.UpdateTaskRequest](c)
 
-	task, err := h.service.UpdateTask(id, &req)
+	task, err := h.service.UpdateTaskContext(c.UserContext(), id, &req)
 	if err != nil {
-		switch err.Code {
-		case apperrors.ErrCodeTaskNotFound:
-			return c.Status(fiber.StatusBadRequest).JSON(apperrors.ToResponse(err))
-		default:
-			return c.Status(fiber.StatusInternalServerError).JSON(apperrors.ErrInternalErrorResponse)
-		}
+		return err
+	}
+	h.invalidateList()
+
+	return c.JSON(task)
+}
+
+// AddSubtask handles POST /tasks/:id/subtasks and adds an item to the
+// task's checklist.
+func (h *TaskHandler) AddSubtask(c *fiber.Ctx) error {
+	id := middleware.GetValidatedID(c)
+	req := middleware.GetValidatedRequest[requests.CreateSubtaskRequest](c)
+
+	task, err := h.service.AddSubtaskContext(c.UserContext(), id, &req)
+	if err != nil {
+		return err
+	}
+	h.invalidateList()
+
+	return c.Status(fiber.StatusCreated).JSON(task)
+}
+
+// ToggleSubtask handles POST /tasks/:id/subtasks/:subtaskId/toggle and flips
+// whether the subtask is done.
+func (h *TaskHandler) ToggleSubtask(c *fiber.Ctx) error {
+	id := middleware.GetValidatedID(c)
+	subID, err := strconv.Atoi(c.Params("subtaskId"))
+	if err != nil {
+		return apperrors.ErrInvalidID
+	}
+
+	task, appErr := h.service.ToggleSubtaskContext(c.UserContext(), id, subID)
+	if appErr != nil {
+		return appErr
+	}
+	h.invalidateList()
+
+	return c.JSON(task)
+}
+
+// RemoveSubtask handles DELETE /tasks/:id/subtasks/:subtaskId and returns the
+// task without the subtask, so clients get the new progress.
+func (h *TaskHandler) RemoveSubtask(c *fiber.Ctx) error {
+	id := middleware.GetValidatedID(c)
+	subID, err := strconv.Atoi(c.Params("subtaskId"))
+	if err != nil {
+		return apperrors.ErrInvalidID
+	}
+
+	task, appErr := h.service.RemoveSubtaskContext(c.UserContext(), id, subID)
+	if appErr != nil {
+		return appErr
+	}
+	h.invalidateList()
+
+	return c.JSON(task)
+}
+
+// MaxExternalKeyLength bounds the external_id of PUT /tasks/by-key/:external_id
+const MaxExternalKeyLength = 128
+
+// UpsertTask handles PUT /tasks/by-key/:external_id. It updates the task
+// mapped to the key with 200 OK, or creates one with 201 Created, so
+// pipelines can replay the same request safely.
+func (h *TaskHandler) UpsertTask(c *fiber.Ctx) error {
+	key, err := url.PathUnescape(c.Params("external_id"))
+	if err != nil || key == "" || len(key) > MaxExternalKeyLength {
+		return apperrors.ErrInvalidID.WithMessage("external_id must be 1-" + strconv.Itoa(MaxExternalKeyLength) + " bytes")
+	}
+	req := middleware.GetValidatedRequest[requests.UpdateTaskRequest](c)
+
+	task, created, appErr := h.service.UpsertTaskContext(c.UserContext(), key, &req)
+	if appErr != nil {
+		return appErr
 	}
+	h.invalidateList()
 
+	if created {
+		return c.Status(fiber.StatusCreated).JSON(task)
+	}
 	return c.JSON(task)
 }
 
@@ -78,11 +482,59 @@ func (h *TaskHandler) UpdateTask(c *fiber.Ctx) error {
 func (h *TaskHandler) DeleteTask(c *fiber.Ctx) error {
 	id := middleware.GetValidatedID(c)
 
-	err := h.service.DeleteTask(id)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(apperrors.ErrInternalErrorResponse)
+	if err := h.service.DeleteTaskContext(c.UserContext(), id); err != nil {
+		return err
 	}
+	h.invalidateList()
 
 	// RESTful DELETE: Always return 204 No Content for successful DELETE (idempotent)
 	return c.Status(fiber.StatusNoContent).Send(nil)
 }
+
+// DeleteTasks handles DELETE /tasks?status=<0|1>&before=<time>&dryRun=<bool>
+// and deletes every task matching the filter, returning how many it
+// deleted. At least one filter is required, so a bare DELETE /tasks cannot
+// empty the store. before is a date or an RFC 3339 time, compared with the
+// last write of each task as stamped for POST /sync (see reconcile), and
+// is refused when no StampingStore records those writes.
+func (h *TaskHandler) DeleteTasks(c *fiber.Ctx) error {
+	var filter services.TaskFilter
+	if statusStr := c.Query("status"); statusStr != "" {
+		status, err := strconv.Atoi(statusStr)
+		if err != nil || (status != 0 && status != 1) {
+			return apperrors.ErrInvalidQuery.WithMessage("status must be 0 or 1")
+		}
+		filter.Status = &status
+	}
+	if beforeStr := c.Query("before"); beforeStr != "" {
+		before, err := parseTime(beforeStr)
+		if err != nil {
+			return apperrors.ErrInvalidQuery.WithMessage("before must be a date (2006-01-02) or an RFC 3339 time")
+		}
+		reconciler := reconcile.Get()
+		if !reconciler.Stamped() {
+			return apperrors.ErrInvalidQuery.WithMessage("before needs write stamps, which this server does not record")
+		}
+		filter.Before, filter.ModifiedAt = before, reconciler.LastModified
+	}
+	if filter.Status == nil && filter.Before.IsZero() {
+		return apperrors.ErrInvalidQuery.WithMessage("at least one filter is required")
+	}
+
+	dryRun := false
+	if dryRunStr := c.Query("dryRun"); dryRunStr != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(dryRunStr); err != nil {
+			return apperrors.ErrInvalidQuery.WithMessage("dryRun must be true or false")
+		}
+	}
+
+	res, err := h.service.DeleteTasksContext(c.UserContext(), filter, dryRun)
+	if res.Deleted > 0 && !dryRun {
+		h.invalidateList()
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(res)
+}
@@ -1,28 +1,37 @@
 package handlers
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"tasks-service-demo/internal/changes"
 	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
 	"tasks-service-demo/internal/middleware"
+	"tasks-service-demo/internal/reconcile"
 	"tasks-service-demo/internal/requests"
 	"tasks-service-demo/internal/services"
-	"tasks-service-demo/internal/storage"
 	"tasks-service-demo/internal/storage/naive"
+	"tasks-service-demo/internal/storage/storetest"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// newTestApp creates an app with the production ErrorHandler
+func newTestApp() *fiber.App {
+	return fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+}
+
 func setupTestApp() (*fiber.App, *TaskHandler) {
-	app := fiber.New()
-	storage.ResetStore()
-	storage.InitStore(naive.NewMemoryStore())
-	service := services.NewTaskService()
+	app := newTestApp()
+	service := services.NewTaskServiceWithStore(storetest.NewFakeStore())
 	handler := NewTaskHandler(service)
 	return app, handler
 }
@@ -181,8 +190,8 @@ func TestGetTaskByID_NotFound(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if resp.StatusCode != fiber.StatusBadRequest {
-		t.Errorf("Expected status %d, got %d", fiber.StatusBadRequest, resp.StatusCode)
+	if resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", fiber.StatusNotFound, resp.StatusCode)
 	}
 }
 
@@ -259,8 +268,8 @@ func TestUpdateTask_NotFound(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if resp.StatusCode != fiber.StatusBadRequest {
-		t.Errorf("Expected status %d, got %d", fiber.StatusBadRequest, resp.StatusCode)
+	if resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", fiber.StatusNotFound, resp.StatusCode)
 	}
 }
 
@@ -305,3 +314,637 @@ func TestDeleteTask_NotFound(t *testing.T) {
 		t.Errorf("Expected status %d, got %d", fiber.StatusNoContent, resp.StatusCode)
 	}
 }
+
+func TestGetTaskByID_StorageError(t *testing.T) {
+	app := newTestApp()
+	mock := storetest.NewMockStore()
+	mock.GetByIDFunc = func(id int) (*entities.Task, *apperrors.AppError) {
+		return nil, apperrors.ErrStorageError
+	}
+	handler := NewTaskHandler(services.NewTaskServiceWithStore(mock))
+	app.Get("/tasks/:id", middleware.ValidatePathID(), handler.GetTaskByID)
+
+	req := httptest.NewRequest("GET", "/tasks/1", nil)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != fiber.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", fiber.StatusInternalServerError, resp.StatusCode)
+	}
+}
+
+func TestCreateTask_StorageFull(t *testing.T) {
+	app := newTestApp()
+	mock := storetest.NewMockStore()
+	mock.CreateFunc = func(task *entities.Task) *apperrors.AppError {
+		return apperrors.ErrStorageFull
+	}
+	handler := NewTaskHandler(services.NewTaskServiceWithStore(mock))
+	app.Post("/tasks", middleware.ValidateRequest[requests.CreateTaskRequest](), handler.CreateTask)
+
+	reqBody, _ := json.Marshal(requests.CreateTaskRequest{Name: "Test Task"})
+	req := httptest.NewRequest("POST", "/tasks", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != fiber.StatusInsufficientStorage {
+		t.Errorf("Expected status %d, got %d", fiber.StatusInsufficientStorage, resp.StatusCode)
+	}
+
+	var errResp apperrors.ErrorResponse
+	json.NewDecoder(resp.Body).Decode(&errResp)
+	if errResp.Code != apperrors.ErrCodeStorageFull {
+		t.Errorf("Expected error code %d, got %d", apperrors.ErrCodeStorageFull, errResp.Code)
+	}
+}
+
+func TestGetAllTasks_Coalesced(t *testing.T) {
+	app := newTestApp()
+	store := storetest.NewFakeStore()
+	handler := NewTaskHandler(services.NewTaskServiceWithStore(store), WithListCoalescing(time.Minute, nil))
+	app.Use(middleware.ReadConsistency())
+	app.Get("/tasks", handler.GetAllTasks)
+	app.Post("/tasks", middleware.ValidateRequest[requests.CreateTaskRequest](), handler.CreateTask)
+
+	listTasks := func(consistency ...string) []entities.Task {
+		req := httptest.NewRequest("GET", "/tasks", nil)
+		for _, c := range consistency {
+			req.Header.Set(middleware.ConsistencyHeader, c)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Expected Content-Type 'application/json', got '%s'", ct)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		var tasks []entities.Task
+		if err := json.Unmarshal(body, &tasks); err != nil {
+			t.Fatal(err)
+		}
+		return tasks
+	}
+
+	if tasks := listTasks(); len(tasks) != 0 {
+		t.Fatalf("Expected empty list, got %d tasks", len(tasks))
+	}
+
+	// A write through the handler invalidates the shared response
+	req := httptest.NewRequest("POST", "/tasks", bytes.NewBufferString(`{"name":"Test Task","status":0}`))
+	req.Header.Set("Content-Type", "application/json")
+	if _, err := app.Test(req); err != nil {
+		t.Fatal(err)
+	}
+	if tasks := listTasks(); len(tasks) != 1 {
+		t.Fatalf("Expected created task to be visible, got %d tasks", len(tasks))
+	}
+
+	// Writes that bypass the handler are hidden until the TTL expires
+	store.Create(&entities.Task{Name: "direct"})
+	if tasks := listTasks(); len(tasks) != 1 {
+		t.Errorf("Expected cached list of 1 task, got %d", len(tasks))
+	}
+
+	// ...except from strong reads
+	if tasks := listTasks("strong"); len(tasks) != 2 {
+		t.Errorf("Expected a fresh list of 2 tasks, got %d", len(tasks))
+	}
+}
+
+func TestGetAllTasks_CoalescedFollowsFeed(t *testing.T) {
+	app := newTestApp()
+	feed := changes.NewFeed(100)
+	store := changes.NewTrackingStore(storetest.NewFakeStore(), feed)
+	handler := NewTaskHandler(services.NewTaskServiceWithStore(store), WithListCoalescing(time.Minute, feed))
+	app.Get("/tasks", handler.GetAllTasks)
+
+	listTasks := func() []entities.Task {
+		resp, err := app.Test(httptest.NewRequest("GET", "/tasks", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tasks []entities.Task
+		if err := json.NewDecoder(resp.Body).Decode(&tasks); err != nil {
+			t.Fatal(err)
+		}
+		return tasks
+	}
+
+	store.Create(&entities.Task{Name: "a"})
+	if tasks := listTasks(); len(tasks) != 1 {
+		t.Fatalf("Expected 1 task, got %d", len(tasks))
+	}
+
+	// Writes that bypass the handler are recorded in the feed, which drops
+	// the shared response
+	store.Create(&entities.Task{Name: "direct"})
+	if tasks := listTasks(); len(tasks) != 2 {
+		t.Errorf("Expected the direct write to be visible, got %d tasks", len(tasks))
+	}
+	if stats := handler.listCache.Stats(); stats.Misses != 2 {
+		t.Errorf("Expected one load per revision, got %+v", stats)
+	}
+	listTasks()
+	if stats := handler.listCache.Stats(); stats.Hits != 1 {
+		t.Errorf("Expected the unchanged list served from the cache, got %+v", stats)
+	}
+}
+
+func TestTaskHandler_DeadlineExceeded(t *testing.T) {
+	app, handler := setupTestApp()
+	app.Use(middleware.Timeout(middleware.RouteTimeouts{
+		"GET /tasks":     time.Nanosecond,
+		"GET /tasks/:id": time.Nanosecond,
+		"POST /tasks":    time.Nanosecond,
+	}))
+	app.Get("/tasks", handler.GetAllTasks)
+	app.Get("/tasks/:id", middleware.ValidatePathID(), handler.GetTaskByID)
+	app.Post("/tasks", middleware.ValidateRequest[requests.CreateTaskRequest](), handler.CreateTask)
+
+	for _, req := range []struct{ method, path, body string }{
+		{"GET", "/tasks", ""},
+		{"GET", "/tasks/1", ""},
+		{"POST", "/tasks", `{"name":"late"}`},
+	} {
+		r := httptest.NewRequest(req.method, req.path, bytes.NewBufferString(req.body))
+		r.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusGatewayTimeout {
+			t.Errorf("Expected status %d for %s %s, got %d", fiber.StatusGatewayTimeout, req.method, req.path, resp.StatusCode)
+		}
+
+		var errResp apperrors.ErrorResponse
+		json.NewDecoder(resp.Body).Decode(&errResp)
+		if errResp.Code != apperrors.ErrCodeTimeout {
+			t.Errorf("Expected error code %d, got %d", apperrors.ErrCodeTimeout, errResp.Code)
+		}
+	}
+
+	if tasks := handler.service.GetAllTasks(); len(tasks) != 0 {
+		t.Errorf("Expected no task created after the deadline, got %d", len(tasks))
+	}
+}
+
+func TestGetAllTasks_Range(t *testing.T) {
+	app := newTestApp()
+	store := storetest.NewFakeStore()
+	for i := 0; i < 5; i++ {
+		store.Create(&entities.Task{Name: fmt.Sprintf("task %d", i+1)})
+	}
+	store.Delete(3)
+	app.Get("/tasks", NewTaskHandler(services.NewTaskServiceWithStore(store), WithListCoalescing(time.Minute, nil)).GetAllTasks)
+
+	cases := map[string][]int{
+		"?from=2&to=4":    {2, 4},
+		"?from=2":         {2, 4, 5},
+		"?to=2":           {1, 2},
+		"?from=1&limit=2": {1, 2},
+		"?from=6":         {},
+	}
+	for query, expected := range cases {
+		resp, err := app.Test(httptest.NewRequest("GET", "/tasks"+query, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Errorf("Expected status %d for %s, got %d", fiber.StatusOK, query, resp.StatusCode)
+			continue
+		}
+
+		var tasks []entities.Task
+		json.NewDecoder(resp.Body).Decode(&tasks)
+		if tasks == nil || len(tasks) != len(expected) {
+			t.Errorf("Expected IDs %v for %s, got %+v", expected, query, tasks)
+			continue
+		}
+		for i := range tasks {
+			if tasks[i].ID != expected[i] {
+				t.Errorf("Expected IDs %v for %s, got %+v", expected, query, tasks)
+				break
+			}
+		}
+	}
+}
+
+func TestGetAllTasks_InvalidRange(t *testing.T) {
+	app, handler := setupTestApp()
+	app.Get("/tasks", handler.GetAllTasks)
+
+	for _, query := range []string{"?from=0", "?from=x", "?to=-1", "?from=5&to=2", "?from=1&limit=0"} {
+		resp, err := app.Test(httptest.NewRequest("GET", "/tasks"+query, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", fiber.StatusBadRequest, query, resp.StatusCode)
+		}
+	}
+}
+
+func TestGetAllTasks_Pages(t *testing.T) {
+	app := newTestApp()
+	store := storetest.NewFakeStore()
+	for i := 0; i < 5; i++ {
+		store.Create(&entities.Task{Name: fmt.Sprintf("task %d", i+1)})
+	}
+	app.Get("/tasks", NewTaskHandler(services.NewTaskServiceWithStore(store)).GetAllTasks)
+
+	var ids []int
+	query := "?limit=2"
+	for pages := 1; ; pages++ {
+		resp, err := app.Test(httptest.NewRequest("GET", "/tasks"+query, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tasks []entities.Task
+		json.NewDecoder(resp.Body).Decode(&tasks)
+		for _, task := range tasks {
+			ids = append(ids, task.ID)
+		}
+		cursor := resp.Header.Get(middleware.NextCursorHeader)
+		if cursor == "" {
+			if pages != 3 {
+				t.Errorf("Expected 3 pages, got %d", pages)
+			}
+			break
+		}
+		query = "?limit=2&cursor=" + cursor
+	}
+	if fmt.Sprint(ids) != "[1 2 3 4 5]" {
+		t.Errorf("Expected every task once, got %v", ids)
+	}
+
+	resp, _ := app.Test(httptest.NewRequest("GET", "/tasks?cursor=bogus", nil))
+	if resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("Expected status %d for an invalid cursor, got %d", fiber.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+func TestGetTasks_Fields(t *testing.T) {
+	app := newTestApp()
+	store := storetest.NewFakeStore()
+	for i := 0; i < 3; i++ {
+		store.Create(&entities.Task{Name: fmt.Sprintf("task %d", i+1), Status: i % 2})
+	}
+	handler := NewTaskHandler(services.NewTaskServiceWithStore(store), WithListCoalescing(time.Minute, nil))
+	app.Get("/tasks", handler.GetAllTasks)
+	app.Get("/tasks/:id", middleware.ValidatePathID(), handler.GetTaskByID)
+
+	cases := map[string]string{
+		"/tasks?fields=id,status&from=1&to=3": `[{"id":1,"status":0},{"id":2,"status":1},{"id":3,"status":0}]`,
+		"/tasks?fields=name&from=2&to=2":      `[{"name":"task 2"}]`,
+		"/tasks?fields=id&limit=1":            `[{"id":1}]`,
+		"/tasks/3?fields=name,id":             `{"id":3,"name":"task 3"}`,
+	}
+	for target, expected := range cases {
+		resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != fiber.StatusOK || string(body) != expected {
+			t.Errorf("%s: expected %s, got %d %s", target, expected, resp.StatusCode, body)
+		}
+		if ct := resp.Header.Get(fiber.HeaderContentType); ct != fiber.MIMEApplicationJSON {
+			t.Errorf("%s: expected JSON, got %q", target, ct)
+		}
+	}
+
+	for _, target := range []string{"/tasks?fields=", "/tasks?fields=owner", "/tasks/1?fields=id,due"} {
+		resp, _ := app.Test(httptest.NewRequest("GET", target, nil))
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", target, fiber.StatusBadRequest, resp.StatusCode)
+		}
+	}
+}
+
+func TestGetAllTasks_NDJSON(t *testing.T) {
+	app := newTestApp()
+	store := storetest.NewFakeStore()
+	for i := 0; i < ndjsonBatch*2+5; i++ {
+		store.Create(&entities.Task{Name: fmt.Sprintf("task %d", i+1)})
+	}
+	app.Get("/tasks", NewTaskHandler(services.NewTaskServiceWithStore(store), WithListCoalescing(time.Minute, nil)).GetAllTasks)
+
+	req := httptest.NewRequest("GET", "/tasks?fields=id", nil)
+	req.Header.Set(fiber.HeaderAccept, NDJSONContentType)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Header.Get(fiber.HeaderContentType) != NDJSONContentType {
+		t.Fatalf("Expected an NDJSON stream, got %q", resp.Header.Get(fiber.HeaderContentType))
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	lines := 0
+	for scanner.Scan() {
+		lines++
+		if expected := fmt.Sprintf(`{"id":%d}`, lines); scanner.Text() != expected {
+			t.Fatalf("Expected line %s, got %s", expected, scanner.Text())
+		}
+	}
+	if lines != ndjsonBatch*2+5 {
+		t.Errorf("Expected every task on its own line, got %d lines", lines)
+	}
+
+	// Range and paged listings answer in lines too
+	req = httptest.NewRequest("GET", "/tasks?from=2&to=3", nil)
+	req.Header.Set(fiber.HeaderAccept, "application/json;q=0.5, "+NDJSONContentType)
+	resp, _ = app.Test(req)
+	body, _ := io.ReadAll(resp.Body)
+	if resp.Header.Get(fiber.HeaderContentType) != NDJSONContentType || string(body) != `{"id":2,"name":"task 2","status":0}`+"\n"+`{"id":3,"name":"task 3","status":0}`+"\n" {
+		t.Errorf("Expected the range as lines, got %s", body)
+	}
+}
+
+func TestGetTasksByIDs(t *testing.T) {
+	app := newTestApp()
+	store := storetest.NewFakeStore()
+	for i := 0; i < 4; i++ {
+		store.Create(&entities.Task{Name: fmt.Sprintf("task %d", i+1)})
+	}
+	store.Delete(2)
+	app.Post("/tasks/byIds",
+		middleware.ValidateRequest[requests.GetTasksByIDsRequest](),
+		NewTaskHandler(services.NewTaskServiceWithStore(store)).GetTasksByIDs,
+	)
+
+	req := httptest.NewRequest("POST", "/tasks/byIds", bytes.NewBufferString(`{"ids":[4,2,1,9,4]}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status %d, got %d", fiber.StatusOK, resp.StatusCode)
+	}
+	var result GetTasksByIDsResponse
+	json.NewDecoder(resp.Body).Decode(&result)
+	if len(result.Tasks) != 2 || result.Tasks[0].ID != 4 || result.Tasks[1].ID != 1 {
+		t.Errorf("Expected tasks 4 and 1, got %+v", result.Tasks)
+	}
+	if fmt.Sprint(result.Missing) != "[2 9]" {
+		t.Errorf("Expected IDs 2 and 9 missing, got %v", result.Missing)
+	}
+
+	tooMany := make([]int, 501)
+	for i := range tooMany {
+		tooMany[i] = i + 1
+	}
+	body, _ := json.Marshal(map[string][]int{"ids": tooMany})
+	for _, body := range []string{`{}`, `{"ids":[]}`, `{"ids":[1,0]}`, string(body)} {
+		req := httptest.NewRequest("POST", "/tasks/byIds", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status %d for %.40s, got %d", fiber.StatusBadRequest, body, resp.StatusCode)
+		}
+	}
+}
+
+func TestUpsertTask(t *testing.T) {
+	app := newTestApp()
+	app.Put("/tasks/by-key/:external_id",
+		middleware.ValidateRequest[requests.UpdateTaskRequest](),
+		NewTaskHandler(services.NewTaskServiceWithStore(naive.NewMemoryStore())).UpsertTask,
+	)
+	put := func(key, body string) (int, entities.Task) {
+		req := httptest.NewRequest("PUT", "/tasks/by-key/"+key, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var task entities.Task
+		json.NewDecoder(resp.Body).Decode(&task)
+		return resp.StatusCode, task
+	}
+
+	status, created := put("crm%2F42", `{"name":"a"}`)
+	if status != fiber.StatusCreated || created.Name != "a" {
+		t.Fatalf("Expected status %d and the new task, got %d and %+v", fiber.StatusCreated, status, created)
+	}
+	status, updated := put("crm%2F42", `{"name":"b","status":1}`)
+	if status != fiber.StatusOK || updated.ID != created.ID || updated.Name != "b" {
+		t.Errorf("Expected status %d and task %d updated, got %d and %+v", fiber.StatusOK, created.ID, status, updated)
+	}
+	if status, _ := put(strings.Repeat("k", MaxExternalKeyLength+1), `{"name":"a"}`); status != fiber.StatusBadRequest {
+		t.Errorf("Expected status %d for a long key, got %d", fiber.StatusBadRequest, status)
+	}
+}
+
+func TestGetThroughput(t *testing.T) {
+	app, handler := setupTestApp()
+	app.Post("/tasks", middleware.ValidateRequest[requests.CreateTaskRequest](), handler.CreateTask)
+	app.Get("/tasks/stats/throughput", handler.GetThroughput)
+
+	req := httptest.NewRequest("POST", "/tasks", bytes.NewBufferString(`{"name":"a"}`))
+	req.Header.Set("Content-Type", "application/json")
+	if _, err := app.Test(req); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/tasks/stats/throughput", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status %d, got %d", fiber.StatusOK, resp.StatusCode)
+	}
+
+	var stats services.ThroughputStats
+	json.NewDecoder(resp.Body).Decode(&stats)
+	if stats.Total.Created != 1 || stats.Current.Created != 1 || stats.Minutes == nil {
+		t.Errorf("Expected one create, got %+v", stats)
+	}
+}
+
+func TestSubtasks(t *testing.T) {
+	app, handler := setupTestApp()
+	app.Post("/tasks", middleware.ValidateRequest[requests.CreateTaskRequest](), handler.CreateTask)
+	app.Put("/tasks/:id", middleware.ValidatePathID(), middleware.ValidateRequest[requests.UpdateTaskRequest](), handler.UpdateTask)
+	app.Post("/tasks/:id/subtasks", middleware.ValidatePathID(), middleware.ValidateRequest[requests.CreateSubtaskRequest](), handler.AddSubtask)
+	app.Post("/tasks/:id/subtasks/:subtaskId/toggle", middleware.ValidatePathID(), handler.ToggleSubtask)
+	app.Delete("/tasks/:id/subtasks/:subtaskId", middleware.ValidatePathID(), handler.RemoveSubtask)
+
+	send := func(method, path, body string, wantStatus int) entities.Task {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != wantStatus {
+			t.Fatalf("%s %s: Expected status %d, got %d: %s", method, path, wantStatus, resp.StatusCode, data)
+		}
+		var task entities.Task
+		json.Unmarshal(data, &task)
+		return task
+	}
+
+	send("POST", "/tasks", `{"name":"Release"}`, fiber.StatusCreated)
+	send("POST", "/tasks/1/subtasks", `{"name":"Tag"}`, fiber.StatusCreated)
+	task := send("POST", "/tasks/1/subtasks", `{"name":"Publish"}`, fiber.StatusCreated)
+	if len(task.Subtasks) != 2 || task.Subtasks[1].ID != 2 || task.Progress == nil || *task.Progress != 0 {
+		t.Fatalf("Expected two open subtasks, got %+v", task)
+	}
+
+	task = send("POST", "/tasks/1/subtasks/1/toggle", "", fiber.StatusOK)
+	if !task.Subtasks[0].Done || *task.Progress != 50 {
+		t.Errorf("Expected half the checklist done, got %+v", task)
+	}
+	task = send("PUT", "/tasks/1", `{"name":"Release 2","status":1}`, fiber.StatusOK)
+	if task.Name != "Release 2" || len(task.Subtasks) != 2 || *task.Progress != 50 {
+		t.Errorf("Expected an update to keep the subtasks, got %+v", task)
+	}
+	task = send("DELETE", "/tasks/1/subtasks/2", "", fiber.StatusOK)
+	if len(task.Subtasks) != 1 || *task.Progress != 100 {
+		t.Errorf("Expected the remaining subtask done, got %+v", task)
+	}
+	task = send("POST", "/tasks/1/subtasks", `{"name":"Announce"}`, fiber.StatusCreated)
+	if task.Subtasks[1].ID != 2 {
+		t.Errorf("Expected IDs to continue after the highest in use, got %+v", task.Subtasks)
+	}
+
+	send("POST", "/tasks/1/subtasks/9/toggle", "", fiber.StatusNotFound)
+	send("DELETE", "/tasks/1/subtasks/x", "", fiber.StatusBadRequest)
+	send("POST", "/tasks/7/subtasks", `{"name":"Orphan"}`, fiber.StatusNotFound)
+	send("POST", "/tasks/1/subtasks", `{"name":""}`, fiber.StatusBadRequest)
+	for i := len(task.Subtasks); i < entities.MaxSubtasks; i++ {
+		send("POST", "/tasks/1/subtasks", `{"name":"More"}`, fiber.StatusCreated)
+	}
+	send("POST", "/tasks/1/subtasks", `{"name":"One too many"}`, fiber.StatusBadRequest)
+}
+
+func TestDeleteTasks(t *testing.T) {
+	app, handler := setupTestApp()
+	app.Post("/tasks", middleware.ValidateRequest[requests.CreateTaskRequest](), handler.CreateTask)
+	app.Get("/tasks", handler.GetAllTasks)
+	app.Delete("/tasks", handler.DeleteTasks)
+
+	for _, body := range []string{`{"name":"a","status":1}`, `{"name":"b","status":0}`, `{"name":"c","status":1}`} {
+		req := httptest.NewRequest("POST", "/tasks", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if _, err := app.Test(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, query := range []string{"", "?status=2", "?status=1&before=yesterday", "?status=1&dryRun=maybe"} {
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/tasks"+query, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("DELETE /tasks%s: Expected status %d, got %d", query, fiber.StatusBadRequest, resp.StatusCode)
+		}
+	}
+
+	deleteTasks := func(query string) services.BulkDeleteResult {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/tasks"+query, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status %d, got %d", fiber.StatusOK, resp.StatusCode)
+		}
+		var res services.BulkDeleteResult
+		json.NewDecoder(resp.Body).Decode(&res)
+		return res
+	}
+
+	if res := deleteTasks("?status=1&dryRun=true"); res.Deleted != 2 || !res.DryRun {
+		t.Errorf("Expected a dry run counting 2, got %+v", res)
+	}
+	if res := deleteTasks("?status=1"); res.Deleted != 2 || res.DryRun {
+		t.Errorf("Expected 2 deleted, got %+v", res)
+	}
+
+	resp, _ := app.Test(httptest.NewRequest("GET", "/tasks", nil))
+	var tasks []entities.Task
+	json.NewDecoder(resp.Body).Decode(&tasks)
+	if len(tasks) != 1 || tasks[0].Name != "b" {
+		t.Errorf("Expected only the open task left, got %+v", tasks)
+	}
+}
+
+func TestDeleteTasks_BeforeWithoutStamps(t *testing.T) {
+	defer reconcile.Reset()
+	reconcile.Init(reconcile.New(reconcile.PolicyLWW, nil, nil))
+	store := storetest.NewFakeStore()
+	app := newTestApp()
+	app.Delete("/tasks", NewTaskHandler(services.NewTaskServiceWithStore(store)).DeleteTasks)
+	store.Create(&entities.Task{Name: "t"})
+
+	resp, err := app.Test(httptest.NewRequest("DELETE", "/tasks?before=2024-02-01&status=0", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("Expected 400 without a StampingStore, got %d", resp.StatusCode)
+	}
+	if _, err := store.GetByID(1); err != nil {
+		t.Errorf("Expected the task to be kept, got %v", err)
+	}
+}
+
+func TestDeleteTasks_Before(t *testing.T) {
+	defer reconcile.Reset()
+	reconcile.Init(reconcile.New(reconcile.PolicyLWW, nil, nil))
+	store := storetest.NewFakeStore()
+	// The stamps are set by hand below; the StampingStore only enables ?before
+	reconcile.NewStampingStore(store, reconcile.Get())
+	app := newTestApp()
+	app.Delete("/tasks", NewTaskHandler(services.NewTaskServiceWithStore(store)).DeleteTasks)
+	for i := 0; i < 4; i++ {
+		store.Create(&entities.Task{Name: "t", Status: i / 2})
+	}
+	// Task 4 has no stamp, so its age is unknown and it is kept
+	reconcile.Get().Touch(1, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), reconcile.FieldName)
+	reconcile.Get().Touch(2, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), reconcile.FieldName)
+	reconcile.Get().Touch(2, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), reconcile.FieldStatus)
+	reconcile.Get().Touch(3, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), reconcile.FieldStatus)
+
+	for query, expected := range map[string]int{
+		"?before=2024-02-01&dryRun=true":                    2,
+		"?before=2024-02-01T00:00:00Z&status=0&dryRun=true": 1,
+		"?before=2024-01-01&dryRun=true":                    0,
+	} {
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/tasks"+query, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var res services.BulkDeleteResult
+		json.NewDecoder(resp.Body).Decode(&res)
+		if resp.StatusCode != fiber.StatusOK || res.Deleted != expected {
+			t.Errorf("DELETE /tasks%s: Expected %d matches, got %d %+v", query, expected, resp.StatusCode, res)
+		}
+	}
+
+	resp, _ := app.Test(httptest.NewRequest("DELETE", "/tasks?before=2024-02-01", nil))
+	var res services.BulkDeleteResult
+	json.NewDecoder(resp.Body).Decode(&res)
+	if res.Deleted != 2 {
+		t.Fatalf("Expected 2 deleted, got %+v", res)
+	}
+	for id, kept := range map[int]bool{1: false, 2: true, 3: false, 4: true} {
+		if _, err := store.GetByID(id); (err == nil) != kept {
+			t.Errorf("Task %d: expected kept=%t, got %v", id, kept, err)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"tasks-service-demo/internal/usage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// UsageResponse is the response of GET /admin/usage
+type UsageResponse struct {
+	Enabled       bool              `json:"enabled"`
+	WindowSeconds float64           `json:"windowSeconds"`
+	Persistent    bool              `json:"persistent"` // Monthly counts are saved in the backend
+	Keys          []usage.KeyReport `json:"keys"`
+}
+
+// GetUsage handles GET /admin/usage?key=<id> and returns the requests of
+// each API key over the rolling window and the current month. With ?key=
+// only that key is listed.
+func GetUsage(c *fiber.Ctx) error {
+	t := usage.Get()
+	keys := t.Report()
+	if key := c.Query("key"); key != "" {
+		filtered := make([]usage.KeyReport, 0, 1)
+		for _, r := range keys {
+			if r.Key == key {
+				filtered = append(filtered, r)
+			}
+		}
+		keys = filtered
+	}
+	return c.JSON(UsageResponse{
+		Enabled:       t.Enabled(),
+		WindowSeconds: t.Config().Window.Seconds(),
+		Persistent:    t.Persistent(),
+		Keys:          keys,
+	})
+}
@@ -1,7 +1,7 @@
 package handlers
 
 import (
-	"os"
+	"tasks-service-demo/internal/buildinfo"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -10,18 +10,23 @@ import (
 
 // VersionInfo represents the version information of the API
 type VersionInfo struct {
-	Version string `json:"version"`
+	Version   string                  `json:"version"`
+	GitCommit string                  `json:"gitCommit"`
+	BuildDate string                  `json:"buildDate"`
+	GoVersion string                  `json:"goVersion"`
+	Storage   buildinfo.StorageConfig `json:"storage"`
 }
 
-// VersionHandler returns version information about the API
+// VersionHandler returns version, build and storage metadata about the API
 func VersionHandler(c *fiber.Ctx) error {
-	version := os.Getenv("APP_VERSION")
-	if version == "" {
-		version = "1.0.0" // Default semantic version
-	}
+	info := buildinfo.Get()
 
 	versionInfo := VersionInfo{
-		Version: version,
+		Version:   info.Version,
+		GitCommit: info.GitCommit,
+		BuildDate: info.BuildDate,
+		GoVersion: info.GoVersion,
+		Storage:   info.Storage,
 	}
 
 	return c.JSON(versionInfo)
@@ -5,8 +5,11 @@ import (
 	"io"
 	"net/http/httptest"
 	"os"
+	"runtime"
 	"testing"
 
+	"tasks-service-demo/internal/buildinfo"
+
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -14,7 +17,7 @@ func TestVersionHandler_DefaultVersion(t *testing.T) {
 	// Ensure APP_VERSION env var is not set
 	os.Unsetenv("APP_VERSION")
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Get("/version", VersionHandler)
 
 	req := httptest.NewRequest("GET", "/version", nil)
@@ -44,7 +47,7 @@ func TestVersionHandler_CustomVersion(t *testing.T) {
 	os.Setenv("APP_VERSION", "2.3.1")
 	defer os.Unsetenv("APP_VERSION")
 
-	app := fiber.New()
+	app := newTestApp()
 	app.Get("/version", VersionHandler)
 
 	req := httptest.NewRequest("GET", "/version", nil)
@@ -70,7 +73,7 @@ func TestVersionHandler_CustomVersion(t *testing.T) {
 }
 
 func TestVersionHandler_ResponseFormat(t *testing.T) {
-	app := fiber.New()
+	app := newTestApp()
 	app.Get("/version", VersionHandler)
 
 	req := httptest.NewRequest("GET", "/version", nil)
@@ -98,3 +101,32 @@ func TestVersionHandler_ResponseFormat(t *testing.T) {
 		t.Error("Required field 'version' missing from response")
 	}
 }
+
+func TestVersionHandler_BuildMetadata(t *testing.T) {
+	buildinfo.SetStorage("shard", 16)
+	defer buildinfo.SetStorage("", 0)
+
+	app := newTestApp()
+	app.Get("/version", VersionHandler)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/version", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	var versionInfo VersionInfo
+	if err := json.Unmarshal(body, &versionInfo); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if versionInfo.GoVersion != runtime.Version() {
+		t.Errorf("Expected Go version '%s', got '%s'", runtime.Version(), versionInfo.GoVersion)
+	}
+	if versionInfo.GitCommit == "" || versionInfo.BuildDate == "" {
+		t.Errorf("Expected git commit and build date to be populated, got %+v", versionInfo)
+	}
+	if versionInfo.Storage.Type != "shard" || versionInfo.Storage.ShardCount != 16 {
+		t.Errorf("Expected active storage config, got %+v", versionInfo.Storage)
+	}
+}
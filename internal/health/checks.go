@@ -0,0 +1,142 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/events"
+	"tasks-service-demo/internal/storage"
+)
+
+// storeProbeID is an ID no store ever assigns, so probing it is read-only
+const storeProbeID = -1
+
+// StoreCheck reports the storage backend type and verifies the global store
+// answers a strong lookup, past caches and filters, within timeout. A store
+// that fails the lookup or blocks (e.g. a stalled ChannelStore worker) is
+// reported as unhealthy.
+func StoreCheck(backend string, timeout time.Duration) CheckFunc {
+	var probe storeProbe
+	return func() CheckResult {
+		details := map[string]interface{}{"type": backend}
+
+		store := storage.GetStore()
+		if store == nil {
+			return CheckResult{Status: StatusUnhealthy, Message: "storage not initialized", Details: details}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		err, ok := probe.lookup(ctx, store)
+		switch {
+		case !ok:
+			return CheckResult{
+				Status:  StatusUnhealthy,
+				Message: fmt.Sprintf("storage did not respond within %s", timeout),
+				Details: details,
+			}
+		case err != nil && err.Code != apperrors.ErrCodeTaskNotFound:
+			return CheckResult{Status: StatusUnhealthy, Message: fmt.Sprintf("storage lookup failed: %v", err), Details: details}
+		}
+		return CheckResult{Status: StatusOK, Details: details}
+	}
+}
+
+// storeProbe runs one store lookup at a time. A lookup can't be interrupted,
+// so one that outlives its check keeps running, and later checks wait for
+// it rather than start another: a hung store holds one goroutine, not one
+// per check.
+type storeProbe struct {
+	mu       sync.Mutex
+	inFlight *probeCall
+}
+
+type probeCall struct {
+	done chan struct{}
+	err  *apperrors.AppError
+}
+
+// lookup returns the result of the lookup in flight, starting one if none
+// is; ok is false when ctx is done first
+func (p *storeProbe) lookup(ctx context.Context, store storage.Store) (err *apperrors.AppError, ok bool) {
+	p.mu.Lock()
+	call := p.inFlight
+	if call == nil {
+		call = &probeCall{done: make(chan struct{})}
+		p.inFlight = call
+		go func() {
+			_, call.err = storage.GetByIDStrong(store, storeProbeID)
+			p.mu.Lock()
+			p.inFlight = nil
+			p.mu.Unlock()
+			close(call.done)
+		}()
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.err, true
+	case <-ctx.Done():
+		return nil, false
+	}
+}
+
+// IntegrityCheck reports the startup integrity check of a durable backend.
+// Corruption that was not repaired degrades the service rather than taking
+// it down, since reads are still served; writesAllowed tells whether writes
+// were forced on despite it.
+func IntegrityCheck(result storage.Integrity, err error, writesAllowed bool) CheckFunc {
+	details := map[string]interface{}{
+		"records":       result.Records,
+		"badRecords":    result.BadRecords,
+		"maxId":         result.MaxID,
+		"writesAllowed": writesAllowed,
+	}
+	if len(result.Repairs) > 0 {
+		details["repairs"] = result.Repairs
+	}
+	if len(result.Problems) > 0 {
+		details["problems"] = result.Problems
+	}
+
+	check := CheckResult{Status: StatusOK, Details: details}
+	switch {
+	case err != nil:
+		check.Status, check.Message = StatusDegraded, fmt.Sprintf("integrity check did not complete: %v", err)
+	case !result.OK():
+		check.Status, check.Message = StatusDegraded, "storage failed its integrity check"
+	}
+	return func() CheckResult { return check }
+}
+
+// EventBusCheck reports the event bus behind the outbox of task events. The
+// bus is failing while the outbox can't publish, or while its oldest
+// pending event has waited longer than maxLag; writes go on meanwhile, so
+// register it as non-critical.
+func EventBusCheck(driver string, outbox func() events.OutboxStats, maxLag time.Duration) CheckFunc {
+	return func() CheckResult {
+		stats := outbox()
+		details := map[string]interface{}{
+			"driver":    driver,
+			"pending":   stats.Pending,
+			"published": stats.Published,
+			"failures":  stats.Failures,
+			"lost":      stats.Lost,
+		}
+		switch {
+		case stats.LastError != "":
+			return CheckResult{Status: StatusUnhealthy, Message: fmt.Sprintf("publishing failed: %s", stats.LastError), Details: details}
+		case stats.Oldest != nil && time.Since(*stats.Oldest) > maxLag:
+			return CheckResult{
+				Status:  StatusUnhealthy,
+				Message: fmt.Sprintf("oldest pending event is %s old", time.Since(*stats.Oldest).Round(time.Second)),
+				Details: details,
+			}
+		}
+		return CheckResult{Status: StatusOK, Details: details}
+	}
+}
@@ -0,0 +1,155 @@
+package health
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Package health aggregates per-dependency health checks into a single report.
+
+// Status is the health state of a single check or of the whole service
+type Status string
+
+const (
+	StatusOK        Status = "ok"        // Everything is working
+	StatusDegraded  Status = "degraded"  // A non-critical dependency is failing
+	StatusUnhealthy Status = "unhealthy" // A critical dependency is failing
+)
+
+// CheckFunc probes a dependency and returns its result
+type CheckFunc func() CheckResult
+
+// CheckResult is the outcome of a single health check
+type CheckResult struct {
+	Status    Status                 `json:"status"`
+	Critical  bool                   `json:"critical"`
+	Message   string                 `json:"message,omitempty"`
+	LatencyMs float64                `json:"latencyMs"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// Report is the aggregated health of the service
+type Report struct {
+	Status        Status                 `json:"status"`
+	Message       string                 `json:"message"`
+	Ready         bool                   `json:"ready"`
+	Uptime        string                 `json:"uptime"`
+	UptimeSeconds int64                  `json:"uptimeSeconds"`
+	Checks        map[string]CheckResult `json:"checks"`
+}
+
+type registeredCheck struct {
+	name     string
+	critical bool
+	fn       CheckFunc
+}
+
+// Checker holds the registered checks, the service start time and readiness
+type Checker struct {
+	mu        sync.RWMutex
+	checks    []registeredCheck
+	startedAt time.Time
+	ready     atomic.Bool // Flipped once startup warm-up has completed
+}
+
+// NewChecker creates a Checker with no checks, starting its uptime clock now
+func NewChecker() *Checker {
+	return &Checker{startedAt: time.Now()}
+}
+
+// Register adds or replaces a named check. A failing critical check makes the
+// service unhealthy; a failing non-critical check only degrades it.
+func (c *Checker) Register(name string, critical bool, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, check := range c.checks {
+		if check.name == name {
+			c.checks[i] = registeredCheck{name: name, critical: critical, fn: fn}
+			return
+		}
+	}
+	c.checks = append(c.checks, registeredCheck{name: name, critical: critical, fn: fn})
+	sort.Slice(c.checks, func(i, j int) bool { return c.checks[i].name < c.checks[j].name })
+}
+
+// SetReady marks whether the service should receive traffic
+func (c *Checker) SetReady(ready bool) {
+	c.ready.Store(ready)
+}
+
+// Ready reports whether the service has completed warm-up and accepts traffic
+func (c *Checker) Ready() bool {
+	return c.ready.Load()
+}
+
+// Run executes all registered checks and aggregates them into a Report
+func (c *Checker) Run() Report {
+	c.mu.RLock()
+	checks := make([]registeredCheck, len(c.checks))
+	copy(checks, c.checks)
+	c.mu.RUnlock()
+
+	report := Report{
+		Status: StatusOK,
+		Ready:  c.Ready(),
+		Checks: make(map[string]CheckResult, len(checks)),
+	}
+
+	for _, check := range checks {
+		start := time.Now()
+		result := check.fn()
+		result.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+		result.Critical = check.critical
+		if result.Status == "" {
+			result.Status = StatusOK
+		}
+		report.Checks[check.name] = result
+
+		if result.Status == StatusOK {
+			continue
+		}
+		if check.critical && result.Status == StatusUnhealthy {
+			report.Status = StatusUnhealthy
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+
+	uptime := time.Since(c.startedAt)
+	report.Uptime = uptime.Round(time.Second).String()
+	report.UptimeSeconds = int64(uptime.Seconds())
+
+	switch report.Status {
+	case StatusOK:
+		report.Message = "Task API is running"
+	case StatusDegraded:
+		report.Message = "Task API is running in degraded mode"
+	default:
+		report.Message = "Task API is unhealthy"
+	}
+
+	return report
+}
+
+// Singleton checker for application-wide health reporting
+var (
+	instance *Checker
+	once     sync.Once
+)
+
+// Get returns the application-wide Checker
+func Get() *Checker {
+	once.Do(func() {
+		instance = NewChecker()
+	})
+	return instance
+}
+
+// Reset replaces the application-wide Checker with an empty one
+func Reset() {
+	once = sync.Once{}
+	instance = nil
+}
@@ -0,0 +1,204 @@
+package health
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/events"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/storetest"
+)
+
+func okCheck() CheckResult { return CheckResult{Status: StatusOK} }
+
+func failingCheck() CheckResult {
+	return CheckResult{Status: StatusUnhealthy, Message: "down"}
+}
+
+func TestChecker_NoChecks(t *testing.T) {
+	report := NewChecker().Run()
+
+	if report.Status != StatusOK {
+		t.Errorf("Expected status %s, got %s", StatusOK, report.Status)
+	}
+	if report.Message != "Task API is running" {
+		t.Errorf("Unexpected message '%s'", report.Message)
+	}
+	if report.Checks == nil {
+		t.Error("Expected non-nil checks map")
+	}
+}
+
+func TestChecker_StatusAggregation(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(c *Checker)
+		expected Status
+	}{
+		{"all ok", func(c *Checker) {
+			c.Register("a", true, okCheck)
+			c.Register("b", false, okCheck)
+		}, StatusOK},
+		{"non-critical failure degrades", func(c *Checker) {
+			c.Register("a", true, okCheck)
+			c.Register("b", false, failingCheck)
+		}, StatusDegraded},
+		{"critical failure is unhealthy", func(c *Checker) {
+			c.Register("a", true, failingCheck)
+			c.Register("b", false, okCheck)
+		}, StatusUnhealthy},
+		{"critical degraded stays degraded", func(c *Checker) {
+			c.Register("a", true, func() CheckResult { return CheckResult{Status: StatusDegraded} })
+		}, StatusDegraded},
+		{"unhealthy wins over degraded", func(c *Checker) {
+			c.Register("a", false, failingCheck)
+			c.Register("b", true, failingCheck)
+		}, StatusUnhealthy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChecker()
+			tt.register(c)
+			if report := c.Run(); report.Status != tt.expected {
+				t.Errorf("Expected status %s, got %s", tt.expected, report.Status)
+			}
+		})
+	}
+}
+
+func TestChecker_RegisterReplaces(t *testing.T) {
+	c := NewChecker()
+	c.Register("storage", true, failingCheck)
+	c.Register("storage", true, okCheck)
+
+	report := c.Run()
+	if len(report.Checks) != 1 || report.Status != StatusOK {
+		t.Errorf("Expected single passing check, got %+v", report)
+	}
+	if !report.Checks["storage"].Critical {
+		t.Error("Expected critical flag to be reported")
+	}
+}
+
+func TestGet_Singleton(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	if Get() != Get() {
+		t.Error("Expected Get to return the same instance")
+	}
+}
+
+func TestStoreCheck(t *testing.T) {
+	storage.ResetStore()
+	defer storage.ResetStore()
+
+	check := StoreCheck("memory", 100*time.Millisecond)
+
+	if result := check(); result.Status != StatusUnhealthy {
+		t.Errorf("Expected unhealthy without store, got %s", result.Status)
+	}
+
+	storage.InitStore(storetest.NewFakeStore())
+	result := check()
+	if result.Status != StatusOK {
+		t.Errorf("Expected ok, got %s (%s)", result.Status, result.Message)
+	}
+	if result.Details["type"] != "memory" {
+		t.Errorf("Expected backend type 'memory', got %v", result.Details["type"])
+	}
+}
+
+func TestStoreCheck_Timeout(t *testing.T) {
+	storage.ResetStore()
+	defer storage.ResetStore()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	mock := storetest.NewMockStore()
+	mock.GetByIDFunc = func(id int) (*entities.Task, *apperrors.AppError) {
+		<-release
+		return nil, apperrors.ErrTaskNotFound
+	}
+	storage.InitStore(mock)
+
+	check := StoreCheck("channel", 20*time.Millisecond)
+	for i := 0; i < 3; i++ {
+		if result := check(); result.Status != StatusUnhealthy {
+			t.Errorf("Expected unhealthy on timeout, got %s", result.Status)
+		}
+	}
+	if calls := len(mock.Calls()); calls != 1 {
+		t.Errorf("Expected checks to wait for the hung lookup rather than start more, got %d lookups", calls)
+	}
+}
+
+func TestStoreCheck_Errors(t *testing.T) {
+	storage.ResetStore()
+	defer storage.ResetStore()
+
+	mock := storetest.NewMockStore()
+	mock.GetByIDFunc = func(id int) (*entities.Task, *apperrors.AppError) {
+		return nil, apperrors.ErrStorageError.WithCause(errors.New("disk I/O error"))
+	}
+	storage.InitStore(mock)
+
+	result := StoreCheck("badger", time.Second)()
+	if result.Status != StatusUnhealthy || !strings.Contains(result.Message, "disk I/O error") {
+		t.Errorf("Expected a failed lookup to be unhealthy, got %+v", result)
+	}
+}
+
+func TestChecker_Readiness(t *testing.T) {
+	c := NewChecker()
+	if c.Ready() || c.Run().Ready {
+		t.Error("Expected checker to start not ready")
+	}
+
+	c.SetReady(true)
+	if !c.Ready() || !c.Run().Ready {
+		t.Error("Expected checker to be ready after SetReady(true)")
+	}
+}
+
+func TestIntegrityCheck(t *testing.T) {
+	repaired := storage.Integrity{Records: 3, MaxID: 3, Repairs: []string{"rebuilt the index"}}
+	if result := IntegrityCheck(repaired, nil, false)(); result.Status != StatusOK || result.Details["repairs"] == nil {
+		t.Errorf("Expected repaired store to be ok with its repairs listed, got %+v", result)
+	}
+
+	corrupt := storage.Integrity{Records: 3, BadRecords: 1}
+	corrupt.Problemf("slot %d fails its checksum", 2)
+	result := IntegrityCheck(corrupt, nil, false)()
+	if result.Status != StatusDegraded || result.Details["writesAllowed"] != false {
+		t.Errorf("Expected corrupt store to degrade with writes refused, got %+v", result)
+	}
+}
+
+func TestEventBusCheck(t *testing.T) {
+	var stats events.OutboxStats
+	check := EventBusCheck("nats", func() events.OutboxStats { return stats }, time.Minute)
+
+	recent := time.Now()
+	stats = events.OutboxStats{Pending: 2, Published: 10, Oldest: &recent}
+	if result := check(); result.Status != StatusOK || result.Details["pending"] != 2 {
+		t.Errorf("Expected a short backlog to be ok, got %+v", result)
+	}
+
+	stale := time.Now().Add(-2 * time.Minute)
+	stats.Oldest = &stale
+	if result := check(); result.Status != StatusUnhealthy || !strings.Contains(result.Message, "oldest pending event") {
+		t.Errorf("Expected a stale backlog to fail, got %+v", result)
+	}
+
+	stats = events.OutboxStats{Pending: 1, Failures: 3, LastError: "nats: no responders available"}
+	if result := check(); result.Status != StatusUnhealthy || !strings.Contains(result.Message, "no responders") {
+		t.Errorf("Expected failing publishes to fail the check, got %+v", result)
+	}
+}
@@ -0,0 +1,47 @@
+package importer
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// GitHubIssues imports the JSON array returned by the GitHub REST API
+// (GET /repos/{owner}/{repo}/issues?state=all). Pull requests are skipped.
+type GitHubIssues struct{}
+
+type githubIssue struct {
+	ID          int64           `json:"id"`
+	Title       string          `json:"title"`
+	State       string          `json:"state"`
+	PullRequest json.RawMessage `json:"pull_request"`
+}
+
+func init() {
+	Register(GitHubIssues{})
+}
+
+// Source returns "github"
+func (GitHubIssues) Source() string {
+	return "github"
+}
+
+// Parse maps open issues to incomplete tasks and closed ones to completed tasks
+func (GitHubIssues) Parse(data []byte) ([]Item, error) {
+	var issues []githubIssue
+	if err := json.Unmarshal(data, &issues); err != nil {
+		return nil, err
+	}
+
+	items := make([]Item, 0, len(issues))
+	for _, issue := range issues {
+		if len(issue.PullRequest) > 0 && string(issue.PullRequest) != "null" {
+			continue
+		}
+		items = append(items, Item{
+			ExternalID: strconv.FormatInt(issue.ID, 10),
+			Name:       issue.Title,
+			Done:       issue.State == "closed",
+		})
+	}
+	return items, nil
+}
@@ -0,0 +1,244 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/jobs"
+	"tasks-service-demo/internal/storage"
+)
+
+// Package importer maps items from external todo systems to tasks. Each
+// source has a Connector that parses its export format; imports run as
+// background jobs and are deduplicated by external ID.
+
+// MaxNameLength matches the task name limit enforced by the API
+const MaxNameLength = 100
+
+// keyPrefix sets import keys apart from keys upserted through the API
+const keyPrefix = "import/"
+
+// Item is one external todo item
+type Item struct {
+	ExternalID string // Stable ID in the source system
+	Name       string
+	Done       bool
+}
+
+// Connector parses one source's export format into items
+type Connector interface {
+	Source() string
+	Parse(data []byte) ([]Item, error)
+}
+
+var (
+	connectorsMu sync.RWMutex
+	connectors   = make(map[string]Connector)
+)
+
+// Register makes a connector available under its source name
+func Register(c Connector) {
+	connectorsMu.Lock()
+	defer connectorsMu.Unlock()
+	connectors[c.Source()] = c
+}
+
+// Lookup returns the connector for source
+func Lookup(source string) (Connector, bool) {
+	connectorsMu.RLock()
+	defer connectorsMu.RUnlock()
+	c, ok := connectors[source]
+	return c, ok
+}
+
+// Sources lists registered source names in sorted order
+func Sources() []string {
+	connectorsMu.RLock()
+	defer connectorsMu.RUnlock()
+	sources := make([]string, 0, len(connectors))
+	for source := range connectors {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// Summary counts what an import did with each item
+type Summary struct {
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	Skipped   int `json:"skipped"` // Items without an ID or name
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("created %d, updated %d, unchanged %d, skipped %d", s.Created, s.Updated, s.Unchanged, s.Skipped)
+}
+
+// Importer writes items to the store, mapping each external ID to the task
+// it was imported as so re-imports update instead of duplicating. The
+// mapping lives in the backend's key index, so it survives restarts with a
+// durable backend; backends without one get an index in process memory.
+type Importer struct {
+	st storage.Store // Explicit store; nil falls back to the global singleton
+
+	mu    sync.Mutex // Serializes imports
+	local localKeys  // Key index for backends that keep none
+}
+
+// New creates an Importer; a nil store uses the global store
+func New(store storage.Store) *Importer {
+	return &Importer{st: store, local: localKeys{ids: make(map[string]int)}}
+}
+
+func (im *Importer) store() storage.Store {
+	if im.st != nil {
+		return im.st
+	}
+	return storage.GetStore()
+}
+
+// Import creates or updates a task for each item. A task deleted since the
+// previous import is created again.
+func (im *Importer) Import(ctx context.Context, source string, items []Item, p *jobs.Progress) (Summary, error) {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+
+	index, ok := storage.FindKeyIndex(im.store())
+	if !ok {
+		index = &im.local
+	}
+
+	var summary Summary
+	p.SetTotal(int64(len(items)))
+	for _, item := range items {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+
+		name := truncate(strings.TrimSpace(item.Name), MaxNameLength)
+		if item.ExternalID == "" || name == "" {
+			summary.Skipped++
+			p.Add(1)
+			continue
+		}
+		status := 0
+		if item.Done {
+			status = 1
+		}
+
+		key := keyPrefix + source + "/" + item.ExternalID
+		var writeErr error
+		err := index.WithKey(key, func(id int) (int, *apperrors.AppError) {
+			if id != 0 {
+				current, err := im.store().GetByID(id)
+				switch {
+				case err == nil && current.Name == name && current.Status == status:
+					summary.Unchanged++
+					return id, nil
+				case err == nil:
+					// The checklist is local to this service, so it is kept
+					task := &entities.Task{ID: id, Name: name, Status: status}
+					task.SetSubtasks(current.Subtasks)
+					if err := im.store().Update(id, task); err != nil {
+						writeErr = fmt.Errorf("update task %d for %s: %w", id, key, err)
+						return 0, err
+					}
+					summary.Updated++
+					return id, nil
+				case err.Code != apperrors.ErrCodeTaskNotFound:
+					writeErr = fmt.Errorf("read task %d for %s: %w", id, key, err)
+					return 0, err
+				}
+			}
+
+			task := &entities.Task{Name: name, Status: status}
+			if err := im.store().Create(task); err != nil {
+				writeErr = fmt.Errorf("create task for %s: %w", key, err)
+				return 0, err
+			}
+			summary.Created++
+			return task.ID, nil
+		})
+		if writeErr != nil {
+			return summary, writeErr
+		}
+		if err != nil {
+			return summary, fmt.Errorf("key index for %s: %w", key, err)
+		}
+		p.Add(1)
+	}
+	return summary, nil
+}
+
+// Preallocate makes room in the store for n tasks when its backend supports
+// it, so a large import does not rehash maps along the way
+func (im *Importer) Preallocate(n int) {
+	if prealloc, ok := storage.FindPreallocator(im.store()); ok {
+		prealloc.Preallocate(n)
+	}
+}
+
+// Job returns a jobs.Func that imports items and reports the summary
+func (im *Importer) Job(source string, items []Item) jobs.Func {
+	return func(ctx context.Context, p *jobs.Progress) error {
+		summary, err := im.Import(ctx, source, items, p)
+		p.SetMessage(summary.String())
+		return err
+	}
+}
+
+// localKeys is a storage.KeyIndex in process memory, for backends that
+// keep no external keys. It is lost on restart, so re-importing into such
+// a backend after one creates the tasks again.
+type localKeys struct {
+	mu  sync.Mutex
+	ids map[string]int
+}
+
+func (k *localKeys) WithKey(key string, write func(id int) (int, *apperrors.AppError)) *apperrors.AppError {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	next, err := write(k.ids[key])
+	if err != nil {
+		return err
+	}
+	k.ids[key] = next
+	return nil
+}
+
+// truncate shortens s to at most n runes
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+var current atomic.Pointer[Importer]
+
+func init() {
+	Reset()
+}
+
+// Get returns the process-wide Importer
+func Get() *Importer {
+	return current.Load()
+}
+
+// Init replaces the process-wide Importer
+func Init(im *Importer) {
+	current.Store(im)
+}
+
+// Reset installs an Importer on the global store
+func Reset() {
+	current.Store(New(nil))
+}
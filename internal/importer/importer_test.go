@@ -0,0 +1,176 @@
+package importer
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/jobs"
+	"tasks-service-demo/internal/storage/storetest"
+	"tasks-service-demo/internal/storage/xsync"
+)
+
+func TestImport_DedupsByExternalID(t *testing.T) {
+	store := storetest.NewFakeStore()
+	im := New(store)
+	ctx := context.Background()
+
+	first := []Item{
+		{ExternalID: "1", Name: "Write docs"},
+		{ExternalID: "2", Name: "Fix bug"},
+		{ExternalID: "", Name: "no id"},
+		{ExternalID: "3", Name: "   "},
+	}
+	var p jobs.Progress
+	summary, err := im.Import(ctx, "github", first, &p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (Summary{Created: 2, Skipped: 2}); summary != expected {
+		t.Errorf("Expected %+v, got %+v", expected, summary)
+	}
+	if done, total, _ := p.Snapshot(); done != 4 || total != 4 {
+		t.Errorf("Expected progress 4/4, got %d/%d", done, total)
+	}
+
+	// Same IDs again: issue 2 was closed upstream, issue 1 was deleted locally
+	store.Delete(1)
+	second := []Item{
+		{ExternalID: "1", Name: "Write docs"},
+		{ExternalID: "2", Name: "Fix bug", Done: true},
+		{ExternalID: "2", Name: "Fix bug", Done: true},
+	}
+	summary, err = im.Import(ctx, "github", second, &jobs.Progress{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (Summary{Created: 1, Updated: 1, Unchanged: 1}); summary != expected {
+		t.Errorf("Expected %+v, got %+v", expected, summary)
+	}
+	if task, _ := store.GetByID(2); task.Status != 1 {
+		t.Errorf("Expected task 2 completed, got %+v", task)
+	}
+
+	// The same external ID from another source is a different item
+	summary, _ = im.Import(ctx, "todoist", []Item{{ExternalID: "2", Name: "Fix bug"}}, &jobs.Progress{})
+	if summary.Created != 1 || store.Len() != 3 {
+		t.Errorf("Expected a new task for another source, got %+v with %d tasks", summary, store.Len())
+	}
+}
+
+func TestImport_KeepsSubtasks(t *testing.T) {
+	store := storetest.NewFakeStore()
+	im := New(store)
+	ctx := context.Background()
+
+	im.Import(ctx, "github", []Item{{ExternalID: "1", Name: "Release"}}, &jobs.Progress{})
+	task, _ := store.GetByID(1)
+	task.SetSubtasks([]entities.Subtask{{ID: 1, Name: "Tag"}, {ID: 2, Name: "Announce", Done: true}})
+	store.Update(1, task)
+
+	summary, err := im.Import(ctx, "github", []Item{{ExternalID: "1", Name: "Release 2", Done: true}}, &jobs.Progress{})
+	if err != nil || summary.Updated != 1 {
+		t.Fatalf("Expected the task updated, got %+v, %v", summary, err)
+	}
+	task, _ = store.GetByID(1)
+	if task.Name != "Release 2" || task.Status != 1 || len(task.Subtasks) != 2 || !task.Subtasks[1].Done {
+		t.Errorf("Expected the re-import to keep the checklist, got %+v", task)
+	}
+}
+
+type preallocStore struct {
+	*storetest.FakeStore
+	room int
+}
+
+func (s *preallocStore) Preallocate(n int) { s.room += n }
+
+func TestImport_DedupsAcrossImporters(t *testing.T) {
+	// The backend's key index outlives the Importer, as it outlives the
+	// process with a durable backend
+	store := xsync.NewXSyncStore()
+	ctx := context.Background()
+	items := []Item{{ExternalID: "1", Name: "Write docs"}, {ExternalID: "2", Name: "Fix bug"}}
+
+	New(store).Import(ctx, "github", items, &jobs.Progress{})
+	summary, err := New(store).Import(ctx, "github", items, &jobs.Progress{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (Summary{Unchanged: 2}); summary != expected || len(store.GetAll()) != 2 {
+		t.Errorf("Expected %+v with 2 tasks, got %+v with %d", expected, summary, len(store.GetAll()))
+	}
+}
+
+func TestImporter_Preallocate(t *testing.T) {
+	store := &preallocStore{FakeStore: storetest.NewFakeStore()}
+	New(store).Preallocate(500)
+	if store.room != 500 {
+		t.Errorf("Expected room for 500 tasks, got %d", store.room)
+	}
+
+	// Stores without the hook are left alone
+	New(storetest.NewFakeStore()).Preallocate(500)
+}
+
+func TestImport_TruncatesLongNames(t *testing.T) {
+	store := storetest.NewFakeStore()
+	New(store).Import(context.Background(), "github", []Item{{ExternalID: "1", Name: strings.Repeat("é", 150)}}, &jobs.Progress{})
+
+	task, err := store.GetByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len([]rune(task.Name)); n != MaxNameLength {
+		t.Errorf("Expected name truncated to %d runes, got %d", MaxNameLength, n)
+	}
+}
+
+func TestConnectors(t *testing.T) {
+	if !reflect.DeepEqual(Sources(), []string{"github", "todoist"}) {
+		t.Errorf("Expected github and todoist connectors, got %v", Sources())
+	}
+
+	tests := []struct {
+		source   string
+		payload  string
+		expected []Item
+	}{
+		{
+			"github",
+			`[{"id": 11, "number": 1, "title": "Bug", "state": "open"},
+			  {"id": 12, "number": 2, "title": "PR", "state": "open", "pull_request": {"url": "x"}},
+			  {"id": 13, "number": 3, "title": "Done", "state": "closed", "pull_request": null}]`,
+			[]Item{{"11", "Bug", false}, {"13", "Done", true}},
+		},
+		{
+			"todoist",
+			`{"items": [{"id": "6X7r", "content": "Milk", "checked": true},
+			            {"id": 42, "content": "Old", "checked": 0},
+			            {"id": "9", "content": "Gone", "is_deleted": 1}]}`,
+			[]Item{{"6X7r", "Milk", true}, {"42", "Old", false}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			c, _ := Lookup(tt.source)
+			items, err := c.Parse([]byte(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(items, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, items)
+			}
+		})
+	}
+
+	for source, payload := range map[string]string{"github": `{"id": 1}`, "todoist": `[]`} {
+		c, _ := Lookup(source)
+		if _, err := c.Parse([]byte(payload)); err == nil {
+			t.Errorf("Expected %s to reject %s", source, payload)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Todoist imports the "items" array of a Todoist sync export. Deleted
+// items are skipped.
+type Todoist struct{}
+
+type todoistExport struct {
+	Items *[]todoistItem `json:"items"`
+}
+
+type todoistItem struct {
+	ID        flexID   `json:"id"`
+	Content   string   `json:"content"`
+	Checked   flexBool `json:"checked"`
+	IsDeleted flexBool `json:"is_deleted"`
+}
+
+// flexID accepts string and numeric IDs, as older exports use numbers
+type flexID string
+
+func (id *flexID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*id = flexID(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("invalid id %s", data)
+	}
+	*id = flexID(n.String())
+	return nil
+}
+
+// flexBool accepts both true/false and 1/0, as older exports use integers
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case "true", "1":
+		*b = true
+	case "false", "0", "null":
+		*b = false
+	default:
+		return fmt.Errorf("invalid boolean %s", data)
+	}
+	return nil
+}
+
+func init() {
+	Register(Todoist{})
+}
+
+// Source returns "todoist"
+func (Todoist) Source() string {
+	return "todoist"
+}
+
+// Parse maps checked items to completed tasks
+func (Todoist) Parse(data []byte) ([]Item, error) {
+	var export todoistExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, err
+	}
+	if export.Items == nil {
+		return nil, fmt.Errorf("missing items array")
+	}
+
+	items := make([]Item, 0, len(*export.Items))
+	for _, item := range *export.Items {
+		if item.IsDeleted {
+			continue
+		}
+		items = append(items, Item{
+			ExternalID: string(item.ID),
+			Name:       item.Content,
+			Done:       bool(item.Checked),
+		})
+	}
+	return items, nil
+}
@@ -0,0 +1,114 @@
+package ipfilter
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+)
+
+// Package ipfilter restricts admin routes to client addresses matching CIDR
+// allow and deny lists, and counts the requests it turns away.
+
+// Rules are the CIDR lists a client address is checked against. A denied
+// address is rejected even if it is also allowed; with an allow list, every
+// address outside it is rejected.
+type Rules struct {
+	Allow []netip.Prefix `json:"allow"`
+	Deny  []netip.Prefix `json:"deny"`
+}
+
+// ParsePrefixes parses a comma-separated list of CIDRs. A bare address
+// stands for itself (/32 or /128).
+func ParsePrefixes(s string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not an IP address or CIDR", entry)
+			}
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an IP address or CIDR", entry)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// Enabled reports whether any list is configured
+func (r Rules) Enabled() bool {
+	return len(r.Allow) > 0 || len(r.Deny) > 0
+}
+
+// Allows reports whether addr passes the rules. Without rules every address
+// passes; with rules an invalid address never does.
+func (r Rules) Allows(addr netip.Addr) bool {
+	if !r.Enabled() {
+		return true
+	}
+	if !addr.IsValid() {
+		return false
+	}
+	addr = addr.Unmap()
+	if contains(r.Deny, addr) {
+		return false
+	}
+	return len(r.Allow) == 0 || contains(r.Allow, addr)
+}
+
+func contains(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	current atomic.Pointer[Rules]
+	denied  atomic.Uint64
+)
+
+func init() {
+	Reset()
+}
+
+// Get returns the active rules
+func Get() Rules {
+	return *current.Load()
+}
+
+// Set replaces the active rules
+func Set(r Rules) {
+	current.Store(&r)
+}
+
+// Reset removes all rules
+func Reset() {
+	current.Store(&Rules{})
+}
+
+// Allowed checks addr against the active rules, counting it if it is denied
+func Allowed(addr netip.Addr) bool {
+	if Get().Allows(addr) {
+		return true
+	}
+	denied.Add(1)
+	return false
+}
+
+// Denied returns how many addresses Allowed has turned away
+func Denied() uint64 {
+	return denied.Load()
+}
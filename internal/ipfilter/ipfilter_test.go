@@ -0,0 +1,65 @@
+package ipfilter
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestParsePrefixes(t *testing.T) {
+	prefixes, err := ParsePrefixes(" 10.1.2.3/8, 192.168.0.7 ,::1,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"10.0.0.0/8", "192.168.0.7/32", "::1/128"}
+	if len(prefixes) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, prefixes)
+	}
+	for i, prefix := range prefixes {
+		if prefix.String() != expected[i] {
+			t.Errorf("Expected %s, got %s", expected[i], prefix)
+		}
+	}
+	for _, invalid := range []string{"10.0.0.0/33", "localhost", "10.0.0/8"} {
+		if _, err := ParsePrefixes(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestRules_Allows(t *testing.T) {
+	allow, _ := ParsePrefixes("10.0.0.0/8")
+	deny, _ := ParsePrefixes("10.0.0.66")
+	tests := []struct {
+		name     string
+		rules    Rules
+		addr     string
+		expected bool
+	}{
+		{"no rules", Rules{}, "203.0.113.9", true},
+		{"inside allow list", Rules{Allow: allow}, "10.1.2.3", true},
+		{"outside allow list", Rules{Allow: allow}, "203.0.113.9", false},
+		{"IPv4-mapped address", Rules{Allow: allow}, "::ffff:10.1.2.3", true},
+		{"denied inside allow list", Rules{Allow: allow, Deny: deny}, "10.0.0.66", false},
+		{"deny list only", Rules{Deny: deny}, "203.0.113.9", true},
+		{"invalid address", Rules{Deny: deny}, "", false},
+	}
+	for _, tt := range tests {
+		addr, _ := netip.ParseAddr(tt.addr)
+		if got := tt.rules.Allows(addr); got != tt.expected {
+			t.Errorf("%s: expected %t, got %t", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestAllowed_CountsDenials(t *testing.T) {
+	allow, _ := ParsePrefixes("127.0.0.1")
+	Set(Rules{Allow: allow})
+	t.Cleanup(Reset)
+
+	before := Denied()
+	Allowed(netip.MustParseAddr("127.0.0.1"))
+	Allowed(netip.MustParseAddr("127.0.0.2"))
+	if got := Denied() - before; got != 1 {
+		t.Errorf("Expected 1 denial, got %d", got)
+	}
+}
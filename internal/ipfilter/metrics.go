@@ -0,0 +1,29 @@
+package ipfilter
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collector exports the denial count as a Prometheus metric
+type collector struct {
+	denied *prometheus.Desc
+}
+
+// NewCollector returns a Prometheus collector for the requests turned away
+// by the admin IP rules. Register it once.
+func NewCollector(namespace string) prometheus.Collector {
+	return &collector{
+		denied: prometheus.NewDesc(prometheus.BuildFQName(namespace, "admin", "ip_denied_total"),
+			"Admin requests rejected because of the client address.", nil, nil),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.denied
+}
+
+// Collect implements prometheus.Collector
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.denied, prometheus.CounterValue, float64(Denied()))
+}
@@ -0,0 +1,21 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Schedule returns the next activation after a given time; the zero time
+// means there is none
+type Schedule interface {
+	Next(time.Time) time.Time
+}
+
+// ParseCron parses a standard five-field cron expression (minute, hour, day
+// of month, month, day of week), or a descriptor such as @daily or
+// @every 90m. Times are in the server's time zone unless the expression
+// starts with CRON_TZ=<zone>.
+func ParseCron(spec string) (Schedule, error) {
+	return cron.ParseStandard(spec)
+}
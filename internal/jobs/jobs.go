@@ -0,0 +1,493 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tasks-service-demo/internal/logger"
+)
+
+// Package jobs runs named background jobs, on a fixed interval, on a cron
+// schedule or once on demand, and keeps their status for the admin API.
+
+// State is the lifecycle state of a job
+type State string
+
+const (
+	StateIdle      State = "idle"      // Scheduled, waiting for the next run
+	StateRunning   State = "running"   // Currently executing
+	StateSucceeded State = "succeeded" // Last run finished without error
+	StateFailed    State = "failed"    // Last run returned an error
+)
+
+// MaxFinished bounds how many finished one-off jobs are kept for listing
+const MaxFinished = 100
+
+// MaxHistory bounds the finished runs kept per job
+const MaxHistory = 20
+
+// ErrExists is returned when a recurring job name is already taken
+var ErrExists = errors.New("job already registered")
+
+// Func is the work of a job. It reports progress through p and should return
+// promptly once ctx is cancelled.
+type Func func(ctx context.Context, p *Progress) error
+
+// Progress is updated by a running job and read by the admin API
+type Progress struct {
+	mu      sync.Mutex
+	done    int64
+	total   int64
+	message string
+}
+
+// SetTotal sets the number of work items, 0 when unknown
+func (p *Progress) SetTotal(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.total = n
+}
+
+// Add marks n more work items as done
+func (p *Progress) Add(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done += n
+}
+
+// SetMessage sets a short human-readable status line
+func (p *Progress) SetMessage(msg string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.message = msg
+}
+
+func (p *Progress) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done, p.total, p.message = 0, 0, ""
+}
+
+// Snapshot returns the current progress
+func (p *Progress) Snapshot() (done, total int64, message string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.done, p.total, p.message
+}
+
+// Status is a point-in-time view of a job
+type Status struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Interval   string     `json:"interval,omitempty"` // Empty for one-off and cron jobs
+	Cron       string     `json:"cron,omitempty"`     // Cron expression of cron jobs
+	State      State      `json:"state"`
+	Runs       int        `json:"runs"`
+	Skipped    int        `json:"skipped,omitempty"` // Cron fires dropped because the previous run was still going
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	NextRunAt  *time.Time `json:"nextRunAt,omitempty"`
+	LastError  string     `json:"lastError,omitempty"`
+	Done       int64      `json:"done"`
+	Total      int64      `json:"total,omitempty"`
+	Message    string     `json:"message,omitempty"`
+}
+
+// Run is one finished execution of a job
+type Run struct {
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	State      State     `json:"state"`
+	Error      string    `json:"error,omitempty"`
+	Done       int64     `json:"done"`
+	Total      int64     `json:"total,omitempty"`
+	Message    string    `json:"message,omitempty"`
+}
+
+type job struct {
+	id       string
+	name     string
+	interval time.Duration
+	cron     string
+	sched    Schedule
+	fn       Func
+	progress Progress
+
+	// Recurring jobs run under their own context so Unschedule can stop them
+	ctx  context.Context
+	stop context.CancelFunc
+
+	mu       sync.Mutex
+	state    State
+	running  bool
+	runs     int
+	skipped  int
+	started  time.Time
+	finished time.Time
+	next     time.Time
+	lastErr  string
+	history  []Run // Newest last
+}
+
+func (j *job) recurring() bool {
+	return j.interval > 0 || j.sched != nil
+}
+
+func (j *job) status() Status {
+	j.mu.Lock()
+	s := Status{
+		ID:        j.id,
+		Name:      j.name,
+		Cron:      j.cron,
+		State:     j.state,
+		Runs:      j.runs,
+		Skipped:   j.skipped,
+		LastError: j.lastErr,
+	}
+	if j.interval > 0 {
+		s.Interval = j.interval.String()
+	}
+	if !j.started.IsZero() {
+		t := j.started
+		s.StartedAt = &t
+	}
+	if !j.finished.IsZero() {
+		t := j.finished
+		s.FinishedAt = &t
+	}
+	if !j.next.IsZero() {
+		t := j.next
+		s.NextRunAt = &t
+	}
+	j.mu.Unlock()
+
+	s.Done, s.Total, s.Message = j.progress.Snapshot()
+	return s
+}
+
+func (j *job) run(ctx context.Context) {
+	if j.begin() {
+		j.execute(ctx)
+	}
+}
+
+// begin marks the job as running. It returns false, counting a skipped run,
+// if the previous run has not finished yet.
+func (j *job) begin() bool {
+	j.mu.Lock()
+	if j.running {
+		j.skipped++
+		j.mu.Unlock()
+		return false
+	}
+	j.running = true
+	j.state = StateRunning
+	j.runs++
+	j.started = time.Now().UTC()
+	j.mu.Unlock()
+
+	j.progress.reset()
+	return true
+}
+
+// execute runs the job started by begin and records the outcome
+func (j *job) execute(ctx context.Context) {
+	err := j.fn(ctx, &j.progress)
+	done, total, message := j.progress.Snapshot()
+
+	j.mu.Lock()
+	j.running = false
+	j.finished = time.Now().UTC()
+	if err != nil {
+		j.state = StateFailed
+		j.lastErr = err.Error()
+	} else {
+		j.state = StateSucceeded
+		j.lastErr = ""
+	}
+	j.history = append(j.history, Run{
+		StartedAt:  j.started,
+		FinishedAt: j.finished,
+		State:      j.state,
+		Error:      j.lastErr,
+		Done:       done,
+		Total:      total,
+		Message:    message,
+	})
+	if over := len(j.history) - MaxHistory; over > 0 {
+		j.history = append([]Run(nil), j.history[over:]...)
+	}
+	elapsed := j.finished.Sub(j.started)
+	j.mu.Unlock()
+
+	if err != nil {
+		logger.For(logger.ModuleJobs).Errorw("Job failed", "job", j.id, "elapsed", elapsed, "error", err)
+	} else {
+		logger.For(logger.ModuleJobs).Infow("Job finished", "job", j.id, "elapsed", elapsed)
+	}
+}
+
+// Registry owns the background jobs of the process
+type Registry struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	jobs    []*job // Registration order
+	byID    map[string]*job
+	seq     int
+	started bool
+}
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Registry{ctx: ctx, cancel: cancel, byID: make(map[string]*job)}
+}
+
+// Schedule registers fn to run every interval, starting one interval after
+// Start. The job ID is its name, which must be unique.
+func (r *Registry) Schedule(name string, interval time.Duration, fn Func) error {
+	if interval <= 0 {
+		return fmt.Errorf("job %s: interval must be positive", name)
+	}
+	return r.register(&job{id: name, name: name, interval: interval, fn: fn, state: StateIdle})
+}
+
+// ScheduleCron registers fn to run at the times of a cron expression (see
+// ParseCron). The job ID is its name, which must be unique. Runs never
+// overlap: a fire while the previous run is still going is skipped.
+func (r *Registry) ScheduleCron(name, spec string, fn Func) error {
+	sched, err := ParseCron(spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+	return r.register(&job{id: name, name: name, cron: spec, sched: sched, fn: fn, state: StateIdle})
+}
+
+func (r *Registry) register(j *job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.byID[j.id]; ok {
+		return fmt.Errorf("job %s: %w", j.id, ErrExists)
+	}
+
+	j.ctx, j.stop = context.WithCancel(r.ctx)
+	r.jobs = append(r.jobs, j)
+	r.byID[j.id] = j
+	if r.started {
+		r.startLoop(j)
+	}
+	return nil
+}
+
+// Unschedule stops recurring job id, cancelling a run in progress, and
+// removes it. It returns false if there is no such recurring job.
+func (r *Registry) Unschedule(id string) bool {
+	r.mu.Lock()
+	j, ok := r.byID[id]
+	if !ok || !j.recurring() {
+		r.mu.Unlock()
+		return false
+	}
+	delete(r.byID, id)
+	for i, other := range r.jobs {
+		if other == j {
+			r.jobs = append(r.jobs[:i], r.jobs[i+1:]...)
+			break
+		}
+	}
+	r.mu.Unlock()
+
+	j.stop()
+	return true
+}
+
+// Submit runs fn once in the background and returns the new job ID
+func (r *Registry) Submit(name string, fn Func) string {
+	r.mu.Lock()
+	r.seq++
+	j := &job{id: fmt.Sprintf("%s-%d", name, r.seq), name: name, fn: fn, state: StateIdle}
+	r.jobs = append(r.jobs, j)
+	r.byID[j.id] = j
+	r.pruneLocked()
+	r.mu.Unlock()
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		j.run(r.ctx)
+	}()
+	return j.id
+}
+
+// pruneLocked drops the oldest finished one-off jobs beyond MaxFinished
+func (r *Registry) pruneLocked() {
+	finished := 0
+	for i := len(r.jobs) - 1; i >= 0; i-- {
+		j := r.jobs[i]
+		if j.recurring() {
+			continue
+		}
+		j.mu.Lock()
+		done := j.state == StateSucceeded || j.state == StateFailed
+		j.mu.Unlock()
+		if !done {
+			continue
+		}
+		if finished++; finished > MaxFinished {
+			delete(r.byID, j.id)
+			r.jobs = append(r.jobs[:i], r.jobs[i+1:]...)
+		}
+	}
+}
+
+// Start begins running scheduled jobs. Jobs scheduled later start immediately.
+func (r *Registry) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started {
+		return
+	}
+	r.started = true
+	for _, j := range r.jobs {
+		if j.recurring() {
+			r.startLoop(j)
+		}
+	}
+}
+
+func (r *Registry) startLoop(j *job) {
+	if j.sched != nil {
+		r.startCron(j)
+		return
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+		for {
+			j.mu.Lock()
+			j.next = time.Now().Add(j.interval).UTC()
+			j.mu.Unlock()
+
+			select {
+			case <-j.ctx.Done():
+				return
+			case <-ticker.C:
+				j.run(j.ctx)
+			}
+		}
+	}()
+}
+
+// startCron fires j at each activation of its schedule. Runs are started in
+// the background so a long run makes the following fires show up as skipped
+// rather than shifting the schedule.
+func (r *Registry) startCron(j *job) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		for {
+			next := j.sched.Next(time.Now())
+			if next.IsZero() {
+				return // The expression has no future activation
+			}
+			j.mu.Lock()
+			j.next = next.UTC()
+			j.mu.Unlock()
+
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-j.ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			if !j.begin() {
+				logger.For(logger.ModuleJobs).Warnw("Job still running, skipping scheduled run", "job", j.id)
+				continue
+			}
+			r.wg.Add(1)
+			go func() {
+				defer r.wg.Done()
+				j.execute(j.ctx)
+			}()
+		}
+	}()
+}
+
+// Stop cancels running jobs and waits for them to return
+func (r *Registry) Stop() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+// List returns the status of all jobs in registration order
+func (r *Registry) List() []Status {
+	r.mu.Lock()
+	jobs := make([]*job, len(r.jobs))
+	copy(jobs, r.jobs)
+	r.mu.Unlock()
+
+	statuses := make([]Status, len(jobs))
+	for i, j := range jobs {
+		statuses[i] = j.status()
+	}
+	return statuses
+}
+
+// Status returns the status of job id
+func (r *Registry) Status(id string) (Status, bool) {
+	r.mu.Lock()
+	j, ok := r.byID[id]
+	r.mu.Unlock()
+	if !ok {
+		return Status{}, false
+	}
+	return j.status(), true
+}
+
+// History returns the finished runs of job id, newest first
+func (r *Registry) History(id string) ([]Run, bool) {
+	r.mu.Lock()
+	j, ok := r.byID[id]
+	r.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	runs := make([]Run, len(j.history))
+	for i, run := range j.history {
+		runs[len(runs)-1-i] = run
+	}
+	return runs, true
+}
+
+var current atomic.Pointer[Registry]
+
+func init() {
+	Reset()
+}
+
+// Get returns the process-wide Registry
+func Get() *Registry {
+	return current.Load()
+}
+
+// Reset installs an empty, unstarted Registry
+func Reset() {
+	current.Store(NewRegistry())
+}
@@ -0,0 +1,206 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func waitFor(t *testing.T, r *Registry, id string, state State) Status {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if s, ok := r.Status(id); ok && s.State == state {
+			return s
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	s, _ := r.Status(id)
+	t.Fatalf("Expected job %s to reach %s, got %+v", id, state, s)
+	return s
+}
+
+func TestRegistry_Submit(t *testing.T) {
+	r := NewRegistry()
+	defer r.Stop()
+
+	ok := r.Submit("import", func(ctx context.Context, p *Progress) error {
+		p.SetTotal(3)
+		p.Add(3)
+		p.SetMessage("done")
+		return nil
+	})
+	failed := r.Submit("import", func(ctx context.Context, p *Progress) error {
+		return errors.New("boom")
+	})
+	if ok == failed {
+		t.Fatalf("Expected unique job IDs, got %s twice", ok)
+	}
+
+	s := waitFor(t, r, ok, StateSucceeded)
+	if s.Done != 3 || s.Total != 3 || s.Message != "done" || s.Runs != 1 || s.FinishedAt == nil {
+		t.Errorf("Unexpected status %+v", s)
+	}
+	if s := waitFor(t, r, failed, StateFailed); s.LastError != "boom" {
+		t.Errorf("Expected lastError boom, got %+v", s)
+	}
+}
+
+func TestRegistry_Schedule(t *testing.T) {
+	r := NewRegistry()
+	runs := make(chan struct{}, 10)
+	if err := r.Schedule("export", 10*time.Millisecond, func(ctx context.Context, p *Progress) error {
+		runs <- struct{}{}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Schedule("export", time.Second, nil); err == nil {
+		t.Error("Expected duplicate name to be rejected")
+	}
+	if err := r.Schedule("other", 0, nil); err == nil {
+		t.Error("Expected non-positive interval to be rejected")
+	}
+
+	if s, _ := r.Status("export"); s.State != StateIdle || s.Runs != 0 {
+		t.Errorf("Expected idle job before Start, got %+v", s)
+	}
+
+	r.Start()
+	for i := 0; i < 2; i++ {
+		select {
+		case <-runs:
+		case <-time.After(2 * time.Second):
+			t.Fatal("Expected scheduled job to run")
+		}
+	}
+	r.Stop()
+
+	s, _ := r.Status("export")
+	if s.Runs < 2 || s.Interval != "10ms" || s.NextRunAt == nil {
+		t.Errorf("Unexpected status %+v", s)
+	}
+	if list := r.List(); len(list) != 1 || list[0].ID != "export" {
+		t.Errorf("Expected one listed job, got %+v", list)
+	}
+}
+
+func TestRegistry_StopCancelsJobs(t *testing.T) {
+	r := NewRegistry()
+	id := r.Submit("slow", func(ctx context.Context, p *Progress) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	r.Stop()
+	if s, _ := r.Status(id); s.State != StateFailed || s.LastError != context.Canceled.Error() {
+		t.Errorf("Expected cancelled job, got %+v", s)
+	}
+}
+
+// everySchedule fires at a fixed sub-second period, which cron expressions can't express
+type everySchedule time.Duration
+
+func (e everySchedule) Next(t time.Time) time.Time { return t.Add(time.Duration(e)) }
+
+func TestParseCron(t *testing.T) {
+	sched, err := ParseCron("30 3 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2024, 1, 1, 4, 0, 0, 0, time.Local)
+	if next := sched.Next(from); !next.Equal(time.Date(2024, 1, 2, 3, 30, 0, 0, time.Local)) {
+		t.Errorf("Expected next day at 03:30, got %s", next)
+	}
+	for _, spec := range []string{"@daily", "@every 90m", "CRON_TZ=UTC 0 0 * * 1"} {
+		if _, err := ParseCron(spec); err != nil {
+			t.Errorf("Expected %q to parse, got %v", spec, err)
+		}
+	}
+	for _, spec := range []string{"", "* * *", "61 * * * *", "@sometimes"} {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("Expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestRegistry_ScheduleCronSkipsOverlap(t *testing.T) {
+	r := NewRegistry()
+	defer r.Stop()
+
+	release := make(chan struct{})
+	calls := 0
+	j := &job{id: "snapshot", name: "snapshot", cron: "fast", sched: everySchedule(5 * time.Millisecond), state: StateIdle,
+		fn: func(ctx context.Context, p *Progress) error {
+			calls++
+			p.SetMessage("saved")
+			if calls == 1 {
+				<-release
+			}
+			return nil
+		}}
+	if err := r.register(j); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.ScheduleCron("snapshot", "@daily", nil); !errors.Is(err, ErrExists) {
+		t.Errorf("Expected ErrExists, got %v", err)
+	}
+	if err := r.ScheduleCron("bad", "every day", nil); err == nil {
+		t.Error("Expected invalid cron expression to be rejected")
+	}
+
+	r.Start()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if s, _ := r.Status("snapshot"); s.Skipped >= 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected fires during the long run to be skipped")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(release)
+
+	deadline = time.Now().Add(2 * time.Second)
+	for {
+		if runs, _ := r.History("snapshot"); len(runs) >= 2 {
+			if runs[0].State != StateSucceeded || runs[0].Message != "saved" || runs[0].StartedAt.Before(runs[1].StartedAt) {
+				t.Errorf("Expected newest successful run first, got %+v", runs)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the schedule to resume after the long run")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if s, _ := r.Status("snapshot"); s.Cron != "fast" || s.NextRunAt == nil {
+		t.Errorf("Unexpected status %+v", s)
+	}
+}
+
+func TestRegistry_Unschedule(t *testing.T) {
+	r := NewRegistry()
+	defer r.Stop()
+	r.Start()
+
+	if err := r.ScheduleCron("cleanup", "@hourly", func(ctx context.Context, p *Progress) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	oneOff := r.Submit("import", func(ctx context.Context, p *Progress) error { return nil })
+
+	if r.Unschedule(oneOff) {
+		t.Error("Expected one-off jobs not to be unscheduled")
+	}
+	if !r.Unschedule("cleanup") {
+		t.Fatal("Expected cron job to be unscheduled")
+	}
+	if _, ok := r.Status("cleanup"); ok {
+		t.Error("Expected unscheduled job to be gone")
+	}
+	if err := r.ScheduleCron("cleanup", "@daily", func(ctx context.Context, p *Progress) error { return nil }); err != nil {
+		t.Errorf("Expected name to be free again, got %v", err)
+	}
+}
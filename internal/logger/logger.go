@@ -1,7 +1,9 @@
 package logger
 
 import (
+	"fmt"
 	"sync"
+	"sync/atomic"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -9,25 +11,167 @@ import (
 
 // Package logger provides a singleton logger instance using Uber's zap.
 
+// Modules with their own runtime-adjustable level
+const (
+	ModuleHTTP    = "http"    // Request and payload logging
+	ModuleStorage = "storage" // Store errors and warm-up
+	ModuleJobs    = "jobs"    // Background jobs, exports and notification retries
+)
+
+// Modules lists the known module names, in name order
+var Modules = []string{ModuleHTTP, ModuleJobs, ModuleStorage}
+
 var (
 	// once ensures the logger is only initialized once.
 	once sync.Once
 	// instance holds the singleton zap.SugaredLogger.
 	instance *zap.SugaredLogger
+	// modules holds one logger per module, tagged with a "module" field.
+	modules map[string]*zap.SugaredLogger
+
+	// level is the root level, shared by Get and modules without an override.
+	level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	// overrides holds per-module levels; nil follows the root level.
+	overrides = func() map[string]*atomic.Pointer[zapcore.Level] {
+		m := make(map[string]*atomic.Pointer[zapcore.Level], len(Modules))
+		for _, name := range Modules {
+			m[name] = &atomic.Pointer[zapcore.Level]{}
+		}
+		return m
+	}()
 )
 
 // Get returns a singleton SugaredLogger instance for application-wide logging.
 // The logger is configured with production settings and ISO8601 time encoding.
 func Get() *zap.SugaredLogger {
-	once.Do(func() {
-		cfg := zap.NewProductionConfig()
-		cfg.EncoderConfig.TimeKey = "ts"
-		cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-		log, err := cfg.Build()
-		if err != nil {
-			panic(err)
-		}
-		instance = log.Sugar()
-	})
+	once.Do(build)
+	return instance
+}
+
+// For returns the logger of module, which honours the module's level.
+// Unknown modules get the root logger.
+func For(module string) *zap.SugaredLogger {
+	once.Do(build)
+	if log, ok := modules[module]; ok {
+		return log
+	}
 	return instance
 }
+
+func build() {
+	cfg := zap.NewProductionConfig()
+	cfg.EncoderConfig.TimeKey = "ts"
+	cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	// The core accepts everything; levelCore wrappers do the filtering
+	cfg.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	base, err := cfg.Build()
+	if err != nil {
+		panic(err)
+	}
+
+	instance = base.WithOptions(wrap(level)).Sugar()
+	modules = make(map[string]*zap.SugaredLogger, len(Modules))
+	for _, name := range Modules {
+		enabler := moduleLevel{override: overrides[name]}
+		modules[name] = base.WithOptions(wrap(enabler)).With(zap.String("module", name)).Sugar()
+	}
+}
+
+// ParseLevel parses one of debug, info, warn or error
+func ParseLevel(s string) (zapcore.Level, error) {
+	switch s {
+	case "debug":
+		return zapcore.DebugLevel, nil
+	case "info":
+		return zapcore.InfoLevel, nil
+	case "warn":
+		return zapcore.WarnLevel, nil
+	case "error":
+		return zapcore.ErrorLevel, nil
+	default:
+		return 0, fmt.Errorf("unknown log level %q (want debug, info, warn or error)", s)
+	}
+}
+
+// Level returns the root level
+func Level() zapcore.Level {
+	return level.Level()
+}
+
+// SetLevel changes the root level
+func SetLevel(l zapcore.Level) {
+	level.SetLevel(l)
+}
+
+// ModuleLevel returns the level of module and whether it overrides the root level
+func ModuleLevel(module string) (zapcore.Level, bool) {
+	override, ok := overrides[module]
+	if !ok {
+		return level.Level(), false
+	}
+	if l := override.Load(); l != nil {
+		return *l, true
+	}
+	return level.Level(), false
+}
+
+// SetModuleLevel overrides the level of module; nil makes it follow the root level again
+func SetModuleLevel(module string, l *zapcore.Level) error {
+	override, ok := overrides[module]
+	if !ok {
+		return fmt.Errorf("unknown log module %q", module)
+	}
+	if l != nil {
+		copied := *l
+		l = &copied
+	}
+	override.Store(l)
+	return nil
+}
+
+// ResetLevels restores info as the root level and clears all module overrides
+func ResetLevels() {
+	level.SetLevel(zapcore.InfoLevel)
+	for _, override := range overrides {
+		override.Store(nil)
+	}
+}
+
+// moduleLevel enables a level per the module override, or the root level without one
+type moduleLevel struct {
+	override *atomic.Pointer[zapcore.Level]
+}
+
+func (m moduleLevel) Enabled(l zapcore.Level) bool {
+	if override := m.override.Load(); override != nil {
+		return override.Enabled(l)
+	}
+	return level.Enabled(l)
+}
+
+// levelCore filters an all-levels core with a dynamic LevelEnabler
+type levelCore struct {
+	zapcore.Core
+	level zapcore.LevelEnabler
+}
+
+func wrap(level zapcore.LevelEnabler) zap.Option {
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &levelCore{Core: core, level: level}
+	})
+}
+
+func (c *levelCore) Enabled(l zapcore.Level) bool {
+	return c.level.Enabled(l)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.level.Enabled(ent.Level) {
+		return c.Core.Check(ent, ce)
+	}
+	return ce
+}
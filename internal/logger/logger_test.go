@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
 )
 
 func TestGet_Bootstrap(t *testing.T) {
@@ -17,3 +18,59 @@ func TestGet_Bootstrap(t *testing.T) {
 		logger.Error("test error")
 	})
 }
+
+func TestSetLevel(t *testing.T) {
+	defer ResetLevels()
+
+	if Get().Desugar().Core().Enabled(zapcore.DebugLevel) {
+		t.Error("Expected debug disabled at the default info level")
+	}
+
+	SetLevel(zapcore.DebugLevel)
+	if !Get().Desugar().Core().Enabled(zapcore.DebugLevel) {
+		t.Error("Expected debug enabled after SetLevel(debug)")
+	}
+	if !For(ModuleStorage).Desugar().Core().Enabled(zapcore.DebugLevel) {
+		t.Error("Expected modules without an override to follow the root level")
+	}
+}
+
+func TestSetModuleLevel(t *testing.T) {
+	defer ResetLevels()
+
+	warn := zapcore.WarnLevel
+	if err := SetModuleLevel(ModuleHTTP, &warn); err != nil {
+		t.Fatal(err)
+	}
+	if For(ModuleHTTP).Desugar().Core().Enabled(zapcore.InfoLevel) {
+		t.Error("Expected info disabled for http at warn")
+	}
+	if !For(ModuleJobs).Desugar().Core().Enabled(zapcore.InfoLevel) {
+		t.Error("Expected other modules unaffected")
+	}
+	if l, overridden := ModuleLevel(ModuleHTTP); l != zapcore.WarnLevel || !overridden {
+		t.Errorf("Expected http override warn, got %v %v", l, overridden)
+	}
+
+	SetModuleLevel(ModuleHTTP, nil)
+	if l, overridden := ModuleLevel(ModuleHTTP); l != zapcore.InfoLevel || overridden {
+		t.Errorf("Expected http to follow root info, got %v %v", l, overridden)
+	}
+
+	if err := SetModuleLevel("db", &warn); err == nil {
+		t.Error("Expected unknown module to be rejected")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, s := range []string{"debug", "info", "warn", "error"} {
+		if l, err := ParseLevel(s); err != nil || l.String() != s {
+			t.Errorf("Expected %s, got %v, %v", s, l, err)
+		}
+	}
+	for _, s := range []string{"", "fatal", "INFO"} {
+		if _, err := ParseLevel(s); err == nil {
+			t.Errorf("Expected %q to be rejected", s)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package maintenance
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Package maintenance holds the process-wide maintenance mode state.
+
+// Mode is the current maintenance mode
+type Mode string
+
+const (
+	ModeOff      Mode = "off"       // Normal operation
+	ModeReadOnly Mode = "read_only" // Reads are served, writes are rejected with 503
+	ModeFull     Mode = "full"      // All task API traffic is rejected with 503
+)
+
+// DefaultRetryAfter is the Retry-After hint used when none is configured
+const DefaultRetryAfter = 60 * time.Second
+
+// State describes the active maintenance mode
+type State struct {
+	Mode              Mode      `json:"mode"`
+	RetryAfterSeconds int       `json:"retryAfterSeconds,omitempty"`
+	Reason            string    `json:"reason,omitempty"`
+	Since             time.Time `json:"since,omitempty"`
+}
+
+// Enabled reports whether any maintenance mode is active
+func (s State) Enabled() bool {
+	return s.Mode != ModeOff
+}
+
+// RetryAfter returns the Retry-After hint for rejected requests
+func (s State) RetryAfter() time.Duration {
+	if s.RetryAfterSeconds <= 0 {
+		return DefaultRetryAfter
+	}
+	return time.Duration(s.RetryAfterSeconds) * time.Second
+}
+
+var current atomic.Pointer[State]
+
+func init() {
+	Reset()
+}
+
+// Get returns the active maintenance state
+func Get() State {
+	return *current.Load()
+}
+
+// Set switches the maintenance mode. Switching to ModeOff clears all other fields.
+func Set(state State) {
+	if state.Mode == "" || state.Mode == ModeOff {
+		Reset()
+		return
+	}
+	if state.Since.IsZero() {
+		state.Since = time.Now().UTC()
+	}
+	current.Store(&state)
+}
+
+// Reset turns maintenance mode off
+func Reset() {
+	current.Store(&State{Mode: ModeOff})
+}
@@ -0,0 +1,42 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDefaultStateIsOff(t *testing.T) {
+	Reset()
+
+	state := Get()
+	if state.Mode != ModeOff || state.Enabled() {
+		t.Errorf("Expected maintenance off by default, got %+v", state)
+	}
+}
+
+func TestSet(t *testing.T) {
+	defer Reset()
+
+	Set(State{Mode: ModeReadOnly, RetryAfterSeconds: 30, Reason: "snapshot restore"})
+	state := Get()
+	if state.Mode != ModeReadOnly || !state.Enabled() {
+		t.Errorf("Expected read-only mode, got %+v", state)
+	}
+	if state.Since.IsZero() {
+		t.Error("Expected Since to be set")
+	}
+	if state.RetryAfter() != 30*time.Second {
+		t.Errorf("Expected 30s retry-after, got %s", state.RetryAfter())
+	}
+
+	Set(State{Mode: ModeOff, Reason: "ignored"})
+	if state := Get(); state.Enabled() || state.Reason != "" {
+		t.Errorf("Expected switching off to clear state, got %+v", state)
+	}
+}
+
+func TestRetryAfterDefault(t *testing.T) {
+	if got := (State{Mode: ModeFull}).RetryAfter(); got != DefaultRetryAfter {
+		t.Errorf("Expected default retry-after %s, got %s", DefaultRetryAfter, got)
+	}
+}
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"tasks-service-demo/internal/buildinfo"
+)
+
+// Package metrics owns the Prometheus registry and the /metrics endpoint.
+
+// Namespace prefixes every metric exported by the service
+const Namespace = "tasks_service"
+
+var (
+	once     sync.Once
+	registry *prometheus.Registry
+
+	buildInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "build_info",
+		Help:      "Build metadata of the running binary; the value is always 1.",
+	}, []string{"version", "git_commit", "build_date", "go_version", "storage_type"})
+)
+
+// Registry returns the application-wide Prometheus registry, which already
+// includes the Go runtime and process collectors.
+func Registry() *prometheus.Registry {
+	once.Do(func() {
+		registry = prometheus.NewRegistry()
+		registry.MustRegister(
+			collectors.NewGoCollector(),
+			collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+			buildInfo,
+		)
+	})
+	return registry
+}
+
+// SetBuildInfo publishes the build_info gauge for the given metadata
+func SetBuildInfo(info buildinfo.Info) {
+	Registry()
+	buildInfo.Reset()
+	buildInfo.WithLabelValues(info.Version, info.GitCommit, info.BuildDate, info.GoVersion, info.Storage.Type).Set(1)
+}
+
+// Handler serves the registry in the Prometheus text exposition format
+func Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.HandlerFor(Registry(), promhttp.HandlerOpts{}))
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"tasks-service-demo/internal/buildinfo"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestHandler_BuildInfo(t *testing.T) {
+	SetBuildInfo(buildinfo.Info{
+		Version:   "1.2.3",
+		GitCommit: "abc1234",
+		BuildDate: "2024-01-01T00:00:00Z",
+		GoVersion: "go1.21.13",
+		Storage:   buildinfo.StorageConfig{Type: "xsync"},
+	})
+
+	app := fiber.New()
+	app.Get("/metrics", Handler())
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/metrics", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status %d, got %d", fiber.StatusOK, resp.StatusCode)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	expected := `tasks_service_build_info{build_date="2024-01-01T00:00:00Z",git_commit="abc1234",go_version="go1.21.13",storage_type="xsync",version="1.2.3"} 1`
+	if !strings.Contains(string(body), expected) {
+		t.Errorf("Expected metrics output to contain %q", expected)
+	}
+	if !strings.Contains(string(body), "go_goroutines") {
+		t.Error("Expected Go runtime metrics to be registered")
+	}
+}
+
+func TestSetBuildInfo_ReplacesPreviousSeries(t *testing.T) {
+	SetBuildInfo(buildinfo.Info{Version: "1.0.0"})
+	SetBuildInfo(buildinfo.Info{Version: "2.0.0"})
+
+	families, err := Registry().Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() == Namespace+"_build_info" && len(family.GetMetric()) != 1 {
+			t.Errorf("Expected a single build_info series, got %d", len(family.GetMetric()))
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"tasks-service-demo/internal/auth"
+	"tasks-service-demo/internal/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func setupAuthApp(t *testing.T) *fiber.App {
+	t.Helper()
+	auth.Init(auth.New(auth.Config{
+		APIKeys:   map[string]auth.Role{"reader-key": auth.RoleReader, "writer-key": auth.RoleWriter},
+		JWTSecret: "secret",
+	}))
+	t.Cleanup(auth.Reset)
+
+	app := setupTestApp()
+	app.Use(Authenticate())
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/public", ok)
+	app.Use("/tasks", RequireRoleByMethod(auth.RoleReader, auth.RoleWriter))
+	app.Get("/tasks", ok)
+	app.Post("/tasks", ok)
+	app.Post(BatchGetPath, ok)
+	app.Use("/admin", RequireRole(auth.RoleAdmin))
+	app.Get("/admin", ok)
+	return app
+}
+
+func TestRBAC(t *testing.T) {
+	app := setupAuthApp(t)
+	adminToken := "Bearer " + auth.SignHS256([]byte("secret"), "ops", auth.RoleAdmin, 0)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		header   string
+		value    string
+		expected int
+		code     int
+	}{
+		{"public without credentials", "GET", "/public", "", "", fiber.StatusOK, 0},
+		{"tasks without credentials", "GET", "/tasks", "", "", fiber.StatusUnauthorized, errors.ErrCodeUnauthorized},
+		{"invalid key on public route", "GET", "/public", APIKeyHeader, "nope", fiber.StatusUnauthorized, errors.ErrCodeUnauthorized},
+		{"reader reads", "GET", "/tasks", APIKeyHeader, "reader-key", fiber.StatusOK, 0},
+		{"reader writes", "POST", "/tasks", APIKeyHeader, "reader-key", fiber.StatusForbidden, errors.ErrCodeForbidden},
+		{"writer writes", "POST", "/tasks", APIKeyHeader, "writer-key", fiber.StatusOK, 0},
+		{"reader batch reads", "POST", BatchGetPath, APIKeyHeader, "reader-key", fiber.StatusOK, 0},
+		{"writer on admin", "GET", "/admin", APIKeyHeader, "writer-key", fiber.StatusForbidden, errors.ErrCodeForbidden},
+		{"admin jwt on admin", "GET", "/admin", "Authorization", adminToken, fiber.StatusOK, 0},
+		{"non-bearer authorization", "GET", "/tasks", "Authorization", "Basic Zm9vOmJhcg==", fiber.StatusUnauthorized, errors.ErrCodeUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.expected {
+				t.Fatalf("Expected status %d, got %d", tt.expected, resp.StatusCode)
+			}
+			if tt.code == 0 {
+				return
+			}
+
+			var errResp errors.ErrorResponse
+			json.NewDecoder(resp.Body).Decode(&errResp)
+			if errResp.Code != tt.code {
+				t.Errorf("Expected error code %d, got %d", tt.code, errResp.Code)
+			}
+		})
+	}
+}
+
+func TestAuthenticate_Disabled(t *testing.T) {
+	auth.Reset()
+
+	app := setupTestApp()
+	app.Use(Authenticate())
+	app.Use("/admin", RequireRole(auth.RoleAdmin))
+	app.Get("/admin", func(c *fiber.Ctx) error {
+		principal, _ := GetPrincipal(c)
+		return c.SendString(principal.Subject)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/admin", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Errorf("Expected status %d with auth disabled, got %d", fiber.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestRequireRole_WithoutAuthenticate(t *testing.T) {
+	app := setupTestApp()
+	app.Get("/admin", RequireRole(auth.RoleReader), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/admin", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", fiber.StatusUnauthorized, resp.StatusCode)
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ConsistencyHeader selects how fresh a request's reads must be: "strong"
+// or "eventual", the default
+const ConsistencyHeader = "X-Consistency"
+
+// ReadConsistency passes the consistency level named by ConsistencyHeader to
+// the store through the request context and echoes it in the response.
+// Unknown levels are rejected rather than read eventually, since a client
+// asking for fresh data must not silently get stale data.
+func ReadConsistency() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		level := storage.Eventual
+		if tag := c.Get(ConsistencyHeader); tag != "" {
+			var ok bool
+			if level, ok = storage.ParseConsistency(tag); !ok {
+				return errors.ErrInvalidConsistency
+			}
+		}
+		c.SetUserContext(storage.WithConsistency(c.UserContext(), level))
+		c.Set(ConsistencyHeader, string(level))
+		return c.Next()
+	}
+}
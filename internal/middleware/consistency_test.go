@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"tasks-service-demo/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestReadConsistency(t *testing.T) {
+	app := setupTestApp()
+	app.Use(ReadConsistency())
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(string(storage.ConsistencyFrom(c.UserContext())))
+	})
+
+	tests := []struct {
+		header   string
+		status   int
+		expected string
+	}{
+		{"", fiber.StatusOK, "eventual"},
+		{"Strong", fiber.StatusOK, "strong"},
+		{"eventual", fiber.StatusOK, "eventual"},
+		{"linearizable", fiber.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(ConsistencyHeader, tt.header)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tt.status {
+			t.Errorf("Expected status %d for %q, got %d", tt.status, tt.header, resp.StatusCode)
+		}
+		if got := resp.Header.Get(ConsistencyHeader); got != tt.expected {
+			t.Errorf("Expected level %q for %q, got %q", tt.expected, tt.header, got)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"strings"
+	"time"
+
+	"tasks-service-demo/internal/errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+)
+
+// DemoGuard returns a middleware for public demos that rejects requests
+// which would destroy what other visitors see or change the server itself:
+// every mutating admin request and the bulk DELETE /tasks. Single-task
+// writes pass, as the demo reset undoes them.
+func DemoGuard() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if isSafeRequest(c) {
+			return c.Next()
+		}
+		path := c.Path()
+		if path == "/admin" || strings.HasPrefix(path, "/admin/") || (c.Method() == fiber.MethodDelete && path == "/tasks") {
+			return errors.ErrDemoDisabled
+		}
+		return c.Next()
+	}
+}
+
+// RateLimit returns a middleware allowing each client address perMinute
+// requests in a sliding minute. Requests beyond that get 429 with a
+// Retry-After header and a retryable ErrRateLimited body. Paths under any
+// of the skip prefixes (health probes, metrics) are never limited.
+func RateLimit(perMinute int, skip ...string) fiber.Handler {
+	return limiter.New(limiter.Config{
+		Max:        perMinute,
+		Expiration: time.Minute,
+		Next: func(c *fiber.Ctx) bool {
+			path := c.Path()
+			for _, prefix := range skip {
+				if strings.HasPrefix(path, prefix) {
+					return true
+				}
+			}
+			return false
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			// The limiter has set Retry-After already
+			return errors.ErrRateLimited
+		},
+		LimiterMiddleware: limiter.SlidingWindow{},
+	})
+}
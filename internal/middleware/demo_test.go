@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestDemoGuard(t *testing.T) {
+	app := setupTestApp()
+	app.Use(DemoGuard())
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.All("/admin/*", ok)
+	app.All("/tasks", ok)
+	app.All("/tasks/:id", ok)
+
+	tests := []struct {
+		method, path string
+		expected     int
+	}{
+		{"GET", "/admin/jobs", fiber.StatusOK},
+		{"POST", "/admin/maintenance", fiber.StatusForbidden},
+		{"DELETE", "/admin/slowlog", fiber.StatusForbidden},
+		{"DELETE", "/tasks", fiber.StatusForbidden},
+		{"POST", "/tasks", fiber.StatusOK},
+		{"DELETE", "/tasks/1", fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		resp, err := app.Test(httptest.NewRequest(tt.method, tt.path, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tt.expected {
+			t.Errorf("%s %s: Expected status %d, got %d", tt.method, tt.path, tt.expected, resp.StatusCode)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	app := setupTestApp()
+	app.Use(RateLimit(2, "/health"))
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/tasks", ok)
+	app.Get("/health", ok)
+
+	for i, expected := range []int{fiber.StatusOK, fiber.StatusOK, fiber.StatusTooManyRequests} {
+		resp, err := app.Test(httptest.NewRequest("GET", "/tasks", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != expected {
+			t.Fatalf("Request %d: Expected status %d, got %d", i+1, expected, resp.StatusCode)
+		}
+		if expected == fiber.StatusTooManyRequests && resp.Header.Get(fiber.HeaderRetryAfter) == "" {
+			t.Error("Expected Retry-After on a limited request")
+		}
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/health", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Errorf("Expected skipped paths never limited, got %d", resp.StatusCode)
+	}
+}
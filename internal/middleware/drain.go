@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/shutdown"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// WriteBarrier admits writes through b so shutdown can wait for them, and
+// rejects those arriving after b is closed with a retryable 503. Reads
+// pass unchanged.
+func WriteBarrier(b *shutdown.Barrier) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if isSafeRequest(c) {
+			return c.Next()
+		}
+		if !b.Enter() {
+			SetRetryAfter(c, DefaultOverloadRetryAfter)
+			return errors.ErrShuttingDown
+		}
+		defer b.Leave()
+		return c.Next()
+	}
+}
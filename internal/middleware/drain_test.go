@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"tasks-service-demo/internal/shutdown"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestWriteBarrier(t *testing.T) {
+	barrier := shutdown.NewBarrier()
+	app := setupTestApp()
+	app.Use(WriteBarrier(barrier))
+	var inFlight int
+	ok := func(c *fiber.Ctx) error {
+		inFlight = barrier.InFlight()
+		return c.SendStatus(fiber.StatusOK)
+	}
+	app.Get("/tasks", ok)
+	app.Post("/tasks", ok)
+
+	status := func(method string) int {
+		resp, err := app.Test(httptest.NewRequest(method, "/tasks", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+
+	if status("POST") != fiber.StatusOK || inFlight != 1 || barrier.InFlight() != 0 {
+		t.Errorf("Expected the write to be tracked while it runs, saw %d in flight", inFlight)
+	}
+
+	if err := barrier.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := status("POST"); got != fiber.StatusServiceUnavailable {
+		t.Errorf("Expected status %d for writes while draining, got %d", fiber.StatusServiceUnavailable, got)
+	}
+	if got := status("GET"); got != fiber.StatusOK {
+		t.Errorf("Expected reads to pass while draining, got %d", got)
+	}
+}
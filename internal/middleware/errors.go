@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	stderrors "errors"
+
+	"tasks-service-demo/internal/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrorHandler is the app-wide fiber ErrorHandler. Handlers and middleware
+// return *errors.AppError and this maps the code to its HTTP status from the
+// error catalog. Errors served as 500 are masked as ErrInternalError, and so
+// is anything that is not an AppError; in debug mode their cause is still
+// reported. Framework errors (*fiber.Error) keep their status.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	var appErr *errors.AppError
+	var fiberErr *fiber.Error
+
+	switch {
+	case stderrors.As(err, &appErr):
+		status := errors.HTTPStatus(appErr.Code)
+		if status == fiber.StatusInternalServerError {
+			return c.Status(status).JSON(errors.InternalErrorResponse(appErr))
+		}
+		return c.Status(status).JSON(errors.ToResponse(appErr))
+
+	case stderrors.As(err, &fiberErr):
+		switch {
+		case fiberErr.Code == fiber.StatusNotFound:
+			appErr = errors.ErrRouteNotFound.WithMessage(fiberErr.Message)
+		case fiberErr.Code < fiber.StatusInternalServerError:
+			appErr = errors.ErrBadRequest.WithMessage(fiberErr.Message)
+		default:
+			appErr = errors.ErrInternalError.WithMessage(fiberErr.Message)
+		}
+		return c.Status(fiberErr.Code).JSON(errors.ToResponse(appErr))
+
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(errors.InternalErrorResponse(err))
+	}
+}
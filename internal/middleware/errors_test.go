@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"net/http/httptest"
+	"testing"
+
+	"tasks-service-demo/internal/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestErrorHandler(t *testing.T) {
+	app := setupTestApp()
+	app.Get("/not-found", func(c *fiber.Ctx) error { return errors.ErrTaskNotFound })
+	app.Get("/full", func(c *fiber.Ctx) error { return errors.ErrStorageFull })
+	app.Get("/storage", func(c *fiber.Ctx) error {
+		return errors.ErrStorageError.WithCause(stderrors.New("disk gone"))
+	})
+	app.Get("/plain", func(c *fiber.Ctx) error { return stderrors.New("secret detail") })
+	app.Get("/too-large", func(c *fiber.Ctx) error { return fiber.ErrRequestEntityTooLarge })
+
+	tests := []struct {
+		path    string
+		status  int
+		code    int
+		message string
+	}{
+		{"/not-found", fiber.StatusNotFound, errors.ErrCodeTaskNotFound, errors.ErrTaskNotFound.Message},
+		{"/full", fiber.StatusInsufficientStorage, errors.ErrCodeStorageFull, errors.ErrStorageFull.Message},
+		{"/storage", fiber.StatusInternalServerError, errors.ErrCodeInternalError, errors.ErrInternalError.Message},
+		{"/plain", fiber.StatusInternalServerError, errors.ErrCodeInternalError, errors.ErrInternalError.Message},
+		{"/too-large", fiber.StatusRequestEntityTooLarge, errors.ErrCodeBadRequest, fiber.ErrRequestEntityTooLarge.Message},
+		{"/missing", fiber.StatusNotFound, errors.ErrCodeRouteNotFound, "Cannot GET /missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", tt.path, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, resp.StatusCode)
+			}
+
+			var errResp errors.ErrorResponse
+			json.NewDecoder(resp.Body).Decode(&errResp)
+			if errResp.Code != tt.code || errResp.Message != tt.message {
+				t.Errorf("Expected %d %q, got %d %q", tt.code, tt.message, errResp.Code, errResp.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"tasks-service-demo/internal/requests"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func FuzzValidateRequest(f *testing.F) {
+	seeds := []string{
+		`{"name":"Test Task","status":0}`,
+		`{"name":"","status":0}`,
+		`{"name":"Test","status":2}`,
+		`{"name":"Test","status":"1"}`,
+		`{"name":"Test"`,
+		`null`,
+		`[]`,
+		``,
+		`invalid json`,
+	}
+	for _, s := range seeds {
+		f.Add([]byte(s))
+	}
+
+	app := setupTestApp()
+	app.Post("/test", ValidateRequest[requests.CreateTaskRequest](), func(c *fiber.Ctx) error {
+		req := GetValidatedRequest[requests.CreateTaskRequest](c)
+		if err := req.Validate(); err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		req := httptest.NewRequest("POST", "/test", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Anything that reaches the handler must already be valid.
+		if resp.StatusCode != fiber.StatusOK && resp.StatusCode != fiber.StatusBadRequest {
+			t.Fatalf("unexpected status %d for body %q", resp.StatusCode, body)
+		}
+	})
+}
+
+func FuzzValidatePathID(f *testing.F) {
+	seeds := []string{"1", "0", "-1", "123", "abc", "12.34", "+5", "007", "9223372036854775807", "9223372036854775808", " 1"}
+	for _, s := range seeds {
+		f.Add(s)
+	}
+
+	app := setupTestApp()
+	app.Get("/test/:id", ValidatePathID(), func(c *fiber.Ctx) error {
+		return c.SendString(strconv.Itoa(GetValidatedID(c)))
+	})
+
+	f.Fuzz(func(t *testing.T, raw string) {
+		// Only exercise strings that survive as a single, unescaped path segment.
+		if raw == "" || raw == "." || raw == ".." || url.PathEscape(raw) != raw {
+			return
+		}
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/test/"+raw, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		id, convErr := strconv.Atoi(raw)
+		switch {
+		case convErr == nil && resp.StatusCode != fiber.StatusOK:
+			t.Fatalf("expected %q to be accepted as %d, got status %d", raw, id, resp.StatusCode)
+		case convErr != nil && resp.StatusCode != fiber.StatusBadRequest:
+			t.Fatalf("expected %q to be rejected, got status %d", raw, resp.StatusCode)
+		}
+	})
+}
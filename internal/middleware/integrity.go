@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"tasks-service-demo/internal/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CorruptionGuard returns a middleware for a store that failed its startup
+// integrity check: safe methods pass through, so the data can still be read
+// and exported, and anything mutating is rejected with 503.
+func CorruptionGuard() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if isSafeRequest(c) {
+			return c.Next()
+		}
+		return errors.ErrStorageCorrupt
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCorruptionGuard(t *testing.T) {
+	app := setupTestApp()
+	app.Use(CorruptionGuard())
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/tasks", ok)
+	app.Post("/tasks", ok)
+
+	for method, expected := range map[string]int{"GET": fiber.StatusOK, "POST": fiber.StatusServiceUnavailable} {
+		resp, err := app.Test(httptest.NewRequest(method, "/tasks", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != expected {
+			t.Errorf("Expected status %d for %s, got %d", expected, method, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/netip"
+
+	"tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/ipfilter"
+	"tasks-service-demo/internal/logger"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// IPFilter rejects requests whose client address fails the active
+// ipfilter rules with 403, logging and counting each one. It checks the
+// peer address, so behind a proxy the rules must list the proxy's address.
+func IPFilter() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !ipfilter.Get().Enabled() {
+			return c.Next()
+		}
+		addr, _ := netip.ParseAddr(c.IP())
+		if ipfilter.Allowed(addr) {
+			return c.Next()
+		}
+
+		logger.For(logger.ModuleHTTP).Warnw("Request denied by admin IP rules",
+			"ip", c.IP(),
+			"method", c.Method(),
+			"path", c.Path(),
+		)
+		return errors.ErrAddressDenied
+	}
+}
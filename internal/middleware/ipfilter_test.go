@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"tasks-service-demo/internal/auth"
+	"tasks-service-demo/internal/ipfilter"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestIPFilter(t *testing.T) {
+	auth.Init(auth.New(auth.Config{APIKeys: map[string]auth.Role{"admin-key": auth.RoleAdmin}}))
+	t.Cleanup(auth.Reset)
+	t.Cleanup(ipfilter.Reset)
+
+	app := setupTestApp()
+	app.Use("/admin", IPFilter())
+	app.Use(Authenticate())
+	app.Use("/admin", RequireRole(auth.RoleAdmin))
+	app.Get("/admin", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	// app.Test requests come from 0.0.0.0
+	get := func(key string) int {
+		req := httptest.NewRequest("GET", "/admin", nil)
+		if key != "" {
+			req.Header.Set(APIKeyHeader, key)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+
+	if status := get("admin-key"); status != fiber.StatusOK {
+		t.Errorf("Expected status %d without rules, got %d", fiber.StatusOK, status)
+	}
+
+	allow, _ := ipfilter.ParsePrefixes("10.0.0.0/8")
+	ipfilter.Set(ipfilter.Rules{Allow: allow})
+	before := ipfilter.Denied()
+	// Rejected before authentication, so an invalid key gets 403 too
+	for _, key := range []string{"admin-key", "nope"} {
+		if status := get(key); status != fiber.StatusForbidden {
+			t.Errorf("Expected status %d outside the allow list, got %d", fiber.StatusForbidden, status)
+		}
+	}
+	if got := ipfilter.Denied() - before; got != 2 {
+		t.Errorf("Expected 2 denials counted, got %d", got)
+	}
+
+	allow, _ = ipfilter.ParsePrefixes("0.0.0.0/0")
+	ipfilter.Set(ipfilter.Rules{Allow: allow})
+	if status := get("nope"); status != fiber.StatusUnauthorized {
+		t.Errorf("Expected allowed addresses to reach authentication, got %d", status)
+	}
+}
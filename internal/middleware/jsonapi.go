@@ -0,0 +1,411 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MIMEJSONAPI is the media type of JSON:API documents (https://jsonapi.org)
+const MIMEJSONAPI = "application/vnd.api+json"
+
+// JSON:API resource types
+const (
+	TypeTasks    = "tasks"
+	TypeSubtasks = "subtasks"
+)
+
+// jsonAPIVersion is the version of the spec the documents follow
+const jsonAPIVersion = "1.1"
+
+// jsonAPITaskRoutes respond with one task. Requests to those with a body
+// send a task, or a subtask to /subtasks.
+var jsonAPITaskRoutes = map[string]bool{
+	"GET /tasks/:id":                             true,
+	"POST /tasks":                                true,
+	"PUT /tasks/:id":                             true,
+	"PUT /tasks/by-key/:external_id":             true,
+	"POST /tasks/:id/subtasks":                   true,
+	"POST /tasks/:id/subtasks/:subtaskId/toggle": true,
+	"DELETE /tasks/:id/subtasks/:subtaskId":      true,
+}
+
+// ResourceObject is a task or subtask of a JSON:API document
+type ResourceObject struct {
+	Type          string                  `json:"type"`
+	ID            string                  `json:"id,omitempty"` // Omitted on creates
+	Attributes    map[string]any          `json:"attributes,omitempty"`
+	Relationships map[string]Relationship `json:"relationships,omitempty"`
+	Links         map[string]string       `json:"links,omitempty"`
+}
+
+// ResourceIdentifier names a resource of a relationship
+type ResourceIdentifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// Relationship links a task to its subtasks
+type Relationship struct {
+	Data []ResourceIdentifier `json:"data"`
+}
+
+// JSONAPIError is an error object of a JSON:API document. Code is the code
+// of the error catalog.
+type JSONAPIError struct {
+	Status string         `json:"status"`
+	Code   string         `json:"code"`
+	Title  string         `json:"title,omitempty"`
+	Meta   map[string]any `json:"meta,omitempty"`
+}
+
+// jsonAPIDocument is a top-level JSON:API document
+type jsonAPIDocument struct {
+	Data     any               `json:"data,omitempty"`
+	Included []ResourceObject  `json:"included,omitempty"`
+	Errors   []JSONAPIError    `json:"errors,omitempty"`
+	Meta     any               `json:"meta,omitempty"`
+	Links    map[string]string `json:"links,omitempty"`
+	JSONAPI  map[string]string `json:"jsonapi"`
+}
+
+// jsonAPIOptions are the include and sparse fieldset query parameters
+type jsonAPIOptions struct {
+	include bool                // ?include=subtasks
+	fields  map[string][]string // ?fields[tasks]=name,status; nil fields are all
+}
+
+// JSONAPI serves the task endpoints as JSON:API documents to requests that
+// accept or send application/vnd.api+json; other requests pass through.
+// Tasks are "tasks" resources with their subtasks as a relationship, sent
+// in "included" with ?include=subtasks. ?fields[tasks] and
+// ?fields[subtasks] select the fields of each type. Request bodies are
+// resource objects on the routes that take a task or subtask, and plain
+// JSON elsewhere. Responses that are not tasks go in "meta", and errors
+// are error objects carrying the catalog code.
+func JSONAPI() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		sends := isJSONAPI(string(c.Request().Header.ContentType()))
+		if !sends && !acceptsJSONAPI(c.Get(fiber.HeaderAccept)) {
+			return c.Next()
+		}
+		c.Append(fiber.HeaderVary, fiber.HeaderAccept)
+
+		var err error
+		opts, appErr := parseJSONAPIOptions(c)
+		if appErr == nil && sends {
+			appErr = unwrapResource(c)
+		}
+		if appErr != nil {
+			err = appErr
+		} else {
+			err = c.Next()
+		}
+		if err != nil {
+			if herr := c.App().Config().ErrorHandler(c, err); herr != nil {
+				return herr
+			}
+		}
+		return writeJSONAPI(c, opts)
+	}
+}
+
+// isJSONAPI reports whether contentType is the JSON:API media type
+func isJSONAPI(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == MIMEJSONAPI
+}
+
+// acceptsJSONAPI reports whether accept lists the JSON:API media type. The
+// spec has clients without JSON:API support ignore it, so it is only served
+// when asked for by name.
+func acceptsJSONAPI(accept string) bool {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		if isJSONAPI(strings.TrimSpace(mediaRange)) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseJSONAPIOptions reads ?include and ?fields[type]
+func parseJSONAPIOptions(c *fiber.Ctx) (jsonAPIOptions, *errors.AppError) {
+	var opts jsonAPIOptions
+	for _, path := range strings.Split(c.Query("include"), ",") {
+		switch strings.TrimSpace(path) {
+		case "":
+		case TypeSubtasks:
+			opts.include = true
+		default:
+			return opts, errors.ErrInvalidQuery.WithMessage("include supports only " + TypeSubtasks)
+		}
+	}
+	for _, typ := range []string{TypeTasks, TypeSubtasks} {
+		key := "fields[" + typ + "]"
+		if !c.Request().URI().QueryArgs().Has(key) {
+			continue
+		}
+		if opts.fields == nil {
+			opts.fields = make(map[string][]string)
+		}
+		opts.fields[typ] = []string{}
+		for _, field := range strings.Split(c.Query(key), ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				opts.fields[typ] = append(opts.fields[typ], field)
+			}
+		}
+	}
+	return opts, nil
+}
+
+// unwrapResource replaces a resource object request body with its
+// attributes, which the request validators read. Bodies of other routes
+// only get the JSON media type.
+func unwrapResource(c *fiber.Ctx) *errors.AppError {
+	c.Request().Header.SetContentType(fiber.MIMEApplicationJSON)
+	pattern := matchTaskRoute(c.Method(), c.Path())
+	if len(c.Body()) == 0 || pattern == "" {
+		return nil
+	}
+
+	var doc struct {
+		Data *ResourceObject `json:"data"`
+	}
+	if err := json.Unmarshal(c.Body(), &doc); err != nil {
+		return errors.ErrInvalidJSON.WithMessage(err.Error())
+	}
+	if doc.Data == nil {
+		return errors.ErrInvalidJSON.WithMessage("data must be a resource object")
+	}
+	want := TypeTasks
+	if strings.HasSuffix(pattern, "/subtasks") {
+		want = TypeSubtasks
+	}
+	if doc.Data.Type != want {
+		return errors.ErrInvalidJSON.WithMessage("data.type must be " + want)
+	}
+	if pattern == "/tasks/:id" && doc.Data.ID != "" && "/tasks/"+doc.Data.ID != c.Path() {
+		return errors.ErrInvalidJSON.WithMessage("data.id must match the task of the path")
+	}
+	body, err := json.Marshal(doc.Data.Attributes)
+	if err != nil {
+		return errors.ErrInvalidJSON.WithMessage(err.Error())
+	}
+	c.Request().SetBody(body)
+	return nil
+}
+
+// matchTaskRoute returns the pattern of jsonAPITaskRoutes matching the
+// request, or "". The router only resolves the route once the request
+// reaches it, after the body has to be rewritten.
+func matchTaskRoute(method, path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for route := range jsonAPITaskRoutes {
+		m, pattern, _ := strings.Cut(route, " ")
+		if m == method && matchesPattern(strings.Split(strings.Trim(pattern, "/"), "/"), segments) {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// matchesPattern reports whether path segments match a route pattern whose
+// ":param" segments match any segment
+func matchesPattern(pattern, segments []string) bool {
+	if len(pattern) != len(segments) {
+		return false
+	}
+	for i, p := range pattern {
+		if !strings.HasPrefix(p, ":") && p != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// writeJSONAPI rewrites the JSON response as a JSON:API document
+func writeJSONAPI(c *fiber.Ctx, opts jsonAPIOptions) error {
+	resp := c.Response()
+	mediaType, _, _ := mime.ParseMediaType(string(resp.Header.ContentType()))
+	// Content type first: reading the body drains a stream
+	if mediaType != fiber.MIMEApplicationJSON || len(resp.Body()) == 0 {
+		return nil
+	}
+
+	doc := jsonAPIDocument{JSONAPI: map[string]string{"version": jsonAPIVersion}}
+	status := resp.StatusCode()
+	if status >= 400 {
+		var errResp errors.ErrorResponse
+		if err := json.Unmarshal(resp.Body(), &errResp); err != nil {
+			return err
+		}
+		doc.Errors = []JSONAPIError{newJSONAPIError(status, errResp)}
+	} else if err := fillJSONAPIData(c, &doc, opts); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	resp.SetBodyRaw(body)
+	c.Set(fiber.HeaderContentType, MIMEJSONAPI)
+	return nil
+}
+
+// fillJSONAPIData sets the data of doc from the response body: tasks on the
+// task routes, the rest as meta
+func fillJSONAPIData(c *fiber.Ctx, doc *jsonAPIDocument, opts jsonAPIOptions) error {
+	body := c.Response().Body()
+	route := c.Method() + " " + c.Route().Path
+	doc.Links = map[string]string{"self": c.OriginalURL()}
+
+	switch route {
+	case "GET /tasks":
+		var tasks []*entities.Task
+		if err := json.Unmarshal(body, &tasks); err != nil {
+			return err
+		}
+		doc.Data, doc.Included = taskResources(tasks, opts)
+		if cursor := string(c.Response().Header.Peek(NextCursorHeader)); cursor != "" {
+			doc.Links["next"] = nextPageLink(c, cursor)
+		}
+		return nil
+
+	case "POST " + BatchGetPath:
+		var batch struct {
+			Tasks   []*entities.Task `json:"tasks"`
+			Missing []int            `json:"missing"`
+		}
+		if err := json.Unmarshal(body, &batch); err != nil {
+			return err
+		}
+		doc.Data, doc.Included = taskResources(batch.Tasks, opts)
+		doc.Meta = map[string]any{"missing": batch.Missing}
+		return nil
+
+	case "POST /tasks/:id/unarchive":
+		var unarchived struct {
+			Task       *entities.Task `json:"task"`
+			ArchivedID int            `json:"archivedId"`
+		}
+		if err := json.Unmarshal(body, &unarchived); err != nil {
+			return err
+		}
+		doc.Data, doc.Included = taskResource(unarchived.Task, opts)
+		doc.Meta = map[string]any{"archivedId": unarchived.ArchivedID}
+		return nil
+	}
+
+	if jsonAPITaskRoutes[route] {
+		var task entities.Task
+		if err := json.Unmarshal(body, &task); err != nil {
+			return err
+		}
+		doc.Data, doc.Included = taskResource(&task, opts)
+		return nil
+	}
+
+	// Not a resource, e.g. throughput or bulk delete results
+	doc.Meta = json.RawMessage(bytes.TrimSpace(body))
+	return nil
+}
+
+// nextPageLink returns the URL of the request with ?cursor set to cursor
+func nextPageLink(c *fiber.Ctx, cursor string) string {
+	query, _ := url.ParseQuery(string(c.Request().URI().QueryString()))
+	query.Set("cursor", cursor)
+	return c.Path() + "?" + query.Encode()
+}
+
+// taskResources converts tasks, with their included subtasks
+func taskResources(tasks []*entities.Task, opts jsonAPIOptions) ([]ResourceObject, []ResourceObject) {
+	data := make([]ResourceObject, 0, len(tasks))
+	var included []ResourceObject
+	for _, task := range tasks {
+		res, subtasks := taskResource(task, opts)
+		data = append(data, res)
+		included = append(included, subtasks...)
+	}
+	return data, included
+}
+
+// taskResource converts a task. Its subtasks are a relationship, and
+// included resources when opts.include is set.
+func taskResource(task *entities.Task, opts jsonAPIOptions) (ResourceObject, []ResourceObject) {
+	id := strconv.Itoa(task.ID)
+	res := ResourceObject{
+		Type:  TypeTasks,
+		ID:    id,
+		Links: map[string]string{"self": "/tasks/" + id},
+		Attributes: sparse(opts, TypeTasks, map[string]any{
+			"name":     task.Name,
+			"status":   task.Status,
+			"progress": task.Progress,
+		}),
+	}
+
+	var included []ResourceObject
+	if selected(opts, TypeTasks, TypeSubtasks) {
+		rel := Relationship{Data: make([]ResourceIdentifier, 0, len(task.Subtasks))}
+		for _, st := range task.Subtasks {
+			subID := id + "-" + strconv.Itoa(st.ID)
+			rel.Data = append(rel.Data, ResourceIdentifier{Type: TypeSubtasks, ID: subID})
+			if opts.include {
+				included = append(included, ResourceObject{
+					Type:       TypeSubtasks,
+					ID:         subID,
+					Attributes: sparse(opts, TypeSubtasks, map[string]any{"name": st.Name, "done": st.Done}),
+				})
+			}
+		}
+		res.Relationships = map[string]Relationship{TypeSubtasks: rel}
+	}
+	return res, included
+}
+
+// selected reports whether field of typ is in its sparse fieldset
+func selected(opts jsonAPIOptions, typ, field string) bool {
+	fields, ok := opts.fields[typ]
+	return !ok || slices.Contains(fields, field)
+}
+
+// sparse drops the attributes outside the fieldset of typ
+func sparse(opts jsonAPIOptions, typ string, attrs map[string]any) map[string]any {
+	for name, value := range attrs {
+		if !selected(opts, typ, name) {
+			delete(attrs, name)
+		} else if p, ok := value.(*int); ok && p == nil {
+			delete(attrs, name) // Like the omitempty fields of the plain body
+		}
+	}
+	return attrs
+}
+
+// newJSONAPIError converts an error response of the error catalog
+func newJSONAPIError(status int, errResp errors.ErrorResponse) JSONAPIError {
+	e := JSONAPIError{
+		Status: strconv.Itoa(status),
+		Code:   strconv.Itoa(errResp.Code),
+		Title:  errResp.Message,
+	}
+	if errResp.Retryable || errResp.Debug != nil {
+		e.Meta = map[string]any{}
+		if errResp.Retryable {
+			e.Meta["retryable"] = true
+		}
+		if errResp.Debug != nil {
+			e.Meta["debug"] = errResp.Debug
+		}
+	}
+	return e
+}
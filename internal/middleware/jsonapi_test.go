@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/requests"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func setupJSONAPIApp() *fiber.App {
+	task := func() *entities.Task {
+		t := &entities.Task{ID: 7, Name: "write docs", Status: 1}
+		t.SetSubtasks([]entities.Subtask{{ID: 1, Name: "draft", Done: true}, {ID: 2, Name: "review"}})
+		return t
+	}
+
+	app := setupTestApp()
+	app.Use("/tasks", JSONAPI())
+	app.Get("/tasks", func(c *fiber.Ctx) error {
+		c.Set(NextCursorHeader, "next")
+		return c.JSON([]*entities.Task{task(), {ID: 8, Name: "plain"}})
+	})
+	app.Get("/tasks/stats/throughput", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"total": 3})
+	})
+	app.Get("/tasks/:id", ValidatePathID(), func(c *fiber.Ctx) error {
+		if GetValidatedID(c) != 7 {
+			return errors.ErrTaskNotFound
+		}
+		return c.JSON(task())
+	})
+	app.Post("/tasks", ValidateRequest[requests.CreateTaskRequest](), func(c *fiber.Ctx) error {
+		req := GetValidatedRequest[requests.CreateTaskRequest](c)
+		return c.Status(fiber.StatusCreated).JSON(&entities.Task{ID: 9, Name: req.Name, Status: req.Status})
+	})
+	return app
+}
+
+// jsonAPIDoc is the part of a document the tests read
+type jsonAPIDoc struct {
+	Data     json.RawMessage   `json:"data"`
+	Included []ResourceObject  `json:"included"`
+	Errors   []JSONAPIError    `json:"errors"`
+	Meta     map[string]any    `json:"meta"`
+	Links    map[string]string `json:"links"`
+	JSONAPI  map[string]string `json:"jsonapi"`
+}
+
+func doJSONAPI(t *testing.T, app *fiber.App, method, target, body string) (int, jsonAPIDoc) {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set(fiber.HeaderAccept, MIMEJSONAPI)
+	if body != "" {
+		req.Header.Set(fiber.HeaderContentType, MIMEJSONAPI)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := resp.Header.Get(fiber.HeaderContentType); ct != MIMEJSONAPI {
+		t.Fatalf("Expected content type %s, got %q", MIMEJSONAPI, ct)
+	}
+	var doc jsonAPIDoc
+	raw, _ := io.ReadAll(resp.Body)
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("Invalid document %s: %v", raw, err)
+	}
+	if doc.JSONAPI["version"] != jsonAPIVersion {
+		t.Errorf("Expected the jsonapi member, got %s", raw)
+	}
+	return resp.StatusCode, doc
+}
+
+func TestJSONAPI_Resource(t *testing.T) {
+	app := setupJSONAPIApp()
+
+	status, doc := doJSONAPI(t, app, "GET", "/tasks/7", "")
+	var res ResourceObject
+	json.Unmarshal(doc.Data, &res)
+	if status != fiber.StatusOK || res.Type != TypeTasks || res.ID != "7" || res.Attributes["name"] != "write docs" || res.Attributes["progress"] != float64(50) {
+		t.Fatalf("Unexpected resource: %d %+v", status, res)
+	}
+	if _, ok := res.Attributes["id"]; ok {
+		t.Error("Expected the ID outside the attributes")
+	}
+	rel := res.Relationships[TypeSubtasks].Data
+	if len(rel) != 2 || rel[0] != (ResourceIdentifier{Type: TypeSubtasks, ID: "7-1"}) {
+		t.Errorf("Expected the subtasks relationship, got %+v", rel)
+	}
+	if len(doc.Included) != 0 {
+		t.Errorf("Expected no included resources without ?include, got %+v", doc.Included)
+	}
+
+	_, doc = doJSONAPI(t, app, "GET", "/tasks/7?include=subtasks&fields[tasks]=name,subtasks&fields[subtasks]=done", "")
+	res = ResourceObject{}
+	json.Unmarshal(doc.Data, &res)
+	if len(res.Attributes) != 1 || res.Attributes["name"] != "write docs" {
+		t.Errorf("Expected only the name attribute, got %+v", res.Attributes)
+	}
+	if len(doc.Included) != 2 || doc.Included[1].ID != "7-2" || len(doc.Included[1].Attributes) != 1 || doc.Included[1].Attributes["done"] != false {
+		t.Errorf("Expected the sparse subtasks included, got %+v", doc.Included)
+	}
+
+	_, doc = doJSONAPI(t, app, "GET", "/tasks/7?fields[tasks]=status", "")
+	res = ResourceObject{}
+	json.Unmarshal(doc.Data, &res)
+	if res.Relationships != nil || len(res.Attributes) != 1 {
+		t.Errorf("Expected the relationship left out of the fieldset, got %+v", res)
+	}
+}
+
+func TestJSONAPI_Collection(t *testing.T) {
+	_, doc := doJSONAPI(t, setupJSONAPIApp(), "GET", "/tasks?limit=2", "")
+	var data []ResourceObject
+	json.Unmarshal(doc.Data, &data)
+	if len(data) != 2 || data[1].ID != "8" || len(data[1].Relationships[TypeSubtasks].Data) != 0 {
+		t.Errorf("Unexpected collection: %+v", data)
+	}
+	if doc.Links["next"] != "/tasks?cursor=next&limit=2" || doc.Links["self"] != "/tasks?limit=2" {
+		t.Errorf("Expected pagination links, got %+v", doc.Links)
+	}
+}
+
+func TestJSONAPI_Meta(t *testing.T) {
+	_, doc := doJSONAPI(t, setupJSONAPIApp(), "GET", "/tasks/stats/throughput", "")
+	if doc.Data != nil || doc.Meta["total"] != float64(3) {
+		t.Errorf("Expected the response in meta, got %+v", doc)
+	}
+}
+
+func TestJSONAPI_Create(t *testing.T) {
+	app := setupJSONAPIApp()
+
+	status, doc := doJSONAPI(t, app, "POST", "/tasks", `{"data":{"type":"tasks","attributes":{"name":"new","status":1}}}`)
+	var res ResourceObject
+	json.Unmarshal(doc.Data, &res)
+	if status != fiber.StatusCreated || res.ID != "9" || res.Attributes["name"] != "new" || res.Attributes["status"] != float64(1) {
+		t.Errorf("Expected the created task, got %d %+v", status, res)
+	}
+
+	for _, body := range []string{
+		`{"data":{"type":"subtasks","attributes":{"name":"new"}}}`,
+		`{"name":"new"}`,
+		`{"data":`,
+	} {
+		status, doc := doJSONAPI(t, app, "POST", "/tasks", body)
+		if status != fiber.StatusBadRequest || len(doc.Errors) != 1 || doc.Errors[0].Code != "2001" {
+			t.Errorf("%s: expected an invalid JSON error, got %d %+v", body, status, doc.Errors)
+		}
+	}
+}
+
+func TestJSONAPI_Errors(t *testing.T) {
+	app := setupJSONAPIApp()
+
+	status, doc := doJSONAPI(t, app, "GET", "/tasks/8", "")
+	if len(doc.Errors) != 1 || doc.Errors[0].Status != "404" || doc.Errors[0].Code != "1001" || doc.Errors[0].Title == "" || status != 404 {
+		t.Errorf("Expected an error object, got %d %+v", status, doc)
+	}
+
+	status, doc = doJSONAPI(t, app, "GET", "/tasks/7?include=project", "")
+	if status != fiber.StatusBadRequest || len(doc.Errors) != 1 || doc.Errors[0].Code != "2009" {
+		t.Errorf("Expected unknown includes rejected, got %d %+v", status, doc)
+	}
+}
+
+func TestJSONAPI_PassThrough(t *testing.T) {
+	resp, err := setupJSONAPIApp().Test(httptest.NewRequest("GET", "/tasks/7", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var task entities.Task
+	json.NewDecoder(resp.Body).Decode(&task)
+	if resp.Header.Get(fiber.HeaderContentType) != fiber.MIMEApplicationJSON || task.ID != 7 {
+		t.Errorf("Expected the plain task, got %+v", task)
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/maintenance"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MaintenanceGuard returns a middleware that rejects requests according to the
+// active maintenance mode with 503 and a Retry-After header. In read-only mode
+// only safe methods (GET, HEAD, OPTIONS) and read-only POSTs such as
+// BatchGetPath are let through.
+func MaintenanceGuard() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		state := maintenance.Get()
+
+		switch state.Mode {
+		case maintenance.ModeReadOnly:
+			if isSafeRequest(c) {
+				return c.Next()
+			}
+		case maintenance.ModeFull:
+		default:
+			return c.Next()
+		}
+
+		SetRetryAfter(c, state.RetryAfter())
+		return errors.ErrMaintenance
+	}
+}
+
+// isSafeMethod reports whether the HTTP method does not modify state
+func isSafeMethod(method string) bool {
+	switch method {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// BatchGetPath is the batch task lookup, a POST only because the IDs travel
+// in the body
+const BatchGetPath = "/tasks/byIds"
+
+// readPaths are the POST endpoints that don't modify state. Role checks,
+// maintenance mode and read-only replicas treat them like GET.
+var readPaths = map[string]bool{BatchGetPath: true}
+
+// isSafeRequest reports whether the request does not modify state
+func isSafeRequest(c *fiber.Ctx) bool {
+	return isSafeMethod(c.Method()) || (c.Method() == fiber.MethodPost && readPaths[c.Path()])
+}
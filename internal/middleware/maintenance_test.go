@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/maintenance"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func setupMaintenanceApp() *fiber.App {
+	app := setupTestApp()
+	app.Use(MaintenanceGuard())
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/test", ok)
+	app.Post("/test", ok)
+	app.Delete("/test", ok)
+	app.Post(BatchGetPath, ok)
+	return app
+}
+
+func TestMaintenanceGuard(t *testing.T) {
+	defer maintenance.Reset()
+	app := setupMaintenanceApp()
+
+	tests := []struct {
+		mode     maintenance.Mode
+		method   string
+		path     string
+		expected int
+	}{
+		{maintenance.ModeOff, "GET", "/test", fiber.StatusOK},
+		{maintenance.ModeOff, "POST", "/test", fiber.StatusOK},
+		{maintenance.ModeReadOnly, "GET", "/test", fiber.StatusOK},
+		{maintenance.ModeReadOnly, "POST", "/test", fiber.StatusServiceUnavailable},
+		{maintenance.ModeReadOnly, "POST", BatchGetPath, fiber.StatusOK},
+		{maintenance.ModeReadOnly, "DELETE", "/test", fiber.StatusServiceUnavailable},
+		{maintenance.ModeFull, "GET", "/test", fiber.StatusServiceUnavailable},
+		{maintenance.ModeFull, "POST", "/test", fiber.StatusServiceUnavailable},
+		{maintenance.ModeFull, "POST", BatchGetPath, fiber.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode)+"_"+tt.method+tt.path, func(t *testing.T) {
+			maintenance.Set(maintenance.State{Mode: tt.mode, RetryAfterSeconds: 120})
+
+			resp, err := app.Test(httptest.NewRequest(tt.method, tt.path, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, resp.StatusCode)
+			}
+			if tt.expected == fiber.StatusServiceUnavailable && resp.Header.Get("Retry-After") != "120" {
+				t.Errorf("Expected Retry-After 120, got '%s'", resp.Header.Get("Retry-After"))
+			}
+			if tt.expected == fiber.StatusServiceUnavailable {
+				var errResp errors.ErrorResponse
+				json.NewDecoder(resp.Body).Decode(&errResp)
+				if !errResp.Retryable {
+					t.Error("Expected maintenance response to be retryable")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"tasks-service-demo/internal/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultOverloadRetryAfter is the Retry-After hint sent when load is shed
+const DefaultOverloadRetryAfter = time.Second
+
+// SetRetryAfter sets the Retry-After header to d, rounded up to whole seconds
+func SetRetryAfter(c *fiber.Ctx, d time.Duration) {
+	seconds := int(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+}
+
+// LoadShedder rejects requests beyond maxInFlight concurrent ones with 503,
+// a Retry-After header and a retryable ErrOverloaded body. Paths under any of
+// the skip prefixes (health probes, metrics) are never shed.
+func LoadShedder(maxInFlight int, retryAfter time.Duration, skip ...string) fiber.Handler {
+	var inFlight atomic.Int64
+
+	return func(c *fiber.Ctx) error {
+		path := c.Path()
+		for _, prefix := range skip {
+			if strings.HasPrefix(path, prefix) {
+				return c.Next()
+			}
+		}
+
+		if inFlight.Add(1) > int64(maxInFlight) {
+			inFlight.Add(-1)
+			SetRetryAfter(c, retryAfter)
+			return errors.ErrOverloaded
+		}
+		defer inFlight.Add(-1)
+		return c.Next()
+	}
+}
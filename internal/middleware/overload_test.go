@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestLoadShedder(t *testing.T) {
+	release := make(chan struct{})
+	entered := make(chan struct{})
+
+	app := setupTestApp()
+	app.Use(LoadShedder(1, 1500*time.Millisecond, "/health"))
+	app.Get("/slow", func(c *fiber.Ctx) error {
+		close(entered)
+		<-release
+		return c.SendStatus(fiber.StatusOK)
+	})
+	app.Get("/fast", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	app.Get("/health", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	done := make(chan int)
+	go func() {
+		resp, err := app.Test(httptest.NewRequest("GET", "/slow", nil), -1)
+		if err != nil {
+			done <- 0
+			return
+		}
+		done <- resp.StatusCode
+	}()
+	<-entered
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/fast", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusServiceUnavailable {
+		t.Fatalf("Expected status %d, got %d", fiber.StatusServiceUnavailable, resp.StatusCode)
+	}
+	if got := resp.Header.Get(fiber.HeaderRetryAfter); got != "2" {
+		t.Errorf("Expected Retry-After 2, got %q", got)
+	}
+	var errResp errors.ErrorResponse
+	json.NewDecoder(resp.Body).Decode(&errResp)
+	if errResp.Code != errors.ErrCodeOverloaded || !errResp.Retryable {
+		t.Errorf("Expected retryable error %d, got %+v", errors.ErrCodeOverloaded, errResp)
+	}
+
+	// Skipped paths are served regardless of load
+	if resp, _ := app.Test(httptest.NewRequest("GET", "/health", nil)); resp.StatusCode != fiber.StatusOK {
+		t.Errorf("Expected health to bypass the shedder, got %d", resp.StatusCode)
+	}
+
+	close(release)
+	if status := <-done; status != fiber.StatusOK {
+		t.Errorf("Expected in-flight request to complete, got %d", status)
+	}
+	if resp, _ := app.Test(httptest.NewRequest("GET", "/fast", nil)); resp.StatusCode != fiber.StatusOK {
+		t.Errorf("Expected capacity to be released, got %d", resp.StatusCode)
+	}
+}
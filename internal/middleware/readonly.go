@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"tasks-service-demo/internal/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ReadOnlyGuard returns a middleware for read-only replicas: safe methods pass
+// through, anything mutating is rejected with 405 Method Not Allowed.
+func ReadOnlyGuard() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if isSafeRequest(c) {
+			return c.Next()
+		}
+
+		c.Set(fiber.HeaderAllow, "GET, HEAD, OPTIONS")
+		return errors.ErrReadOnly
+	}
+}
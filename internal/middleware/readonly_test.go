@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"tasks-service-demo/internal/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestReadOnlyGuard(t *testing.T) {
+	app := setupTestApp()
+	app.Use(ReadOnlyGuard())
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/test", ok)
+	app.Post("/test", ok)
+	app.Put("/test", ok)
+	app.Delete("/test", ok)
+
+	tests := []struct {
+		method   string
+		expected int
+	}{
+		{"GET", fiber.StatusOK},
+		{"POST", fiber.StatusMethodNotAllowed},
+		{"PUT", fiber.StatusMethodNotAllowed},
+		{"DELETE", fiber.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest(tt.method, "/test", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.expected {
+				t.Fatalf("Expected status %d, got %d", tt.expected, resp.StatusCode)
+			}
+			if tt.expected != fiber.StatusMethodNotAllowed {
+				return
+			}
+
+			if resp.Header.Get("Allow") == "" {
+				t.Error("Expected Allow header on rejected write")
+			}
+			body, _ := io.ReadAll(resp.Body)
+			var errResp errors.ErrorResponse
+			json.Unmarshal(body, &errResp)
+			if errResp.Code != errors.ErrCodeReadOnly {
+				t.Errorf("Expected error code %d, got %d", errors.ErrCodeReadOnly, errResp.Code)
+			}
+		})
+	}
+}
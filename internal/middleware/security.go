@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"tasks-service-demo/internal/errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/csrf"
+	"github.com/gofiber/fiber/v2/middleware/helmet"
+)
+
+// DocsPathPrefix is served with a CSP that lets API docs load their own assets
+const DocsPathPrefix = "/docs"
+
+// UIPathPrefix is served with a CSP for the server-rendered web UI
+const UIPathPrefix = "/ui"
+
+// DashboardPathPrefix is served with a CSP for the embedded admin dashboard
+const DashboardPathPrefix = "/admin/dashboard"
+
+const (
+	// apiCSP forbids everything: JSON responses never need to load resources
+	apiCSP = "default-src 'none'; frame-ancestors 'none'"
+	// docsCSP allows same-origin scripts, styles and images for the docs UI
+	docsCSP = "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; " +
+		"img-src 'self' data:; frame-ancestors 'none'"
+	// uiCSP allows inline styles and same-origin form posts, but no scripts
+	uiCSP = "default-src 'none'; style-src 'unsafe-inline'; form-action 'self'; frame-ancestors 'none'"
+	// dashboardCSP allows same-origin scripts, styles and polling, nothing inline
+	dashboardCSP = "default-src 'none'; script-src 'self'; style-src 'self'; connect-src 'self'; frame-ancestors 'none'"
+)
+
+const (
+	// CSRFHeader is the request header carrying the CSRF token
+	CSRFHeader = "X-Csrf-Token"
+	// CSRFFormField is the form field carrying the CSRF token for HTML forms
+	CSRFFormField = "_csrf"
+	// CSRFContextKey is the Locals key holding the current CSRF token
+	CSRFContextKey = "csrf"
+)
+
+// CORSConfig holds the configurable CORS settings
+type CORSConfig struct {
+	AllowOrigins     string // Comma-separated origins, "*" allows any
+	AllowMethods     string // Comma-separated methods
+	AllowHeaders     string // Comma-separated headers; empty reflects the request
+	AllowCredentials bool   // Allow cookies and auth headers; needs explicit origins
+	MaxAge           int    // Preflight cache lifetime in seconds
+}
+
+// DefaultCORSConfig keeps the previous wide-open behaviour
+var DefaultCORSConfig = CORSConfig{
+	AllowOrigins: "*",
+	AllowMethods: "GET,POST,HEAD,PUT,DELETE,PATCH,OPTIONS",
+}
+
+// CORS returns a CORS middleware for cfg. Credentials cannot be combined with a
+// wildcard origin, since browsers would reject every response.
+func CORS(cfg CORSConfig) (fiber.Handler, error) {
+	if cfg.AllowOrigins == "" {
+		cfg.AllowOrigins = DefaultCORSConfig.AllowOrigins
+	}
+	if cfg.AllowMethods == "" {
+		cfg.AllowMethods = DefaultCORSConfig.AllowMethods
+	}
+	if cfg.AllowCredentials && strings.Contains(cfg.AllowOrigins, "*") {
+		return nil, fmt.Errorf("CORS credentials require explicit origins, got %q", cfg.AllowOrigins)
+	}
+
+	return cors.New(cors.Config{
+		AllowOrigins:     cfg.AllowOrigins,
+		AllowMethods:     cfg.AllowMethods,
+		AllowHeaders:     cfg.AllowHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           cfg.MaxAge,
+	}), nil
+}
+
+// SecurityHeaders sets standard hardening headers (nosniff, frame denial,
+// referrer policy, CSP) on every response. HSTS is only sent over HTTPS and
+// is disabled when hstsMaxAge is 0. Paths under DocsPathPrefix, UIPathPrefix
+// and DashboardPathPrefix get CSPs that let their HTML pages work.
+func SecurityHeaders(hstsMaxAge int) fiber.Handler {
+	isDocs := func(c *fiber.Ctx) bool {
+		return strings.HasPrefix(c.Path(), DocsPathPrefix)
+	}
+	isUI := func(c *fiber.Ctx) bool {
+		return strings.HasPrefix(c.Path(), UIPathPrefix)
+	}
+	isDashboard := func(c *fiber.Ctx) bool {
+		return strings.HasPrefix(c.Path(), DashboardPathPrefix)
+	}
+	base := helmet.Config{
+		XFrameOptions:  "DENY",
+		ReferrerPolicy: "no-referrer",
+		HSTSMaxAge:     hstsMaxAge,
+	}
+
+	api := base
+	api.ContentSecurityPolicy = apiCSP
+	apiHeaders := helmet.New(api)
+
+	docs := base
+	docs.ContentSecurityPolicy = docsCSP
+	docsHeaders := helmet.New(docs)
+
+	ui := base
+	ui.ContentSecurityPolicy = uiCSP
+	uiHeaders := helmet.New(ui)
+
+	dashboard := base
+	dashboard.ContentSecurityPolicy = dashboardCSP
+	dashboardHeaders := helmet.New(dashboard)
+
+	return func(c *fiber.Ctx) error {
+		switch {
+		case isDocs(c):
+			return docsHeaders(c)
+		case isUI(c):
+			return uiHeaders(c)
+		case isDashboard(c):
+			return dashboardHeaders(c)
+		default:
+			return apiHeaders(c)
+		}
+	}
+}
+
+// CSRF returns a double-submit CSRF middleware for cookie-based clients: safe
+// requests receive a token cookie, unsafe ones must echo it in CSRFHeader
+// or, for HTML forms, in CSRFFormField. Unsafe requests without any cookie
+// are skipped, as header- or token-authed API clients are not exposed to
+// cross-site request forgery. The token is stored in Locals under
+// CSRFContextKey for templates.
+func CSRF(secureCookie bool) fiber.Handler {
+	fromHeader := csrf.CsrfFromHeader(CSRFHeader)
+	fromForm := csrf.CsrfFromForm(CSRFFormField)
+
+	return csrf.New(csrf.Config{
+		Next: func(c *fiber.Ctx) bool {
+			return !isSafeMethod(c.Method()) && len(c.Request().Header.Peek(fiber.HeaderCookie)) == 0
+		},
+		Extractor: func(c *fiber.Ctx) (string, error) {
+			if token, err := fromHeader(c); err == nil {
+				return token, nil
+			}
+			return fromForm(c)
+		},
+		ContextKey:     CSRFContextKey,
+		CookieName:     "csrf_",
+		CookieSameSite: "Lax",
+		CookieSecure:   secureCookie,
+		Expiration:     time.Hour,
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			return errors.ErrCSRFTokenInvalid
+		},
+	})
+}
@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"tasks-service-demo/internal/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCORS(t *testing.T) {
+	handler, err := CORS(CORSConfig{AllowOrigins: "https://app.example.com", AllowCredentials: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := setupTestApp()
+	app.Use(handler)
+	app.Get("/test", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	tests := []struct {
+		origin   string
+		expected string
+	}{
+		{"https://app.example.com", "https://app.example.com"},
+		{"https://evil.example.com", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("Origin", tt.origin)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resp.Header.Get("Access-Control-Allow-Origin"); got != tt.expected {
+				t.Errorf("Expected allowed origin %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCORS_CredentialsWithWildcard(t *testing.T) {
+	if _, err := CORS(CORSConfig{AllowOrigins: "*", AllowCredentials: true}); err == nil {
+		t.Error("Expected error for credentials with wildcard origin")
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	app := setupTestApp()
+	app.Use(SecurityHeaders(3600))
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/tasks", ok)
+	app.Get("/docs/index.html", ok)
+	app.Get("/admin/dashboard/app.js", ok)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/tasks", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"X-Content-Type-Options":  "nosniff",
+		"X-Frame-Options":         "DENY",
+		"Referrer-Policy":         "no-referrer",
+		"Content-Security-Policy": apiCSP,
+	}
+	for header, value := range expected {
+		if got := resp.Header.Get(header); got != value {
+			t.Errorf("Expected %s %q, got %q", header, value, got)
+		}
+	}
+	// HSTS is only meaningful over HTTPS
+	if got := resp.Header.Get("Strict-Transport-Security"); got != "" {
+		t.Errorf("Expected no HSTS over plain HTTP, got %q", got)
+	}
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/docs/index.html", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Header.Get("Content-Security-Policy"); got != docsCSP {
+		t.Errorf("Expected docs CSP %q, got %q", docsCSP, got)
+	}
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/admin/dashboard/app.js", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Header.Get("Content-Security-Policy"); got != dashboardCSP {
+		t.Errorf("Expected dashboard CSP %q, got %q", dashboardCSP, got)
+	}
+}
+
+func TestCSRF(t *testing.T) {
+	app := setupTestApp()
+	app.Use(CSRF(false))
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/tasks", ok)
+	app.Post("/tasks", ok)
+
+	// A safe request issues the token cookie
+	resp, err := app.Test(httptest.NewRequest("GET", "/tasks", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var token string
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == "csrf_" {
+			token = cookie.Value
+		}
+	}
+	if token == "" {
+		t.Fatal("Expected csrf_ cookie on GET")
+	}
+
+	post := func(cookie, header string) int {
+		req := httptest.NewRequest("POST", "/tasks", nil)
+		if cookie != "" {
+			req.Header.Set("Cookie", cookie)
+		}
+		if header != "" {
+			req.Header.Set(CSRFHeader, header)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode == fiber.StatusForbidden {
+			var errResp errors.ErrorResponse
+			json.NewDecoder(resp.Body).Decode(&errResp)
+			if errResp.Code != errors.ErrCodeCSRFInvalid {
+				t.Errorf("Expected error code %d, got %d", errors.ErrCodeCSRFInvalid, errResp.Code)
+			}
+		}
+		return resp.StatusCode
+	}
+
+	tests := []struct {
+		name     string
+		cookie   string
+		header   string
+		expected int
+	}{
+		{"no cookies", "", "", fiber.StatusOK},
+		{"cookie without token", "csrf_=" + token, "", fiber.StatusForbidden},
+		{"mismatched token", "csrf_=" + token, strings.Repeat("x", len(token)), fiber.StatusForbidden},
+		{"valid token", "csrf_=" + token, token, fiber.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := post(tt.cookie, tt.header); got != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"unicode"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// FieldCase is the naming of the JSON fields of a response
+type FieldCase string
+
+const (
+	CamelCase FieldCase = "camel" // As the handlers write them, e.g. "dryRun"
+	SnakeCase FieldCase = "snake" // e.g. "dry_run"
+)
+
+// ParseFieldCase parses "camel" or "snake"
+func ParseFieldCase(s string) (FieldCase, error) {
+	switch c := FieldCase(strings.ToLower(strings.TrimSpace(s))); c {
+	case CamelCase, SnakeCase:
+		return c, nil
+	}
+	return "", fmt.Errorf("invalid field case %q (want camel or snake)", s)
+}
+
+// ResponseShape is how JSON responses are laid out
+type ResponseShape struct {
+	Envelope bool // Wrap the body as {"data": ..., "meta": {...}}
+	Case     FieldCase
+}
+
+// Profiles accepted in the profile parameter of Accept, e.g.
+// Accept: application/json; profile="envelope snake_case"
+const (
+	ProfileEnvelope  = "envelope"
+	ProfilePlain     = "plain" // No envelope, overriding RESPONSE_ENVELOPE
+	ProfileSnakeCase = "snake_case"
+	ProfileCamelCase = "camelCase"
+)
+
+// NextCursorHeader carries the cursor of the next page of a listing
+const NextCursorHeader = "X-Next-Cursor"
+
+// ResponseMeta is the meta member of an enveloped response
+type ResponseMeta struct {
+	Count      *int   `json:"count,omitempty"`      // Items in data, when it is a list
+	NextCursor string `json:"nextCursor,omitempty"` // Of the next page, as in X-Next-Cursor
+}
+
+// ShapeResponses lays out successful JSON responses as def says, or as the
+// profiles of the request's Accept header say. The envelope moves the
+// pagination headers into meta; the headers are still set, so clients can
+// switch one endpoint at a time. Errors keep the layout of the error
+// catalog, and other content types pass through untouched.
+func ShapeResponses(def ResponseShape) fiber.Handler {
+	if def.Case == "" {
+		def.Case = CamelCase
+	}
+	return func(c *fiber.Ctx) error {
+		shape := acceptedShape(c.Get(fiber.HeaderAccept), def)
+		c.Append(fiber.HeaderVary, fiber.HeaderAccept)
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if !shape.Envelope && shape.Case == CamelCase {
+			return nil
+		}
+
+		// Content type first: reading the body drains a stream
+		resp := c.Response()
+		if mediaType, _, err := mime.ParseMediaType(string(resp.Header.ContentType())); err != nil || mediaType != fiber.MIMEApplicationJSON {
+			return nil
+		}
+		if resp.StatusCode() < 200 || resp.StatusCode() >= 300 || len(resp.Body()) == 0 {
+			return nil
+		}
+
+		body, err := shapeBody(resp.Body(), shape, string(resp.Header.Peek(NextCursorHeader)))
+		if err != nil {
+			return err
+		}
+		resp.SetBodyRaw(body)
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON+`; profile="`+shape.profile()+`"`)
+		return nil
+	}
+}
+
+// acceptedShape applies the profiles of the first JSON media range of accept
+// to def
+func acceptedShape(accept string, def ResponseShape) ResponseShape {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(mediaRange)
+		if err != nil || (mediaType != fiber.MIMEApplicationJSON && mediaType != "*/*") {
+			continue
+		}
+		for _, profile := range strings.Fields(params["profile"]) {
+			switch profile {
+			case ProfileEnvelope:
+				def.Envelope = true
+			case ProfilePlain:
+				def.Envelope = false
+			case ProfileSnakeCase:
+				def.Case = SnakeCase
+			case ProfileCamelCase:
+				def.Case = CamelCase
+			}
+		}
+		break
+	}
+	return def
+}
+
+// profile names the shape for the profile parameter of the response
+func (s ResponseShape) profile() string {
+	profiles := []string{ProfilePlain, ProfileCamelCase}
+	if s.Envelope {
+		profiles[0] = ProfileEnvelope
+	}
+	if s.Case == SnakeCase {
+		profiles[1] = ProfileSnakeCase
+	}
+	return strings.Join(profiles, " ")
+}
+
+// shapeBody renames the fields of body and wraps it in the envelope
+func shapeBody(body []byte, shape ResponseShape, nextCursor string) ([]byte, error) {
+	if shape.Envelope {
+		meta := ResponseMeta{NextCursor: nextCursor}
+		var items []json.RawMessage
+		if json.Unmarshal(body, &items) == nil && items != nil {
+			n := len(items)
+			meta.Count = &n
+		}
+		metaJSON, err := json.Marshal(meta)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		buf.Grow(len(body) + len(metaJSON) + 20)
+		buf.WriteString(`{"data":`)
+		buf.Write(bytes.TrimSpace(body))
+		buf.WriteString(`,"meta":`)
+		buf.Write(metaJSON)
+		buf.WriteByte('}')
+		body = buf.Bytes()
+	}
+	if shape.Case == SnakeCase {
+		return renameKeys(body, snakeCase)
+	}
+	return body, nil
+}
+
+// renameKeys rewrites every object key of the JSON document body with
+// rename, keeping the order of the fields and the text of the numbers
+func renameKeys(body []byte, rename func(string) string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	type frame struct {
+		object bool
+		n      int // Keys of an object, elements of an array written so far
+	}
+	var stack []frame
+	var out bytes.Buffer
+	out.Grow(len(body))
+
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return out.Bytes(), nil
+			}
+			return nil, err
+		}
+
+		// The key of an object member, or the separator before a value
+		var top *frame
+		if len(stack) > 0 {
+			top = &stack[len(stack)-1]
+		}
+		if d, ok := tok.(json.Delim); !ok || d == '{' || d == '[' {
+			if top != nil && top.object {
+				if out.Bytes()[out.Len()-1] != ':' {
+					// tok is a key: decoders only return strings there
+					if top.n > 0 {
+						out.WriteByte(',')
+					}
+					top.n++
+					key, _ := json.Marshal(rename(tok.(string)))
+					out.Write(key)
+					out.WriteByte(':')
+					continue
+				}
+			} else if top != nil {
+				if top.n > 0 {
+					out.WriteByte(',')
+				}
+				top.n++
+			}
+		}
+
+		switch v := tok.(type) {
+		case json.Delim:
+			out.WriteRune(rune(v))
+			switch v {
+			case '{', '[':
+				stack = append(stack, frame{object: v == '{'})
+			default:
+				stack = stack[:len(stack)-1]
+			}
+		case json.Number:
+			out.WriteString(v.String())
+		default:
+			value, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			out.Write(value)
+		}
+	}
+}
+
+// snakeCase converts a camelCase name, e.g. "currentMinute" to
+// "current_minute" and "taskID" to "task_id"
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	b.Grow(len(name) + 4)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]))
+			acronymEnd := i > 0 && unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || acronymEnd {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"tasks-service-demo/internal/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func setupShapeApp(def ResponseShape) *fiber.App {
+	app := setupTestApp()
+	app.Use(ShapeResponses(def))
+	app.Get("/list", func(c *fiber.Ctx) error {
+		c.Set(NextCursorHeader, "abc")
+		return c.JSON([]fiber.Map{{"id": 1, "dryRun": true, "perMinute": 1.5}})
+	})
+	app.Get("/object", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"currentMinute": fiber.Map{"taskID": 7}, "missing": []int{}})
+	})
+	app.Get("/error", func(c *fiber.Ctx) error {
+		return errors.ErrTaskNotFound
+	})
+	app.Get("/text", func(c *fiber.Ctx) error {
+		return c.SendString("dryRun")
+	})
+	return app
+}
+
+func TestShapeResponses(t *testing.T) {
+	tests := []struct {
+		name     string
+		def      ResponseShape
+		path     string
+		accept   string
+		expected string
+	}{
+		{"default", ResponseShape{}, "/list", "", `[{"dryRun":true,"id":1,"perMinute":1.5}]`},
+		{"envelope", ResponseShape{Envelope: true}, "/list", "", `{"data":[{"dryRun":true,"id":1,"perMinute":1.5}],"meta":{"count":1,"nextCursor":"abc"}}`},
+		{"snake case", ResponseShape{Case: SnakeCase}, "/object", "", `{"current_minute":{"task_id":7},"missing":[]}`},
+		{"profile", ResponseShape{}, "/list", `application/json; profile="envelope snake_case"`, `{"data":[{"dry_run":true,"id":1,"per_minute":1.5}],"meta":{"count":1,"next_cursor":"abc"}}`},
+		{"profile overrides default", ResponseShape{Envelope: true, Case: SnakeCase}, "/object", `text/html, */*;profile="plain camelCase"`, `{"currentMinute":{"taskID":7},"missing":[]}`},
+		{"envelope of an object", ResponseShape{Envelope: true}, "/object", "", `{"data":{"currentMinute":{"taskID":7},"missing":[]},"meta":{}}`},
+		{"error", ResponseShape{Envelope: true, Case: SnakeCase}, "/error", "", ""},
+		{"text", ResponseShape{Envelope: true, Case: SnakeCase}, "/text", "", "dryRun"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set(fiber.HeaderAccept, tt.accept)
+			}
+			resp, err := setupShapeApp(tt.def).Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if tt.path == "/error" {
+				// Errors keep the catalog layout
+				if resp.StatusCode < 400 || !strings.Contains(string(body), `"code":`) {
+					t.Errorf("Expected the error response, got %d %s", resp.StatusCode, body)
+				}
+				return
+			}
+			if string(body) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, body)
+			}
+		})
+	}
+}
+
+func TestShapeResponses_ProfileHeader(t *testing.T) {
+	app := setupShapeApp(ResponseShape{Envelope: true})
+	resp, err := app.Test(httptest.NewRequest("GET", "/list", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Header.Get(fiber.HeaderContentType); got != `application/json; profile="envelope camelCase"` {
+		t.Errorf("Expected the applied profiles in the content type, got %q", got)
+	}
+	if got := resp.Header.Get(fiber.HeaderVary); got != fiber.HeaderAccept {
+		t.Errorf("Expected Vary: Accept, got %q", got)
+	}
+	if got := resp.Header.Get(NextCursorHeader); got != "abc" {
+		t.Errorf("Expected the cursor header kept, got %q", got)
+	}
+}
+
+func TestParseFieldCase(t *testing.T) {
+	for s, expected := range map[string]FieldCase{"camel": CamelCase, " Snake ": SnakeCase} {
+		if got, err := ParseFieldCase(s); err != nil || got != expected {
+			t.Errorf("ParseFieldCase(%q) = %q, %v", s, got, err)
+		}
+	}
+	if _, err := ParseFieldCase("kebab"); err == nil {
+		t.Error("Expected an error for an unknown case")
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for name, expected := range map[string]string{
+		"id":            "id",
+		"dryRun":        "dry_run",
+		"currentMinute": "current_minute",
+		"taskID":        "task_id",
+		"HTTPStatus":    "http_status",
+		"p99Ms":         "p99_ms",
+	} {
+		if got := snakeCase(name); got != expected {
+			t.Errorf("snakeCase(%q) = %q, expected %q", name, got, expected)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/auth"
+	"tasks-service-demo/internal/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestVerifySignature(t *testing.T) {
+	auth.Init(auth.New(auth.Config{
+		APIKeys:     map[string]auth.Role{"signed-key": auth.RoleWriter, "writer-key": auth.RoleWriter},
+		SigningKeys: map[string]string{"signed-key": "hmac-secret"},
+	}))
+	t.Cleanup(auth.Reset)
+
+	app := setupTestApp()
+	app.Use(Authenticate())
+	app.Use(VerifySignature())
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/tasks", ok)
+	app.Post("/tasks", ok)
+
+	body := `{"name":"signed"}`
+	now := time.Now().Unix()
+	fresh := auth.SignRequest([]byte("hmac-secret"), now, "POST", "/tasks", []byte(body))
+	stale := now - int64((10 * time.Minute).Seconds())
+
+	tests := []struct {
+		name      string
+		method    string
+		key       string
+		timestamp int64
+		signature string
+		expected  int
+		code      int
+	}{
+		{"unsigned read", "GET", "signed-key", 0, "", fiber.StatusOK, 0},
+		{"key without secret", "POST", "writer-key", 0, "", fiber.StatusOK, 0},
+		{"unsigned write", "POST", "signed-key", 0, "", fiber.StatusUnauthorized, errors.ErrCodeSignatureInvalid},
+		{"wrong signature", "POST", "signed-key", now, strings.Repeat("ab", 32), fiber.StatusUnauthorized, errors.ErrCodeSignatureInvalid},
+		{"signed write", "POST", "signed-key", now, fresh, fiber.StatusOK, 0},
+		{"replayed write", "POST", "signed-key", now, fresh, fiber.StatusConflict, errors.ErrCodeSignatureReplayed},
+		{"stale write", "POST", "signed-key", stale, auth.SignRequest([]byte("hmac-secret"), stale, "POST", "/tasks", []byte(body)), fiber.StatusUnauthorized, errors.ErrCodeSignatureExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/tasks", strings.NewReader(body))
+			req.Header.Set(APIKeyHeader, tt.key)
+			if tt.signature != "" {
+				req.Header.Set(SignatureTimestampHeader, strconv.FormatInt(tt.timestamp, 10))
+				req.Header.Set(SignatureHeader, tt.signature)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.expected {
+				t.Fatalf("Expected status %d, got %d", tt.expected, resp.StatusCode)
+			}
+			if tt.code == 0 {
+				return
+			}
+
+			var errResp errors.ErrorResponse
+			json.NewDecoder(resp.Body).Decode(&errResp)
+			if errResp.Code != tt.code {
+				t.Errorf("Expected error code %d, got %d", tt.code, errResp.Code)
+			}
+		})
+	}
+}
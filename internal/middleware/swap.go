@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"sync/atomic"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Swappable is a middleware whose handler can be replaced while the app is
+// serving, for settings reloaded at runtime. Fiber's middleware stack is fixed
+// once routes are registered, so the stack holds Handler() and Swap changes
+// what it delegates to.
+type Swappable struct {
+	current atomic.Pointer[fiber.Handler]
+}
+
+// NewSwappable creates a Swappable delegating to h; nil passes requests through
+func NewSwappable(h fiber.Handler) *Swappable {
+	s := &Swappable{}
+	s.Swap(h)
+	return s
+}
+
+// Swap replaces the handler; nil passes requests through. Requests already
+// inside the old handler finish there.
+func (s *Swappable) Swap(h fiber.Handler) {
+	if h == nil {
+		h = Pass
+	}
+	s.current.Store(&h)
+}
+
+// Handler returns the middleware to register with the app
+func (s *Swappable) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return (*s.current.Load())(c)
+	}
+}
+
+// Pass is a middleware that does nothing
+func Pass(c *fiber.Ctx) error {
+	return c.Next()
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSwappable(t *testing.T) {
+	swap := NewSwappable(nil)
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(swap.Handler())
+	app.Post("/tasks", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusCreated) })
+
+	post := func() int {
+		resp, err := app.Test(httptest.NewRequest("POST", "/tasks", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+
+	if status := post(); status != fiber.StatusCreated {
+		t.Errorf("Expected nil handler to pass through, got %d", status)
+	}
+
+	swap.Swap(ReadOnlyGuard())
+	if status := post(); status != fiber.StatusMethodNotAllowed {
+		t.Errorf("Expected swapped guard to reject writes, got %d", status)
+	}
+
+	swap.Swap(nil)
+	if status := post(); status != fiber.StatusCreated {
+		t.Errorf("Expected pass-through after swapping back, got %d", status)
+	}
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RouteTimeouts maps route keys such as "GET /tasks" or "PUT /tasks/:id" to
+// request deadlines. A zero duration disables the deadline for that route.
+type RouteTimeouts map[string]time.Duration
+
+// DefaultRouteTimeouts gives list reads more time than single-task operations
+var DefaultRouteTimeouts = RouteTimeouts{
+	"GET /tasks":        2 * time.Second,
+	"GET /tasks/:id":    500 * time.Millisecond,
+	"POST /tasks":       500 * time.Millisecond,
+	"PUT /tasks/:id":    500 * time.Millisecond,
+	"DELETE /tasks/:id": 500 * time.Millisecond,
+}
+
+// ParseRouteTimeouts parses "GET /tasks=2s,PUT /tasks/:id=500ms"
+func ParseRouteTimeouts(s string) (RouteTimeouts, error) {
+	timeouts := make(RouteTimeouts)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, durStr, ok := strings.Cut(entry, "=")
+		method, path, hasPath := strings.Cut(strings.TrimSpace(route), " ")
+		if !ok || !hasPath || method == "" || !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("invalid route timeout %q (want \"METHOD /path=duration\")", entry)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(durStr))
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid duration in route timeout %q", entry)
+		}
+		timeouts[strings.ToUpper(method)+" "+strings.TrimSpace(path)] = d
+	}
+	return timeouts, nil
+}
+
+// lookup returns the deadline for method and path; ":param" segments in a
+// route key match any single path segment
+func (t RouteTimeouts) lookup(method, path string) time.Duration {
+	if d, ok := t[method+" "+path]; ok {
+		return d
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for key, d := range t {
+		keyMethod, pattern, _ := strings.Cut(key, " ")
+		if keyMethod == method && matchRoute(strings.Split(strings.Trim(pattern, "/"), "/"), segments) {
+			return d
+		}
+	}
+	return 0
+}
+
+func matchRoute(pattern, segments []string) bool {
+	if len(pattern) != len(segments) {
+		return false
+	}
+	for i, p := range pattern {
+		if strings.HasPrefix(p, ":") {
+			if segments[i] == "" {
+				return false
+			}
+		} else if p != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Timeout bounds each request by its route's deadline. The deadline is set on
+// c.UserContext(), which handlers pass down to the context-aware store; they
+// answer 504 with ErrTimeout once it expires. Routes without an entry run
+// unbounded.
+func Timeout(timeouts RouteTimeouts) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		d := timeouts.lookup(c.Method(), c.Path())
+		if d <= 0 {
+			return c.Next()
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), d)
+		defer cancel()
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
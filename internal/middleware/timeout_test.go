@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestParseRouteTimeouts(t *testing.T) {
+	got, err := ParseRouteTimeouts("get /tasks=2s, PUT /tasks/:id=500ms,DELETE /tasks/:id=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := RouteTimeouts{
+		"GET /tasks":        2 * time.Second,
+		"PUT /tasks/:id":    500 * time.Millisecond,
+		"DELETE /tasks/:id": 0,
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+	for key, d := range expected {
+		if got[key] != d {
+			t.Errorf("Expected %s=%v, got %v", key, d, got[key])
+		}
+	}
+
+	for _, invalid := range []string{"GET=1s", "/tasks=1s", "GET /tasks", "GET /tasks=soon", "GET /tasks=-1s"} {
+		if _, err := ParseRouteTimeouts(invalid); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	app := setupTestApp()
+	app.Use(Timeout(RouteTimeouts{
+		"GET /tasks":     2 * time.Second,
+		"PUT /tasks/:id": 500 * time.Millisecond,
+	}))
+
+	var remaining time.Duration
+	var bounded bool
+	probe := func(c *fiber.Ctx) error {
+		var deadline time.Time
+		deadline, bounded = c.UserContext().Deadline()
+		remaining = time.Until(deadline)
+		return c.SendStatus(fiber.StatusOK)
+	}
+	app.Get("/tasks", probe)
+	app.Get("/tasks/changes", probe)
+	app.Put("/tasks/:id", probe)
+
+	tests := []struct {
+		method  string
+		path    string
+		bounded bool
+		max     time.Duration
+	}{
+		{"GET", "/tasks", true, 2 * time.Second},
+		{"PUT", "/tasks/7", true, 500 * time.Millisecond},
+		{"GET", "/tasks/changes", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			if _, err := app.Test(httptest.NewRequest(tt.method, tt.path, nil)); err != nil {
+				t.Fatal(err)
+			}
+			if bounded != tt.bounded {
+				t.Fatalf("Expected deadline set %v, got %v", tt.bounded, bounded)
+			}
+			if tt.bounded && (remaining <= 0 || remaining > tt.max) {
+				t.Errorf("Expected deadline within %v, got %v", tt.max, remaining)
+			}
+		})
+	}
+}
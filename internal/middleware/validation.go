@@ -18,17 +18,11 @@ func ValidateRequest[T requests.Validatable]() fiber.Handler {
 		var req T
 
 		if err := c.BodyParser(&req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(&errors.ErrorResponse{
-				Message: err.Error(),
-				Code:    errors.ErrCodeInvalidJSON,
-			})
+			return errors.ErrInvalidJSON.WithMessage(err.Error())
 		}
 
 		if err := req.Validate(); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(&errors.ErrorResponse{
-				Message: err.Error(),
-				Code:    errors.ErrCodeTaskInvalidInput,
-			})
+			return errors.ErrTaskInvalidInput.WithMessage(err.Error())
 		}
 
 		c.Locals("validated_request", req)
@@ -43,10 +37,7 @@ func ValidatePathID() fiber.Handler {
 		idStr := c.Params("id")
 		id, err := strconv.Atoi(idStr)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(&errors.ErrorResponse{
-				Code:    errors.ErrCodeInvalidID,
-				Message: "ID must be a valid integer",
-			})
+			return errors.ErrInvalidID
 		}
 
 		c.Locals("validated_id", id)
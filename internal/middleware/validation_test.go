@@ -11,7 +11,7 @@ import (
 )
 
 func setupTestApp() *fiber.App {
-	return fiber.New()
+	return fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
 }
 
 func TestValidateRequest_Success(t *testing.T) {
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"tasks-service-demo/internal/storage/mirror"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// StorageVariant picks the store variant of each request for a storage A/B
+// experiment and passes it to the store through the request context. The
+// variant is echoed in the routing header of the response.
+func StorageVariant(router *mirror.Router) fiber.Handler {
+	header := router.Config().Header
+	return func(c *fiber.Ctx) error {
+		v := router.Pick(c.Get(header))
+		c.SetUserContext(mirror.WithVariant(c.UserContext(), v))
+		c.Set(header, string(v))
+		return c.Next()
+	}
+}
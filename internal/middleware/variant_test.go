@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"tasks-service-demo/internal/storage/mirror"
+	"tasks-service-demo/internal/storage/naive"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestStorageVariant(t *testing.T) {
+	m := mirror.New(naive.NewMemoryStore(), naive.NewMemoryStore())
+	router := mirror.NewRouter(m, mirror.Config{})
+
+	app := fiber.New()
+	app.Use(StorageVariant(router))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(string(mirror.VariantFrom(c.UserContext())))
+	})
+
+	for tag, expected := range map[string]mirror.Variant{"": mirror.Primary, "alternate": mirror.Alternate} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(mirror.DefaultHeader, tag)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := resp.Header.Get(mirror.DefaultHeader); got != string(expected) {
+			t.Errorf("Expected variant %s for %q, got %s", expected, tag, got)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tasks-service-demo/internal/dlq"
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/jobs"
+	"tasks-service-demo/internal/logger"
+)
+
+// Package notify sends task notifications by email. Messages are rendered
+// from templates, skipped for users who opted out, and delivered by
+// background jobs that retry with exponential backoff.
+
+// Kind selects the notification template
+type Kind string
+
+const (
+	KindReminder   Kind = "reminder"
+	KindAssignment Kind = "assignment"
+)
+
+// Kinds lists every notification kind
+var Kinds = []Kind{KindReminder, KindAssignment}
+
+var (
+	// ErrDisabled is returned by Send when no SMTP relay is configured
+	ErrDisabled = errors.New("email notifications are not configured")
+	// ErrOptedOut is returned by Send when the user declined email
+	ErrOptedOut = errors.New("recipient opted out of email notifications")
+)
+
+// Message is a notification about a task for one user
+type Message struct {
+	Kind Kind
+	User string // Principal subject, used for opt-out
+	To   string // Email address
+	Task *entities.Task
+	Note string // Optional free text
+}
+
+// Email is a rendered message ready to send
+type Email struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Sender delivers a rendered email
+type Sender interface {
+	Send(ctx context.Context, email Email) error
+}
+
+// RetryPolicy controls redelivery of failed sends
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration // Delay before the second attempt; doubles after each failure
+	MaxBackoff  time.Duration
+}
+
+// DefaultRetryPolicy makes 5 attempts over roughly half a minute
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 5, Backoff: 2 * time.Second, MaxBackoff: time.Minute}
+
+// delay returns the wait after the given failed attempt (1-based)
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// DefaultWorkers bounds concurrent SMTP deliveries
+const DefaultWorkers = 4
+
+// Config tunes delivery
+type Config struct {
+	Retry   RetryPolicy
+	Workers int
+}
+
+// Notifier queues notifications for delivery
+type Notifier struct {
+	sender   Sender
+	renderer *Renderer
+	retry    RetryPolicy
+	slots    chan struct{} // Limits concurrent sends
+
+	mu       sync.RWMutex
+	optedOut map[string]bool
+}
+
+// New creates a Notifier. A nil sender disables delivery but keeps
+// opt-out preferences working.
+func New(sender Sender, renderer *Renderer, cfg Config) *Notifier {
+	if cfg.Retry.MaxAttempts <= 0 {
+		cfg.Retry = DefaultRetryPolicy
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = DefaultWorkers
+	}
+	return &Notifier{
+		sender:   sender,
+		renderer: renderer,
+		retry:    cfg.Retry,
+		slots:    make(chan struct{}, cfg.Workers),
+		optedOut: make(map[string]bool),
+	}
+}
+
+// Enabled reports whether a sender is configured
+func (n *Notifier) Enabled() bool {
+	return n.sender != nil
+}
+
+// SetOptOut records whether user declines email notifications
+func (n *Notifier) SetOptOut(user string, optOut bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if optOut {
+		n.optedOut[user] = true
+	} else {
+		delete(n.optedOut, user)
+	}
+}
+
+// OptedOut reports whether user declines email notifications
+func (n *Notifier) OptedOut(user string) bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.optedOut[user]
+}
+
+// Send renders msg and queues it as a background job, returning the job ID.
+// Rendering errors are returned immediately; delivery errors are retried
+// and end up in the job status.
+func (n *Notifier) Send(msg Message) (string, error) {
+	if !n.Enabled() {
+		return "", ErrDisabled
+	}
+	if n.OptedOut(msg.User) {
+		return "", ErrOptedOut
+	}
+
+	email, err := n.renderer.Render(msg)
+	if err != nil {
+		return "", fmt.Errorf("render %s: %w", msg.Kind, err)
+	}
+	return jobs.Get().Submit("email-"+string(msg.Kind), n.deliver(msg.User, email)), nil
+}
+
+// DeadLetterSource names email deliveries in the dead-letter queue
+const DeadLetterSource = "email"
+
+// deadLetter is the dead-letter payload of an email that could not be sent
+type deadLetter struct {
+	User  string `json:"user"`
+	Email Email  `json:"email"`
+}
+
+// Replay queues a dead-lettered email for delivery again and returns the
+// job ID. It is the dlq.Replayer for DeadLetterSource.
+func (n *Notifier) Replay(_ context.Context, payload json.RawMessage) (string, error) {
+	if !n.Enabled() {
+		return "", ErrDisabled
+	}
+	var dl deadLetter
+	if err := json.Unmarshal(payload, &dl); err != nil {
+		return "", fmt.Errorf("decode dead letter: %w", err)
+	}
+	if n.OptedOut(dl.User) {
+		return "", ErrOptedOut
+	}
+	return jobs.Get().Submit("email-replay", n.deliver(dl.User, dl.Email)), nil
+}
+
+// deliver returns a job that sends email, retrying per the retry policy.
+// An email that still fails after the last attempt goes to the dead-letter
+// queue; cancellation (shutdown) does not count as a failure.
+func (n *Notifier) deliver(user string, email Email) jobs.Func {
+	return func(ctx context.Context, p *jobs.Progress) error {
+		select {
+		case n.slots <- struct{}{}:
+			defer func() { <-n.slots }()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		p.SetTotal(1)
+		var err error
+		for attempt := 1; attempt <= n.retry.MaxAttempts; attempt++ {
+			if err = n.sender.Send(ctx, email); err == nil {
+				p.Add(1)
+				p.SetMessage(fmt.Sprintf("sent to %s after %d attempt(s)", email.To, attempt))
+				return nil
+			}
+			p.SetMessage(fmt.Sprintf("attempt %d/%d failed: %v", attempt, n.retry.MaxAttempts, err))
+			if attempt == n.retry.MaxAttempts {
+				break
+			}
+
+			delay := n.retry.delay(attempt)
+			logger.For(logger.ModuleJobs).Warnw("Email delivery failed, retrying", "to", email.To, "attempt", attempt, "retryIn", delay, "error", err)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if _, dlqErr := dlq.Get().Add(DeadLetterSource, deadLetter{User: user, Email: email}, n.retry.MaxAttempts, err); dlqErr != nil {
+			logger.For(logger.ModuleJobs).Errorw("Failed to save dead letter", "to", email.To, "error", dlqErr)
+		}
+		return fmt.Errorf("send to %s: %w", email.To, err)
+	}
+}
+
+var current atomic.Pointer[Notifier]
+
+func init() {
+	Reset()
+}
+
+// Get returns the process-wide Notifier
+func Get() *Notifier {
+	return current.Load()
+}
+
+// Init replaces the process-wide Notifier
+func Init(n *Notifier) {
+	current.Store(n)
+}
+
+// Reset installs a disabled Notifier using the default templates
+func Reset() {
+	renderer, err := NewRenderer(DefaultTemplates)
+	if err != nil {
+		panic(err)
+	}
+	current.Store(New(nil, renderer, Config{}))
+}
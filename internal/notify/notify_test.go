@@ -0,0 +1,201 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"tasks-service-demo/internal/dlq"
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/jobs"
+)
+
+type fakeSender struct {
+	mu       sync.Mutex
+	failures int // Sends to fail before succeeding
+	sent     []Email
+	attempts int
+}
+
+func (f *fakeSender) Send(ctx context.Context, email Email) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts++
+	if f.attempts <= f.failures {
+		return errors.New("connection refused")
+	}
+	f.sent = append(f.sent, email)
+	return nil
+}
+
+func defaultRenderer(t *testing.T) *Renderer {
+	t.Helper()
+	r, err := NewRenderer(DefaultTemplates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func waitForJob(t *testing.T, id string) jobs.Status {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if s, _ := jobs.Get().Status(id); s.State == jobs.StateSucceeded || s.State == jobs.StateFailed {
+			return s
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Job %s did not finish", id)
+	return jobs.Status{}
+}
+
+func TestRenderer_Render(t *testing.T) {
+	r := defaultRenderer(t)
+
+	email, err := r.Render(Message{
+		Kind: KindReminder,
+		User: "alice",
+		To:   "alice@example.com",
+		Task: &entities.Task{ID: 7, Name: "Pay <rent>\r\nBcc: evil@example.com"},
+		Note: "Due today",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if email.Subject != "Reminder: Pay <rent> Bcc: evil@example.com" {
+		t.Errorf("Expected single-line subject, got %q", email.Subject)
+	}
+	if !strings.Contains(email.Text, "task #7") || !strings.Contains(email.Text, "Due today") {
+		t.Errorf("Unexpected text body %q", email.Text)
+	}
+	if !strings.Contains(email.HTML, "Pay &lt;rent&gt;") {
+		t.Errorf("Expected escaped HTML body, got %q", email.HTML)
+	}
+
+	if _, err := r.Render(Message{Kind: "digest", Task: &entities.Task{}}); err == nil {
+		t.Error("Expected error for unknown kind")
+	}
+}
+
+func TestNewRenderer_CustomTemplates(t *testing.T) {
+	fsys := fstest.MapFS{
+		"reminder.txt.tmpl":    {Data: []byte(`{{define "subject"}}Ping {{.Task.ID}}{{end}}ping`)},
+		"reminder.html.tmpl":   {Data: []byte(`<p>ping</p>`)},
+		"assignment.txt.tmpl":  {Data: []byte(`{{define "subject"}}Yours{{end}}yours`)},
+		"assignment.html.tmpl": {Data: []byte(`<p>yours</p>`)},
+	}
+	r, err := NewRenderer(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if email, _ := r.Render(Message{Kind: KindReminder, Task: &entities.Task{ID: 3}}); email.Subject != "Ping 3" {
+		t.Errorf("Expected custom subject, got %q", email.Subject)
+	}
+
+	delete(fsys, "assignment.html.tmpl")
+	if _, err := NewRenderer(fsys); err == nil {
+		t.Error("Expected error for missing template")
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 10, Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, want := range expected {
+		if got := p.delay(i + 1); got != want {
+			t.Errorf("Attempt %d: expected %s, got %s", i+1, want, got)
+		}
+	}
+}
+
+func TestNotifier_SendRetries(t *testing.T) {
+	jobs.Reset()
+	defer jobs.Reset()
+
+	sender := &fakeSender{failures: 2}
+	n := New(sender, defaultRenderer(t), Config{Retry: RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}})
+	msg := Message{Kind: KindAssignment, User: "bob", To: "bob@example.com", Task: &entities.Task{ID: 1, Name: "Ship"}}
+
+	id, err := n.Send(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := waitForJob(t, id); s.State != jobs.StateSucceeded || s.Done != 1 {
+		t.Errorf("Expected delivery on the third attempt, got %+v", s)
+	}
+	if len(sender.sent) != 1 || sender.sent[0].Subject != "Assigned to you: Ship" {
+		t.Errorf("Unexpected sent emails %+v", sender.sent)
+	}
+
+	sender.failures, sender.attempts = 10, 0
+	id, _ = n.Send(msg)
+	if s := waitForJob(t, id); s.State != jobs.StateFailed || !strings.Contains(s.LastError, "connection refused") {
+		t.Errorf("Expected failure after retries, got %+v", s)
+	}
+	if sender.attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", sender.attempts)
+	}
+}
+
+func TestNotifier_OptOutAndDisabled(t *testing.T) {
+	n := New(&fakeSender{}, defaultRenderer(t), Config{})
+	n.SetOptOut("carol", true)
+
+	if _, err := n.Send(Message{Kind: KindReminder, User: "carol", Task: &entities.Task{}}); err != ErrOptedOut {
+		t.Errorf("Expected ErrOptedOut, got %v", err)
+	}
+	n.SetOptOut("carol", false)
+	if n.OptedOut("carol") {
+		t.Error("Expected opt-out to be cleared")
+	}
+
+	disabled := New(nil, defaultRenderer(t), Config{})
+	if _, err := disabled.Send(Message{Kind: KindReminder, Task: &entities.Task{}}); err != ErrDisabled {
+		t.Errorf("Expected ErrDisabled, got %v", err)
+	}
+}
+
+func TestNotifier_DeadLetterReplay(t *testing.T) {
+	jobs.Reset()
+	defer jobs.Reset()
+	dlq.Reset()
+	defer dlq.Reset()
+
+	sender := &fakeSender{failures: 2}
+	n := New(sender, defaultRenderer(t), Config{Retry: RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond}})
+	dlq.Get().Register(DeadLetterSource, n.Replay)
+
+	id, _ := n.Send(Message{Kind: KindReminder, User: "dave", To: "dave@example.com", Task: &entities.Task{ID: 4, Name: "Renew"}})
+	waitForJob(t, id)
+
+	entries := dlq.Get().List()
+	if len(entries) != 1 || entries[0].Source != DeadLetterSource || entries[0].Attempts != 2 {
+		t.Fatalf("Expected one email dead letter, got %+v", entries)
+	}
+
+	id, err := dlq.Get().Replay(context.Background(), entries[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := waitForJob(t, id); s.State != jobs.StateSucceeded {
+		t.Errorf("Expected replay to deliver, got %+v", s)
+	}
+	if len(sender.sent) != 1 || sender.sent[0].To != "dave@example.com" || sender.sent[0].Subject != "Reminder: Renew" {
+		t.Errorf("Unexpected sent emails %+v", sender.sent)
+	}
+	if len(dlq.Get().List()) != 0 {
+		t.Error("Expected replayed entry to be removed")
+	}
+
+	n.SetOptOut("dave", true)
+	e, _ := dlq.Get().Add(DeadLetterSource, deadLetter{User: "dave"}, 1, nil)
+	if _, err := dlq.Get().Replay(context.Background(), e.ID); err != ErrOptedOut {
+		t.Errorf("Expected ErrOptedOut, got %v", err)
+	}
+}
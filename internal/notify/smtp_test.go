@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"testing"
+	"time"
+)
+
+func TestBuildMessage(t *testing.T) {
+	raw, err := buildMessage("tasks@example.com", Email{
+		To:      "dave@example.com",
+		Subject: "Erinnerung: Müll",
+		Text:    "plain body",
+		HTML:    "<p>html body</p>",
+	}, time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if subject != "Erinnerung: Müll" || msg.Header.Get("To") != "dave@example.com" {
+		t.Errorf("Unexpected headers %v", msg.Header)
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if mediaType != "multipart/alternative" {
+		t.Fatalf("Expected multipart/alternative, got %s", mediaType)
+	}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	var parts []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(part)
+		parts = append(parts, part.Header.Get("Content-Type")+"|"+string(body))
+	}
+
+	expected := []string{"text/plain; charset=utf-8|plain body", "text/html; charset=utf-8|<p>html body</p>"}
+	if len(parts) != 2 || parts[0] != expected[0] || parts[1] != expected[1] {
+		t.Errorf("Expected %v, got %v", expected, parts)
+	}
+}
+
+func TestNewSMTPSender_Validation(t *testing.T) {
+	if _, err := NewSMTPSender(SMTPConfig{Addr: "localhost", From: "a@b.c"}); err == nil {
+		t.Error("Expected error for address without port")
+	}
+	if _, err := NewSMTPSender(SMTPConfig{Addr: "localhost:25"}); err == nil {
+		t.Error("Expected error for missing sender")
+	}
+	if _, err := NewSMTPSender(SMTPConfig{Addr: "localhost:25", From: "a@b.c"}); err != nil {
+		t.Errorf("Expected valid config, got %v", err)
+	}
+}
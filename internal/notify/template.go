@@ -0,0 +1,82 @@
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"strings"
+	texttemplate "text/template"
+)
+
+// DefaultTemplates holds the built-in templates. Each kind has a
+// <kind>.txt.tmpl defining a "subject" block and a <kind>.html.tmpl.
+//
+//go:embed templates/*.tmpl
+var DefaultTemplates embed.FS
+
+// Renderer turns a Message into an Email using text and HTML templates
+type Renderer struct {
+	text map[Kind]*texttemplate.Template
+	html map[Kind]*htmltemplate.Template
+}
+
+// NewRenderer parses the templates of every kind from fsys. Templates are
+// looked up in fsys directly or under templates/, so both DefaultTemplates
+// and a plain directory work.
+func NewRenderer(fsys fs.FS) (*Renderer, error) {
+	if sub, err := fs.Sub(fsys, "templates"); err == nil {
+		if _, err := fs.Stat(sub, string(Kinds[0])+".txt.tmpl"); err == nil {
+			fsys = sub
+		}
+	}
+
+	r := &Renderer{
+		text: make(map[Kind]*texttemplate.Template),
+		html: make(map[Kind]*htmltemplate.Template),
+	}
+	for _, kind := range Kinds {
+		text, err := texttemplate.ParseFS(fsys, string(kind)+".txt.tmpl")
+		if err != nil {
+			return nil, err
+		}
+		if text.Lookup("subject") == nil {
+			return nil, fmt.Errorf("%s.txt.tmpl: missing subject block", kind)
+		}
+		html, err := htmltemplate.ParseFS(fsys, string(kind)+".html.tmpl")
+		if err != nil {
+			return nil, err
+		}
+		r.text[kind] = text
+		r.html[kind] = html
+	}
+	return r, nil
+}
+
+// Render executes the templates for msg.Kind
+func (r *Renderer) Render(msg Message) (Email, error) {
+	text, ok := r.text[msg.Kind]
+	if !ok {
+		return Email{}, fmt.Errorf("unknown notification kind %q", msg.Kind)
+	}
+
+	var subject, body, html bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", msg); err != nil {
+		return Email{}, err
+	}
+	if err := text.Execute(&body, msg); err != nil {
+		return Email{}, err
+	}
+	if err := r.html[msg.Kind].Execute(&html, msg); err != nil {
+		return Email{}, err
+	}
+
+	return Email{
+		To: msg.To,
+		// Task names may contain line breaks; keep them out of the header
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Text:    body.String(),
+		HTML:    html.String(),
+	}, nil
+}
@@ -0,0 +1,51 @@
+package payloadlog
+
+import (
+	"math/rand"
+	"strings"
+	"time"
+
+	"tasks-service-demo/internal/logger"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Middleware logs sampled request and response bodies while payload logging
+// is enabled. Errors are rendered through the app's ErrorHandler first so the
+// logged response is the one the client receives. Headers are never logged.
+// Paths under any of the skip prefixes are not logged.
+func Middleware(skip ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		cfg := Get()
+		if !cfg.Enabled || rand.Float64() >= cfg.SampleRate {
+			return c.Next()
+		}
+		path := c.Path()
+		for _, prefix := range skip {
+			if strings.HasPrefix(path, prefix) {
+				return c.Next()
+			}
+		}
+
+		start := time.Now()
+		if err := c.Next(); err != nil {
+			if herr := c.App().Config().ErrorHandler(c, err); herr != nil {
+				return herr
+			}
+		}
+
+		reqBody, reqTruncated := cfg.Sanitize(c.Body(), string(c.Request().Header.ContentType()))
+		respBody, respTruncated := cfg.Sanitize(c.Response().Body(), string(c.Response().Header.ContentType()))
+		logger.For(logger.ModuleHTTP).Infow("HTTP payload",
+			"method", c.Method(),
+			"path", path,
+			"status", c.Response().StatusCode(),
+			"latency", time.Since(start),
+			"request", reqBody,
+			"requestTruncated", reqTruncated,
+			"response", respBody,
+			"responseTruncated", respTruncated,
+		)
+		return nil
+	}
+}
@@ -0,0 +1,125 @@
+package payloadlog
+
+import (
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"tasks-service-demo/internal/redact"
+)
+
+// Package payloadlog holds the process-wide settings for debug logging of
+// request and response bodies, and the redaction applied before logging.
+
+const (
+	DefaultSampleRate = 0.1  // Fraction of requests logged when enabled
+	DefaultMaxBytes   = 2048 // Per-body cap; longer bodies are truncated
+	Redacted          = "[REDACTED]"
+)
+
+// DefaultRedact lists JSON fields that are always masked. Task payloads have
+// no sensitive fields today; these cover credentials clients might send.
+var DefaultRedact = []string{"password", "token", "secret", "apiKey", "authorization", "email"}
+
+// Config controls payload logging
+type Config struct {
+	Enabled    bool     `json:"enabled"`
+	SampleRate float64  `json:"sampleRate"` // 0..1
+	MaxBytes   int      `json:"maxBytes"`
+	Redact     []string `json:"redact"` // JSON field names, matched case-insensitively at any depth
+}
+
+// DefaultConfig is disabled, with the default sampling, cap and redaction
+func DefaultConfig() Config {
+	return Config{
+		SampleRate: DefaultSampleRate,
+		MaxBytes:   DefaultMaxBytes,
+		Redact:     append([]string(nil), DefaultRedact...),
+	}
+}
+
+// redactSet returns the lower-cased redaction field names, including the
+// task fields redacted everywhere by the redact rules
+func (c Config) redactSet() map[string]bool {
+	rules := redact.Get()
+	set := make(map[string]bool, len(c.Redact)+len(rules.Fields))
+	for _, f := range c.Redact {
+		set[strings.ToLower(f)] = true
+	}
+	for _, f := range rules.Fields {
+		set[strings.ToLower(f)] = true
+	}
+	return set
+}
+
+// Sanitize prepares a body for logging: JSON bodies have redacted fields
+// masked, other bodies are only described, and the result is capped at
+// MaxBytes. truncated reports whether the cap was hit.
+func (c Config) Sanitize(body []byte, contentType string) (out string, truncated bool) {
+	if len(body) == 0 {
+		return "", false
+	}
+
+	if strings.HasPrefix(contentType, "application/json") {
+		var v interface{}
+		if err := json.Unmarshal(body, &v); err == nil {
+			redacted, _ := json.Marshal(redactJSON(v, c.redactSet()))
+			return capBytes(string(redacted), c.MaxBytes)
+		}
+	}
+	// Forms, text and binary bodies may carry anything: don't log their content
+	return "<" + contentType + " body omitted>", false
+}
+
+func redactJSON(v interface{}, fields map[string]bool) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, inner := range val {
+			if fields[strings.ToLower(k)] {
+				val[k] = Redacted
+			} else {
+				val[k] = redactJSON(inner, fields)
+			}
+		}
+	case []interface{}:
+		for i, inner := range val {
+			val[i] = redactJSON(inner, fields)
+		}
+	}
+	return v
+}
+
+// capBytes truncates s to at most max bytes without splitting a rune
+func capBytes(s string, max int) (string, bool) {
+	if max <= 0 || len(s) <= max {
+		return s, false
+	}
+	s = s[:max]
+	for len(s) > 0 && !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s, true
+}
+
+var current atomic.Pointer[Config]
+
+func init() {
+	Reset()
+}
+
+// Get returns the active configuration
+func Get() Config {
+	return *current.Load()
+}
+
+// Set replaces the active configuration
+func Set(cfg Config) {
+	current.Store(&cfg)
+}
+
+// Reset restores DefaultConfig
+func Reset() {
+	cfg := DefaultConfig()
+	current.Store(&cfg)
+}
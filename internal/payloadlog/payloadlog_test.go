@@ -0,0 +1,111 @@
+package payloadlog
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/redact"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSanitize_RedactsNestedFields(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Redact = append(cfg.Redact, "note")
+
+	body := `{"name":"a","Password":"p","nested":{"token":"t","items":[{"note":"n","keep":1}]}}`
+	out, truncated := cfg.Sanitize([]byte(body), "application/json; charset=utf-8")
+	if truncated {
+		t.Error("Expected no truncation")
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("Expected JSON output, got %q", out)
+	}
+	nested := got["nested"].(map[string]interface{})
+	item := nested["items"].([]interface{})[0].(map[string]interface{})
+	if got["Password"] != Redacted || nested["token"] != Redacted || item["note"] != Redacted {
+		t.Errorf("Expected sensitive fields redacted, got %s", out)
+	}
+	if got["name"] != "a" || item["keep"] != float64(1) {
+		t.Errorf("Expected other fields kept, got %s", out)
+	}
+}
+
+func TestSanitize_TruncatesAndOmits(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxBytes = 10
+
+	out, truncated := cfg.Sanitize([]byte(`{"name":"ééééééééé"}`), "application/json")
+	if !truncated || len(out) > 10 || !strings.HasPrefix(out, `{"name":"`) {
+		t.Errorf("Expected truncated output of at most 10 bytes, got %q", out)
+	}
+
+	out, _ = cfg.Sanitize([]byte("password=secret"), "application/x-www-form-urlencoded")
+	if strings.Contains(out, "secret") {
+		t.Errorf("Expected non-JSON body omitted, got %q", out)
+	}
+
+	if out, _ := cfg.Sanitize(nil, "application/json"); out != "" {
+		t.Errorf("Expected empty body to stay empty, got %q", out)
+	}
+}
+
+func TestMiddleware_PassesThrough(t *testing.T) {
+	defer Reset()
+	cfg := DefaultConfig()
+	cfg.Enabled = true
+	cfg.SampleRate = 1
+	Set(cfg)
+
+	app := fiber.New(fiber.Config{ErrorHandler: func(c *fiber.Ctx, err error) error {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"code": err.(*apperrors.AppError).Code})
+	}})
+	app.Use(Middleware("/health"))
+	app.Get("/ok", func(c *fiber.Ctx) error { return c.JSON(fiber.Map{"ok": true}) })
+	app.Get("/missing", func(c *fiber.Ctx) error { return apperrors.ErrTaskNotFound })
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/ok", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := io.ReadAll(resp.Body); resp.StatusCode != fiber.StatusOK || string(data) != `{"ok":true}` {
+		t.Errorf("Expected response unchanged, got %d %s", resp.StatusCode, data)
+	}
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/missing", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("Expected error rendered by the ErrorHandler, got %d", resp.StatusCode)
+	}
+}
+
+func TestSet_Reset(t *testing.T) {
+	defer Reset()
+	Set(Config{Enabled: true, SampleRate: 0.5})
+	if cfg := Get(); !cfg.Enabled || cfg.SampleRate != 0.5 {
+		t.Errorf("Expected updated config, got %+v", cfg)
+	}
+
+	Reset()
+	if cfg := Get(); cfg.Enabled || cfg.SampleRate != DefaultSampleRate || cfg.MaxBytes != DefaultMaxBytes {
+		t.Errorf("Expected defaults after Reset, got %+v", cfg)
+	}
+}
+
+func TestSanitize_RedactsTaskFields(t *testing.T) {
+	redact.Set(redact.Rules{Fields: []string{"name"}})
+	defer redact.Reset()
+
+	out, _ := DefaultConfig().Sanitize([]byte(`{"tasks":[{"id":1,"name":"Call Dr. Smith"}]}`), "application/json")
+	if strings.Contains(out, "Smith") || !strings.Contains(out, `"id":1`) {
+		t.Errorf("Expected the task name masked, got %s", out)
+	}
+}
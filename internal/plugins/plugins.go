@@ -0,0 +1,158 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plugin"
+	"sort"
+	"sync"
+
+	"tasks-service-demo/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Package plugins loads Go plugins (shared objects built with
+// -buildmode=plugin) that add storage backends and middleware at startup.
+//
+// A plugin is a main package exporting
+//
+//	func Register(r plugins.Registrar) error
+//
+// It must be built with the same Go toolchain and the same version of this
+// module as the server, which is a requirement of the Go plugin package.
+// Plugins are trusted code running in the server process.
+
+// RegisterSymbol is the function every plugin exports
+const RegisterSymbol = "Register"
+
+// MiddlewareFactory creates a middleware enabled through PLUGIN_MIDDLEWARE
+type MiddlewareFactory func() (fiber.Handler, error)
+
+// Registrar is passed to a plugin's Register function
+type Registrar interface {
+	// RegisterStore adds a backend selectable with STORAGE_TYPE
+	RegisterStore(b storage.Backend) error
+	// RegisterMiddleware adds a middleware that can be enabled by name
+	RegisterMiddleware(name string, factory MiddlewareFactory) error
+}
+
+// Info reports what a plugin registered
+type Info struct {
+	Path       string
+	Stores     []string
+	Middleware []string
+}
+
+var (
+	mu         sync.RWMutex
+	middleware = make(map[string]MiddlewareFactory)
+)
+
+// Middleware returns the middleware factory registered as name
+func Middleware(name string) (MiddlewareFactory, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	factory, ok := middleware[name]
+	return factory, ok
+}
+
+// MiddlewareNames returns the registered middleware names, sorted
+func MiddlewareNames() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(middleware))
+	for name := range middleware {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// registrar records the registrations of one plugin
+type registrar struct {
+	info *Info
+}
+
+func (r registrar) RegisterStore(b storage.Backend) error {
+	if err := storage.Register(b); err != nil {
+		return err
+	}
+	r.info.Stores = append(r.info.Stores, b.Name)
+	return nil
+}
+
+func (r registrar) RegisterMiddleware(name string, factory MiddlewareFactory) error {
+	if name == "" || factory == nil {
+		return fmt.Errorf("middleware needs a name and a factory")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _, exists := middleware[name]; exists {
+		return fmt.Errorf("middleware %q is already registered", name)
+	}
+	middleware[name] = factory
+	r.info.Middleware = append(r.info.Middleware, name)
+	return nil
+}
+
+// symbols is the part of *plugin.Plugin the loader uses
+type symbols interface {
+	Lookup(name string) (plugin.Symbol, error)
+}
+
+func openPlugin(path string) (symbols, error) {
+	return plugin.Open(path)
+}
+
+// LoadDir loads every *.so file in dir in name order. It stops at the first
+// plugin that fails to load or register, since a half-configured server is
+// worse than one that doesn't start.
+func LoadDir(dir string) ([]Info, error) {
+	return loadDir(dir, openPlugin)
+}
+
+func loadDir(dir string, open func(string) (symbols, error)) ([]Info, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("plugin directory: %w", err)
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.so"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	infos := make([]Info, 0, len(paths))
+	for _, path := range paths {
+		info, err := load(path, open)
+		if err != nil {
+			return infos, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func load(path string, open func(string) (symbols, error)) (Info, error) {
+	info := Info{Path: path}
+
+	p, err := open(path)
+	if err != nil {
+		return info, fmt.Errorf("plugin %s: %w", path, err)
+	}
+	sym, err := p.Lookup(RegisterSymbol)
+	if err != nil {
+		return info, fmt.Errorf("plugin %s: %w", path, err)
+	}
+	register, ok := sym.(func(Registrar) error)
+	if !ok {
+		return info, fmt.Errorf("plugin %s: %s has type %T, want func(plugins.Registrar) error", path, RegisterSymbol, sym)
+	}
+	if err := register(registrar{info: &info}); err != nil {
+		return info, fmt.Errorf("plugin %s: %w", path, err)
+	}
+	return info, nil
+}
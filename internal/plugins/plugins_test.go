@@ -0,0 +1,116 @@
+package plugins
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"plugin"
+	"strings"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/storetest"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// fakePlugin serves exported symbols from a map
+type fakePlugin map[string]plugin.Symbol
+
+func (p fakePlugin) Lookup(name string) (plugin.Symbol, error) {
+	if sym, ok := p[name]; ok {
+		return sym, nil
+	}
+	return nil, fmt.Errorf("symbol %s not found", name)
+}
+
+func resetMiddleware() {
+	mu.Lock()
+	defer mu.Unlock()
+	middleware = make(map[string]MiddlewareFactory)
+}
+
+func pluginDir(t *testing.T, names ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range names {
+		os.WriteFile(filepath.Join(dir, name), nil, 0o644)
+	}
+	return dir
+}
+
+func TestLoadDir(t *testing.T) {
+	defer resetMiddleware()
+	storeName := fmt.Sprintf("plugin-test-%d", time.Now().UnixNano())
+	dir := pluginDir(t, "b.so", "a.so", "readme.txt")
+
+	plugins := map[string]fakePlugin{
+		"a.so": {RegisterSymbol: func(r Registrar) error {
+			return r.RegisterStore(storage.Backend{
+				Name: storeName,
+				New:  func(storage.Options) (storage.Store, error) { return storetest.NewFakeStore(), nil },
+			})
+		}},
+		"b.so": {RegisterSymbol: func(r Registrar) error {
+			return r.RegisterMiddleware("noop", func() (fiber.Handler, error) { return nil, nil })
+		}},
+	}
+	open := func(path string) (symbols, error) {
+		return plugins[filepath.Base(path)], nil
+	}
+
+	infos, err := loadDir(dir, open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 2 || infos[0].Stores[0] != storeName || infos[1].Middleware[0] != "noop" {
+		t.Fatalf("Unexpected plugins %+v", infos)
+	}
+	if _, ok := storage.Lookup(storeName); !ok {
+		t.Error("Expected plugin store in the storage registry")
+	}
+	if _, ok := Middleware("noop"); !ok {
+		t.Error("Expected plugin middleware to be registered")
+	}
+}
+
+func TestLoadDir_Errors(t *testing.T) {
+	defer resetMiddleware()
+	noop := func() (fiber.Handler, error) { return nil, nil }
+
+	tests := []struct {
+		name   string
+		plugin fakePlugin
+		open   error
+		want   string
+	}{
+		{"open fails", nil, errors.New("bad ELF"), "bad ELF"},
+		{"missing symbol", fakePlugin{}, nil, "not found"},
+		{"wrong type", fakePlugin{RegisterSymbol: func() {}}, nil, "want func(plugins.Registrar) error"},
+		{"register fails", fakePlugin{RegisterSymbol: func(r Registrar) error {
+			r.RegisterMiddleware("dup", noop)
+			return r.RegisterMiddleware("dup", noop)
+		}}, nil, "already registered"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			open := func(string) (symbols, error) {
+				if tt.open != nil {
+					return nil, tt.open
+				}
+				return tt.plugin, nil
+			}
+			_, err := loadDir(pluginDir(t, "p.so"), open)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	if _, err := LoadDir(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected missing directory to be an error")
+	}
+}
@@ -0,0 +1,44 @@
+package projection
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collector exports the lag of the process-wide Engine as Prometheus metrics
+type collector struct {
+	applied, lagRevisions, lagSeconds, rebuilds *prometheus.Desc
+}
+
+// NewCollector returns a Prometheus collector for the staleness of the
+// projections. Register it once.
+func NewCollector(namespace string) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "projection", name), help, nil, nil)
+	}
+	return &collector{
+		applied:      desc("applied_revision", "Last change feed revision applied to the projections."),
+		lagRevisions: desc("lag_revisions", "Changes written but not applied to the projections yet."),
+		lagSeconds:   desc("lag_seconds", "Age of the oldest change not applied to the projections yet."),
+		rebuilds:     desc("rebuilds_total", "Rebuilds of the projections from the store."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.applied
+	ch <- c.lagRevisions
+	ch <- c.lagSeconds
+	ch <- c.rebuilds
+}
+
+// Collect implements prometheus.Collector
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	s := Get().Stats(false)
+	if !s.Enabled {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.applied, prometheus.GaugeValue, float64(s.AppliedRevision))
+	ch <- prometheus.MustNewConstMetric(c.lagRevisions, prometheus.GaugeValue, float64(s.LagRevisions))
+	ch <- prometheus.MustNewConstMetric(c.lagSeconds, prometheus.GaugeValue, s.LagSeconds)
+	ch <- prometheus.MustNewConstMetric(c.rebuilds, prometheus.CounterValue, float64(s.Rebuilds))
+}
@@ -0,0 +1,279 @@
+package projection
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tasks-service-demo/internal/changes"
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/storage"
+)
+
+// Package projection maintains read models derived from the tasks, such as
+// counts by status, without scanning the store on every read. An Engine
+// follows the change feed and applies each write to every view as it
+// happens. When the feed has dropped changes the engine has not applied,
+// e.g. after a burst longer than the history, it rebuilds the views from a
+// snapshot of the store instead.
+
+// batch is the number of changes applied per lock of the views
+const batch = 500
+
+// View is a read model kept up to date from the writes. Views are keyed by
+// task ID, so applying the same write twice leaves them unchanged; the
+// engine relies on that when a rebuild races with writes.
+type View interface {
+	Name() string
+	// Put sets the task as the latest write left it
+	Put(task *entities.Task)
+	// Remove drops a deleted task
+	Remove(id int)
+	// Reset empties the view before a rebuild
+	Reset()
+	// Snapshot returns the current state of the view for GET /admin/projections
+	Snapshot() any
+}
+
+// Stats reports how far the views trail the writes
+type Stats struct {
+	Enabled         bool           `json:"enabled"`
+	Revision        uint64         `json:"revision"`        // Of the change feed
+	AppliedRevision uint64         `json:"appliedRevision"` // Last change applied to the views
+	LagRevisions    uint64         `json:"lagRevisions"`
+	LagSeconds      float64        `json:"lagSeconds"` // Age of the oldest change not applied yet
+	Rebuilds        uint64         `json:"rebuilds"`
+	LastRebuild     *time.Time     `json:"lastRebuild,omitempty"`
+	Views           map[string]any `json:"views,omitempty"`
+}
+
+// Engine applies the change feed to a set of views
+type Engine struct {
+	feed  *changes.Feed
+	store storage.Store
+	views []View
+
+	mu          sync.Mutex // Guards the views and applied
+	applied     uint64
+	rebuilds    atomic.Uint64
+	lastRebuild atomic.Pointer[time.Time]
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New creates an Engine applying feed to views; rebuilds read store. The
+// views are empty until Rebuild or Start.
+func New(feed *changes.Feed, store storage.Store, views ...View) *Engine {
+	return &Engine{feed: feed, store: store, views: views}
+}
+
+// Enabled reports whether the engine maintains any view
+func (e *Engine) Enabled() bool {
+	return len(e.views) > 0
+}
+
+// Rebuild replaces the views with the tasks in the store and returns how
+// many it read. Writes made while the store is read are applied again by
+// CatchUp, which the views absorb.
+func (e *Engine) Rebuild() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.rebuildLocked()
+}
+
+func (e *Engine) rebuildLocked() int {
+	rev := e.feed.Revision() // Before reading, so no later write is skipped
+	for _, v := range e.views {
+		v.Reset()
+	}
+	tasks := storage.Snapshot(e.store)
+	for _, task := range tasks {
+		for _, v := range e.views {
+			v.Put(task)
+		}
+	}
+	e.applied = rev
+	now := time.Now()
+	e.lastRebuild.Store(&now)
+	e.rebuilds.Add(1)
+	return len(tasks)
+}
+
+// CatchUp applies the changes written since the last call, rebuilding the
+// views when the feed no longer holds them all
+func (e *Engine) CatchUp() {
+	for {
+		e.mu.Lock()
+		records, current, complete := e.feed.Records(e.applied, batch)
+		if !complete {
+			e.rebuildLocked()
+			e.mu.Unlock()
+			continue
+		}
+		for _, r := range records {
+			e.applyLocked(r)
+		}
+		applied := e.applied
+		e.mu.Unlock()
+		if applied >= current {
+			return
+		}
+	}
+}
+
+// applyLocked applies one change to every view. Creates and updates
+// recorded without the task (Feed.Record) cannot be applied and leave the
+// views as they were.
+func (e *Engine) applyLocked(r changes.Record) {
+	e.applied = r.Revision
+	for _, v := range e.views {
+		switch {
+		case r.Op == changes.OpDeleted:
+			v.Remove(r.TaskID)
+		case r.Task != nil:
+			v.Put(r.Task)
+		}
+	}
+}
+
+// Start rebuilds the views and keeps applying the feed until Close
+func (e *Engine) Start() {
+	if !e.Enabled() {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel, e.done = cancel, make(chan struct{})
+	e.Rebuild()
+	go func() {
+		defer close(e.done)
+		for {
+			e.CatchUp()
+			e.mu.Lock()
+			applied := e.applied
+			e.mu.Unlock()
+			if _, err := e.feed.Wait(ctx, applied); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+// Close stops applying the feed; it has the signature of a shutdown step
+func (e *Engine) Close(ctx context.Context) error {
+	if e.cancel == nil {
+		return nil
+	}
+	e.cancel()
+	select {
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats returns the lag of the views, and their state when withViews is set
+func (e *Engine) Stats(withViews bool) Stats {
+	s := Stats{Enabled: e.Enabled(), Rebuilds: e.rebuilds.Load(), LastRebuild: e.lastRebuild.Load()}
+
+	e.mu.Lock()
+	s.AppliedRevision = e.applied
+	if withViews && s.Enabled {
+		s.Views = make(map[string]any, len(e.views))
+		for _, v := range e.views {
+			s.Views[v.Name()] = v.Snapshot()
+		}
+	}
+	e.mu.Unlock()
+
+	pending, current, _ := e.feed.Records(s.AppliedRevision, 1)
+	s.Revision = current
+	if current > s.AppliedRevision {
+		s.LagRevisions = current - s.AppliedRevision
+	}
+	if len(pending) > 0 && !pending[0].At.IsZero() {
+		s.LagSeconds = time.Since(pending[0].At).Seconds()
+	}
+	return s
+}
+
+// StatusCounts counts the tasks by status
+type StatusCounts struct {
+	status map[int]int // Task ID -> status
+	counts map[int]int // Status -> tasks
+}
+
+// StatusCountsSnapshot is the state of a StatusCounts view
+type StatusCountsSnapshot struct {
+	Total    int         `json:"total"`
+	ByStatus map[int]int `json:"byStatus"`
+}
+
+// NewStatusCounts creates an empty StatusCounts view
+func NewStatusCounts() *StatusCounts {
+	v := &StatusCounts{}
+	v.Reset()
+	return v
+}
+
+// Name implements View
+func (v *StatusCounts) Name() string {
+	return "statusCounts"
+}
+
+// Put implements View
+func (v *StatusCounts) Put(task *entities.Task) {
+	if old, ok := v.status[task.ID]; ok {
+		v.counts[old]--
+	}
+	v.status[task.ID] = task.Status
+	v.counts[task.Status]++
+}
+
+// Remove implements View
+func (v *StatusCounts) Remove(id int) {
+	if old, ok := v.status[id]; ok {
+		v.counts[old]--
+		delete(v.status, id)
+	}
+}
+
+// Reset implements View
+func (v *StatusCounts) Reset() {
+	v.status = make(map[int]int)
+	v.counts = make(map[int]int)
+}
+
+// Snapshot implements View
+func (v *StatusCounts) Snapshot() any {
+	s := StatusCountsSnapshot{Total: len(v.status), ByStatus: make(map[int]int, len(v.counts))}
+	for status, n := range v.counts {
+		if n > 0 {
+			s.ByStatus[status] = n
+		}
+	}
+	return s
+}
+
+var current atomic.Pointer[Engine]
+
+func init() {
+	Reset()
+}
+
+// Get returns the process-wide Engine
+func Get() *Engine {
+	return current.Load()
+}
+
+// Init replaces the process-wide Engine
+func Init(e *Engine) {
+	current.Store(e)
+}
+
+// Reset installs an Engine without views
+func Reset() {
+	current.Store(New(changes.NewFeed(1), nil))
+}
@@ -0,0 +1,106 @@
+package projection
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/changes"
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/storage/storetest"
+)
+
+func counts(e *Engine) StatusCountsSnapshot {
+	return e.Stats(true).Views["statusCounts"].(StatusCountsSnapshot)
+}
+
+func TestEngine_AppliesChanges(t *testing.T) {
+	feed := changes.NewFeed(100)
+	store := changes.NewTrackingStore(storetest.NewFakeStore(), feed)
+	store.Create(&entities.Task{Name: "before start"})
+
+	e := New(feed, store, NewStatusCounts())
+	if n := e.Rebuild(); n != 1 {
+		t.Fatalf("Expected 1 task read by the rebuild, got %d", n)
+	}
+
+	a, b := &entities.Task{Name: "a"}, &entities.Task{Name: "b"}
+	store.Create(a)
+	store.Create(b)
+	if s := e.Stats(false); s.LagRevisions != 2 || s.LagSeconds <= 0 {
+		t.Errorf("Expected 2 changes of lag before catching up, got %+v", s)
+	}
+
+	store.Update(a.ID, &entities.Task{Name: "a", Status: 1})
+	store.Delete(b.ID)
+	e.CatchUp()
+
+	got := counts(e)
+	if got.Total != 2 || got.ByStatus[0] != 1 || got.ByStatus[1] != 1 {
+		t.Errorf("Expected one incomplete and one complete task, got %+v", got)
+	}
+	if s := e.Stats(false); s.LagRevisions != 0 || s.LagSeconds != 0 || s.AppliedRevision != 5 {
+		t.Errorf("Expected no lag at revision 5, got %+v", s)
+	}
+
+	// Applying the same changes again leaves the view unchanged
+	e.mu.Lock()
+	records, _, _ := feed.Records(0, 0)
+	for _, r := range records {
+		e.applyLocked(r)
+	}
+	e.mu.Unlock()
+	if again := counts(e); again.Total != got.Total || again.ByStatus[0] != 1 || again.ByStatus[1] != 1 {
+		t.Errorf("Expected replayed changes absorbed, got %+v", again)
+	}
+}
+
+func TestEngine_RebuildsWhenHistoryDropped(t *testing.T) {
+	feed := changes.NewFeed(2)
+	store := changes.NewTrackingStore(storetest.NewFakeStore(), feed)
+	e := New(feed, store, NewStatusCounts())
+	e.Rebuild()
+
+	for i := 0; i < 5; i++ {
+		store.Create(&entities.Task{Name: "t", Status: i % 2})
+	}
+	e.CatchUp()
+
+	s := e.Stats(true)
+	if s.Rebuilds != 2 || s.AppliedRevision != 5 {
+		t.Errorf("Expected a second rebuild up to revision 5, got %+v", s)
+	}
+	if got := counts(e); got.Total != 5 || got.ByStatus[0] != 3 || got.ByStatus[1] != 2 {
+		t.Errorf("Expected the rebuilt counts of 5 tasks, got %+v", got)
+	}
+}
+
+func TestEngine_Follows(t *testing.T) {
+	feed := changes.NewFeed(100)
+	store := changes.NewTrackingStore(storetest.NewFakeStore(), feed)
+	e := New(feed, store, NewStatusCounts())
+	e.Start()
+	defer e.Close(context.Background())
+
+	store.Create(&entities.Task{Name: "a"})
+	deadline := time.Now().Add(time.Second)
+	for counts(e).Total != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the running engine to apply the write")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := e.Close(context.Background()); err != nil {
+		t.Errorf("Expected a clean stop, got %v", err)
+	}
+}
+
+func TestEngine_Disabled(t *testing.T) {
+	if Get().Enabled() {
+		t.Error("Expected the default engine disabled")
+	}
+	if s := Get().Stats(true); s.Enabled || s.Views != nil {
+		t.Errorf("Expected no views, got %+v", s)
+	}
+}
@@ -0,0 +1,467 @@
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/jobs"
+	"tasks-service-demo/internal/requests"
+	"tasks-service-demo/internal/storage"
+)
+
+// Package reconcile applies batches of offline client mutations against the
+// store, resolving conflicts with last-writer-wins or field-level merge based
+// on per-field modification timestamps.
+
+// Policy selects how concurrent edits to the same task are resolved
+type Policy string
+
+const (
+	PolicyLWW   Policy = "lww"   // The newest mutation replaces the whole task, older ones are rejected
+	PolicyField Policy = "field" // Each field keeps its newest value; older fields are dropped
+)
+
+// ParsePolicy converts a configuration string into a Policy
+func ParsePolicy(s string) (Policy, error) {
+	switch Policy(s) {
+	case PolicyLWW, PolicyField:
+		return Policy(s), nil
+	default:
+		return "", fmt.Errorf("unknown sync conflict policy %q (want lww or field)", s)
+	}
+}
+
+// Mutable task fields tracked for field-level merge
+const (
+	FieldName   = "name"
+	FieldStatus = "status"
+)
+
+var allFields = []string{FieldName, FieldStatus}
+
+// Result status values
+const (
+	StatusApplied  = "applied"  // The mutation was applied as sent
+	StatusMerged   = "merged"   // Some fields were applied, newer server fields were kept
+	StatusConflict = "conflict" // The server copy is newer; nothing was applied
+	StatusRejected = "rejected" // The mutation is invalid
+)
+
+// Conflict explains why a mutation was not (fully) applied
+type Conflict struct {
+	Reason string         `json:"reason"`
+	Fields []string       `json:"fields,omitempty"` // Fields whose server value was kept
+	Server *entities.Task `json:"server,omitempty"` // Server copy after the mutation
+}
+
+// Result reports the outcome of one mutation
+type Result struct {
+	Index    int       `json:"index"`
+	Op       string    `json:"op"`
+	ID       int       `json:"id,omitempty"`
+	TempID   string    `json:"tempId,omitempty"`
+	Status   string    `json:"status"`
+	Conflict *Conflict `json:"conflict,omitempty"`
+}
+
+// taskLocks is the number of mutexes serializing writes to a task, picked
+// by task ID
+const taskLocks = 64
+
+// metaKey is the storage meta record of the field stamps
+const metaKey = "sync-stamps"
+
+// DefaultSaveInterval is how often the stamps are saved to a backend that
+// holds metadata
+const DefaultSaveInterval = time.Minute
+
+// Reconciler applies client mutations and tracks when each task field last changed
+type Reconciler struct {
+	policy Policy
+	st     storage.Store     // Explicit store; nil falls back to the global singleton
+	meta   storage.MetaStore // Saves the stamps; nil keeps them in memory only
+
+	batchMu sync.Mutex // Serializes Apply calls
+	locks   [taskLocks]sync.Mutex
+	stamped atomic.Bool // A StampingStore records API writes
+
+	mu     sync.Mutex // Guards stamps and dirty
+	stamps map[int]map[string]time.Time
+	dirty  bool // Stamps changed since the last Save
+}
+
+// New creates a Reconciler with policy; a nil store uses the global store.
+// Stamps are saved in meta when it is not nil.
+func New(policy Policy, store storage.Store, meta storage.MetaStore) *Reconciler {
+	if policy == "" {
+		policy = PolicyLWW
+	}
+	return &Reconciler{policy: policy, st: store, meta: meta, stamps: make(map[int]map[string]time.Time)}
+}
+
+// Policy returns the configured conflict policy
+func (r *Reconciler) Policy() Policy {
+	return r.policy
+}
+
+// Stamped reports whether a StampingStore records the writes of regular
+// API requests in r. Without one only mutations applied by Apply are
+// stamped, so LastModified knows nothing about most tasks.
+func (r *Reconciler) Stamped() bool {
+	return r.stamped.Load()
+}
+
+func (r *Reconciler) store() storage.Store {
+	if r.st != nil {
+		return r.st
+	}
+	return storage.GetStore()
+}
+
+// writer returns the store Apply writes to: the store below the
+// StampingStore of r, which would stamp the writes with server time and
+// wait for the task lock Apply already holds, or the whole store when r
+// has no StampingStore
+func (r *Reconciler) writer() storage.Store {
+	for s := r.store(); s != nil; {
+		if stamping, ok := s.(*StampingStore); ok && stamping.reconciler == r {
+			return stamping.inner
+		}
+		wrapper, ok := s.(interface{ Inner() storage.Store })
+		if !ok {
+			break
+		}
+		s = wrapper.Inner()
+	}
+	return r.store()
+}
+
+// lockTask locks the writes to task id and returns the unlock function.
+// Apply holds the lock from reading a task to writing it, and the
+// StampingStore takes it for every other write, so none lands in between.
+func (r *Reconciler) lockTask(id int) func() {
+	mu := &r.locks[uint(id)%taskLocks]
+	mu.Lock()
+	return mu.Unlock
+}
+
+// Touch records that fields of task id changed at ts. Later timestamps win,
+// so out-of-order calls never move a stamp backwards.
+func (r *Reconciler) Touch(id int, ts time.Time, fields ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stamps, ok := r.stamps[id]
+	if !ok {
+		stamps = make(map[string]time.Time, len(allFields))
+		r.stamps[id] = stamps
+	}
+	for _, f := range fields {
+		if ts.After(stamps[f]) {
+			stamps[f] = ts
+			r.dirty = true
+		}
+	}
+}
+
+// LastModified returns when task id was last written, the latest of its
+// stamps; ok is false when it has none
+func (r *Reconciler) LastModified(id int) (at time.Time, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, ts := range r.stamps[id] {
+		if ts.After(at) {
+			at = ts
+		}
+	}
+	return at, !at.IsZero()
+}
+
+// snapshot returns a copy of the stamps of task id
+func (r *Reconciler) snapshot(id int) map[string]time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stamps := make(map[string]time.Time, len(allFields))
+	for f, ts := range r.stamps[id] {
+		stamps[f] = ts
+	}
+	return stamps
+}
+
+// Forget drops the stamps of a deleted task
+func (r *Reconciler) Forget(id int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.stamps[id]; ok {
+		delete(r.stamps, id)
+		r.dirty = true
+	}
+}
+
+// Load restores the stamps saved by Save. Stamps recorded since are kept
+// where they are newer.
+func (r *Reconciler) Load() error {
+	if r.meta == nil {
+		return nil
+	}
+	data, ok, err := r.meta.GetMeta(metaKey)
+	if err != nil || !ok {
+		return err
+	}
+	var saved map[int]map[string]time.Time
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("parse saved sync stamps: %w", err)
+	}
+	for id, stamps := range saved {
+		for f, ts := range stamps {
+			r.Touch(id, ts, f)
+		}
+	}
+	return nil
+}
+
+// Save writes the stamps to the backend when they changed since the last
+// save
+func (r *Reconciler) Save() error {
+	if r.meta == nil {
+		return nil
+	}
+	r.mu.Lock()
+	if !r.dirty {
+		r.mu.Unlock()
+		return nil
+	}
+	data, err := json.Marshal(r.stamps)
+	r.dirty = false
+	r.mu.Unlock()
+
+	if err == nil {
+		err = r.meta.PutMeta(metaKey, data)
+	}
+	if err != nil {
+		r.mu.Lock()
+		r.dirty = true
+		r.mu.Unlock()
+	}
+	return err
+}
+
+// Persistent reports whether the stamps are saved in the backend
+func (r *Reconciler) Persistent() bool {
+	return r.meta != nil
+}
+
+// Job returns the job saving the stamps
+func (r *Reconciler) Job() jobs.Func {
+	return func(ctx context.Context, p *jobs.Progress) error {
+		return r.Save()
+	}
+}
+
+// Apply applies mutations in order and returns one result per mutation
+func (r *Reconciler) Apply(mutations []requests.SyncMutation) []Result {
+	r.batchMu.Lock()
+	defer r.batchMu.Unlock()
+
+	results := make([]Result, len(mutations))
+	for i, m := range mutations {
+		res := Result{Index: i, Op: m.Op, ID: m.ID, TempID: m.TempID}
+		ts := time.UnixMilli(m.Timestamp)
+
+		switch m.Op {
+		case requests.SyncOpCreate:
+			r.applyCreate(&res, m, ts)
+		case requests.SyncOpUpdate:
+			r.applyUpdate(&res, m, ts)
+		case requests.SyncOpDelete:
+			r.applyDelete(&res, m, ts)
+		}
+		results[i] = res
+	}
+	return results
+}
+
+func (r *Reconciler) applyCreate(res *Result, m requests.SyncMutation, ts time.Time) {
+	if m.Name == nil {
+		res.Status = StatusRejected
+		res.Conflict = &Conflict{Reason: "name is required to create a task"}
+		return
+	}
+
+	task := &entities.Task{Name: *m.Name}
+	if m.Status != nil {
+		task.Status = *m.Status
+	}
+	if err := r.writer().Create(task); err != nil {
+		res.Status = StatusRejected
+		res.Conflict = &Conflict{Reason: err.Message}
+		return
+	}
+
+	res.ID = task.ID
+	res.Status = StatusApplied
+	r.Touch(task.ID, ts, allFields...)
+}
+
+func (r *Reconciler) applyUpdate(res *Result, m requests.SyncMutation, ts time.Time) {
+	defer r.lockTask(m.ID)()
+
+	current, err := storage.GetByIDStrong(r.writer(), m.ID)
+	if err != nil {
+		res.Status = StatusConflict
+		res.Conflict = &Conflict{Reason: "task was deleted on the server"}
+		return
+	}
+
+	sent := sentFields(m)
+	if len(sent) == 0 {
+		res.Status = StatusRejected
+		res.Conflict = &Conflict{Reason: "update sets no fields"}
+		return
+	}
+
+	stamps := r.snapshot(m.ID)
+
+	// Under LWW the task is one value: any newer server field rejects the
+	// whole mutation, including fields the mutation does not touch
+	if r.policy == PolicyLWW {
+		var newer []string
+		for _, f := range allFields {
+			if ts.Before(stamps[f]) {
+				newer = append(newer, f)
+			}
+		}
+		if len(newer) > 0 {
+			res.Status = StatusConflict
+			res.Conflict = &Conflict{Reason: "server copy is newer", Fields: newer, Server: copyTask(current)}
+			return
+		}
+	}
+
+	var apply, kept []string
+	for _, f := range sent {
+		if ts.Before(stamps[f]) {
+			kept = append(kept, f)
+		} else {
+			apply = append(apply, f)
+		}
+	}
+	if len(apply) == 0 {
+		res.Status = StatusConflict
+		res.Conflict = &Conflict{Reason: "server copy is newer", Fields: kept, Server: copyTask(current)}
+		return
+	}
+
+	updated := copyTask(current)
+	for _, f := range apply {
+		switch f {
+		case FieldName:
+			updated.Name = *m.Name
+		case FieldStatus:
+			updated.Status = *m.Status
+		}
+	}
+	if err := r.writer().Update(m.ID, updated); err != nil {
+		res.Status = StatusRejected
+		res.Conflict = &Conflict{Reason: err.Message}
+		return
+	}
+	r.Touch(m.ID, ts, apply...)
+
+	res.Status = StatusApplied
+	if len(kept) > 0 {
+		res.Status = StatusMerged
+		res.Conflict = &Conflict{Reason: "newer server fields kept", Fields: kept, Server: copyTask(updated)}
+	}
+}
+
+func (r *Reconciler) applyDelete(res *Result, m requests.SyncMutation, ts time.Time) {
+	defer r.lockTask(m.ID)()
+
+	current, err := storage.GetByIDStrong(r.writer(), m.ID)
+	if err != nil {
+		// Already gone: deletes are idempotent
+		res.Status = StatusApplied
+		return
+	}
+
+	// A delete loses against any edit made after it
+	var newer []string
+	for f, stamp := range r.snapshot(m.ID) {
+		if ts.Before(stamp) {
+			newer = append(newer, f)
+		}
+	}
+	if len(newer) > 0 {
+		res.Status = StatusConflict
+		res.Conflict = &Conflict{Reason: "task was modified after the delete", Fields: sortFields(newer), Server: copyTask(current)}
+		return
+	}
+
+	if err := r.writer().Delete(m.ID); err != nil {
+		res.Status = StatusRejected
+		res.Conflict = &Conflict{Reason: err.Message}
+		return
+	}
+	r.Forget(m.ID)
+	res.Status = StatusApplied
+}
+
+// sentFields lists the fields a mutation sets, in allFields order
+func sentFields(m requests.SyncMutation) []string {
+	var fields []string
+	if m.Name != nil {
+		fields = append(fields, FieldName)
+	}
+	if m.Status != nil {
+		fields = append(fields, FieldStatus)
+	}
+	return fields
+}
+
+// sortFields orders fields as in allFields
+func sortFields(fields []string) []string {
+	sorted := make([]string, 0, len(fields))
+	for _, f := range allFields {
+		for _, g := range fields {
+			if f == g {
+				sorted = append(sorted, f)
+			}
+		}
+	}
+	return sorted
+}
+
+// copyTask returns a snapshot so responses don't alias stored tasks
+func copyTask(t *entities.Task) *entities.Task {
+	c := *t
+	return &c
+}
+
+var current atomic.Pointer[Reconciler]
+
+func init() {
+	Reset()
+}
+
+// Get returns the process-wide Reconciler
+func Get() *Reconciler {
+	return current.Load()
+}
+
+// Init replaces the process-wide Reconciler
+func Init(r *Reconciler) {
+	current.Store(r)
+}
+
+// Reset installs an LWW Reconciler on the global store
+func Reset() {
+	current.Store(New(PolicyLWW, nil, nil))
+}
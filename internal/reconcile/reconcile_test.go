@@ -0,0 +1,264 @@
+package reconcile
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/requests"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/storetest"
+)
+
+func strPtr(s string) *string { return &s }
+func intPtr(i int) *int       { return &i }
+
+// setup returns a reconciler whose store stamps API writes like production
+func setup(policy Policy) (*Reconciler, *StampingStore) {
+	fake := storetest.NewFakeStore()
+	r := New(policy, nil, nil)
+	store := NewStampingStore(fake, r)
+	r.st = store
+	return r, store
+}
+
+func TestParsePolicy(t *testing.T) {
+	for _, valid := range []string{"lww", "field"} {
+		if p, err := ParsePolicy(valid); err != nil || string(p) != valid {
+			t.Errorf("Expected %s to parse, got %q, %v", valid, p, err)
+		}
+	}
+	if _, err := ParsePolicy("crdt"); err == nil {
+		t.Error("Expected error for unknown policy")
+	}
+}
+
+func TestApply_CreateMapsTempID(t *testing.T) {
+	r, store := setup(PolicyLWW)
+
+	results := r.Apply([]requests.SyncMutation{
+		{Op: requests.SyncOpCreate, TempID: "tmp-1", Name: strPtr("offline"), Status: intPtr(1), Timestamp: 1000},
+		{Op: requests.SyncOpCreate, TempID: "tmp-2", Timestamp: 1000},
+	})
+
+	if results[0].Status != StatusApplied || results[0].TempID != "tmp-1" || results[0].ID == 0 {
+		t.Errorf("Expected applied create with server ID, got %+v", results[0])
+	}
+	if task, err := store.GetByID(results[0].ID); err != nil || task.Name != "offline" || task.Status != 1 {
+		t.Errorf("Expected stored task, got %+v, %v", task, err)
+	}
+	if results[1].Status != StatusRejected {
+		t.Errorf("Expected create without name to be rejected, got %+v", results[1])
+	}
+}
+
+func TestApply_LWW(t *testing.T) {
+	r, store := setup(PolicyLWW)
+	task := &entities.Task{Name: "server"}
+	store.Create(task)
+	now := time.Now().UnixMilli()
+
+	results := r.Apply([]requests.SyncMutation{
+		// Made offline before the API created the task: loses
+		{Op: requests.SyncOpUpdate, ID: task.ID, Name: strPtr("stale"), Timestamp: now - 60_000},
+		// Made after: wins
+		{Op: requests.SyncOpUpdate, ID: task.ID, Name: strPtr("fresh"), Timestamp: now + 1000},
+		// Older than the previous mutation in the same batch: loses
+		{Op: requests.SyncOpUpdate, ID: task.ID, Status: intPtr(1), Timestamp: now + 500},
+	})
+
+	if results[0].Status != StatusConflict || results[0].Conflict.Server.Name != "server" {
+		t.Errorf("Expected conflict reporting server copy, got %+v", results[0])
+	}
+	if results[1].Status != StatusApplied {
+		t.Errorf("Expected newer update to apply, got %+v", results[1])
+	}
+	if results[2].Status != StatusConflict || !reflect.DeepEqual(results[2].Conflict.Fields, []string{FieldName}) {
+		t.Errorf("Expected conflict on the newer name, got %+v", results[2])
+	}
+
+	stored, _ := store.GetByID(task.ID)
+	if stored.Name != "fresh" || stored.Status != 0 {
+		t.Errorf("Expected fresh/0, got %+v", stored)
+	}
+}
+
+func TestApply_FieldMerge(t *testing.T) {
+	r, store := setup(PolicyField)
+	task := &entities.Task{Name: "a"}
+	store.Create(task)
+	base := time.Now().UnixMilli() + 1000
+
+	// Client A renames at base+10, client B completes and renames at base
+	results := r.Apply([]requests.SyncMutation{
+		{Op: requests.SyncOpUpdate, ID: task.ID, Name: strPtr("from A"), Timestamp: base + 10},
+		{Op: requests.SyncOpUpdate, ID: task.ID, Name: strPtr("from B"), Status: intPtr(1), Timestamp: base},
+	})
+
+	if results[0].Status != StatusApplied {
+		t.Errorf("Expected first update applied, got %+v", results[0])
+	}
+	if results[1].Status != StatusMerged || !reflect.DeepEqual(results[1].Conflict.Fields, []string{FieldName}) {
+		t.Errorf("Expected merge keeping name, got %+v", results[1])
+	}
+
+	stored, _ := store.GetByID(task.ID)
+	if stored.Name != "from A" || stored.Status != 1 {
+		t.Errorf("Expected name from A and status from B, got %+v", stored)
+	}
+}
+
+func TestApply_Delete(t *testing.T) {
+	r, store := setup(PolicyLWW)
+	task := &entities.Task{Name: "a"}
+	store.Create(task)
+	now := time.Now().UnixMilli()
+
+	results := r.Apply([]requests.SyncMutation{
+		{Op: requests.SyncOpDelete, ID: task.ID, Timestamp: now - 60_000},
+		{Op: requests.SyncOpDelete, ID: task.ID, Timestamp: now + 1000},
+		{Op: requests.SyncOpDelete, ID: task.ID, Timestamp: now + 2000},
+		{Op: requests.SyncOpUpdate, ID: task.ID, Name: strPtr("late"), Timestamp: now + 3000},
+	})
+
+	expected := []string{StatusConflict, StatusApplied, StatusApplied, StatusConflict}
+	for i, status := range expected {
+		if results[i].Status != status {
+			t.Errorf("Mutation %d: expected %s, got %+v", i, status, results[i])
+		}
+	}
+	if _, err := store.GetByID(task.ID); err == nil {
+		t.Error("Expected task to be deleted")
+	}
+}
+
+func TestStampingStore_APIWriteBeatsOfflineEdit(t *testing.T) {
+	r, store := setup(PolicyField)
+	task := &entities.Task{Name: "a"}
+	store.Create(task)
+
+	time.Sleep(5 * time.Millisecond)
+	offline := time.Now().UnixMilli()
+	time.Sleep(5 * time.Millisecond)
+	store.Update(task.ID, &entities.Task{Name: "a", Status: 1})
+
+	results := r.Apply([]requests.SyncMutation{
+		{Op: requests.SyncOpUpdate, ID: task.ID, Name: strPtr("b"), Status: intPtr(0), Timestamp: offline},
+	})
+
+	// Only status changed through the API, so the offline rename still applies
+	if results[0].Status != StatusMerged || !reflect.DeepEqual(results[0].Conflict.Fields, []string{FieldStatus}) {
+		t.Errorf("Expected merge keeping status, got %+v", results[0])
+	}
+	stored, _ := store.GetByID(task.ID)
+	if stored.Name != "b" || stored.Status != 1 {
+		t.Errorf("Expected b/1, got %+v", stored)
+	}
+}
+
+// pausingStore blocks the first GetByID until resume is closed
+type pausingStore struct {
+	storage.Store
+	paused  atomic.Bool
+	reading chan struct{}
+	resume  chan struct{}
+}
+
+func (s *pausingStore) GetByID(id int) (*entities.Task, *apperrors.AppError) {
+	if s.paused.CompareAndSwap(false, true) {
+		close(s.reading)
+		<-s.resume
+	}
+	return s.Store.GetByID(id)
+}
+
+func TestApply_APIWriteNotLost(t *testing.T) {
+	fake := storetest.NewFakeStore()
+	task := &entities.Task{Name: "a"}
+	fake.Create(task)
+
+	paused := &pausingStore{Store: fake, reading: make(chan struct{}), resume: make(chan struct{})}
+	r := New(PolicyField, nil, nil)
+	store := NewStampingStore(paused, r)
+	r.st = store
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		r.Apply([]requests.SyncMutation{
+			{Op: requests.SyncOpUpdate, ID: task.ID, Status: intPtr(1), Timestamp: time.Now().UnixMilli()},
+		})
+	}()
+	<-paused.reading
+
+	// An API rename between the read and the write of the mutation waits
+	// for it instead of being overwritten by the copy it read
+	written := make(chan struct{})
+	go func() {
+		store.Update(task.ID, &entities.Task{Name: "api", Status: 1})
+		close(written)
+	}()
+	select {
+	case <-written:
+		t.Error("Expected the API write to wait for the mutation")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(paused.resume)
+	wg.Wait()
+	<-written
+
+	stored, _ := store.GetByID(task.ID)
+	if stored.Name != "api" || stored.Status != 1 {
+		t.Errorf("Expected api/1, got %+v", stored)
+	}
+}
+
+type memMeta struct {
+	records map[string][]byte
+	puts    int
+}
+
+func (m *memMeta) GetMeta(key string) ([]byte, bool, error) {
+	value, ok := m.records[key]
+	return value, ok, nil
+}
+
+func (m *memMeta) PutMeta(key string, value []byte) error {
+	m.records[key] = value
+	m.puts++
+	return nil
+}
+
+func TestReconciler_SaveLoad(t *testing.T) {
+	meta := &memMeta{records: make(map[string][]byte)}
+	old := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	r := New(PolicyLWW, nil, meta)
+	r.Touch(1, old, FieldName, FieldStatus)
+	r.Touch(2, old, FieldName)
+	if err := r.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Save(); err != nil || meta.puts != 1 {
+		t.Errorf("Expected unchanged stamps not to be saved again, got %d puts, %v", meta.puts, err)
+	}
+
+	// Stamps recorded before Load are kept where they are newer
+	restarted := New(PolicyLWW, nil, meta)
+	now := time.Now()
+	restarted.Touch(1, now, FieldStatus)
+	if err := restarted.Load(); err != nil {
+		t.Fatal(err)
+	}
+	stamps := restarted.snapshot(1)
+	if !stamps[FieldName].Equal(old) || !stamps[FieldStatus].Equal(now) {
+		t.Errorf("Expected the saved name stamp and the newer status stamp, got %v", stamps)
+	}
+	if at, ok := restarted.LastModified(2); !ok || !at.Equal(old) {
+		t.Errorf("Expected the saved stamp of task 2, got %v, %t", at, ok)
+	}
+}
@@ -0,0 +1,105 @@
+package reconcile
+
+import (
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage"
+)
+
+// StampingStore wraps a storage.Store and stamps every successful write with
+// the server time, so offline mutations older than a regular API write lose.
+// Updates and deletes take the task lock of the reconciler, so they never
+// land between the read and the write of a mutation applied by Apply.
+type StampingStore struct {
+	inner      storage.Store
+	reconciler *Reconciler
+}
+
+// NewStampingStore wraps inner, recording write times in reconciler
+func NewStampingStore(inner storage.Store, reconciler *Reconciler) *StampingStore {
+	reconciler.stamped.Store(true)
+	return &StampingStore{inner: inner, reconciler: reconciler}
+}
+
+// Inner returns the wrapped store
+func (s *StampingStore) Inner() storage.Store {
+	return s.inner
+}
+
+// Create stores the task and stamps all of its fields
+func (s *StampingStore) Create(task *entities.Task) *apperrors.AppError {
+	if err := s.inner.Create(task); err != nil {
+		return err
+	}
+	s.reconciler.Touch(task.ID, time.Now(), allFields...)
+	return nil
+}
+
+// GetByID reads from the inner store
+func (s *StampingStore) GetByID(id int) (*entities.Task, *apperrors.AppError) {
+	return s.inner.GetByID(id)
+}
+
+// GetAll reads from the inner store
+func (s *StampingStore) GetAll() []*entities.Task {
+	return s.inner.GetAll()
+}
+
+// GetRange reads from the inner store
+func (s *StampingStore) GetRange(fromID, toID, limit int) []*entities.Task {
+	return s.inner.GetRange(fromID, toID, limit)
+}
+
+// Update replaces the task and stamps the fields that changed
+func (s *StampingStore) Update(id int, task *entities.Task) *apperrors.AppError {
+	defer s.reconciler.lockTask(id)()
+
+	previous, _ := s.inner.GetByID(id)
+	var before entities.Task
+	if previous != nil {
+		before = *previous
+	}
+
+	if err := s.inner.Update(id, task); err != nil {
+		return err
+	}
+
+	var changed []string
+	if previous == nil || before.Name != task.Name {
+		changed = append(changed, FieldName)
+	}
+	if previous == nil || before.Status != task.Status {
+		changed = append(changed, FieldStatus)
+	}
+	s.reconciler.Touch(id, time.Now(), changed...)
+	return nil
+}
+
+// Delete removes the task and its stamps
+func (s *StampingStore) Delete(id int) *apperrors.AppError {
+	defer s.reconciler.lockTask(id)()
+
+	if err := s.inner.Delete(id); err != nil {
+		return err
+	}
+	s.reconciler.Forget(id)
+	return nil
+}
+
+// DeletedWhere drops the stamps of tasks a storage.BulkDeleter below the
+// store deleted
+func (s *StampingStore) DeletedWhere(ids []int) {
+	for _, id := range ids {
+		s.reconciler.Forget(id)
+	}
+}
+
+// Close closes the inner store if it supports closing
+func (s *StampingStore) Close() error {
+	if closer, ok := s.inner.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package redact
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"tasks-service-demo/internal/entities"
+)
+
+// Package redact masks configured task fields where tasks leave the store
+// for logs and exports. The store itself keeps the fields intact, so the API
+// still returns them.
+
+// Mask replaces the value of a redacted field
+const Mask = "[REDACTED]"
+
+// fields maps the JSON name of each redactable task field to its value;
+// only string fields can be masked without changing the field's type
+var fields = map[string]func(*entities.Task) *string{
+	"name": func(t *entities.Task) *string { return &t.Name },
+}
+
+// Rules names the task fields to redact
+type Rules struct {
+	Fields []string `json:"fields"` // JSON field names, e.g. "name"
+}
+
+// ParseFields parses a comma-separated list of task fields, rejecting
+// fields that don't exist or can't be redacted
+func ParseFields(s string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if fields[name] == nil {
+			return nil, fmt.Errorf("unknown task field %q (redactable: %s)", name, strings.Join(Redactable(), ", "))
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// Redactable returns the names of the fields that can be redacted
+func Redactable() []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Enabled reports whether any field is redacted
+func (r Rules) Enabled() bool {
+	return len(r.Fields) > 0
+}
+
+// Task returns task with the redacted fields masked. task is not modified;
+// without rules it is returned as is.
+func (r Rules) Task(task *entities.Task) *entities.Task {
+	if !r.Enabled() || task == nil {
+		return task
+	}
+	masked := *task
+	for _, name := range r.Fields {
+		if field := fields[name]; field != nil {
+			*field(&masked) = Mask
+		}
+		// Subtask names are free text like the task name, and masked with it
+		if name == "name" && len(task.Subtasks) > 0 {
+			masked.Subtasks = make([]entities.Subtask, len(task.Subtasks))
+			for i, st := range task.Subtasks {
+				st.Name = Mask
+				masked.Subtasks[i] = st
+			}
+		}
+	}
+	return &masked
+}
+
+// Tasks is Task for every task of a list
+func (r Rules) Tasks(tasks []*entities.Task) []*entities.Task {
+	if !r.Enabled() {
+		return tasks
+	}
+	masked := make([]*entities.Task, len(tasks))
+	for i, task := range tasks {
+		masked[i] = r.Task(task)
+	}
+	return masked
+}
+
+var current atomic.Pointer[Rules]
+
+func init() {
+	Reset()
+}
+
+// Get returns the active rules
+func Get() Rules {
+	return *current.Load()
+}
+
+// Set replaces the active rules
+func Set(r Rules) {
+	current.Store(&r)
+}
+
+// Reset removes all rules
+func Reset() {
+	current.Store(&Rules{})
+}
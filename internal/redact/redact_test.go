@@ -0,0 +1,51 @@
+package redact
+
+import (
+	"testing"
+
+	"tasks-service-demo/internal/entities"
+)
+
+func TestParseFields(t *testing.T) {
+	names, err := ParseFields(" name, ")
+	if err != nil || len(names) != 1 || names[0] != "name" {
+		t.Errorf("Expected [name], got %v, %v", names, err)
+	}
+	for _, invalid := range []string{"description", "status", "name,id"} {
+		if _, err := ParseFields(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestRules_Task(t *testing.T) {
+	task := &entities.Task{ID: 1, Name: "Call Dr. Smith", Status: 1}
+
+	if got := (Rules{}).Task(task); got != task {
+		t.Error("Expected the task itself without rules")
+	}
+
+	rules := Rules{Fields: []string{"name"}}
+	got := rules.Task(task)
+	if got.Name != Mask || got.ID != 1 || got.Status != 1 {
+		t.Errorf("Expected only the name masked, got %+v", got)
+	}
+	if task.Name != "Call Dr. Smith" {
+		t.Errorf("Expected the original task intact, got %+v", task)
+	}
+	if list := rules.Tasks([]*entities.Task{task, nil}); list[0].Name != Mask || list[1] != nil {
+		t.Errorf("Expected every task masked, got %+v", list)
+	}
+}
+
+func TestSet_Reset(t *testing.T) {
+	defer Reset()
+	Set(Rules{Fields: []string{"name"}})
+	if !Get().Enabled() {
+		t.Error("Expected the rules to be active")
+	}
+	Reset()
+	if Get().Enabled() {
+		t.Error("Expected no rules after Reset")
+	}
+}
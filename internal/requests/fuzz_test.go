@@ -0,0 +1,78 @@
+package requests
+
+import (
+	"encoding/json"
+	"testing"
+	"unicode/utf8"
+)
+
+// expectValid mirrors the validate tags on CreateTaskRequest/UpdateTaskRequest.
+func expectValid(name string, status int) bool {
+	n := utf8.RuneCountInString(name)
+	return n >= 1 && n <= 100 && (status == 0 || status == 1)
+}
+
+func addTaskRequestSeeds(f *testing.F) {
+	seeds := []string{
+		`{"name":"Test Task","status":0}`,
+		`{"name":"Done","status":1}`,
+		`{"name":"","status":0}`,
+		`{"name":"Test","status":2}`,
+		`{"name":"Test","status":-1}`,
+		`{"name":"日本語","status":1}`,
+		`{"status":0}`,
+		`{}`,
+		`[]`,
+		`null`,
+		`{"name":null,"status":null}`,
+		`{"name":123,"status":"0"}`,
+		`{"name":"Test","status":1e400}`,
+		`invalid json`,
+	}
+	for _, s := range seeds {
+		f.Add([]byte(s))
+	}
+}
+
+func FuzzCreateTaskRequest(f *testing.F) {
+	addTaskRequestSeeds(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var req CreateTaskRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			return
+		}
+
+		err := req.Validate()
+		want := expectValid(req.Name, req.Status)
+		if want && err != nil {
+			t.Fatalf("expected %+v to be valid, got %v", req, err)
+		}
+		if !want && err == nil {
+			t.Fatalf("expected %+v to be invalid", req)
+		}
+		if err != nil && err.Message == "" {
+			t.Fatalf("validation error for %+v has empty message", req)
+		}
+	})
+}
+
+func FuzzUpdateTaskRequest(f *testing.F) {
+	addTaskRequestSeeds(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var req UpdateTaskRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			return
+		}
+
+		err := req.Validate()
+		want := expectValid(req.Name, req.Status)
+		if want && err != nil {
+			t.Fatalf("expected %+v to be valid, got %v", req, err)
+		}
+		if !want && err == nil {
+			t.Fatalf("expected %+v to be invalid", req)
+		}
+	})
+}
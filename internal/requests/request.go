@@ -1,6 +1,11 @@
 package requests
 
-import apperrors "tasks-service-demo/internal/errors"
+import (
+	"encoding/json"
+	"fmt"
+
+	apperrors "tasks-service-demo/internal/errors"
+)
 
 // Package requests defines request types and validation logic for the Task API.
 
@@ -16,6 +21,126 @@ type UpdateTaskRequest struct {
 	Status int    `json:"status" validate:"oneof=0 1"`
 }
 
+// CreateSubtaskRequest represents the request body for adding a subtask to
+// a task's checklist.
+type CreateSubtaskRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=100"`
+}
+
+// MaxBatchIDs bounds the IDs of one GetTasksByIDsRequest.
+const MaxBatchIDs = 500
+
+// GetTasksByIDsRequest represents the request body for fetching up to
+// MaxBatchIDs tasks by ID in one call.
+type GetTasksByIDsRequest struct {
+	IDs []int `json:"ids"`
+}
+
+// MaintenanceRequest represents the request body for switching maintenance mode.
+type MaintenanceRequest struct {
+	Mode              string `json:"mode" validate:"required,oneof=off read_only full"`
+	RetryAfterSeconds int    `json:"retryAfterSeconds" validate:"min=0,max=86400"`
+	Reason            string `json:"reason" validate:"max=200"`
+}
+
+// PayloadLoggingRequest represents the request body for toggling payload logging.
+// Omitted SampleRate and MaxBytes keep their current values.
+type PayloadLoggingRequest struct {
+	Enabled    bool     `json:"enabled"`
+	SampleRate *float64 `json:"sampleRate" validate:"omitempty,gte=0,lte=1"`
+	MaxBytes   *int     `json:"maxBytes" validate:"omitempty,min=0,max=65536"`
+}
+
+// LogLevelRequest represents the request body for changing log levels.
+// An omitted Level keeps the root level; a module set to "inherit" follows it again.
+type LogLevelRequest struct {
+	Level   string            `json:"level" validate:"omitempty,oneof=debug info warn error"`
+	Modules map[string]string `json:"modules" validate:"omitempty,dive,keys,oneof=http jobs storage,endkeys,oneof=debug info warn error inherit"`
+}
+
+// ScheduleJobRequest represents the request body for scheduling a recurring job.
+// Params are specific to the kind; kinds without parameters take none.
+type ScheduleJobRequest struct {
+	Name   string          `json:"name" validate:"required,max=64"`
+	Kind   string          `json:"kind" validate:"required"`
+	Cron   string          `json:"cron" validate:"required,max=100"`
+	Params json.RawMessage `json:"params"`
+}
+
+// RetentionPurgeRequest represents the request body for purging retained
+// records. An omitted OlderThanDays uses the configured retention window.
+type RetentionPurgeRequest struct {
+	DryRun        bool `json:"dryRun"`
+	OlderThanDays *int `json:"olderThanDays" validate:"omitempty,min=0,max=3650"`
+}
+
+// CompactStorageRequest represents the request body for compacting the
+// storage backend. An omitted PauseMs uses storage.DefaultCompactPause; Wait
+// runs the compaction within the request instead of a background job.
+type CompactStorageRequest struct {
+	PauseMs *int `json:"pauseMs" validate:"omitempty,min=0,max=10000"`
+	Wait    bool `json:"wait"`
+}
+
+// LoadTestRequest represents the request body for running a benchmark
+// scenario on a scratch store. An empty Storage uses the configured
+// STORAGE_TYPE; omitted numbers use the defaults of the handler and of
+// bench.Config. Wait runs the load test within the request instead of a
+// background job.
+type LoadTestRequest struct {
+	Scenario   string `json:"scenario" validate:"required"`
+	Storage    string `json:"storage"`
+	Dataset    int    `json:"dataset" validate:"omitempty,min=1,max=1000000"`
+	Workers    int    `json:"workers" validate:"omitempty,min=1,max=256"`
+	DurationMs int    `json:"durationMs" validate:"omitempty,min=1,max=60000"`
+	Wait       bool   `json:"wait"`
+}
+
+// CreateShareLinkRequest represents the request body for issuing a share link.
+// A zero TTLSeconds uses the default lifetime.
+type CreateShareLinkRequest struct {
+	TTLSeconds int `json:"ttlSeconds" validate:"min=0,max=604800"`
+}
+
+// NotificationRequest represents the request body for sending a task notification.
+type NotificationRequest struct {
+	Kind   string `json:"kind" validate:"required,oneof=reminder assignment"`
+	TaskID int    `json:"taskId" validate:"required,min=1"`
+	User   string `json:"user" validate:"required,max=200"`
+	Email  string `json:"email" validate:"required,email"`
+	Note   string `json:"note" validate:"max=500"`
+}
+
+// NotificationPreferencesRequest represents the request body for updating
+// the caller's notification preferences.
+type NotificationPreferencesRequest struct {
+	Email *bool `json:"email" validate:"required"`
+}
+
+// Sync mutation operations
+const (
+	SyncOpCreate = "create"
+	SyncOpUpdate = "update"
+	SyncOpDelete = "delete"
+)
+
+// SyncMutation is one offline client edit. Timestamp is the client clock in
+// unix milliseconds when the edit was made; Name and Status are optional so
+// an update only carries the fields the client changed.
+type SyncMutation struct {
+	Op        string  `json:"op" validate:"required,oneof=create update delete"`
+	ID        int     `json:"id" validate:"required_unless=Op create"`
+	TempID    string  `json:"tempId" validate:"max=64"`
+	Name      *string `json:"name" validate:"omitempty,min=1,max=100"`
+	Status    *int    `json:"status" validate:"omitempty,oneof=0 1"`
+	Timestamp int64   `json:"timestamp" validate:"required,gt=0"`
+}
+
+// SyncRequest represents the request body for pushing offline mutations.
+type SyncRequest struct {
+	Mutations []SyncMutation `json:"mutations" validate:"required,min=1,max=500,dive"`
+}
+
 // Validatable is an interface for request validation.
 type Validatable interface {
 	Validate() *apperrors.AppError
@@ -30,3 +155,81 @@ func (c CreateTaskRequest) Validate() *apperrors.AppError {
 func (u UpdateTaskRequest) Validate() *apperrors.AppError {
 	return ValidateStruct(&u)
 }
+
+// Validate validates the CreateSubtaskRequest fields.
+func (r CreateSubtaskRequest) Validate() *apperrors.AppError {
+	return ValidateStruct(&r)
+}
+
+// Validate validates the GetTasksByIDsRequest fields. The IDs are checked by
+// hand: the validator's dive allocates once per ID, which adds up on a path
+// that batching clients hit for every page they render.
+func (r GetTasksByIDsRequest) Validate() *apperrors.AppError {
+	switch {
+	case len(r.IDs) == 0:
+		return apperrors.NewValidationError(apperrors.ErrCodeTaskInvalidInput, "ids is required")
+	case len(r.IDs) > MaxBatchIDs:
+		return apperrors.NewValidationError(apperrors.ErrCodeTaskInvalidInput, fmt.Sprintf("ids must hold at most %d IDs", MaxBatchIDs))
+	}
+	for i, id := range r.IDs {
+		if id < 1 {
+			return apperrors.NewValidationError(apperrors.ErrCodeTaskInvalidInput, fmt.Sprintf("ids[%d] must be a positive task ID", i))
+		}
+	}
+	return nil
+}
+
+// Validate validates the MaintenanceRequest fields.
+func (m MaintenanceRequest) Validate() *apperrors.AppError {
+	return ValidateStruct(&m)
+}
+
+// Validate validates the PayloadLoggingRequest fields.
+func (r PayloadLoggingRequest) Validate() *apperrors.AppError {
+	return ValidateStruct(&r)
+}
+
+// Validate validates the LogLevelRequest fields.
+func (r LogLevelRequest) Validate() *apperrors.AppError {
+	return ValidateStruct(&r)
+}
+
+// Validate validates the ScheduleJobRequest fields.
+func (r ScheduleJobRequest) Validate() *apperrors.AppError {
+	return ValidateStruct(&r)
+}
+
+// Validate validates the RetentionPurgeRequest fields.
+func (r RetentionPurgeRequest) Validate() *apperrors.AppError {
+	return ValidateStruct(&r)
+}
+
+// Validate validates the CompactStorageRequest fields.
+func (r CompactStorageRequest) Validate() *apperrors.AppError {
+	return ValidateStruct(&r)
+}
+
+// Validate validates the LoadTestRequest fields.
+func (r LoadTestRequest) Validate() *apperrors.AppError {
+	return ValidateStruct(&r)
+}
+
+// Validate validates the CreateShareLinkRequest fields.
+func (r CreateShareLinkRequest) Validate() *apperrors.AppError {
+	return ValidateStruct(&r)
+}
+
+// Validate validates the NotificationRequest fields.
+func (r NotificationRequest) Validate() *apperrors.AppError {
+	return ValidateStruct(&r)
+}
+
+// Validate validates the NotificationPreferencesRequest fields.
+func (r NotificationPreferencesRequest) Validate() *apperrors.AppError {
+	return ValidateStruct(&r)
+}
+
+// Validate validates the SyncRequest and each of its mutations.
+func (r SyncRequest) Validate() *apperrors.AppError {
+	return ValidateStruct(&r)
+}
@@ -112,6 +112,37 @@ func TestUpdateTaskRequest_Validation(t *testing.T) {
 	}
 }
 
+func TestGetTasksByIDsRequest_Validation(t *testing.T) {
+	full := make([]int, MaxBatchIDs)
+	for i := range full {
+		full[i] = i + 1
+	}
+
+	tests := []struct {
+		name     string
+		ids      []int
+		expected string
+	}{
+		{"valid", []int{3, 1, 3}, ""},
+		{"largest batch", full, ""},
+		{"missing", nil, "ids is required"},
+		{"too many", append(full, 1), "ids must hold at most 500 IDs"},
+		{"not positive", []int{4, 0}, "ids[1] must be a positive task ID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := GetTasksByIDsRequest{IDs: tt.ids}.Validate()
+			switch {
+			case tt.expected == "" && err != nil:
+				t.Errorf("Expected no validation error, got: %v", err)
+			case tt.expected != "" && (err == nil || err.Message != tt.expected):
+				t.Errorf("Expected %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
 func TestValidatableInterface(t *testing.T) {
 	// Test that both request types implement Validatable interface
 	var createReq Validatable = CreateTaskRequest{Name: "Test", Status: 0}
@@ -0,0 +1,46 @@
+package retention
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collector exports Bin stats as Prometheus metrics
+type collector struct {
+	bin *Bin
+
+	records, purged, wouldPurge *prometheus.Desc
+}
+
+// NewCollector returns a Prometheus collector for the retained and purged
+// record counts, labelled with the record kind. Register it once per Bin.
+func NewCollector(namespace string, bin *Bin) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "retention", name), help, []string{"kind"}, nil)
+	}
+	return &collector{
+		bin:        bin,
+		records:    desc("records", "Soft-deleted tasks and old revisions currently retained."),
+		purged:     desc("purged_total", "Retained records permanently purged."),
+		wouldPurge: desc("dry_run_purgeable", "Records the last dry-run purge would have removed."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.records
+	ch <- c.purged
+	ch <- c.wouldPurge
+}
+
+// Collect implements prometheus.Collector
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.bin.Stats()
+	ch <- prometheus.MustNewConstMetric(c.records, prometheus.GaugeValue, float64(stats.Deleted), KindDeleted)
+	ch <- prometheus.MustNewConstMetric(c.records, prometheus.GaugeValue, float64(stats.Revisions), KindRevision)
+	ch <- prometheus.MustNewConstMetric(c.purged, prometheus.CounterValue, float64(stats.PurgedDeleted), KindDeleted)
+	ch <- prometheus.MustNewConstMetric(c.purged, prometheus.CounterValue, float64(stats.PurgedRevisions), KindRevision)
+	if stats.LastDryRun != nil {
+		ch <- prometheus.MustNewConstMetric(c.wouldPurge, prometheus.GaugeValue, float64(stats.LastDryRun.Deleted), KindDeleted)
+		ch <- prometheus.MustNewConstMetric(c.wouldPurge, prometheus.GaugeValue, float64(stats.LastDryRun.Revisions), KindRevision)
+	}
+}
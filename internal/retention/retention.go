@@ -0,0 +1,353 @@
+package retention
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/jobs"
+	"tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/storage"
+)
+
+// Package retention turns task deletes into soft deletes and keeps the
+// versions replaced by updates, then purges both for good once they are
+// older than the retention window.
+
+const (
+	DefaultMaxRevisions = 10        // Revisions kept per task when none is configured
+	DefaultInterval     = time.Hour // Purge job period when none is configured
+)
+
+// metaKey is the storage.MetaStore record holding the retained records
+const metaKey = "retention-bin"
+
+// Record kinds
+const (
+	KindDeleted  = "deleted"  // A task as it was when deleted
+	KindRevision = "revision" // A task version replaced by an update
+)
+
+// Config is the retention policy
+type Config struct {
+	Window       time.Duration // Records older than this are purged; 0 keeps deletes hard
+	MaxRevisions int           // Revisions kept per task; older ones are dropped on update
+	Interval     time.Duration // Period of the purge job
+	DryRun       bool          // The purge job only counts what it would purge
+}
+
+// Record is a soft-deleted task or an old revision
+type Record struct {
+	Task       entities.Task `json:"task"`
+	Kind       string        `json:"kind"`
+	RetainedAt time.Time     `json:"retainedAt"` // When the task was deleted or the revision replaced
+}
+
+// PurgeResult reports one purge
+type PurgeResult struct {
+	Cutoff    time.Time `json:"cutoff"` // Records retained before this were purged
+	DryRun    bool      `json:"dryRun"`
+	Deleted   int       `json:"deleted"`   // Soft-deleted tasks purged (or that would be)
+	Revisions int       `json:"revisions"` // Revisions purged (or that would be)
+}
+
+// Stats counts what the Bin holds and has purged
+type Stats struct {
+	Deleted         int          `json:"deleted"`
+	Revisions       int          `json:"revisions"`
+	PurgedDeleted   int64        `json:"purgedDeleted"`
+	PurgedRevisions int64        `json:"purgedRevisions"`
+	LastPurge       *time.Time   `json:"lastPurge,omitempty"`
+	LastDryRun      *PurgeResult `json:"lastDryRun,omitempty"`
+}
+
+// Bin holds soft-deleted tasks and replaced revisions until they are
+// purged. They are saved in storage meta on every change when the backend
+// holds it, so they outlive restarts like the tasks do.
+type Bin struct {
+	cfg  Config
+	now  func() time.Time
+	meta storage.MetaStore // Nil keeps the records in memory only
+
+	mu              sync.Mutex
+	deleted         map[int]Record
+	revisions       map[int][]Record // Oldest first
+	revisionCount   int
+	purgedDeleted   int64
+	purgedRevisions int64
+	lastPurge       time.Time
+	lastDryRun      *PurgeResult
+}
+
+// saved is the record of the Bin in storage meta
+type saved struct {
+	Deleted   map[int]Record   `json:"deleted"`
+	Revisions map[int][]Record `json:"revisions"`
+}
+
+// NewBin creates a Bin for cfg, saving its records in meta when it is not
+// nil; a zero Window creates a disabled Bin
+func NewBin(cfg Config, meta storage.MetaStore) *Bin {
+	if cfg.MaxRevisions <= 0 {
+		cfg.MaxRevisions = DefaultMaxRevisions
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	return &Bin{
+		cfg:       cfg,
+		now:       time.Now,
+		meta:      meta,
+		deleted:   make(map[int]Record),
+		revisions: make(map[int][]Record),
+	}
+}
+
+// Enabled reports whether deletes are soft
+func (b *Bin) Enabled() bool {
+	return b.cfg.Window > 0
+}
+
+// Config returns the retention policy
+func (b *Bin) Config() Config {
+	return b.cfg
+}
+
+// Persistent reports whether the records are saved in the backend
+func (b *Bin) Persistent() bool {
+	return b.meta != nil
+}
+
+// Load restores the records saved by earlier processes
+func (b *Bin) Load() error {
+	if b.meta == nil {
+		return nil
+	}
+	data, ok, err := b.meta.GetMeta(metaKey)
+	if err != nil || !ok {
+		return err
+	}
+	var sv saved
+	if err := json.Unmarshal(data, &sv); err != nil {
+		return fmt.Errorf("parse saved retention bin: %w", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for id, r := range sv.Deleted {
+		b.deleted[id] = r
+	}
+	for id, revs := range sv.Revisions {
+		b.revisionCount += len(revs) - len(b.revisions[id])
+		b.revisions[id] = revs
+	}
+	return nil
+}
+
+// Save writes the records to the backend
+func (b *Bin) Save() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.saveLocked()
+}
+
+func (b *Bin) saveLocked() error {
+	if b.meta == nil {
+		return nil
+	}
+	data, err := json.Marshal(saved{Deleted: b.deleted, Revisions: b.revisions})
+	if err != nil {
+		return err
+	}
+	return b.meta.PutMeta(metaKey, data)
+}
+
+// changedLocked saves the records after a change. The change is already
+// made in the store, so a failed save is logged and left to the next one.
+func (b *Bin) changedLocked() {
+	if err := b.saveLocked(); err != nil {
+		logger.For(logger.ModuleStorage).Errorw("Failed to save retention bin", "error", err)
+	}
+}
+
+func (b *Bin) addDeleted(task entities.Task) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.deleted[task.ID] = Record{Task: task, Kind: KindDeleted, RetainedAt: b.now().UTC()}
+	b.changedLocked()
+}
+
+func (b *Bin) addRevision(task entities.Task) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	revs := append(b.revisions[task.ID], Record{Task: task, Kind: KindRevision, RetainedAt: b.now().UTC()})
+	if over := len(revs) - b.cfg.MaxRevisions; over > 0 {
+		revs = append([]Record(nil), revs[over:]...)
+		b.revisionCount -= over
+	}
+	b.revisions[task.ID] = revs
+	b.revisionCount++
+	b.changedLocked()
+}
+
+// Deleted returns the soft-deleted tasks, most recently deleted first
+func (b *Bin) Deleted() []Record {
+	b.mu.Lock()
+	records := make([]Record, 0, len(b.deleted))
+	for _, r := range b.deleted {
+		records = append(records, r)
+	}
+	b.mu.Unlock()
+
+	sort.Slice(records, func(i, j int) bool {
+		if !records[i].RetainedAt.Equal(records[j].RetainedAt) {
+			return records[i].RetainedAt.After(records[j].RetainedAt)
+		}
+		return records[i].Task.ID < records[j].Task.ID
+	})
+	return records
+}
+
+// Revisions returns the retained earlier versions of task id, newest first
+func (b *Bin) Revisions(id int) []Record {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	revs := b.revisions[id]
+	records := make([]Record, len(revs))
+	for i, r := range revs {
+		records[len(revs)-1-i] = r
+	}
+	return records
+}
+
+// Purge permanently removes records retained more than olderThan ago. With
+// dryRun nothing is removed and the result reports what would be.
+func (b *Bin) Purge(olderThan time.Duration, dryRun bool) PurgeResult {
+	res := PurgeResult{Cutoff: b.now().Add(-olderThan).UTC(), DryRun: dryRun}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for id, r := range b.deleted {
+		if r.RetainedAt.Before(res.Cutoff) {
+			res.Deleted++
+			if !dryRun {
+				delete(b.deleted, id)
+			}
+		}
+	}
+	for id, revs := range b.revisions {
+		// Oldest first, so the expired revisions are a prefix
+		n := sort.Search(len(revs), func(i int) bool { return !revs[i].RetainedAt.Before(res.Cutoff) })
+		res.Revisions += n
+		if dryRun || n == 0 {
+			continue
+		}
+		if n == len(revs) {
+			delete(b.revisions, id)
+		} else {
+			b.revisions[id] = append([]Record(nil), revs[n:]...)
+		}
+		b.revisionCount -= n
+	}
+
+	if dryRun {
+		b.lastDryRun = &res
+	} else {
+		b.purgedDeleted += int64(res.Deleted)
+		b.purgedRevisions += int64(res.Revisions)
+		b.lastPurge = b.now().UTC()
+		if res.Deleted+res.Revisions > 0 {
+			b.changedLocked()
+		}
+	}
+	return res
+}
+
+// Stats returns the current counts
+func (b *Bin) Stats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := Stats{
+		Deleted:         len(b.deleted),
+		Revisions:       b.revisionCount,
+		PurgedDeleted:   b.purgedDeleted,
+		PurgedRevisions: b.purgedRevisions,
+	}
+	if !b.lastPurge.IsZero() {
+		t := b.lastPurge
+		s.LastPurge = &t
+	}
+	if b.lastDryRun != nil {
+		res := *b.lastDryRun
+		s.LastDryRun = &res
+	}
+	return s
+}
+
+// Job returns a jobs.Func purging records older than olderThan
+func (b *Bin) Job(olderThan time.Duration, dryRun bool) jobs.Func {
+	return func(ctx context.Context, p *jobs.Progress) error {
+		res := b.Purge(olderThan, dryRun)
+		verb := "purged"
+		if dryRun {
+			verb = "would purge"
+		}
+		p.SetMessage(fmt.Sprintf("%s %d deleted tasks and %d revisions retained before %s", verb, res.Deleted, res.Revisions, res.Cutoff.Format(time.RFC3339)))
+		logger.For(logger.ModuleJobs).Infow("Retention purge", "dryRun", dryRun, "deleted", res.Deleted, "revisions", res.Revisions, "cutoff", res.Cutoff)
+		return nil
+	}
+}
+
+// CleanupParams are the params of a scheduled cleanup job
+type CleanupParams struct {
+	OlderThanDays *int `json:"olderThanDays"` // Omitted: the retention window
+	DryRun        bool `json:"dryRun"`
+}
+
+// CleanupJob builds a purge job from the params of a cleanup schedule
+func (b *Bin) CleanupJob(params json.RawMessage) (jobs.Func, error) {
+	var p CleanupParams
+	if len(bytes.TrimSpace(params)) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(params))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&p); err != nil {
+			return nil, err
+		}
+	}
+	olderThan := b.cfg.Window
+	if p.OlderThanDays != nil {
+		if *p.OlderThanDays < 0 {
+			return nil, errors.New("olderThanDays must not be negative")
+		}
+		olderThan = time.Duration(*p.OlderThanDays) * 24 * time.Hour
+	}
+	return b.Job(olderThan, p.DryRun), nil
+}
+
+var current atomic.Pointer[Bin]
+
+func init() {
+	Reset()
+}
+
+// Get returns the process-wide Bin
+func Get() *Bin {
+	return current.Load()
+}
+
+// Init replaces the process-wide Bin
+func Init(b *Bin) {
+	current.Store(b)
+}
+
+// Reset installs a disabled Bin
+func Reset() {
+	current.Store(NewBin(Config{}, nil))
+}
@@ -0,0 +1,243 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/jobs"
+	"tasks-service-demo/internal/storage/storetest"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func newTestStore(cfg Config) (*Store, *Bin, *clock) {
+	c := &clock{t: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)}
+	bin := NewBin(cfg, nil)
+	bin.now = c.now
+	inner := storetest.NewFakeStore(&entities.Task{ID: 1, Name: "a"}, &entities.Task{ID: 2, Name: "b"})
+	return NewStore(inner, bin), bin, c
+}
+
+func TestStore_RetainsDeletesAndRevisions(t *testing.T) {
+	store, bin, _ := newTestStore(Config{Window: time.Hour, MaxRevisions: 2})
+
+	for _, name := range []string{"a2", "a3", "a4"} {
+		store.Update(1, &entities.Task{ID: 1, Name: name})
+	}
+	store.Update(1, &entities.Task{ID: 1, Name: "a4"}) // Unchanged: no revision
+	revs := bin.Revisions(1)
+	if len(revs) != 2 || revs[0].Task.Name != "a3" || revs[1].Task.Name != "a2" || revs[0].Kind != KindRevision {
+		t.Errorf("Expected the 2 newest revisions, newest first, got %+v", revs)
+	}
+
+	if err := store.Delete(2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetByID(2); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected soft-deleted task to be gone from the store, got %v", err)
+	}
+	if deleted := bin.Deleted(); len(deleted) != 1 || deleted[0].Task.Name != "b" || deleted[0].Kind != KindDeleted {
+		t.Errorf("Expected retained task b, got %+v", deleted)
+	}
+	if err := store.Delete(99); err == nil {
+		t.Error("Expected deleting a missing task to fail")
+	}
+	if s := bin.Stats(); s.Deleted != 1 || s.Revisions != 2 {
+		t.Errorf("Unexpected stats %+v", s)
+	}
+}
+
+func TestStore_ConcurrentUpdatesRetainEveryRevision(t *testing.T) {
+	store, bin, _ := newTestStore(Config{Window: time.Hour, MaxRevisions: 100})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			store.Update(1, &entities.Task{ID: 1, Name: fmt.Sprintf("v%d", i)})
+		}(i)
+	}
+	wg.Wait()
+
+	// Each update replaced a different version, so none is retained twice
+	seen := make(map[string]bool)
+	for _, r := range bin.Revisions(1) {
+		if seen[r.Task.Name] {
+			t.Errorf("Expected each replaced version retained once, %q is twice", r.Task.Name)
+		}
+		seen[r.Task.Name] = true
+	}
+	if len(seen) != 20 {
+		t.Errorf("Expected 20 revisions, got %d", len(seen))
+	}
+}
+
+func TestStore_ReadErrorFailsWrite(t *testing.T) {
+	mock := storetest.NewMockStore()
+	mock.GetByIDFunc = func(id int) (*entities.Task, *apperrors.AppError) {
+		return nil, apperrors.ErrStorageError
+	}
+	store := NewStore(mock, NewBin(Config{Window: time.Hour}, nil))
+
+	if err := store.Update(1, &entities.Task{Name: "a"}); err != apperrors.ErrStorageError {
+		t.Errorf("Expected the read error, got %v", err)
+	}
+	if err := store.Delete(1); err != apperrors.ErrStorageError {
+		t.Errorf("Expected the read error, got %v", err)
+	}
+	if mock.CallCount(storetest.MethodUpdate)+mock.CallCount(storetest.MethodDelete) != 0 {
+		t.Error("Expected no write when the previous version could not be read")
+	}
+}
+
+type memMeta struct {
+	records map[string][]byte
+	fail    error
+}
+
+func (m *memMeta) GetMeta(key string) ([]byte, bool, error) {
+	value, ok := m.records[key]
+	return value, ok, nil
+}
+
+func (m *memMeta) PutMeta(key string, value []byte) error {
+	if m.fail != nil {
+		return m.fail
+	}
+	m.records[key] = value
+	return nil
+}
+
+func TestBin_SurvivesRestart(t *testing.T) {
+	meta := &memMeta{records: make(map[string][]byte)}
+	inner := storetest.NewFakeStore(&entities.Task{ID: 1, Name: "a"}, &entities.Task{ID: 2, Name: "b"})
+	store := NewStore(inner, NewBin(Config{Window: time.Hour}, meta))
+	store.Update(1, &entities.Task{ID: 1, Name: "a2"})
+	store.Delete(2)
+
+	restarted := NewBin(Config{Window: time.Hour}, meta)
+	if err := restarted.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if deleted := restarted.Deleted(); len(deleted) != 1 || deleted[0].Task.Name != "b" {
+		t.Errorf("Expected the soft-deleted task after a restart, got %+v", deleted)
+	}
+	if revs := restarted.Revisions(1); len(revs) != 1 || revs[0].Task.Name != "a" {
+		t.Errorf("Expected the revision after a restart, got %+v", revs)
+	}
+	if s := restarted.Stats(); s.Deleted != 1 || s.Revisions != 1 {
+		t.Errorf("Unexpected stats %+v", s)
+	}
+
+	// Purges are saved too
+	restarted.Purge(0, false)
+	again := NewBin(Config{Window: time.Hour}, meta)
+	again.Load()
+	if s := again.Stats(); s.Deleted != 0 || s.Revisions != 0 {
+		t.Errorf("Expected the purge saved, got %+v", s)
+	}
+
+	// A failed save leaves the records in memory for the next one
+	meta.fail = errors.New("disk full")
+	again.addDeleted(entities.Task{ID: 3, Name: "c"})
+	meta.fail = nil
+	if err := again.Save(); err != nil {
+		t.Fatal(err)
+	}
+	last := NewBin(Config{Window: time.Hour}, meta)
+	last.Load()
+	if deleted := last.Deleted(); len(deleted) != 1 || deleted[0].Task.ID != 3 {
+		t.Errorf("Expected the record saved by the next save, got %+v", deleted)
+	}
+}
+
+func TestBin_Purge(t *testing.T) {
+	store, bin, clk := newTestStore(Config{Window: time.Hour})
+	store.Update(1, &entities.Task{ID: 1, Name: "old"})
+	store.Delete(2)
+	clk.t = clk.t.Add(90 * time.Minute)
+	store.Update(1, &entities.Task{ID: 1, Name: "new"})
+
+	res := bin.Purge(time.Hour, true)
+	if !res.DryRun || res.Deleted != 1 || res.Revisions != 1 {
+		t.Errorf("Unexpected dry run %+v", res)
+	}
+	if s := bin.Stats(); s.Deleted != 1 || s.Revisions != 2 || s.PurgedDeleted != 0 || s.LastDryRun == nil {
+		t.Errorf("Expected dry run to keep everything, got %+v", s)
+	}
+
+	var p jobs.Progress
+	if err := bin.Job(time.Hour, false)(context.Background(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, msg := p.Snapshot(); !strings.HasPrefix(msg, "purged 1 deleted tasks and 1 revisions") {
+		t.Errorf("Unexpected job message %q", msg)
+	}
+	if revs := bin.Revisions(1); len(revs) != 1 || revs[0].Task.Name != "old" {
+		t.Errorf("Expected only the recent revision to remain, got %+v", revs)
+	}
+	s := bin.Stats()
+	if s.Deleted != 0 || s.Revisions != 1 || s.PurgedDeleted != 1 || s.PurgedRevisions != 1 || s.LastPurge == nil {
+		t.Errorf("Unexpected stats after purge %+v", s)
+	}
+}
+
+func TestCollector(t *testing.T) {
+	store, bin, _ := newTestStore(Config{Window: time.Hour})
+	store.Delete(1)
+	store.Delete(2)
+	bin.Purge(-time.Minute, false)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewCollector("test", bin))
+
+	expected := `
+# HELP test_retention_purged_total Retained records permanently purged.
+# TYPE test_retention_purged_total counter
+test_retention_purged_total{kind="deleted"} 2
+test_retention_purged_total{kind="revision"} 0
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "test_retention_purged_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBin_CleanupJob(t *testing.T) {
+	store, bin, clk := newTestStore(Config{Window: time.Hour})
+	store.Delete(1)
+	clk.t = clk.t.Add(2 * 24 * time.Hour)
+
+	job, err := bin.CleanupJob([]byte(`{"olderThanDays": 3, "dryRun": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	job(context.Background(), &jobs.Progress{})
+	if s := bin.Stats(); s.LastDryRun == nil || s.LastDryRun.Deleted != 0 {
+		t.Errorf("Expected nothing older than 3 days, got %+v", s.LastDryRun)
+	}
+
+	job, _ = bin.CleanupJob(nil) // The retention window
+	job(context.Background(), &jobs.Progress{})
+	if s := bin.Stats(); s.Deleted != 0 || s.PurgedDeleted != 1 {
+		t.Errorf("Expected the deleted task to be purged, got %+v", s)
+	}
+
+	for _, params := range []string{`{"olderThanDays": -1}`, `{"days": 3}`, `[]`} {
+		if _, err := bin.CleanupJob([]byte(params)); err == nil {
+			t.Errorf("Expected %s to be rejected", params)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package retention
+
+import (
+	"sync"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage"
+)
+
+// idLocks is the number of mutexes serializing the writes to a task, picked
+// by task ID
+const idLocks = 64
+
+// Store wraps a storage.Store and copies tasks into a Bin before updates
+// replace them and deletes remove them. Updates and deletes of a task are
+// serialized, so each retains the version the other replaced.
+type Store struct {
+	inner storage.Store
+	bin   *Bin
+	locks [idLocks]sync.Mutex
+}
+
+// NewStore wraps inner, retaining into bin
+func NewStore(inner storage.Store, bin *Bin) *Store {
+	return &Store{inner: inner, bin: bin}
+}
+
+// Inner returns the wrapped store
+func (s *Store) Inner() storage.Store {
+	return s.inner
+}
+
+// Create writes to the inner store
+func (s *Store) Create(task *entities.Task) *apperrors.AppError {
+	return s.inner.Create(task)
+}
+
+// GetByID reads from the inner store
+func (s *Store) GetByID(id int) (*entities.Task, *apperrors.AppError) {
+	return s.inner.GetByID(id)
+}
+
+// GetAll reads from the inner store
+func (s *Store) GetAll() []*entities.Task {
+	return s.inner.GetAll()
+}
+
+// GetRange reads from the inner store
+func (s *Store) GetRange(fromID, toID, limit int) []*entities.Task {
+	return s.inner.GetRange(fromID, toID, limit)
+}
+
+// lock locks the writes to task id and returns the unlock function
+func (s *Store) lock(id int) func() {
+	mu := &s.locks[uint(id)%idLocks]
+	mu.Lock()
+	return mu.Unlock
+}
+
+// previous reads the version a write to task id replaces; nil if there is
+// none. Other read errors are returned, as the version could not be retained.
+func (s *Store) previous(id int) (*entities.Task, *apperrors.AppError) {
+	previous, err := s.inner.GetByID(id)
+	if err != nil && err.Code != apperrors.ErrCodeTaskNotFound {
+		return nil, err
+	}
+	if previous == nil {
+		return nil, nil
+	}
+	before := *previous
+	return &before, nil
+}
+
+// Update replaces the task and retains the previous version if it changed
+func (s *Store) Update(id int, task *entities.Task) *apperrors.AppError {
+	defer s.lock(id)()
+
+	before, err := s.previous(id)
+	if err != nil {
+		return err
+	}
+	if err := s.inner.Update(id, task); err != nil {
+		return err
+	}
+	if before != nil && (before.Name != task.Name || before.Status != task.Status) {
+		s.bin.addRevision(*before)
+	}
+	return nil
+}
+
+// Delete removes the task and retains its last version
+func (s *Store) Delete(id int) *apperrors.AppError {
+	defer s.lock(id)()
+
+	before, err := s.previous(id)
+	if err != nil {
+		return err
+	}
+	if err := s.inner.Delete(id); err != nil {
+		return err
+	}
+	if before != nil {
+		s.bin.addDeleted(*before)
+	}
+	return nil
+}
@@ -1,10 +1,18 @@
 package routes
 
 import (
+	"tasks-service-demo/internal/adaptive"
+	"tasks-service-demo/internal/admission"
+	"tasks-service-demo/internal/auth"
+	"tasks-service-demo/internal/changes"
+	"tasks-service-demo/internal/dashboard"
 	"tasks-service-demo/internal/handlers"
+	"tasks-service-demo/internal/metrics"
 	"tasks-service-demo/internal/middleware"
 	"tasks-service-demo/internal/requests"
 	"tasks-service-demo/internal/services"
+	"tasks-service-demo/internal/ui"
+	"tasks-service-demo/internal/usage"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -12,17 +20,158 @@ import (
 // Package routes defines the application's HTTP route setup.
 
 // SetupRoutes registers all API routes and handlers with the Fiber app.
-func SetupRoutes(app *fiber.App, taskService *services.TaskService) {
-	taskHandler := handlers.NewTaskHandler(taskService)
+// Optional TaskHandlerOptions configure the task handler (e.g. list coalescing).
+func SetupRoutes(app *fiber.App, taskService *services.TaskService, opts ...handlers.TaskHandlerOption) {
+	taskHandler := handlers.NewTaskHandler(taskService, opts...)
+	shareHandler := handlers.NewShareHandler(taskService)
+	notificationHandler := handlers.NewNotificationHandler(taskService)
+	changesHandler := handlers.NewChangesHandler(changes.Get())
+	syncHandler := handlers.NewSyncHandler(taskService, changes.Get())
+	cdcHandler := handlers.NewCDCHandler(changes.Get())
+	archiveHandler := handlers.NewArchiveHandler(taskService)
+
+	// Admin routes may be limited to CIDR allow/deny lists, checked before
+	// credentials so other addresses learn nothing about them
+	app.Use("/admin", middleware.IPFilter())
+
+	// Resolve the caller's role; public endpoints below ignore it
+	app.Use(middleware.Authenticate())
+
+	// Under overload, shed listings before reads and reads before writes;
+	// API key tiers shift the priority, probes are never shed
+	app.Use(admission.Middleware("/health", "/readyz", "/metrics"))
+
+	// Per-route concurrency limits that follow the latency, so load spikes
+	// are turned away instead of queueing in the store
+	app.Use(adaptive.Middleware("/health", "/readyz", "/metrics"))
+
+	// Writes by API keys with a signing secret must be signed and not replayed
+	app.Use(middleware.VerifySignature())
+
+	// Requests per API key, capped by the monthly quota; probes are not counted
+	app.Use(usage.Middleware("/health", "/readyz", "/metrics"))
 
 	// Health check endpoint
 	app.Get("/health", handlers.HealthCheck)
 
+	// Readiness endpoint (flips to ready after startup warm-up)
+	app.Get("/readyz", handlers.ReadinessCheck)
+
 	// Version endpoint
 	app.Get("/version", handlers.VersionHandler)
 
-	// Task API endpoints
+	// Prometheus metrics endpoint
+	app.Get("/metrics", metrics.Handler())
+
+	// Machine-readable error catalog
+	app.Get("/errors", handlers.ErrorCatalog)
+
+	// Admin endpoints
+	app.Use("/admin", middleware.RequireRole(auth.RoleAdmin))
+	app.Get("/admin/maintenance", handlers.GetMaintenance)
+	app.Post("/admin/maintenance",
+		middleware.ValidateRequest[requests.MaintenanceRequest](),
+		handlers.SetMaintenance,
+	)
+	app.Get("/admin/payload-logging", handlers.GetPayloadLogging)
+	app.Post("/admin/payload-logging",
+		middleware.ValidateRequest[requests.PayloadLoggingRequest](),
+		handlers.SetPayloadLogging,
+	)
+	app.Get("/admin/log-level", handlers.GetLogLevel)
+	app.Put("/admin/log-level",
+		middleware.ValidateRequest[requests.LogLevelRequest](),
+		handlers.SetLogLevel,
+	)
+	app.Get("/admin/jobs", handlers.ListJobs)
+	app.Post("/admin/jobs",
+		middleware.ValidateRequest[requests.ScheduleJobRequest](),
+		handlers.ScheduleJob,
+	)
+	app.Get("/admin/jobs/:id", handlers.GetJob)
+	app.Delete("/admin/jobs/:id", handlers.DeleteJob)
+	app.Get("/admin/jobs/:id/runs", handlers.GetJobRuns)
+	app.Get("/admin/dlq", handlers.ListDeadLetters)
+	app.Post("/admin/dlq/:id/replay", handlers.ReplayDeadLetter)
+	app.Get("/admin/retention", handlers.GetRetention)
+	app.Post("/admin/retention/purge",
+		middleware.ValidateRequest[requests.RetentionPurgeRequest](),
+		handlers.PurgeRetention,
+	)
+	app.Get("/admin/retention/deleted", handlers.ListDeletedTasks)
+	app.Get("/admin/retention/revisions/:id", middleware.ValidatePathID(), handlers.GetTaskRevisions)
+	app.Get("/admin/storage/experiment", handlers.GetStorageExperiment)
+	app.Post("/admin/storage/compact",
+		middleware.ValidateRequest[requests.CompactStorageRequest](),
+		handlers.CompactStorage,
+	)
+	app.Get("/admin/loadtest", handlers.ListLoadTestScenarios)
+	app.Post("/admin/loadtest",
+		middleware.ValidateRequest[requests.LoadTestRequest](),
+		handlers.RunLoadTest,
+	)
+	app.Get("/admin/slowlog", handlers.GetSlowLog)
+	app.Delete("/admin/slowlog", handlers.ResetSlowLog)
+	app.Get("/admin/usage", handlers.GetUsage)
+	app.Get("/admin/admission", handlers.GetAdmission)
+	app.Get("/admin/concurrency", handlers.GetConcurrency)
+	app.Get("/admin/projections", handlers.GetProjections)
+	app.Post("/admin/projections/rebuild", handlers.RebuildProjections)
+	app.Post("/admin/imports/:source",
+		middleware.MaintenanceGuard(),
+		handlers.CreateImport,
+	)
+	app.Post("/admin/notifications",
+		middleware.ValidateRequest[requests.NotificationRequest](),
+		notificationHandler.SendNotification,
+	)
+
+	// Store internals, restricted like the admin endpoints
+	app.Get("/debug/store",
+		middleware.IPFilter(),
+		middleware.RequireRole(auth.RoleAdmin),
+		handlers.GetStoreDebug,
+	)
+
+	// Embedded dashboard; the stats route is registered before the static files
+	app.Get(middleware.DashboardPathPrefix+"/stats", handlers.GetDashboardStats)
+	app.Use(middleware.DashboardPathPrefix, dashboard.Handler())
+
+	// Notification preferences of the calling user
+	app.Get("/me/notifications",
+		middleware.RequireRole(auth.RoleReader),
+		notificationHandler.GetNotificationPreferences,
+	)
+	app.Put("/me/notifications",
+		middleware.RequireRole(auth.RoleReader),
+		middleware.ValidateRequest[requests.NotificationPreferencesRequest](),
+		notificationHandler.SetNotificationPreferences,
+	)
+
+	// Task API endpoints: readers may read, writers may modify (subject to maintenance mode)
+	app.Use("/tasks", middleware.RequireRoleByMethod(auth.RoleReader, auth.RoleWriter))
+	app.Use("/tasks", middleware.MaintenanceGuard())
+	// X-Consistency: strong reads skip caches and replicas
+	app.Use("/tasks", middleware.ReadConsistency())
+
 	app.Get("/tasks", taskHandler.GetAllTasks)
+	// Bulk delete by filter, e.g. ?status=1&dryRun=true
+	app.Delete("/tasks", taskHandler.DeleteTasks)
+
+	// Long-poll change feed; registered before /tasks/:id so "changes" is not parsed as an ID
+	app.Get("/tasks/changes", changesHandler.GetChanges)
+
+	// Mutation counters of the task service, independent of /metrics
+	app.Get("/tasks/stats/throughput", taskHandler.GetThroughput)
+
+	// Batch lookup; middleware treats this POST as a read
+	app.Post(middleware.BatchGetPath,
+		middleware.ValidateRequest[requests.GetTasksByIDsRequest](),
+		taskHandler.GetTasksByIDs,
+	)
+
+	// Tasks moved out of the store by the archival job
+	app.Get("/tasks/archive", archiveHandler.ListArchivedTasks)
 
 	app.Get("/tasks/:id",
 		middleware.ValidatePathID(),
@@ -44,4 +193,66 @@ func SetupRoutes(app *fiber.App, taskService *services.TaskService) {
 		middleware.ValidateRequest[requests.UpdateTaskRequest](),
 		taskHandler.UpdateTask,
 	)
+
+	// Idempotent create-or-update keyed by the caller's own ID
+	app.Put("/tasks/by-key/:external_id",
+		middleware.ValidateRequest[requests.UpdateTaskRequest](),
+		taskHandler.UpsertTask,
+	)
+
+	// Checklist items of a task
+	app.Post("/tasks/:id/subtasks",
+		middleware.ValidatePathID(),
+		middleware.ValidateRequest[requests.CreateSubtaskRequest](),
+		taskHandler.AddSubtask,
+	)
+	app.Post("/tasks/:id/subtasks/:subtaskId/toggle",
+		middleware.ValidatePathID(),
+		taskHandler.ToggleSubtask,
+	)
+	app.Delete("/tasks/:id/subtasks/:subtaskId",
+		middleware.ValidatePathID(),
+		taskHandler.RemoveSubtask,
+	)
+
+	app.Post("/tasks/:id/unarchive",
+		middleware.ValidatePathID(),
+		archiveHandler.UnarchiveTask,
+	)
+
+	app.Post("/tasks/:id/share",
+		middleware.ValidatePathID(),
+		middleware.ValidateRequest[requests.CreateShareLinkRequest](),
+		shareHandler.CreateShareLink,
+	)
+
+	// Share links: the token itself grants read access to one task
+	app.Get("/share/:token", shareHandler.GetSharedTask)
+	app.Delete("/share/:token",
+		middleware.RequireRole(auth.RoleWriter),
+		shareHandler.RevokeShareLink,
+	)
+
+	// Server-rendered web UI: readers may browse, writers may use the forms
+	app.Use(middleware.UIPathPrefix, middleware.RequireRoleByMethod(auth.RoleReader, auth.RoleWriter))
+	app.Use(middleware.UIPathPrefix, middleware.MaintenanceGuard())
+	app.Mount(middleware.UIPathPrefix, ui.New(taskService))
+
+	// Delta sync for offline clients
+	app.Get("/sync",
+		middleware.RequireRole(auth.RoleReader),
+		syncHandler.GetSync,
+	)
+	app.Post("/sync",
+		middleware.RequireRole(auth.RoleWriter),
+		middleware.MaintenanceGuard(),
+		middleware.ValidateRequest[requests.SyncRequest](),
+		syncHandler.PostSync,
+	)
+
+	// Change data capture: the change feed as a stream of task states
+	app.Get("/cdc",
+		middleware.RequireRole(auth.RoleReader),
+		cdcHandler.Stream,
+	)
 }
@@ -9,8 +9,11 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"tasks-service-demo/internal/auth"
 	"tasks-service-demo/internal/entities"
 	"tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/maintenance"
+	"tasks-service-demo/internal/middleware"
 	"tasks-service-demo/internal/requests"
 	"tasks-service-demo/internal/services"
 	"tasks-service-demo/internal/storage"
@@ -25,7 +28,7 @@ func setupTestApp() *fiber.App {
 	storage.InitStore(naive.NewMemoryStore())
 
 	// Create app and service
-	app := fiber.New()
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
 	taskService := services.NewTaskService()
 
 	// Setup routes
@@ -221,8 +224,8 @@ func TestSetupRoutes_GetTaskByID_NotFound(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if resp.StatusCode != fiber.StatusBadRequest {
-		t.Errorf("Expected status %d, got %d", fiber.StatusBadRequest, resp.StatusCode)
+	if resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", fiber.StatusNotFound, resp.StatusCode)
 	}
 }
 
@@ -315,7 +318,7 @@ func TestSetupRoutes_DeleteTask(t *testing.T) {
 	// Verify task is deleted
 	getReq := httptest.NewRequest("GET", fmt.Sprintf("/tasks/%d", createdTask.ID), nil)
 	getResp, _ := app.Test(getReq)
-	if getResp.StatusCode != fiber.StatusBadRequest {
+	if getResp.StatusCode != fiber.StatusNotFound {
 		t.Error("Expected task to be deleted")
 	}
 }
@@ -419,7 +422,7 @@ func TestSetupRoutes_IntegrationFlow(t *testing.T) {
 	// 8. Verify task is gone
 	getReq2 := httptest.NewRequest("GET", fmt.Sprintf("/tasks/%d", createdTask.ID), nil)
 	getResp2, _ := app.Test(getReq2)
-	if getResp2.StatusCode != fiber.StatusBadRequest {
+	if getResp2.StatusCode != fiber.StatusNotFound {
 		t.Error("Expected task to be deleted")
 	}
 }
@@ -461,3 +464,96 @@ func TestSetupRoutes_RouteRegistration(t *testing.T) {
 		}
 	}
 }
+
+func TestSetupRoutes_MaintenanceMode(t *testing.T) {
+	app := setupTestApp()
+	defer maintenance.Reset()
+
+	req := httptest.NewRequest("POST", "/admin/maintenance", bytes.NewBufferString(`{"mode":"read_only"}`))
+	req.Header.Set("Content-Type", "application/json")
+	if resp, err := app.Test(req); err != nil || resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Failed to enable maintenance: %v", err)
+	}
+
+	resp, _ := app.Test(httptest.NewRequest("GET", "/tasks", nil))
+	if resp.StatusCode != fiber.StatusOK {
+		t.Errorf("Expected reads to be served in read-only mode, got %d", resp.StatusCode)
+	}
+
+	req = httptest.NewRequest("POST", "/tasks", bytes.NewBufferString(`{"name":"test","status":0}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, _ = app.Test(req)
+	if resp.StatusCode != fiber.StatusServiceUnavailable {
+		t.Errorf("Expected writes to be rejected in read-only mode, got %d", resp.StatusCode)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	var errResp errors.ErrorResponse
+	json.Unmarshal(body, &errResp)
+	if errResp.Code != errors.ErrCodeMaintenance {
+		t.Errorf("Expected error code %d, got %d", errors.ErrCodeMaintenance, errResp.Code)
+	}
+
+	// Admin and health endpoints stay reachable
+	resp, _ = app.Test(httptest.NewRequest("GET", "/health", nil))
+	if resp.StatusCode != fiber.StatusOK {
+		t.Errorf("Expected /health to stay available, got %d", resp.StatusCode)
+	}
+}
+
+func TestSetupRoutes_RBAC(t *testing.T) {
+	auth.Init(auth.New(auth.Config{APIKeys: map[string]auth.Role{
+		"reader": auth.RoleReader,
+		"writer": auth.RoleWriter,
+		"admin":  auth.RoleAdmin,
+	}}))
+	defer auth.Reset()
+	app := setupTestApp()
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		key      string
+		expected int
+	}{
+		{"health is public", "GET", "/health", "", "", fiber.StatusOK},
+		{"anonymous list", "GET", "/tasks", "", "", fiber.StatusUnauthorized},
+		{"reader list", "GET", "/tasks", "", "reader", fiber.StatusOK},
+		{"reader create", "POST", "/tasks", `{"name":"test","status":0}`, "reader", fiber.StatusForbidden},
+		{"writer create", "POST", "/tasks", `{"name":"test","status":0}`, "writer", fiber.StatusCreated},
+		{"writer maintenance", "GET", "/admin/maintenance", "", "writer", fiber.StatusForbidden},
+		{"admin maintenance", "GET", "/admin/maintenance", "", "admin", fiber.StatusOK},
+		{"writer log level", "PUT", "/admin/log-level", `{"level":"debug"}`, "writer", fiber.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.key != "" {
+				req.Header.Set(middleware.APIKeyHeader, tt.key)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestSetupRoutes_ChangesNotShadowedByID(t *testing.T) {
+	app := setupTestApp()
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/tasks/changes?wait=0", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode == fiber.StatusBadRequest {
+		t.Errorf("Expected /tasks/changes to reach the change feed, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,254 @@
+package schedules
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tasks-service-demo/internal/jobs"
+	"tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/storage"
+)
+
+// Package schedules manages recurring jobs created through the admin API.
+// Each schedule runs one of a fixed set of job kinds on a cron expression,
+// and the definitions are saved in the durable backend so they survive
+// restarts.
+
+// metaKey is the storage.MetaStore record holding all definitions
+const metaKey = "schedules"
+
+var (
+	// ErrInvalid is returned for bad names, unknown kinds, bad cron expressions and bad parameters
+	ErrInvalid = errors.New("invalid schedule")
+	// ErrNotFound is returned when deleting a name that is not a schedule
+	ErrNotFound = errors.New("schedule not found")
+)
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+
+// Kind is a type of job that can be scheduled
+type Kind struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// New builds the job from the schedule's parameters, rejecting bad ones
+	New func(params json.RawMessage) (jobs.Func, error) `json:"-"`
+}
+
+// NoParams adapts a job without parameters to Kind.New
+func NoParams(fn jobs.Func) func(json.RawMessage) (jobs.Func, error) {
+	return func(params json.RawMessage) (jobs.Func, error) {
+		switch strings.TrimSpace(string(params)) {
+		case "", "null", "{}":
+			return fn, nil
+		}
+		return nil, errors.New("this kind takes no params")
+	}
+}
+
+// Definition is a schedule created through the API
+type Definition struct {
+	Name      string          `json:"name"` // Also the job ID
+	Kind      string          `json:"kind"`
+	Cron      string          `json:"cron"`
+	Params    json.RawMessage `json:"params,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// Manager registers schedules with a jobs.Registry and persists them
+type Manager struct {
+	reg  *jobs.Registry    // Nil uses the process-wide registry
+	meta storage.MetaStore // Nil keeps definitions in memory only
+
+	mu    sync.Mutex
+	kinds map[string]Kind
+	defs  map[string]Definition
+}
+
+// New creates a Manager; a nil registry uses jobs.Get() and a nil meta
+// store keeps schedules in memory
+func New(reg *jobs.Registry, meta storage.MetaStore) *Manager {
+	return &Manager{reg: reg, meta: meta, kinds: make(map[string]Kind), defs: make(map[string]Definition)}
+}
+
+func (m *Manager) registry() *jobs.Registry {
+	if m.reg != nil {
+		return m.reg
+	}
+	return jobs.Get()
+}
+
+// Persistent reports whether schedules survive a restart
+func (m *Manager) Persistent() bool {
+	return m.meta != nil
+}
+
+// RegisterKind makes a job kind available to schedules
+func (m *Manager) RegisterKind(k Kind) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.kinds[k.Name] = k
+}
+
+// Kinds returns the available job kinds, sorted by name
+func (m *Manager) Kinds() []Kind {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kinds := make([]Kind, 0, len(m.kinds))
+	for _, k := range m.kinds {
+		kinds = append(kinds, k)
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i].Name < kinds[j].Name })
+	return kinds
+}
+
+func (m *Manager) kindNames() string {
+	names := make([]string, 0, len(m.kinds))
+	for name := range m.kinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// Load schedules the saved definitions. Definitions of a kind that is no
+// longer available, such as export after S3 was unconfigured, are kept but
+// not run.
+func (m *Manager) Load() error {
+	if m.meta == nil {
+		return nil
+	}
+	data, ok, err := m.meta.GetMeta(metaKey)
+	if err != nil || !ok {
+		return err
+	}
+	var defs []Definition
+	if err := json.Unmarshal(data, &defs); err != nil {
+		return fmt.Errorf("parse saved schedules: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, def := range defs {
+		m.defs[def.Name] = def
+		if err := m.scheduleLocked(def); err != nil {
+			logger.For(logger.ModuleJobs).Warnw("Saved schedule not started", "name", def.Name, "kind", def.Kind, "error", err)
+		}
+	}
+	return nil
+}
+
+// Create validates def, starts its job and saves it. CreatedAt is set here.
+// A name already used by any job fails with jobs.ErrExists.
+func (m *Manager) Create(def Definition) (Definition, error) {
+	if !validName.MatchString(def.Name) {
+		return Definition{}, fmt.Errorf("%w: name must be 1-64 letters, digits, '-' or '_'", ErrInvalid)
+	}
+	if bytes.Equal(bytes.TrimSpace(def.Params), []byte("null")) {
+		def.Params = nil
+	}
+	def.CreatedAt = time.Now().UTC()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.scheduleLocked(def); err != nil {
+		return Definition{}, err
+	}
+	m.defs[def.Name] = def
+	if err := m.saveLocked(); err != nil {
+		delete(m.defs, def.Name)
+		m.registry().Unschedule(def.Name)
+		return Definition{}, err
+	}
+	logger.For(logger.ModuleJobs).Infow("Job scheduled", "name", def.Name, "kind", def.Kind, "cron", def.Cron)
+	return def, nil
+}
+
+func (m *Manager) scheduleLocked(def Definition) error {
+	kind, ok := m.kinds[def.Kind]
+	if !ok {
+		return fmt.Errorf("%w: unknown kind %q; available: %s", ErrInvalid, def.Kind, m.kindNames())
+	}
+	if _, err := jobs.ParseCron(def.Cron); err != nil {
+		return fmt.Errorf("%w: cron: %v", ErrInvalid, err)
+	}
+	fn, err := kind.New(def.Params)
+	if err != nil {
+		return fmt.Errorf("%w: params: %v", ErrInvalid, err)
+	}
+	return m.registry().ScheduleCron(def.Name, def.Cron, fn)
+}
+
+// Delete stops and forgets schedule name. Jobs not created through the
+// Manager, such as the built-in export, fail with ErrNotFound.
+func (m *Manager) Delete(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	def, ok := m.defs[name]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(m.defs, name)
+	if err := m.saveLocked(); err != nil {
+		m.defs[name] = def
+		return err
+	}
+	m.registry().Unschedule(name)
+	logger.For(logger.ModuleJobs).Infow("Job unscheduled", "name", name)
+	return nil
+}
+
+// List returns the definitions, sorted by name
+func (m *Manager) List() []Definition {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sortedLocked()
+}
+
+func (m *Manager) sortedLocked() []Definition {
+	defs := make([]Definition, 0, len(m.defs))
+	for _, def := range m.defs {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
+
+func (m *Manager) saveLocked() error {
+	if m.meta == nil {
+		return nil
+	}
+	data, err := json.Marshal(m.sortedLocked())
+	if err != nil {
+		return err
+	}
+	return m.meta.PutMeta(metaKey, data)
+}
+
+var current atomic.Pointer[Manager]
+
+func init() {
+	Reset()
+}
+
+// Get returns the process-wide Manager
+func Get() *Manager {
+	return current.Load()
+}
+
+// Init replaces the process-wide Manager
+func Init(m *Manager) {
+	current.Store(m)
+}
+
+// Reset installs an in-memory Manager without kinds
+func Reset() {
+	current.Store(New(nil, nil))
+}
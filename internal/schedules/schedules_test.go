@@ -0,0 +1,133 @@
+package schedules
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"tasks-service-demo/internal/jobs"
+	"tasks-service-demo/internal/storage"
+)
+
+type memMeta struct {
+	records map[string][]byte
+	fail    error
+}
+
+func (m *memMeta) GetMeta(key string) ([]byte, bool, error) {
+	value, ok := m.records[key]
+	return value, ok, nil
+}
+
+func (m *memMeta) PutMeta(key string, value []byte) error {
+	if m.fail != nil {
+		return m.fail
+	}
+	m.records[key] = value
+	return nil
+}
+
+func newManager(reg *jobs.Registry, meta storage.MetaStore) *Manager {
+	m := New(reg, meta)
+	m.RegisterKind(Kind{Name: "snapshot", New: NoParams(func(ctx context.Context, p *jobs.Progress) error { return nil })})
+	m.RegisterKind(Kind{Name: "cleanup", New: func(params json.RawMessage) (jobs.Func, error) {
+		var p struct{ Days int }
+		if err := json.Unmarshal(params, &p); err != nil || p.Days < 1 {
+			return nil, errors.New("days must be positive")
+		}
+		return func(ctx context.Context, p *jobs.Progress) error { return nil }, nil
+	}})
+	return m
+}
+
+func TestManager_CreateAndReload(t *testing.T) {
+	reg := jobs.NewRegistry()
+	defer reg.Stop()
+	meta := &memMeta{records: make(map[string][]byte)}
+	m := newManager(reg, meta)
+
+	def, err := m.Create(Definition{Name: "nightly", Kind: "snapshot", Cron: "0 3 * * *"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if def.CreatedAt.IsZero() {
+		t.Error("Expected CreatedAt to be set")
+	}
+	if s, ok := reg.Status("nightly"); !ok || s.Cron != "0 3 * * *" {
+		t.Errorf("Expected cron job, got %+v", s)
+	}
+	if _, err := m.Create(Definition{Name: "purge", Kind: "cleanup", Cron: "@daily", Params: json.RawMessage(`{"days":30}`)}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new process loads both from the meta store
+	reg2 := jobs.NewRegistry()
+	defer reg2.Stop()
+	m2 := newManager(reg2, meta)
+	if err := m2.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if defs := m2.List(); len(defs) != 2 || defs[0].Name != "nightly" || string(defs[1].Params) != `{"days":30}` {
+		t.Errorf("Expected saved definitions, got %+v", defs)
+	}
+	if _, ok := reg2.Status("purge"); !ok {
+		t.Error("Expected loaded schedule to be registered")
+	}
+
+	if err := m2.Delete("nightly"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := reg2.Status("nightly"); ok {
+		t.Error("Expected deleted schedule to be unregistered")
+	}
+	m3 := newManager(jobs.NewRegistry(), meta)
+	m3.Load()
+	if defs := m3.List(); len(defs) != 1 || defs[0].Name != "purge" {
+		t.Errorf("Expected deletion to be saved, got %+v", defs)
+	}
+}
+
+func TestManager_CreateRejects(t *testing.T) {
+	reg := jobs.NewRegistry()
+	defer reg.Stop()
+	reg.Schedule("s3-export", 1<<40, func(ctx context.Context, p *jobs.Progress) error { return nil })
+	m := newManager(reg, nil)
+
+	invalid := []Definition{
+		{Name: "../x", Kind: "snapshot", Cron: "@daily"},
+		{Name: "a", Kind: "reindex", Cron: "@daily"},
+		{Name: "a", Kind: "snapshot", Cron: "daily"},
+		{Name: "a", Kind: "snapshot", Cron: "@daily", Params: json.RawMessage(`{"x":1}`)},
+		{Name: "a", Kind: "cleanup", Cron: "@daily", Params: json.RawMessage(`{"days":0}`)},
+	}
+	for _, def := range invalid {
+		if _, err := m.Create(def); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Expected ErrInvalid for %+v, got %v", def, err)
+		}
+	}
+
+	if _, err := m.Create(Definition{Name: "s3-export", Kind: "snapshot", Cron: "@daily"}); !errors.Is(err, jobs.ErrExists) {
+		t.Errorf("Expected ErrExists, got %v", err)
+	}
+	if err := m.Delete("s3-export"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected built-in jobs not to be deletable, got %v", err)
+	}
+	if len(m.List()) != 0 {
+		t.Errorf("Expected no schedules, got %+v", m.List())
+	}
+}
+
+func TestManager_SaveFailureRollsBack(t *testing.T) {
+	reg := jobs.NewRegistry()
+	defer reg.Stop()
+	meta := &memMeta{records: make(map[string][]byte), fail: errors.New("disk full")}
+	m := newManager(reg, meta)
+
+	if _, err := m.Create(Definition{Name: "nightly", Kind: "snapshot", Cron: "@daily"}); err == nil {
+		t.Fatal("Expected save error")
+	}
+	if _, ok := reg.Status("nightly"); ok || len(m.List()) != 0 {
+		t.Error("Expected the schedule to be rolled back")
+	}
+}
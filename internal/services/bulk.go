@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/storage"
+)
+
+// TaskFilter selects the tasks of a bulk operation. Nil and zero fields
+// match every task.
+type TaskFilter struct {
+	Status *int
+
+	// Before matches the tasks last written before it. Tasks carry no
+	// timestamps, so ModifiedAt reports when one was last written; tasks it
+	// has no time for don't match, as their age is unknown.
+	Before     time.Time
+	ModifiedAt func(id int) (time.Time, bool)
+}
+
+// Match reports whether task satisfies every field of the filter
+func (f TaskFilter) Match(task *entities.Task) bool {
+	if f.Status != nil && task.Status != *f.Status {
+		return false
+	}
+	if !f.Before.IsZero() {
+		at, ok := f.ModifiedAt(task.ID)
+		return ok && at.Before(f.Before)
+	}
+	return true
+}
+
+// BulkDeleteResult is the outcome of DeleteTasksContext
+type BulkDeleteResult struct {
+	Deleted       int  `json:"deleted"` // Tasks deleted, or on a dry run that would be
+	DryRun        bool `json:"dryRun"`
+	Transactional bool `json:"transactional"` // The deletes were committed at once
+}
+
+// DeleteTasksContext deletes every task that matches filter, running the
+// delete hooks of each; with dryRun it only counts them. Before hooks run
+// for all matches ahead of any delete, so one refusing aborts the whole
+// operation. When the decorator chain has a storage.BulkDeleter (see
+// storage.FindBulkDeleter) the deletes are one transaction that re-checks
+// the filter; otherwise tasks are deleted one at a time, and an error
+// returns with the tasks deleted so far counted.
+func (s *TaskService) DeleteTasksContext(ctx context.Context, filter TaskFilter, dryRun bool) (BulkDeleteResult, *apperrors.AppError) {
+	store := s.store()
+	res := BulkDeleteResult{DryRun: dryRun}
+
+	var events []Event
+	for _, task := range storage.Snapshot(store) {
+		if filter.Match(task) {
+			events = append(events, Event{Op: OpDelete, ID: task.ID})
+		}
+	}
+	if err := storage.ContextError(ctx); err != nil {
+		return res, err
+	}
+	if dryRun {
+		res.Deleted = len(events)
+		return res, nil
+	}
+
+	for i := range events {
+		if err := s.hooks.before(ctx, &events[i]); err != nil {
+			return res, err
+		}
+	}
+
+	if bulk, ok := storage.FindBulkDeleter(store); ok {
+		ids := make([]int, len(events))
+		for i, ev := range events {
+			ids[i] = ev.ID
+		}
+		deleted, err := bulk.DeleteWhere(ids, filter.Match)
+		if err != nil {
+			logger.For(logger.ModuleStorage).Error(err)
+			return res, err
+		}
+		res.Transactional = true
+
+		byID := make(map[int]Event, len(events))
+		for _, ev := range events {
+			byID[ev.ID] = ev
+		}
+		for _, id := range deleted {
+			s.deleted(ctx, byID[id])
+		}
+		res.Deleted = len(deleted)
+		return res, nil
+	}
+
+	for _, ev := range events {
+		err := storage.WithContext(store).DeleteContext(ctx, ev.ID)
+		if err != nil && err.Code == apperrors.ErrCodeTaskNotFound {
+			continue
+		}
+		if err != nil {
+			logger.For(logger.ModuleStorage).Error(err)
+			return res, err
+		}
+		s.deleted(ctx, ev)
+		res.Deleted++
+	}
+	return res, nil
+}
+
+// deleted records a successful delete and runs its after hooks
+func (s *TaskService) deleted(ctx context.Context, ev Event) {
+	s.throughput.Record(OpDelete, time.Now())
+	s.hooks.after(ctx, ev)
+}
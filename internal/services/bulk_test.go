@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/changes"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/reconcile"
+	"tasks-service-demo/internal/slowlog"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/badger"
+	"tasks-service-demo/internal/storage/bloom"
+	"tasks-service-demo/internal/storage/xsync"
+)
+
+func TestTaskService_DeleteTasks(t *testing.T) {
+	db, err := badger.Open(badger.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	for _, tc := range []struct {
+		name          string
+		store         storage.Store
+		transactional bool
+	}{
+		{"one at a time", xsync.NewXSyncStore(), false},
+		{"transactional", db, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			service := NewTaskServiceWithStore(tc.store)
+			for i := 0; i < 6; i++ {
+				tc.store.Create(&entities.Task{Name: "task", Status: i % 2})
+			}
+			var afterHooks int
+			service.OnDelete(Hook{Name: "count", After: func(context.Context, Event) { afterHooks++ }})
+			done := 1
+			filter := TaskFilter{Status: &done}
+
+			res, appErr := service.DeleteTasksContext(context.Background(), filter, true)
+			if appErr != nil || res.Deleted != 3 || !res.DryRun || len(tc.store.GetAll()) != 6 {
+				t.Fatalf("Expected a dry run to count 3 and delete nothing, got %+v, %v", res, appErr)
+			}
+
+			service.OnDelete(Hook{Name: "veto", Before: func(_ context.Context, ev *Event) *apperrors.AppError {
+				if ev.ID == 6 {
+					return apperrors.ErrForbidden.WithMessage("task 6 is locked")
+				}
+				return nil
+			}})
+			if _, appErr := service.DeleteTasksContext(context.Background(), filter, false); appErr == nil {
+				t.Fatal("Expected a refusing before hook to abort the bulk delete")
+			}
+			if len(tc.store.GetAll()) != 6 || afterHooks != 0 {
+				t.Fatalf("Expected nothing deleted after a refusal, got %d tasks and %d after hooks", len(tc.store.GetAll()), afterHooks)
+			}
+
+			tc.store.Delete(6)
+			res, appErr = service.DeleteTasksContext(context.Background(), filter, false)
+			if appErr != nil || res.Deleted != 2 || res.Transactional != tc.transactional {
+				t.Fatalf("Expected 2 deleted, got %+v, %v", res, appErr)
+			}
+			for _, task := range tc.store.GetAll() {
+				if task.Status == done {
+					t.Errorf("Expected every done task deleted, found %+v", task)
+				}
+			}
+			if afterHooks != 2 || service.Throughput().Total.Deleted != 2 {
+				t.Errorf("Expected after hooks and throughput for 2 deletes, got %d and %+v", afterHooks, service.Throughput().Total)
+			}
+		})
+	}
+}
+
+// TestTaskService_DeleteTasksThroughDecorators deletes through the
+// decorators main wraps every backend in: the batch is still one
+// transaction, and each decorator sees the deletes
+func TestTaskService_DeleteTasksThroughDecorators(t *testing.T) {
+	db, err := badger.Open(badger.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	filtered := bloom.NewBloomStore(db, bloom.Config{ExpectedTasks: 100})
+	feed := changes.NewFeed(100)
+	reconciler := reconcile.New(reconcile.PolicyLWW, nil, nil)
+	store := slowlog.NewStore(reconcile.NewStampingStore(changes.NewTrackingStore(filtered, feed), reconciler), slowlog.New(slowlog.Config{}))
+	for i := 0; i < 4; i++ {
+		store.Create(&entities.Task{Name: "task", Status: i % 2})
+	}
+
+	done := 1
+	res, appErr := NewTaskServiceWithStore(store).DeleteTasksContext(context.Background(), TaskFilter{Status: &done}, false)
+	if appErr != nil || res.Deleted != 2 || !res.Transactional {
+		t.Fatalf("Expected 2 deleted in one transaction, got %+v, %v", res, appErr)
+	}
+
+	changed, _, _ := feed.Since(4)
+	if len(changed) != 2 || changed[0].Op != changes.OpDeleted || changed[1].Op != changes.OpDeleted {
+		t.Errorf("Expected the feed to record both deletes, got %+v", changed)
+	}
+	if items := filtered.Stats().Items; items != 2 {
+		t.Errorf("Expected the filter to drop the deleted IDs, got %d items", items)
+	}
+	if _, err := store.GetByID(2); err != apperrors.ErrTaskNotFound || filtered.Stats().Skipped != 1 {
+		t.Errorf("Expected a deleted ID answered by the filter, got %v and %+v", err, filtered.Stats())
+	}
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/logger"
+)
+
+// Op identifies a TaskService mutation
+type Op string
+
+const (
+	OpCreate Op = "create"
+	OpUpdate Op = "update"
+	OpDelete Op = "delete"
+)
+
+// Event describes a mutation passed to hooks
+type Event struct {
+	Op   Op
+	ID   int            // Zero in before hooks of a create
+	Task *entities.Task // Values being written; nil for deletes
+}
+
+// Hook intercepts one kind of TaskService mutation. Either function may be nil.
+//
+// Hooks run in ascending Priority, and in registration order within the same
+// priority. Before hooks run ahead of the store write and may change
+// ev.Task; the first one returning an error aborts the operation, so later
+// before hooks, the write and all after hooks are skipped and the caller gets
+// that error. After hooks run only once the write succeeded, so they cannot
+// fail the operation: every after hook runs, and panics are logged.
+//
+// Hooks run synchronously on the request path with the request context;
+// slow work such as webhooks should be handed off to a queue.
+type Hook struct {
+	Name     string // Used in logs
+	Priority int
+	Before   func(ctx context.Context, ev *Event) *apperrors.AppError
+	After    func(ctx context.Context, ev Event)
+}
+
+// hookSet holds the hooks of one TaskService, per Op, in run order
+type hookSet struct {
+	mu   sync.RWMutex
+	byOp map[Op][]Hook
+}
+
+func (h *hookSet) add(op Op, hook Hook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.byOp == nil {
+		h.byOp = make(map[Op][]Hook)
+	}
+	hooks := append(h.byOp[op], hook)
+	// Stable, so equal priorities keep registration order
+	sort.SliceStable(hooks, func(i, j int) bool { return hooks[i].Priority < hooks[j].Priority })
+	h.byOp[op] = hooks
+}
+
+func (h *hookSet) get(op Op) []Hook {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.byOp[op]
+}
+
+// before runs the before hooks of ev.Op and returns the first error
+func (h *hookSet) before(ctx context.Context, ev *Event) *apperrors.AppError {
+	for _, hook := range h.get(ev.Op) {
+		if hook.Before == nil {
+			continue
+		}
+		if err := runBefore(ctx, hook, ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runBefore runs one before hook; a panic aborts the operation with ErrInternalError
+func runBefore(ctx context.Context, hook Hook, ev *Event) (err *apperrors.AppError) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Get().Errorw("Task hook panicked", "hook", hook.Name, "op", ev.Op, "stage", "before", "panic", r)
+			err = apperrors.ErrInternalError.WithCause(fmt.Errorf("hook %s panicked: %v", hook.Name, r))
+		}
+	}()
+	return hook.Before(ctx, ev)
+}
+
+// after runs every after hook of ev.Op
+func (h *hookSet) after(ctx context.Context, ev Event) {
+	for _, hook := range h.get(ev.Op) {
+		if hook.After == nil {
+			continue
+		}
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Get().Errorw("Task hook panicked", "hook", hook.Name, "op", ev.Op, "stage", "after", "panic", r)
+				}
+			}()
+			hook.After(ctx, ev)
+		}()
+	}
+}
+
+// OnCreate registers a hook around CreateTask
+func (s *TaskService) OnCreate(hook Hook) {
+	s.hooks.add(OpCreate, hook)
+}
+
+// OnUpdate registers a hook around UpdateTask
+func (s *TaskService) OnUpdate(hook Hook) {
+	s.hooks.add(OpUpdate, hook)
+}
+
+// OnDelete registers a hook around DeleteTask. After hooks only run when a
+// task was actually deleted, not for idempotent deletes of missing tasks.
+func (s *TaskService) OnDelete(hook Hook) {
+	s.hooks.add(OpDelete, hook)
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/requests"
+)
+
+func TestHooks_Order(t *testing.T) {
+	service := setupTestService()
+
+	var calls []string
+	record := func(name string) Hook {
+		return Hook{
+			Name:     name,
+			Priority: map[string]int{"audit": 10, "index": 0, "events": 0}[name],
+			Before: func(ctx context.Context, ev *Event) *apperrors.AppError {
+				calls = append(calls, "before:"+name)
+				return nil
+			},
+			After: func(ctx context.Context, ev Event) {
+				calls = append(calls, "after:"+name)
+			},
+		}
+	}
+	service.OnCreate(record("audit"))
+	service.OnCreate(record("index"))
+	service.OnCreate(record("events"))
+
+	if _, err := service.CreateTask(&requests.CreateTaskRequest{Name: "task"}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"before:index", "before:events", "before:audit",
+		"after:index", "after:events", "after:audit",
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected %v, got %v", expected, calls)
+	}
+}
+
+func TestHooks_BeforeMutatesAndAfterSeesID(t *testing.T) {
+	service := setupTestService()
+
+	var created Event
+	service.OnCreate(Hook{
+		Name: "trim",
+		Before: func(ctx context.Context, ev *Event) *apperrors.AppError {
+			ev.Task.Name = strings.TrimSpace(ev.Task.Name)
+			return nil
+		},
+		After: func(ctx context.Context, ev Event) { created = ev },
+	})
+
+	task, err := service.CreateTask(&requests.CreateTaskRequest{Name: "  padded  "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Name != "padded" {
+		t.Errorf("Expected before hook to trim the name, got %q", task.Name)
+	}
+	if created.ID != task.ID || created.Op != OpCreate {
+		t.Errorf("Expected after hook to see ID %d, got %+v", task.ID, created)
+	}
+}
+
+func TestHooks_BeforeErrorAborts(t *testing.T) {
+	service := setupTestService()
+	task, _ := service.CreateTask(&requests.CreateTaskRequest{Name: "original"})
+
+	afterCalled, laterCalled := false, false
+	service.OnUpdate(Hook{
+		Name: "veto",
+		Before: func(ctx context.Context, ev *Event) *apperrors.AppError {
+			return apperrors.ErrForbidden
+		},
+		After: func(ctx context.Context, ev Event) { afterCalled = true },
+	})
+	service.OnUpdate(Hook{
+		Name:     "later",
+		Priority: 1,
+		Before: func(ctx context.Context, ev *Event) *apperrors.AppError {
+			laterCalled = true
+			return nil
+		},
+	})
+
+	if _, err := service.UpdateTask(task.ID, &requests.UpdateTaskRequest{Name: "changed"}); err != apperrors.ErrForbidden {
+		t.Errorf("Expected ErrForbidden, got %v", err)
+	}
+	if afterCalled || laterCalled {
+		t.Error("Expected later before hooks and after hooks to be skipped")
+	}
+	if current, _ := service.GetTaskByID(task.ID); current.Name != "original" {
+		t.Errorf("Expected the write to be skipped, got %q", current.Name)
+	}
+}
+
+func TestHooks_DeleteAfterOnlyWhenDeleted(t *testing.T) {
+	service := setupTestService()
+	task, _ := service.CreateTask(&requests.CreateTaskRequest{Name: "task"})
+
+	var deleted []int
+	service.OnDelete(Hook{Name: "index", After: func(ctx context.Context, ev Event) { deleted = append(deleted, ev.ID) }})
+
+	service.DeleteTask(task.ID)
+	service.DeleteTask(task.ID) // Already gone: still no error, but no after hook
+
+	if !reflect.DeepEqual(deleted, []int{task.ID}) {
+		t.Errorf("Expected one after call for %d, got %v", task.ID, deleted)
+	}
+}
+
+func TestHooks_Panics(t *testing.T) {
+	service := setupTestService()
+
+	secondAfter := false
+	service.OnCreate(Hook{Name: "broken", After: func(ctx context.Context, ev Event) { panic("boom") }})
+	service.OnCreate(Hook{Name: "ok", Priority: 1, After: func(ctx context.Context, ev Event) { secondAfter = true }})
+
+	if _, err := service.CreateTask(&requests.CreateTaskRequest{Name: "task"}); err != nil {
+		t.Errorf("Expected after hook panic not to fail the create, got %v", err)
+	}
+	if !secondAfter {
+		t.Error("Expected remaining after hooks to run")
+	}
+
+	service.OnDelete(Hook{Name: "broken", Before: func(ctx context.Context, ev *Event) *apperrors.AppError { panic("boom") }})
+	if err := service.DeleteTask(1); err == nil || err.Code != apperrors.ErrCodeInternalError {
+		t.Errorf("Expected before hook panic to abort with an internal error, got %v", err)
+	}
+}
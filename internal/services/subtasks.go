@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"slices"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/requests"
+)
+
+// taskLockStripes is the number of locks read-modify-writes of tasks are
+// spread over
+const taskLockStripes = 64
+
+// lockTask serializes read-modify-writes of the task with id within this
+// service, so concurrent subtask edits and updates don't lose each other's
+// changes, and returns the unlock function
+func (s *TaskService) lockTask(id int) func() {
+	mu := &s.taskLocks[uint(id)%taskLockStripes]
+	mu.Lock()
+	return mu.Unlock
+}
+
+// editSubtasks replaces the checklist of the task with id by what edit
+// returns for the current one. edit must not modify the slice it is given.
+func (s *TaskService) editSubtasks(ctx context.Context, id int, edit func([]entities.Subtask) ([]entities.Subtask, *apperrors.AppError)) (*entities.Task, *apperrors.AppError) {
+	unlock := s.lockTask(id)
+	defer unlock()
+
+	current, err := s.getStrong(ctx, id)
+	if err != nil {
+		logger.For(logger.ModuleStorage).Error(err)
+		return nil, err
+	}
+	subtasks, err := edit(current.Subtasks)
+	if err != nil {
+		return nil, err
+	}
+	task := &entities.Task{
+		Name:   current.Name,
+		Status: current.Status,
+	}
+	task.SetSubtasks(subtasks)
+	return s.update(ctx, id, task)
+}
+
+// AddSubtaskContext appends a subtask to the checklist of the task with id
+// and returns the updated task, or ErrSubtaskLimit when the checklist has
+// entities.MaxSubtasks items.
+func (s *TaskService) AddSubtaskContext(ctx context.Context, id int, req *requests.CreateSubtaskRequest) (*entities.Task, *apperrors.AppError) {
+	return s.editSubtasks(ctx, id, func(subtasks []entities.Subtask) ([]entities.Subtask, *apperrors.AppError) {
+		if len(subtasks) >= entities.MaxSubtasks {
+			return nil, apperrors.ErrSubtaskLimit
+		}
+		next := (&entities.Task{Subtasks: subtasks}).NextSubtaskID()
+		return append(slices.Clip(subtasks), entities.Subtask{ID: next, Name: req.Name}), nil
+	})
+}
+
+// ToggleSubtaskContext flips whether the subtask subID of the task with id
+// is done and returns the updated task
+func (s *TaskService) ToggleSubtaskContext(ctx context.Context, id, subID int) (*entities.Task, *apperrors.AppError) {
+	return s.editSubtasks(ctx, id, func(subtasks []entities.Subtask) ([]entities.Subtask, *apperrors.AppError) {
+		i := slices.IndexFunc(subtasks, func(st entities.Subtask) bool { return st.ID == subID })
+		if i < 0 {
+			return nil, apperrors.ErrSubtaskNotFound
+		}
+		toggled := slices.Clone(subtasks)
+		toggled[i].Done = !toggled[i].Done
+		return toggled, nil
+	})
+}
+
+// RemoveSubtaskContext removes the subtask subID from the task with id and
+// returns the updated task
+func (s *TaskService) RemoveSubtaskContext(ctx context.Context, id, subID int) (*entities.Task, *apperrors.AppError) {
+	return s.editSubtasks(ctx, id, func(subtasks []entities.Subtask) ([]entities.Subtask, *apperrors.AppError) {
+		i := slices.IndexFunc(subtasks, func(st entities.Subtask) bool { return st.ID == subID })
+		if i < 0 {
+			return nil, apperrors.ErrSubtaskNotFound
+		}
+		return slices.Delete(slices.Clone(subtasks), i, i+1), nil
+	})
+}
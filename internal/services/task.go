@@ -1,6 +1,10 @@
 package services
 
 import (
+	"context"
+	"sync"
+	"time"
+
 	"tasks-service-demo/internal/entities"
 	apperrors "tasks-service-demo/internal/errors"
 	"tasks-service-demo/internal/logger"
@@ -11,68 +15,260 @@ import (
 // Package services implements business logic for the Task API.
 
 // TaskService provides methods for managing tasks.
-type TaskService struct{}
+type TaskService struct {
+	st         storage.Store // Explicit store; nil falls back to the global singleton
+	hooks      hookSet
+	throughput *Throughput
+	taskLocks  [taskLockStripes]sync.Mutex // See lockTask
+}
 
-// NewTaskService creates a new TaskService instance.
+// NewTaskService creates a new TaskService instance backed by the global store.
 func NewTaskService() *TaskService {
-	return &TaskService{}
+	return &TaskService{throughput: NewThroughput()}
+}
+
+// NewTaskServiceWithStore creates a TaskService bound to the given store
+// instead of the global singleton (useful for tests and multiple instances).
+func NewTaskServiceWithStore(store storage.Store) *TaskService {
+	return &TaskService{st: store, throughput: NewThroughput()}
 }
 
 func (s *TaskService) store() storage.Store {
+	if s.st != nil {
+		return s.st
+	}
 	return storage.GetStore()
 }
 
+// Throughput returns the counts of successful creates, updates and deletes
+// made through this service.
+func (s *TaskService) Throughput() ThroughputStats {
+	if s.throughput == nil {
+		return NewThroughput().Snapshot(time.Now())
+	}
+	return s.throughput.Snapshot(time.Now())
+}
+
 // GetAllTasks returns all tasks from the store.
 func (s *TaskService) GetAllTasks() []*entities.Task {
 	return s.store().GetAll()
 }
 
+// GetAllTasksContext returns all tasks, or ErrTimeout once ctx is done.
+func (s *TaskService) GetAllTasksContext(ctx context.Context) ([]*entities.Task, *apperrors.AppError) {
+	tasks, err := storage.WithContext(s.store()).GetAllContext(ctx)
+	if err != nil {
+		logger.For(logger.ModuleStorage).Error(err)
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// GetTaskPageContext returns up to limit tasks after cursor, the cursor of
+// the next page and whether one follows, or ErrTimeout once ctx is done.
+// Sharded stores list one shard at a time (see storage.GetPage).
+func (s *TaskService) GetTaskPageContext(ctx context.Context, cursor storage.PageCursor, limit int) ([]*entities.Task, storage.PageCursor, bool, *apperrors.AppError) {
+	if err := storage.ContextError(ctx); err != nil {
+		return nil, cursor, false, err
+	}
+	tasks, next, more := storage.GetPage(s.store(), cursor, limit)
+	return tasks, next, more, nil
+}
+
+// GetTaskRangeContext returns tasks with fromID <= ID <= toID in ID order, at
+// most limit, or ErrTimeout once ctx is done.
+func (s *TaskService) GetTaskRangeContext(ctx context.Context, fromID, toID, limit int) ([]*entities.Task, *apperrors.AppError) {
+	tasks, err := storage.WithContext(s.store()).GetRangeContext(ctx, fromID, toID, limit)
+	if err != nil {
+		logger.For(logger.ModuleStorage).Error(err)
+		return nil, err
+	}
+	return tasks, nil
+}
+
 // GetTaskByID returns a task by its ID, or an error if not found.
 func (s *TaskService) GetTaskByID(id int) (*entities.Task, *apperrors.AppError) {
-	task, err := s.store().GetByID(id)
+	return s.GetTaskByIDContext(context.Background(), id)
+}
+
+// GetTaskByIDContext is GetTaskByID bounded by ctx. Strong reads (see
+// storage.WithConsistency) skip caches and replicas.
+func (s *TaskService) GetTaskByIDContext(ctx context.Context, id int) (*entities.Task, *apperrors.AppError) {
+	var task *entities.Task
+	var err *apperrors.AppError
+	if storage.ConsistencyFrom(ctx) == storage.Strong {
+		task, err = s.getStrong(ctx, id)
+	} else {
+		task, err = storage.WithContext(s.store()).GetByIDContext(ctx, id)
+	}
 	if err != nil {
-		logger.Get().Error(err)
+		logger.For(logger.ModuleStorage).Error(err)
 		return nil, err
 	}
 	return task, nil
 }
 
+// getStrong reads id past caches and replicas, or returns ErrTimeout once
+// ctx is done
+func (s *TaskService) getStrong(ctx context.Context, id int) (*entities.Task, *apperrors.AppError) {
+	if err := storage.ContextError(ctx); err != nil {
+		return nil, err
+	}
+	task, err := storage.GetByIDStrong(s.store(), id)
+	if ctxErr := storage.ContextError(ctx); ctxErr != nil {
+		return nil, ctxErr
+	}
+	return task, err
+}
+
+// GetTasksByIDsContext returns the tasks with the given IDs and the IDs not
+// found, both in request order with repeats dropped, or ErrTimeout once ctx
+// is done.
+func (s *TaskService) GetTasksByIDsContext(ctx context.Context, ids []int) ([]*entities.Task, []int, *apperrors.AppError) {
+	if err := storage.ContextError(ctx); err != nil {
+		return nil, nil, err
+	}
+	found, missing, err := storage.GetMany(s.store(), ids)
+	if err == nil {
+		err = storage.ContextError(ctx)
+	}
+	if err != nil {
+		logger.For(logger.ModuleStorage).Error(err)
+		return nil, nil, err
+	}
+	return found, missing, nil
+}
+
 // CreateTask creates a new task from the given request.
 func (s *TaskService) CreateTask(req *requests.CreateTaskRequest) (*entities.Task, *apperrors.AppError) {
+	return s.CreateTaskContext(context.Background(), req)
+}
+
+// CreateTaskContext is CreateTask bounded by ctx.
+func (s *TaskService) CreateTaskContext(ctx context.Context, req *requests.CreateTaskRequest) (*entities.Task, *apperrors.AppError) {
 	task := &entities.Task{
 		Name:   req.Name,
 		Status: req.Status,
 	}
 
-	if err := s.store().Create(task); err != nil {
-		logger.Get().Error(err)
+	ev := Event{Op: OpCreate, Task: task}
+	if err := s.hooks.before(ctx, &ev); err != nil {
 		return nil, err
 	}
 
-	return task, nil
+	if err := storage.WithContext(s.store()).CreateContext(ctx, ev.Task); err != nil {
+		logger.For(logger.ModuleStorage).Error(err)
+		return nil, err
+	}
+
+	ev.ID = ev.Task.ID
+	s.throughput.Record(OpCreate, time.Now())
+	s.hooks.after(ctx, ev)
+	return ev.Task, nil
 }
 
 // UpdateTask updates an existing task by ID with the given request.
 func (s *TaskService) UpdateTask(id int, req *requests.UpdateTaskRequest) (*entities.Task, *apperrors.AppError) {
+	return s.UpdateTaskContext(context.Background(), id, req)
+}
+
+// UpdateTaskContext is UpdateTask bounded by ctx. The request replaces the
+// name and status; the subtasks are kept.
+func (s *TaskService) UpdateTaskContext(ctx context.Context, id int, req *requests.UpdateTaskRequest) (*entities.Task, *apperrors.AppError) {
+	unlock := s.lockTask(id)
+	defer unlock()
+
+	current, err := s.getStrong(ctx, id)
+	if err != nil {
+		logger.For(logger.ModuleStorage).Error(err)
+		return nil, err
+	}
 	task := &entities.Task{
 		Name:   req.Name,
 		Status: req.Status,
 	}
+	task.SetSubtasks(current.Subtasks)
+	return s.update(ctx, id, task)
+}
 
-	if err := s.store().Update(id, task); err != nil {
-		logger.Get().Error(err)
+// update writes task over the task with id through the hooks
+func (s *TaskService) update(ctx context.Context, id int, task *entities.Task) (*entities.Task, *apperrors.AppError) {
+	ev := Event{Op: OpUpdate, ID: id, Task: task}
+	if err := s.hooks.before(ctx, &ev); err != nil {
 		return nil, err
 	}
 
-	return task, nil
+	if err := storage.WithContext(s.store()).UpdateContext(ctx, id, ev.Task); err != nil {
+		logger.For(logger.ModuleStorage).Error(err)
+		return nil, err
+	}
+
+	s.throughput.Record(OpUpdate, time.Now())
+	s.hooks.after(ctx, ev)
+	return ev.Task, nil
+}
+
+// UpsertTaskContext updates the task mapped to the external key, or creates
+// one when the key is new or its task has been deleted, and reports whether
+// it created it. The backend's key index runs upserts of one key one at a
+// time, so concurrent retries of a pipeline create the task only once.
+// Returns ErrNoKeyIndex when the backend keeps no external keys.
+func (s *TaskService) UpsertTaskContext(ctx context.Context, key string, req *requests.UpdateTaskRequest) (task *entities.Task, created bool, appErr *apperrors.AppError) {
+	index, ok := storage.FindKeyIndex(s.store())
+	if !ok {
+		return nil, false, apperrors.ErrNoKeyIndex
+	}
+
+	appErr = index.WithKey(key, func(id int) (int, *apperrors.AppError) {
+		if id != 0 {
+			updated, err := s.UpdateTaskContext(ctx, id, req)
+			if err == nil {
+				task = updated
+				return id, nil
+			}
+			if err.Code != apperrors.ErrCodeTaskNotFound {
+				return 0, err
+			}
+		}
+
+		createReq := requests.CreateTaskRequest{Name: req.Name, Status: req.Status}
+		createdTask, err := s.CreateTaskContext(ctx, &createReq)
+		if err != nil {
+			return 0, err
+		}
+		task, created = createdTask, true
+		return task.ID, nil
+	})
+	if appErr != nil {
+		return nil, false, appErr
+	}
+	return task, created, nil
 }
 
 // DeleteTask deletes a task by its ID. Returns nil if not found (idempotent).
 func (s *TaskService) DeleteTask(id int) *apperrors.AppError {
-	err := s.store().Delete(id)
+	return s.DeleteTaskContext(context.Background(), id)
+}
+
+// DeleteTaskContext is DeleteTask bounded by ctx. Only ErrTimeout and errors
+// from before hooks are returned; a missing task is still not an error.
+func (s *TaskService) DeleteTaskContext(ctx context.Context, id int) *apperrors.AppError {
+	ev := Event{Op: OpDelete, ID: id}
+	if err := s.hooks.before(ctx, &ev); err != nil {
+		return err
+	}
+
+	err := storage.WithContext(s.store()).DeleteContext(ctx, id)
 	if err != nil {
 		// RESTful design: DELETE should be idempotent
-		logger.Get().Error(err)
+		logger.For(logger.ModuleStorage).Error(err)
+		if err.Code == apperrors.ErrCodeTimeout {
+			return err
+		}
+		return nil
 	}
+
+	s.throughput.Record(OpDelete, time.Now())
+	s.hooks.after(ctx, ev)
 	return nil
 }
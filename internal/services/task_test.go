@@ -1,18 +1,25 @@
 package services
 
 import (
+	"context"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
 	"tasks-service-demo/internal/requests"
 	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/lru"
 	"tasks-service-demo/internal/storage/naive"
+	"tasks-service-demo/internal/storage/shard"
+	"tasks-service-demo/internal/storage/storetest"
+	"tasks-service-demo/internal/storage/xsync"
 )
 
 func setupTestService() *TaskService {
-	storage.ResetStore()
-	storage.InitStore(naive.NewMemoryStore())
-	return NewTaskService()
+	return NewTaskServiceWithStore(storetest.NewFakeStore())
 }
 
 func TestTaskService_GetAllTasks(t *testing.T) {
@@ -58,6 +65,27 @@ func TestTaskService_GetTaskByID(t *testing.T) {
 	}
 }
 
+func TestTaskService_GetTaskByID_Strong(t *testing.T) {
+	inner := naive.NewMemoryStore()
+	service := NewTaskServiceWithStore(lru.NewCachedStore(inner, lru.Config{Size: 10}))
+	task, _ := service.CreateTask(&requests.CreateTaskRequest{Name: "v1"})
+	inner.Update(task.ID, &entities.Task{ID: task.ID, Name: "v2"}) // Behind the cache's back
+
+	strong := storage.WithConsistency(context.Background(), storage.Strong)
+	if got, _ := service.GetTaskByIDContext(context.Background(), task.ID); got.Name != "v1" {
+		t.Errorf("Expected the cached 'v1' from an eventual read, got '%s'", got.Name)
+	}
+	if got, err := service.GetTaskByIDContext(strong, task.ID); err != nil || got.Name != "v2" {
+		t.Errorf("Expected 'v2' from a strong read, got %+v, %v", got, err)
+	}
+
+	ctx, cancel := context.WithCancel(strong)
+	cancel()
+	if _, err := service.GetTaskByIDContext(ctx, task.ID); err == nil || err.Code != apperrors.ErrCodeTimeout {
+		t.Errorf("Expected ErrTimeout, got %v", err)
+	}
+}
+
 func TestTaskService_CreateTask_Success(t *testing.T) {
 	service := setupTestService()
 
@@ -415,3 +443,134 @@ func TestTaskService_ValidationIntegration(t *testing.T) {
 		})
 	}
 }
+
+func TestTaskService_StorageErrors(t *testing.T) {
+	mock := storetest.NewMockStore()
+	mock.CreateFunc = func(task *entities.Task) *apperrors.AppError {
+		return apperrors.ErrStorageError
+	}
+	mock.GetByIDFunc = func(id int) (*entities.Task, *apperrors.AppError) {
+		return &entities.Task{ID: id, Name: "Test"}, nil
+	}
+	mock.UpdateFunc = func(id int, task *entities.Task) *apperrors.AppError {
+		return apperrors.ErrStorageError
+	}
+	service := NewTaskServiceWithStore(mock)
+
+	if _, err := service.CreateTask(&requests.CreateTaskRequest{Name: "Test", Status: 0}); err != apperrors.ErrStorageError {
+		t.Errorf("Expected ErrStorageError from CreateTask, got %v", err)
+	}
+	if _, err := service.UpdateTask(1, &requests.UpdateTaskRequest{Name: "Test", Status: 0}); err != apperrors.ErrStorageError {
+		t.Errorf("Expected ErrStorageError from UpdateTask, got %v", err)
+	}
+
+	if mock.CallCount(storetest.MethodCreate) != 1 || mock.CallCount(storetest.MethodUpdate) != 1 {
+		t.Errorf("Expected one Create and one Update call, got %+v", mock.Calls())
+	}
+}
+
+func TestTaskService_UpsertTask(t *testing.T) {
+	service := NewTaskServiceWithStore(naive.NewMemoryStore())
+	ctx := context.Background()
+
+	task, created, err := service.UpsertTaskContext(ctx, "order-1", &requests.UpdateTaskRequest{Name: "a"})
+	if err != nil || !created || task.ID == 0 {
+		t.Fatalf("Expected a new task, got %+v, %v, %v", task, created, err)
+	}
+	id := task.ID
+
+	task, created, err = service.UpsertTaskContext(ctx, "order-1", &requests.UpdateTaskRequest{Name: "b", Status: 1})
+	if err != nil || created || task.ID != id || task.Name != "b" {
+		t.Errorf("Expected task %d updated, got %+v, %v, %v", id, task, created, err)
+	}
+
+	service.DeleteTask(id)
+	task, created, err = service.UpsertTaskContext(ctx, "order-1", &requests.UpdateTaskRequest{Name: "c"})
+	if err != nil || !created || task.ID == id {
+		t.Errorf("Expected a new task after the delete, got %+v, %v, %v", task, created, err)
+	}
+
+	_, _, err = setupTestService().UpsertTaskContext(ctx, "order-1", &requests.UpdateTaskRequest{Name: "a"})
+	if err == nil || err.Code != apperrors.ErrCodeNoKeyIndex {
+		t.Errorf("Expected error %d without a key index, got %v", apperrors.ErrCodeNoKeyIndex, err)
+	}
+}
+
+// slowCreates is a decorator whose creates take long enough for upserts
+// racing on one key to overlap
+type slowCreates struct{ storage.Store }
+
+func (s slowCreates) Inner() storage.Store { return s.Store }
+
+func (s slowCreates) Create(task *entities.Task) *apperrors.AppError {
+	time.Sleep(time.Millisecond)
+	return s.Store.Create(task)
+}
+
+func TestTaskService_UpsertTask_ConcurrentSameKey(t *testing.T) {
+	stores := map[string]func() storage.Store{
+		"memory": func() storage.Store { return naive.NewMemoryStore() },
+		"xsync":  func() storage.Store { return xsync.NewXSyncStore() },
+		"shard":  func() storage.Store { return shard.NewShardStore(4) },
+		"gopool": func() storage.Store { return shard.NewShardStoreGopool(4) },
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := newStore()
+			service := NewTaskServiceWithStore(slowCreates{store})
+
+			var creates atomic.Int32
+			var wg sync.WaitGroup
+			start := make(chan struct{})
+			for i := 0; i < 64; i++ {
+				wg.Add(1)
+				go func(key string) {
+					defer wg.Done()
+					<-start
+					_, created, err := service.UpsertTaskContext(context.Background(), key, &requests.UpdateTaskRequest{Name: key})
+					if err != nil {
+						t.Error(err)
+					}
+					if created {
+						creates.Add(1)
+					}
+				}([]string{"a", "b"}[i%2])
+			}
+			close(start)
+			wg.Wait()
+
+			if creates.Load() != 2 || len(store.GetAll()) != 2 {
+				t.Errorf("Expected 2 tasks created once each, got %d creates and %d tasks", creates.Load(), len(store.GetAll()))
+			}
+		})
+	}
+}
+
+func TestTaskService_ConcurrentSubtaskEdits(t *testing.T) {
+	service := NewTaskServiceWithStore(naive.NewMemoryStore())
+	task, _ := service.CreateTask(&requests.CreateTaskRequest{Name: "Checklist"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := service.AddSubtaskContext(context.Background(), task.ID, &requests.CreateSubtaskRequest{Name: "Item"}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, _ := service.GetTaskByID(task.ID)
+	ids := make(map[int]bool)
+	for _, st := range got.Subtasks {
+		ids[st.ID] = true
+	}
+	if len(got.Subtasks) != 20 || len(ids) != 20 {
+		t.Errorf("Expected 20 subtasks with distinct IDs, got %+v", got.Subtasks)
+	}
+	if _, err := service.ToggleSubtaskContext(context.Background(), task.ID, 99); err != apperrors.ErrSubtaskNotFound {
+		t.Errorf("Expected ErrSubtaskNotFound, got %v", err)
+	}
+}
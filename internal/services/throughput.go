@@ -0,0 +1,135 @@
+package services
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ThroughputWindow is the number of minute buckets a Throughput keeps
+const ThroughputWindow = 60
+
+// ThroughputCounts holds successful mutations per Op
+type ThroughputCounts struct {
+	Created int64 `json:"created"`
+	Updated int64 `json:"updated"`
+	Deleted int64 `json:"deleted"`
+}
+
+// ThroughputRates holds mutations per minute, per Op
+type ThroughputRates struct {
+	Created float64 `json:"created"`
+	Updated float64 `json:"updated"`
+	Deleted float64 `json:"deleted"`
+}
+
+// ThroughputMinute is the counts of one minute
+type ThroughputMinute struct {
+	Minute int64 `json:"minute"` // Unix time of the start of the minute
+	ThroughputCounts
+}
+
+// ThroughputStats is a point-in-time view of a Throughput
+type ThroughputStats struct {
+	Since   time.Time          `json:"since"`
+	Total   ThroughputCounts   `json:"total"`
+	Current ThroughputCounts   `json:"currentMinute"` // So far in the running minute
+	Rate    ThroughputRates    `json:"perMinute"`     // Average over the complete minutes in the window
+	Minutes []ThroughputMinute `json:"minutes"`       // Complete minutes in the window, oldest first
+}
+
+type minuteBucket struct {
+	minute atomic.Int64 // Unix minute the bucket holds; stale buckets are reset on reuse
+	counts [3]atomic.Int64
+}
+
+// Throughput counts task mutations with atomic counters and a ring of
+// one-minute buckets, so recording never takes a lock. It is independent
+// of Prometheus and meant for quick looks at a running service.
+type Throughput struct {
+	started time.Time
+	totals  [3]atomic.Int64
+	buckets [ThroughputWindow]minuteBucket
+}
+
+// NewThroughput creates an empty Throughput
+func NewThroughput() *Throughput {
+	return &Throughput{started: time.Now()}
+}
+
+func opIndex(op Op) int {
+	switch op {
+	case OpCreate:
+		return 0
+	case OpUpdate:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Record counts one successful op at now. A nil Throughput ignores it.
+func (t *Throughput) Record(op Op, now time.Time) {
+	if t == nil {
+		return
+	}
+	i := opIndex(op)
+	t.totals[i].Add(1)
+
+	minute := now.Unix() / 60
+	b := &t.buckets[minute%ThroughputWindow]
+	if old := b.minute.Load(); old != minute && b.minute.CompareAndSwap(old, minute) {
+		// A concurrent Record between the swap and the reset may be lost;
+		// the buckets are approximate by design, the totals are exact
+		for j := range b.counts {
+			b.counts[j].Store(0)
+		}
+	}
+	b.counts[i].Add(1)
+}
+
+func (b *minuteBucket) load(minute int64) ThroughputCounts {
+	if b.minute.Load() != minute {
+		return ThroughputCounts{}
+	}
+	return ThroughputCounts{
+		Created: b.counts[0].Load(),
+		Updated: b.counts[1].Load(),
+		Deleted: b.counts[2].Load(),
+	}
+}
+
+// Snapshot returns the totals and the per-minute history as of now. Minutes
+// before the Throughput was created are left out, so rates aren't diluted
+// right after startup.
+func (t *Throughput) Snapshot(now time.Time) ThroughputStats {
+	stats := ThroughputStats{
+		Since: t.started,
+		Total: ThroughputCounts{
+			Created: t.totals[0].Load(),
+			Updated: t.totals[1].Load(),
+			Deleted: t.totals[2].Load(),
+		},
+		Minutes: make([]ThroughputMinute, 0, ThroughputWindow-1),
+	}
+
+	current := now.Unix() / 60
+	stats.Current = t.buckets[current%ThroughputWindow].load(current)
+
+	first := max(current-ThroughputWindow+1, t.started.Unix()/60)
+	var sum ThroughputCounts
+	for minute := first; minute < current; minute++ {
+		counts := t.buckets[minute%ThroughputWindow].load(minute)
+		sum.Created += counts.Created
+		sum.Updated += counts.Updated
+		sum.Deleted += counts.Deleted
+		stats.Minutes = append(stats.Minutes, ThroughputMinute{Minute: minute * 60, ThroughputCounts: counts})
+	}
+	if n := float64(len(stats.Minutes)); n > 0 {
+		stats.Rate = ThroughputRates{
+			Created: float64(sum.Created) / n,
+			Updated: float64(sum.Updated) / n,
+			Deleted: float64(sum.Deleted) / n,
+		}
+	}
+	return stats
+}
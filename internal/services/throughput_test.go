@@ -0,0 +1,87 @@
+package services
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/requests"
+)
+
+func TestThroughput_MinuteBuckets(t *testing.T) {
+	start := time.Unix(6000, 0) // Minute 100
+	tp := &Throughput{started: start}
+
+	tp.Record(OpCreate, start)
+	tp.Record(OpCreate, start.Add(10*time.Second))
+	tp.Record(OpUpdate, start.Add(time.Minute))
+	tp.Record(OpDelete, start.Add(2*time.Minute))
+
+	stats := tp.Snapshot(start.Add(2*time.Minute + 30*time.Second))
+	if stats.Total != (ThroughputCounts{Created: 2, Updated: 1, Deleted: 1}) {
+		t.Errorf("Unexpected totals %+v", stats.Total)
+	}
+	if stats.Current != (ThroughputCounts{Deleted: 1}) {
+		t.Errorf("Expected the running minute to hold the delete, got %+v", stats.Current)
+	}
+	if len(stats.Minutes) != 2 || stats.Minutes[0].Minute != 6000 || stats.Minutes[0].Created != 2 || stats.Minutes[1].Updated != 1 {
+		t.Errorf("Unexpected minutes %+v", stats.Minutes)
+	}
+	if stats.Rate != (ThroughputRates{Created: 1, Updated: 0.5}) {
+		t.Errorf("Unexpected rates %+v", stats.Rate)
+	}
+
+	// Once the ring wraps, stale buckets no longer count
+	later := start.Add(ThroughputWindow * time.Minute)
+	tp.Record(OpUpdate, later)
+	stats = tp.Snapshot(later)
+	if stats.Current != (ThroughputCounts{Updated: 1}) {
+		t.Errorf("Expected reused bucket to be reset, got %+v", stats.Current)
+	}
+	if len(stats.Minutes) != ThroughputWindow-1 || stats.Minutes[0].Minute != 6060 {
+		t.Errorf("Expected %d minutes starting at 6060, got %d starting at %d", ThroughputWindow-1, len(stats.Minutes), stats.Minutes[0].Minute)
+	}
+	if stats.Total.Updated != 2 {
+		t.Errorf("Expected totals to survive the wrap, got %+v", stats.Total)
+	}
+}
+
+func TestThroughput_Concurrent(t *testing.T) {
+	tp := NewThroughput()
+	now := time.Now()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				tp.Record(OpCreate, now)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := tp.Snapshot(now).Total.Created; got != 8000 {
+		t.Errorf("Expected 8000 creates, got %d", got)
+	}
+}
+
+func TestTaskService_Throughput(t *testing.T) {
+	service := setupTestService()
+
+	task, _ := service.CreateTask(&requests.CreateTaskRequest{Name: "a"})
+	service.CreateTask(&requests.CreateTaskRequest{Name: "b"})
+	service.UpdateTask(task.ID, &requests.UpdateTaskRequest{Name: "a2"})
+	service.UpdateTask(999, &requests.UpdateTaskRequest{Name: "missing"})
+	service.DeleteTask(task.ID)
+	service.DeleteTask(task.ID) // Already gone: not counted
+
+	stats := service.Throughput()
+	if stats.Total != (ThroughputCounts{Created: 2, Updated: 1, Deleted: 1}) {
+		t.Errorf("Expected only successful mutations to count, got %+v", stats.Total)
+	}
+	if stats.Current != stats.Total {
+		t.Errorf("Expected all mutations in the running minute, got %+v", stats.Current)
+	}
+}
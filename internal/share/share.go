@@ -0,0 +1,270 @@
+package share
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tasks-service-demo/internal/storage"
+)
+
+// Package share issues and verifies time-limited, HMAC-signed tokens granting
+// read-only access to a single task, with revocation.
+
+const (
+	DefaultTTL = 24 * time.Hour     // Link lifetime when none is requested
+	MaxTTL     = 7 * 24 * time.Hour // Longest lifetime a link may be issued for
+)
+
+// metaKey is the storage.MetaStore record holding the revocations
+const metaKey = "share-revocations"
+
+var (
+	ErrDisabled     = errors.New("sharing is not configured")
+	ErrInvalidToken = errors.New("invalid share token")
+	ErrExpired      = errors.New("share link expired")
+	ErrRevoked      = errors.New("share link revoked")
+)
+
+// Link describes an issued share link
+type Link struct {
+	ID        string    `json:"id"`
+	TaskID    int       `json:"taskId"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// claims is the signed token payload
+type claims struct {
+	ID        string `json:"jti"`
+	TaskID    int    `json:"tid"`
+	ExpiresAt int64  `json:"exp"`
+	KeyID     string `json:"kid"`
+}
+
+// Key is a named HMAC signing key
+type Key struct {
+	ID     string
+	Secret string
+}
+
+// Signer issues and verifies share tokens. The first configured key signs new
+// tokens; every key verifies, so keys can be rotated without breaking links.
+type Signer struct {
+	keys      map[string][]byte
+	activeKey string
+	meta      storage.MetaStore // Nil keeps revocations in memory only
+
+	mu      sync.Mutex
+	revoked map[string]time.Time // Link ID -> expiry, pruned once expired
+}
+
+// New creates a Signer from keys, the first being the active signing key.
+// With no keys the Signer is disabled. Revocations are saved in meta when it
+// is not nil, so revoked links stay revoked after a restart.
+func New(keys []Key, meta storage.MetaStore) *Signer {
+	s := &Signer{keys: make(map[string][]byte, len(keys)), meta: meta, revoked: make(map[string]time.Time)}
+	for i, k := range keys {
+		if i == 0 {
+			s.activeKey = k.ID
+		}
+		s.keys[k.ID] = []byte(k.Secret)
+	}
+	return s
+}
+
+// ParseKeys parses comma-separated "kid:secret" pairs
+func ParseKeys(s string) ([]Key, error) {
+	var keys []Key
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		id, secret, ok := strings.Cut(pair, ":")
+		if !ok || id == "" || secret == "" {
+			return nil, fmt.Errorf("invalid signing key entry %q (want kid:secret)", pair)
+		}
+		keys = append(keys, Key{ID: id, Secret: secret})
+	}
+	return keys, nil
+}
+
+// Enabled reports whether a signing key is configured
+func (s *Signer) Enabled() bool {
+	return s.activeKey != ""
+}
+
+// Issue creates a link to taskID valid for ttl, clamped to MaxTTL
+// (ttl <= 0 uses DefaultTTL)
+func (s *Signer) Issue(taskID int, ttl time.Duration) (Link, error) {
+	if !s.Enabled() {
+		return Link{}, ErrDisabled
+	}
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	ttl = min(ttl, MaxTTL)
+
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return Link{}, err
+	}
+
+	expiresAt := time.Now().Add(ttl).UTC().Truncate(time.Second)
+	c := claims{ID: hex.EncodeToString(id), TaskID: taskID, ExpiresAt: expiresAt.Unix(), KeyID: s.activeKey}
+	payload, _ := json.Marshal(c)
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	token := encoded + "." + base64.RawURLEncoding.EncodeToString(sign(s.keys[s.activeKey], encoded))
+
+	return Link{ID: c.ID, TaskID: taskID, Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// Verify checks a token's signature, expiry and revocation and returns its link
+func (s *Signer) Verify(token string) (Link, error) {
+	if !s.Enabled() {
+		return Link{}, ErrDisabled
+	}
+
+	encoded, sigStr, ok := strings.Cut(token, ".")
+	if !ok {
+		return Link{}, ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Link{}, ErrInvalidToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(sigStr)
+	if err != nil {
+		return Link{}, ErrInvalidToken
+	}
+
+	var c claims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return Link{}, ErrInvalidToken
+	}
+	key, ok := s.keys[c.KeyID]
+	if !ok || !hmac.Equal(sig, sign(key, encoded)) {
+		return Link{}, ErrInvalidToken
+	}
+
+	link := Link{ID: c.ID, TaskID: c.TaskID, Token: token, ExpiresAt: time.Unix(c.ExpiresAt, 0).UTC()}
+	if !time.Now().Before(link.ExpiresAt) {
+		return Link{}, ErrExpired
+	}
+	if s.isRevoked(c.ID) {
+		return Link{}, ErrRevoked
+	}
+	return link, nil
+}
+
+// Load restores the revocations saved by earlier processes
+func (s *Signer) Load() error {
+	if s.meta == nil {
+		return nil
+	}
+	data, ok, err := s.meta.GetMeta(metaKey)
+	if err != nil || !ok {
+		return err
+	}
+	var saved map[string]time.Time
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("parse saved revocations: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, expiresAt := range saved {
+		s.revoked[id] = expiresAt
+	}
+	s.pruneLocked()
+	return nil
+}
+
+// Persistent reports whether revocations are saved in the backend
+func (s *Signer) Persistent() bool {
+	return s.meta != nil
+}
+
+// Revoke invalidates a verified token before it expires. A revocation that
+// could not be saved still applies until the process stops, and is reported.
+func (s *Signer) Revoke(token string) error {
+	link, err := s.Verify(token)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked()
+	s.revoked[link.ID] = link.ExpiresAt
+	if err := s.saveLocked(); err != nil {
+		return fmt.Errorf("save revocation: %w", err)
+	}
+	return nil
+}
+
+func (s *Signer) isRevoked(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.revoked[id]
+	return ok
+}
+
+// pruneLocked drops revocations whose links have expired anyway
+func (s *Signer) pruneLocked() {
+	now := time.Now()
+	for id, expiresAt := range s.revoked {
+		if !now.Before(expiresAt) {
+			delete(s.revoked, id)
+		}
+	}
+}
+
+func (s *Signer) saveLocked() error {
+	if s.meta == nil {
+		return nil
+	}
+	data, err := json.Marshal(s.revoked)
+	if err != nil {
+		return err
+	}
+	return s.meta.PutMeta(metaKey, data)
+}
+
+func sign(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+var current atomic.Pointer[Signer]
+
+func init() {
+	Reset()
+}
+
+// Get returns the process-wide Signer
+func Get() *Signer {
+	return current.Load()
+}
+
+// Init replaces the process-wide Signer
+func Init(s *Signer) {
+	current.Store(s)
+}
+
+// Reset installs a Signer without keys (sharing disabled)
+func Reset() {
+	current.Store(New(nil, nil))
+}
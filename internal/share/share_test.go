@@ -0,0 +1,193 @@
+package share
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseKeys(t *testing.T) {
+	keys, err := ParseKeys("k2:new-secret, k1:old:secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0] != (Key{"k2", "new-secret"}) || keys[1] != (Key{"k1", "old:secret"}) {
+		t.Errorf("Unexpected keys %+v", keys)
+	}
+
+	for _, invalid := range []string{"nosecret", ":secret", "kid:"} {
+		if _, err := ParseKeys(invalid); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
+
+func TestSigner_IssueAndVerify(t *testing.T) {
+	s := New([]Key{{"k1", "secret"}}, nil)
+
+	link, err := s.Issue(42, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.TaskID != 42 || link.ID == "" || link.Token == "" {
+		t.Fatalf("Unexpected link %+v", link)
+	}
+
+	got, err := s.Verify(link.Token)
+	if err != nil {
+		t.Fatalf("Expected token to verify, got %v", err)
+	}
+	if got.TaskID != 42 || got.ID != link.ID || !got.ExpiresAt.Equal(link.ExpiresAt) {
+		t.Errorf("Expected %+v, got %+v", link, got)
+	}
+}
+
+func TestSigner_TTL(t *testing.T) {
+	s := New([]Key{{"k1", "secret"}}, nil)
+
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		expected time.Duration
+	}{
+		{"default", 0, DefaultTTL},
+		{"clamped", 30 * 24 * time.Hour, MaxTTL},
+		{"custom", time.Minute, time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link, _ := s.Issue(1, tt.ttl)
+			if got := time.Until(link.ExpiresAt); got > tt.expected || got < tt.expected-2*time.Second {
+				t.Errorf("Expected lifetime ~%s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSigner_Rejects(t *testing.T) {
+	s := New([]Key{{"k1", "secret"}}, nil)
+	link, _ := s.Issue(1, time.Hour)
+	other, _ := New([]Key{{"k1", "other"}}, nil).Issue(1, time.Hour)
+	payload, sig, _ := strings.Cut(link.Token, ".")
+	forged, _, _ := strings.Cut(other.Token, ".")
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"wrong key", other.Token},
+		{"swapped payload", forged + "." + sig},
+		{"truncated signature", payload + "." + sig[:10]},
+		{"no signature", payload},
+		{"garbage", "not-a-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Verify(tt.token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Expected ErrInvalidToken, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSigner_Expired(t *testing.T) {
+	s := New([]Key{{"k1", "secret"}}, nil)
+	link, _ := s.Issue(1, time.Second)
+
+	time.Sleep(1100 * time.Millisecond)
+	if _, err := s.Verify(link.Token); !errors.Is(err, ErrExpired) {
+		t.Errorf("Expected ErrExpired, got %v", err)
+	}
+}
+
+func TestSigner_Revoke(t *testing.T) {
+	s := New([]Key{{"k1", "secret"}}, nil)
+	link, _ := s.Issue(1, time.Hour)
+	kept, _ := s.Issue(1, time.Hour)
+
+	if err := s.Revoke(link.Token); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Verify(link.Token); !errors.Is(err, ErrRevoked) {
+		t.Errorf("Expected ErrRevoked, got %v", err)
+	}
+	if _, err := s.Verify(kept.Token); err != nil {
+		t.Errorf("Expected other links to stay valid, got %v", err)
+	}
+}
+
+type memMeta struct {
+	records map[string][]byte
+	fail    error
+}
+
+func (m *memMeta) GetMeta(key string) ([]byte, bool, error) {
+	value, ok := m.records[key]
+	return value, ok, nil
+}
+
+func (m *memMeta) PutMeta(key string, value []byte) error {
+	if m.fail != nil {
+		return m.fail
+	}
+	m.records[key] = value
+	return nil
+}
+
+func TestSigner_RevokeSurvivesRestart(t *testing.T) {
+	meta := &memMeta{records: make(map[string][]byte)}
+	s := New([]Key{{"k1", "secret"}}, meta)
+	link, _ := s.Issue(1, time.Hour)
+	kept, _ := s.Issue(1, time.Hour)
+	if err := s.Revoke(link.Token); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted := New([]Key{{"k1", "secret"}}, meta)
+	if err := restarted.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := restarted.Verify(link.Token); !errors.Is(err, ErrRevoked) {
+		t.Errorf("Expected the link revoked after a restart, got %v", err)
+	}
+	if _, err := restarted.Verify(kept.Token); err != nil {
+		t.Errorf("Expected other links to stay valid, got %v", err)
+	}
+
+	// A revocation that cannot be saved is reported but still applies
+	meta.fail = errors.New("disk full")
+	if err := restarted.Revoke(kept.Token); err == nil {
+		t.Error("Expected the save failure reported")
+	}
+	if _, err := restarted.Verify(kept.Token); !errors.Is(err, ErrRevoked) {
+		t.Errorf("Expected the link revoked in memory, got %v", err)
+	}
+}
+
+func TestSigner_KeyRotation(t *testing.T) {
+	old := New([]Key{{"k1", "old"}}, nil)
+	link, _ := old.Issue(7, time.Hour)
+
+	rotated := New([]Key{{"k2", "new"}, {"k1", "old"}}, nil)
+	if _, err := rotated.Verify(link.Token); err != nil {
+		t.Errorf("Expected link signed with a retired key to verify, got %v", err)
+	}
+
+	retired := New([]Key{{"k2", "new"}}, nil)
+	if _, err := retired.Verify(link.Token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected link to fail once its key is removed, got %v", err)
+	}
+}
+
+func TestSigner_Disabled(t *testing.T) {
+	Reset()
+	if Get().Enabled() {
+		t.Fatal("Expected default signer to be disabled")
+	}
+	if _, err := Get().Issue(1, time.Hour); !errors.Is(err, ErrDisabled) {
+		t.Errorf("Expected ErrDisabled, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package shutdown
+
+import (
+	"context"
+	"sync"
+)
+
+// Barrier tracks in-flight writes so shutdown can wait for them. Once
+// closed it admits no new ones.
+type Barrier struct {
+	mu       sync.Mutex
+	closed   bool
+	inFlight int
+	idle     chan struct{} // Closed when closed and nothing is in flight
+}
+
+// NewBarrier creates an open Barrier
+func NewBarrier() *Barrier {
+	return &Barrier{idle: make(chan struct{})}
+}
+
+// Enter admits a write, which must call Leave when done. It returns false
+// once the barrier is closed.
+func (b *Barrier) Enter() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return false
+	}
+	b.inFlight++
+	return true
+}
+
+// Leave marks an admitted write as done
+func (b *Barrier) Leave() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.inFlight--
+	if b.closed && b.inFlight == 0 {
+		close(b.idle)
+	}
+}
+
+// InFlight returns the number of admitted writes not yet done
+func (b *Barrier) InFlight() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.inFlight
+}
+
+// Close stops admitting writes and waits until those in flight are done or
+// ctx is done
+func (b *Barrier) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		if b.inFlight == 0 {
+			close(b.idle)
+		}
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-b.idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
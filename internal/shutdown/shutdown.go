@@ -0,0 +1,136 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	applog "tasks-service-demo/internal/logger"
+)
+
+// Package shutdown stops the service in dependency order under one
+// deadline: intake first, then in-flight writes, background workers,
+// buffered data and finally storage.
+
+// DefaultTimeout bounds the whole shutdown when none is configured
+const DefaultTimeout = 30 * time.Second
+
+// ErrDeadline is returned by Run when steps were still running or never ran
+// when the deadline passed
+var ErrDeadline = errors.New("shutdown deadline exceeded")
+
+// Phase orders shutdown steps; every step of a phase finishes before the
+// next phase starts
+type Phase int
+
+const (
+	PhaseIntake  Phase = iota // Stop accepting requests
+	PhaseDrain                // Wait for in-flight writes
+	PhaseWorkers              // Stop jobs, subscribers and publishers
+	PhaseFlush                // Flush buffered writes
+	PhaseStorage              // Close the store
+)
+
+var phaseNames = map[Phase]string{
+	PhaseIntake:  "intake",
+	PhaseDrain:   "drain",
+	PhaseWorkers: "workers",
+	PhaseFlush:   "flush",
+	PhaseStorage: "storage",
+}
+
+func (p Phase) String() string {
+	if name, ok := phaseNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("phase(%d)", int(p))
+}
+
+type step struct {
+	phase Phase
+	name  string
+	fn    func(ctx context.Context) error
+}
+
+// Coordinator runs the registered shutdown steps once
+type Coordinator struct {
+	timeout time.Duration
+
+	mu    sync.Mutex
+	steps []step
+	once  sync.Once
+	err   error
+}
+
+// New creates a Coordinator whose steps must all finish within timeout
+// (DefaultTimeout when not positive)
+func New(timeout time.Duration) *Coordinator {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Coordinator{timeout: timeout}
+}
+
+// Timeout is the deadline of the whole shutdown
+func (c *Coordinator) Timeout() time.Duration {
+	return c.timeout
+}
+
+// Add registers fn to run in phase, after the steps already added to it.
+// fn should return when ctx is done; a step still running at the deadline
+// is abandoned.
+func (c *Coordinator) Add(phase Phase, name string, fn func(ctx context.Context) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.steps = append(c.steps, step{phase: phase, name: name, fn: fn})
+}
+
+// Run runs the steps by phase, one at a time, and returns their joined
+// errors. Once the deadline passes the running step is abandoned, the
+// remaining ones are skipped and ErrDeadline is included. Later calls
+// return the result of the first.
+func (c *Coordinator) Run(ctx context.Context) error {
+	c.once.Do(func() {
+		ctx, cancel := context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+		c.err = c.run(ctx)
+	})
+	return c.err
+}
+
+func (c *Coordinator) run(ctx context.Context) error {
+	c.mu.Lock()
+	steps := make([]step, len(c.steps))
+	copy(steps, c.steps)
+	c.mu.Unlock()
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].phase < steps[j].phase })
+
+	var errs []error
+	for i, s := range steps {
+		start := time.Now()
+		done := make(chan error, 1)
+		go func(s step) { done <- s.fn(ctx) }(s)
+
+		select {
+		case err := <-done:
+			log := applog.Get().With("phase", s.phase.String(), "step", s.name, "duration", time.Since(start))
+			if err != nil {
+				log.Errorw("Shutdown step failed", "error", err)
+				errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+			} else {
+				log.Info("Shutdown step complete")
+			}
+		case <-ctx.Done():
+			skipped := make([]string, 0, len(steps)-i-1)
+			for _, rest := range steps[i+1:] {
+				skipped = append(skipped, rest.name)
+			}
+			applog.Get().Errorw("Shutdown deadline exceeded", "step", s.name, "skipped", skipped)
+			return errors.Join(append(errs, fmt.Errorf("%w in %s", ErrDeadline, s.name))...)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,108 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCoordinator_RunsPhasesInOrder(t *testing.T) {
+	c := New(time.Second)
+	var mu sync.Mutex
+	var order []string
+	record := func(name string, err error) func(context.Context) error {
+		return func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			return err
+		}
+	}
+	c.Add(PhaseStorage, "store", record("store", nil))
+	c.Add(PhaseWorkers, "jobs", record("jobs", nil))
+	c.Add(PhaseIntake, "http", record("http", nil))
+	c.Add(PhaseWorkers, "events", record("events", errors.New("broker gone")))
+	c.Add(PhaseDrain, "writes", record("writes", nil))
+
+	err := c.Run(context.Background())
+	if got := strings.Join(order, ","); got != "http,writes,jobs,events,store" {
+		t.Errorf("Expected steps by phase, then in the order added, got %s", got)
+	}
+	if err == nil || !strings.Contains(err.Error(), "events: broker gone") {
+		t.Errorf("Expected the failed step's error, got %v", err)
+	}
+	if again := c.Run(context.Background()); again != err {
+		t.Errorf("Expected a second Run to return the first result, got %v", again)
+	}
+	if len(order) != 5 {
+		t.Errorf("Expected the steps to run once, got %v", order)
+	}
+}
+
+func TestCoordinator_Deadline(t *testing.T) {
+	c := New(20 * time.Millisecond)
+	release := make(chan struct{})
+	defer close(release)
+	ran := false
+	c.Add(PhaseDrain, "stuck", func(context.Context) error {
+		<-release // Ignores ctx, like a hung writer
+		return nil
+	})
+	c.Add(PhaseStorage, "store", func(context.Context) error {
+		ran = true
+		return nil
+	})
+
+	start := time.Now()
+	err := c.Run(context.Background())
+	if !errors.Is(err, ErrDeadline) {
+		t.Errorf("Expected ErrDeadline, got %v", err)
+	}
+	if ran {
+		t.Error("Expected steps after the deadline to be skipped")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected Run to return at the deadline, took %s", elapsed)
+	}
+}
+
+func TestBarrier(t *testing.T) {
+	b := NewBarrier()
+	if !b.Enter() || !b.Enter() {
+		t.Fatal("Expected an open barrier to admit writes")
+	}
+	b.Leave()
+
+	closed := make(chan error, 1)
+	go func() { closed <- b.Close(context.Background()) }()
+
+	// Close rejects new writes at once but waits for the one in flight
+	deadline := time.Now().Add(time.Second)
+	for b.Enter() {
+		b.Leave()
+		if time.Now().After(deadline) {
+			t.Fatal("Expected Close to stop admitting writes")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case err := <-closed:
+		t.Fatalf("Expected Close to wait for the write in flight, got %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	b.Leave()
+	if err := <-closed; err != nil || b.InFlight() != 0 {
+		t.Errorf("Expected Close to return once idle, got %v with %d in flight", err, b.InFlight())
+	}
+
+	stuck := NewBarrier()
+	stuck.Enter()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := stuck.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Close to give up with ctx, got %v", err)
+	}
+}
@@ -0,0 +1,279 @@
+package lincheck
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/sim"
+	"tasks-service-demo/internal/storage"
+)
+
+// Package lincheck checks that histories of concurrent store operations are
+// linearizable: that every operation appears to take effect at one instant
+// between its call and its return, in an order the sequential sim.Model
+// accepts. The search follows Wing & Gong with Lowe's memoization, as in
+// Porcupine, and histories without list or range reads are checked one task
+// ID at a time.
+
+// Operation is one store call of a history
+type Operation struct {
+	Client       int
+	Call, Return int64    // Logical times; Call < Return
+	Step         sim.Step // The operation and its result; Seq, Actor and At are unused
+}
+
+func (op Operation) String() string {
+	return fmt.Sprintf("client %d [%d, %d] %s", op.Client, op.Call, op.Return, op.Step.Summary())
+}
+
+// History is the operations of concurrent clients against a store holding
+// the Initial tasks
+type History struct {
+	Initial []*entities.Task
+	Ops     []Operation
+}
+
+// Recorder builds a History from calls made by concurrent goroutines. The
+// zero value is ready to use.
+type Recorder struct {
+	clock atomic.Int64
+	mu    sync.Mutex
+	ops   []Operation
+}
+
+// Do runs step on store for client and records it
+func (r *Recorder) Do(client int, store storage.Store, step sim.Step) sim.Step {
+	call := r.clock.Add(1)
+	step = sim.Do(store, step)
+	ret := r.clock.Add(1)
+
+	r.mu.Lock()
+	r.ops = append(r.ops, Operation{Client: client, Call: call, Return: ret, Step: step})
+	r.mu.Unlock()
+	return step
+}
+
+// Operations returns the recorded operations
+func (r *Recorder) Operations() []Operation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Operation(nil), r.ops...)
+}
+
+// maxListed bounds the operations a Violation lists per section
+const maxListed = 50
+
+// Violation reports operations with no linearization
+type Violation struct {
+	ID      int         // Task ID of the operations checked; 0 when the history couldn't be split
+	Ops     []Operation // The operations checked
+	Longest []Operation // The longest order found that the model accepts
+}
+
+func (v *Violation) Error() string {
+	var b strings.Builder
+	scope := "history"
+	if v.ID != 0 {
+		scope = fmt.Sprintf("operations on task %d", v.ID)
+	}
+	fmt.Fprintf(&b, "%s not linearizable: %d of %d operations ordered\n", scope, len(v.Longest), len(v.Ops))
+	list := func(title string, ops []Operation) {
+		b.WriteString(title + ":\n")
+		for i, op := range ops {
+			if i == maxListed {
+				fmt.Fprintf(&b, "  ... %d more\n", len(ops)-i)
+				break
+			}
+			fmt.Fprintf(&b, "  %s\n", op)
+		}
+	}
+	list("longest valid order", v.Longest)
+	list("operations", v.Ops)
+	return b.String()
+}
+
+// Check returns nil if h is linearizable and a *Violation if not. The
+// search is exponential in the worst case, so it gives up with ctx's error
+// once ctx is done.
+func Check(ctx context.Context, h History) error {
+	for _, p := range partition(h) {
+		if err := check(ctx, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// part is a subset of a history that can be checked on its own
+type part struct {
+	id      int
+	initial []*entities.Task
+	ops     []Operation
+}
+
+// partition splits h by task ID. Operations on different tasks don't
+// interact in the model, so h is linearizable iff every part is. Reads of
+// several tasks tie the parts together; then h is checked whole.
+func partition(h History) []part {
+	for _, op := range h.Ops {
+		if op.Step.Op == sim.OpList || op.Step.Op == sim.OpRange {
+			return []part{{initial: h.Initial, ops: h.Ops}}
+		}
+	}
+	byID := make(map[int]*part)
+	get := func(id int) *part {
+		p, ok := byID[id]
+		if !ok {
+			p = &part{id: id}
+			byID[id] = p
+		}
+		return p
+	}
+	for _, task := range h.Initial {
+		p := get(task.ID)
+		p.initial = append(p.initial, task)
+	}
+	for _, op := range h.Ops {
+		p := get(op.Step.ID)
+		p.ops = append(p.ops, op)
+	}
+
+	parts := make([]part, 0, len(byID))
+	for _, p := range byID {
+		parts = append(parts, *p)
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].id < parts[j].id })
+	return parts
+}
+
+// node is the call or return of an operation in the list of pending events
+type node struct {
+	op         int
+	call       bool
+	match      *node // The return of a call
+	prev, next *node
+}
+
+// events links the calls and returns of ops in time order behind a sentinel
+func events(ops []Operation) *node {
+	type event struct {
+		time int64
+		node *node
+	}
+	evs := make([]event, 0, 2*len(ops))
+	for i, op := range ops {
+		ret := &node{op: i}
+		call := &node{op: i, call: true, match: ret}
+		evs = append(evs, event{op.Call, call}, event{op.Return, ret})
+	}
+	// Calls sort before returns at the same time, so such operations overlap
+	sort.SliceStable(evs, func(i, j int) bool {
+		if evs[i].time != evs[j].time {
+			return evs[i].time < evs[j].time
+		}
+		return evs[i].node.call && !evs[j].node.call
+	})
+
+	head := &node{op: -1}
+	prev := head
+	for _, ev := range evs {
+		prev.next, ev.node.prev = ev.node, prev
+		prev = ev.node
+	}
+	return head
+}
+
+// lift unlinks a call and its return
+func lift(n *node) {
+	n.prev.next = n.next
+	n.next.prev = n.prev // A call is always followed by its return
+	m := n.match
+	m.prev.next = m.next
+	if m.next != nil {
+		m.next.prev = m.prev
+	}
+}
+
+// unlift relinks what lift unlinked; calls must be undone in reverse order
+func unlift(n *node) {
+	m := n.match
+	m.prev.next = m
+	if m.next != nil {
+		m.next.prev = m
+	}
+	n.prev.next = n
+	n.next.prev = n
+}
+
+// check searches for an order of p's operations that respects real time and
+// that the model accepts. It repeatedly linearizes the first pending call
+// the model accepts; reaching a return means the operation it ends had to
+// come earlier, so the search backtracks. States already explored, keyed
+// by the set of linearized operations and the model's contents, are skipped.
+func check(ctx context.Context, p part) error {
+	head := events(p.ops)
+	model := sim.NewModel(p.initial...)
+	done := make([]byte, (len(p.ops)+7)/8)
+	seen := make(map[string]struct{})
+
+	type frame struct {
+		call  *node
+		model *sim.Model
+	}
+	var stack []frame
+	var longest []int
+
+	n := head.next
+	for i := 0; head.next != nil; i++ {
+		if i%1024 == 0 {
+			if err := ctx.Err(); err != nil {
+				return fmt.Errorf("linearizability check of %d operations gave up: %w", len(p.ops), err)
+			}
+		}
+
+		if n.call {
+			next := model.Clone()
+			if next.Apply(p.ops[n.op].Step) == nil {
+				done[n.op/8] |= 1 << (n.op % 8)
+				key := string(done) + fmt.Sprint(next.Tasks())
+				if _, ok := seen[key]; !ok {
+					seen[key] = struct{}{}
+					stack = append(stack, frame{call: n, model: model})
+					model = next
+					lift(n)
+					if len(stack) > len(longest) {
+						longest = longest[:0]
+						for _, f := range stack {
+							longest = append(longest, f.call.op)
+						}
+					}
+					n = head.next
+					continue
+				}
+				done[n.op/8] &^= 1 << (n.op % 8)
+			}
+			n = n.next
+			continue
+		}
+
+		if len(stack) == 0 {
+			v := &Violation{ID: p.id, Ops: p.ops}
+			for _, op := range longest {
+				v.Longest = append(v.Longest, p.ops[op])
+			}
+			return v
+		}
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		model = top.model
+		done[top.call.op/8] &^= 1 << (top.call.op % 8)
+		unlift(top.call)
+		n = top.call.next
+	}
+	return nil
+}
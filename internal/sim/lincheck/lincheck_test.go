@@ -0,0 +1,197 @@
+package lincheck
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/sim"
+	"tasks-service-demo/internal/storage"
+	_ "tasks-service-demo/internal/storage/backends"
+	"tasks-service-demo/internal/storage/channel"
+	"tasks-service-demo/internal/storage/naive"
+)
+
+func op(client int, call, ret int64, step sim.Step) Operation {
+	return Operation{Client: client, Call: call, Return: ret, Step: step}
+}
+
+func task(id int, name string) *entities.Task {
+	return &entities.Task{ID: id, Name: name}
+}
+
+func TestCheck(t *testing.T) {
+	notFound := apperrors.ErrCodeTaskNotFound
+	tests := []struct {
+		name     string
+		history  History
+		expected bool
+	}{
+		{"sequential", History{Ops: []Operation{
+			op(0, 1, 2, sim.Step{Op: sim.OpCreate, ID: 1, Task: task(1, "a")}),
+			op(0, 3, 4, sim.Step{Op: sim.OpGet, ID: 1, Task: task(1, "a")}),
+			op(0, 5, 6, sim.Step{Op: sim.OpDelete, ID: 1}),
+			op(0, 7, 8, sim.Step{Op: sim.OpGet, ID: 1, Err: notFound}),
+		}}, true},
+		{"read overlapping an update sees the new value", History{Initial: []*entities.Task{task(1, "a")}, Ops: []Operation{
+			op(0, 1, 4, sim.Step{Op: sim.OpUpdate, ID: 1, Task: task(1, "b")}),
+			op(1, 2, 3, sim.Step{Op: sim.OpGet, ID: 1, Task: task(1, "b")}),
+		}}, true},
+		{"read overlapping an update sees the old value", History{Initial: []*entities.Task{task(1, "a")}, Ops: []Operation{
+			op(0, 1, 4, sim.Step{Op: sim.OpUpdate, ID: 1, Task: task(1, "b")}),
+			op(1, 2, 3, sim.Step{Op: sim.OpGet, ID: 1, Task: task(1, "a")}),
+		}}, true},
+		{"stale read after an update returned", History{Initial: []*entities.Task{task(1, "a")}, Ops: []Operation{
+			op(0, 1, 2, sim.Step{Op: sim.OpUpdate, ID: 1, Task: task(1, "b")}),
+			op(1, 3, 4, sim.Step{Op: sim.OpGet, ID: 1, Task: task(1, "a")}),
+		}}, false},
+		{"reads disagree on the order of concurrent updates", History{Initial: []*entities.Task{task(1, "a")}, Ops: []Operation{
+			op(0, 1, 10, sim.Step{Op: sim.OpUpdate, ID: 1, Task: task(1, "b")}),
+			op(1, 2, 10, sim.Step{Op: sim.OpUpdate, ID: 1, Task: task(1, "c")}),
+			op(2, 3, 4, sim.Step{Op: sim.OpGet, ID: 1, Task: task(1, "b")}),
+			op(2, 5, 6, sim.Step{Op: sim.OpGet, ID: 1, Task: task(1, "c")}),
+			op(2, 7, 8, sim.Step{Op: sim.OpGet, ID: 1, Task: task(1, "b")}),
+		}}, false},
+		{"update and delete racing", History{Initial: []*entities.Task{task(1, "a")}, Ops: []Operation{
+			op(0, 1, 4, sim.Step{Op: sim.OpUpdate, ID: 1, Err: notFound}),
+			op(1, 2, 3, sim.Step{Op: sim.OpDelete, ID: 1}),
+		}}, true},
+		{"list missing a task created before it", History{Ops: []Operation{
+			op(0, 1, 2, sim.Step{Op: sim.OpCreate, ID: 1, Task: task(1, "a")}),
+			op(1, 3, 6, sim.Step{Op: sim.OpList}),
+			op(0, 4, 5, sim.Step{Op: sim.OpCreate, ID: 2, Task: task(2, "b")}),
+		}}, false},
+		{"list overlapping a create", History{Ops: []Operation{
+			op(0, 1, 2, sim.Step{Op: sim.OpCreate, ID: 1, Task: task(1, "a")}),
+			op(1, 3, 6, sim.Step{Op: sim.OpList, Tasks: []entities.Task{*task(1, "a"), *task(2, "b")}}),
+			op(0, 4, 5, sim.Step{Op: sim.OpCreate, ID: 2, Task: task(2, "b")}),
+		}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Check(context.Background(), tt.history)
+			var v *Violation
+			if tt.expected && err != nil {
+				t.Errorf("Expected a linearizable history, got %v", err)
+			}
+			if !tt.expected && !errors.As(err, &v) {
+				t.Errorf("Expected a violation, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCheck_ReportsTheTaskAndGivesUp(t *testing.T) {
+	h := History{Initial: []*entities.Task{task(1, "a"), task(2, "a")}, Ops: []Operation{
+		op(0, 1, 2, sim.Step{Op: sim.OpGet, ID: 1, Task: task(1, "a")}),
+		op(0, 3, 4, sim.Step{Op: sim.OpDelete, ID: 2}),
+		op(1, 5, 6, sim.Step{Op: sim.OpGet, ID: 2, Task: task(2, "a")}),
+	}}
+	var v *Violation
+	if err := Check(context.Background(), h); !errors.As(err, &v) || v.ID != 2 || len(v.Longest) != 1 {
+		t.Errorf("Expected a violation on task 2 after 1 operation, got %v", err)
+	} else if !strings.Contains(err.Error(), "operations on task 2 not linearizable") {
+		t.Errorf("Unexpected message %q", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Check(ctx, h); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the check to give up, got %v", err)
+	}
+}
+
+// staleStore serves reads from a cache that updates and deletes don't
+// invalidate
+type staleStore struct {
+	storage.Store
+	mu    sync.Mutex
+	cache map[int]entities.Task
+}
+
+func (s *staleStore) GetByID(id int) (*entities.Task, *apperrors.AppError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if task, ok := s.cache[id]; ok {
+		return &task, nil
+	}
+	task, err := s.Store.GetByID(id)
+	if err == nil {
+		s.cache[id] = *task
+	}
+	return task, err
+}
+
+func checkStress(t *testing.T, store storage.Store, cfg StressConfig) error {
+	t.Helper()
+	h, err := Stress(store, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return Check(ctx, h)
+}
+
+func TestStress_CatchesStaleReads(t *testing.T) {
+	store := &staleStore{Store: naive.NewMemoryStore(), cache: make(map[int]entities.Task)}
+	var v *Violation
+	if err := checkStress(t, store, DefaultStress); !errors.As(err, &v) {
+		t.Errorf("Expected a violation, got %v", err)
+	}
+}
+
+// TestLinearizable_Backends gates every registered backend, and the
+// channel store, on point operations being linearizable
+func TestLinearizable_Backends(t *testing.T) {
+	stores := map[string]func(t *testing.T) storage.Store{
+		"channel": func(t *testing.T) storage.Store {
+			store := channel.NewChannelStore(1)
+			t.Cleanup(store.Shutdown)
+			return store
+		},
+	}
+	for _, name := range storage.Backends() {
+		backend, _ := storage.Lookup(name)
+		stores[name] = func(t *testing.T) storage.Store {
+			store, err := backend.New(storage.Options{ShardCount: 4, DataDir: t.TempDir()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { storage.Close(store) })
+			return store
+		}
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			if err := checkStress(t, newStore(t), DefaultStress); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// TestLinearizable_Scans checks list and range reads on the stores that
+// read all tasks at one instant. Sharded and lock-free stores read their
+// partitions one after another, so a scan racing writes may match no
+// single point in time.
+func TestLinearizable_Scans(t *testing.T) {
+	cfg := DefaultStress
+	cfg.Scans, cfg.Clients, cfg.Ops = true, 4, 100
+
+	if err := checkStress(t, naive.NewMemoryStore(), cfg); err != nil {
+		t.Errorf("memory: %v", err)
+	}
+	store := channel.NewChannelStore(1)
+	defer store.Shutdown()
+	if err := checkStress(t, store, cfg); err != nil {
+		t.Errorf("channel: %v", err)
+	}
+}
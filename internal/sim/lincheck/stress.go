@@ -0,0 +1,90 @@
+package lincheck
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/sim"
+	"tasks-service-demo/internal/storage"
+)
+
+// StressConfig shapes the load Stress puts on a store
+type StressConfig struct {
+	Clients int   // Concurrent goroutines
+	Ops     int   // Operations per client
+	Keys    int   // Tasks created before the clients start
+	Scans   bool  // Mix in list and range reads; the history is then checked whole
+	Seed    int64 // Seeds the clients' choices; the interleaving still varies
+}
+
+// DefaultStress is a load that finishes checking in well under a second
+var DefaultStress = StressConfig{Clients: 8, Ops: 200, Keys: 8, Seed: 1}
+
+// Stress hammers store from concurrent clients and returns the history it
+// recorded. Clients pick IDs among the tasks created so far, plus one that
+// never exists, so updates and deletes often race on the same task.
+func Stress(store storage.Store, cfg StressConfig) (History, error) {
+	var h History
+	var idsMu sync.Mutex
+	var ids []int
+	for i := 0; i < cfg.Keys; i++ {
+		task := &entities.Task{Name: fmt.Sprintf("seed-%d", i)}
+		if err := store.Create(task); err != nil {
+			return h, fmt.Errorf("creating initial task: %w", err)
+		}
+		copied := *task
+		h.Initial = append(h.Initial, &copied)
+		ids = append(ids, task.ID)
+	}
+
+	var rec Recorder
+	var wg sync.WaitGroup
+	for c := 0; c < cfg.Clients; c++ {
+		wg.Add(1)
+		go func(client int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(cfg.Seed*1000 + int64(client)))
+			pick := func() int {
+				idsMu.Lock()
+				defer idsMu.Unlock()
+				if len(ids) == 0 || rng.Intn(10) == 0 {
+					return -1 // Never assigned
+				}
+				return ids[rng.Intn(len(ids))]
+			}
+
+			for i := 0; i < cfg.Ops; i++ {
+				task := &entities.Task{Name: fmt.Sprintf("c%d-%d", client, i), Status: rng.Intn(2)}
+				var step sim.Step
+				switch r := rng.Intn(100); {
+				case r < 10:
+					step = sim.Step{Op: sim.OpCreate, Task: task}
+				case r < 25:
+					step = sim.Step{Op: sim.OpDelete, ID: pick()}
+				case r < 50:
+					step = sim.Step{Op: sim.OpUpdate, ID: pick(), Task: task}
+				case cfg.Scans && r < 55:
+					step = sim.Step{Op: sim.OpList}
+				case cfg.Scans && r < 60:
+					from := pick()
+					step = sim.Step{Op: sim.OpRange, From: from, To: from + rng.Intn(8), Limit: rng.Intn(4)}
+				default:
+					step = sim.Step{Op: sim.OpGet, ID: pick()}
+				}
+
+				step = rec.Do(client, store, step)
+				if step.Op == sim.OpCreate && step.Err == 0 {
+					idsMu.Lock()
+					ids = append(ids, step.ID)
+					idsMu.Unlock()
+				}
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	h.Ops = rec.Operations()
+	return h, nil
+}
@@ -0,0 +1,225 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"runtime/debug"
+	"time"
+)
+
+// Package sim runs concurrent scenarios deterministically. Actors are
+// goroutines, but only one of them runs at any moment: each parks before
+// every store operation and a scheduler seeded by Config.Seed picks which
+// one goes next. A seed therefore names one interleaving, and a failure
+// found by Explore replays exactly from its seed. Time is virtual: actors
+// sleep on a Clock that jumps ahead when every actor is asleep.
+
+// DefaultStallTimeout bounds the real time an actor may run between two steps
+const DefaultStallTimeout = 5 * time.Second
+
+// DefaultStart is the virtual time a simulation starts at
+var DefaultStart = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Config tunes a Sim
+type Config struct {
+	Seed         int64         // Picks the interleaving
+	Start        time.Time     // Virtual start time; zero means DefaultStart
+	StallTimeout time.Duration // Zero means DefaultStallTimeout
+}
+
+// Sim schedules the steps of its actors one at a time
+type Sim struct {
+	cfg     Config
+	rng     *rand.Rand
+	clock   *Clock
+	actors  []*Actor
+	parked  chan *Actor // An actor parked or finished
+	current *Actor      // The actor running, nil between steps
+	started bool
+	trace   Trace
+}
+
+// Actor is one concurrent participant of a simulation
+type Actor struct {
+	sim    *Sim
+	name   string
+	fn     func(a *Actor) error
+	resume chan struct{}
+	wake   time.Time // Set while sleeping
+	done   bool
+	err    error
+}
+
+// New creates a Sim with no actors
+func New(cfg Config) *Sim {
+	if cfg.Start.IsZero() {
+		cfg.Start = DefaultStart
+	}
+	if cfg.StallTimeout <= 0 {
+		cfg.StallTimeout = DefaultStallTimeout
+	}
+	return &Sim{
+		cfg:    cfg,
+		rng:    rand.New(rand.NewSource(cfg.Seed)),
+		clock:  NewClock(cfg.Start),
+		parked: make(chan *Actor),
+	}
+}
+
+// Seed returns the seed of the interleaving
+func (s *Sim) Seed() int64 {
+	return s.cfg.Seed
+}
+
+// Clock returns the virtual clock
+func (s *Sim) Clock() *Clock {
+	return s.clock
+}
+
+// Go adds an actor running fn; call it before Run
+func (s *Sim) Go(name string, fn func(a *Actor) error) {
+	if s.started {
+		panic("sim: Go called after Run")
+	}
+	s.actors = append(s.actors, &Actor{sim: s, name: name, fn: fn, resume: make(chan struct{})})
+}
+
+// Run runs the actors to completion and returns the steps they took in
+// order, with the errors they returned. An actor that blocks outside the
+// simulation for longer than the stall timeout, such as on a lock held by
+// a parked actor, stops the run; its goroutines are then left behind.
+func (s *Sim) Run() (Trace, error) {
+	if s.started {
+		return nil, errors.New("sim: Run called twice")
+	}
+	s.started = true
+	for _, a := range s.actors {
+		go a.main()
+	}
+
+	timer := time.NewTimer(s.cfg.StallTimeout)
+	defer timer.Stop()
+	for a := s.next(); a != nil; a = s.next() {
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(s.cfg.StallTimeout)
+
+		s.current = a
+		a.resume <- struct{}{}
+		select {
+		case <-s.parked:
+		case <-timer.C:
+			return nil, fmt.Errorf("sim: seed %d: actor %s stalled for %s after %d steps", s.cfg.Seed, a.name, s.cfg.StallTimeout, len(s.trace))
+		}
+		s.current = nil
+	}
+
+	var errs []error
+	for _, a := range s.actors {
+		if a.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", a.name, a.err))
+		}
+	}
+	return s.trace, errors.Join(errs...)
+}
+
+// next picks a ready actor at random. When every live actor is asleep, the
+// clock jumps to the earliest wake-up. It returns nil once all are done.
+func (s *Sim) next() *Actor {
+	now := s.clock.Now()
+	var ready []*Actor
+	var sleeper *Actor
+	for _, a := range s.actors {
+		switch {
+		case a.done:
+		case !a.wake.After(now):
+			ready = append(ready, a)
+		case sleeper == nil || a.wake.Before(sleeper.wake):
+			sleeper = a
+		}
+	}
+	if len(ready) > 0 {
+		return ready[s.rng.Intn(len(ready))]
+	}
+	if sleeper != nil {
+		s.clock.advanceTo(sleeper.wake)
+	}
+	return sleeper
+}
+
+// step parks the running actor until the scheduler picks it again and
+// returns it. It panics when called outside an actor.
+func (s *Sim) step() *Actor {
+	a := s.current
+	if a == nil {
+		panic("sim: store operation outside an actor")
+	}
+	a.Yield()
+	return a
+}
+
+// record appends a step taken by a
+func (s *Sim) record(a *Actor, step Step) {
+	step.Seq = len(s.trace)
+	step.Actor = a.name
+	step.At = s.clock.Now().Sub(s.cfg.Start)
+	s.trace = append(s.trace, step)
+}
+
+func (a *Actor) main() {
+	<-a.resume
+	defer func() {
+		if p := recover(); p != nil {
+			a.err = fmt.Errorf("panic: %v\n%s", p, debug.Stack())
+		}
+		a.done = true
+		a.sim.parked <- a
+	}()
+	a.err = a.fn(a)
+}
+
+// Name returns the actor's name
+func (a *Actor) Name() string {
+	return a.name
+}
+
+// Now returns the virtual time
+func (a *Actor) Now() time.Time {
+	return a.sim.clock.Now()
+}
+
+// Yield parks the actor and lets the scheduler run any actor, this one
+// included. Store operations yield on their own; call it to add a
+// scheduling point to code that doesn't touch the store.
+func (a *Actor) Yield() {
+	a.sim.parked <- a
+	<-a.resume
+}
+
+// Sleep parks the actor until the virtual clock has moved on by d
+func (a *Actor) Sleep(d time.Duration) {
+	a.wake = a.sim.clock.Now().Add(d)
+	a.Yield()
+	a.wake = time.Time{}
+	a.sim.record(a, Step{Op: OpSleep, Sleep: d})
+}
+
+// Explore runs scenario once per seed from cfg.Seed on, n seeds in all,
+// and stops at the first failure. The error names the failing seed, which
+// replays the same interleaving. scenario adds actors to the Sim, calls
+// Run and checks the outcome.
+func Explore(cfg Config, n int, scenario func(s *Sim) error) error {
+	first := cfg.Seed
+	for i := 0; i < n; i++ {
+		cfg.Seed = first + int64(i)
+		if err := scenario(New(cfg)); err != nil {
+			return fmt.Errorf("seed %d: %w", cfg.Seed, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/requests"
+	"tasks-service-demo/internal/services"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/naive"
+	"tasks-service-demo/internal/storage/shard"
+	"tasks-service-demo/internal/storage/xsync"
+)
+
+// exploreConfig reads SIM_SEED (first seed, default 1) and SIM_SEEDS (how
+// many, default 50), so a failing seed can be replayed alone
+func exploreConfig(t *testing.T) (Config, int) {
+	t.Helper()
+	cfg, n := Config{Seed: 1}, 50
+	if v := os.Getenv("SIM_SEED"); v != "" {
+		seed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			t.Fatalf("Invalid SIM_SEED %q", v)
+		}
+		cfg.Seed, n = seed, 1
+	}
+	if v := os.Getenv("SIM_SEEDS"); v != "" {
+		seeds, err := strconv.Atoi(v)
+		if err != nil || seeds < 1 {
+			t.Fatalf("Invalid SIM_SEEDS %q", v)
+		}
+		n = seeds
+	}
+	return cfg, n
+}
+
+var backends = map[string]func() storage.Store{
+	"memory": func() storage.Store { return naive.NewMemoryStore() },
+	"xsync":  func() storage.Store { return xsync.NewXSyncStore() },
+	"shard":  func() storage.Store { return shard.NewShardStore(4) },
+	"gopool": func() storage.Store { return shard.NewShardStoreGopool(4) },
+}
+
+// updateDelete races updates, deletes and reads of the same tasks through
+// the service, then checks every result and the final state against the model
+func updateDelete(backend storage.Store) func(s *Sim) error {
+	return func(s *Sim) error {
+		var initial []*entities.Task
+		for _, name := range []string{"a", "b", "c"} {
+			task := &entities.Task{Name: name}
+			backend.Create(task)
+			copied := *task
+			initial = append(initial, &copied)
+		}
+		ids := []int{initial[0].ID, initial[1].ID, initial[2].ID}
+		store := s.Store(backend)
+		svc := services.NewTaskServiceWithStore(store)
+
+		s.Go("updater", func(a *Actor) error {
+			for i, id := range ids {
+				_, err := svc.UpdateTask(id, &requests.UpdateTaskRequest{Name: fmt.Sprintf("u%d", i), Status: 1})
+				if err != nil && err.Code != apperrors.ErrCodeTaskNotFound {
+					return err
+				}
+			}
+			return nil
+		})
+		s.Go("deleter", func(a *Actor) error {
+			for i := len(ids) - 1; i >= 0; i-- {
+				if err := svc.DeleteTask(ids[i]); err != nil {
+					return err
+				}
+			}
+			created, err := svc.CreateTask(&requests.CreateTaskRequest{Name: "d"})
+			if err != nil {
+				return err
+			}
+			a.Sleep(time.Second)
+			if _, err := svc.UpdateTask(created.ID, &requests.UpdateTaskRequest{Name: "d2"}); err != nil {
+				return err
+			}
+			return nil
+		})
+		s.Go("reader", func(a *Actor) error {
+			store.GetByID(ids[0])
+			store.GetRange(ids[0], ids[2], 2)
+			a.Sleep(time.Second)
+			store.GetAll()
+			return nil
+		})
+
+		trace, err := s.Run()
+		if err != nil {
+			return err
+		}
+		model, err := Check(trace, initial...)
+		if err != nil {
+			return fmt.Errorf("%w\n%s", err, trace)
+		}
+		final := snapshots(backend.GetAll())
+		sort.Slice(final, func(i, j int) bool { return final[i].ID < final[j].ID })
+		if expected := model.Tasks(); fmt.Sprint(final) != fmt.Sprint(expected) {
+			return fmt.Errorf("store holds %v, model %v\n%s", final, expected, trace)
+		}
+		return nil
+	}
+}
+
+func TestSim_ConcurrentUpdateDelete(t *testing.T) {
+	cfg, n := exploreConfig(t)
+	for name, newStore := range backends {
+		t.Run(name, func(t *testing.T) {
+			err := Explore(cfg, n, func(s *Sim) error { return updateDelete(newStore())(s) })
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestSim_SameSeedSameTrace(t *testing.T) {
+	run := func(seed int64) string {
+		s := New(Config{Seed: seed})
+		if err := updateDelete(xsync.NewXSyncStore())(s); err != nil {
+			t.Fatal(err)
+		}
+		return s.trace.String()
+	}
+
+	first := run(7)
+	if second := run(7); second != first {
+		t.Errorf("Expected seed 7 to replay the same trace, got\n%s\nthen\n%s", first, second)
+	}
+	distinct := make(map[string]bool)
+	for seed := int64(1); seed <= 20; seed++ {
+		distinct[run(seed)] = true
+	}
+	if len(distinct) < 2 {
+		t.Error("Expected different seeds to interleave differently")
+	}
+}
+
+// lostUpdate has two actors append to a task's name with a read followed by
+// a write, which loses one of the appends in some interleavings
+func lostUpdate(s *Sim) error {
+	backend := naive.NewMemoryStore()
+	backend.Create(&entities.Task{Name: ""})
+	store := s.Store(backend)
+	for _, name := range []string{"x", "y"} {
+		name := name
+		s.Go(name, func(a *Actor) error {
+			task, err := store.GetByID(1)
+			if err != nil {
+				return err
+			}
+			if err := store.Update(1, &entities.Task{Name: task.Name + name}); err != nil {
+				return err
+			}
+			return nil
+		})
+	}
+	trace, err := s.Run()
+	if err != nil {
+		return err
+	}
+	if task, _ := backend.GetByID(1); len(task.Name) != 2 {
+		return fmt.Errorf("lost update: name is %q\n%s", task.Name, trace)
+	}
+	return nil
+}
+
+func TestSim_FindsAndReplaysLostUpdate(t *testing.T) {
+	err := Explore(Config{Seed: 1}, 100, lostUpdate)
+	if err == nil || !strings.Contains(err.Error(), "lost update") {
+		t.Fatalf("Expected a lost update within 100 seeds, got %v", err)
+	}
+
+	var seed int64
+	fmt.Sscanf(err.Error(), "seed %d:", &seed)
+	replayed := lostUpdate(New(Config{Seed: seed}))
+	if replayed == nil || fmt.Sprintf("seed %d: %v", seed, replayed) != err.Error() {
+		t.Errorf("Expected seed %d to replay %v, got %v", seed, err, replayed)
+	}
+}
+
+func TestSim_VirtualClock(t *testing.T) {
+	s := New(Config{})
+	var woke []string
+	s.Go("hour", func(a *Actor) error {
+		a.Sleep(time.Hour)
+		woke = append(woke, "hour")
+		a.Sleep(time.Hour)
+		if elapsed := a.Now().Sub(DefaultStart); elapsed != 2*time.Hour {
+			return fmt.Errorf("expected 2h of virtual time, got %s", elapsed)
+		}
+		return nil
+	})
+	s.Go("minutes", func(a *Actor) error {
+		a.Sleep(90 * time.Minute)
+		woke = append(woke, "minutes")
+		return nil
+	})
+
+	start := time.Now()
+	trace, err := s.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Expected virtual sleeps to take no real time, took %s", time.Since(start))
+	}
+	if strings.Join(woke, ",") != "hour,minutes" {
+		t.Errorf("Expected the actors to wake in virtual time order, got %v", woke)
+	}
+	if len(trace) != 3 || trace[2].At != 2*time.Hour || trace[2].Op != OpSleep {
+		t.Errorf("Unexpected trace\n%s", trace)
+	}
+	if !s.Clock().Now().Equal(DefaultStart.Add(2 * time.Hour)) {
+		t.Errorf("Expected the clock at start+2h, got %s", s.Clock().Now())
+	}
+}
+
+func TestSim_ReportsActorErrorsPanicsAndStalls(t *testing.T) {
+	s := New(Config{})
+	s.Go("failing", func(a *Actor) error { return errors.New("boom") })
+	s.Go("panicking", func(a *Actor) error { panic("oops") })
+	_, err := s.Run()
+	if err == nil || !strings.Contains(err.Error(), "failing: boom") || !strings.Contains(err.Error(), "panicking: panic: oops") {
+		t.Errorf("Expected both actors' failures, got %v", err)
+	}
+
+	block := make(chan struct{})
+	defer close(block)
+	s = New(Config{StallTimeout: 20 * time.Millisecond})
+	s.Go("blocked", func(a *Actor) error { <-block; return nil })
+	if _, err := s.Run(); err == nil || !strings.Contains(err.Error(), "actor blocked stalled") {
+		t.Errorf("Expected a stall, got %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package sim
+
+import (
+	"sort"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage"
+)
+
+// Store wraps inner so that each of its operations is a step of the
+// calling actor: the actor parks until the scheduler picks it, runs the
+// operation alone and records it in the trace. Wrap a backend under the
+// decorators to interleave their inner calls too. Only actors may call it;
+// background goroutines of the stores under test must not.
+func (s *Sim) Store(inner storage.Store) storage.Store {
+	return &store{sim: s, inner: inner}
+}
+
+type store struct {
+	sim   *Sim
+	inner storage.Store
+}
+
+// Inner returns the wrapped store
+func (st *store) Inner() storage.Store {
+	return st.inner
+}
+
+func (st *store) Create(task *entities.Task) *apperrors.AppError {
+	a := st.sim.step()
+	err := st.inner.Create(task)
+	step := Step{Op: OpCreate, Task: snapshot(task), Err: code(err)}
+	if task != nil {
+		step.ID = task.ID
+	}
+	st.sim.record(a, step)
+	return err
+}
+
+func (st *store) GetByID(id int) (*entities.Task, *apperrors.AppError) {
+	a := st.sim.step()
+	task, err := st.inner.GetByID(id)
+	st.sim.record(a, Step{Op: OpGet, ID: id, Task: snapshot(task), Err: code(err)})
+	return task, err
+}
+
+func (st *store) GetAll() []*entities.Task {
+	a := st.sim.step()
+	tasks := st.inner.GetAll()
+	read := snapshots(tasks)
+	sort.Slice(read, func(i, j int) bool { return read[i].ID < read[j].ID }) // Map order would make traces differ
+	st.sim.record(a, Step{Op: OpList, Tasks: read})
+	return tasks
+}
+
+func (st *store) GetRange(fromID, toID, limit int) []*entities.Task {
+	a := st.sim.step()
+	tasks := st.inner.GetRange(fromID, toID, limit)
+	st.sim.record(a, Step{Op: OpRange, From: fromID, To: toID, Limit: limit, Tasks: snapshots(tasks)})
+	return tasks
+}
+
+func (st *store) Update(id int, task *entities.Task) *apperrors.AppError {
+	a := st.sim.step()
+	err := st.inner.Update(id, task)
+	st.sim.record(a, Step{Op: OpUpdate, ID: id, Task: snapshot(task), Err: code(err)})
+	return err
+}
+
+func (st *store) Delete(id int) *apperrors.AppError {
+	a := st.sim.step()
+	err := st.inner.Delete(id)
+	st.sim.record(a, Step{Op: OpDelete, ID: id, Err: code(err)})
+	return err
+}
+
+// Do runs the operation described by step on store and returns step with
+// its result filled in. It doesn't yield, so any goroutine may call it;
+// harnesses that drive stores directly use it to record the same steps.
+func Do(store storage.Store, step Step) Step {
+	switch step.Op {
+	case OpCreate:
+		task := snapshot(step.Task)
+		step.Err = code(store.Create(task))
+		step.Task = snapshot(task)
+		if task != nil {
+			step.ID = task.ID
+		}
+	case OpGet:
+		task, err := store.GetByID(step.ID)
+		step.Task, step.Err = snapshot(task), code(err)
+	case OpList:
+		step.Tasks = snapshots(store.GetAll())
+		sort.Slice(step.Tasks, func(i, j int) bool { return step.Tasks[i].ID < step.Tasks[j].ID })
+	case OpRange:
+		step.Tasks = snapshots(store.GetRange(step.From, step.To, step.Limit))
+	case OpUpdate:
+		task := snapshot(step.Task)
+		step.Err = code(store.Update(step.ID, task))
+		step.Task = snapshot(task)
+	case OpDelete:
+		step.Err = code(store.Delete(step.ID))
+	}
+	return step
+}
+
+// snapshot copies task so later writes through the pointer don't change the trace
+func snapshot(task *entities.Task) *entities.Task {
+	if task == nil {
+		return nil
+	}
+	copied := *task
+	return &copied
+}
+
+// snapshots copies tasks, keeping their order
+func snapshots(tasks []*entities.Task) []entities.Task {
+	out := make([]entities.Task, 0, len(tasks))
+	for _, task := range tasks {
+		out = append(out, *task)
+	}
+	return out
+}
+
+func code(err *apperrors.AppError) int {
+	if err == nil {
+		return 0
+	}
+	return err.Code
+}
@@ -0,0 +1,197 @@
+package sim
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+)
+
+// Operations recorded in a Trace
+const (
+	OpCreate = "create"
+	OpGet    = "get"
+	OpList   = "list"
+	OpRange  = "range"
+	OpUpdate = "update"
+	OpDelete = "delete"
+	OpSleep  = "sleep"
+)
+
+// Step is one operation of an actor, with its arguments and result
+type Step struct {
+	Seq             int
+	Actor           string
+	At              time.Duration   // Virtual time since the start
+	Op              string          // One of the Op constants
+	ID              int             // Argument of get, update and delete; the ID create assigned
+	Task            *entities.Task  // Copy of the task create or update wrote, or get read
+	Tasks           []entities.Task // What list (sorted by ID) and range read
+	From, To, Limit int             // Arguments of range
+	Sleep           time.Duration   // Argument of sleep
+	Err             int             // AppError code, 0 on success
+}
+
+func (s Step) String() string {
+	return fmt.Sprintf("%4d %8s %s: %s", s.Seq, s.At, s.Actor, s.Summary())
+}
+
+// Summary describes the operation and its result, without who ran it or when
+func (s Step) Summary() string {
+	var call string
+	switch s.Op {
+	case OpCreate:
+		call = fmt.Sprintf("create %v", s.Task)
+	case OpGet:
+		call = fmt.Sprintf("get %d", s.ID)
+	case OpList:
+		call = "list"
+	case OpRange:
+		call = fmt.Sprintf("range %d..%d limit %d", s.From, s.To, s.Limit)
+	case OpUpdate:
+		call = fmt.Sprintf("update %d %v", s.ID, s.Task)
+	case OpDelete:
+		call = fmt.Sprintf("delete %d", s.ID)
+	case OpSleep:
+		return fmt.Sprintf("sleep %s", s.Sleep)
+	}
+
+	result := "ok"
+	switch {
+	case s.Err != 0:
+		result = fmt.Sprintf("error %d", s.Err)
+	case s.Op == OpGet:
+		result = fmt.Sprintf("%v", s.Task)
+	case s.Op == OpList || s.Op == OpRange:
+		result = fmt.Sprintf("%v", s.Tasks)
+	}
+	return call + " -> " + result
+}
+
+// Trace is the steps of a run in the order they took effect
+type Trace []Step
+
+// String lists the steps one per line
+func (t Trace) String() string {
+	var b strings.Builder
+	for _, step := range t {
+		b.WriteString(step.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Model is the sequential specification of a task store: given the
+// operations before it, what each operation must return
+type Model struct {
+	tasks map[int]entities.Task
+}
+
+// NewModel creates a Model holding the seed tasks
+func NewModel(seed ...*entities.Task) *Model {
+	m := &Model{tasks: make(map[int]entities.Task, len(seed))}
+	for _, task := range seed {
+		m.tasks[task.ID] = *task
+	}
+	return m
+}
+
+// Clone returns a copy of the model
+func (m *Model) Clone() *Model {
+	c := &Model{tasks: make(map[int]entities.Task, len(m.tasks))}
+	for id, task := range m.tasks {
+		c.tasks[id] = task
+	}
+	return c
+}
+
+// Tasks returns the tasks in ID order
+func (m *Model) Tasks() []entities.Task {
+	tasks := make([]entities.Task, 0, len(m.tasks))
+	for _, task := range m.tasks {
+		tasks = append(tasks, task)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks
+}
+
+// Apply checks that step returned what the model expects and applies it.
+// Stores pick the IDs of new tasks, so any ID not in use is accepted.
+func (m *Model) Apply(step Step) error {
+	task, exists := m.tasks[step.ID]
+	switch step.Op {
+	case OpCreate:
+		if step.Err != 0 || step.ID <= 0 || exists {
+			return fmt.Errorf("create assigned ID %d with error %d, which is in use or invalid", step.ID, step.Err)
+		}
+		m.tasks[step.ID] = *step.Task
+	case OpGet:
+		if !exists {
+			return m.expectNotFound(step)
+		}
+		if step.Err != 0 || step.Task == nil || !step.Task.Equal(&task) {
+			return fmt.Errorf("get %d returned %v with error %d, expected %v", step.ID, step.Task, step.Err, task)
+		}
+	case OpList:
+		return expectTasks(step, m.Tasks())
+	case OpRange:
+		var inRange []entities.Task
+		for _, t := range m.Tasks() {
+			if t.ID >= step.From && t.ID <= step.To && (step.Limit <= 0 || len(inRange) < step.Limit) {
+				inRange = append(inRange, t)
+			}
+		}
+		return expectTasks(step, inRange)
+	case OpUpdate:
+		if !exists {
+			return m.expectNotFound(step)
+		}
+		if step.Err != 0 {
+			return fmt.Errorf("update %d failed with error %d", step.ID, step.Err)
+		}
+		m.tasks[step.ID] = *step.Task
+	case OpDelete:
+		if !exists {
+			return m.expectNotFound(step)
+		}
+		if step.Err != 0 {
+			return fmt.Errorf("delete %d failed with error %d", step.ID, step.Err)
+		}
+		delete(m.tasks, step.ID)
+	}
+	return nil
+}
+
+func (m *Model) expectNotFound(step Step) error {
+	if step.Err != apperrors.ErrCodeTaskNotFound {
+		return fmt.Errorf("%s %d of a missing task returned error %d, expected %d", step.Op, step.ID, step.Err, apperrors.ErrCodeTaskNotFound)
+	}
+	return nil
+}
+
+func expectTasks(step Step, expected []entities.Task) error {
+	if len(step.Tasks) != len(expected) {
+		return fmt.Errorf("%s returned %d tasks, expected %d", step.Op, len(step.Tasks), len(expected))
+	}
+	for i := range expected {
+		if !step.Tasks[i].Equal(&expected[i]) {
+			return fmt.Errorf("%s returned %v, expected %v", step.Op, step.Tasks[i], expected[i])
+		}
+	}
+	return nil
+}
+
+// Check replays trace on a Model holding the initial tasks and returns it.
+// The error names the first step whose result the model disagrees with.
+func Check(trace Trace, initial ...*entities.Task) (*Model, error) {
+	m := NewModel(initial...)
+	for _, step := range trace {
+		if err := m.Apply(step); err != nil {
+			return m, fmt.Errorf("step %d (%s): %w", step.Seq, step.Actor, err)
+		}
+	}
+	return m, nil
+}
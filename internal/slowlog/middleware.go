@@ -0,0 +1,15 @@
+package slowlog
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// Middleware passes the method and path of each request to the store
+// through the request context, so slow calls name the request that made
+// them. Concatenating copies the path out of the reused request buffer.
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.SetUserContext(WithRoute(c.UserContext(), c.Method()+" "+c.Path()))
+		return c.Next()
+	}
+}
@@ -0,0 +1,153 @@
+package slowlog
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tasks-service-demo/internal/logger"
+)
+
+// Package slowlog records store calls that take longer than a threshold,
+// like Redis's SLOWLOG: each one is logged and the latest are kept in a
+// fixed-size ring for the admin API.
+
+const (
+	DefaultThreshold = 10 * time.Millisecond // Calls at least this slow are recorded
+	DefaultSize      = 128                   // Entries kept in the ring
+)
+
+// Config configures a Log
+type Config struct {
+	Threshold time.Duration // 0 disables the log
+	Size      int           // Ring capacity; DefaultSize when not positive
+}
+
+// Entry is one slow store call
+type Entry struct {
+	ID             int64     `json:"id"` // Increases by one per recorded call, also across resets
+	Time           time.Time `json:"time"`
+	Op             string    `json:"op"`
+	TaskID         int       `json:"taskId,omitempty"`
+	Args           string    `json:"args,omitempty"`  // Arguments of ops without a task ID
+	Shard          *int      `json:"shard,omitempty"` // Shard of the task ID on sharded backends
+	DurationMicros int64     `json:"durationMicros"`
+	Route          string    `json:"route,omitempty"` // Method and path of the request; empty for background work
+	Error          string    `json:"error,omitempty"`
+}
+
+// Log keeps the latest slow calls
+type Log struct {
+	cfg Config
+
+	mu      sync.Mutex
+	ring    []Entry
+	next    int   // Ring slot of the next entry
+	seq     int64 // ID of the last entry
+	dropped int64 // Entries overwritten or reset away
+}
+
+// New creates an empty Log
+func New(cfg Config) *Log {
+	if cfg.Size <= 0 {
+		cfg.Size = DefaultSize
+	}
+	return &Log{cfg: cfg, ring: make([]Entry, 0, cfg.Size)}
+}
+
+// Enabled reports whether calls are recorded
+func (l *Log) Enabled() bool {
+	return l.cfg.Threshold > 0
+}
+
+// Config returns the configuration of the log
+func (l *Log) Config() Config {
+	return l.cfg
+}
+
+// Observe records e, with its duration set to d, when d reaches the
+// threshold, and reports whether it did
+func (l *Log) Observe(e Entry, d time.Duration) bool {
+	if !l.Enabled() || d < l.cfg.Threshold {
+		return false
+	}
+	e.DurationMicros = d.Microseconds()
+
+	l.mu.Lock()
+	l.seq++
+	e.ID = l.seq
+	if len(l.ring) < cap(l.ring) {
+		l.ring = append(l.ring, e)
+	} else {
+		l.ring[l.next] = e
+		l.dropped++
+	}
+	l.next = (l.next + 1) % cap(l.ring)
+	l.mu.Unlock()
+
+	logger.For(logger.ModuleStorage).Warnw("Slow storage operation",
+		"op", e.Op,
+		"taskId", e.TaskID,
+		"args", e.Args,
+		"shard", e.Shard,
+		"duration", d,
+		"route", e.Route,
+		"error", e.Error,
+	)
+	return true
+}
+
+// Entries returns up to limit entries, newest first; all when limit is not
+// positive
+func (l *Log) Entries(limit int) []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	n := len(l.ring)
+	if limit > 0 && limit < n {
+		n = limit
+	}
+	entries := make([]Entry, 0, n)
+	for i := 1; i <= n; i++ {
+		entries = append(entries, l.ring[(l.next-i+cap(l.ring))%cap(l.ring)])
+	}
+	return entries
+}
+
+// Stats returns how many entries are held and how many were dropped to
+// make room or by Reset
+func (l *Log) Stats() (held int, dropped int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.ring), l.dropped
+}
+
+// Reset empties the ring
+func (l *Log) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.dropped += int64(len(l.ring))
+	l.ring = l.ring[:0]
+	l.next = 0
+}
+
+var current atomic.Pointer[Log]
+
+func init() {
+	Reset()
+}
+
+// Get returns the process-wide Log
+func Get() *Log {
+	return current.Load()
+}
+
+// Init replaces the process-wide Log
+func Init(l *Log) {
+	current.Store(l)
+}
+
+// Reset installs a disabled Log
+func Reset() {
+	current.Store(New(Config{}))
+}
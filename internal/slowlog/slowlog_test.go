@@ -0,0 +1,93 @@
+package slowlog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/storetest"
+)
+
+func TestLog_Ring(t *testing.T) {
+	log := New(Config{Threshold: time.Millisecond, Size: 3})
+	if log.Observe(Entry{Op: OpGet, TaskID: 1}, time.Microsecond) {
+		t.Error("Expected calls under the threshold to be ignored")
+	}
+	for id := 1; id <= 5; id++ {
+		log.Observe(Entry{Op: OpGet, TaskID: id}, time.Duration(id)*time.Millisecond)
+	}
+
+	entries := log.Entries(0)
+	if len(entries) != 3 || entries[0].TaskID != 5 || entries[2].TaskID != 3 {
+		t.Fatalf("Expected the 3 newest entries, newest first, got %+v", entries)
+	}
+	if entries[0].ID != 5 || entries[0].DurationMicros != 5000 {
+		t.Errorf("Expected sequence ID and duration, got %+v", entries[0])
+	}
+	if limited := log.Entries(1); len(limited) != 1 || limited[0].TaskID != 5 {
+		t.Errorf("Expected the newest entry, got %+v", limited)
+	}
+
+	log.Reset()
+	if held, dropped := log.Stats(); held != 0 || dropped != 5 {
+		t.Errorf("Expected an empty ring with 5 dropped, got %d, %d", held, dropped)
+	}
+	log.Observe(Entry{Op: OpDelete}, time.Second)
+	if entries := log.Entries(0); len(entries) != 1 || entries[0].ID != 6 {
+		t.Errorf("Expected IDs to continue after a reset, got %+v", entries)
+	}
+}
+
+type fakeShards struct {
+	storage.Store
+}
+
+func (fakeShards) ShardOf(id int) int { return id % 4 }
+
+func TestStore_RecordsSlowCalls(t *testing.T) {
+	log := New(Config{Threshold: time.Millisecond})
+	mock := storetest.NewMockStore()
+	mock.GetByIDFunc = func(id int) (*entities.Task, *apperrors.AppError) {
+		if id == 7 {
+			time.Sleep(2 * time.Millisecond)
+		}
+		return nil, apperrors.ErrTaskNotFound
+	}
+	mock.DeleteFunc = func(id int) *apperrors.AppError {
+		time.Sleep(2 * time.Millisecond)
+		return apperrors.ErrStorageCorrupt
+	}
+	store := NewStore(fakeShards{mock}, log)
+
+	ctx := WithRoute(context.Background(), "GET /tasks/7")
+	store.GetByIDContext(ctx, 1)
+	store.GetByIDContext(ctx, 7)
+	store.Delete(6)
+
+	entries := log.Entries(0)
+	if len(entries) != 2 {
+		t.Fatalf("Expected only the 2 slow calls, got %+v", entries)
+	}
+	del, get := entries[0], entries[1]
+	if get.Op != OpGet || get.TaskID != 7 || get.Route != "GET /tasks/7" || get.Error != "" {
+		t.Errorf("Expected a slow get without an error, got %+v", get)
+	}
+	if get.Shard == nil || *get.Shard != 3 {
+		t.Errorf("Expected shard 3, got %v", get.Shard)
+	}
+	if del.Op != OpDelete || del.Route != "" || del.Error == "" || del.DurationMicros < 2000 {
+		t.Errorf("Expected a slow background delete with its error, got %+v", del)
+	}
+}
+
+func TestStore_Disabled(t *testing.T) {
+	log := New(Config{})
+	store := NewStore(storetest.NewFakeStore(), log)
+	store.Create(&entities.Task{Name: "a"})
+	if entries := log.Entries(0); len(entries) != 0 {
+		t.Errorf("Expected a disabled log to record nothing, got %+v", entries)
+	}
+}
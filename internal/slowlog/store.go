@@ -0,0 +1,193 @@
+package slowlog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage"
+)
+
+// Store operations as named in entries
+const (
+	OpCreate  = "create"
+	OpGet     = "get"
+	OpGetMany = "getMany"
+	OpList    = "list"
+	OpRange   = "range"
+	OpUpdate  = "update"
+	OpDelete  = "delete"
+)
+
+type routeKey struct{}
+
+// WithRoute returns ctx carrying the route of the request that makes the
+// store calls
+func WithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeKey{}, route)
+}
+
+// RouteFrom returns the route carried by ctx, empty if none
+func RouteFrom(ctx context.Context) string {
+	route, _ := ctx.Value(routeKey{}).(string)
+	return route
+}
+
+// Store times every call to the store it wraps and records the slow ones
+// in a Log. It should be the outermost decorator, so the recorded duration
+// is the one the caller sees.
+type Store struct {
+	inner   storage.Store
+	log     *Log
+	shards  storage.ShardLocator // Nil unless the backend is sharded
+	context storage.ContextStore
+}
+
+// NewStore wraps inner, recording its slow calls in log
+func NewStore(inner storage.Store, log *Log) *Store {
+	shards, _ := storage.FindShardLocator(inner)
+	return &Store{inner: inner, log: log, shards: shards, context: storage.WithContext(inner)}
+}
+
+// Inner returns the wrapped store
+func (s *Store) Inner() storage.Store {
+	return s.inner
+}
+
+// slow returns how long ago start was and whether that reaches the threshold
+func (s *Store) slow(start time.Time) (time.Duration, bool) {
+	d := time.Since(start)
+	return d, s.log.Enabled() && d >= s.log.Config().Threshold
+}
+
+// record completes e, a call that started at start and took d, and adds
+// it to the log
+func (s *Store) record(ctx context.Context, e Entry, start time.Time, d time.Duration, err *apperrors.AppError) {
+	e.Time, e.Route = start, RouteFrom(ctx)
+	if s.shards != nil && e.TaskID > 0 {
+		shard := s.shards.ShardOf(e.TaskID)
+		e.Shard = &shard
+	}
+	if err != nil && err.Code != apperrors.ErrCodeTaskNotFound {
+		e.Error = err.Error()
+	}
+	s.log.Observe(e, d)
+}
+
+// CreateContext creates the task, recording the ID it was given
+func (s *Store) CreateContext(ctx context.Context, task *entities.Task) *apperrors.AppError {
+	start := time.Now()
+	err := s.context.CreateContext(ctx, task)
+	if d, slow := s.slow(start); slow {
+		s.record(ctx, Entry{Op: OpCreate, TaskID: task.ID}, start, d, err)
+	}
+	return err
+}
+
+// GetByIDContext retrieves a task by its ID
+func (s *Store) GetByIDContext(ctx context.Context, id int) (*entities.Task, *apperrors.AppError) {
+	start := time.Now()
+	task, err := s.context.GetByIDContext(ctx, id)
+	if d, slow := s.slow(start); slow {
+		s.record(ctx, Entry{Op: OpGet, TaskID: id}, start, d, err)
+	}
+	return task, err
+}
+
+// GetAllContext retrieves all tasks
+func (s *Store) GetAllContext(ctx context.Context) ([]*entities.Task, *apperrors.AppError) {
+	start := time.Now()
+	tasks, err := s.context.GetAllContext(ctx)
+	if d, slow := s.slow(start); slow {
+		s.record(ctx, Entry{Op: OpList, Args: fmt.Sprintf("tasks=%d", len(tasks))}, start, d, err)
+	}
+	return tasks, err
+}
+
+// GetRangeContext retrieves tasks with fromID <= ID <= toID
+func (s *Store) GetRangeContext(ctx context.Context, fromID, toID, limit int) ([]*entities.Task, *apperrors.AppError) {
+	start := time.Now()
+	tasks, err := s.context.GetRangeContext(ctx, fromID, toID, limit)
+	if d, slow := s.slow(start); slow {
+		s.record(ctx, Entry{Op: OpRange, Args: fmt.Sprintf("from=%d to=%d limit=%d", fromID, toID, limit)}, start, d, err)
+	}
+	return tasks, err
+}
+
+// UpdateContext updates an existing task
+func (s *Store) UpdateContext(ctx context.Context, id int, task *entities.Task) *apperrors.AppError {
+	start := time.Now()
+	err := s.context.UpdateContext(ctx, id, task)
+	if d, slow := s.slow(start); slow {
+		s.record(ctx, Entry{Op: OpUpdate, TaskID: id}, start, d, err)
+	}
+	return err
+}
+
+// DeleteContext deletes a task by ID
+func (s *Store) DeleteContext(ctx context.Context, id int) *apperrors.AppError {
+	start := time.Now()
+	err := s.context.DeleteContext(ctx, id)
+	if d, slow := s.slow(start); slow {
+		s.record(ctx, Entry{Op: OpDelete, TaskID: id}, start, d, err)
+	}
+	return err
+}
+
+// Create creates a task outside of a request
+func (s *Store) Create(task *entities.Task) *apperrors.AppError {
+	return s.CreateContext(context.Background(), task)
+}
+
+// GetByID retrieves a task by its ID outside of a request
+func (s *Store) GetByID(id int) (*entities.Task, *apperrors.AppError) {
+	return s.GetByIDContext(context.Background(), id)
+}
+
+// GetAll retrieves all tasks outside of a request
+func (s *Store) GetAll() []*entities.Task {
+	tasks, _ := s.GetAllContext(context.Background())
+	return tasks
+}
+
+// GetRange retrieves a range of tasks outside of a request
+func (s *Store) GetRange(fromID, toID, limit int) []*entities.Task {
+	tasks, _ := s.GetRangeContext(context.Background(), fromID, toID, limit)
+	return tasks
+}
+
+// Update updates a task outside of a request
+func (s *Store) Update(id int, task *entities.Task) *apperrors.AppError {
+	return s.UpdateContext(context.Background(), id, task)
+}
+
+// Delete deletes a task outside of a request
+func (s *Store) Delete(id int) *apperrors.AppError {
+	return s.DeleteContext(context.Background(), id)
+}
+
+// GetByIDStrong reads id past any cache in the wrapped chain
+func (s *Store) GetByIDStrong(id int) (*entities.Task, *apperrors.AppError) {
+	start := time.Now()
+	task, err := storage.GetByIDStrong(s.inner, id)
+	if d, slow := s.slow(start); slow {
+		s.record(context.Background(), Entry{Op: OpGet, TaskID: id, Args: "strong"}, start, d, err)
+	}
+	return task, err
+}
+
+// GetMany looks up a batch of IDs with the wrapped chain
+func (s *Store) GetMany(ids []int) ([]*entities.Task, []int, *apperrors.AppError) {
+	start := time.Now()
+	found, missing, err := storage.GetMany(s.inner, ids)
+	if d, slow := s.slow(start); slow {
+		s.record(context.Background(), Entry{Op: OpGetMany, Args: fmt.Sprintf("ids=%d", len(ids))}, start, d, err)
+	}
+	return found, missing, err
+}
+
+// DeletedWhere lets storage.FindBulkDeleter pass the store. It keeps
+// nothing about tasks, so bulk deletes pass through untimed.
+func (s *Store) DeletedWhere(ids []int) {}
@@ -0,0 +1,57 @@
+package stats
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	apperrors "tasks-service-demo/internal/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// hotKeyRoute is the route whose :id parameter is counted as a hot key
+const hotKeyRoute = "/tasks/:id"
+
+// Middleware records every request into the process-wide Recorder. Requests
+// under any of the skip prefixes (such as the dashboard polling itself) are
+// not recorded.
+func Middleware(skip ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		path := c.Path()
+		for _, prefix := range skip {
+			if strings.HasPrefix(path, prefix) {
+				return c.Next()
+			}
+		}
+
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = errorStatus(err)
+		}
+
+		var key string
+		if c.Route().Path == hotKeyRoute {
+			key = c.Params("id")
+		}
+		Get().Record(start, time.Since(start), status >= fiber.StatusInternalServerError, key)
+		return err
+	}
+}
+
+// errorStatus is the status the app's ErrorHandler will send for err
+func errorStatus(err error) int {
+	var appErr *apperrors.AppError
+	var fiberErr *fiber.Error
+	switch {
+	case errors.As(err, &appErr):
+		return apperrors.HTTPStatus(appErr.Code)
+	case errors.As(err, &fiberErr):
+		return fiberErr.Code
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
@@ -0,0 +1,234 @@
+package stats
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Package stats keeps a rolling in-process view of request rate, latency
+// and hot keys for the admin dashboard. It is deliberately coarse: one-second
+// buckets over the last minute and a fixed latency histogram.
+
+// Window is how far back the recorder remembers
+const Window = 60 * time.Second
+
+// HotKeyLimit bounds the number of distinct keys counted per window, so a
+// scan over many IDs cannot grow memory without bound
+const HotKeyLimit = 10000
+
+// latencyBounds are the histogram bucket upper bounds; the last bucket is open
+var latencyBounds = []time.Duration{
+	50 * time.Microsecond, 100 * time.Microsecond, 250 * time.Microsecond, 500 * time.Microsecond,
+	time.Millisecond, 2500 * time.Microsecond, 5 * time.Millisecond, 10 * time.Millisecond,
+	25 * time.Millisecond, 50 * time.Millisecond, 100 * time.Millisecond, 250 * time.Millisecond,
+	500 * time.Millisecond, time.Second,
+}
+
+type bucket struct {
+	second   int64 // Unix second the bucket holds; stale buckets are reset on reuse
+	requests int64
+	errors   int64
+	latency  time.Duration
+	hist     [15]int64 // len(latencyBounds)+1
+}
+
+// Recorder aggregates request samples
+type Recorder struct {
+	mu      sync.Mutex
+	started time.Time
+	total   int64
+	buckets [60]bucket
+
+	hotStart time.Time // Start of the current hot-key window
+	hot      map[string]int64
+	prevHot  map[string]int64
+}
+
+// NewRecorder creates an empty Recorder
+func NewRecorder() *Recorder {
+	now := time.Now()
+	return &Recorder{started: now, hotStart: now, hot: make(map[string]int64)}
+}
+
+// Record adds one request. key identifies the accessed entity for hot-key
+// tracking and may be empty.
+func (r *Recorder) Record(now time.Time, latency time.Duration, failed bool, key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.total++
+	b := r.bucketLocked(now.Unix())
+	b.requests++
+	if failed {
+		b.errors++
+	}
+	b.latency += latency
+	b.hist[histIndex(latency)]++
+
+	if key == "" {
+		return
+	}
+	if now.Sub(r.hotStart) >= Window {
+		r.prevHot, r.hot = r.hot, make(map[string]int64)
+		r.hotStart = now
+	}
+	if _, ok := r.hot[key]; ok || len(r.hot) < HotKeyLimit {
+		r.hot[key]++
+	}
+}
+
+func (r *Recorder) bucketLocked(second int64) *bucket {
+	b := &r.buckets[second%int64(len(r.buckets))]
+	if b.second != second {
+		*b = bucket{second: second}
+	}
+	return b
+}
+
+func histIndex(latency time.Duration) int {
+	for i, bound := range latencyBounds {
+		if latency <= bound {
+			return i
+		}
+	}
+	return len(latencyBounds)
+}
+
+// Point is one second of traffic
+type Point struct {
+	Time         int64   `json:"t"` // Unix seconds
+	Requests     int64   `json:"requests"`
+	Errors       int64   `json:"errors"`
+	AvgLatencyMs float64 `json:"avgLatencyMs"`
+}
+
+// Latency holds percentile estimates in milliseconds, taken from histogram
+// bucket upper bounds
+type Latency struct {
+	P50 float64 `json:"p50"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+}
+
+// KeyCount is a hot key and its hits in the last one to two windows
+type KeyCount struct {
+	Key  string `json:"key"`
+	Hits int64  `json:"hits"`
+}
+
+// Snapshot is the dashboard view of the recorder
+type Snapshot struct {
+	UptimeSeconds int64      `json:"uptimeSeconds"`
+	Requests      int64      `json:"requests"`
+	RPS           float64    `json:"rps"` // Average over the last 10 complete seconds
+	Latency       Latency    `json:"latencyMs"`
+	Series        []Point    `json:"series"`
+	HotKeys       []KeyCount `json:"hotKeys"`
+}
+
+// Snapshot summarizes the last Window and returns the top hot keys
+func (r *Recorder) Snapshot(now time.Time, topKeys int) Snapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snap := Snapshot{
+		UptimeSeconds: int64(now.Sub(r.started).Seconds()),
+		Requests:      r.total,
+		Series:        make([]Point, 0, len(r.buckets)),
+	}
+
+	var hist [15]int64
+	var recent int64
+	current := now.Unix()
+	for second := current - int64(len(r.buckets)) + 1; second <= current; second++ {
+		b := r.buckets[second%int64(len(r.buckets))]
+		p := Point{Time: second}
+		if b.second == second {
+			p.Requests, p.Errors = b.requests, b.errors
+			if b.requests > 0 {
+				p.AvgLatencyMs = float64(b.latency) / float64(b.requests) / float64(time.Millisecond)
+			}
+			for i, n := range b.hist {
+				hist[i] += n
+			}
+			if second < current && second >= current-10 {
+				recent += b.requests
+			}
+		}
+		snap.Series = append(snap.Series, p)
+	}
+	snap.RPS = float64(recent) / 10
+	snap.Latency = Latency{
+		P50: percentile(hist, 0.50),
+		P95: percentile(hist, 0.95),
+		P99: percentile(hist, 0.99),
+	}
+
+	counts := make(map[string]int64, len(r.hot)+len(r.prevHot))
+	for k, n := range r.prevHot {
+		counts[k] += n
+	}
+	for k, n := range r.hot {
+		counts[k] += n
+	}
+	snap.HotKeys = make([]KeyCount, 0, len(counts))
+	for k, n := range counts {
+		snap.HotKeys = append(snap.HotKeys, KeyCount{Key: k, Hits: n})
+	}
+	sort.Slice(snap.HotKeys, func(i, j int) bool {
+		if snap.HotKeys[i].Hits != snap.HotKeys[j].Hits {
+			return snap.HotKeys[i].Hits > snap.HotKeys[j].Hits
+		}
+		return snap.HotKeys[i].Key < snap.HotKeys[j].Key
+	})
+	if len(snap.HotKeys) > topKeys {
+		snap.HotKeys = snap.HotKeys[:topKeys]
+	}
+	return snap
+}
+
+// percentile returns the upper bound, in ms, of the bucket holding quantile q
+func percentile(hist [15]int64, q float64) float64 {
+	var total int64
+	for _, n := range hist {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+
+	target := int64(q*float64(total) + 0.5)
+	if target < 1 {
+		target = 1
+	}
+	var cumulative int64
+	for i, n := range hist {
+		cumulative += n
+		if cumulative >= target {
+			if i == len(latencyBounds) {
+				// Open-ended bucket: report its lower bound
+				i--
+			}
+			return float64(latencyBounds[i]) / float64(time.Millisecond)
+		}
+	}
+	return 0
+}
+
+var current atomic.Pointer[Recorder]
+
+func init() {
+	Reset()
+}
+
+// Get returns the process-wide Recorder
+func Get() *Recorder {
+	return current.Load()
+}
+
+// Reset installs an empty Recorder
+func Reset() {
+	current.Store(NewRecorder())
+}
@@ -0,0 +1,110 @@
+package stats
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRecorder_Snapshot(t *testing.T) {
+	r := NewRecorder()
+	now := time.Unix(1700000000, 0)
+
+	// 20 requests per second over the 10 seconds before now, one failing each second
+	for s := 1; s <= 10; s++ {
+		at := now.Add(-time.Duration(s) * time.Second)
+		for i := 0; i < 20; i++ {
+			r.Record(at, 3*time.Millisecond, i == 0, "")
+		}
+	}
+	// The current second is incomplete and does not count towards RPS
+	r.Record(now, time.Second*2, false, "")
+
+	snap := r.Snapshot(now, 10)
+	if snap.Requests != 201 {
+		t.Errorf("Expected 201 requests, got %d", snap.Requests)
+	}
+	if snap.RPS != 20 {
+		t.Errorf("Expected 20 RPS, got %v", snap.RPS)
+	}
+	if len(snap.Series) != 60 {
+		t.Fatalf("Expected 60 points, got %d", len(snap.Series))
+	}
+	last := snap.Series[len(snap.Series)-2]
+	if last.Time != now.Unix()-1 || last.Requests != 20 || last.Errors != 1 || last.AvgLatencyMs != 3 {
+		t.Errorf("Unexpected point %+v", last)
+	}
+	if snap.Latency.P50 != 5 || snap.Latency.P99 != 5 {
+		t.Errorf("Expected p50 and p99 in the 5ms bucket, got %+v", snap.Latency)
+	}
+}
+
+func TestRecorder_ExpiresOldBuckets(t *testing.T) {
+	r := NewRecorder()
+	now := time.Unix(1700000000, 0)
+	r.Record(now.Add(-2*Window), time.Millisecond, false, "")
+
+	snap := r.Snapshot(now, 10)
+	for _, p := range snap.Series {
+		if p.Requests != 0 {
+			t.Fatalf("Expected stale bucket to be ignored, got %+v", p)
+		}
+	}
+}
+
+func TestRecorder_HotKeys(t *testing.T) {
+	r := NewRecorder()
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		r.Record(now, time.Millisecond, false, "1")
+	}
+	r.Record(now, time.Millisecond, false, "2")
+	r.Record(now, time.Millisecond, false, "3")
+
+	snap := r.Snapshot(now, 2)
+	if len(snap.HotKeys) != 2 || snap.HotKeys[0] != (KeyCount{"1", 3}) || snap.HotKeys[1] != (KeyCount{"2", 1}) {
+		t.Errorf("Expected [1:3 2:1], got %+v", snap.HotKeys)
+	}
+
+	// Keys from the previous window still count; older ones are dropped
+	r.Record(now.Add(Window), time.Millisecond, false, "2")
+	r.Record(now.Add(2*Window), time.Millisecond, false, "2")
+	snap = r.Snapshot(now.Add(2*Window), 10)
+	if len(snap.HotKeys) != 1 || snap.HotKeys[0] != (KeyCount{"2", 2}) {
+		t.Errorf("Expected [2:2], got %+v", snap.HotKeys)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	app := fiber.New()
+	app.Use(Middleware("/admin/dashboard"))
+	app.Get("/tasks/:id", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	app.Get("/fail", func(c *fiber.Ctx) error { return fiber.ErrServiceUnavailable })
+	app.Get("/admin/dashboard/stats", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	for _, path := range []string{"/tasks/5", "/tasks/5", "/fail", "/admin/dashboard/stats"} {
+		if _, err := app.Test(httptest.NewRequest("GET", path, nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snap := Get().Snapshot(time.Now(), 10)
+	if snap.Requests != 3 {
+		t.Errorf("Expected 3 recorded requests, got %d", snap.Requests)
+	}
+	var errors int64
+	for _, p := range snap.Series {
+		errors += p.Errors
+	}
+	if errors != 1 {
+		t.Errorf("Expected 1 error, got %d", errors)
+	}
+	if len(snap.HotKeys) != 1 || snap.HotKeys[0] != (KeyCount{"5", 2}) {
+		t.Errorf("Expected hot key 5 with 2 hits, got %+v", snap.HotKeys)
+	}
+}
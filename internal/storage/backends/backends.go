@@ -0,0 +1,106 @@
+package backends
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/badger"
+	"tasks-service-demo/internal/storage/mmap"
+	"tasks-service-demo/internal/storage/naive"
+	"tasks-service-demo/internal/storage/shard"
+	"tasks-service-demo/internal/storage/xsync"
+)
+
+// Package backends registers the built-in storage backends with the storage
+// registry. It lives apart from the backend packages so they don't depend on
+// the registry; import it for its side effect.
+
+// Default is the backend used when STORAGE_TYPE is unset
+const Default = "xsync"
+
+func init() {
+	for _, b := range []storage.Backend{
+		{
+			Name:        "xsync",
+			Description: "XSyncStore (lock-free concurrent map - best performance)",
+			New: func(opts storage.Options) (storage.Store, error) {
+				if opts.Expected > 0 {
+					return xsync.NewXSyncStorePresized(opts.Expected), nil
+				}
+				return xsync.NewXSyncStore(), nil
+			},
+		},
+		{
+			Name:        "gopool",
+			Description: "ShardStoreGopool (sharded maps on a goroutine pool)",
+			Sharded:     true,
+			New: func(opts storage.Options) (storage.Store, error) {
+				store := shard.NewShardStoreGopool(opts.ShardCount)
+				store.SampleLocks(opts.LockSample)
+				store.Preallocate(opts.Expected)
+				return store, nil
+			},
+		},
+		{
+			Name:        "shard",
+			Description: "ShardStore (sharded maps with dedicated workers)",
+			Sharded:     true,
+			New: func(opts storage.Options) (storage.Store, error) {
+				store := shard.NewShardStore(opts.ShardCount)
+				store.SampleLocks(opts.LockSample)
+				store.Preallocate(opts.Expected)
+				return store, nil
+			},
+		},
+		{
+			Name:        "badger",
+			Description: "BadgerStore (embedded LSM key-value store - durable with DATA_DIR)",
+			Durable:     true,
+			Expiring:    true,
+			New: func(opts storage.Options) (storage.Store, error) {
+				return badger.Open(badger.Config{
+					Dir:        opts.DataDir,
+					SyncWrites: opts.SyncWrites,
+					TTL:        opts.TaskTTL,
+					GCInterval: opts.GCInterval,
+					Sealer:     opts.Sealer,
+					Compressor: opts.Compressor,
+				})
+			},
+		},
+		{
+			Name:        "mmap",
+			Description: "MmapStore (fixed-size records in a memory-mapped file - needs DATA_DIR)",
+			Durable:     true,
+			New: func(opts storage.Options) (storage.Store, error) {
+				if opts.DataDir == "" {
+					return nil, fmt.Errorf("the mmap backend needs DATA_DIR")
+				}
+				if err := os.MkdirAll(opts.DataDir, 0o755); err != nil {
+					return nil, err
+				}
+				return mmap.Open(mmap.Config{
+					Path:       filepath.Join(opts.DataDir, "tasks.mmap"),
+					SyncWrites: opts.SyncWrites,
+					Group:      opts.Group,
+					Sealer:     opts.Sealer,
+				})
+			},
+		},
+		{
+			Name:        "memory",
+			Description: "MemoryStore (single mutex - not recommended for production)",
+			New: func(opts storage.Options) (storage.Store, error) {
+				store := naive.NewMemoryStore()
+				store.Preallocate(opts.Expected)
+				return store, nil
+			},
+		},
+	} {
+		if err := storage.Register(b); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package backends
+
+import (
+	"testing"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/channel"
+)
+
+func TestBuiltinBackends(t *testing.T) {
+	for _, name := range []string{"xsync", "gopool", "shard", "badger", "mmap", "memory"} {
+		b, ok := storage.Lookup(name)
+		if !ok {
+			t.Fatalf("Expected %s to be registered", name)
+		}
+
+		store, err := b.New(storage.Options{ShardCount: 4, DataDir: t.TempDir()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		task := &entities.Task{Name: "registered"}
+		if err := store.Create(task); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got, err := store.GetByID(task.ID); err != nil || got.Name != "registered" {
+			t.Errorf("%s: expected to read back the task, got %v, %v", name, got, err)
+		}
+
+		if closer, ok := store.(interface{ Close() error }); ok {
+			closer.Close()
+		}
+	}
+}
+
+func TestBuiltinBackends_Subtasks(t *testing.T) {
+	for _, name := range []string{"xsync", "gopool", "shard", "badger", "mmap", "memory"} {
+		b, _ := storage.Lookup(name)
+		opts := storage.Options{ShardCount: 4, DataDir: t.TempDir()}
+		store, err := b.New(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		task := &entities.Task{Name: "checklist"}
+		task.SetSubtasks([]entities.Subtask{{ID: 1, Name: "first", Done: true}, {ID: 2, Name: "second"}})
+		if err := store.Create(task); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if b.Durable {
+			storage.Close(store)
+			if store, err = b.New(opts); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+		}
+		if got, err := store.GetByID(task.ID); err != nil || !got.Equal(task) || *got.Progress != 50 {
+			t.Errorf("%s: expected the subtasks to round-trip, got %+v, %v", name, got, err)
+		}
+		storage.Close(store)
+	}
+}
+
+// TestBackends_ErrorCodes checks that every backend, and the channel store,
+// reports the same error codes, so a missing task gets the same response
+// whichever store served it, and never that of a storage error
+func TestBackends_ErrorCodes(t *testing.T) {
+	stores := map[string]storage.Store{"channel": channel.NewChannelStore(1)}
+	for _, name := range []string{"xsync", "gopool", "shard", "badger", "mmap", "memory"} {
+		b, _ := storage.Lookup(name)
+		store, err := b.New(storage.Options{ShardCount: 4, DataDir: t.TempDir()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		stores[name] = store
+	}
+
+	for name, store := range stores {
+		task := &entities.Task{Name: "conformance"}
+		if err := store.Create(task); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := store.Delete(task.ID); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		_, getErr := store.GetByID(task.ID)
+		missing := map[string]*apperrors.AppError{
+			"GetByID":       getErr,
+			"Update":        store.Update(task.ID, &entities.Task{Name: "gone"}),
+			"Delete":        store.Delete(task.ID),
+			"Update(never)": store.Update(1_000_000, &entities.Task{Name: "never"}),
+		}
+		for call, err := range missing {
+			if err == nil || err.Code != apperrors.ErrCodeTaskNotFound || err.Type != apperrors.ErrTaskNotFound.Type {
+				t.Errorf("%s: expected %s of a missing task to fail with code %d, got %v", name, call, apperrors.ErrCodeTaskNotFound, err)
+			}
+		}
+
+		if s, ok := store.(*channel.ChannelStore); ok {
+			s.Shutdown()
+		} else {
+			storage.Close(store)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package backends
+
+import (
+	"fmt"
+
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/shard"
+	"tasks-service-demo/internal/storage/xsync"
+)
+
+// NewCollection returns a collection of T on the machinery of the built-in
+// in-memory backend storageType, so every resource of the service runs on
+// the backend chosen for tasks. The durable backends and plugins hold only
+// tasks.
+func NewCollection[T entities.Entity](storageType string, opts storage.Options) (storage.Collection[T], error) {
+	switch storageType {
+	case "xsync":
+		if opts.Expected > 0 {
+			return xsync.NewCollectionPresized[T](opts.Expected), nil
+		}
+		return xsync.NewCollection[T](), nil
+	case "shard", "gopool":
+		c := shard.NewCollection[T](opts.ShardCount)
+		c.SampleLocks(opts.LockSample)
+		return c, nil
+	case "memory":
+		// One unit is one map behind one mutex, as in MemoryStore
+		return shard.NewCollection[T](1), nil
+	}
+	return nil, fmt.Errorf("storage backend %q does not provide collections of other resources", storageType)
+}
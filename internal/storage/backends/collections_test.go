@@ -0,0 +1,74 @@
+package backends
+
+import (
+	"sync"
+	"testing"
+
+	"tasks-service-demo/internal/storage"
+)
+
+// label is a resource other than tasks
+type label struct {
+	ID   int
+	Name string
+}
+
+func (l *label) GetID() int   { return l.ID }
+func (l *label) SetID(id int) { l.ID = id }
+
+func TestNewCollection(t *testing.T) {
+	for _, name := range []string{"xsync", "shard", "gopool", "memory"} {
+		t.Run(name, func(t *testing.T) {
+			labels, err := NewCollection[*label](name, storage.Options{ShardCount: 4})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var wg sync.WaitGroup
+			for w := 0; w < 4; w++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < 50; i++ {
+						labels.Create(&label{Name: "bug"})
+					}
+				}()
+			}
+			wg.Wait()
+			if labels.Len() != 200 || len(labels.All()) != 200 {
+				t.Fatalf("Expected 200 labels, got %d", labels.Len())
+			}
+
+			l := &label{Name: "urgent"}
+			id := labels.Create(l)
+			if id != 201 || l.ID != 201 {
+				t.Errorf("Expected the next ID to be set on the label, got %d (%d)", id, l.ID)
+			}
+			if got, ok := labels.Get(id); !ok || got.Name != "urgent" {
+				t.Errorf("Expected to read back the label, got %v, %v", got, ok)
+			}
+			if !labels.Update(id, &label{Name: "blocker"}) {
+				t.Error("Expected the label to be updated")
+			}
+			if got, _ := labels.Get(id); got.Name != "blocker" || got.ID != id {
+				t.Errorf("Expected the updated label under its ID, got %+v", got)
+			}
+			if labels.Update(999, &label{}) || labels.Delete(999) {
+				t.Error("Expected missing IDs to be reported")
+			}
+			if _, ok := labels.Get(999); ok {
+				t.Error("Expected a missing label")
+			}
+
+			labels.Delete(11)
+			page := labels.Range(10, 20, 3)
+			if len(page) != 3 || page[0].ID != 10 || page[1].ID != 12 || page[2].ID != 13 {
+				t.Errorf("Expected labels 10, 12 and 13 in ID order, got %+v", page)
+			}
+		})
+	}
+
+	if _, err := NewCollection[*label]("badger", storage.Options{}); err == nil {
+		t.Error("Expected the durable backends to hold only tasks")
+	}
+}
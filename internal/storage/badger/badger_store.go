@@ -0,0 +1,811 @@
+package badger
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"tasks-service-demo/internal/compress"
+	"tasks-service-demo/internal/crypt"
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/migrate"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Package badger provides a durable storage backend on the Badger embedded
+// LSM key-value store. Tasks are stored as JSON under big-endian ID keys, so
+// prefix iteration returns them in ID order. With a Compressor large values
+// are compressed, and with a Sealer the JSON is then encrypted, bound to its
+// key. The version of the JSON format is recorded
+// in the database, and tasks written under an older one are migrated when
+// the database opens.
+
+const (
+	DefaultGCInterval = 5 * time.Minute // Value-log GC period
+	gcDiscardRatio    = 0.5             // Rewrite value-log files with at least this much garbage
+	seqBandwidth      = 100             // IDs leased from the persistent sequence at once
+	conflictRetries   = 100             // Attempts at a read-write transaction before giving up
+	keyStripes        = 64              // Mutexes serializing upserts, picked by key hash
+	rekeyBatch        = 500             // Tasks re-encrypted per transaction
+	migrateBatch      = 500             // Tasks migrated per transaction
+)
+
+var (
+	taskPrefix = []byte("task/")
+	seqKey     = []byte("seq/task")
+	metaPrefix = []byte("meta/")
+	keyPrefix  = []byte("key/")
+
+	// Meta records of the value format: its version, and during a migration
+	// the last task key migrated, written with each batch so an interrupted
+	// migration resumes instead of upgrading tasks twice
+	formatKey   = "format-version"
+	progressKey = "format-migration"
+)
+
+// valueFormat is the JSON format of task values. Databases that predate the
+// recorded version hold version 1.
+var valueFormat = migrate.NewFormat[[]byte]("badger task", 1)
+
+// Config configures a BadgerStore
+type Config struct {
+	Dir        string               // Data directory; empty keeps everything in memory
+	SyncWrites bool                 // Sync each commit to disk; without it a power loss may drop recent writes
+	TTL        time.Duration        // Tasks expire this long after their last write; 0 keeps them
+	GCInterval time.Duration        // Value-log GC period; 0 uses DefaultGCInterval
+	Sealer     *crypt.Sealer        // Encrypts task values; nil stores them in plaintext
+	Compressor *compress.Compressor // Compresses large task values; nil stores them as JSON
+}
+
+// BadgerStore persists tasks in a Badger database
+type BadgerStore struct {
+	db  *badger.DB
+	seq *badger.Sequence
+	cfg Config
+
+	keyLocks [keyStripes]sync.Mutex // Serialize WithKey calls per key
+
+	stop      chan struct{}
+	gcDone    chan struct{}
+	closeOnce sync.Once
+}
+
+// Open opens or creates the database in cfg.Dir and starts value-log GC
+func Open(cfg Config) (*BadgerStore, error) {
+	if cfg.GCInterval <= 0 {
+		cfg.GCInterval = DefaultGCInterval
+	}
+
+	opts := badger.DefaultOptions(cfg.Dir).WithLogger(badgerLogger{}).WithSyncWrites(cfg.SyncWrites)
+	if cfg.Dir == "" {
+		opts = opts.WithInMemory(true)
+	}
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, err
+	}
+	// IDs come from a persistent sequence so they are never reused, even
+	// after the newest task is deleted and the process restarts
+	seq, err := db.GetSequence(seqKey, seqBandwidth)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	s := &BadgerStore{
+		db:     db,
+		seq:    seq,
+		cfg:    cfg,
+		stop:   make(chan struct{}),
+		gcDone: make(chan struct{}),
+	}
+	if err := s.migrate(); err != nil {
+		seq.Release()
+		db.Close()
+		return nil, err
+	}
+	go s.gcLoop()
+	return s, nil
+}
+
+func taskKey(id int) []byte {
+	key := make([]byte, len(taskPrefix)+8)
+	copy(key, taskPrefix)
+	binary.BigEndian.PutUint64(key[len(taskPrefix):], uint64(id))
+	return key
+}
+
+// put writes task under its ID, applying the configured TTL
+func (s *BadgerStore) put(txn *badger.Txn, task *entities.Task) error {
+	value, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	key := taskKey(task.ID)
+	entry := badger.NewEntry(key, s.seal(value, key))
+	if s.cfg.TTL > 0 {
+		entry = entry.WithTTL(s.cfg.TTL)
+	}
+	return txn.SetEntry(entry)
+}
+
+// update runs fn in a read-write transaction, retrying when a concurrent
+// commit changed a key fn read. Update and Delete check that the task exists
+// first, so two writes racing on one task would otherwise fail one of them.
+func (s *BadgerStore) update(fn func(txn *badger.Txn) error) error {
+	var err error
+	for i := 0; i < conflictRetries; i++ {
+		if err = s.db.Update(fn); !errors.Is(err, badger.ErrConflict) {
+			return err
+		}
+	}
+	return err
+}
+
+// seal compresses and encrypts the JSON of the task under key, as
+// configured
+func (s *BadgerStore) seal(value, key []byte) []byte {
+	return s.cfg.Sealer.Seal(s.cfg.Compressor.Compress(value), key)
+}
+
+// open returns the JSON of a value written by seal, under any codec and
+// configured key
+func (s *BadgerStore) open(value, key []byte) ([]byte, error) {
+	plain, err := s.cfg.Sealer.Open(value, key)
+	if err != nil {
+		return nil, fmt.Errorf("task key %x: %w", key, err)
+	}
+	if plain, err = compress.Decompress(plain); err != nil {
+		return nil, fmt.Errorf("task key %x: %w", key, err)
+	}
+	return plain, nil
+}
+
+// decode reads the task stored in item, decrypting and decompressing it as
+// needed
+func (s *BadgerStore) decode(item *badger.Item) (*entities.Task, error) {
+	task := &entities.Task{}
+	err := item.Value(func(value []byte) error {
+		plain, err := s.open(value, item.Key())
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(plain, task)
+	})
+	return task, err
+}
+
+// Create stores a new task with an auto-generated ID
+func (s *BadgerStore) Create(task *entities.Task) *apperrors.AppError {
+	next, err := s.seq.Next()
+	if err != nil {
+		return apperrors.ErrStorageError.WithCause(err)
+	}
+	task.ID = int(next) + 1 // Sequences start at 0, task IDs at 1
+
+	if err := s.db.Update(func(txn *badger.Txn) error { return s.put(txn, task) }); err != nil {
+		return apperrors.ErrStorageError.WithCause(err)
+	}
+	return nil
+}
+
+// GetByID retrieves a task by its ID, returns error if not found or expired
+func (s *BadgerStore) GetByID(id int) (*entities.Task, *apperrors.AppError) {
+	var task *entities.Task
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(taskKey(id))
+		if err != nil {
+			return err
+		}
+		task, err = s.decode(item)
+		return err
+	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, apperrors.ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, apperrors.ErrStorageError.WithCause(err)
+	}
+	return task, nil
+}
+
+// GetAll returns all tasks in ID order. The Store interface has no error
+// result, so read failures are logged and the tasks read so far returned.
+func (s *BadgerStore) GetAll() []*entities.Task {
+	return s.GetRange(1, math.MaxInt, 0)
+}
+
+// GetRange seeks to fromID and iterates the key prefix in ID order until
+// toID or limit, so a page costs the same wherever it starts
+func (s *BadgerStore) GetRange(fromID, toID, limit int) []*entities.Task {
+	tasks := make([]*entities.Task, 0)
+	fromID = max(fromID, 1)
+	if toID < fromID {
+		return tasks
+	}
+	last := taskKey(toID)
+
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = taskPrefix
+		if limit > 0 {
+			opts.PrefetchSize = min(limit, opts.PrefetchSize)
+		}
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(taskKey(fromID)); it.Valid(); it.Next() {
+			if bytes.Compare(it.Item().Key(), last) > 0 || (limit > 0 && len(tasks) == limit) {
+				return nil
+			}
+			task, err := s.decode(it.Item())
+			if err != nil {
+				return err
+			}
+			tasks = append(tasks, task)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.For(logger.ModuleStorage).Errorw("Badger iteration failed", "error", err)
+	}
+	return tasks
+}
+
+// Update modifies an existing task by ID, returns error if not found.
+// The write renews the task's TTL.
+func (s *BadgerStore) Update(id int, updatedTask *entities.Task) *apperrors.AppError {
+	err := s.update(func(txn *badger.Txn) error {
+		if _, err := txn.Get(taskKey(id)); err != nil {
+			return err
+		}
+		updatedTask.ID = id
+		return s.put(txn, updatedTask)
+	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return apperrors.ErrTaskNotFound
+	}
+	if err != nil {
+		return apperrors.ErrStorageError.WithCause(err)
+	}
+	return nil
+}
+
+// Delete removes a task by ID, returns error if not found
+func (s *BadgerStore) Delete(id int) *apperrors.AppError {
+	err := s.update(func(txn *badger.Txn) error {
+		if _, err := txn.Get(taskKey(id)); err != nil {
+			return err
+		}
+		return txn.Delete(taskKey(id))
+	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return apperrors.ErrTaskNotFound
+	}
+	if err != nil {
+		return apperrors.ErrStorageError.WithCause(err)
+	}
+	return nil
+}
+
+// DeleteWhere implements storage.BulkDeleter in a single transaction. A
+// transaction larger than Badger allows fails with ErrStorageError, and
+// deletes nothing.
+func (s *BadgerStore) DeleteWhere(ids []int, match func(*entities.Task) bool) ([]int, *apperrors.AppError) {
+	var deleted []int
+	err := s.update(func(txn *badger.Txn) error {
+		deleted = deleted[:0]
+		for _, id := range ids {
+			item, err := txn.Get(taskKey(id))
+			if errors.Is(err, badger.ErrKeyNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			task, err := s.decode(item)
+			if err != nil {
+				return err
+			}
+			if !match(task) {
+				continue
+			}
+			if err := txn.Delete(taskKey(id)); err != nil {
+				return err
+			}
+			deleted = append(deleted, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, apperrors.ErrStorageError.WithCause(err)
+	}
+	return deleted, nil
+}
+
+// GetMeta reads a record stored with PutMeta
+func (s *BadgerStore) GetMeta(key string) ([]byte, bool, error) {
+	var value []byte
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(append(bytes.Clone(metaPrefix), key...))
+		if err != nil {
+			return err
+		}
+		value, err = item.ValueCopy(nil)
+		return err
+	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, false, nil
+	}
+	return value, err == nil, err
+}
+
+// PutMeta stores a record outside the task keyspace; the task TTL does not apply
+func (s *BadgerStore) PutMeta(key string, value []byte) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(append(bytes.Clone(metaPrefix), key...), value)
+	})
+}
+
+// WithKey runs write holding the mutex key hashes to, then stores the ID
+// it returns under the key prefix, so the mapping survives restarts.
+// write goes through the whole decorator chain in transactions of its
+// own; a crash after it and before the mapping is stored leaves the key
+// on its old ID, and the next upsert of the key writes again.
+func (s *BadgerStore) WithKey(key string, write func(id int) (int, *apperrors.AppError)) *apperrors.AppError {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	mu := &s.keyLocks[h.Sum32()%keyStripes]
+	mu.Lock()
+	defer mu.Unlock()
+
+	dbKey := append(bytes.Clone(keyPrefix), key...)
+	id := 0
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(dbKey)
+		if err != nil {
+			return err
+		}
+		return item.Value(func(value []byte) error {
+			id, err = strconv.Atoi(string(value))
+			return err
+		})
+	})
+	if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+		return apperrors.ErrStorageError.WithCause(fmt.Errorf("key %q: %w", key, err))
+	}
+
+	next, appErr := write(id)
+	if appErr != nil {
+		return appErr
+	}
+	if next == id {
+		return nil
+	}
+	if err := s.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(dbKey, []byte(strconv.Itoa(next)))
+	}); err != nil {
+		return apperrors.ErrStorageError.WithCause(err)
+	}
+	return nil
+}
+
+// migrate upgrades tasks written under an older value format and records
+// the current version, refusing a database written by a newer build
+func (s *BadgerStore) migrate() error {
+	current := valueFormat.Current()
+	version, recorded, err := s.formatVersion()
+	if err != nil {
+		return err
+	}
+	if err := valueFormat.Check(version); err != nil {
+		return err
+	}
+	if version == current {
+		if recorded {
+			return nil
+		}
+		return s.PutMeta(formatKey, []byte(strconv.Itoa(current)))
+	}
+
+	progress, _, err := s.GetMeta(progressKey)
+	if err != nil {
+		return err
+	}
+	var keys [][]byte
+	err = s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = taskPrefix
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			if key := it.Item().KeyCopy(nil); bytes.Compare(key, progress) > 0 {
+				keys = append(keys, key)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log := logger.For(logger.ModuleStorage)
+	log.Infow("Migrating badger tasks", "from", version, "to", current,
+		"steps", valueFormat.Pending(version), "tasks", len(keys), "resumed", progress != nil)
+	migrated := 0
+	for len(keys) > 0 {
+		batch := keys[:min(len(keys), migrateBatch)]
+		keys = keys[len(batch):]
+		err := s.update(func(txn *badger.Txn) error {
+			for _, key := range batch {
+				item, err := txn.Get(key)
+				if errors.Is(err, badger.ErrKeyNotFound) {
+					continue // Expired since the scan
+				}
+				if err != nil {
+					return err
+				}
+				value, err := item.ValueCopy(nil)
+				if err != nil {
+					return err
+				}
+				plain, err := s.open(value, key)
+				if err != nil {
+					return err
+				}
+				if plain, err = valueFormat.Migrate(plain, version); err != nil {
+					return fmt.Errorf("task key %x: %w", key, err)
+				}
+				entry := badger.NewEntry(key, s.seal(plain, key))
+				entry.ExpiresAt = item.ExpiresAt()
+				if err := txn.SetEntry(entry); err != nil {
+					return err
+				}
+			}
+			return txn.Set(append(bytes.Clone(metaPrefix), progressKey...), batch[len(batch)-1])
+		})
+		if err != nil {
+			return err
+		}
+		migrated += len(batch)
+	}
+
+	err = s.db.Update(func(txn *badger.Txn) error {
+		if err := txn.Set(append(bytes.Clone(metaPrefix), formatKey...), []byte(strconv.Itoa(current))); err != nil {
+			return err
+		}
+		return txn.Delete(append(bytes.Clone(metaPrefix), progressKey...))
+	})
+	if err != nil {
+		return err
+	}
+	log.Infow("Migrated badger tasks", "version", current, "tasks", migrated)
+	return nil
+}
+
+// formatVersion returns the recorded value format version. A database
+// without one is empty, and takes the current version, or predates it.
+func (s *BadgerStore) formatVersion() (version int, recorded bool, err error) {
+	value, ok, err := s.GetMeta(formatKey)
+	if err != nil {
+		return 0, false, err
+	}
+	if ok {
+		version, err = strconv.Atoi(string(value))
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid %s record %q", formatKey, value)
+		}
+		return version, true, nil
+	}
+	empty := true
+	err = s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = taskPrefix
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		it.Rewind()
+		empty = !it.Valid()
+		return nil
+	})
+	if empty {
+		return valueFormat.Current(), false, err
+	}
+	return 1, false, err
+}
+
+// Rekey re-encrypts the tasks not sealed under the active key, or decrypts
+// them when encryption was turned off, keeping their expiry. It returns how
+// many tasks were rewritten.
+func (s *BadgerStore) Rekey(ctx context.Context) (int, error) {
+	var stale [][]byte
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = taskPrefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			err := it.Item().Value(func(value []byte) error {
+				if !s.cfg.Sealer.Current(value) {
+					stale = append(stale, it.Item().KeyCopy(nil))
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	rekeyed := 0
+	for len(stale) > 0 {
+		if err := ctx.Err(); err != nil {
+			return rekeyed, err
+		}
+		batch := stale[:min(len(stale), rekeyBatch)]
+		stale = stale[len(batch):]
+		n := 0
+		err := s.update(func(txn *badger.Txn) error {
+			n = 0
+			for _, key := range batch {
+				item, err := txn.Get(key)
+				if errors.Is(err, badger.ErrKeyNotFound) {
+					continue // Deleted or expired since the scan
+				}
+				if err != nil {
+					return err
+				}
+				value, err := item.ValueCopy(nil)
+				if err != nil {
+					return err
+				}
+				if s.cfg.Sealer.Current(value) {
+					continue // Rewritten by a concurrent write
+				}
+				plain, err := s.cfg.Sealer.Open(value, key)
+				if err != nil {
+					return fmt.Errorf("task key %x: %w", key, err)
+				}
+				// Compressed values are resealed as they are
+				entry := badger.NewEntry(key, s.cfg.Sealer.Seal(plain, key))
+				entry.ExpiresAt = item.ExpiresAt()
+				if err := txn.SetEntry(entry); err != nil {
+					return err
+				}
+				n++
+			}
+			return nil
+		})
+		if err != nil {
+			return rekeyed, err
+		}
+		rekeyed += n
+	}
+	return rekeyed, nil
+}
+
+// Verify checks the block checksums of the database, that every task
+// decodes under the ID of its key, and that the persisted ID sequence is
+// past the highest stored ID; a sequence left behind is moved forward so
+// IDs are not handed out twice. Verify must not run concurrently with
+// Create.
+func (s *BadgerStore) Verify(ctx context.Context) (storage.Integrity, error) {
+	var result storage.Integrity
+	if err := s.db.VerifyChecksum(); err != nil {
+		result.Problemf("checksum: %v", err)
+	}
+
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = taskPrefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			result.Records++
+			key := it.Item().Key()
+			id := int(binary.BigEndian.Uint64(key[len(taskPrefix):]))
+			task, err := s.decode(it.Item())
+			if err != nil {
+				result.BadRecords++
+				result.Problemf("task %d: %v", id, err)
+				continue
+			}
+			if task.ID != id {
+				result.BadRecords++
+				result.Problemf("task %d is stored under the key of task %d", task.ID, id)
+				continue
+			}
+			result.MaxID = max(result.MaxID, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	from, err := s.advanceSequence(uint64(result.MaxID))
+	if err != nil {
+		return result, fmt.Errorf("check ID sequence: %w", err)
+	}
+	if from < uint64(result.MaxID) {
+		result.Repairf("moved the ID sequence from %d past task %d", from, result.MaxID)
+	}
+	return result, nil
+}
+
+// advanceSequence makes sure the sequence hands out no value below next
+// and returns the value it would have handed out. Only a released sequence
+// persists its next value, rather than the end of its lease, so it is
+// released and leased again.
+func (s *BadgerStore) advanceSequence(next uint64) (uint64, error) {
+	if err := s.seq.Release(); err != nil {
+		return 0, err
+	}
+	var from uint64
+	err := s.db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get(seqKey)
+		if err != nil {
+			return err
+		}
+		err = item.Value(func(value []byte) error {
+			from = binary.BigEndian.Uint64(value)
+			return nil
+		})
+		if err != nil || from >= next {
+			return err
+		}
+		return txn.Set(seqKey, binary.BigEndian.AppendUint64(nil, next))
+	})
+	if err != nil {
+		return 0, err
+	}
+	seq, err := s.db.GetSequence(seqKey, seqBandwidth)
+	if err != nil {
+		return 0, err
+	}
+	s.seq = seq
+	return from, nil
+}
+
+// CompactOnline merges the LSM tree into one level, dropping overwritten,
+// deleted and expired keys, then rewrites value-log files one at a time
+// with a pause between them. Badger keeps serving throughout; the pause
+// spares the disk for foreground requests.
+func (s *BadgerStore) CompactOnline(ctx context.Context, opts storage.CompactOptions) (storage.CompactStats, error) {
+	stats := storage.CompactStats{SizeBefore: s.diskSize()}
+	step := func() {
+		stats.Steps++
+		if opts.Progress != nil {
+			opts.Progress(int64(stats.Steps), 0) // The number of files to rewrite is not known up front
+		}
+	}
+
+	if err := s.db.Flatten(1); err != nil {
+		return stats, err
+	}
+	step()
+	for {
+		select {
+		case <-ctx.Done():
+			return stats, ctx.Err()
+		case <-time.After(opts.Pause):
+		}
+		err := s.db.RunValueLogGC(gcDiscardRatio)
+		if errors.Is(err, badger.ErrNoRewrite) || errors.Is(err, badger.ErrGCInMemoryMode) {
+			break
+		}
+		if errors.Is(err, badger.ErrRejected) {
+			return stats, errors.New("value-log GC is already running")
+		}
+		if err != nil {
+			return stats, err
+		}
+		step()
+	}
+
+	stats.SizeAfter = s.diskSize()
+	stats.ReclaimedBytes = stats.SizeBefore - stats.SizeAfter
+	return stats, nil
+}
+
+// diskSize returns the bytes of the table and value-log files. Badger's
+// own Size is refreshed only once a minute, too late to measure a
+// compaction.
+func (s *BadgerStore) diskSize() int64 {
+	if s.cfg.Dir == "" {
+		return 0
+	}
+	entries, err := os.ReadDir(s.cfg.Dir)
+	if err != nil {
+		return 0
+	}
+	var size int64
+	for _, e := range entries {
+		if ext := filepath.Ext(e.Name()); ext != ".sst" && ext != ".vlog" {
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+// gcLoop reclaims value-log space left by updates, deletes and expired tasks
+func (s *BadgerStore) gcLoop() {
+	defer close(s.gcDone)
+
+	ticker := time.NewTicker(s.cfg.GCInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.RunGC()
+		}
+	}
+}
+
+// RunGC rewrites value-log files until none has enough garbage left
+func (s *BadgerStore) RunGC() {
+	for {
+		err := s.db.RunValueLogGC(gcDiscardRatio)
+		if err == nil {
+			continue // A file was rewritten; there may be more
+		}
+		if !errors.Is(err, badger.ErrNoRewrite) && !errors.Is(err, badger.ErrRejected) {
+			logger.For(logger.ModuleStorage).Warnw("Badger value-log GC failed", "error", err)
+		}
+		return
+	}
+}
+
+// Close stops GC, returns unused leased IDs and closes the database
+func (s *BadgerStore) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		<-s.gcDone
+		err = errors.Join(s.seq.Release(), s.db.Close())
+	})
+	return err
+}
+
+// badgerLogger routes Badger's logs to the storage module logger; its
+// info and debug output is chatty, so only warnings and errors are kept
+type badgerLogger struct{}
+
+func (badgerLogger) Errorf(format string, args ...interface{}) {
+	logger.For(logger.ModuleStorage).Errorf("badger: "+format, args...)
+}
+
+func (badgerLogger) Warningf(format string, args ...interface{}) {
+	logger.For(logger.ModuleStorage).Warnf("badger: "+format, args...)
+}
+
+func (badgerLogger) Infof(string, ...interface{}) {}
+
+func (badgerLogger) Debugf(string, ...interface{}) {}
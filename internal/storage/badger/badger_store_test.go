@@ -0,0 +1,608 @@
+package badger
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/compress"
+	"tasks-service-demo/internal/crypt"
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/migrate"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+func openStore(t *testing.T, cfg Config) *BadgerStore {
+	t.Helper()
+	store, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Expected store to open, got %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestBadgerStore_CRUD(t *testing.T) {
+	store := openStore(t, Config{})
+
+	task := &entities.Task{Name: "Test Task"}
+	if err := store.Create(task); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if task.ID != 1 {
+		t.Errorf("Expected first task ID to be 1, got %d", task.ID)
+	}
+
+	if err := store.Update(task.ID, &entities.Task{Name: "Updated", Status: 1}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	got, err := store.GetByID(task.ID)
+	if err != nil || got.Name != "Updated" || got.Status != 1 || got.ID != task.ID {
+		t.Errorf("Expected updated task, got %+v, %v", got, err)
+	}
+
+	if err := store.Delete(task.ID); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if _, err := store.GetByID(task.ID); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected ErrTaskNotFound after delete, got %v", err)
+	}
+	if err := store.Update(999, &entities.Task{Name: "x"}); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected ErrTaskNotFound updating missing task, got %v", err)
+	}
+	if err := store.Delete(999); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected ErrTaskNotFound deleting missing task, got %v", err)
+	}
+}
+
+func TestBadgerStore_ConcurrentUpdatesRetryConflicts(t *testing.T) {
+	store := openStore(t, Config{})
+	task := &entities.Task{Name: "Test Task"}
+	store.Create(task)
+
+	var wg sync.WaitGroup
+	errs := make(chan *apperrors.AppError, 32)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- store.Update(task.ID, &entities.Task{Name: "Updated"})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Expected concurrent updates to succeed, got %v", err)
+		}
+	}
+}
+
+func TestBadgerStore_GetAllAndRange(t *testing.T) {
+	store := openStore(t, Config{})
+	for i := 0; i < 300; i++ {
+		store.Create(&entities.Task{Name: "task"})
+	}
+	store.Delete(2)
+
+	all := store.GetAll()
+	if len(all) != 299 || all[0].ID != 1 || all[1].ID != 3 || all[298].ID != 300 {
+		t.Fatalf("Expected 299 tasks in ID order, got %d", len(all))
+	}
+
+	// Big-endian keys keep 256 after 255
+	page := store.GetRange(255, 1000, 2)
+	if len(page) != 2 || page[0].ID != 255 || page[1].ID != 256 {
+		t.Errorf("Expected tasks 255-256, got %v", page)
+	}
+	if page := store.GetRange(1, 4, 0); len(page) != 3 || page[1].ID != 3 {
+		t.Errorf("Expected tasks 1, 3 and 4, got %v", page)
+	}
+	if page := store.GetRange(299, 5000, 5); len(page) != 2 {
+		t.Errorf("Expected last 2 tasks, got %d", len(page))
+	}
+	if page := store.GetRange(10, 5, 0); len(page) != 0 {
+		t.Errorf("Expected empty range, got %d", len(page))
+	}
+}
+
+func TestBadgerStore_Persists(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := Open(Config{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Create(&entities.Task{Name: "a"})
+	store.Create(&entities.Task{Name: "b"})
+	store.Delete(2)
+	if err := store.Close(); err != nil {
+		t.Fatalf("Expected clean close, got %v", err)
+	}
+
+	reopened := openStore(t, Config{Dir: dir})
+	if got, err := reopened.GetByID(1); err != nil || got.Name != "a" {
+		t.Errorf("Expected task to survive restart, got %+v, %v", got, err)
+	}
+
+	// Deleted IDs are not handed out again
+	task := &entities.Task{Name: "c"}
+	reopened.Create(task)
+	if task.ID <= 2 {
+		t.Errorf("Expected a fresh ID after restart, got %d", task.ID)
+	}
+}
+
+func TestBadgerStore_TTL(t *testing.T) {
+	store := openStore(t, Config{TTL: time.Second})
+
+	task := &entities.Task{Name: "short-lived"}
+	store.Create(task)
+	if _, err := store.GetByID(task.ID); err != nil {
+		t.Fatalf("Expected task before expiry, got %v", err)
+	}
+
+	// Badger TTLs have one-second resolution
+	time.Sleep(2 * time.Second)
+	if _, err := store.GetByID(task.ID); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected expired task to be gone, got %v", err)
+	}
+	if all := store.GetAll(); len(all) != 0 {
+		t.Errorf("Expected expired task to be skipped, got %d tasks", len(all))
+	}
+}
+
+func TestBadgerStore_CloseIdempotent(t *testing.T) {
+	store, err := Open(Config{GCInterval: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond) // Let the GC loop tick
+	if err := store.Close(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Errorf("Expected second close to be a no-op, got %v", err)
+	}
+}
+
+func TestBadgerStore_Meta(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := Open(Config{Dir: dir, TTL: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := store.GetMeta("schedules"); ok || err != nil {
+		t.Errorf("Expected missing record, got %v, %v", ok, err)
+	}
+	if err := store.PutMeta("schedules", []byte(`[]`)); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	time.Sleep(5 * time.Millisecond)
+	reopened := openStore(t, Config{Dir: dir})
+	if value, ok, err := reopened.GetMeta("schedules"); !ok || err != nil || string(value) != `[]` {
+		t.Errorf("Expected record to survive restart without TTL, got %q, %v, %v", value, ok, err)
+	}
+	if tasks := reopened.GetAll(); len(tasks) != 0 {
+		t.Errorf("Expected meta records to stay out of GetAll, got %+v", tasks)
+	}
+}
+
+func TestBadgerStore_WithKey(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := Open(Config{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	create := func(int) (int, *apperrors.AppError) {
+		task := &entities.Task{Name: "keyed"}
+		if err := store.Create(task); err != nil {
+			return 0, err
+		}
+		return task.ID, nil
+	}
+	if err := store.WithKey("ext-1", create); err != nil {
+		t.Fatal(err)
+	}
+	// A failed write keeps the mapping
+	if err := store.WithKey("ext-1", func(int) (int, *apperrors.AppError) {
+		return 0, apperrors.ErrStorageError
+	}); err != apperrors.ErrStorageError {
+		t.Errorf("Expected the write error, got %v", err)
+	}
+	store.Close()
+
+	reopened := openStore(t, Config{Dir: dir})
+	var seen int
+	reopened.WithKey("ext-1", func(id int) (int, *apperrors.AppError) {
+		seen = id
+		return id, nil
+	})
+	if seen != 1 {
+		t.Errorf("Expected the key to map to task 1 after restart, got %d", seen)
+	}
+	if tasks := reopened.GetAll(); len(tasks) != 1 {
+		t.Errorf("Expected key records to stay out of GetAll, got %+v", tasks)
+	}
+
+	var _ storage.KeyIndex = reopened
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reopened.WithKey("ext-2", func(id int) (int, *apperrors.AppError) {
+				if id != 0 {
+					return id, nil
+				}
+				task := &entities.Task{Name: "once"}
+				if err := reopened.Create(task); err != nil {
+					return 0, err
+				}
+				return task.ID, nil
+			})
+		}()
+	}
+	wg.Wait()
+	if tasks := reopened.GetAll(); len(tasks) != 2 {
+		t.Errorf("Expected concurrent upserts of one key to create one task, got %d tasks", len(tasks))
+	}
+}
+
+func TestBadgerStore_Migrate(t *testing.T) {
+	dir := t.TempDir()
+	sealer := newSealer(t, "a")
+
+	// A database written under version 1 of a format whose version 2
+	// renamed "title" to "name", left by a migration interrupted after
+	// task 1
+	store := openStore(t, Config{Dir: dir, Sealer: sealer})
+	err := store.db.Update(func(txn *badger.Txn) error {
+		values := map[int]string{
+			1: `{"id":1,"name":"migrated","status":0}`,
+			2: `{"id":2,"title":"second","status":1}`,
+			3: `{"id":3,"title":"third","status":0}`,
+		}
+		for id, value := range values {
+			if err := txn.Set(taskKey(id), sealer.Seal([]byte(value), taskKey(id))); err != nil {
+				return err
+			}
+		}
+		return txn.Delete(append(bytes.Clone(metaPrefix), formatKey...))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.PutMeta(progressKey, taskKey(1)); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	original := valueFormat
+	t.Cleanup(func() { valueFormat = original })
+	valueFormat = migrate.NewFormat[[]byte]("badger task", 1, migrate.JSON("rename title to name", func(doc map[string]any) error {
+		doc["name"] = doc["title"]
+		delete(doc, "title")
+		return nil
+	}))
+
+	store = openStore(t, Config{Dir: dir, Sealer: sealer})
+	all := store.GetAll()
+	if len(all) != 3 || all[0].Name != "migrated" || all[1].Name != "second" || all[2].Name != "third" || all[1].Status != 1 {
+		t.Errorf("Expected every task in the current format, got %+v", all)
+	}
+	if version, ok, _ := store.GetMeta(formatKey); !ok || string(version) != "2" {
+		t.Errorf("Expected format version 2 recorded, got %q", version)
+	}
+	if _, ok, _ := store.GetMeta(progressKey); ok {
+		t.Error("Expected the migration progress cleared")
+	}
+
+	// A database written by a newer build is refused
+	store.PutMeta(formatKey, []byte("3"))
+	store.Close()
+	if _, err := Open(Config{Dir: dir, Sealer: sealer}); !errors.Is(err, migrate.ErrNewer) {
+		t.Errorf("Expected a newer format to be refused, got %v", err)
+	}
+}
+
+func TestBadgerStore_OpensVersion1Database(t *testing.T) {
+	// Every key and value of a database written by the build before format
+	// versions were recorded, as hex pairs: tasks 1 and 3 plain, task 2
+	// deleted and task 4 sealed under the "fixture" key
+	data, err := os.ReadFile(filepath.Join("testdata", "tasks-v1.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	db, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Update(func(txn *badger.Txn) error {
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			k, v, _ := strings.Cut(line, " ")
+			key, err := hex.DecodeString(k)
+			if err != nil {
+				return err
+			}
+			value, err := hex.DecodeString(v)
+			if err != nil {
+				return err
+			}
+			if err := txn.Set(key, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	sealer, err := crypt.New([]crypt.Key{{ID: "fixture", Secret: bytes.Repeat([]byte("f"), 32)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := openStore(t, Config{Dir: dir, Sealer: sealer})
+	all := store.GetAll()
+	if len(all) != 3 || all[0].ID != 1 || all[0].Name != "Write the migration" || all[0].Status != 1 ||
+		all[1].ID != 3 || all[1].Name != "Ünïcode name" || all[2].ID != 4 || all[2].Name != "Sealed name" {
+		t.Errorf("Expected the tasks of the version 1 database, got %+v", all)
+	}
+	if version, ok, _ := store.GetMeta(formatKey); !ok || string(version) != strconv.Itoa(valueFormat.Current()) {
+		t.Errorf("Expected format version %d recorded, got %q", valueFormat.Current(), version)
+	}
+
+	// The tasks take the fields added since, and IDs keep counting
+	task := all[0]
+	task.SetSubtasks([]entities.Subtask{{ID: 1, Name: "fixture", Done: true}})
+	if err := store.Update(task.ID, task); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.GetByID(task.ID); len(got.Subtasks) != 1 {
+		t.Errorf("Expected the subtasks stored, got %+v", got)
+	}
+	next := &entities.Task{Name: "next"}
+	store.Create(next)
+	if next.ID <= 4 {
+		t.Errorf("Expected a new ID after 4, got %d", next.ID)
+	}
+}
+
+func newSealer(t *testing.T, ids ...string) *crypt.Sealer {
+	t.Helper()
+	var keys []crypt.Key
+	for _, id := range ids {
+		keys = append(keys, crypt.Key{ID: id, Secret: bytes.Repeat([]byte(id[:1]), 32)})
+	}
+	sealer, err := crypt.New(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sealer
+}
+
+func TestBadgerStore_Encryption(t *testing.T) {
+	dir := t.TempDir()
+	reopen := func(store *BadgerStore, sealer *crypt.Sealer) *BadgerStore {
+		t.Helper()
+		if store != nil {
+			store.Close()
+		}
+		return openStore(t, Config{Dir: dir, Sealer: sealer})
+	}
+
+	// Tasks written before encryption was enabled stay readable
+	store := reopen(nil, nil)
+	store.Create(&entities.Task{Name: "plain"})
+	store = reopen(store, newSealer(t, "a"))
+	store.Create(&entities.Task{Name: "secret"})
+	err := store.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(taskKey(2))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(value []byte) error {
+			if bytes.Contains(value, []byte("secret")) {
+				t.Errorf("Expected the stored value to be encrypted, got %q", value)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all := store.GetAll(); len(all) != 2 || all[0].Name != "plain" || all[1].Name != "secret" {
+		t.Errorf("Expected both tasks, got %+v", all)
+	}
+	if n, err := store.Rekey(context.Background()); n != 1 || err != nil {
+		t.Errorf("Expected the plaintext task re-encrypted, got %d, %v", n, err)
+	}
+
+	// After rotating and rekeying, the old key can be removed
+	store = reopen(store, newSealer(t, "b", "a"))
+	if n, err := store.Rekey(context.Background()); n != 2 || err != nil {
+		t.Errorf("Expected 2 tasks re-encrypted under the new key, got %d, %v", n, err)
+	}
+	store = reopen(store, newSealer(t, "b"))
+	if got, err := store.GetByID(2); err != nil || got.Name != "secret" {
+		t.Errorf("Expected the task under the new key, got %+v, %v", got, err)
+	}
+
+	store = reopen(store, nil)
+	if _, err := store.GetByID(2); err == nil || err.Code != apperrors.ErrCodeStorageError {
+		t.Errorf("Expected a storage error without the key, got %v", err)
+	}
+}
+
+func TestBadgerStore_Compression(t *testing.T) {
+	dir := t.TempDir()
+	reopen := func(store *BadgerStore, codec compress.Codec, sealer *crypt.Sealer) *BadgerStore {
+		t.Helper()
+		if store != nil {
+			store.Close()
+		}
+		compressor, err := compress.New(compress.Config{Codec: codec, Threshold: 128})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return openStore(t, Config{Dir: dir, Compressor: compressor, Sealer: sealer})
+	}
+	large := func(name string) *entities.Task {
+		task := &entities.Task{Name: name}
+		task.SetSubtasks([]entities.Subtask{{ID: 1, Name: strings.Repeat("checklist item ", 20)}})
+		return task
+	}
+	stored := func(store *BadgerStore, id int) (value []byte) {
+		t.Helper()
+		err := store.db.View(func(txn *badger.Txn) error {
+			item, err := txn.Get(taskKey(id))
+			if err != nil {
+				return err
+			}
+			value, err = item.ValueCopy(nil)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value
+	}
+
+	// Tasks written under no codec, and under another one, stay readable
+	store := reopen(nil, compress.None, nil)
+	store.Create(large("plain"))
+	store = reopen(store, compress.Snappy, nil)
+	store.Create(large("snappy"))
+	store.Create(&entities.Task{Name: "small"})
+	store = reopen(store, compress.Zstd, nil)
+	store.Create(large("zstd"))
+
+	for id, expected := range map[int]compress.Codec{1: compress.None, 2: compress.Snappy, 3: compress.None, 4: compress.Zstd} {
+		if codec, _ := compress.CodecOf(stored(store, id)); codec != expected {
+			t.Errorf("Expected task %d stored under %s, got %s", id, expected, codec)
+		}
+	}
+	if all := store.GetAll(); len(all) != 4 || all[1].Name != "snappy" || len(all[3].Subtasks) != 1 {
+		t.Errorf("Expected every task, got %+v", all)
+	}
+
+	// Compressed before it is sealed, and readable once compression is off
+	store = reopen(store, compress.Zstd, newSealer(t, "a"))
+	store.Update(2, large("sealed"))
+	if _, ok := crypt.KeyID(stored(store, 2)); !ok {
+		t.Error("Expected the task sealed")
+	}
+	if n, err := store.Rekey(context.Background()); n != 3 || err != nil {
+		t.Errorf("Expected the other tasks sealed, got %d, %v", n, err)
+	}
+	store = reopen(store, compress.None, newSealer(t, "a"))
+	for id, name := range map[int]string{1: "plain", 2: "sealed", 4: "zstd"} {
+		if got, err := store.GetByID(id); err != nil || got.Name != name {
+			t.Errorf("Expected task %d named %s, got %+v, %v", id, name, got, err)
+		}
+	}
+	if result, err := store.Verify(context.Background()); err != nil || result.BadRecords != 0 {
+		t.Errorf("Expected every record to verify, got %+v, %v", result, err)
+	}
+}
+
+func TestBadgerStore_Verify(t *testing.T) {
+	store := openStore(t, Config{})
+	for _, name := range []string{"a", "b", "c"} {
+		store.Create(&entities.Task{Name: name})
+	}
+	result, err := store.Verify(context.Background())
+	if err != nil || !result.OK() || result.Records != 3 || result.MaxID != 3 || len(result.Repairs) != 0 {
+		t.Fatalf("Expected a clean store, got %+v, %v", result, err)
+	}
+
+	// A sequence behind the stored tasks, as if its lease was lost, and a
+	// value that no longer decodes
+	store.seq.Release()
+	store.db.Update(func(txn *badger.Txn) error { return txn.Set(seqKey, make([]byte, 8)) })
+	store.seq, _ = store.db.GetSequence(seqKey, seqBandwidth)
+	store.db.Update(func(txn *badger.Txn) error { return txn.Set(taskKey(2), []byte("{garbage")) })
+
+	result, err = store.Verify(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.OK() || result.BadRecords != 1 || len(result.Problems) != 1 {
+		t.Errorf("Expected the undecodable task to be reported, got %+v", result)
+	}
+	if len(result.Repairs) != 1 {
+		t.Errorf("Expected the sequence to be repaired, got %+v", result.Repairs)
+	}
+	task := &entities.Task{Name: "d"}
+	if store.Create(task); task.ID != 4 {
+		t.Errorf("Expected ID 4 after the repair, got %d", task.ID)
+	}
+}
+
+func TestBadgerStore_CompactOnline(t *testing.T) {
+	store := openStore(t, Config{Dir: t.TempDir()})
+	for i := 0; i < 100; i++ {
+		task := &entities.Task{Name: "task"}
+		store.Create(task)
+		store.Update(task.ID, &entities.Task{Name: "updated"})
+		store.Delete(task.ID)
+	}
+
+	stats, err := store.CompactOnline(context.Background(), storage.CompactOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Steps < 1 || stats.SizeBefore == 0 || stats.ReclaimedBytes != stats.SizeBefore-stats.SizeAfter {
+		t.Errorf("Expected compaction stats, got %+v", stats)
+	}
+	task := &entities.Task{Name: "after"}
+	if err := store.Create(task); err != nil {
+		t.Errorf("Expected create after compaction, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := store.CompactOnline(ctx, storage.CompactOptions{Pause: time.Hour}); err != context.Canceled {
+		t.Errorf("Expected compaction to stop with ctx, got %v", err)
+	}
+}
+
+func TestBadgerStore_DeleteWhere(t *testing.T) {
+	store := openStore(t, Config{})
+	for i := 0; i < 4; i++ {
+		store.Create(&entities.Task{Name: "task", Status: i % 2})
+	}
+	done := func(task *entities.Task) bool { return task.Status == 1 }
+
+	deleted, err := store.DeleteWhere([]int{1, 2, 4, 99}, done)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 2 || deleted[0] != 2 || deleted[1] != 4 {
+		t.Errorf("Expected the matching tasks 2 and 4 deleted, got %v", deleted)
+	}
+	if _, err := store.GetByID(1); err != nil {
+		t.Errorf("Expected task 1, which does not match, kept, got %v", err)
+	}
+	if all := store.GetAll(); len(all) != 2 {
+		t.Errorf("Expected 2 tasks left, got %d", len(all))
+	}
+}
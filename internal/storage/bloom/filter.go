@@ -0,0 +1,70 @@
+package bloom
+
+import (
+	"math"
+)
+
+// filter is a counting Bloom filter over task IDs. Each position holds a
+// saturating 8-bit counter instead of a bit, so IDs can be removed again;
+// a counter that reached 255 is never decremented, which keeps removal
+// from ever causing a false negative. Not safe for concurrent use.
+type filter struct {
+	counters []uint8
+	k        int // Positions per ID
+	items    int
+}
+
+// newFilter sizes a filter for n IDs at false-positive rate p
+func newFilter(n int, p float64) *filter {
+	n = max(n, 1)
+	m := int(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	k := int(math.Round(float64(m) / float64(n) * math.Ln2))
+	return &filter{counters: make([]uint8, max(m, 1)), k: max(k, 1)}
+}
+
+// hash mixes id into two independent 32-bit hashes (SplitMix64 finalizer);
+// positions are derived by double hashing
+func hash(id int) (uint32, uint32) {
+	z := uint64(id) + 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	z ^= z >> 31
+	return uint32(z), uint32(z>>32) | 1
+}
+
+func (f *filter) positions(id int, fn func(pos int)) {
+	h1, h2 := hash(id)
+	m := uint32(len(f.counters))
+	for i := 0; i < f.k; i++ {
+		fn(int((h1 + uint32(i)*h2) % m))
+	}
+}
+
+func (f *filter) add(id int) {
+	f.positions(id, func(pos int) {
+		if f.counters[pos] < math.MaxUint8 {
+			f.counters[pos]++
+		}
+	})
+	f.items++
+}
+
+// remove must only be called for IDs that were added
+func (f *filter) remove(id int) {
+	f.positions(id, func(pos int) {
+		if c := f.counters[pos]; c > 0 && c < math.MaxUint8 {
+			f.counters[pos]--
+		}
+	})
+	f.items--
+}
+
+func (f *filter) mayContain(id int) bool {
+	found := true
+	f.positions(id, func(pos int) {
+		if f.counters[pos] == 0 {
+			found = false
+		}
+	})
+	return found
+}
@@ -0,0 +1,61 @@
+package bloom
+
+import (
+	"testing"
+)
+
+func TestFilter_NoFalseNegatives(t *testing.T) {
+	f := newFilter(10000, 0.01)
+	for id := 1; id <= 10000; id++ {
+		f.add(id)
+	}
+	for id := 1; id <= 10000; id++ {
+		if !f.mayContain(id) {
+			t.Fatalf("Expected %d to be present", id)
+		}
+	}
+}
+
+func TestFilter_FalsePositiveRate(t *testing.T) {
+	f := newFilter(10000, 0.01)
+	for id := 1; id <= 10000; id++ {
+		f.add(id)
+	}
+
+	fp := 0
+	for id := 10001; id <= 110000; id++ {
+		if f.mayContain(id) {
+			fp++
+		}
+	}
+	if rate := float64(fp) / 100000; rate > 0.02 {
+		t.Errorf("Expected a false-positive rate near 1%%, got %.4f", rate)
+	}
+}
+
+func TestFilter_Remove(t *testing.T) {
+	f := newFilter(100, 0.01)
+	f.add(1)
+	f.add(2)
+	f.remove(1)
+
+	if f.mayContain(1) {
+		t.Error("Expected removed ID to be absent")
+	}
+	if !f.mayContain(2) || f.items != 1 {
+		t.Errorf("Expected remaining ID to be present, items=%d", f.items)
+	}
+}
+
+func TestFilter_SaturatedCountersStay(t *testing.T) {
+	f := newFilter(1, 0.5)
+	for i := 0; i < 300; i++ {
+		f.add(7)
+	}
+	for i := 0; i < 299; i++ {
+		f.remove(7)
+	}
+	if !f.mayContain(7) {
+		t.Error("Expected saturated counters to never drop to zero")
+	}
+}
@@ -0,0 +1,42 @@
+package bloom
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collector exports BloomStore stats as Prometheus metrics
+type collector struct {
+	store *BloomStore
+
+	skipped, falsePositives, items *prometheus.Desc
+}
+
+// NewCollector returns a Prometheus collector for the filter counters.
+// Register it once per BloomStore.
+func NewCollector(namespace string, store *BloomStore) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "bloom", name), help, nil, nil)
+	}
+
+	return &collector{
+		store:          store,
+		skipped:        desc("skipped_lookups_total", "Lookups of missing IDs answered without the backend."),
+		falsePositives: desc("false_positives_total", "Lookups the filter let through that the backend did not find."),
+		items:          desc("items", "IDs currently in the filters."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.skipped
+	ch <- c.falsePositives
+	ch <- c.items
+}
+
+// Collect implements prometheus.Collector
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.store.Stats()
+	ch <- prometheus.MustNewConstMetric(c.skipped, prometheus.CounterValue, float64(stats.Skipped))
+	ch <- prometheus.MustNewConstMetric(c.falsePositives, prometheus.CounterValue, float64(stats.FalsePositives))
+	ch <- prometheus.MustNewConstMetric(c.items, prometheus.GaugeValue, float64(stats.Items))
+}
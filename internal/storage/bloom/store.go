@@ -0,0 +1,196 @@
+package bloom
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage"
+)
+
+// Package bloom provides a storage.Store decorator that keeps per-shard
+// counting Bloom filters of existing task IDs, so lookups of IDs that
+// definitely do not exist are answered without touching a disk or remote
+// backend.
+
+const (
+	DefaultFalsePositiveRate = 0.01    // Used when Config.FalsePositiveRate is out of (0, 1)
+	DefaultExpectedTasks     = 1000000 // Used when Config.ExpectedTasks is not positive
+	DefaultShards            = 16      // Used when Config.Shards is not positive
+)
+
+// Config sizes the filters
+type Config struct {
+	FalsePositiveRate float64 // Target rate while the store holds up to ExpectedTasks
+	ExpectedTasks     int     // Sizing hint; beyond it the false-positive rate rises
+	Shards            int     // Independent filters, each with its own lock
+}
+
+// Stats counts filter activity since creation
+type Stats struct {
+	Skipped        uint64 `json:"skipped"`        // Lookups answered not-found without the inner store
+	FalsePositives uint64 `json:"falsePositives"` // Lookups the filters let through that the inner store did not find
+	Items          int    `json:"items"`
+}
+
+type shard struct {
+	mu sync.RWMutex
+	f  *filter
+}
+
+// BloomStore answers definite misses from Bloom filters and forwards
+// everything else to the inner store
+type BloomStore struct {
+	inner  storage.Store
+	cfg    Config
+	shards []*shard
+
+	skipped, falsePositives atomic.Uint64
+}
+
+// NewBloomStore wraps inner and builds the filters from the tasks it
+// already holds. Filters are sized for at least twice the existing tasks.
+func NewBloomStore(inner storage.Store, cfg Config) *BloomStore {
+	if cfg.FalsePositiveRate <= 0 || cfg.FalsePositiveRate >= 1 {
+		cfg.FalsePositiveRate = DefaultFalsePositiveRate
+	}
+	if cfg.ExpectedTasks <= 0 {
+		cfg.ExpectedTasks = DefaultExpectedTasks
+	}
+	if cfg.Shards <= 0 {
+		cfg.Shards = DefaultShards
+	}
+
+	existing := inner.GetAll()
+	expected := max(cfg.ExpectedTasks, 2*len(existing))
+	s := &BloomStore{inner: inner, cfg: cfg, shards: make([]*shard, cfg.Shards)}
+	for i := range s.shards {
+		s.shards[i] = &shard{f: newFilter(expected/cfg.Shards+1, cfg.FalsePositiveRate)}
+	}
+	for _, task := range existing {
+		s.shardOf(task.ID).f.add(task.ID)
+	}
+	return s
+}
+
+// Inner returns the wrapped store
+func (s *BloomStore) Inner() storage.Store {
+	return s.inner
+}
+
+func (s *BloomStore) shardOf(id int) *shard {
+	return s.shards[uint(id)%uint(len(s.shards))]
+}
+
+// mayExist reports whether id could be in the inner store
+func (s *BloomStore) mayExist(id int) bool {
+	sh := s.shardOf(id)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	return sh.f.mayContain(id)
+}
+
+// Create stores the task and adds its ID to the filter. A concurrent
+// lookup of the new ID can miss until Create returns, which is when the
+// caller learns the ID.
+func (s *BloomStore) Create(task *entities.Task) *apperrors.AppError {
+	if err := s.inner.Create(task); err != nil {
+		return err
+	}
+	sh := s.shardOf(task.ID)
+	sh.mu.Lock()
+	sh.f.add(task.ID)
+	sh.mu.Unlock()
+	return nil
+}
+
+// GetByID skips the inner store for IDs the filter rules out
+func (s *BloomStore) GetByID(id int) (*entities.Task, *apperrors.AppError) {
+	if !s.mayExist(id) {
+		s.skipped.Add(1)
+		return nil, apperrors.ErrTaskNotFound
+	}
+	task, err := s.inner.GetByID(id)
+	if err == apperrors.ErrTaskNotFound {
+		s.falsePositives.Add(1)
+	}
+	return task, err
+}
+
+// GetByIDStrong reads the inner store whatever the filter says, so a
+// strong read, such as the health probe, reaches the backend
+func (s *BloomStore) GetByIDStrong(id int) (*entities.Task, *apperrors.AppError) {
+	return storage.GetByIDStrong(s.inner, id)
+}
+
+// GetAll reads the inner store
+func (s *BloomStore) GetAll() []*entities.Task {
+	return s.inner.GetAll()
+}
+
+// GetRange reads the inner store
+func (s *BloomStore) GetRange(fromID, toID, limit int) []*entities.Task {
+	return s.inner.GetRange(fromID, toID, limit)
+}
+
+// Update skips the inner store for IDs the filter rules out
+func (s *BloomStore) Update(id int, task *entities.Task) *apperrors.AppError {
+	if !s.mayExist(id) {
+		s.skipped.Add(1)
+		return apperrors.ErrTaskNotFound
+	}
+	return s.inner.Update(id, task)
+}
+
+// Delete removes the task and its ID from the filter
+func (s *BloomStore) Delete(id int) *apperrors.AppError {
+	if !s.mayExist(id) {
+		s.skipped.Add(1)
+		return apperrors.ErrTaskNotFound
+	}
+
+	// Hold the shard lock so a concurrent Delete of the same ID cannot
+	// remove it from the filter twice
+	sh := s.shardOf(id)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if err := s.inner.Delete(id); err != nil {
+		return err
+	}
+	sh.f.remove(id)
+	return nil
+}
+
+// DeletedWhere removes the IDs a storage.BulkDeleter below the store
+// deleted from the filter. Each was deleted once, so no Delete removed it
+// already.
+func (s *BloomStore) DeletedWhere(ids []int) {
+	for _, id := range ids {
+		sh := s.shardOf(id)
+		sh.mu.Lock()
+		sh.f.remove(id)
+		sh.mu.Unlock()
+	}
+}
+
+// Close closes the inner store if it supports closing
+func (s *BloomStore) Close() error {
+	_, err := storage.Close(s.inner)
+	return err
+}
+
+// Stats returns a snapshot of the filter counters
+func (s *BloomStore) Stats() Stats {
+	items := 0
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+		items += sh.f.items
+		sh.mu.RUnlock()
+	}
+	return Stats{
+		Skipped:        s.skipped.Load(),
+		FalsePositives: s.falsePositives.Load(),
+		Items:          items,
+	}
+}
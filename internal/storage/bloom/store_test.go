@@ -0,0 +1,92 @@
+package bloom
+
+import (
+	"strings"
+	"testing"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/storetest"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestBloomStore_ImplementsStore(t *testing.T) {
+	var _ storage.Store = NewBloomStore(storetest.NewFakeStore(), Config{})
+}
+
+func TestBloomStore_SkipsDefiniteMisses(t *testing.T) {
+	mock := storetest.NewMockStore()
+	store := NewBloomStore(mock, Config{ExpectedTasks: 1000})
+
+	for _, id := range []int{1, 2, 3} {
+		if _, err := store.GetByID(id); err != apperrors.ErrTaskNotFound {
+			t.Errorf("Expected ErrTaskNotFound, got %v", err)
+		}
+	}
+	if err := store.Update(1, &entities.Task{Name: "x"}); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+	if err := store.Delete(1); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+	if calls := len(mock.Calls()); calls != 1 { // The GetAll that built the filters
+		t.Errorf("Expected no inner calls for missing IDs, got %d", calls)
+	}
+	if stats := store.Stats(); stats.Skipped != 5 {
+		t.Errorf("Expected 5 skipped lookups, got %+v", stats)
+	}
+
+	if _, err := storage.GetByIDStrong(store, 1); err != apperrors.ErrTaskNotFound || len(mock.Calls()) != 2 {
+		t.Errorf("Expected a strong read to reach the inner store, got %v after %d calls", err, len(mock.Calls()))
+	}
+}
+
+func TestBloomStore_TracksCreatesAndDeletes(t *testing.T) {
+	inner := storetest.NewFakeStore(&entities.Task{ID: 1, Name: "existing"})
+	store := NewBloomStore(inner, Config{ExpectedTasks: 1000, Shards: 4})
+
+	if got, err := store.GetByID(1); err != nil || got.Name != "existing" {
+		t.Errorf("Expected existing task after rebuild, got %+v, %v", got, err)
+	}
+
+	task := &entities.Task{Name: "new"}
+	store.Create(task)
+	if _, err := store.GetByID(task.ID); err != nil {
+		t.Errorf("Expected created task, got %v", err)
+	}
+	if err := store.Update(task.ID, &entities.Task{Name: "renamed"}); err != nil {
+		t.Errorf("Expected update to pass the filter, got %v", err)
+	}
+
+	if err := store.Delete(task.ID); err != nil {
+		t.Fatalf("Expected delete, got %v", err)
+	}
+	before := store.Stats().Skipped
+	store.GetByID(task.ID)
+	if stats := store.Stats(); stats.Skipped != before+1 || stats.Items != 1 {
+		t.Errorf("Expected deleted ID to be ruled out, got %+v", stats)
+	}
+}
+
+func TestCollector(t *testing.T) {
+	store := NewBloomStore(storetest.NewFakeStore(&entities.Task{ID: 1, Name: "a"}), Config{ExpectedTasks: 100})
+	store.GetByID(99)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewCollector("test", store))
+
+	expected := `
+# HELP test_bloom_items IDs currently in the filters.
+# TYPE test_bloom_items gauge
+test_bloom_items 1
+# HELP test_bloom_skipped_lookups_total Lookups of missing IDs answered without the backend.
+# TYPE test_bloom_skipped_lookups_total counter
+test_bloom_skipped_lookups_total 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "test_bloom_items", "test_bloom_skipped_lookups_total"); err != nil {
+		t.Error(err)
+	}
+}
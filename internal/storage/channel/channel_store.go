@@ -1,7 +1,9 @@
 package channel
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 	"sync/atomic"
 	"tasks-service-demo/internal/entities"
 	apperrors "tasks-service-demo/internal/errors"
@@ -14,6 +16,7 @@ const (
 	OpUpdate   = "update"
 	OpDelete   = "delete"
 	OpGetAll   = "getall"
+	OpRange    = "range"
 	OpShutdown = "shutdown"
 )
 
@@ -21,11 +24,15 @@ const (
 type Operation struct {
 	Type     string
 	TaskID   int
+	ToID     int // Upper bound of OpRange; TaskID is the lower bound
+	Limit    int // Maximum tasks returned by OpRange
 	Task     *entities.Task
 	Response chan Result
 }
 
-// Result represents the response from an operation
+// Result represents the response from an operation. An *apperrors.AppError
+// in Error reaches the caller with its code; any other error is reported as
+// a storage error.
 type Result struct {
 	Task  *entities.Task
 	Tasks []*entities.Task
@@ -101,6 +108,20 @@ func (cs *ChannelStore) worker() {
 				}
 				op.Response <- Result{Tasks: tasks, Error: nil}
 
+			case OpRange:
+				tasks := make([]*entities.Task, 0)
+				for id, task := range localStorage {
+					if id >= op.TaskID && id <= op.ToID {
+						taskCopy := *task
+						tasks = append(tasks, &taskCopy)
+					}
+				}
+				sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+				if op.Limit > 0 && len(tasks) > op.Limit {
+					tasks = tasks[:op.Limit]
+				}
+				op.Response <- Result{Tasks: tasks, Error: nil}
+
 			case OpShutdown:
 				return
 			}
@@ -111,96 +132,74 @@ func (cs *ChannelStore) worker() {
 	}
 }
 
+// call sends op to the worker and waits for its result
+func (cs *ChannelStore) call(op Operation) Result {
+	op.Response = make(chan Result, 1)
+	cs.operations <- op
+	return <-op.Response
+}
+
+// appError converts the error of a result of op. AppErrors keep their code
+// across the channel, so a missing task stays ErrTaskNotFound rather than
+// becoming a storage error; only other errors are storage errors.
+func appError(op string, err error) *apperrors.AppError {
+	if err == nil {
+		return nil
+	}
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return apperrors.ErrStorageError.WithCause(fmt.Errorf("channel store %s: %w", op, err))
+}
+
 // Create adds a new task to the store
 func (cs *ChannelStore) Create(task *entities.Task) *apperrors.AppError {
+	if task == nil {
+		return apperrors.ErrTaskCannotBeNil
+	}
 	// Generate unique ID atomically
 	id := int(atomic.AddInt64(&cs.nextID, 1))
 	task.ID = id
 
-	response := make(chan Result, 1)
-
-	op := Operation{
-		Type:     OpCreate,
-		Task:     task,
-		Response: response,
-	}
-
-	cs.operations <- op
-	result := <-response
-	if result.Error != nil {
-		return apperrors.ErrStorageError.WithCause(fmt.Errorf("Create failed from channel result: %v", result.Error))
-	}
-	return nil
+	result := cs.call(Operation{Type: OpCreate, Task: task})
+	return appError(OpCreate, result.Error)
 }
 
 // GetByID retrieves a task by its ID
 func (cs *ChannelStore) GetByID(id int) (*entities.Task, *apperrors.AppError) {
-	response := make(chan Result, 1)
-
-	op := Operation{
-		Type:     OpRead,
-		TaskID:   id,
-		Response: response,
-	}
-
-	cs.operations <- op
-	result := <-response
-	if result.Error != nil {
-		return nil, apperrors.ErrStorageError.WithCause(fmt.Errorf("GetByID failed from channel result: %v", result.Error))
+	result := cs.call(Operation{Type: OpRead, TaskID: id})
+	if err := appError(OpRead, result.Error); err != nil {
+		return nil, err
 	}
-
 	return result.Task, nil
 }
 
 // Update modifies an existing task
 func (cs *ChannelStore) Update(id int, updatedTask *entities.Task) *apperrors.AppError {
-	response := make(chan Result, 1)
-
-	op := Operation{
-		Type:     OpUpdate,
-		TaskID:   id,
-		Task:     updatedTask,
-		Response: response,
-	}
-
-	cs.operations <- op
-	result := <-response
-	if result.Error != nil {
-		return apperrors.ErrStorageError.WithCause(fmt.Errorf("Update failed from channel result: %v", result.Error))
-	}
-	return nil
+	result := cs.call(Operation{Type: OpUpdate, TaskID: id, Task: updatedTask})
+	return appError(OpUpdate, result.Error)
 }
 
 // Delete removes a task from the store
 func (cs *ChannelStore) Delete(id int) *apperrors.AppError {
-	response := make(chan Result, 1)
-
-	op := Operation{
-		Type:     OpDelete,
-		TaskID:   id,
-		Response: response,
-	}
-
-	cs.operations <- op
-	result := <-response
-	if result.Error != nil {
-		return apperrors.ErrStorageError.WithCause(fmt.Errorf("Delete failed from channel result: %v", result.Error))
-	}
-	return nil
+	result := cs.call(Operation{Type: OpDelete, TaskID: id})
+	return appError(OpDelete, result.Error)
 }
 
 // GetAll retrieves all tasks
 func (cs *ChannelStore) GetAll() []*entities.Task {
-	response := make(chan Result, 1)
-
-	op := Operation{
-		Type:     OpGetAll,
-		Response: response,
+	result := cs.call(Operation{Type: OpGetAll})
+	if result.Error != nil {
+		return []*entities.Task{}
 	}
 
-	cs.operations <- op
-	result := <-response
+	return result.Tasks
+}
 
+// GetRange retrieves tasks with fromID <= ID <= toID in ID order
+func (cs *ChannelStore) GetRange(fromID, toID, limit int) []*entities.Task {
+	result := cs.call(Operation{Type: OpRange, TaskID: fromID, ToID: toID, Limit: limit})
 	if result.Error != nil {
 		return []*entities.Task{}
 	}
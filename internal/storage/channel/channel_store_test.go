@@ -1,7 +1,11 @@
 package channel
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
 	"testing"
 )
 
@@ -61,6 +65,9 @@ func TestChannelStore_GetByID_NotFound(t *testing.T) {
 	if err == nil {
 		t.Fatal("Expected error for non-existent task")
 	}
+	if err.Code != apperrors.ErrCodeTaskNotFound {
+		t.Errorf("Expected task not found, got %v", err)
+	}
 }
 
 func TestChannelStore_Update(t *testing.T) {
@@ -114,6 +121,9 @@ func TestChannelStore_Update_NotFound(t *testing.T) {
 	if err == nil {
 		t.Fatal("Expected error for non-existent task")
 	}
+	if err.Code != apperrors.ErrCodeTaskNotFound {
+		t.Errorf("Expected task not found, got %v", err)
+	}
 }
 
 func TestChannelStore_Delete(t *testing.T) {
@@ -151,6 +161,34 @@ func TestChannelStore_Delete_NotFound(t *testing.T) {
 	if err == nil {
 		t.Fatal("Expected error for non-existent task")
 	}
+	if err.Code != apperrors.ErrCodeTaskNotFound {
+		t.Errorf("Expected task not found, got %v", err)
+	}
+}
+
+func TestAppError_KeepsCodes(t *testing.T) {
+	notFound := apperrors.ErrTaskNotFound.WithMessage("Task 7 not found")
+	if err := appError(OpRead, notFound); err != notFound {
+		t.Errorf("Expected the AppError as is, got %v", err)
+	}
+	if err := appError(OpUpdate, fmt.Errorf("worker: %w", apperrors.ErrTaskNameTooLong)); err == nil || err.Code != apperrors.ErrCodeTaskNameTooLong {
+		t.Errorf("Expected a wrapped AppError unwrapped, got %v", err)
+	}
+	if err := appError(OpDelete, errors.New("disk on fire")); err == nil || err.Code != apperrors.ErrCodeStorageError || !strings.Contains(err.Error(), "disk on fire") {
+		t.Errorf("Expected other errors as storage errors, got %v", err)
+	}
+	if err := appError(OpCreate, nil); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestChannelStore_CreateNil(t *testing.T) {
+	store := NewChannelStore(1)
+	defer store.Shutdown()
+
+	if err := store.Create(nil); err == nil || err != apperrors.ErrTaskCannotBeNil {
+		t.Errorf("Expected a nil task rejected, got %v", err)
+	}
 }
 
 func TestChannelStore_GetAll(t *testing.T) {
@@ -253,3 +291,21 @@ func TestChannelStore_IDGeneration(t *testing.T) {
 		}
 	}
 }
+
+func TestChannelStore_GetRange(t *testing.T) {
+	store := NewChannelStore(4)
+	defer store.Shutdown()
+
+	for i := 0; i < 6; i++ {
+		store.Create(&entities.Task{Name: "task"})
+	}
+	store.Delete(3)
+
+	tasks := store.GetRange(2, 5, 2)
+	if len(tasks) != 2 || tasks[0].ID != 2 || tasks[1].ID != 4 {
+		t.Errorf("Expected tasks 2 and 4, got %+v", tasks)
+	}
+	if tasks := store.GetRange(7, 10, 0); len(tasks) != 0 {
+		t.Errorf("Expected empty range, got %+v", tasks)
+	}
+}
@@ -0,0 +1,21 @@
+package storage
+
+import "tasks-service-demo/internal/entities"
+
+// Collection stores the entities of one resource type other than tasks,
+// such as projects or labels. The in-memory backends provide it on the
+// same machinery as their task stores: shard.Collection for the sharded
+// and memory backends, xsync.Collection for xsync. Build one with
+// backends.NewCollection, which picks it by STORAGE_TYPE.
+//
+// Unlike Store it reports a missing ID with false, so each resource's
+// service returns its own not-found error.
+type Collection[T entities.Entity] interface {
+	Create(item T) int // Assigns and returns the ID
+	Get(id int) (T, bool)
+	Update(id int, item T) bool        // False if id does not exist
+	Delete(id int) bool                // False if id does not exist
+	All() []T                          // In no particular order
+	Range(fromID, toID, limit int) []T // In ID order; limit <= 0 means no limit
+	Len() int
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"strings"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+)
+
+// Consistency is how fresh the reads of a request must be
+type Consistency string
+
+const (
+	// Eventual reads may be served by caches and replicas, which can lag
+	// writes made past them
+	Eventual Consistency = "eventual"
+	// Strong reads see every write that completed before they started
+	Strong Consistency = "strong"
+)
+
+// ParseConsistency parses a consistency level, ignoring case
+func ParseConsistency(s string) (Consistency, bool) {
+	switch c := Consistency(strings.ToLower(strings.TrimSpace(s))); c {
+	case Eventual, Strong:
+		return c, true
+	}
+	return "", false
+}
+
+type consistencyKey struct{}
+
+// WithConsistency returns ctx carrying the consistency level of a request
+func WithConsistency(ctx context.Context, c Consistency) context.Context {
+	return context.WithValue(ctx, consistencyKey{}, c)
+}
+
+// ConsistencyFrom returns the consistency level carried by ctx, Eventual if none
+func ConsistencyFrom(ctx context.Context) Consistency {
+	if c, ok := ctx.Value(consistencyKey{}).(Consistency); ok {
+		return c
+	}
+	return Eventual
+}
+
+// StrongReader is implemented by decorators that may serve reads from a copy
+// that lags the store they wrap, such as caches and replicas. Decorators that
+// serve reads from anything but the store they wrap must implement it too, so
+// GetByIDStrong doesn't look past them.
+type StrongReader interface {
+	// GetByIDStrong reads id past the copy, usually with GetByIDStrong on
+	// the wrapped store
+	GetByIDStrong(id int) (*entities.Task, *apperrors.AppError)
+}
+
+// GetByIDStrong reads id with the first StrongReader in the decorator chain,
+// and with GetByID when no decorator keeps a copy. Caches only serve GetByID,
+// so lists need no such path; replicas that serve lists read the level from
+// the request context (see WithConsistency).
+func GetByIDStrong(store Store, id int) (*entities.Task, *apperrors.AppError) {
+	if reader, ok := find[StrongReader](store); ok {
+		return reader.GetByIDStrong(id)
+	}
+	return store.GetByID(id)
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage/naive"
+)
+
+type strongStore struct {
+	Store
+	strong int
+}
+
+func (s *strongStore) GetByIDStrong(id int) (*entities.Task, *apperrors.AppError) {
+	s.strong++
+	return s.GetByID(id)
+}
+
+func TestGetByIDStrong(t *testing.T) {
+	backend := &strongStore{Store: naive.NewMemoryStore()}
+	if _, err := GetByIDStrong(wrappingStore{wrappingStore{backend}}, 1); err != apperrors.ErrTaskNotFound || backend.strong != 1 {
+		t.Errorf("Expected a strong read of the wrapped store, got %v after %d strong reads", err, backend.strong)
+	}
+
+	plain := naive.NewMemoryStore()
+	plain.Create(&entities.Task{Name: "a"})
+	if task, err := GetByIDStrong(wrappingStore{plain}, 1); err != nil || task.Name != "a" {
+		t.Errorf("Expected GetByID for a store without copies, got %v, %v", task, err)
+	}
+}
+
+func TestConsistency(t *testing.T) {
+	if c := ConsistencyFrom(context.Background()); c != Eventual {
+		t.Errorf("Expected eventual by default, got %s", c)
+	}
+	if c := ConsistencyFrom(WithConsistency(context.Background(), Strong)); c != Strong {
+		t.Errorf("Expected strong, got %s", c)
+	}
+	for s, expected := range map[string]Consistency{"strong": Strong, " Eventual ": Eventual, "STRONG": Strong} {
+		if c, ok := ParseConsistency(s); !ok || c != expected {
+			t.Errorf("Expected %q to parse as %s, got %s, %t", s, expected, c, ok)
+		}
+	}
+	if _, ok := ParseConsistency("linearizable"); ok {
+		t.Error("Expected an unknown level to be rejected")
+	}
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+)
+
+// ContextStore is a Store whose operations honour a request context. Backends
+// may implement it natively; WithContext adapts any other Store.
+type ContextStore interface {
+	CreateContext(ctx context.Context, task *entities.Task) *apperrors.AppError
+	GetByIDContext(ctx context.Context, id int) (*entities.Task, *apperrors.AppError)
+	GetAllContext(ctx context.Context) ([]*entities.Task, *apperrors.AppError)
+	GetRangeContext(ctx context.Context, fromID, toID, limit int) ([]*entities.Task, *apperrors.AppError)
+	UpdateContext(ctx context.Context, id int, task *entities.Task) *apperrors.AppError
+	DeleteContext(ctx context.Context, id int) *apperrors.AppError
+}
+
+// WithContext returns store as a ContextStore. Stores that don't implement it
+// are wrapped: every operation is refused once ctx is done, and reads that
+// finish after the deadline are discarded. In-memory operations can't be
+// interrupted, so a write that has started always completes.
+func WithContext(store Store) ContextStore {
+	if cs, ok := store.(ContextStore); ok {
+		return cs
+	}
+	return contextStore{store}
+}
+
+type contextStore struct {
+	Store
+}
+
+func (s contextStore) CreateContext(ctx context.Context, task *entities.Task) *apperrors.AppError {
+	if err := ContextError(ctx); err != nil {
+		return err
+	}
+	return s.Create(task)
+}
+
+func (s contextStore) GetByIDContext(ctx context.Context, id int) (*entities.Task, *apperrors.AppError) {
+	if err := ContextError(ctx); err != nil {
+		return nil, err
+	}
+	task, appErr := s.GetByID(id)
+	if err := ContextError(ctx); err != nil {
+		return nil, err
+	}
+	return task, appErr
+}
+
+func (s contextStore) GetAllContext(ctx context.Context) ([]*entities.Task, *apperrors.AppError) {
+	if err := ContextError(ctx); err != nil {
+		return nil, err
+	}
+	tasks := s.GetAll()
+	if err := ContextError(ctx); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (s contextStore) GetRangeContext(ctx context.Context, fromID, toID, limit int) ([]*entities.Task, *apperrors.AppError) {
+	if err := ContextError(ctx); err != nil {
+		return nil, err
+	}
+	tasks := s.GetRange(fromID, toID, limit)
+	if err := ContextError(ctx); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (s contextStore) UpdateContext(ctx context.Context, id int, task *entities.Task) *apperrors.AppError {
+	if err := ContextError(ctx); err != nil {
+		return err
+	}
+	return s.Update(id, task)
+}
+
+func (s contextStore) DeleteContext(ctx context.Context, id int) *apperrors.AppError {
+	if err := ContextError(ctx); err != nil {
+		return err
+	}
+	return s.Delete(id)
+}
+
+// ContextError returns ErrTimeout once ctx is done, or nil
+func ContextError(ctx context.Context) *apperrors.AppError {
+	if err := ctx.Err(); err != nil {
+		return apperrors.ErrTimeout.WithCause(err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage/naive"
+)
+
+func TestWithContext(t *testing.T) {
+	store := WithContext(naive.NewMemoryStore())
+	ctx := context.Background()
+
+	task := &entities.Task{Name: "live"}
+	if err := store.CreateContext(ctx, task); err != nil {
+		t.Fatalf("Expected create to succeed, got %v", err)
+	}
+	if got, err := store.GetByIDContext(ctx, task.ID); err != nil || got.Name != "live" {
+		t.Errorf("Expected task live, got %+v, %v", got, err)
+	}
+
+	expired, cancel := context.WithCancel(ctx)
+	cancel()
+
+	checks := map[string]*apperrors.AppError{
+		"create": store.CreateContext(expired, &entities.Task{Name: "late"}),
+		"update": store.UpdateContext(expired, task.ID, &entities.Task{Name: "late"}),
+		"delete": store.DeleteContext(expired, task.ID),
+	}
+	_, checks["get"] = store.GetByIDContext(expired, task.ID)
+	_, checks["list"] = store.GetAllContext(expired)
+
+	for op, err := range checks {
+		if err == nil || err.Code != apperrors.ErrCodeTimeout {
+			t.Errorf("Expected %s to fail with code %d, got %v", op, apperrors.ErrCodeTimeout, err)
+		}
+	}
+
+	// Nothing was written after the context was done
+	tasks, _ := store.GetAllContext(ctx)
+	if len(tasks) != 1 || tasks[0].Name != "live" {
+		t.Errorf("Expected only the live task, got %+v", tasks)
+	}
+}
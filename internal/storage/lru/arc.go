@@ -0,0 +1,173 @@
+package lru
+
+import "container/list"
+
+// arcEvictor implements the Adaptive Replacement Cache (Megiddo & Modha).
+// T1 holds entries seen once recently, T2 entries seen at least twice;
+// B1/B2 are ghost lists of recently evicted keys that steer the target size p
+// of T1 towards whichever side is producing hits.
+type arcEvictor struct {
+	size int
+	p    int // Target size of T1
+
+	t1, t2, b1, b2 *list.List
+	items          map[int]*list.Element // Resident entries in T1/T2 (values *entry)
+	ghosts         map[int]*list.Element // Ghost keys in B1/B2 (values int)
+	where          map[int]*list.List    // Which list each key currently lives in
+}
+
+func newARC(size int) *arcEvictor {
+	return &arcEvictor{
+		size:   size,
+		t1:     list.New(),
+		t2:     list.New(),
+		b1:     list.New(),
+		b2:     list.New(),
+		items:  make(map[int]*list.Element, size),
+		ghosts: make(map[int]*list.Element, size),
+		where:  make(map[int]*list.List, 2*size),
+	}
+}
+
+func (c *arcEvictor) get(id int) (*entry, bool) {
+	el, ok := c.items[id]
+	if !ok {
+		return nil, false
+	}
+
+	// Any hit promotes the entry to the frequency side
+	e := el.Value.(*entry)
+	c.where[id].Remove(el)
+	c.items[id] = c.t2.PushFront(e)
+	c.where[id] = c.t2
+	return e, true
+}
+
+func (c *arcEvictor) set(e *entry) int {
+	id := e.id
+
+	// Resident: update in place and count as an access
+	if el, ok := c.items[id]; ok {
+		el.Value = e
+		c.get(id)
+		return 0
+	}
+
+	evicted := 0
+
+	// Ghost hit in B1: recency side was too small
+	if el, ok := c.ghosts[id]; ok && c.where[id] == c.b1 {
+		delta := 1
+		if c.b1.Len() < c.b2.Len() {
+			delta = c.b2.Len() / c.b1.Len()
+		}
+		c.p = min(c.p+delta, c.size)
+		evicted += c.replace(false)
+		c.b1.Remove(el)
+		delete(c.ghosts, id)
+		c.push(c.t2, e)
+		return evicted
+	}
+
+	// Ghost hit in B2: frequency side was too small
+	if el, ok := c.ghosts[id]; ok && c.where[id] == c.b2 {
+		delta := 1
+		if c.b2.Len() < c.b1.Len() {
+			delta = c.b1.Len() / c.b2.Len()
+		}
+		c.p = max(c.p-delta, 0)
+		evicted += c.replace(true)
+		c.b2.Remove(el)
+		delete(c.ghosts, id)
+		c.push(c.t2, e)
+		return evicted
+	}
+
+	// Brand new key
+	l1 := c.t1.Len() + c.b1.Len()
+	total := l1 + c.t2.Len() + c.b2.Len()
+	if l1 >= c.size {
+		if c.t1.Len() < c.size {
+			c.dropGhost(c.b1)
+			evicted += c.replace(false)
+		} else {
+			// B1 is empty and T1 is full: evict straight from T1
+			evicted += c.evict(c.t1, nil)
+		}
+	} else if total >= c.size {
+		if total >= 2*c.size {
+			c.dropGhost(c.b2)
+		}
+		evicted += c.replace(false)
+	}
+
+	c.push(c.t1, e)
+	return evicted
+}
+
+// replace evicts one resident entry from T1 or T2 into its ghost list
+func (c *arcEvictor) replace(inB2 bool) int {
+	if c.t1.Len()+c.t2.Len() < c.size {
+		return 0
+	}
+	if c.t1.Len() > 0 && (c.t1.Len() > c.p || (inB2 && c.t1.Len() == c.p)) {
+		return c.evict(c.t1, c.b1)
+	}
+	if c.t2.Len() > 0 {
+		return c.evict(c.t2, c.b2)
+	}
+	return c.evict(c.t1, c.b1)
+}
+
+// evict removes the LRU entry of from, remembering its key in ghost (if any)
+func (c *arcEvictor) evict(from, ghost *list.List) int {
+	victim := from.Back()
+	if victim == nil {
+		return 0
+	}
+	id := victim.Value.(*entry).id
+	from.Remove(victim)
+	delete(c.items, id)
+	delete(c.where, id)
+
+	if ghost != nil {
+		c.ghosts[id] = ghost.PushFront(id)
+		c.where[id] = ghost
+	}
+	return 1
+}
+
+// dropGhost forgets the oldest key of a ghost list
+func (c *arcEvictor) dropGhost(ghost *list.List) {
+	oldest := ghost.Back()
+	if oldest == nil {
+		return
+	}
+	id := oldest.Value.(int)
+	ghost.Remove(oldest)
+	delete(c.ghosts, id)
+	delete(c.where, id)
+}
+
+func (c *arcEvictor) push(to *list.List, e *entry) {
+	c.items[e.id] = to.PushFront(e)
+	c.where[e.id] = to
+}
+
+func (c *arcEvictor) remove(id int) {
+	if el, ok := c.items[id]; ok {
+		c.where[id].Remove(el)
+		delete(c.items, id)
+		delete(c.where, id)
+		return
+	}
+	if el, ok := c.ghosts[id]; ok {
+		c.where[id].Remove(el)
+		delete(c.ghosts, id)
+		delete(c.where, id)
+	}
+}
+
+func (c *arcEvictor) len() int {
+	return len(c.items)
+}
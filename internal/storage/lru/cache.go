@@ -0,0 +1,269 @@
+package lru
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage"
+)
+
+// Package lru provides a storage.Store decorator that caches GetByID results
+// in process with LRU, LFU or ARC eviction and optional TTL. Misses can be
+// cached too, so repeated lookups of nonexistent IDs skip the inner store.
+
+// DefaultSize is the cache capacity used when Config.Size is not positive
+const DefaultSize = 10000
+
+// Config controls the cache decorator
+type Config struct {
+	Policy Policy        // Eviction policy (default: LRU)
+	Size   int           // Maximum number of cached tasks
+	TTL    time.Duration // Entry lifetime; 0 disables expiry
+
+	NegativeTTL  time.Duration // How long a not-found result is remembered; 0 disables negative caching
+	NegativeSize int           // Maximum remembered missing IDs
+}
+
+// Stats counts cache activity since creation
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Expired   uint64 `json:"expired"`
+	Entries   int    `json:"entries"`
+
+	NegativeHits    uint64 `json:"negativeHits"`    // GetByID calls answered not-found from the negative cache
+	NegativeEntries int    `json:"negativeEntries"` // Missing IDs currently remembered
+}
+
+// CachedStore wraps a storage.Store and serves GetByID from an in-process cache.
+// Writes go to the inner store first; Create caches the new task while Update
+// and Delete invalidate, so the cache never holds data the inner store rejected.
+type CachedStore struct {
+	inner  storage.Store
+	policy Policy
+	ttl    time.Duration
+
+	mu       sync.Mutex // Guards cache and negative; eviction bookkeeping mutates on reads too
+	cache    evictor
+	negative *negativeCache // nil when negative caching is disabled
+	writeSeq uint64         // Bumped by every write so racing read-fills can be discarded
+
+	hits, misses, evictions, expired, negativeHits atomic.Uint64
+}
+
+// NewCachedStore wraps inner with a cache configured by cfg
+func NewCachedStore(inner storage.Store, cfg Config) *CachedStore {
+	if cfg.Policy == "" {
+		cfg.Policy = PolicyLRU
+	}
+	if cfg.Size <= 0 {
+		cfg.Size = DefaultSize
+	}
+
+	s := &CachedStore{
+		inner:  inner,
+		policy: cfg.Policy,
+		ttl:    cfg.TTL,
+		cache:  newEvictor(cfg.Policy, cfg.Size),
+	}
+	if cfg.NegativeTTL > 0 {
+		if cfg.NegativeSize <= 0 {
+			cfg.NegativeSize = DefaultNegativeSize
+		}
+		s.negative = newNegativeCache(cfg.NegativeTTL, cfg.NegativeSize)
+	}
+	return s
+}
+
+// Inner returns the wrapped store
+func (s *CachedStore) Inner() storage.Store {
+	return s.inner
+}
+
+// Policy returns the configured eviction policy
+func (s *CachedStore) Policy() Policy {
+	return s.policy
+}
+
+func (s *CachedStore) newEntry(task *entities.Task) *entry {
+	e := &entry{id: task.ID, task: task}
+	if s.ttl > 0 {
+		e.expiresAt = time.Now().Add(s.ttl)
+	}
+	return e
+}
+
+// put caches a task after a successful write
+func (s *CachedStore) put(task *entities.Task) {
+	e := s.newEntry(task)
+
+	s.mu.Lock()
+	s.writeSeq++
+	evicted := s.cache.set(e)
+	if s.negative != nil {
+		s.negative.remove(task.ID) // The ID exists now
+	}
+	s.mu.Unlock()
+
+	s.evictions.Add(uint64(evicted))
+}
+
+// fill caches a task read from the inner store unless a write happened since seq,
+// in which case the read may be stale (e.g. a concurrent Delete)
+func (s *CachedStore) fill(task *entities.Task, seq uint64) {
+	e := s.newEntry(task)
+
+	s.mu.Lock()
+	if s.writeSeq != seq {
+		s.mu.Unlock()
+		return
+	}
+	evicted := s.cache.set(e)
+	s.mu.Unlock()
+
+	s.evictions.Add(uint64(evicted))
+}
+
+// fillMissing remembers that id was not found unless a write happened since
+// seq, in which case the task may have been created meanwhile. A cached copy
+// is dropped, as a strong read may find the task gone from the inner store.
+func (s *CachedStore) fillMissing(id int, seq uint64) {
+	s.mu.Lock()
+	if s.writeSeq == seq {
+		s.cache.remove(id)
+		if s.negative != nil {
+			s.negative.add(id, time.Now())
+		}
+	}
+	s.mu.Unlock()
+}
+
+// drop removes a cached task after a write
+func (s *CachedStore) drop(id int) {
+	s.mu.Lock()
+	s.writeSeq++
+	s.cache.remove(id)
+	s.mu.Unlock()
+}
+
+// Create stores the task in the inner store and caches it
+func (s *CachedStore) Create(task *entities.Task) *apperrors.AppError {
+	if err := s.inner.Create(task); err != nil {
+		return err
+	}
+	s.put(task)
+	return nil
+}
+
+// GetByID serves the task from cache, falling back to the inner store on a miss.
+// Not-found results are cached only when negative caching is enabled.
+func (s *CachedStore) GetByID(id int) (*entities.Task, *apperrors.AppError) {
+	s.mu.Lock()
+	e, ok := s.cache.get(id)
+	if ok && !e.expiresAt.IsZero() && time.Now().After(e.expiresAt) {
+		s.cache.remove(id)
+		ok = false
+		s.expired.Add(1)
+	}
+	missing := !ok && s.negative != nil && s.negative.has(id, time.Now())
+	seq := s.writeSeq
+	s.mu.Unlock()
+
+	if ok {
+		s.hits.Add(1)
+		return e.task, nil
+	}
+	if missing {
+		s.negativeHits.Add(1)
+		return nil, apperrors.ErrTaskNotFound
+	}
+
+	s.misses.Add(1)
+	task, err := s.inner.GetByID(id)
+	if err == apperrors.ErrTaskNotFound {
+		s.fillMissing(id, seq)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.fill(task, seq)
+	return task, nil
+}
+
+// GetByIDStrong reads past the cache and refreshes the cached copy with the
+// result, so changes the cache missed, such as backend expiry, are picked up
+func (s *CachedStore) GetByIDStrong(id int) (*entities.Task, *apperrors.AppError) {
+	s.mu.Lock()
+	seq := s.writeSeq
+	s.mu.Unlock()
+
+	task, err := storage.GetByIDStrong(s.inner, id)
+	if err == apperrors.ErrTaskNotFound {
+		s.fillMissing(id, seq)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.fill(task, seq)
+	return task, nil
+}
+
+// GetAll bypasses the cache
+func (s *CachedStore) GetAll() []*entities.Task {
+	return s.inner.GetAll()
+}
+
+// GetRange bypasses the cache
+func (s *CachedStore) GetRange(fromID, toID, limit int) []*entities.Task {
+	return s.inner.GetRange(fromID, toID, limit)
+}
+
+// Update writes through to the inner store and invalidates the cached entry.
+// The next GetByID refills it, which keeps concurrent updates from leaving
+// an older value cached.
+func (s *CachedStore) Update(id int, task *entities.Task) *apperrors.AppError {
+	err := s.inner.Update(id, task)
+	s.drop(id)
+	return err
+}
+
+// Delete removes the task from the inner store and the cache
+func (s *CachedStore) Delete(id int) *apperrors.AppError {
+	err := s.inner.Delete(id)
+	s.drop(id)
+	return err
+}
+
+// Close closes the inner store if it supports closing
+func (s *CachedStore) Close() error {
+	if closer, ok := s.inner.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Stats returns a snapshot of the cache counters
+func (s *CachedStore) Stats() Stats {
+	s.mu.Lock()
+	entries := s.cache.len()
+	negative := 0
+	if s.negative != nil {
+		negative = s.negative.len()
+	}
+	s.mu.Unlock()
+
+	return Stats{
+		Hits:      s.hits.Load(),
+		Misses:    s.misses.Load(),
+		Evictions: s.evictions.Load(),
+		Expired:   s.expired.Load(),
+		Entries:   entries,
+
+		NegativeHits:    s.negativeHits.Load(),
+		NegativeEntries: negative,
+	}
+}
@@ -0,0 +1,276 @@
+package lru
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/storetest"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCachedStore_ImplementsStore(t *testing.T) {
+	var _ storage.Store = NewCachedStore(storetest.NewFakeStore(), Config{})
+}
+
+func TestCachedStore_HitAndMiss(t *testing.T) {
+	for _, policy := range []Policy{PolicyLRU, PolicyLFU, PolicyARC} {
+		t.Run(string(policy), func(t *testing.T) {
+			inner := storetest.NewFakeStore(&entities.Task{ID: 1, Name: "seeded"})
+			store := NewCachedStore(inner, Config{Policy: policy, Size: 10})
+
+			for i := 0; i < 3; i++ {
+				task, err := store.GetByID(1)
+				if err != nil || task.Name != "seeded" {
+					t.Fatalf("Unexpected result %v, %v", task, err)
+				}
+			}
+
+			stats := store.Stats()
+			if stats.Misses != 1 || stats.Hits != 2 || stats.Entries != 1 {
+				t.Errorf("Expected 1 miss, 2 hits, 1 entry; got %+v", stats)
+			}
+		})
+	}
+}
+
+func TestCachedStore_CreateIsCached(t *testing.T) {
+	mock := storetest.NewMockStore()
+	store := NewCachedStore(mock, Config{Size: 10})
+
+	task := &entities.Task{ID: 5, Name: "new"}
+	store.Create(task)
+
+	if got, err := store.GetByID(5); err != nil || got != task {
+		t.Errorf("Expected created task from cache, got %v, %v", got, err)
+	}
+	if mock.CallCount(storetest.MethodGetByID) != 0 {
+		t.Error("Expected no inner GetByID call for a freshly created task")
+	}
+}
+
+func TestCachedStore_NotFoundIsNotCached(t *testing.T) {
+	mock := storetest.NewMockStore()
+	store := NewCachedStore(mock, Config{Size: 10})
+
+	for i := 0; i < 2; i++ {
+		if _, err := store.GetByID(42); err != apperrors.ErrTaskNotFound {
+			t.Errorf("Expected ErrTaskNotFound, got %v", err)
+		}
+	}
+	if mock.CallCount(storetest.MethodGetByID) != 2 {
+		t.Errorf("Expected every not-found lookup to reach the inner store, got %d", mock.CallCount(storetest.MethodGetByID))
+	}
+}
+
+func TestCachedStore_NegativeCache(t *testing.T) {
+	mock := storetest.NewMockStore()
+	store := NewCachedStore(mock, Config{Size: 10, NegativeTTL: time.Hour})
+
+	for i := 0; i < 3; i++ {
+		if _, err := store.GetByID(42); err != apperrors.ErrTaskNotFound {
+			t.Errorf("Expected ErrTaskNotFound, got %v", err)
+		}
+	}
+	if mock.CallCount(storetest.MethodGetByID) != 1 {
+		t.Errorf("Expected repeated misses to be absorbed, got %d inner lookups", mock.CallCount(storetest.MethodGetByID))
+	}
+	if stats := store.Stats(); stats.NegativeHits != 2 || stats.NegativeEntries != 1 {
+		t.Errorf("Expected 2 negative hits and 1 entry, got %+v", stats)
+	}
+
+	// Creating the ID invalidates the negative entry
+	task := &entities.Task{ID: 42, Name: "now exists"}
+	store.Create(task)
+	if got, err := store.GetByID(42); err != nil || got != task {
+		t.Errorf("Expected created task, got %v, %v", got, err)
+	}
+	if stats := store.Stats(); stats.NegativeEntries != 0 {
+		t.Errorf("Expected negative entry to be dropped, got %+v", stats)
+	}
+}
+
+func TestCachedStore_NegativeCacheExpiresAndIsBounded(t *testing.T) {
+	mock := storetest.NewMockStore()
+	store := NewCachedStore(mock, Config{Size: 10, NegativeTTL: 20 * time.Millisecond, NegativeSize: 2})
+
+	for id := 1; id <= 3; id++ {
+		store.GetByID(id)
+	}
+	if stats := store.Stats(); stats.NegativeEntries != 2 {
+		t.Errorf("Expected the oldest missing ID to be evicted, got %+v", stats)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	store.GetByID(3)
+	if mock.CallCount(storetest.MethodGetByID) != 4 {
+		t.Errorf("Expected expired entry to reach the inner store, got %d lookups", mock.CallCount(storetest.MethodGetByID))
+	}
+}
+
+func TestCachedStore_WritesInvalidate(t *testing.T) {
+	inner := storetest.NewFakeStore()
+	store := NewCachedStore(inner, Config{Size: 10})
+
+	task := &entities.Task{Name: "v1"}
+	store.Create(task)
+
+	store.Update(task.ID, &entities.Task{Name: "v2", Status: 1})
+	if got, _ := store.GetByID(task.ID); got.Name != "v2" {
+		t.Errorf("Expected updated name 'v2', got '%s'", got.Name)
+	}
+
+	store.Delete(task.ID)
+	if _, err := store.GetByID(task.ID); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected ErrTaskNotFound after delete, got %v", err)
+	}
+
+	if err := store.Update(task.ID, &entities.Task{Name: "v3"}); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected inner error to propagate from Update, got %v", err)
+	}
+}
+
+func TestCachedStore_GetByIDStrong(t *testing.T) {
+	inner := storetest.NewFakeStore(&entities.Task{ID: 1, Name: "v1"})
+	store := NewCachedStore(inner, Config{Size: 10})
+	store.GetByID(1)
+
+	// Changes made past the cache are only seen by strong reads, which
+	// refresh the cached copy
+	inner.Update(1, &entities.Task{ID: 1, Name: "v2"})
+	if got, _ := store.GetByID(1); got.Name != "v1" {
+		t.Errorf("Expected the cached 'v1', got '%s'", got.Name)
+	}
+	if got, err := store.GetByIDStrong(1); err != nil || got.Name != "v2" {
+		t.Errorf("Expected 'v2' from the inner store, got %+v, %v", got, err)
+	}
+	if got, _ := store.GetByID(1); got.Name != "v2" {
+		t.Errorf("Expected the refreshed 'v2', got '%s'", got.Name)
+	}
+
+	inner.Delete(1)
+	if _, err := store.GetByIDStrong(1); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+	if _, err := store.GetByID(1); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected the cached copy to be dropped, got %v", err)
+	}
+}
+
+func TestCachedStore_TTL(t *testing.T) {
+	inner := storetest.NewFakeStore(&entities.Task{ID: 1, Name: "seeded"})
+	store := NewCachedStore(inner, Config{Size: 10, TTL: 10 * time.Millisecond})
+
+	store.GetByID(1)
+	time.Sleep(20 * time.Millisecond)
+	store.GetByID(1)
+
+	stats := store.Stats()
+	if stats.Expired != 1 || stats.Misses != 2 {
+		t.Errorf("Expected 1 expiry and 2 misses, got %+v", stats)
+	}
+}
+
+func TestCachedStore_SizeLimit(t *testing.T) {
+	inner := storetest.NewFakeStore()
+	store := NewCachedStore(inner, Config{Size: 3})
+
+	for i := 0; i < 10; i++ {
+		store.Create(&entities.Task{Name: "t"})
+	}
+
+	stats := store.Stats()
+	if stats.Entries != 3 || stats.Evictions != 7 {
+		t.Errorf("Expected 3 entries and 7 evictions, got %+v", stats)
+	}
+}
+
+func TestCachedStore_StaleFillDiscarded(t *testing.T) {
+	inner := storetest.NewFakeStore(&entities.Task{ID: 1, Name: "seeded"})
+	mock := storetest.NewMockStore()
+	release := make(chan struct{})
+	mock.GetByIDFunc = func(id int) (*entities.Task, *apperrors.AppError) {
+		task, err := inner.GetByID(id)
+		<-release
+		return task, err
+	}
+	mock.DeleteFunc = inner.Delete
+	store := NewCachedStore(mock, Config{Size: 10})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		store.GetByID(1) // Reads the task, then blocks before filling the cache
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	store.Delete(1)
+	close(release)
+	wg.Wait()
+
+	mock.GetByIDFunc = inner.GetByID
+	if _, err := store.GetByID(1); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected deleted task not to be resurrected by a racing read, got %v", err)
+	}
+}
+
+func TestCachedStore_Concurrent(t *testing.T) {
+	inner := storetest.NewFakeStore()
+	for i := 0; i < 100; i++ {
+		inner.Create(&entities.Task{Name: "t"})
+	}
+
+	for _, policy := range []Policy{PolicyLRU, PolicyLFU, PolicyARC} {
+		store := NewCachedStore(inner, Config{Policy: policy, Size: 32})
+
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < 1000; i++ {
+					id := (i*7+g)%100 + 1
+					if i%10 == 0 {
+						store.Update(id, &entities.Task{Name: "u"})
+					} else {
+						store.GetByID(id)
+					}
+				}
+			}(g)
+		}
+		wg.Wait()
+
+		if entries := store.Stats().Entries; entries > 32 {
+			t.Errorf("%s: cache exceeded size limit with %d entries", policy, entries)
+		}
+	}
+}
+
+func TestCollector(t *testing.T) {
+	store := NewCachedStore(storetest.NewFakeStore(&entities.Task{ID: 1, Name: "x"}), Config{Policy: PolicyARC})
+	store.GetByID(1)
+	store.GetByID(1)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewCollector("tasks_service", store))
+
+	expected := `
+# HELP tasks_service_cache_hits_total GetByID calls served from the cache.
+# TYPE tasks_service_cache_hits_total counter
+tasks_service_cache_hits_total{policy="arc"} 1
+# HELP tasks_service_cache_misses_total GetByID calls that fell through to the inner store.
+# TYPE tasks_service_cache_misses_total counter
+tasks_service_cache_misses_total{policy="arc"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"tasks_service_cache_hits_total", "tasks_service_cache_misses_total"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,118 @@
+package lru
+
+import "container/list"
+
+// lfuEvictor evicts the least frequently used entry in O(1), breaking ties by
+// evicting the least recently used entry among those with the lowest count.
+type lfuEvictor struct {
+	size    int
+	items   map[int]*list.Element // Element values are *lfuNode
+	buckets map[int]*list.List    // Access count -> nodes, front is most recent
+	minFreq int
+}
+
+type lfuNode struct {
+	entry *entry
+	freq  int
+}
+
+func newLFU(size int) *lfuEvictor {
+	return &lfuEvictor{
+		size:    size,
+		items:   make(map[int]*list.Element, size),
+		buckets: make(map[int]*list.List),
+	}
+}
+
+// touch moves a node into the next frequency bucket
+func (c *lfuEvictor) touch(el *list.Element) *list.Element {
+	node := el.Value.(*lfuNode)
+	bucket := c.buckets[node.freq]
+	bucket.Remove(el)
+	if bucket.Len() == 0 {
+		delete(c.buckets, node.freq)
+		if c.minFreq == node.freq {
+			c.minFreq++
+		}
+	}
+
+	node.freq++
+	next, ok := c.buckets[node.freq]
+	if !ok {
+		next = list.New()
+		c.buckets[node.freq] = next
+	}
+	el = next.PushFront(node)
+	c.items[node.entry.id] = el
+	return el
+}
+
+func (c *lfuEvictor) get(id int) (*entry, bool) {
+	el, ok := c.items[id]
+	if !ok {
+		return nil, false
+	}
+	el = c.touch(el)
+	return el.Value.(*lfuNode).entry, true
+}
+
+func (c *lfuEvictor) set(e *entry) int {
+	if el, ok := c.items[e.id]; ok {
+		el.Value.(*lfuNode).entry = e
+		c.touch(el)
+		return 0
+	}
+
+	evicted := 0
+	if len(c.items) >= c.size {
+		bucket := c.buckets[c.minFreq]
+		victim := bucket.Back()
+		bucket.Remove(victim)
+		if bucket.Len() == 0 {
+			delete(c.buckets, c.minFreq)
+		}
+		delete(c.items, victim.Value.(*lfuNode).entry.id)
+		evicted++
+	}
+
+	bucket, ok := c.buckets[1]
+	if !ok {
+		bucket = list.New()
+		c.buckets[1] = bucket
+	}
+	c.items[e.id] = bucket.PushFront(&lfuNode{entry: e, freq: 1})
+	c.minFreq = 1
+	return evicted
+}
+
+func (c *lfuEvictor) remove(id int) {
+	el, ok := c.items[id]
+	if !ok {
+		return
+	}
+
+	node := el.Value.(*lfuNode)
+	bucket := c.buckets[node.freq]
+	bucket.Remove(el)
+	if bucket.Len() == 0 {
+		delete(c.buckets, node.freq)
+		if c.minFreq == node.freq {
+			c.recomputeMinFreq()
+		}
+	}
+	delete(c.items, id)
+}
+
+// recomputeMinFreq is only needed after explicit removals, which are rare
+func (c *lfuEvictor) recomputeMinFreq() {
+	c.minFreq = 0
+	for freq := range c.buckets {
+		if c.minFreq == 0 || freq < c.minFreq {
+			c.minFreq = freq
+		}
+	}
+}
+
+func (c *lfuEvictor) len() int {
+	return len(c.items)
+}
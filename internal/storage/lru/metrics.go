@@ -0,0 +1,57 @@
+package lru
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collector exports CachedStore stats as Prometheus metrics
+type collector struct {
+	store *CachedStore
+
+	hits, misses, evictions, expired, entries *prometheus.Desc
+	negativeHits, negativeEntries             *prometheus.Desc
+}
+
+// NewCollector returns a Prometheus collector for the cache counters, labelled
+// with the eviction policy. Register it once per CachedStore.
+func NewCollector(namespace string, store *CachedStore) prometheus.Collector {
+	labels := prometheus.Labels{"policy": string(store.policy)}
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "cache", name), help, nil, labels)
+	}
+
+	return &collector{
+		store:     store,
+		hits:      desc("hits_total", "GetByID calls served from the cache."),
+		misses:    desc("misses_total", "GetByID calls that fell through to the inner store."),
+		evictions: desc("evictions_total", "Entries evicted to respect the size limit."),
+		expired:   desc("expired_total", "Entries dropped because their TTL elapsed."),
+		entries:   desc("entries", "Entries currently resident in the cache."),
+
+		negativeHits:    desc("negative_hits_total", "GetByID calls answered not-found from the negative cache."),
+		negativeEntries: desc("negative_entries", "Missing IDs currently remembered by the negative cache."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.evictions
+	ch <- c.expired
+	ch <- c.entries
+	ch <- c.negativeHits
+	ch <- c.negativeEntries
+}
+
+// Collect implements prometheus.Collector
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.store.Stats()
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(stats.Evictions))
+	ch <- prometheus.MustNewConstMetric(c.expired, prometheus.CounterValue, float64(stats.Expired))
+	ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(stats.Entries))
+	ch <- prometheus.MustNewConstMetric(c.negativeHits, prometheus.CounterValue, float64(stats.NegativeHits))
+	ch <- prometheus.MustNewConstMetric(c.negativeEntries, prometheus.GaugeValue, float64(stats.NegativeEntries))
+}
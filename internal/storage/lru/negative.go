@@ -0,0 +1,63 @@
+package lru
+
+import (
+	"container/list"
+	"time"
+)
+
+// DefaultNegativeSize is the negative cache capacity used when
+// Config.NegativeSize is not positive
+const DefaultNegativeSize = 1000
+
+// negativeEntry remembers that an ID was not found
+type negativeEntry struct {
+	id        int
+	expiresAt time.Time
+}
+
+// negativeCache holds recently missing IDs in insertion order, so the
+// oldest (and soonest to expire) entry is evicted first. Not safe for
+// concurrent use; CachedStore serializes access.
+type negativeCache struct {
+	ttl   time.Duration
+	size  int
+	order *list.List // Oldest at the front
+	items map[int]*list.Element
+}
+
+func newNegativeCache(ttl time.Duration, size int) *negativeCache {
+	return &negativeCache{ttl: ttl, size: size, order: list.New(), items: make(map[int]*list.Element)}
+}
+
+// has reports whether id is known missing at now, dropping an expired entry
+func (c *negativeCache) has(id int, now time.Time) bool {
+	el, ok := c.items[id]
+	if !ok {
+		return false
+	}
+	if now.After(el.Value.(*negativeEntry).expiresAt) {
+		c.remove(id)
+		return false
+	}
+	return true
+}
+
+// add records id as missing until now+ttl
+func (c *negativeCache) add(id int, now time.Time) {
+	c.remove(id)
+	c.items[id] = c.order.PushBack(&negativeEntry{id: id, expiresAt: now.Add(c.ttl)})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Front().Value.(*negativeEntry).id)
+	}
+}
+
+func (c *negativeCache) remove(id int) {
+	if el, ok := c.items[id]; ok {
+		c.order.Remove(el)
+		delete(c.items, id)
+	}
+}
+
+func (c *negativeCache) len() int {
+	return c.order.Len()
+}
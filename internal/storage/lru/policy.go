@@ -0,0 +1,109 @@
+package lru
+
+import (
+	"container/list"
+	"fmt"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+)
+
+// Policy selects the eviction algorithm of a CachedStore
+type Policy string
+
+const (
+	PolicyLRU Policy = "lru" // Least recently used
+	PolicyLFU Policy = "lfu" // Least frequently used (ties broken by recency)
+	PolicyARC Policy = "arc" // Adaptive replacement cache (recency + frequency)
+)
+
+// ParsePolicy converts a configuration string into a Policy
+func ParsePolicy(s string) (Policy, error) {
+	switch Policy(s) {
+	case PolicyLRU, PolicyLFU, PolicyARC:
+		return Policy(s), nil
+	default:
+		return "", fmt.Errorf("unknown cache policy %q (want lru, lfu or arc)", s)
+	}
+}
+
+// entry is a cached task with its optional expiry
+type entry struct {
+	id        int
+	task      *entities.Task
+	expiresAt time.Time // Zero means no TTL
+}
+
+// evictor is the eviction algorithm behind a CachedStore.
+// Implementations are not safe for concurrent use; CachedStore serializes access.
+type evictor interface {
+	get(id int) (*entry, bool)  // Looks up and records an access
+	set(e *entry) (evicted int) // Inserts or replaces, returns number of evicted entries
+	remove(id int)              // Drops an entry if present
+	len() int                   // Number of resident entries
+}
+
+func newEvictor(policy Policy, size int) evictor {
+	switch policy {
+	case PolicyLFU:
+		return newLFU(size)
+	case PolicyARC:
+		return newARC(size)
+	default:
+		return newLRU(size)
+	}
+}
+
+// lruEvictor evicts the least recently used entry
+type lruEvictor struct {
+	size  int
+	order *list.List // Front is most recently used
+	items map[int]*list.Element
+}
+
+func newLRU(size int) *lruEvictor {
+	return &lruEvictor{
+		size:  size,
+		order: list.New(),
+		items: make(map[int]*list.Element, size),
+	}
+}
+
+func (c *lruEvictor) get(id int) (*entry, bool) {
+	el, ok := c.items[id]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*entry), true
+}
+
+func (c *lruEvictor) set(e *entry) int {
+	if el, ok := c.items[e.id]; ok {
+		el.Value = e
+		c.order.MoveToFront(el)
+		return 0
+	}
+
+	c.items[e.id] = c.order.PushFront(e)
+
+	evicted := 0
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*entry).id)
+		evicted++
+	}
+	return evicted
+}
+
+func (c *lruEvictor) remove(id int) {
+	if el, ok := c.items[id]; ok {
+		c.order.Remove(el)
+		delete(c.items, id)
+	}
+}
+
+func (c *lruEvictor) len() int {
+	return len(c.items)
+}
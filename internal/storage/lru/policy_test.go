@@ -0,0 +1,165 @@
+package lru
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func set(c evictor, id int) int {
+	return c.set(&entry{id: id})
+}
+
+func has(c evictor, id int) bool {
+	_, ok := c.get(id)
+	return ok
+}
+
+func TestParsePolicy(t *testing.T) {
+	for _, s := range []string{"lru", "lfu", "arc"} {
+		if p, err := ParsePolicy(s); err != nil || string(p) != s {
+			t.Errorf("Expected %s to parse, got %v, %v", s, p, err)
+		}
+	}
+	if _, err := ParsePolicy("fifo"); err == nil {
+		t.Error("Expected error for unknown policy")
+	}
+}
+
+func TestLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := newLRU(2)
+	set(c, 1)
+	set(c, 2)
+	has(c, 1) // 2 is now least recently used
+
+	if evicted := set(c, 3); evicted != 1 {
+		t.Errorf("Expected 1 eviction, got %d", evicted)
+	}
+	if !has(c, 1) || has(c, 2) || !has(c, 3) {
+		t.Error("Expected key 2 to be evicted")
+	}
+}
+
+func TestLFU_EvictsLeastFrequentlyUsed(t *testing.T) {
+	c := newLFU(2)
+	set(c, 1)
+	set(c, 2)
+	has(c, 1)
+	has(c, 1)
+	has(c, 2) // 1 has higher frequency than 2
+
+	set(c, 3)
+	if !has(c, 1) || has(c, 2) || !has(c, 3) {
+		t.Error("Expected key 2 (lowest frequency) to be evicted")
+	}
+}
+
+func TestLFU_TieBrokenByRecency(t *testing.T) {
+	c := newLFU(2)
+	set(c, 1)
+	set(c, 2)
+
+	set(c, 3) // 1 and 2 both have frequency 1; 1 is older
+	if has(c, 1) || !has(c, 2) || !has(c, 3) {
+		t.Error("Expected key 1 (oldest among least frequent) to be evicted")
+	}
+}
+
+func TestLFU_RemoveKeepsMinFreqConsistent(t *testing.T) {
+	c := newLFU(2)
+	set(c, 1)
+	set(c, 2)
+	has(c, 2)
+	c.remove(1) // min frequency bucket is now empty
+
+	set(c, 3)
+	set(c, 4) // must evict 3 (freq 1), not panic on a missing bucket
+	if !has(c, 2) || has(c, 3) || !has(c, 4) {
+		t.Error("Expected key 3 to be evicted after removal")
+	}
+}
+
+func TestARC_ScanResistance(t *testing.T) {
+	c := newARC(4)
+
+	// Establish a frequently used working set
+	for round := 0; round < 3; round++ {
+		for id := 1; id <= 2; id++ {
+			if !has(c, id) {
+				set(c, id)
+			}
+		}
+	}
+
+	// A one-off scan should not flush the frequent keys
+	for id := 100; id < 110; id++ {
+		set(c, id)
+	}
+
+	if !has(c, 1) || !has(c, 2) {
+		t.Error("Expected frequently used keys to survive a scan")
+	}
+}
+
+func TestARC_GhostHitAdaptsTarget(t *testing.T) {
+	c := newARC(2)
+	set(c, 1)
+	set(c, 2)
+	has(c, 1) // 1 moves to T2, leaving 2 alone in T1
+	set(c, 3) // evicts 2 from T1 into B1
+
+	if _, ghost := c.ghosts[2]; !ghost || c.where[2] != c.b1 {
+		t.Fatal("Expected evicted key to be remembered in B1")
+	}
+
+	set(c, 2) // ghost hit grows the recency target
+	if c.p == 0 {
+		t.Error("Expected p to grow after a B1 ghost hit")
+	}
+	if !has(c, 2) {
+		t.Error("Expected ghost-hit key to be resident")
+	}
+}
+
+func TestEvictors_Invariants(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for _, policy := range []Policy{PolicyLRU, PolicyLFU, PolicyARC} {
+		t.Run(string(policy), func(t *testing.T) {
+			const size = 16
+			c := newEvictor(policy, size)
+			resident := 0
+
+			for i := 0; i < 20000; i++ {
+				id := rng.Intn(64)
+				switch op := rng.Intn(10); {
+				case op < 6:
+					has(c, id)
+				case op < 9:
+					before := c.len()
+					_, existed := c.get(id)
+					evicted := set(c, id)
+					if !existed && c.len() != before+1-evicted {
+						t.Fatalf("Length accounting broken: before=%d evicted=%d after=%d", before, evicted, c.len())
+					}
+				default:
+					c.remove(id)
+					if has(c, id) {
+						t.Fatalf("Removed key %d still present", id)
+					}
+				}
+
+				resident = c.len()
+				if resident > size {
+					t.Fatalf("Cache grew to %d entries, limit %d", resident, size)
+				}
+			}
+
+			if arc, ok := c.(*arcEvictor); ok {
+				if arc.t1.Len()+arc.b1.Len() > size || arc.t1.Len()+arc.t2.Len()+arc.b1.Len()+arc.b2.Len() > 2*size {
+					t.Errorf("ARC directory bounds violated: t1=%d t2=%d b1=%d b2=%d",
+						arc.t1.Len(), arc.t2.Len(), arc.b1.Len(), arc.b2.Len())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+)
+
+// ManyGetter is implemented by stores that can look up a batch of IDs more
+// cheaply than one GetByID each, such as sharded stores that read each
+// shard once. Decorators that serve reads from anything but the store they
+// wrap must implement it too, so GetMany doesn't look past them.
+type ManyGetter interface {
+	// GetMany returns the tasks with the given IDs and the IDs not found,
+	// both in the order of ids. The IDs are unique.
+	GetMany(ids []int) (found []*entities.Task, missing []int, err *apperrors.AppError)
+}
+
+// GetMany looks up ids, ignoring repeats, with the first ManyGetter in the
+// decorator chain and with one GetByID per ID otherwise. Found tasks and
+// missing IDs keep the order of ids. Errors other than not-found abort the
+// lookup.
+func GetMany(store Store, ids []int) (found []*entities.Task, missing []int, err *apperrors.AppError) {
+	ids = unique(ids)
+	if getter, ok := find[ManyGetter](store); ok {
+		return getter.GetMany(ids)
+	}
+
+	found = make([]*entities.Task, 0, len(ids))
+	missing = make([]int, 0)
+	for _, id := range ids {
+		task, err := store.GetByID(id)
+		switch {
+		case err == nil:
+			found = append(found, task)
+		case err.Code == apperrors.ErrCodeTaskNotFound:
+			missing = append(missing, id)
+		default:
+			return nil, nil, err
+		}
+	}
+	return found, missing, nil
+}
+
+// unique drops repeated IDs, keeping the first of each
+func unique(ids []int) []int {
+	seen := make(map[int]struct{}, len(ids))
+	result := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			result = append(result, id)
+		}
+	}
+	return result
+}
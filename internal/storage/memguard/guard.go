@@ -0,0 +1,333 @@
+package memguard
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage"
+)
+
+// Package memguard provides a storage.Store decorator that tracks the
+// approximate memory held by an in-memory store and, once a configured limit
+// is reached, either rejects writes or evicts tasks to make room.
+
+// taskOverhead approximates the fixed per-task cost: the Task struct, its
+// pointer and the map entry holding it in the backing store
+const taskOverhead = 96
+
+// Policy decides what happens when a write would exceed the limit
+type Policy string
+
+const (
+	PolicyReject    Policy = "reject"    // Fail the write with ErrStorageFull
+	PolicyOldest    Policy = "oldest"    // Evict least recently written tasks
+	PolicyCompleted Policy = "completed" // Evict completed tasks first, then oldest
+)
+
+// ParsePolicy converts a configuration string into a Policy
+func ParsePolicy(s string) (Policy, error) {
+	switch Policy(s) {
+	case PolicyReject, PolicyOldest, PolicyCompleted:
+		return Policy(s), nil
+	default:
+		return "", fmt.Errorf("unknown storage full policy %q (want reject, oldest or completed)", s)
+	}
+}
+
+// ParseSize converts a byte size such as "512MB", "64KiB" or "1048576" into
+// bytes. Decimal (KB, MB, GB) and binary (KiB, MiB, GiB) suffixes are accepted.
+func ParseSize(s string) (int64, error) {
+	units := []struct {
+		suffix string
+		scale  int64
+	}{
+		{"KIB", 1 << 10}, {"MIB", 1 << 20}, {"GIB", 1 << 30},
+		{"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9}, {"B", 1},
+	}
+
+	str := strings.ToUpper(strings.TrimSpace(s))
+	scale := int64(1)
+	for _, u := range units {
+		if strings.HasSuffix(str, u.suffix) {
+			str, scale = strings.TrimSpace(strings.TrimSuffix(str, u.suffix)), u.scale
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(str, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * scale, nil
+}
+
+// Config sets the guardrail limits; a zero limit is not enforced
+type Config struct {
+	MaxBytes int64  // Approximate memory limit for stored tasks
+	MaxTasks int    // Maximum number of stored tasks
+	Policy   Policy // Behaviour once a limit is reached (default: reject)
+}
+
+// Stats is a snapshot of tracked usage and guardrail activity
+type Stats struct {
+	Tasks    int    `json:"tasks"`
+	Bytes    int64  `json:"bytes"`
+	MaxBytes int64  `json:"maxBytes"`
+	MaxTasks int    `json:"maxTasks"`
+	Rejected uint64 `json:"rejected"`
+	Evicted  uint64 `json:"evicted"`
+}
+
+// tracked is the bookkeeping kept for every stored task
+type tracked struct {
+	id        int
+	size      int64
+	completed bool
+	seq       uint64 // Write order; larger is more recent
+}
+
+// GuardedStore wraps a storage.Store and enforces Config limits on writes.
+// Writes are serialized so usage accounting and evictions stay consistent;
+// reads go straight to the inner store.
+type GuardedStore struct {
+	inner storage.Store
+	cfg   Config
+
+	mu      sync.Mutex
+	pending *list.List // Incomplete tasks, least recently written at the front
+	done    *list.List // Completed tasks, least recently written at the front
+	items   map[int]*list.Element
+	bytes   int64
+	seq     uint64
+
+	rejected, evicted atomic.Uint64
+}
+
+// NewGuardedStore wraps inner with the limits in cfg. Tasks already present
+// in inner are accounted for, oldest ID first.
+func NewGuardedStore(inner storage.Store, cfg Config) *GuardedStore {
+	if cfg.Policy == "" {
+		cfg.Policy = PolicyReject
+	}
+
+	s := &GuardedStore{
+		inner:   inner,
+		cfg:     cfg,
+		pending: list.New(),
+		done:    list.New(),
+		items:   make(map[int]*list.Element),
+	}
+
+	existing := inner.GetAll()
+	sort.Slice(existing, func(i, j int) bool { return existing[i].ID < existing[j].ID })
+	for _, task := range existing {
+		s.track(task)
+	}
+	return s
+}
+
+// Inner returns the wrapped store
+func (s *GuardedStore) Inner() storage.Store {
+	return s.inner
+}
+
+// Policy returns the configured behaviour once a limit is reached
+func (s *GuardedStore) Policy() Policy {
+	return s.cfg.Policy
+}
+
+// sizeOf approximates the memory held by a stored task
+func sizeOf(task *entities.Task) int64 {
+	return taskOverhead + int64(len(task.Name))
+}
+
+// track records a task as the most recently written one
+func (s *GuardedStore) track(task *entities.Task) {
+	s.seq++
+	t := &tracked{id: task.ID, size: sizeOf(task), completed: task.Status == 1, seq: s.seq}
+	if t.completed {
+		s.items[t.id] = s.done.PushBack(t)
+	} else {
+		s.items[t.id] = s.pending.PushBack(t)
+	}
+	s.bytes += t.size
+}
+
+// untrack drops a task from the bookkeeping if present
+func (s *GuardedStore) untrack(id int) {
+	el, ok := s.items[id]
+	if !ok {
+		return
+	}
+	t := el.Value.(*tracked)
+	if t.completed {
+		s.done.Remove(el)
+	} else {
+		s.pending.Remove(el)
+	}
+	delete(s.items, id)
+	s.bytes -= t.size
+}
+
+// fits reports whether usage after adding tasks and bytes stays within limits
+func (s *GuardedStore) fits(tasks int, bytes int64) bool {
+	if s.cfg.MaxTasks > 0 && len(s.items)+tasks > s.cfg.MaxTasks {
+		return false
+	}
+	if s.cfg.MaxBytes > 0 && s.bytes+bytes > s.cfg.MaxBytes {
+		return false
+	}
+	return true
+}
+
+// victim picks the next task to evict according to the policy, skipping keep
+func (s *GuardedStore) victim(keep int) *tracked {
+	pending, done := oldestIn(s.pending, keep), oldestIn(s.done, keep)
+	switch {
+	case done == nil:
+		return pending
+	case pending == nil:
+		return done
+	case s.cfg.Policy == PolicyCompleted || done.seq < pending.seq:
+		return done
+	default:
+		return pending
+	}
+}
+
+// oldestIn returns the least recently written task in l other than keep
+func oldestIn(l *list.List, keep int) *tracked {
+	for el := l.Front(); el != nil; el = el.Next() {
+		if t := el.Value.(*tracked); t.id != keep {
+			return t
+		}
+	}
+	return nil
+}
+
+// makeRoom enforces the limits for a write adding tasks and bytes, evicting
+// when the policy allows. keep is never evicted (the task being updated).
+func (s *GuardedStore) makeRoom(tasks int, bytes int64, keep int) *apperrors.AppError {
+	for !s.fits(tasks, bytes) {
+		if s.cfg.Policy == PolicyReject {
+			s.rejected.Add(1)
+			return apperrors.ErrStorageFull
+		}
+
+		t := s.victim(keep)
+		if t == nil {
+			// Nothing left to evict; the write alone exceeds the limit
+			s.rejected.Add(1)
+			return apperrors.ErrStorageFull
+		}
+		s.inner.Delete(t.id)
+		s.untrack(t.id)
+		s.evicted.Add(1)
+	}
+	return nil
+}
+
+// Create stores the task if it fits, evicting first when the policy allows
+func (s *GuardedStore) Create(task *entities.Task) *apperrors.AppError {
+	if task == nil {
+		return s.inner.Create(task)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.makeRoom(1, sizeOf(task), 0); err != nil {
+		return err
+	}
+	if err := s.inner.Create(task); err != nil {
+		return err
+	}
+	s.track(task)
+	return nil
+}
+
+// GetByID reads from the inner store
+func (s *GuardedStore) GetByID(id int) (*entities.Task, *apperrors.AppError) {
+	return s.inner.GetByID(id)
+}
+
+// GetAll reads from the inner store
+func (s *GuardedStore) GetAll() []*entities.Task {
+	return s.inner.GetAll()
+}
+
+// GetRange reads from the inner store
+func (s *GuardedStore) GetRange(fromID, toID, limit int) []*entities.Task {
+	return s.inner.GetRange(fromID, toID, limit)
+}
+
+// Update replaces the task if the size change fits, evicting other tasks first
+// when the policy allows
+func (s *GuardedStore) Update(id int, task *entities.Task) *apperrors.AppError {
+	if task == nil {
+		return s.inner.Update(id, task)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Unknown IDs go straight through so the inner store reports not-found
+	// without evicting anything first
+	if el, ok := s.items[id]; ok {
+		if growth := sizeOf(task) - el.Value.(*tracked).size; growth > 0 {
+			if err := s.makeRoom(0, growth, id); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := s.inner.Update(id, task); err != nil {
+		return err
+	}
+	s.untrack(id)
+	s.track(task)
+	return nil
+}
+
+// Delete removes the task and releases its accounted memory
+func (s *GuardedStore) Delete(id int) *apperrors.AppError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.inner.Delete(id); err != nil {
+		return err
+	}
+	s.untrack(id)
+	return nil
+}
+
+// Close closes the inner store if it supports closing
+func (s *GuardedStore) Close() error {
+	if closer, ok := s.inner.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Stats returns a snapshot of tracked usage and guardrail counters
+func (s *GuardedStore) Stats() Stats {
+	s.mu.Lock()
+	tasks, bytes := len(s.items), s.bytes
+	s.mu.Unlock()
+
+	return Stats{
+		Tasks:    tasks,
+		Bytes:    bytes,
+		MaxBytes: s.cfg.MaxBytes,
+		MaxTasks: s.cfg.MaxTasks,
+		Rejected: s.rejected.Load(),
+		Evicted:  s.evicted.Load(),
+	}
+}
@@ -0,0 +1,250 @@
+package memguard
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/storetest"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestGuardedStore_ImplementsStore(t *testing.T) {
+	var _ storage.Store = NewGuardedStore(storetest.NewFakeStore(), Config{})
+}
+
+func TestParsePolicy(t *testing.T) {
+	for _, valid := range []string{"reject", "oldest", "completed"} {
+		if p, err := ParsePolicy(valid); err != nil || string(p) != valid {
+			t.Errorf("Expected %q to parse, got %q, %v", valid, p, err)
+		}
+	}
+	if _, err := ParsePolicy("newest"); err == nil {
+		t.Error("Expected error for unknown policy")
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"1024", 1024},
+		{"10B", 10},
+		{"2KB", 2000},
+		{"64KiB", 64 << 10},
+		{"512mb", 512e6},
+		{"1 GiB", 1 << 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseSize(tt.in)
+			if err != nil || got != tt.want {
+				t.Errorf("Expected %d, got %d, %v", tt.want, got, err)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"", "MB", "-1", "1.5GB", "ten"} {
+		if _, err := ParseSize(invalid); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
+
+func TestGuardedStore_TracksUsage(t *testing.T) {
+	inner := storetest.NewFakeStore(&entities.Task{ID: 1, Name: "seeded"})
+	store := NewGuardedStore(inner, Config{})
+
+	if stats := store.Stats(); stats.Tasks != 1 || stats.Bytes != taskOverhead+6 {
+		t.Errorf("Expected seeded task to be accounted for, got %+v", stats)
+	}
+
+	task := &entities.Task{Name: "abc"}
+	store.Create(task)
+	store.Update(task.ID, &entities.Task{Name: "abcdef"})
+	if stats := store.Stats(); stats.Tasks != 2 || stats.Bytes != 2*taskOverhead+12 {
+		t.Errorf("Expected 2 tasks and %d bytes, got %+v", 2*taskOverhead+12, stats)
+	}
+
+	store.Delete(1)
+	store.Delete(task.ID)
+	if stats := store.Stats(); stats.Tasks != 0 || stats.Bytes != 0 {
+		t.Errorf("Expected empty usage after deletes, got %+v", stats)
+	}
+}
+
+func TestGuardedStore_RejectPolicy(t *testing.T) {
+	store := NewGuardedStore(storetest.NewFakeStore(), Config{MaxTasks: 2, Policy: PolicyReject})
+
+	for i := 0; i < 2; i++ {
+		if err := store.Create(&entities.Task{Name: "task"}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	err := store.Create(&entities.Task{Name: "one too many"})
+	if err == nil || err.Code != apperrors.ErrCodeStorageFull {
+		t.Fatalf("Expected ErrStorageFull, got %v", err)
+	}
+	if stats := store.Stats(); stats.Tasks != 2 || stats.Rejected != 1 {
+		t.Errorf("Expected 2 tasks and 1 rejection, got %+v", stats)
+	}
+
+	// Deleting frees room again
+	store.Delete(1)
+	if err := store.Create(&entities.Task{Name: "fits again"}); err != nil {
+		t.Errorf("Expected create to succeed after delete, got %v", err)
+	}
+}
+
+func TestGuardedStore_RejectsGrowingUpdate(t *testing.T) {
+	limit := int64(2*taskOverhead + 10)
+	store := NewGuardedStore(storetest.NewFakeStore(), Config{MaxBytes: limit})
+
+	store.Create(&entities.Task{Name: "aaaaa"})
+	store.Create(&entities.Task{Name: "bbbbb"})
+
+	err := store.Update(1, &entities.Task{Name: "aaaaaa"})
+	if err == nil || err.Code != apperrors.ErrCodeStorageFull {
+		t.Fatalf("Expected ErrStorageFull for growing update, got %v", err)
+	}
+	if err := store.Update(1, &entities.Task{Name: "a"}); err != nil {
+		t.Errorf("Expected shrinking update to succeed, got %v", err)
+	}
+}
+
+func TestGuardedStore_OldestPolicy(t *testing.T) {
+	inner := storetest.NewFakeStore()
+	store := NewGuardedStore(inner, Config{MaxTasks: 3, Policy: PolicyOldest})
+
+	for i := 0; i < 3; i++ {
+		store.Create(&entities.Task{Name: "task"})
+	}
+	// Rewriting task 1 makes task 2 the least recently written
+	store.Update(1, &entities.Task{Name: "task", Status: 1})
+
+	if err := store.Create(&entities.Task{Name: "task 4"}); err != nil {
+		t.Fatalf("Expected create to evict, got %v", err)
+	}
+
+	if _, err := inner.GetByID(2); err == nil {
+		t.Error("Expected task 2 to be evicted")
+	}
+	for _, id := range []int{1, 3, 4} {
+		if _, err := inner.GetByID(id); err != nil {
+			t.Errorf("Expected task %d to remain, got %v", id, err)
+		}
+	}
+	if stats := store.Stats(); stats.Evicted != 1 || stats.Tasks != 3 {
+		t.Errorf("Expected 1 eviction and 3 tasks, got %+v", stats)
+	}
+}
+
+func TestGuardedStore_CompletedPolicy(t *testing.T) {
+	inner := storetest.NewFakeStore()
+	store := NewGuardedStore(inner, Config{MaxTasks: 3, Policy: PolicyCompleted})
+
+	store.Create(&entities.Task{Name: "pending 1"})
+	store.Create(&entities.Task{Name: "done 2", Status: 1})
+	store.Create(&entities.Task{Name: "pending 3"})
+
+	// First eviction takes the completed task even though task 1 is older
+	store.Create(&entities.Task{Name: "pending 4"})
+	if _, err := inner.GetByID(2); err == nil {
+		t.Error("Expected completed task 2 to be evicted first")
+	}
+
+	// With no completed tasks left it falls back to the oldest
+	store.Create(&entities.Task{Name: "pending 5"})
+	if _, err := inner.GetByID(1); err == nil {
+		t.Error("Expected oldest task 1 to be evicted next")
+	}
+	if inner.Len() != 3 {
+		t.Errorf("Expected 3 tasks, got %d", inner.Len())
+	}
+}
+
+func TestGuardedStore_TaskLargerThanLimit(t *testing.T) {
+	store := NewGuardedStore(storetest.NewFakeStore(), Config{MaxBytes: taskOverhead, Policy: PolicyOldest})
+
+	store.Create(&entities.Task{})
+	err := store.Create(&entities.Task{Name: "too big on its own"})
+	if err == nil || err.Code != apperrors.ErrCodeStorageFull {
+		t.Fatalf("Expected ErrStorageFull, got %v", err)
+	}
+	if stats := store.Stats(); stats.Tasks != 0 || stats.Evicted != 1 {
+		t.Errorf("Expected the existing task evicted before giving up, got %+v", stats)
+	}
+}
+
+func TestGuardedStore_UpdateUnknownIDDoesNotEvict(t *testing.T) {
+	store := NewGuardedStore(storetest.NewFakeStore(), Config{MaxTasks: 1, Policy: PolicyOldest})
+	store.Create(&entities.Task{Name: "task"})
+
+	err := store.Update(99, &entities.Task{Name: "missing"})
+	if err == nil || err.Code != apperrors.ErrCodeTaskNotFound {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+	if stats := store.Stats(); stats.Tasks != 1 || stats.Evicted != 0 {
+		t.Errorf("Expected no eviction, got %+v", stats)
+	}
+}
+
+func TestGuardedStore_Concurrent(t *testing.T) {
+	inner := storetest.NewFakeStore()
+	store := NewGuardedStore(inner, Config{MaxTasks: 50, Policy: PolicyOldest})
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				task := &entities.Task{Name: "task", Status: i % 2}
+				if err := store.Create(task); err != nil {
+					t.Errorf("Unexpected error: %v", err)
+					return
+				}
+				store.Update(task.ID, &entities.Task{Name: "updated"})
+				if i%3 == 0 {
+					store.Delete(task.ID)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := store.Stats()
+	if stats.Tasks > 50 || stats.Tasks != inner.Len() {
+		t.Errorf("Expected tracked tasks (%d) to match inner store (%d) within limit", stats.Tasks, inner.Len())
+	}
+}
+
+func TestCollector(t *testing.T) {
+	store := NewGuardedStore(storetest.NewFakeStore(), Config{MaxTasks: 1})
+	store.Create(&entities.Task{Name: "abcd"})
+	store.Create(&entities.Task{Name: "rejected"})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewCollector("test", store))
+
+	expected := `
+# HELP test_storage_rejected_writes_total Writes rejected because a storage limit was reached.
+# TYPE test_storage_rejected_writes_total counter
+test_storage_rejected_writes_total{policy="reject"} 1
+# HELP test_storage_tasks Tasks currently held by the store.
+# TYPE test_storage_tasks gauge
+test_storage_tasks{policy="reject"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"test_storage_rejected_writes_total", "test_storage_tasks"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,52 @@
+package memguard
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collector exports GuardedStore stats as Prometheus metrics
+type collector struct {
+	store *GuardedStore
+
+	tasks, bytes, maxBytes, maxTasks, rejected, evicted *prometheus.Desc
+}
+
+// NewCollector returns a Prometheus collector for the guardrail usage and
+// counters, labelled with the policy. Register it once per GuardedStore.
+func NewCollector(namespace string, store *GuardedStore) prometheus.Collector {
+	labels := prometheus.Labels{"policy": string(store.cfg.Policy)}
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "storage", name), help, nil, labels)
+	}
+
+	return &collector{
+		store:    store,
+		tasks:    desc("tasks", "Tasks currently held by the store."),
+		bytes:    desc("bytes", "Approximate memory held by stored tasks."),
+		maxBytes: desc("limit_bytes", "Configured memory limit; 0 when not enforced."),
+		maxTasks: desc("limit_tasks", "Configured task limit; 0 when not enforced."),
+		rejected: desc("rejected_writes_total", "Writes rejected because a storage limit was reached."),
+		evicted:  desc("evictions_total", "Tasks evicted to stay within the storage limits."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.tasks
+	ch <- c.bytes
+	ch <- c.maxBytes
+	ch <- c.maxTasks
+	ch <- c.rejected
+	ch <- c.evicted
+}
+
+// Collect implements prometheus.Collector
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.store.Stats()
+	ch <- prometheus.MustNewConstMetric(c.tasks, prometheus.GaugeValue, float64(stats.Tasks))
+	ch <- prometheus.MustNewConstMetric(c.bytes, prometheus.GaugeValue, float64(stats.Bytes))
+	ch <- prometheus.MustNewConstMetric(c.maxBytes, prometheus.GaugeValue, float64(stats.MaxBytes))
+	ch <- prometheus.MustNewConstMetric(c.maxTasks, prometheus.GaugeValue, float64(stats.MaxTasks))
+	ch <- prometheus.MustNewConstMetric(c.rejected, prometheus.CounterValue, float64(stats.Rejected))
+	ch <- prometheus.MustNewConstMetric(c.evicted, prometheus.CounterValue, float64(stats.Evicted))
+}
@@ -0,0 +1,108 @@
+package migrate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Package migrate brings data persisted by an older build up to the format
+// the running build writes. A durable backend declares its Format: the
+// oldest version it can read and one Step per later version. The backend
+// records the version it wrote in a header, and on load runs the steps from
+// the recorded version to the current one; data written by a newer build is
+// refused rather than misread.
+
+var (
+	ErrNewer       = errors.New("data was written by a newer build")
+	ErrUnsupported = errors.New("data format is too old to migrate")
+)
+
+// Step upgrades one unit of data, such as a record or a value, from the
+// version before it to its version
+type Step[T any] struct {
+	Name string // What changed, for logs
+	Up   func(T) (T, error)
+}
+
+// Format is a versioned persisted format. Step i upgrades version base+i to
+// base+i+1, so the current version is base plus the number of steps.
+type Format[T any] struct {
+	name  string
+	base  int
+	steps []Step[T]
+}
+
+// NewFormat returns the format called name whose oldest readable version is
+// base, upgraded by steps in order
+func NewFormat[T any](name string, base int, steps ...Step[T]) *Format[T] {
+	return &Format[T]{name: name, base: base, steps: steps}
+}
+
+// Name returns the name of the format
+func (f *Format[T]) Name() string {
+	return f.name
+}
+
+// Current returns the version the running build writes
+func (f *Format[T]) Current() int {
+	return f.base + len(f.steps)
+}
+
+// Check reports whether data of version can be migrated to the current one
+func (f *Format[T]) Check(version int) error {
+	switch {
+	case version > f.Current():
+		return fmt.Errorf("%s format version %d, this build reads up to %d: %w", f.name, version, f.Current(), ErrNewer)
+	case version < f.base:
+		return fmt.Errorf("%s format version %d, this build reads from %d: %w", f.name, version, f.base, ErrUnsupported)
+	}
+	return nil
+}
+
+// Pending returns the names of the steps that upgrade data of version
+func (f *Format[T]) Pending(version int) []string {
+	if f.Check(version) != nil {
+		return nil
+	}
+	names := make([]string, 0, f.Current()-version)
+	for _, step := range f.steps[version-f.base:] {
+		names = append(names, step.Name)
+	}
+	return names
+}
+
+// Migrate upgrades v from version to the current version
+func (f *Format[T]) Migrate(v T, version int) (T, error) {
+	if err := f.Check(version); err != nil {
+		return v, err
+	}
+	for i, step := range f.steps[version-f.base:] {
+		var err error
+		if v, err = step.Up(v); err != nil {
+			return v, fmt.Errorf("%s format version %d to %d (%s): %w", f.name, version+i, version+i+1, step.Name, err)
+		}
+	}
+	return v, nil
+}
+
+// JSON returns a step that upgrades a JSON object by editing it as a map.
+// Numbers are kept as json.Number so IDs survive unrounded.
+func JSON(name string, up func(doc map[string]any) error) Step[[]byte] {
+	return Step[[]byte]{
+		Name: name,
+		Up: func(data []byte) ([]byte, error) {
+			dec := json.NewDecoder(bytes.NewReader(data))
+			dec.UseNumber()
+			var doc map[string]any
+			if err := dec.Decode(&doc); err != nil {
+				return nil, err
+			}
+			if err := up(doc); err != nil {
+				return nil, err
+			}
+			return json.Marshal(doc)
+		},
+	}
+}
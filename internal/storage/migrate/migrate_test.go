@@ -0,0 +1,67 @@
+package migrate
+
+import (
+	"errors"
+	"testing"
+)
+
+// taskFormat chains two steps over a made-up document: version 1 called the
+// name "title" and version 2 kept completion as a "done" flag. The formats
+// of the backends are tested against files written by their older builds,
+// in their own packages.
+var taskFormat = NewFormat[[]byte]("task", 1,
+	JSON("rename title to name", func(doc map[string]any) error {
+		doc["name"] = doc["title"]
+		delete(doc, "title")
+		return nil
+	}),
+	JSON("replace done with status", func(doc map[string]any) error {
+		done, _ := doc["done"].(bool)
+		delete(doc, "done")
+		doc["status"] = 0
+		if done {
+			doc["status"] = 1
+		}
+		return nil
+	}),
+)
+
+func TestMigrateSteps(t *testing.T) {
+	want := `{"id":9007199254740993,"name":"Large ID","status":1}`
+	docs := []string{
+		`{"id":9007199254740993,"title":"Large ID","done":true}`,
+		`{"id":9007199254740993,"name":"Large ID","done":true}`,
+		want,
+	}
+	for i, doc := range docs {
+		version := i + 1
+		if len(taskFormat.Pending(version)) != taskFormat.Current()-version {
+			t.Errorf("v%d: Expected %d pending steps, got %v", version, taskFormat.Current()-version, taskFormat.Pending(version))
+		}
+		got, err := taskFormat.Migrate([]byte(doc), version)
+		if err != nil {
+			t.Fatalf("v%d: %v", version, err)
+		}
+		if string(got) != want {
+			t.Errorf("v%d: Expected %s, got %s", version, want, got)
+		}
+	}
+}
+
+func TestMigrateVersions(t *testing.T) {
+	if taskFormat.Current() != 3 {
+		t.Errorf("Expected version 3, got %d", taskFormat.Current())
+	}
+	if _, err := taskFormat.Migrate([]byte("{}"), 4); !errors.Is(err, ErrNewer) {
+		t.Errorf("Expected data of a newer build to be refused, got %v", err)
+	}
+	if _, err := taskFormat.Migrate([]byte("{}"), 0); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected versions before the base to be refused, got %v", err)
+	}
+	if _, err := taskFormat.Migrate([]byte("not json"), 1); err == nil {
+		t.Error("Expected a step failure to be reported")
+	}
+	if pending := taskFormat.Pending(4); pending != nil {
+		t.Errorf("Expected no steps for an unreadable version, got %v", pending)
+	}
+}
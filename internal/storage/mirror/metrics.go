@@ -0,0 +1,61 @@
+package mirror
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collector exports Router stats as Prometheus metrics
+type collector struct {
+	router *Router
+
+	duration, errors, failures, shadow *prometheus.Desc
+}
+
+// NewCollector returns a Prometheus collector for the per-variant latency
+// and error counts, labelled with variant and op. Register it once per Router.
+func NewCollector(namespace string, router *Router) prometheus.Collector {
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "storage_experiment", name), help, labels, nil)
+	}
+	return &collector{
+		router:   router,
+		duration: desc("duration_seconds", "Latency of store operations per variant.", "variant", "op"),
+		errors:   desc("errors_total", "Store operations that failed, other than task not found.", "variant", "op"),
+		failures: desc("mirror_failures_total", "Writes the alternate store rejected after the primary accepted them."),
+		shadow:   desc("shadow_reads_total", "Primary reads replayed on the alternate, by comparison result.", "op", "result"),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.duration
+	ch <- c.errors
+	ch <- c.failures
+	ch <- c.shadow
+}
+
+// Collect implements prometheus.Collector
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	for v, byOp := range c.router.stats {
+		for op, s := range byOp {
+			buckets := make(map[float64]uint64, len(latencyBuckets))
+			var cumulative uint64
+			for i, le := range latencyBuckets {
+				cumulative += uint64(s.buckets[i].Load())
+				buckets[le] = cumulative
+			}
+			seconds := float64(s.nanos.Load()) / 1e9
+			ch <- prometheus.MustNewConstHistogram(c.duration, uint64(s.count.Load()), seconds, buckets, string(v), op)
+			ch <- prometheus.MustNewConstMetric(c.errors, prometheus.CounterValue, float64(s.errors.Load()), string(v), op)
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(c.failures, prometheus.CounterValue, float64(c.router.mirror.Failures()))
+	if c.router.shadow == nil {
+		return
+	}
+	for op, byResult := range c.router.shadow.counts {
+		for result, n := range byResult {
+			ch <- prometheus.MustNewConstMetric(c.shadow, prometheus.CounterValue, float64(n.Load()), op, result)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package mirror
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/storage"
+)
+
+// Package mirror keeps an alternate store in step with the writes made to
+// the primary store, so a candidate backend can serve live traffic next to
+// the one in production.
+
+// Mirror applies every write to the primary and then to the alternate.
+// Backends assign IDs on create, so the alternate's IDs differ from the
+// primary's; Mirror maps between them and translates the alternate's
+// results back to primary IDs. Creates are serialized to keep both ID
+// sequences in the same order.
+type Mirror struct {
+	primary   storage.Store
+	alternate storage.Store
+
+	mu  sync.RWMutex
+	ids []pair // Ascending in both primary and alternate ID
+
+	failures atomic.Int64
+	writes   atomic.Int64 // Bumped when a write starts and when it ends
+	inflight atomic.Int64
+	observe  func(v Variant, op string, d time.Duration, err *apperrors.AppError) // Optional, set before use
+}
+
+// pair maps a primary ID to the ID of the same task in the alternate store
+type pair struct {
+	primary, alternate int
+}
+
+// New creates a Mirror; call Seed before serving traffic
+func New(primary, alternate storage.Store) *Mirror {
+	return &Mirror{primary: primary, alternate: alternate}
+}
+
+// Seed copies the tasks of the primary into the alternate, which must be empty
+func (m *Mirror) Seed() (int, error) {
+	if n := len(m.alternate.GetAll()); n > 0 {
+		return 0, fmt.Errorf("alternate store already holds %d tasks", n)
+	}
+	tasks := m.primary.GetAll()
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// An in-memory alternate takes the copies in one batch under the same IDs
+	if loader, ok := m.alternate.(storage.BulkLoader); ok {
+		copies := make([]*entities.Task, len(tasks))
+		for i, task := range tasks {
+			copied := *task
+			copies[i] = &copied
+		}
+		if err := loader.BulkLoad(copies); err != nil {
+			return 0, fmt.Errorf("copying tasks: %w", err)
+		}
+		for _, task := range tasks {
+			m.ids = append(m.ids, pair{primary: task.ID, alternate: task.ID})
+		}
+		return len(m.ids), nil
+	}
+
+	for _, task := range tasks {
+		copied := *task
+		if err := m.alternate.Create(&copied); err != nil {
+			return len(m.ids), fmt.Errorf("copying task %d: %w", task.ID, err)
+		}
+		m.ids = append(m.ids, pair{primary: task.ID, alternate: copied.ID})
+	}
+	return len(m.ids), nil
+}
+
+// Failures returns how many writes the alternate rejected after the primary
+// accepted them
+func (m *Mirror) Failures() int64 {
+	return m.failures.Load()
+}
+
+// Primary returns the primary store
+func (m *Mirror) Primary() storage.Store {
+	return m.primary
+}
+
+// Alternate returns the alternate store
+func (m *Mirror) Alternate() storage.Store {
+	return m.alternate
+}
+
+func (m *Mirror) failed(op string, id int, err error) {
+	m.failures.Add(1)
+	logger.For(logger.ModuleStorage).Warnw("Alternate store rejected a mirrored write", "op", op, "id", id, "error", err)
+}
+
+// begin marks a write in progress; the returned func marks its end
+func (m *Mirror) begin() func() {
+	m.inflight.Add(1)
+	m.writes.Add(1)
+	return func() {
+		m.writes.Add(1)
+		m.inflight.Add(-1)
+	}
+}
+
+// generation returns a token for the current state of the stores; ok is
+// false while a write is in progress
+func (m *Mirror) generation() (gen int64, ok bool) {
+	gen = m.writes.Load()
+	return gen, m.inflight.Load() == 0
+}
+
+// unchangedSince reports whether no write started since generation gen
+func (m *Mirror) unchangedSince(gen int64) bool {
+	return m.writes.Load() == gen
+}
+
+// OnWrite sets a function told the duration and result of every write to
+// either store. Call it before serving traffic.
+func (m *Mirror) OnWrite(fn func(v Variant, op string, d time.Duration, err *apperrors.AppError)) {
+	m.observe = fn
+}
+
+// write runs a write against one store and reports it
+func (m *Mirror) write(v Variant, op string, write func() *apperrors.AppError) *apperrors.AppError {
+	start := time.Now()
+	err := write()
+	if m.observe != nil {
+		m.observe(v, op, time.Since(start), err)
+	}
+	return err
+}
+
+// Create creates the task in the primary, then a copy in the alternate
+func (m *Mirror) Create(task *entities.Task) *apperrors.AppError {
+	defer m.begin()()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.write(Primary, OpCreate, func() *apperrors.AppError { return m.primary.Create(task) }); err != nil {
+		return err
+	}
+	copied := *task
+	if err := m.write(Alternate, OpCreate, func() *apperrors.AppError { return m.alternate.Create(&copied) }); err != nil {
+		m.failed(OpCreate, task.ID, err)
+		return nil
+	}
+	m.ids = append(m.ids, pair{primary: task.ID, alternate: copied.ID})
+	return nil
+}
+
+// Update updates the primary, then the alternate
+func (m *Mirror) Update(id int, task *entities.Task) *apperrors.AppError {
+	defer m.begin()()
+	if err := m.write(Primary, OpUpdate, func() *apperrors.AppError { return m.primary.Update(id, task) }); err != nil {
+		return err
+	}
+	altID, ok := m.toAlternate(id)
+	if !ok {
+		return nil
+	}
+	copied := *task
+	copied.ID = altID
+	if err := m.write(Alternate, OpUpdate, func() *apperrors.AppError { return m.alternate.Update(altID, &copied) }); err != nil {
+		m.failed(OpUpdate, id, err)
+	}
+	return nil
+}
+
+// Delete deletes from the primary, then from the alternate
+func (m *Mirror) Delete(id int) *apperrors.AppError {
+	defer m.begin()()
+	if err := m.write(Primary, OpDelete, func() *apperrors.AppError { return m.primary.Delete(id) }); err != nil {
+		return err
+	}
+	altID, ok := m.toAlternate(id)
+	if !ok {
+		return nil
+	}
+	err := m.write(Alternate, OpDelete, func() *apperrors.AppError { return m.alternate.Delete(altID) })
+	if err != nil && err.Code != apperrors.ErrCodeTaskNotFound {
+		m.failed(OpDelete, id, err)
+	}
+	return nil
+}
+
+// GetByID reads task id (a primary ID) from the alternate
+func (m *Mirror) GetByID(id int) (*entities.Task, *apperrors.AppError) {
+	altID, ok := m.toAlternate(id)
+	if !ok {
+		return nil, apperrors.ErrTaskNotFound
+	}
+	task, err := m.alternate.GetByID(altID)
+	if err != nil {
+		return nil, err
+	}
+	translated := *task
+	translated.ID = id
+	return &translated, nil
+}
+
+// GetAll reads every task from the alternate
+func (m *Mirror) GetAll() []*entities.Task {
+	return m.translate(m.alternate.GetAll())
+}
+
+// GetRange reads the tasks with primary IDs in [fromID, toID] from the alternate
+func (m *Mirror) GetRange(fromID, toID, limit int) []*entities.Task {
+	m.mu.RLock()
+	lo := sort.Search(len(m.ids), func(i int) bool { return m.ids[i].primary >= fromID })
+	hi := sort.Search(len(m.ids), func(i int) bool { return m.ids[i].primary > toID }) - 1
+	var from, to int
+	if lo <= hi {
+		from, to = m.ids[lo].alternate, m.ids[hi].alternate
+	}
+	m.mu.RUnlock()
+	if lo > hi {
+		return make([]*entities.Task, 0)
+	}
+	return m.translate(m.alternate.GetRange(from, to, limit))
+}
+
+// toAlternate maps a primary ID to the alternate's ID
+func (m *Mirror) toAlternate(id int) (int, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	i := sort.Search(len(m.ids), func(i int) bool { return m.ids[i].primary >= id })
+	if i < len(m.ids) && m.ids[i].primary == id {
+		return m.ids[i].alternate, true
+	}
+	return 0, false
+}
+
+// translate rewrites alternate IDs to primary IDs, dropping unknown ones
+func (m *Mirror) translate(tasks []*entities.Task) []*entities.Task {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]*entities.Task, 0, len(tasks))
+	for _, task := range tasks {
+		i := sort.Search(len(m.ids), func(i int) bool { return m.ids[i].alternate >= task.ID })
+		if i == len(m.ids) || m.ids[i].alternate != task.ID {
+			continue
+		}
+		translated := *task
+		translated.ID = m.ids[i].primary
+		out = append(out, &translated)
+	}
+	return out
+}
@@ -0,0 +1,273 @@
+package mirror
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/redact"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/naive"
+	"tasks-service-demo/internal/storage/storetest"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// newTestRouter seeds an empty alternate from a primary with gaps in its
+// IDs, one Create at a time, so the two stores use different IDs for the
+// same task
+func newTestRouter(t *testing.T, cfg Config) (*Router, *storetest.FakeStore) {
+	t.Helper()
+	primary := storetest.NewFakeStore(
+		&entities.Task{ID: 3, Name: "a"},
+		&entities.Task{ID: 7, Name: "b", Status: 1},
+		&entities.Task{ID: 9, Name: "c"},
+	)
+	m := New(primary, storetest.NewFakeStore())
+	if n, err := m.Seed(); n != 3 || err != nil {
+		t.Fatalf("Expected 3 tasks seeded, got %d, %v", n, err)
+	}
+	return NewRouter(m, cfg), primary
+}
+
+func TestMirror_SeedBulkLoadsKeepingIDs(t *testing.T) {
+	primary := storetest.NewFakeStore(
+		&entities.Task{ID: 3, Name: "a"},
+		&entities.Task{ID: 7, Name: "b"},
+	)
+	alternate := naive.NewMemoryStore()
+	m := New(primary, alternate)
+	if n, err := m.Seed(); n != 2 || err != nil {
+		t.Fatalf("Expected 2 tasks seeded, got %d, %v", n, err)
+	}
+	if task, err := alternate.GetByID(7); err != nil || task.Name != "b" {
+		t.Errorf("Expected task 7 under its primary ID, got %+v, %v", task, err)
+	}
+
+	task := &entities.Task{Name: "c"}
+	m.Create(task)
+	if id, ok := m.toAlternate(task.ID); !ok || id != 8 {
+		t.Errorf("Expected the next alternate ID after the loaded ones, got %d, %v", id, ok)
+	}
+}
+
+func TestMirror_SeedRefusesNonEmptyAlternate(t *testing.T) {
+	alternate := naive.NewMemoryStore()
+	alternate.Create(&entities.Task{Name: "x"})
+	if _, err := New(storetest.NewFakeStore(), alternate).Seed(); err == nil {
+		t.Error("Expected seeding a non-empty alternate to fail")
+	}
+}
+
+func TestRouter_AlternateReadsUsePrimaryIDs(t *testing.T) {
+	r, _ := newTestRouter(t, Config{})
+	alt := WithVariant(context.Background(), Alternate)
+
+	task, err := r.GetByIDContext(alt, 7)
+	if err != nil || task.ID != 7 || task.Name != "b" {
+		t.Errorf("Expected task 7 from the alternate, got %+v, %v", task, err)
+	}
+	if _, err := r.GetByIDContext(alt, 4); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected not found, got %v", err)
+	}
+
+	tasks, _ := r.GetRangeContext(alt, 4, 9, 0)
+	if len(tasks) != 2 || tasks[0].ID != 7 || tasks[1].ID != 9 {
+		t.Errorf("Expected tasks 7 and 9, got %+v", tasks)
+	}
+	if tasks, _ := r.GetRangeContext(alt, 10, 20, 0); len(tasks) != 0 {
+		t.Errorf("Expected an empty range, got %+v", tasks)
+	}
+	if tasks, _ := r.GetAllContext(alt); len(tasks) != 3 {
+		t.Errorf("Expected 3 tasks, got %+v", tasks)
+	}
+}
+
+func TestRouter_StrongReadsUsePrimary(t *testing.T) {
+	r, primary := newTestRouter(t, Config{})
+	primary.Update(7, &entities.Task{ID: 7, Name: "primary only"}) // Not mirrored
+	ctx := storage.WithConsistency(WithVariant(context.Background(), Alternate), storage.Strong)
+
+	if task, err := r.GetByIDStrong(7); err != nil || task.Name != "primary only" {
+		t.Errorf("Expected task 7 from the primary, got %+v, %v", task, err)
+	}
+	tasks, _ := r.GetRangeContext(ctx, 7, 7, 0)
+	if len(tasks) != 1 || tasks[0].Name != "primary only" {
+		t.Errorf("Expected task 7 from the primary, got %+v", tasks)
+	}
+	if st := r.Report().Variants; st[Alternate][OpRange].Count != 0 || st[Primary][OpGet].Count != 1 {
+		t.Errorf("Expected no alternate reads, got %+v", st)
+	}
+}
+
+func TestRouter_WritesReachBothStores(t *testing.T) {
+	r, primary := newTestRouter(t, Config{})
+	alt := WithVariant(context.Background(), Alternate)
+
+	created := &entities.Task{Name: "d"}
+	if err := r.Create(created); err != nil {
+		t.Fatal(err)
+	}
+	if task, err := r.GetByIDContext(alt, created.ID); err != nil || task.Name != "d" {
+		t.Errorf("Expected the new task in the alternate under ID %d, got %+v, %v", created.ID, task, err)
+	}
+
+	r.Update(3, &entities.Task{ID: 3, Name: "a2"})
+	if task, _ := r.GetByIDContext(alt, 3); task == nil || task.Name != "a2" {
+		t.Errorf("Expected the update in the alternate, got %+v", task)
+	}
+
+	r.Delete(9)
+	if _, err := r.GetByIDContext(alt, 9); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected the delete in the alternate, got %v", err)
+	}
+	if _, err := primary.GetByID(9); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected the delete in the primary, got %v", err)
+	}
+}
+
+func TestRouter_Pick(t *testing.T) {
+	r, _ := newTestRouter(t, Config{Percent: 100})
+	if v := r.Pick("Primary"); v != Primary {
+		t.Errorf("Expected the header to pin the primary, got %s", v)
+	}
+	if v := r.Pick(""); v != Alternate {
+		t.Errorf("Expected 100%% of requests on the alternate, got %s", v)
+	}
+
+	r, _ = newTestRouter(t, Config{})
+	if v := r.Pick("bogus"); v != Primary {
+		t.Errorf("Expected the primary for 0%%, got %s", v)
+	}
+	if v := r.Pick("alternate"); v != Alternate {
+		t.Errorf("Expected the header to pin the alternate, got %s", v)
+	}
+}
+
+func TestRouter_ReportAndMetrics(t *testing.T) {
+	r, _ := newTestRouter(t, Config{Percent: 10})
+	r.GetByIDContext(WithVariant(context.Background(), Alternate), 3)
+	r.GetByID(3)
+	r.GetByID(4) // Not found is not an error
+	r.Create(&entities.Task{Name: "d"})
+
+	rep := r.Report()
+	if !rep.Enabled || rep.Percent != 10 || rep.Header != DefaultHeader {
+		t.Errorf("Unexpected report %+v", rep)
+	}
+	if got := rep.Variants[Primary][OpGet]; got.Count != 2 || got.Errors != 0 {
+		t.Errorf("Expected 2 primary gets without errors, got %+v", got)
+	}
+	if got := rep.Variants[Alternate][OpGet]; got.Count != 1 {
+		t.Errorf("Expected 1 alternate get, got %+v", got)
+	}
+	if rep.Variants[Primary][OpCreate].Count != 1 || rep.Variants[Alternate][OpCreate].Count != 1 {
+		t.Errorf("Expected the create timed on both variants, got %+v", rep.Variants)
+	}
+
+	expected := `
+# HELP tasks_service_storage_experiment_mirror_failures_total Writes the alternate store rejected after the primary accepted them.
+# TYPE tasks_service_storage_experiment_mirror_failures_total counter
+tasks_service_storage_experiment_mirror_failures_total 0
+`
+	if err := testutil.CollectAndCompare(NewCollector("tasks_service", r), strings.NewReader(expected), "tasks_service_storage_experiment_mirror_failures_total"); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(NewCollector("tasks_service", r), "tasks_service_storage_experiment_duration_seconds"); n != len(variants)*len(ops) {
+		t.Errorf("Expected a histogram per variant and op, got %d", n)
+	}
+}
+
+func TestReset_Disabled(t *testing.T) {
+	Reset()
+	if Get().Enabled() || Get().Report().Enabled {
+		t.Error("Expected the default router to be disabled")
+	}
+}
+
+func TestRouter_ShadowReads(t *testing.T) {
+	r, _ := newTestRouter(t, Config{Shadow: true})
+	r.GetByID(3)
+	r.GetByID(4) // Missing in both: a match
+	r.GetAll()
+	r.Close() // Waits for the replays
+
+	stats := r.Report().Shadow
+	if got := stats[OpGet]; got.Match != 2 || got.Mismatch != 0 {
+		t.Errorf("Expected 2 matching gets, got %+v", got)
+	}
+	if got := stats[OpList]; got.Match != 1 {
+		t.Errorf("Expected a matching list, got %+v", got)
+	}
+	if n := testutil.CollectAndCount(NewCollector("tasks_service", r), "tasks_service_storage_experiment_shadow_reads_total"); n != 3*len(shadowResults) {
+		t.Errorf("Expected a counter per op and result, got %d", n)
+	}
+
+	// Diverge the alternate behind the router's back
+	r, _ = newTestRouter(t, Config{Shadow: true})
+	altID, _ := r.mirror.toAlternate(7)
+	r.mirror.Alternate().Update(altID, &entities.Task{ID: altID, Name: "changed", Status: 1})
+	r.GetByID(7)
+	r.GetRange(1, 10, 0)
+	r.Close()
+
+	stats = r.Report().Shadow
+	if got := stats[OpGet]; got.Mismatch != 1 {
+		t.Errorf("Expected a mismatching get, got %+v", got)
+	}
+	if got := stats[OpRange]; got.Mismatch != 1 {
+		t.Errorf("Expected a mismatching range, got %+v", got)
+	}
+}
+
+func TestRouter_ShadowSkipsAlternateReadsAndDropsWhenFull(t *testing.T) {
+	r, _ := newTestRouter(t, Config{Shadow: true, ShadowQueue: 1})
+	r.shadow.close() // No worker: the queue only fills up
+	r.shadow = &shadow{mirror: r.mirror, queue: make(chan replay, 1), counts: r.shadow.counts}
+
+	r.GetByIDContext(WithVariant(context.Background(), Alternate), 3)
+	if len(r.shadow.queue) != 0 {
+		t.Error("Expected reads served by the alternate not to be shadowed")
+	}
+	r.GetByID(3)
+	r.GetByID(3)
+	if got := r.Report().Shadow[OpGet]; got.Dropped != 1 {
+		t.Errorf("Expected 1 dropped replay, got %+v", got)
+	}
+}
+
+func TestShadow_SkipsReadsOverlappingWrites(t *testing.T) {
+	m := New(storetest.NewFakeStore(&entities.Task{ID: 1, Name: "a"}), naive.NewMemoryStore())
+	m.Seed()
+	s := newShadow(m, 0)
+
+	gen, quiet := m.generation()
+	if !quiet {
+		t.Fatal("Expected no write in progress")
+	}
+	m.Update(1, &entities.Task{ID: 1, Name: "a2"})
+	s.submit(OpGet, gen, func() string { return "differs" })
+	s.close()
+
+	if got := s.stats()[OpGet]; got.Skipped != 1 || got.Mismatch != 0 {
+		t.Errorf("Expected the comparison to be skipped, got %+v", got)
+	}
+}
+
+func TestShadow_MismatchLogRedactsTaskFields(t *testing.T) {
+	redact.Set(redact.Rules{Fields: []string{"name"}})
+	defer redact.Reset()
+
+	primary := &entities.Task{ID: 1, Name: "Call Dr. Smith"}
+	alternate := &entities.Task{ID: 1, Name: "Call Dr. Smith", Status: 1}
+	for _, diff := range []string{
+		diffTask(1, primary, nil, alternate, nil),
+		diffTasks([]*entities.Task{primary}, []*entities.Task{alternate}),
+	} {
+		if strings.Contains(diff, "Smith") || !strings.Contains(diff, "Status:1") {
+			t.Errorf("Expected the name masked but the difference kept, got %q", diff)
+		}
+	}
+}
@@ -0,0 +1,377 @@
+package mirror
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage"
+)
+
+// DefaultHeader pins a request to a variant when set to "primary" or "alternate"
+const DefaultHeader = "X-Storage-Variant"
+
+// Variant names the store that serves a request's reads
+type Variant string
+
+const (
+	Primary   Variant = "primary"
+	Alternate Variant = "alternate"
+)
+
+// Store operations measured per variant
+const (
+	OpCreate = "create"
+	OpGet    = "get"
+	OpList   = "list"
+	OpRange  = "range"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+var (
+	variants = []Variant{Primary, Alternate}
+	ops      = []string{OpCreate, OpGet, OpList, OpRange, OpUpdate, OpDelete}
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the latency histograms
+var latencyBuckets = [...]float64{.00005, .0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1}
+
+type variantKey struct{}
+
+// WithVariant returns ctx carrying the variant chosen for the request
+func WithVariant(ctx context.Context, v Variant) context.Context {
+	return context.WithValue(ctx, variantKey{}, v)
+}
+
+// VariantFrom returns the variant carried by ctx, Primary if none
+func VariantFrom(ctx context.Context) Variant {
+	if v, ok := ctx.Value(variantKey{}).(Variant); ok {
+		return v
+	}
+	return Primary
+}
+
+// Config tunes a Router
+type Config struct {
+	Percent     float64 // Share of requests, 0-100, whose reads the alternate serves
+	Header      string  // Request header that pins a request to a variant
+	Shadow      bool    // Replay reads served by the primary on the alternate and compare
+	ShadowQueue int     // Replays waiting at most; more are dropped
+}
+
+// series accumulates the calls of one operation against one variant
+type series struct {
+	count, errors, nanos atomic.Int64
+	buckets              [len(latencyBuckets)]atomic.Int64 // Non-cumulative
+}
+
+func (s *series) observe(d time.Duration, err *apperrors.AppError) {
+	s.count.Add(1)
+	s.nanos.Add(int64(d))
+	if err != nil && err.Code != apperrors.ErrCodeTaskNotFound {
+		s.errors.Add(1)
+	}
+	seconds := d.Seconds()
+	for i, le := range latencyBuckets {
+		if seconds <= le {
+			s.buckets[i].Add(1)
+			break
+		}
+	}
+}
+
+// OpStats summarizes one operation against one variant
+type OpStats struct {
+	Count      int64   `json:"count"`
+	Errors     int64   `json:"errors"` // Failures other than task not found
+	MeanMicros float64 `json:"meanMicros"`
+}
+
+// Report compares the variants side by side
+type Report struct {
+	Enabled        bool                           `json:"enabled"`
+	Percent        float64                        `json:"percent,omitempty"`
+	Header         string                         `json:"header,omitempty"`
+	MirrorFailures int64                          `json:"mirrorFailures"` // Writes the alternate rejected
+	Variants       map[Variant]map[string]OpStats `json:"variants,omitempty"`
+	Shadow         map[string]ShadowStats         `json:"shadow,omitempty"` // Shadow reads per op
+}
+
+// Router is a storage.Store for A/B experiments between two backends.
+// Writes go to both stores through a Mirror; reads are served by the
+// variant chosen for the request (see WithVariant), or by the primary for
+// strong reads (see storage.WithConsistency), and every call is timed per
+// variant. Calls without a request context use the primary.
+// In shadow mode, reads served by the primary are also replayed on the
+// alternate in the background and the results compared.
+type Router struct {
+	cfg    Config
+	mirror *Mirror
+	stats  map[Variant]map[string]*series
+	shadow *shadow // Nil unless cfg.Shadow
+}
+
+// NewRouter creates a Router over a seeded Mirror
+func NewRouter(m *Mirror, cfg Config) *Router {
+	if cfg.Header == "" {
+		cfg.Header = DefaultHeader
+	}
+	r := &Router{cfg: cfg, mirror: m, stats: make(map[Variant]map[string]*series)}
+	for _, v := range variants {
+		r.stats[v] = make(map[string]*series)
+		for _, op := range ops {
+			r.stats[v][op] = &series{}
+		}
+	}
+	m.OnWrite(r.record)
+	if cfg.Shadow {
+		r.shadow = newShadow(m, cfg.ShadowQueue)
+	}
+	return r
+}
+
+// Enabled reports whether an experiment is running
+func (r *Router) Enabled() bool {
+	return r.mirror != nil
+}
+
+// Config returns the routing configuration
+func (r *Router) Config() Config {
+	return r.cfg
+}
+
+// Inner returns the primary store
+func (r *Router) Inner() storage.Store {
+	return r.mirror.Primary()
+}
+
+// Close finishes the queued shadow reads and closes both stores
+func (r *Router) Close() error {
+	if r.shadow != nil {
+		r.shadow.close()
+	}
+	_, altErr := storage.Close(r.mirror.Alternate())
+	_, err := storage.Close(r.mirror.Primary())
+	return errors.Join(err, altErr)
+}
+
+// Pick chooses the variant of a request: the one named by tag (the value
+// of the routing header) if valid, otherwise the alternate for
+// cfg.Percent of requests
+func (r *Router) Pick(tag string) Variant {
+	switch v := Variant(strings.ToLower(strings.TrimSpace(tag))); v {
+	case Primary, Alternate:
+		return v
+	}
+	if r.cfg.Percent > 0 && rand.Float64()*100 < r.cfg.Percent {
+		return Alternate
+	}
+	return Primary
+}
+
+func (r *Router) record(v Variant, op string, d time.Duration, err *apperrors.AppError) {
+	r.stats[v][op].observe(d, err)
+}
+
+// Report returns the per-variant stats
+func (r *Router) Report() Report {
+	if !r.Enabled() {
+		return Report{}
+	}
+	rep := Report{
+		Enabled:        true,
+		Percent:        r.cfg.Percent,
+		Header:         r.cfg.Header,
+		MirrorFailures: r.mirror.Failures(),
+		Variants:       make(map[Variant]map[string]OpStats),
+	}
+	if r.shadow != nil {
+		rep.Shadow = r.shadow.stats()
+	}
+	for v, byOp := range r.stats {
+		rep.Variants[v] = make(map[string]OpStats)
+		for op, s := range byOp {
+			st := OpStats{Count: s.count.Load(), Errors: s.errors.Load()}
+			if st.Count > 0 {
+				st.MeanMicros = float64(s.nanos.Load()) / float64(st.Count) / 1e3
+			}
+			rep.Variants[v][op] = st
+		}
+	}
+	return rep
+}
+
+// read times a read against the variant of ctx, the primary for strong
+// reads. Reads served by the primary are handed to shadow, if set, for
+// replay on the alternate.
+func (r *Router) read(ctx context.Context, op string, primary, alternate func() *apperrors.AppError, shadow func(err *apperrors.AppError) func() string) *apperrors.AppError {
+	if err := storage.ContextError(ctx); err != nil {
+		return err
+	}
+	v := VariantFrom(ctx)
+	if storage.ConsistencyFrom(ctx) == storage.Strong {
+		v = Primary // The alternate may lag or have rejected writes
+	}
+	gen, quiet := r.mirror.generation()
+	start := time.Now()
+	var err *apperrors.AppError
+	if v == Alternate {
+		err = alternate()
+	} else {
+		err = primary()
+	}
+	r.record(v, op, time.Since(start), err)
+
+	if v == Primary && r.shadow != nil {
+		if quiet {
+			r.shadow.submit(op, gen, shadow(err))
+		} else {
+			r.shadow.counts[op][ShadowSkipped].Add(1)
+		}
+	}
+	return err
+}
+
+// CreateContext creates the task in both stores
+func (r *Router) CreateContext(ctx context.Context, task *entities.Task) *apperrors.AppError {
+	if err := storage.ContextError(ctx); err != nil {
+		return err
+	}
+	return r.mirror.Create(task)
+}
+
+// GetByIDContext reads from the request's variant
+func (r *Router) GetByIDContext(ctx context.Context, id int) (*entities.Task, *apperrors.AppError) {
+	var task *entities.Task
+	err := r.read(ctx, OpGet,
+		func() (err *apperrors.AppError) { task, err = r.mirror.Primary().GetByID(id); return err },
+		func() (err *apperrors.AppError) { task, err = r.mirror.GetByID(id); return err },
+		func(primaryErr *apperrors.AppError) func() string {
+			var primary *entities.Task
+			if task != nil {
+				copied := *task
+				primary = &copied
+			}
+			return func() string {
+				alt, altErr := r.mirror.GetByID(id)
+				return diffTask(id, primary, primaryErr, alt, altErr)
+			}
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// GetByIDStrong reads strongly from the primary, whatever the request's
+// variant
+func (r *Router) GetByIDStrong(id int) (*entities.Task, *apperrors.AppError) {
+	start := time.Now()
+	task, err := storage.GetByIDStrong(r.mirror.Primary(), id)
+	r.record(Primary, OpGet, time.Since(start), err)
+	return task, err
+}
+
+// GetAllContext reads from the request's variant
+func (r *Router) GetAllContext(ctx context.Context) ([]*entities.Task, *apperrors.AppError) {
+	var tasks []*entities.Task
+	err := r.read(ctx, OpList,
+		func() *apperrors.AppError { tasks = r.mirror.Primary().GetAll(); return nil },
+		func() *apperrors.AppError { tasks = r.mirror.GetAll(); return nil },
+		func(*apperrors.AppError) func() string {
+			primary := tasks
+			return func() string { return diffTasks(primary, r.mirror.GetAll()) }
+		},
+	)
+	return tasks, err
+}
+
+// GetRangeContext reads from the request's variant
+func (r *Router) GetRangeContext(ctx context.Context, fromID, toID, limit int) ([]*entities.Task, *apperrors.AppError) {
+	var tasks []*entities.Task
+	err := r.read(ctx, OpRange,
+		func() *apperrors.AppError { tasks = r.mirror.Primary().GetRange(fromID, toID, limit); return nil },
+		func() *apperrors.AppError { tasks = r.mirror.GetRange(fromID, toID, limit); return nil },
+		func(*apperrors.AppError) func() string {
+			primary := tasks
+			return func() string { return diffTasks(primary, r.mirror.GetRange(fromID, toID, limit)) }
+		},
+	)
+	return tasks, err
+}
+
+// UpdateContext updates the task in both stores
+func (r *Router) UpdateContext(ctx context.Context, id int, task *entities.Task) *apperrors.AppError {
+	if err := storage.ContextError(ctx); err != nil {
+		return err
+	}
+	return r.mirror.Update(id, task)
+}
+
+// DeleteContext deletes the task from both stores
+func (r *Router) DeleteContext(ctx context.Context, id int) *apperrors.AppError {
+	if err := storage.ContextError(ctx); err != nil {
+		return err
+	}
+	return r.mirror.Delete(id)
+}
+
+// Create creates the task in both stores
+func (r *Router) Create(task *entities.Task) *apperrors.AppError {
+	return r.CreateContext(context.Background(), task)
+}
+
+// GetByID reads from the primary
+func (r *Router) GetByID(id int) (*entities.Task, *apperrors.AppError) {
+	return r.GetByIDContext(context.Background(), id)
+}
+
+// GetAll reads from the primary
+func (r *Router) GetAll() []*entities.Task {
+	tasks, _ := r.GetAllContext(context.Background())
+	return tasks
+}
+
+// GetRange reads from the primary
+func (r *Router) GetRange(fromID, toID, limit int) []*entities.Task {
+	tasks, _ := r.GetRangeContext(context.Background(), fromID, toID, limit)
+	return tasks
+}
+
+// Update updates the task in both stores
+func (r *Router) Update(id int, task *entities.Task) *apperrors.AppError {
+	return r.UpdateContext(context.Background(), id, task)
+}
+
+// Delete deletes the task from both stores
+func (r *Router) Delete(id int) *apperrors.AppError {
+	return r.DeleteContext(context.Background(), id)
+}
+
+var current atomic.Pointer[Router]
+
+func init() {
+	Reset()
+}
+
+// Get returns the process-wide Router
+func Get() *Router {
+	return current.Load()
+}
+
+// Init replaces the process-wide Router
+func Init(r *Router) {
+	current.Store(r)
+}
+
+// Reset installs a disabled Router
+func Reset() {
+	current.Store(&Router{})
+}
@@ -0,0 +1,158 @@
+package mirror
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/redact"
+)
+
+// DefaultShadowQueue bounds the reads waiting to be replayed on the alternate
+const DefaultShadowQueue = 1024
+
+// Shadow comparison results
+const (
+	ShadowMatch    = "match"
+	ShadowMismatch = "mismatch"
+	ShadowSkipped  = "skipped" // A write overlapped the reads, so they may differ legitimately
+	ShadowDropped  = "dropped" // The replay queue was full
+)
+
+var shadowResults = []string{ShadowMatch, ShadowMismatch, ShadowSkipped, ShadowDropped}
+
+// ShadowStats counts the shadow reads of one operation
+type ShadowStats struct {
+	Match    int64 `json:"match"`
+	Mismatch int64 `json:"mismatch"`
+	Skipped  int64 `json:"skipped"`
+	Dropped  int64 `json:"dropped"`
+}
+
+// replay is a read to repeat on the alternate; compare returns a
+// description of the difference, or "" if the results agree
+type replay struct {
+	op      string
+	gen     int64
+	compare func() string
+}
+
+// shadow replays primary reads on the alternate in the background
+type shadow struct {
+	mirror *Mirror
+
+	mu     sync.RWMutex // Held for writing only to close queue
+	closed bool
+	queue  chan replay
+	wg     sync.WaitGroup
+	counts map[string]map[string]*atomic.Int64 // op -> result
+}
+
+func newShadow(m *Mirror, size int) *shadow {
+	if size <= 0 {
+		size = DefaultShadowQueue
+	}
+	s := &shadow{mirror: m, queue: make(chan replay, size), counts: make(map[string]map[string]*atomic.Int64)}
+	for _, op := range []string{OpGet, OpList, OpRange} {
+		s.counts[op] = make(map[string]*atomic.Int64)
+		for _, result := range shadowResults {
+			s.counts[op][result] = &atomic.Int64{}
+		}
+	}
+	s.wg.Add(1)
+	go s.run()
+	return s
+}
+
+// submit queues a replay without waiting; a full queue drops it
+func (s *shadow) submit(op string, gen int64, compare func() string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.queue <- replay{op: op, gen: gen, compare: compare}:
+	default:
+		s.counts[op][ShadowDropped].Add(1)
+	}
+}
+
+func (s *shadow) run() {
+	defer s.wg.Done()
+	for r := range s.queue {
+		diff := r.compare()
+		switch {
+		case !s.mirror.unchangedSince(r.gen):
+			s.counts[r.op][ShadowSkipped].Add(1)
+		case diff != "":
+			s.counts[r.op][ShadowMismatch].Add(1)
+			logger.For(logger.ModuleStorage).Warnw("Shadow read mismatch", "op", r.op, "diff", diff)
+		default:
+			s.counts[r.op][ShadowMatch].Add(1)
+		}
+	}
+}
+
+// close stops accepting replays and waits for the queued ones
+func (s *shadow) close() {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *shadow) stats() map[string]ShadowStats {
+	out := make(map[string]ShadowStats, len(s.counts))
+	for op, byResult := range s.counts {
+		out[op] = ShadowStats{
+			Match:    byResult[ShadowMatch].Load(),
+			Mismatch: byResult[ShadowMismatch].Load(),
+			Skipped:  byResult[ShadowSkipped].Load(),
+			Dropped:  byResult[ShadowDropped].Load(),
+		}
+	}
+	return out
+}
+
+// diffTask compares the results of two GetByID calls
+func diffTask(id int, primary *entities.Task, primaryErr *apperrors.AppError, alternate *entities.Task, alternateErr *apperrors.AppError) string {
+	switch {
+	case primaryErr != nil || alternateErr != nil:
+		if primaryErr != nil && alternateErr != nil && primaryErr.Code == alternateErr.Code {
+			return ""
+		}
+		return fmt.Sprintf("task %d: primary error %v, alternate error %v", id, primaryErr, alternateErr)
+	case !primary.Equal(alternate):
+		rules := redact.Get()
+		return fmt.Sprintf("task %d: primary %+v, alternate %+v", id, *rules.Task(primary), *rules.Task(alternate))
+	}
+	return ""
+}
+
+// diffTasks compares the results of two list reads, ignoring order
+func diffTasks(primary, alternate []*entities.Task) string {
+	if len(primary) != len(alternate) {
+		return fmt.Sprintf("primary returned %d tasks, alternate %d", len(primary), len(alternate))
+	}
+	byID := func(tasks []*entities.Task) []*entities.Task {
+		sorted := append([]*entities.Task(nil), tasks...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+		return sorted
+	}
+	p, a := byID(primary), byID(alternate)
+	for i := range p {
+		if !p[i].Equal(a[i]) {
+			rules := redact.Get()
+			return fmt.Sprintf("primary %+v, alternate %+v", *rules.Task(p[i]), *rules.Task(a[i]))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,103 @@
+package mmap
+
+import (
+	"sync"
+	"time"
+
+	"tasks-service-demo/internal/storage"
+)
+
+// DefaultGroupCommitBatch is the batch size that is synced without waiting
+// out the group commit delay, when none is configured
+const DefaultGroupCommitBatch = 128
+
+// groupCommit collects the writers waiting for their writes to be synced,
+// so one msync serves a whole batch of them
+type groupCommit struct {
+	cfg storage.GroupCommit
+
+	mu      sync.Mutex
+	waiters []chan error
+
+	first chan struct{} // A batch started
+	full  chan struct{} // A batch reached MaxBatch
+	stop  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newGroupCommit(cfg storage.GroupCommit) *groupCommit {
+	if cfg.MaxBatch <= 0 {
+		cfg.MaxBatch = DefaultGroupCommitBatch
+	}
+	return &groupCommit{
+		cfg:   cfg,
+		first: make(chan struct{}, 1),
+		full:  make(chan struct{}, 1),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+}
+
+// notify wakes the syncer without blocking
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// join adds a writer to the current batch. The writer must hold the store's
+// write lock, so its writes precede the sync that answers on the channel.
+func (g *groupCommit) join() <-chan error {
+	ch := make(chan error, 1)
+	g.mu.Lock()
+	g.waiters = append(g.waiters, ch)
+	n := len(g.waiters)
+	g.mu.Unlock()
+	if n == 1 {
+		notify(g.first)
+	}
+	if n >= g.cfg.MaxBatch {
+		notify(g.full)
+	}
+	return ch
+}
+
+// take removes the writers of the current batch
+func (g *groupCommit) take() []chan error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	waiters := g.waiters
+	g.waiters = nil
+	return waiters
+}
+
+// run syncs s once per batch: MaxDelay after the batch starts, or as soon
+// as it reaches MaxBatch
+func (g *groupCommit) run(s *MmapStore) {
+	defer close(g.done)
+	for {
+		select {
+		case <-g.first:
+		case <-g.stop:
+			return
+		}
+		timer := time.NewTimer(g.cfg.MaxDelay)
+		select {
+		case <-timer.C:
+		case <-g.full:
+			timer.Stop()
+		case <-g.stop:
+			timer.Stop()
+			return
+		}
+		s.syncGroup()
+	}
+}
+
+// close stops the syncer; writers still waiting are answered by Close
+func (g *groupCommit) close() {
+	g.once.Do(func() { close(g.stop) })
+	<-g.done
+}
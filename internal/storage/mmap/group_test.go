@@ -0,0 +1,124 @@
+package mmap
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/storage"
+)
+
+func TestMmapStore_GroupCommit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.mmap")
+	store, err := Open(Config{Path: path, SyncWrites: true, Group: storage.GroupCommit{MaxDelay: 5 * time.Millisecond, MaxBatch: 8}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			task := &entities.Task{Name: fmt.Sprintf("task %d", i)}
+			if err := store.Create(task); err != nil {
+				t.Errorf("Expected create to succeed, got %v", err)
+				return
+			}
+			if err := store.Update(task.ID, &entities.Task{Name: task.Name, Status: 1}); err != nil {
+				t.Errorf("Expected update to succeed, got %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if err := store.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+
+	// The slots of replaced versions are freed once their batch is synced
+	store.mu.RLock()
+	pending := len(store.pending)
+	store.mu.RUnlock()
+	if pending != 0 {
+		t.Errorf("Expected no slots pending after the last sync, got %d", pending)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := openStore(t, path)
+	if n := len(reopened.GetAll()); n != 49 {
+		t.Fatalf("Expected 49 tasks after reopening, got %d", n)
+	}
+	for _, task := range reopened.GetAll() {
+		if task.Status != 1 {
+			t.Errorf("Expected the update of task %d persisted, got %+v", task.ID, task)
+		}
+	}
+}
+
+func TestMmapStore_GroupCommitCompaction(t *testing.T) {
+	store, err := Open(Config{Path: filepath.Join(t.TempDir(), "tasks.mmap"), SyncWrites: true, Group: storage.GroupCommit{MaxDelay: time.Hour, MaxBatch: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	for i := 0; i < InitialSlots*2; i++ {
+		if err := store.Create(&entities.Task{Name: "task"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for id := 1; id <= InitialSlots*2; id++ {
+		if id%8 == 0 {
+			store.Update(id, &entities.Task{Name: "kept", Status: 1})
+			continue
+		}
+		if err := store.Delete(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if store.slots != InitialSlots {
+		t.Errorf("Expected the file compacted to %d slots, got %d", InitialSlots, store.slots)
+	}
+	for _, task := range store.GetAll() {
+		if task.Name != "kept" {
+			t.Errorf("Expected only updated tasks left, got %+v", task)
+		}
+	}
+}
+
+// BenchmarkMmapStore_SyncWrites compares synced creates from 16 writers,
+// each msync'd on its own or in batches
+func BenchmarkMmapStore_SyncWrites(b *testing.B) {
+	for _, bc := range []struct {
+		name  string
+		group storage.GroupCommit
+	}{
+		{"PerWrite", storage.GroupCommit{}},
+		{"Group1ms", storage.GroupCommit{MaxDelay: time.Millisecond, MaxBatch: 16}},
+		{"Group5ms", storage.GroupCommit{MaxDelay: 5 * time.Millisecond, MaxBatch: 64}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			store, err := Open(Config{Path: filepath.Join(b.TempDir(), "tasks.mmap"), SyncWrites: true, Group: bc.group})
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer store.Close()
+
+			b.SetParallelism(16)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if err := store.Create(&entities.Task{Name: "benchmark"}); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
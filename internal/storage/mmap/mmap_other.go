@@ -0,0 +1,16 @@
+//go:build !unix
+
+package mmap
+
+import (
+	"errors"
+	"os"
+)
+
+var errUnsupported = errors.New("the mmap backend is only supported on unix systems")
+
+func mapFile(*os.File, int) ([]byte, error) { return nil, errUnsupported }
+
+func unmapFile([]byte) error { return errUnsupported }
+
+func syncFile([]byte) error { return errUnsupported }
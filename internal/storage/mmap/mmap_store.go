@@ -0,0 +1,923 @@
+package mmap
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"tasks-service-demo/internal/crypt"
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/storage"
+)
+
+// Package mmap provides a persistent storage backend that keeps fixed-size
+// task records in a memory-mapped file. Reads decode straight from the
+// mapping, so they run at close to in-memory speed, and the file survives
+// restarts. Updates are copy-on-write and every record carries a checksum,
+// so a crash mid-write never corrupts the previous version of a task. With
+// group commit, synced writes share one msync per batch instead of paying
+// for one each.
+
+// InitialSlots is the record capacity of a new file; it doubles when full
+const InitialSlots = 1024
+
+// Config configures an MmapStore
+type Config struct {
+	Path       string              // Data file, created if missing
+	SyncWrites bool                // msync each write; without it the OS flushes pages on its own schedule
+	Group      storage.GroupCommit // With SyncWrites, one msync per batch of writes instead of per write
+	Sealer     *crypt.Sealer       // Encrypts task names; nil stores them in plaintext
+}
+
+// MmapStore stores tasks in a memory-mapped file
+type MmapStore struct {
+	mu     sync.RWMutex
+	f      *os.File
+	data   []byte
+	slots  int
+	index  map[int]int // Task ID -> slot
+	free   []int       // Free slots, lowest last so allocation fills the front
+	nextID uint64
+	seq    uint64 // Last write sequence
+	sync   bool
+	sealer *crypt.Sealer
+	closed bool
+
+	// Group commit: writes are synced in batches, and the slots of the
+	// versions they replace are freed only once the batch is synced
+	group   *groupCommit
+	pending []int
+
+	// What recovery at Open fixed, reported by Verify
+	tornRecords     int
+	headerRecovered bool
+
+	copiedHook func() // Tests: runs in compact once the copies are synced, before the originals are cleared
+}
+
+// Open opens or creates the data file at cfg.Path, discards torn records
+// and compacts the file if it is mostly empty
+func Open(cfg Config) (*MmapStore, error) {
+	f, err := os.OpenFile(cfg.Path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if f, err = migrateFile(f, cfg.Path); err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	s := &MmapStore{f: f, index: make(map[int]int), nextID: 1, sync: cfg.SyncWrites, sealer: cfg.Sealer}
+	fresh := info.Size() == 0
+	slots := InitialSlots
+	if !fresh {
+		if info.Size() < HeaderSize {
+			f.Close()
+			return nil, fmt.Errorf("%s is not a task data file", cfg.Path)
+		}
+		// A partial trailing slot can only come from an interrupted resize
+		slots = int((info.Size() - HeaderSize) / RecordSize)
+	}
+	if err := s.remap(slots); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if fresh {
+		err = s.writeHeader()
+	} else {
+		err = s.recover(cfg.Path)
+	}
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	for slot := s.slots - 1; slot >= 0; slot-- {
+		if s.record(slot)[recFlags] != flagLive {
+			s.free = append(s.free, slot)
+		}
+	}
+	if err := s.checkKeys(cfg.Path); err != nil {
+		s.Close()
+		return nil, err
+	}
+	if err := s.compact(); err != nil {
+		s.Close()
+		return nil, err
+	}
+	if cfg.SyncWrites && cfg.Group.Enabled() {
+		s.sync = false // Writes are synced per batch, see write
+		s.group = newGroupCommit(cfg.Group)
+		go s.group.run(s)
+	}
+	return s, nil
+}
+
+// checkKeys fails when a sealed name was encrypted with a key that is not
+// configured, rather than failing every read of it later
+func (s *MmapStore) checkKeys(path string) error {
+	for _, slot := range s.index {
+		if r, ok := decodeRecord(s.record(slot)); ok && r.sealed {
+			if err := s.sealer.CanOpen([]byte(r.task.Name)); err != nil {
+				return fmt.Errorf("%s: task %d: %w", path, r.task.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// migrateFile upgrades a file whose header records an older version and
+// returns it reopened; other files are returned as they are, for recover to
+// check. The upgraded copy is written next to the file and renamed over it,
+// so an interrupted migration leaves the original intact. f is closed on
+// error.
+func migrateFile(f *os.File, path string) (*os.File, error) {
+	hdr := make([]byte, HeaderSize)
+	if n, _ := f.ReadAt(hdr, 0); n < HeaderSize || !bytes.Equal(hdr[:len(magic)], magic) {
+		return f, nil
+	}
+	nextID, valid := decodeHeader(hdr)
+	version := int(binary.LittleEndian.Uint32(hdr[hdrVersion:]))
+	if !valid || version == recordFormat.Current() {
+		return f, nil
+	}
+	fail := func(err error) (*os.File, error) {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := recordFormat.Check(version); err != nil {
+		return fail(err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return fail(err)
+	}
+	size := int64(binary.LittleEndian.Uint32(hdr[hdrRecordSize:]))
+	if size == 0 {
+		return fail(fmt.Errorf("header records no record size"))
+	}
+	slots := (info.Size() - HeaderSize) / size
+
+	tmpPath := path + ".migrate"
+	tmp, err := os.Create(tmpPath)
+	if err != nil {
+		return fail(err)
+	}
+	w := bufio.NewWriter(tmp)
+	encodeHeader(hdr, nextID)
+	w.Write(hdr)
+	old, free := make([]byte, size), make([]byte, RecordSize)
+	live := 0
+	for slot := int64(0); slot < slots; slot++ {
+		if _, err = f.ReadAt(old, HeaderSize+slot*size); err != nil {
+			break
+		}
+		if old[recFlags] != flagLive {
+			w.Write(free)
+			continue
+		}
+		var rec []byte
+		if rec, err = recordFormat.Migrate(bytes.Clone(old), version); err != nil {
+			err = fmt.Errorf("slot %d: %w", slot, err)
+			break
+		}
+		if len(rec) != RecordSize {
+			err = fmt.Errorf("slot %d: migrated record is %d bytes, want %d", slot, len(rec), RecordSize)
+			break
+		}
+		w.Write(rec)
+		live++
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		f.Close() // Windows can't rename over an open file
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fail(err)
+	}
+
+	logger.For(logger.ModuleStorage).Infow("Migrated mmap store", "path", path, "from", version,
+		"to", recordFormat.Current(), "steps", recordFormat.Pending(version), "records", live)
+	return os.OpenFile(path, os.O_RDWR, 0o644)
+}
+
+// recover rebuilds the index from the records. Torn records fail their
+// checksum and are freed; when a crash left both copies of an updated task,
+// the one with the higher write sequence wins.
+func (s *MmapStore) recover(path string) error {
+	hdr := s.data[:HeaderSize]
+	if !bytes.Equal(hdr[:len(magic)], magic) {
+		return fmt.Errorf("%s is not a task data file", path)
+	}
+	nextID, headerValid := decodeHeader(hdr)
+	if headerValid {
+		if v := binary.LittleEndian.Uint32(hdr[hdrVersion:]); int(v) != recordFormat.Current() {
+			return fmt.Errorf("%s has unsupported version %d", path, v)
+		}
+		if size := binary.LittleEndian.Uint32(hdr[hdrRecordSize:]); size != RecordSize {
+			return fmt.Errorf("%s has record size %d, want %d", path, size, RecordSize)
+		}
+	}
+
+	torn, maxID := 0, uint64(0)
+	for slot := 0; slot < s.slots; slot++ {
+		rec := s.record(slot)
+		if rec[recFlags] != flagLive {
+			continue
+		}
+		r, ok := decodeRecord(rec)
+		if !ok {
+			torn++
+			rec[recFlags] = 0
+			continue
+		}
+		if prev, exists := s.index[r.task.ID]; exists {
+			older, _ := decodeRecord(s.record(prev))
+			if older.seq > r.seq {
+				rec[recFlags] = 0
+				continue
+			}
+			s.record(prev)[recFlags] = 0
+		}
+		s.index[r.task.ID] = slot
+		s.seq = max(s.seq, r.seq)
+		maxID = max(maxID, uint64(r.task.ID))
+	}
+
+	s.nextID = max(nextID, maxID+1)
+	s.tornRecords, s.headerRecovered = torn, !headerValid
+	if torn > 0 || !headerValid {
+		logger.For(logger.ModuleStorage).Warnw("Recovered mmap store after an unclean shutdown",
+			"path", path, "tornRecords", torn, "headerValid", headerValid)
+		return s.writeHeader()
+	}
+	return nil
+}
+
+func (s *MmapStore) record(slot int) []byte {
+	off := HeaderSize + slot*RecordSize
+	return s.data[off : off+RecordSize]
+}
+
+// remap resizes the file to slots records and maps it again
+func (s *MmapStore) remap(slots int) error {
+	if s.data != nil {
+		if err := unmapFile(s.data); err != nil {
+			return err
+		}
+		s.data = nil
+	}
+	size := HeaderSize + slots*RecordSize
+	if err := s.f.Truncate(int64(size)); err != nil {
+		return err
+	}
+	data, err := mapFile(s.f, size)
+	if err != nil {
+		return err
+	}
+	s.data, s.slots = data, slots
+	return nil
+}
+
+// flush persists n bytes at off when SyncWrites is on without group
+// commit
+func (s *MmapStore) flush(off, n int) error {
+	if !s.sync {
+		return nil
+	}
+	return s.syncRange(off, n)
+}
+
+// syncRange persists n bytes at off. msync needs a page-aligned start, and
+// the mapping itself starts on a page boundary.
+func (s *MmapStore) syncRange(off, n int) error {
+	start := off - off%os.Getpagesize()
+	return syncFile(s.data[start : off+n])
+}
+
+// write runs fn under the write lock. With group commit it then waits for
+// the sync of the batch fn's writes joined.
+func (s *MmapStore) write(fn func() *apperrors.AppError) *apperrors.AppError {
+	s.mu.Lock()
+	err := fn()
+	var synced <-chan error
+	if err == nil && s.group != nil {
+		synced = s.group.join()
+	}
+	s.mu.Unlock()
+
+	if synced == nil {
+		return err
+	}
+	if err := <-synced; err != nil {
+		return apperrors.ErrStorageError.WithCause(err)
+	}
+	return nil
+}
+
+// syncGroup syncs the writes of the current batch and answers its writers
+func (s *MmapStore) syncGroup() {
+	s.mu.Lock()
+	waiters := s.group.take()
+	var err error
+	if !s.closed {
+		err = s.syncPending()
+	}
+	s.mu.Unlock()
+	for _, w := range waiters {
+		w <- err
+	}
+}
+
+// syncPending syncs the whole mapping, then frees the slots of the versions
+// the synced writes replaced. Clearing their flags is synced with the next
+// batch; until then recovery still prefers the newer copy.
+func (s *MmapStore) syncPending() error {
+	if err := syncFile(s.data); err != nil {
+		return err
+	}
+	for _, slot := range s.pending {
+		s.record(slot)[recFlags] = 0
+		s.free = append(s.free, slot)
+	}
+	s.pending = s.pending[:0]
+	return nil
+}
+
+// supersede releases the slot of a task's previous version. With group
+// commit the new version is not synced yet, so the slot stays live until
+// the batch is, and a crash in between still leaves one intact copy.
+func (s *MmapStore) supersede(slot int) error {
+	if s.group != nil {
+		s.pending = append(s.pending, slot)
+		return nil
+	}
+	return s.release(slot)
+}
+
+func (s *MmapStore) writeHeader() error {
+	encodeHeader(s.data[:HeaderSize], s.nextID)
+	return s.flush(0, HeaderSize)
+}
+
+// put writes task into a free slot, growing the file when there is none.
+// The name and subtasks are sealed first when encryption is on.
+func (s *MmapStore) put(task *entities.Task) (int, error) {
+	subtasks := encodeSubtasks(task.Subtasks)
+	if s.sealer.Enabled() {
+		sealed := *task
+		sealed.Name = string(s.sealer.Seal([]byte(task.Name), nameAAD(task.ID)))
+		if len(subtasks) > 0 {
+			subtasks = s.sealer.Seal(subtasks, subtasksAAD(task.ID))
+		}
+		task = &sealed
+	}
+
+	if len(s.free) == 0 {
+		old := s.slots
+		if err := s.remap(max(old*2, InitialSlots)); err != nil {
+			return 0, err
+		}
+		for slot := s.slots - 1; slot >= old; slot-- {
+			s.free = append(s.free, slot)
+		}
+	}
+	slot := s.free[len(s.free)-1]
+	s.free = s.free[:len(s.free)-1]
+
+	s.seq++
+	encodeRecord(s.record(slot), task, subtasks, s.seq, s.sealer.Enabled())
+	return slot, s.flush(HeaderSize+slot*RecordSize, RecordSize)
+}
+
+// release frees slot. Clearing the flag is a single-byte write, so it
+// cannot tear.
+func (s *MmapStore) release(slot int) error {
+	s.record(slot)[recFlags] = 0
+	s.free = append(s.free, slot)
+	return s.flush(HeaderSize+slot*RecordSize, 1)
+}
+
+// checkSize rejects tasks whose name, or name and subtasks together, don't
+// fit a record once sealed
+func (s *MmapStore) checkSize(task *entities.Task) *apperrors.AppError {
+	limit := MaxNameBytes - s.sealer.Overhead()
+	if len(task.Name) > limit {
+		return apperrors.ErrTaskNameTooLong.WithMessage(fmt.Sprintf("Task name must be at most %d bytes", limit))
+	}
+	if n := len(encodeSubtasks(task.Subtasks)); n > 0 && len(task.Name)+n > limit-s.sealer.Overhead() {
+		return apperrors.ErrTaskTooLarge.WithMessage(fmt.Sprintf("Task name and subtasks must fit in %d bytes", limit-s.sealer.Overhead()))
+	}
+	return nil
+}
+
+// decode reads the task in slot, decrypting it if it is sealed
+func (s *MmapStore) decode(slot int) (*entities.Task, error) {
+	r, ok := decodeRecord(s.record(slot))
+	if !ok {
+		return nil, fmt.Errorf("record in slot %d is corrupt", slot)
+	}
+	task, err := s.open(r)
+	if err != nil {
+		return nil, fmt.Errorf("record of task %d in slot %d: %w", r.task.ID, slot, err)
+	}
+	return task, nil
+}
+
+// open returns the task of r with its name decrypted and its subtasks
+// decoded
+func (s *MmapStore) open(r record) (*entities.Task, error) {
+	task, subtasks := r.task, r.subtasks
+	if r.sealed {
+		name, err := s.sealer.Open([]byte(task.Name), nameAAD(task.ID))
+		if err != nil {
+			return nil, err
+		}
+		task.Name = string(name)
+		if len(subtasks) > 0 {
+			if subtasks, err = s.sealer.Open(subtasks, subtasksAAD(task.ID)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(subtasks) > 0 {
+		list, err := decodeSubtasks(subtasks)
+		if err != nil {
+			return nil, err
+		}
+		task.SetSubtasks(list)
+	}
+	return task, nil
+}
+
+// Create stores a new task with an auto-generated ID
+func (s *MmapStore) Create(task *entities.Task) *apperrors.AppError {
+	if err := s.checkSize(task); err != nil {
+		return err
+	}
+
+	return s.write(func() *apperrors.AppError {
+		task.ID = int(s.nextID)
+		slot, err := s.put(task)
+		if err != nil {
+			return apperrors.ErrStorageError.WithCause(err)
+		}
+		s.index[task.ID] = slot
+
+		// The header is written after the record: if it is lost, recovery
+		// derives the next ID from the records instead
+		s.nextID++
+		if err := s.writeHeader(); err != nil {
+			return apperrors.ErrStorageError.WithCause(err)
+		}
+		return nil
+	})
+}
+
+// GetByID retrieves a task by its ID, returns error if not found
+func (s *MmapStore) GetByID(id int) (*entities.Task, *apperrors.AppError) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	slot, ok := s.index[id]
+	if !ok {
+		return nil, apperrors.ErrTaskNotFound
+	}
+	task, err := s.decode(slot)
+	if err != nil {
+		return nil, apperrors.ErrStorageError.WithCause(err)
+	}
+	return task, nil
+}
+
+// GetAll returns all tasks in ID order
+func (s *MmapStore) GetAll() []*entities.Task {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tasks := make([]*entities.Task, 0, len(s.index))
+	for _, slot := range s.index {
+		if task, ok := s.decodeLogged(slot); ok {
+			tasks = append(tasks, task)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks
+}
+
+// decodeLogged is decode for listings, which have no error result; records
+// that fail are logged and left out
+func (s *MmapStore) decodeLogged(slot int) (*entities.Task, bool) {
+	task, err := s.decode(slot)
+	if err != nil {
+		logger.For(logger.ModuleStorage).Errorw("Failed to read mmap record", "error", err)
+		return nil, false
+	}
+	return task, true
+}
+
+// GetRange returns tasks with fromID <= ID <= toID in ID order. IDs are
+// assigned sequentially, so it walks the index by ID instead of sorting.
+func (s *MmapStore) GetRange(fromID, toID, limit int) []*entities.Task {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tasks := make([]*entities.Task, 0)
+	for id := max(fromID, 1); id <= min(toID, int(s.nextID)-1); id++ {
+		if limit > 0 && len(tasks) == limit {
+			break
+		}
+		if slot, ok := s.index[id]; ok {
+			if task, ok := s.decodeLogged(slot); ok {
+				tasks = append(tasks, task)
+			}
+		}
+	}
+	return tasks
+}
+
+// Update modifies an existing task by ID, returns error if not found. The
+// new version goes to a fresh slot before the old one is freed, so a crash
+// in between leaves at least one intact copy.
+func (s *MmapStore) Update(id int, updatedTask *entities.Task) *apperrors.AppError {
+	if err := s.checkSize(updatedTask); err != nil {
+		return err
+	}
+
+	return s.write(func() *apperrors.AppError {
+		old, ok := s.index[id]
+		if !ok {
+			return apperrors.ErrTaskNotFound
+		}
+		updatedTask.ID = id
+		slot, err := s.put(updatedTask)
+		if err != nil {
+			return apperrors.ErrStorageError.WithCause(err)
+		}
+		s.index[id] = slot
+		if err := s.supersede(old); err != nil {
+			return apperrors.ErrStorageError.WithCause(err)
+		}
+		return nil
+	})
+}
+
+// Delete removes a task by ID, returns error if not found
+func (s *MmapStore) Delete(id int) *apperrors.AppError {
+	return s.write(func() *apperrors.AppError {
+		slot, ok := s.index[id]
+		if !ok {
+			return apperrors.ErrTaskNotFound
+		}
+		delete(s.index, id)
+		if err := s.release(slot); err != nil {
+			return apperrors.ErrStorageError.WithCause(err)
+		}
+
+		// Shrink once three quarters of the file is free; compaction leaves it
+		// half full, so a few creates do not immediately grow it again
+		if s.slots > InitialSlots && len(s.free) > s.slots*3/4 {
+			if err := s.compact(); err != nil {
+				logger.For(logger.ModuleStorage).Errorw("mmap store compaction failed", "error", err)
+			}
+		}
+		return nil
+	})
+}
+
+// Compact moves live records into the lowest slots and truncates the free
+// tail of the file
+func (s *MmapStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.compact()
+}
+
+func (s *MmapStore) compact() error {
+	// Slots waiting for a group sync would be cut off or moved over
+	if len(s.pending) > 0 {
+		if err := s.syncPending(); err != nil {
+			return err
+		}
+	}
+	target := s.compactTarget()
+	if target >= s.slots {
+		return nil
+	}
+
+	var holes []int // Free slots below target, highest first so pops fill the front
+	for slot := target - 1; slot >= 0; slot-- {
+		if s.record(slot)[recFlags] != flagLive {
+			holes = append(holes, slot)
+		}
+	}
+	// Moves are copy-on-write like updates: the copy gets a higher sequence,
+	// so recovery prefers it if the old slot is not cleared yet
+	var moved []int
+	for id, slot := range s.index {
+		if slot < target {
+			continue
+		}
+		dst := holes[len(holes)-1]
+		holes = holes[:len(holes)-1]
+		r, _ := decodeRecord(s.record(slot))
+		s.seq++
+		encodeRecord(s.record(dst), r.task, r.subtasks, s.seq, r.sealed) // Moved as is, still sealed
+		s.index[id] = dst
+		moved = append(moved, slot)
+	}
+
+	// The copies must be on disk before any original is cleared: the kernel
+	// writes dirty pages back in any order, so clearing in the same sync
+	// could persist a cleared original without its copy
+	if err := syncFile(s.data); err != nil {
+		return err
+	}
+	if s.copiedHook != nil {
+		s.copiedHook()
+	}
+	for _, slot := range moved {
+		s.record(slot)[recFlags] = 0
+	}
+	// Persist the cleared originals before the tail they are in is cut off
+	if err := syncFile(s.data); err != nil {
+		return err
+	}
+	if err := s.remap(target); err != nil {
+		return err
+	}
+	s.free = holes
+	return nil
+}
+
+// compactBatch is the most records CompactOnline moves under one lock
+const compactBatch = 256
+
+// CompactOnline compacts like Compact, but moves the live records in
+// batches and releases the lock between them, so reads and writes wait for
+// at most one batch. Only the final truncation holds the lock for longer.
+func (s *MmapStore) CompactOnline(ctx context.Context, opts storage.CompactOptions) (storage.CompactStats, error) {
+	s.mu.RLock()
+	stats := storage.CompactStats{SizeBefore: s.size()}
+	target, total := s.compactTarget(), int64(0)
+	for _, slot := range s.index {
+		if slot >= target {
+			total++
+		}
+	}
+	s.mu.RUnlock()
+
+	done := int64(0)
+	for {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			return stats, errors.New("mmap store is closed")
+		}
+		moved, err := s.relocate(compactBatch)
+		s.mu.Unlock()
+		if err != nil {
+			return stats, err
+		}
+		if moved == 0 {
+			break
+		}
+		stats.Steps++
+		done += int64(moved)
+		if opts.Progress != nil {
+			opts.Progress(done, max(total, done))
+		}
+		select {
+		case <-ctx.Done():
+			return stats, ctx.Err()
+		case <-time.After(opts.Pause):
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	slots := s.slots
+	if err := s.compact(); err != nil {
+		return stats, err
+	}
+	if s.slots < slots {
+		stats.Steps++
+	}
+	stats.SizeAfter = s.size()
+	stats.ReclaimedBytes = stats.SizeBefore - stats.SizeAfter
+	return stats, nil
+}
+
+// relocate moves up to limit live records from above the compaction target
+// into the lowest free slots below it and returns how many it moved. Moves
+// are copy-on-write like in compact.
+func (s *MmapStore) relocate(limit int) (moved int, err error) {
+	target := s.compactTarget()
+	if target >= s.slots {
+		return 0, nil
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(s.free))) // Lowest last, popped first
+
+	var released []int
+	defer func() {
+		s.free = append(s.free, released...)
+		moved = len(released)
+	}()
+	for id, slot := range s.index {
+		if len(released) == limit || len(s.free) == 0 || s.free[len(s.free)-1] >= target {
+			break
+		}
+		if slot < target {
+			continue
+		}
+		dst := s.free[len(s.free)-1]
+		s.free = s.free[:len(s.free)-1]
+		r, _ := decodeRecord(s.record(slot))
+		s.seq++
+		encodeRecord(s.record(dst), r.task, r.subtasks, s.seq, r.sealed)
+		s.index[id] = dst
+		// The copy must be on disk before the original is cleared; group
+		// commit does not cover moves, so they sync on their own
+		if s.sync || s.group != nil {
+			if err := s.syncRange(HeaderSize+dst*RecordSize, RecordSize); err != nil {
+				return 0, err
+			}
+		}
+		s.record(slot)[recFlags] = 0
+		released = append(released, slot)
+		if err := s.flush(HeaderSize+slot*RecordSize, 1); err != nil {
+			return 0, err
+		}
+	}
+	return 0, nil
+}
+
+// compactTarget is the slot count compaction shrinks the file to
+func (s *MmapStore) compactTarget() int {
+	return max(InitialSlots, len(s.index)*2)
+}
+
+func (s *MmapStore) size() int64 {
+	return int64(HeaderSize + s.slots*RecordSize)
+}
+
+// Rekey rewrites the tasks whose names are not sealed under the active key,
+// or are sealed while encryption is off, and returns how many it rewrote.
+// Rewrites are copy-on-write like updates.
+func (s *MmapStore) Rekey(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rekeyed := 0
+	for id, slot := range s.index {
+		if err := ctx.Err(); err != nil {
+			return rekeyed, err
+		}
+		r, ok := decodeRecord(s.record(slot))
+		if !ok {
+			continue
+		}
+		if r.sealed == s.sealer.Enabled() && (!r.sealed || s.sealer.Current([]byte(r.task.Name))) {
+			continue // Already sealed under the active key, or plaintext with encryption off
+		}
+		task, err := s.decode(slot)
+		if err != nil {
+			return rekeyed, err
+		}
+		if err := s.checkSize(task); err != nil {
+			return rekeyed, fmt.Errorf("task %d: too large for the active key", id)
+		}
+		dst, err := s.put(task)
+		if err != nil {
+			return rekeyed, err
+		}
+		s.index[id] = dst
+		if err := s.supersede(slot); err != nil {
+			return rekeyed, err
+		}
+		rekeyed++
+	}
+	return rekeyed, nil
+}
+
+// Verify checks every record against its checksum and key, rebuilds the
+// index if it does not match the records and moves the next ID past the
+// highest stored one. Records that fail are reported, not freed, so they
+// can still be inspected.
+func (s *MmapStore) Verify(ctx context.Context) (storage.Integrity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result storage.Integrity
+	if s.tornRecords > 0 {
+		result.Repairf("discarded %d records torn by an unclean shutdown", s.tornRecords)
+	}
+	if s.headerRecovered {
+		result.Repairf("recovered the next ID from the records")
+	}
+
+	index := make(map[int]int, len(s.index))
+	for slot := 0; slot < s.slots; slot++ {
+		if slot%InitialSlots == 0 {
+			if err := ctx.Err(); err != nil {
+				return result, err
+			}
+		}
+		rec := s.record(slot)
+		if rec[recFlags] != flagLive {
+			continue
+		}
+		result.Records++
+		r, ok := decodeRecord(rec)
+		if !ok {
+			result.BadRecords++
+			result.Problemf("slot %d fails its checksum", slot)
+			continue
+		}
+		if _, err := s.open(r); err != nil {
+			result.BadRecords++
+			result.Problemf("task %d in slot %d: %v", r.task.ID, slot, err)
+			continue
+		}
+		if prev, exists := index[r.task.ID]; exists {
+			result.Problemf("task %d is stored in slots %d and %d", r.task.ID, prev, slot)
+			if older, _ := decodeRecord(s.record(prev)); older.seq > r.seq {
+				continue
+			}
+		}
+		index[r.task.ID] = slot
+		result.MaxID = max(result.MaxID, r.task.ID)
+	}
+
+	if !maps.Equal(index, s.index) {
+		result.Repairf("rebuilt the index from %d records, it held %d", len(index), len(s.index))
+		s.index = index
+	}
+	if _, valid := decodeHeader(s.data[:HeaderSize]); !valid || s.nextID <= uint64(result.MaxID) {
+		s.nextID = max(s.nextID, uint64(result.MaxID)+1)
+		result.Repairf("rewrote the header with next ID %d", s.nextID)
+		if err := s.writeHeader(); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// Close flushes the mapping and closes the file. Writers waiting for a
+// group sync are answered by the final one.
+func (s *MmapStore) Close() error {
+	if s.group != nil {
+		s.group.close() // Before locking: the syncer may be waiting for the lock
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	var errs []error
+	if s.data != nil {
+		if len(s.pending) > 0 {
+			errs = append(errs, s.syncPending())
+		}
+		errs = append(errs, syncFile(s.data), unmapFile(s.data))
+		s.data = nil
+	}
+	errs = append(errs, s.f.Close())
+	err := errors.Join(errs...)
+	if s.group != nil {
+		for _, w := range s.group.take() {
+			w <- err
+		}
+	}
+	return err
+}
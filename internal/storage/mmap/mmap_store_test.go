@@ -0,0 +1,542 @@
+package mmap
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tasks-service-demo/internal/crypt"
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/migrate"
+)
+
+func openStore(t *testing.T, path string) *MmapStore {
+	t.Helper()
+	store, err := Open(Config{Path: path})
+	if err != nil {
+		t.Fatalf("Expected store to open, got %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestMmapStore_CRUD(t *testing.T) {
+	store := openStore(t, filepath.Join(t.TempDir(), "tasks.mmap"))
+
+	task := &entities.Task{Name: "Test Task"}
+	if err := store.Create(task); err != nil || task.ID != 1 {
+		t.Fatalf("Expected task 1, got %d, %v", task.ID, err)
+	}
+	if err := store.Update(task.ID, &entities.Task{Name: "Updated", Status: 1}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	got, err := store.GetByID(task.ID)
+	if err != nil || got.Name != "Updated" || got.Status != 1 || got.ID != task.ID {
+		t.Errorf("Expected updated task, got %+v, %v", got, err)
+	}
+
+	if err := store.Delete(task.ID); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if _, err := store.GetByID(task.ID); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected ErrTaskNotFound after delete, got %v", err)
+	}
+	if err := store.Update(999, &entities.Task{Name: "x"}); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected ErrTaskNotFound updating missing task, got %v", err)
+	}
+	if err := store.Delete(999); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected ErrTaskNotFound deleting missing task, got %v", err)
+	}
+
+	long := &entities.Task{Name: strings.Repeat("é", MaxNameBytes)}
+	if err := store.Create(long); err == nil || err.Code != apperrors.ErrCodeTaskNameTooLong {
+		t.Errorf("Expected name too long, got %v", err)
+	}
+}
+
+func TestMmapStore_PersistsAcrossRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.mmap")
+
+	store, err := Open(Config{Path: path, SyncWrites: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Create(&entities.Task{Name: "a"})
+	store.Create(&entities.Task{Name: "b", Status: 1})
+	store.Create(&entities.Task{Name: "c"})
+	store.Update(1, &entities.Task{Name: "a2"})
+	store.Delete(3)
+	if err := store.Close(); err != nil {
+		t.Fatalf("Expected clean close, got %v", err)
+	}
+
+	reopened := openStore(t, path)
+	all := reopened.GetAll()
+	if len(all) != 2 || all[0].Name != "a2" || all[1].Name != "b" || all[1].Status != 1 {
+		t.Fatalf("Expected tasks to survive restart, got %+v", all)
+	}
+
+	// The deleted newest ID is not handed out again
+	task := &entities.Task{Name: "d"}
+	reopened.Create(task)
+	if task.ID != 4 {
+		t.Errorf("Expected ID 4 after restart, got %d", task.ID)
+	}
+}
+
+func TestMmapStore_TornWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.mmap")
+
+	store, err := Open(Config{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Create(&entities.Task{Name: "kept"})
+	store.Create(&entities.Task{Name: "torn"})
+	oldSlot := store.index[1]
+	store.Update(1, &entities.Task{Name: "kept-v2"})
+
+	// Simulate a crash: the update's old copy was never freed, the second
+	// record was half written and the header was torn
+	store.record(oldSlot)[recFlags] = flagLive
+	store.record(store.index[2])[recName] ^= 0xff
+	store.data[hdrNextID] ^= 0xff
+	store.Close()
+
+	reopened := openStore(t, path)
+	if got, err := reopened.GetByID(1); err != nil || got.Name != "kept-v2" {
+		t.Errorf("Expected newest copy of task 1, got %+v, %v", got, err)
+	}
+	if _, err := reopened.GetByID(2); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected torn record to be discarded, got %v", err)
+	}
+	if len(reopened.GetAll()) != 1 {
+		t.Errorf("Expected 1 task, got %d", len(reopened.GetAll()))
+	}
+
+	task := &entities.Task{Name: "next"}
+	reopened.Create(task)
+	if task.ID != 2 {
+		t.Errorf("Expected next ID recovered from records, got %d", task.ID)
+	}
+}
+
+func TestMmapStore_Migrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.mmap")
+	sealer, err := crypt.New([]crypt.Key{{ID: "a", Secret: bytes.Repeat([]byte("a"), 32)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A version 1 file, with its name at offset 32 and no subtasks: a plain
+	// record, a sealed one, a torn one and a free slot
+	file := make([]byte, HeaderSize+4*RecordSize)
+	copy(file, magic)
+	binary.LittleEndian.PutUint32(file[hdrVersion:], 1)
+	binary.LittleEndian.PutUint32(file[hdrRecordSize:], RecordSize)
+	binary.LittleEndian.PutUint64(file[hdrNextID:], 5)
+	binary.LittleEndian.PutUint32(file[hdrCRC:], headerChecksum(file))
+	v1 := func(slot, id int, name string, status int, sealed bool) []byte {
+		rec := file[HeaderSize+slot*RecordSize : HeaderSize+(slot+1)*RecordSize]
+		rec[recFlags] = flagLive
+		if sealed {
+			rec[recSealed] = 1
+			name = string(sealer.Seal([]byte(name), nameAAD(id)))
+		}
+		binary.LittleEndian.PutUint16(rec[recNameLen:], uint16(len(name)))
+		binary.LittleEndian.PutUint64(rec[recID:], uint64(id))
+		binary.LittleEndian.PutUint64(rec[recSeq:], uint64(slot+1))
+		binary.LittleEndian.PutUint64(rec[recStatus:], uint64(status))
+		copy(rec[32:], name)
+		binary.LittleEndian.PutUint32(rec[recCRC:], recordChecksum(rec))
+		return rec
+	}
+	v1(0, 1, "plain", 1, false)
+	v1(1, 2, "secret", 0, true)
+	v1(3, 4, "torn", 0, false)[40] ^= 0xff
+	if err := os.WriteFile(path, file, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := Open(Config{Path: path, Sealer: sealer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	all := store.GetAll()
+	if len(all) != 2 || all[0].Name != "plain" || all[0].Status != 1 || all[1].Name != "secret" {
+		t.Errorf("Expected both intact tasks in the current layout, got %+v", all)
+	}
+	if v := binary.LittleEndian.Uint32(store.data[hdrVersion:]); int(v) != recordFormat.Current() {
+		t.Errorf("Expected the header to record version %d, got %d", recordFormat.Current(), v)
+	}
+	task := &entities.Task{Name: "next"}
+	store.Create(task)
+	if task.ID != 5 {
+		t.Errorf("Expected the next ID kept, got %d", task.ID)
+	}
+	if _, err := os.Stat(path + ".migrate"); !os.IsNotExist(err) {
+		t.Errorf("Expected the migration copy renamed away, got %v", err)
+	}
+
+	// A file written by a newer build is refused
+	binary.LittleEndian.PutUint32(store.data[hdrVersion:], uint32(recordFormat.Current()+1))
+	binary.LittleEndian.PutUint32(store.data[hdrCRC:], headerChecksum(store.data))
+	store.Close()
+	if _, err := Open(Config{Path: path, Sealer: sealer}); !errors.Is(err, migrate.ErrNewer) {
+		t.Errorf("Expected a newer version to be refused, got %v", err)
+	}
+}
+
+func TestMmapStore_OpensVersion1File(t *testing.T) {
+	// A file written by the build before subtasks: tasks 1 (updated, so its
+	// first copy is a free slot) and 3 plain, task 2 deleted and task 4
+	// sealed under the "fixture" key
+	data, err := os.ReadFile(filepath.Join("testdata", "tasks-v1.mmap"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := binary.LittleEndian.Uint32(data[hdrVersion:]); v != 1 {
+		t.Fatalf("Expected a version 1 fixture, got %d", v)
+	}
+	path := filepath.Join(t.TempDir(), "tasks.mmap")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sealer, err := crypt.New([]crypt.Key{{ID: "fixture", Secret: bytes.Repeat([]byte("f"), 32)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := Open(Config{Path: path, Sealer: sealer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := store.GetAll()
+	if len(all) != 3 || all[0].ID != 1 || all[0].Name != "Write the migration" || all[0].Status != 1 ||
+		all[1].ID != 3 || all[1].Name != "Ünïcode name" || all[2].ID != 4 || all[2].Name != "Sealed name" {
+		t.Errorf("Expected the tasks of the version 1 file, got %+v", all)
+	}
+	if v := binary.LittleEndian.Uint32(store.data[hdrVersion:]); int(v) != recordFormat.Current() {
+		t.Errorf("Expected the header to record version %d, got %d", recordFormat.Current(), v)
+	}
+
+	// The upgraded records take subtasks, and IDs keep counting
+	task := all[0]
+	task.SetSubtasks([]entities.Subtask{{ID: 1, Name: "fixture", Done: true}})
+	if err := store.Update(task.ID, task); err != nil {
+		t.Fatal(err)
+	}
+	next := &entities.Task{Name: "next"}
+	store.Create(next)
+	if next.ID != 5 {
+		t.Errorf("Expected ID 5, got %d", next.ID)
+	}
+	store.Close()
+
+	reopened, err := Open(Config{Path: path, Sealer: sealer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if got, err := reopened.GetByID(1); err != nil || len(got.Subtasks) != 1 || got.Name != "Write the migration" {
+		t.Errorf("Expected the upgraded task with its subtasks, got %+v, %v", got, err)
+	}
+}
+
+func TestMmapStore_Subtasks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.mmap")
+	sealer, err := crypt.New([]crypt.Key{{ID: "a", Secret: bytes.Repeat([]byte("a"), 32)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := Open(Config{Path: path, Sealer: sealer})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task := &entities.Task{Name: "Release"}
+	task.SetSubtasks([]entities.Subtask{{ID: 1, Name: "Tag", Done: true}, {ID: 300, Name: "Publish"}})
+	if err := store.Create(task); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(store.data, []byte("Publish")) {
+		t.Error("Expected the subtasks to be encrypted")
+	}
+	store.Close()
+
+	reopened, err := Open(Config{Path: path, Sealer: sealer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { reopened.Close() })
+	got, appErr := reopened.GetByID(task.ID)
+	if appErr != nil || !got.Equal(task) {
+		t.Errorf("Expected the checklist to survive a restart, got %+v, %v", got, appErr)
+	}
+	if result, err := reopened.Verify(context.Background()); err != nil || result.BadRecords != 0 {
+		t.Errorf("Expected the record to verify, got %+v, %v", result, err)
+	}
+
+	big := &entities.Task{Name: "Too much"}
+	list := make([]entities.Subtask, entities.MaxSubtasks)
+	for i := range list {
+		list[i] = entities.Subtask{ID: i + 1, Name: strings.Repeat("x", 100)}
+	}
+	big.SetSubtasks(list)
+	if err := reopened.Update(task.ID, big); err == nil || err.Code != apperrors.ErrCodeTaskTooLarge {
+		t.Errorf("Expected a checklist larger than a record to be rejected, got %v", err)
+	}
+}
+
+func TestMmapStore_Verify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.mmap")
+	store, err := Open(Config{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Create(&entities.Task{Name: "a"})
+	store.Create(&entities.Task{Name: "b"})
+	store.record(store.index[2])[recName] ^= 0xff // Torn, discarded at the next open
+	store.Close()
+
+	reopened := openStore(t, path)
+	result, err := reopened.Verify(context.Background())
+	if err != nil || !result.OK() || result.Records != 1 || len(result.Repairs) != 1 {
+		t.Fatalf("Expected recovery to be reported as a repair, got %+v, %v", result, err)
+	}
+
+	// An index and next ID out of step with the records, and a record
+	// corrupted after open
+	reopened.Create(&entities.Task{Name: "c"})
+	reopened.Create(&entities.Task{Name: "d"})
+	delete(reopened.index, 4)
+	reopened.nextID = 2
+	reopened.record(reopened.index[3])[recName] ^= 0xff
+
+	result, err = reopened.Verify(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.OK() || result.BadRecords != 1 || result.MaxID != 4 {
+		t.Errorf("Expected the corrupt record to be reported, got %+v", result)
+	}
+	if got, err := reopened.GetByID(4); err != nil || got.Name != "d" {
+		t.Errorf("Expected the index to be rebuilt, got %+v, %v", got, err)
+	}
+	task := &entities.Task{Name: "e"}
+	if reopened.Create(task); task.ID != 5 {
+		t.Errorf("Expected the next ID past the records, got %d", task.ID)
+	}
+}
+
+func TestMmapStore_GrowAndCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.mmap")
+	store := openStore(t, path)
+
+	n := InitialSlots * 4
+	for i := 0; i < n; i++ {
+		store.Create(&entities.Task{Name: "task"})
+	}
+	if store.slots != n {
+		t.Fatalf("Expected file to grow to %d slots, got %d", n, store.slots)
+	}
+
+	// Keep the newest tasks so compaction has to move them to the front
+	for id := 1; id <= n-100; id++ {
+		store.Delete(id)
+	}
+	if store.slots != InitialSlots {
+		t.Errorf("Expected compaction to shrink to %d slots, got %d", InitialSlots, store.slots)
+	}
+	info, _ := os.Stat(path)
+	if info.Size() != int64(HeaderSize+InitialSlots*RecordSize) {
+		t.Errorf("Expected file to be truncated, got %d bytes", info.Size())
+	}
+
+	all := store.GetAll()
+	if len(all) != 100 || all[0].ID != n-99 || all[99].ID != n {
+		t.Fatalf("Expected the 100 newest tasks after compaction, got %d", len(all))
+	}
+	if err := store.Update(n, &entities.Task{Name: "after"}); err != nil {
+		t.Errorf("Expected update after compaction, got %v", err)
+	}
+}
+
+func TestMmapStore_CompactCopiesBeforeClearing(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tasks.mmap")
+	store := openStore(t, path)
+
+	n := InitialSlots * 4
+	for i := 0; i < n; i++ {
+		store.Create(&entities.Task{Name: "task"})
+	}
+
+	// Crash once the copies are written, before the originals are cleared
+	var crashed []byte
+	live := 0
+	store.copiedHook = func() {
+		store.copiedHook = nil
+		live = len(store.index)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		crashed = data
+	}
+	for id := 1; id <= n-100; id++ {
+		store.Delete(id)
+	}
+	if crashed == nil {
+		t.Fatal("Expected deletes to compact the file")
+	}
+
+	// The file holds every copy, and every original is still live
+	flagged := 0
+	for off := HeaderSize; off+RecordSize <= len(crashed); off += RecordSize {
+		if crashed[off+recFlags] == flagLive {
+			flagged++
+		}
+	}
+	if flagged <= live {
+		t.Errorf("Expected the moved originals still live next to their copies, got %d live records for %d tasks", flagged, live)
+	}
+
+	// Recovery keeps one copy of each task
+	crashedPath := filepath.Join(dir, "crashed.mmap")
+	os.WriteFile(crashedPath, crashed, 0o644)
+	all := openStore(t, crashedPath).GetAll()
+	if len(all) != live || all[0].ID != n-live+1 || all[len(all)-1].ID != n {
+		t.Errorf("Expected the %d tasks live at compaction after the crash, got %d", live, len(all))
+	}
+}
+
+func TestMmapStore_CompactOnline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.mmap")
+	store := openStore(t, path)
+
+	n := InitialSlots * 4
+	for i := 0; i < n; i++ {
+		store.Create(&entities.Task{Name: "task"})
+	}
+	// Too few deletes for Delete to compact on its own
+	deleted := 2500
+	for id := 1; id <= deleted; id++ {
+		store.Delete(id)
+	}
+	if store.slots != n {
+		t.Fatalf("Expected no automatic compaction, got %d slots", store.slots)
+	}
+
+	var steps []int64
+	stats, err := store.CompactOnline(context.Background(), storage.CompactOptions{
+		Progress: func(done, total int64) { steps = append(steps, done) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := (n - deleted) * 2
+	if len(steps) != 4 || steps[3] != int64(n-target) {
+		t.Errorf("Expected %d moves in batches of %d, got %v", n-target, compactBatch, steps)
+	}
+	if stats.ReclaimedBytes != int64((n-target)*RecordSize) || stats.Steps != 5 {
+		t.Errorf("Expected the freed tail to be reclaimed, got %+v", stats)
+	}
+	if info, _ := os.Stat(path); info.Size() != stats.SizeAfter {
+		t.Errorf("Expected a %d byte file, got %d", stats.SizeAfter, info.Size())
+	}
+
+	all := store.GetAll()
+	if len(all) != n-deleted || all[0].ID != deleted+1 || all[len(all)-1].ID != n {
+		t.Fatalf("Expected every remaining task after compaction, got %d", len(all))
+	}
+	task := &entities.Task{Name: "after"}
+	if err := store.Create(task); err != nil || task.ID != n+1 {
+		t.Errorf("Expected create after compaction, got %d, %v", task.ID, err)
+	}
+}
+
+func TestOpen_RejectsForeignFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.mmap")
+	os.WriteFile(path, make([]byte, HeaderSize+RecordSize), 0o644)
+
+	if _, err := Open(Config{Path: path}); err == nil {
+		t.Error("Expected a file without the magic header to be rejected")
+	}
+}
+
+func TestMmapStore_GetRange(t *testing.T) {
+	store := openStore(t, filepath.Join(t.TempDir(), "tasks.db"))
+	for i := 0; i < 6; i++ {
+		store.Create(&entities.Task{Name: "task"})
+	}
+	store.Delete(3)
+	store.Update(4, &entities.Task{Name: "updated"})
+
+	tasks := store.GetRange(2, math.MaxInt, 3)
+	if len(tasks) != 3 || tasks[0].ID != 2 || tasks[1].ID != 4 || tasks[2].ID != 5 {
+		t.Fatalf("Expected tasks 2, 4 and 5, got %+v", tasks)
+	}
+	if tasks[1].Name != "updated" {
+		t.Errorf("Expected the updated version, got %+v", tasks[1])
+	}
+}
+
+func TestMmapStore_Encryption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.mmap")
+	keyA := crypt.Key{ID: "a", Secret: bytes.Repeat([]byte{1}, 32)}
+	keyB := crypt.Key{ID: "b", Secret: bytes.Repeat([]byte{2}, 32)}
+	reopen := func(store *MmapStore, keys ...crypt.Key) (*MmapStore, error) {
+		t.Helper()
+		if store != nil {
+			store.Close()
+		}
+		var sealer *crypt.Sealer
+		if len(keys) > 0 {
+			sealer, _ = crypt.New(keys)
+		}
+		store, err := Open(Config{Path: path, Sealer: sealer})
+		if err == nil {
+			t.Cleanup(func() { store.Close() })
+		}
+		return store, err
+	}
+
+	store, _ := reopen(nil)
+	store.Create(&entities.Task{Name: "plain"})
+	store, _ = reopen(store, keyA)
+	store.Create(&entities.Task{Name: "secret", Status: 1})
+	if bytes.Contains(store.data, []byte("secret")) {
+		t.Error("Expected the name to be encrypted in the file")
+	}
+	if all := store.GetAll(); len(all) != 2 || all[0].Name != "plain" || all[1].Name != "secret" || all[1].Status != 1 {
+		t.Errorf("Expected both tasks, got %+v", all)
+	}
+	long := &entities.Task{Name: strings.Repeat("x", MaxNameBytes-store.sealer.Overhead()+1)}
+	if err := store.Create(long); err == nil || err.Code != apperrors.ErrCodeTaskNameTooLong {
+		t.Errorf("Expected the limit to leave room for the encryption overhead, got %v", err)
+	}
+
+	store, _ = reopen(store, keyB, keyA)
+	if n, err := store.Rekey(context.Background()); n != 2 || err != nil {
+		t.Errorf("Expected 2 tasks re-encrypted under the new key, got %d, %v", n, err)
+	}
+	store, _ = reopen(store, keyB)
+	if got, err := store.GetByID(2); err != nil || got.Name != "secret" {
+		t.Errorf("Expected the task under the new key, got %+v, %v", got, err)
+	}
+
+	store.Close()
+	if _, err := reopen(nil, keyA); !errors.Is(err, crypt.ErrUnknownKey) {
+		t.Errorf("Expected the store to refuse to open without its key, got %v", err)
+	}
+}
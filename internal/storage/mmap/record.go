@@ -0,0 +1,210 @@
+package mmap
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/storage/migrate"
+)
+
+// File layout: a HeaderSize header followed by fixed-size record slots.
+//
+// Header: magic[8] version u32 recordSize u32 nextID u64 crc u32
+// Record: flags u8 sealed u8 nameLen u16 crc u32 id u64 seq u64 status i64 subLen u16 name[nameLen] subtasks[subLen]
+//
+// All integers are little-endian. The record CRC covers every byte except
+// the CRC itself, so a record torn by a crash mid-write fails the check and
+// is discarded on the next open. A sealed record holds its name and its
+// subtasks encrypted, each bound to the task ID; the sealed byte was
+// reserved as zero before encryption existed, so older files read as
+// plaintext. Subtasks are encoded as uvarint id, done u8, uvarint nameLen,
+// name, one after another.
+//
+// The header and the flags byte keep their layout across versions; a file
+// of an older version is rewritten through recordFormat when it opens.
+// Version 1 had no subtasks: its name started where subLen is now.
+const (
+	HeaderSize   = 512
+	RecordSize   = 512
+	MaxNameBytes = RecordSize - recName // Shared by the name and the subtasks
+
+	flagLive = 1
+
+	recFlags   = 0
+	recSealed  = 1
+	recNameLen = 2
+	recCRC     = 4
+	recID      = 8
+	recSeq     = 16
+	recStatus  = 24
+	recSubLen  = 32
+	recName    = 34
+
+	hdrVersion    = 8
+	hdrRecordSize = 12
+	hdrNextID     = 16
+	hdrCRC        = 24
+)
+
+var magic = []byte("TASKMMAP")
+
+// recordFormat upgrades the live records of older file versions; each step
+// takes a record of its version's size and layout and returns the next
+// version's. Steps see torn records too, and must not make them pass the
+// new checksum.
+var recordFormat = migrate.NewFormat[[]byte]("mmap record", 1, migrate.Step[[]byte]{
+	Name: "make room for subtasks",
+	Up:   upgradeV1,
+})
+
+// upgradeV1 moves the name of a version 1 record two bytes on, making room
+// for the subtask length. Torn records become free slots.
+func upgradeV1(old []byte) ([]byte, error) {
+	const v1Name = 32
+	rec := make([]byte, RecordSize)
+	if binary.LittleEndian.Uint32(old[recCRC:]) != recordChecksum(old) {
+		return rec, nil
+	}
+	nameLen := int(binary.LittleEndian.Uint16(old[recNameLen:]))
+	if nameLen > MaxNameBytes {
+		return nil, fmt.Errorf("task %d: name of %d bytes does not fit version 2", binary.LittleEndian.Uint64(old[recID:]), nameLen)
+	}
+	copy(rec, old[:v1Name])
+	copy(rec[recName:], old[v1Name:v1Name+nameLen])
+	binary.LittleEndian.PutUint32(rec[recCRC:], recordChecksum(rec))
+	return rec, nil
+}
+
+// record is a decoded slot
+type record struct {
+	task     *entities.Task // Name still encrypted when sealed; no subtasks yet
+	subtasks []byte         // Encoded, and encrypted when sealed
+	seq      uint64         // Write sequence; the higher copy wins when a crash leaves two
+	sealed   bool
+}
+
+func recordChecksum(rec []byte) uint32 {
+	sum := crc32.ChecksumIEEE(rec[:recCRC])
+	return crc32.Update(sum, crc32.IEEETable, rec[recID:RecordSize])
+}
+
+// encodeRecord writes task into rec as a live record, with its name and
+// encoded subtasks as given. Unused bytes are zeroed so the checksum does
+// not depend on what the slot held before.
+func encodeRecord(rec []byte, task *entities.Task, subtasks []byte, seq uint64, sealed bool) {
+	name := task.Name
+	rec[recFlags] = flagLive
+	rec[recSealed] = 0
+	if sealed {
+		rec[recSealed] = 1
+	}
+	binary.LittleEndian.PutUint16(rec[recNameLen:], uint16(len(name)))
+	binary.LittleEndian.PutUint64(rec[recID:], uint64(task.ID))
+	binary.LittleEndian.PutUint64(rec[recSeq:], seq)
+	binary.LittleEndian.PutUint64(rec[recStatus:], uint64(int64(task.Status)))
+	binary.LittleEndian.PutUint16(rec[recSubLen:], uint16(len(subtasks)))
+	n := copy(rec[recName:], name)
+	n += copy(rec[recName+n:], subtasks)
+	clear(rec[recName+n : RecordSize])
+	binary.LittleEndian.PutUint32(rec[recCRC:], recordChecksum(rec))
+}
+
+// decodeRecord reads a live slot without decrypting it; ok is false for
+// free or torn slots
+func decodeRecord(rec []byte) (r record, ok bool) {
+	if rec[recFlags] != flagLive || binary.LittleEndian.Uint32(rec[recCRC:]) != recordChecksum(rec) {
+		return record{}, false
+	}
+	nameLen := int(binary.LittleEndian.Uint16(rec[recNameLen:]))
+	subLen := int(binary.LittleEndian.Uint16(rec[recSubLen:]))
+	if nameLen+subLen > MaxNameBytes {
+		return record{}, false
+	}
+	r = record{
+		task: &entities.Task{
+			ID:     int(binary.LittleEndian.Uint64(rec[recID:])),
+			Name:   string(rec[recName : recName+nameLen]),
+			Status: int(int64(binary.LittleEndian.Uint64(rec[recStatus:]))),
+		},
+		seq:    binary.LittleEndian.Uint64(rec[recSeq:]),
+		sealed: rec[recSealed] == 1,
+	}
+	if subLen > 0 {
+		// Copied: the mapping moves when the file grows
+		r.subtasks = append([]byte(nil), rec[recName+nameLen:recName+nameLen+subLen]...)
+	}
+	return r, true
+}
+
+// encodeSubtasks encodes the checklist of a record; none encode to nothing
+func encodeSubtasks(subtasks []entities.Subtask) []byte {
+	var buf []byte
+	for _, st := range subtasks {
+		buf = binary.AppendUvarint(buf, uint64(st.ID))
+		done := byte(0)
+		if st.Done {
+			done = 1
+		}
+		buf = append(buf, done)
+		buf = binary.AppendUvarint(buf, uint64(len(st.Name)))
+		buf = append(buf, st.Name...)
+	}
+	return buf
+}
+
+var errBadSubtasks = errors.New("malformed subtasks")
+
+// decodeSubtasks decodes what encodeSubtasks encoded
+func decodeSubtasks(buf []byte) ([]entities.Subtask, error) {
+	var subtasks []entities.Subtask
+	for len(buf) > 0 {
+		id, n := binary.Uvarint(buf)
+		if n <= 0 || n >= len(buf) {
+			return nil, errBadSubtasks
+		}
+		done := buf[n]
+		buf = buf[n+1:]
+		nameLen, n := binary.Uvarint(buf)
+		if n <= 0 || uint64(len(buf)-n) < nameLen {
+			return nil, errBadSubtasks
+		}
+		subtasks = append(subtasks, entities.Subtask{ID: int(id), Name: string(buf[n : n+int(nameLen)]), Done: done == 1})
+		buf = buf[n+int(nameLen):]
+	}
+	return subtasks, nil
+}
+
+// nameAAD binds a sealed name to its task ID
+func nameAAD(id int) []byte {
+	return binary.LittleEndian.AppendUint64(nil, uint64(id))
+}
+
+// subtasksAAD binds sealed subtasks to their task ID, distinct from the
+// name so the two can't be swapped
+func subtasksAAD(id int) []byte {
+	return append(nameAAD(id), 's')
+}
+
+func headerChecksum(hdr []byte) uint32 {
+	return crc32.ChecksumIEEE(hdr[:hdrCRC])
+}
+
+func encodeHeader(hdr []byte, nextID uint64) {
+	copy(hdr, magic)
+	binary.LittleEndian.PutUint32(hdr[hdrVersion:], uint32(recordFormat.Current()))
+	binary.LittleEndian.PutUint32(hdr[hdrRecordSize:], RecordSize)
+	binary.LittleEndian.PutUint64(hdr[hdrNextID:], nextID)
+	binary.LittleEndian.PutUint32(hdr[hdrCRC:], headerChecksum(hdr))
+}
+
+// decodeHeader returns the stored next ID; valid is false when the header
+// checksum fails and the ID must be recovered from the records
+func decodeHeader(hdr []byte) (nextID uint64, valid bool) {
+	if binary.LittleEndian.Uint32(hdr[hdrCRC:]) != headerChecksum(hdr) {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint64(hdr[hdrNextID:]), true
+}
@@ -9,18 +9,40 @@ import (
 
 // MemoryStore provides an in-memory storage implementation using a map and mutex
 type MemoryStore struct {
-	tasks  map[int]*entities.Task // Map to store tasks by ID
-	mu     sync.RWMutex           // Read-write mutex for thread safety
-	nextID int                    // Auto-incrementing ID counter
+	tasks    map[int]*entities.Task // Map to store tasks by ID
+	capacity int                    // Size hint tasks was made with
+	mu       sync.RWMutex           // Read-write mutex for thread safety
+	nextID   int                    // Auto-incrementing ID counter
+	keyMu    sync.Mutex             // Serializes upserts; separate from mu, which their writes take
+	keys     map[string]int         // External key -> task ID, for upserts
 }
 
 func NewMemoryStore() *MemoryStore {
 	return &MemoryStore{
 		tasks:  make(map[int]*entities.Task),
 		nextID: 1,
+		keys:   make(map[string]int),
 	}
 }
 
+// Preallocate makes room for n more tasks. A map cannot grow in place, so
+// when the current one is too small it is copied into a bigger one, which
+// blocks the store for the length of the copy.
+func (s *MemoryStore) Preallocate(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	need := len(s.tasks) + n
+	if n <= 0 || need <= s.capacity {
+		return
+	}
+	tasks := make(map[int]*entities.Task, need)
+	for id, task := range s.tasks {
+		tasks[id] = task
+	}
+	s.tasks, s.capacity = tasks, need
+}
+
 // Create stores a new task with an auto-generated ID
 func (s *MemoryStore) Create(task *entities.Task) *apperrors.AppError {
 	s.mu.Lock()
@@ -56,6 +78,24 @@ func (s *MemoryStore) GetAll() []*entities.Task {
 	return tasks
 }
 
+// GetRange returns tasks with fromID <= ID <= toID in ID order. IDs are
+// assigned sequentially, so it walks the IDs instead of sorting the map.
+func (s *MemoryStore) GetRange(fromID, toID, limit int) []*entities.Task {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tasks := make([]*entities.Task, 0)
+	for id := max(fromID, 1); id <= min(toID, s.nextID-1); id++ {
+		if limit > 0 && len(tasks) == limit {
+			break
+		}
+		if task, exists := s.tasks[id]; exists {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
 // Update modifies an existing task by ID, returns error if not found
 func (s *MemoryStore) Update(id int, updatedTask *entities.Task) *apperrors.AppError {
 	s.mu.Lock()
@@ -82,3 +122,40 @@ func (s *MemoryStore) Delete(id int) *apperrors.AppError {
 	delete(s.tasks, id)
 	return nil
 }
+
+// BulkLoad stores tasks under the IDs they carry under a single lock and
+// moves the ID counter past the highest of them. Tasks are checked before
+// any is stored, so a bad batch changes nothing.
+func (s *MemoryStore) BulkLoad(tasks []*entities.Task) *apperrors.AppError {
+	maxID := 0
+	for _, task := range tasks {
+		if task == nil {
+			return apperrors.ErrTaskCannotBeNil
+		}
+		if task.ID <= 0 {
+			return apperrors.ErrInvalidID.WithMessage("bulk-loaded tasks need a positive ID")
+		}
+		maxID = max(maxID, task.ID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, task := range tasks {
+		s.tasks[task.ID] = task
+	}
+	s.nextID = max(s.nextID, maxID+1)
+	return nil
+}
+
+// WithKey runs write holding the key index lock, so upserts run one at a time
+func (s *MemoryStore) WithKey(key string, write func(id int) (int, *apperrors.AppError)) *apperrors.AppError {
+	s.keyMu.Lock()
+	defer s.keyMu.Unlock()
+
+	next, err := write(s.keys[key])
+	if err != nil {
+		return err
+	}
+	s.keys[key] = next
+	return nil
+}
@@ -1,6 +1,7 @@
 package naive
 
 import (
+	"math"
 	"tasks-service-demo/internal/entities"
 	"testing"
 )
@@ -154,3 +155,87 @@ func TestMemoryStore_ConcurrentAccess(t *testing.T) {
 		t.Errorf("Expected 2 tasks after concurrent creates, got %d", len(tasks))
 	}
 }
+
+func TestMemoryStore_GetRange(t *testing.T) {
+	store := NewMemoryStore()
+	for i := 0; i < 6; i++ {
+		store.Create(&entities.Task{Name: "task"})
+	}
+	store.Delete(1)
+	store.Delete(3)
+
+	cases := []struct {
+		from, to, limit int
+		expected        []int
+	}{
+		{2, 5, 0, []int{2, 4, 5}},
+		{1, math.MaxInt, 2, []int{2, 4}},
+		{5, 5, 0, []int{5}},
+		{7, 100, 0, []int{}},
+	}
+	for _, c := range cases {
+		var ids []int
+		for _, task := range store.GetRange(c.from, c.to, c.limit) {
+			ids = append(ids, task.ID)
+		}
+		if len(ids) != len(c.expected) {
+			t.Errorf("Expected %v for [%d, %d] limit %d, got %v", c.expected, c.from, c.to, c.limit, ids)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != c.expected[i] {
+				t.Errorf("Expected %v for [%d, %d] limit %d, got %v", c.expected, c.from, c.to, c.limit, ids)
+				break
+			}
+		}
+	}
+}
+
+func TestMemoryStore_Preallocate(t *testing.T) {
+	store := NewMemoryStore()
+	store.Create(&entities.Task{Name: "kept"})
+
+	store.Preallocate(1000)
+	if store.capacity != 1001 {
+		t.Errorf("Expected room for 1001 tasks, got %d", store.capacity)
+	}
+	if task, err := store.GetByID(1); err != nil || task.Name != "kept" {
+		t.Errorf("Expected existing tasks to survive, got %v, %v", task, err)
+	}
+
+	tasks := store.tasks
+	store.Preallocate(500)
+	if store.capacity != 1001 || len(store.tasks) != 1 {
+		t.Errorf("Expected no reallocation while there is room, got capacity %d", store.capacity)
+	}
+	store.Create(&entities.Task{Name: "added"})
+	if tasks[2] == nil {
+		t.Error("Expected the map to be reused")
+	}
+}
+
+func TestMemoryStore_BulkLoad(t *testing.T) {
+	store := NewMemoryStore()
+	if err := store.BulkLoad([]*entities.Task{{ID: 5, Name: "five"}, {ID: 2, Name: "two"}}); err != nil {
+		t.Fatal(err)
+	}
+	if task, err := store.GetByID(5); err != nil || task.Name != "five" {
+		t.Errorf("Expected task 5 under its own ID, got %+v, %v", task, err)
+	}
+	if tasks := store.GetRange(1, 10, 0); len(tasks) != 2 || tasks[0].ID != 2 {
+		t.Errorf("Expected both tasks in range order, got %+v", tasks)
+	}
+
+	task := &entities.Task{Name: "next"}
+	store.Create(task)
+	if task.ID != 6 {
+		t.Errorf("Expected IDs to continue after the loaded ones, got %d", task.ID)
+	}
+
+	if err := store.BulkLoad([]*entities.Task{{ID: 9}, nil}); err == nil {
+		t.Error("Expected a nil task to be rejected")
+	}
+	if _, err := store.GetByID(9); err == nil {
+		t.Error("Expected a rejected batch to store nothing")
+	}
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"encoding/base64"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"tasks-service-demo/internal/entities"
+)
+
+// Pager is implemented by stores that can list their tasks one page at a
+// time at a cost that follows the page size rather than the number of
+// tasks, such as sharded stores that read one shard at a time. Pages follow
+// the order of the store, e.g. shard by shard, not the order of IDs.
+type Pager interface {
+	// GetPage returns up to limit tasks after position (shard, afterID), the
+	// position of the next page, and whether the listing is done. The first
+	// page starts at (0, 0).
+	GetPage(shard, afterID, limit int) (tasks []*entities.Task, nextShard, nextAfterID int, done bool)
+}
+
+// pageCursorPrefix versions the cursor format, like the sync cursor
+const pageCursorPrefix = "p1:"
+
+// ErrInvalidPageCursor is returned for cursors not produced by PageCursor.Encode
+var ErrInvalidPageCursor = errors.New("invalid page cursor")
+
+// PageCursor is the position of a page: the shard being listed and the last
+// ID returned from it. Stores without shards only use AfterID.
+type PageCursor struct {
+	Shard   int
+	AfterID int
+}
+
+// Encode returns the opaque form of the cursor handed to clients
+func (c PageCursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(pageCursorPrefix + strconv.Itoa(c.Shard) + ":" + strconv.Itoa(c.AfterID)))
+}
+
+// DecodePageCursor returns the position of a cursor; the empty cursor is the
+// first page
+func DecodePageCursor(cursor string) (PageCursor, error) {
+	if cursor == "" {
+		return PageCursor{}, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return PageCursor{}, ErrInvalidPageCursor
+	}
+	rest, ok := strings.CutPrefix(string(raw), pageCursorPrefix)
+	shardStr, afterStr, found := strings.Cut(rest, ":")
+	if !ok || !found {
+		return PageCursor{}, ErrInvalidPageCursor
+	}
+	shard, err1 := strconv.Atoi(shardStr)
+	afterID, err2 := strconv.Atoi(afterStr)
+	if err1 != nil || err2 != nil || shard < 0 || afterID < 0 {
+		return PageCursor{}, ErrInvalidPageCursor
+	}
+	return PageCursor{Shard: shard, AfterID: afterID}, nil
+}
+
+// GetPage returns up to limit tasks after cursor with the first Pager in the
+// decorator chain, and with GetRange in ID order otherwise. Decorators that
+// add to or filter the listing implement Snapshotter (see Snapshot); the
+// search stops at them, so their listing is the one paged. more is false
+// on the last page.
+func GetPage(store Store, cursor PageCursor, limit int) (tasks []*entities.Task, next PageCursor, more bool) {
+	for s, ok := store, store != nil; ok; s, ok = inner(s) {
+		if pager, ok := s.(Pager); ok {
+			tasks, shard, afterID, done := pager.GetPage(cursor.Shard, cursor.AfterID, limit)
+			return tasks, PageCursor{Shard: shard, AfterID: afterID}, !done
+		}
+		if _, ok := s.(Snapshotter); ok {
+			break
+		}
+	}
+
+	if cursor.AfterID == math.MaxInt {
+		return []*entities.Task{}, cursor, false
+	}
+	// One task past the page tells whether another page follows
+	tasks = store.GetRange(cursor.AfterID+1, math.MaxInt, limit+1)
+	if len(tasks) > limit {
+		tasks = tasks[:limit]
+		more = true
+	}
+	next = cursor
+	if len(tasks) > 0 {
+		next.AfterID = tasks[len(tasks)-1].ID
+	}
+	return tasks, next, more
+}
@@ -0,0 +1,24 @@
+package storage
+
+import (
+	"sort"
+
+	"tasks-service-demo/internal/entities"
+)
+
+// SelectRange returns the tasks with fromID <= ID <= toID in ID order, at
+// most limit of them (limit <= 0 means no limit). It implements GetRange for
+// stores that cannot iterate in ID order.
+func SelectRange(tasks []*entities.Task, fromID, toID, limit int) []*entities.Task {
+	selected := make([]*entities.Task, 0)
+	for _, task := range tasks {
+		if task.ID >= fromID && task.ID <= toID {
+			selected = append(selected, task)
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].ID < selected[j].ID })
+	if limit > 0 && len(selected) > limit {
+		selected = selected[:limit]
+	}
+	return selected
+}
@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"testing"
+
+	"tasks-service-demo/internal/entities"
+)
+
+func TestSelectRange(t *testing.T) {
+	tasks := []*entities.Task{{ID: 5}, {ID: 1}, {ID: 3}, {ID: 9}, {ID: 2}}
+
+	selected := SelectRange(tasks, 2, 5, 0)
+	if len(selected) != 3 || selected[0].ID != 2 || selected[1].ID != 3 || selected[2].ID != 5 {
+		t.Errorf("Expected tasks 2, 3 and 5 in order, got %+v", selected)
+	}
+	if selected := SelectRange(tasks, 1, 9, 2); len(selected) != 2 || selected[1].ID != 2 {
+		t.Errorf("Expected first 2 tasks, got %+v", selected)
+	}
+	if selected := SelectRange(tasks, 10, 20, 0); selected == nil || len(selected) != 0 {
+		t.Errorf("Expected empty non-nil slice, got %+v", selected)
+	}
+}
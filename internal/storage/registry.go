@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"tasks-service-demo/internal/compress"
+	"tasks-service-demo/internal/crypt"
+)
+
+// Options configure a backend created through the registry
+type Options struct {
+	ShardCount int                  // Partitions for sharded backends
+	LockSample int                  // Sharded backends time one in this many shard lock acquisitions; 0 disables
+	Expected   int                  // In-memory backends size their maps for this many tasks; 0 uses their defaults
+	DataDir    string               // Data directory for durable backends
+	SyncWrites bool                 // Durable backends flush each write to disk before acknowledging it
+	Group      GroupCommit          // With SyncWrites, mmap shares one flush among a batch of writes; badger batches concurrent flushes itself
+	TaskTTL    time.Duration        // Expiring backends drop tasks this long after their last write; 0 disables
+	GCInterval time.Duration        // Expiring backends reclaim space from expired tasks this often
+	Sealer     *crypt.Sealer        // Durable backends encrypt tasks at rest with it; nil stores plaintext
+	Compressor *compress.Compressor // badger compresses large tasks with it; nil stores them as is
+}
+
+// GroupCommit batches the flushes of SyncWrites: writes arriving within
+// MaxDelay of the first one of a batch are acknowledged after one flush
+type GroupCommit struct {
+	MaxDelay time.Duration // 0 flushes each write on its own
+	MaxBatch int           // A batch this large is flushed without waiting out MaxDelay
+}
+
+// Enabled reports whether writes are flushed in batches
+func (g GroupCommit) Enabled() bool {
+	return g.MaxDelay > 0
+}
+
+// Factory creates a backend store
+type Factory func(opts Options) (Store, error)
+
+// Backend describes a storage implementation selectable with STORAGE_TYPE
+type Backend struct {
+	Name        string
+	Description string // Logged when the backend is selected
+	Sharded     bool   // Honours Options.ShardCount and LockSample
+	Durable     bool   // Honours Options.DataDir, SyncWrites and Sealer
+	Expiring    bool   // Honours Options.TaskTTL and GCInterval
+	New         Factory
+}
+
+var (
+	registryMu sync.RWMutex
+	backends   = make(map[string]Backend)
+)
+
+// Register adds a backend. Names are unique, so a plugin cannot replace a
+// built-in backend.
+func Register(b Backend) error {
+	if b.Name == "" || b.New == nil {
+		return fmt.Errorf("storage backend needs a name and a factory")
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, exists := backends[b.Name]; exists {
+		return fmt.Errorf("storage backend %q is already registered", b.Name)
+	}
+	backends[b.Name] = b
+	return nil
+}
+
+// Lookup returns the backend registered as name
+func Lookup(name string) (Backend, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	b, ok := backends[name]
+	return b, ok
+}
+
+// Backends returns the registered backend names, sorted
+func Backends() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"testing"
+)
+
+func TestRegister(t *testing.T) {
+	defer func() {
+		registryMu.Lock()
+		delete(backends, "test-backend")
+		registryMu.Unlock()
+	}()
+
+	factory := func(Options) (Store, error) { return nil, nil }
+	if err := Register(Backend{Name: "test-backend", New: factory}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Register(Backend{Name: "test-backend", New: factory}); err == nil {
+		t.Error("Expected duplicate name to be rejected")
+	}
+	if err := Register(Backend{Name: "no-factory"}); err == nil {
+		t.Error("Expected missing factory to be rejected")
+	}
+
+	if _, ok := Lookup("test-backend"); !ok {
+		t.Error("Expected registered backend to be found")
+	}
+	found := false
+	for _, name := range Backends() {
+		found = found || name == "test-backend"
+	}
+	if !found {
+		t.Errorf("Expected test-backend in %v", Backends())
+	}
+}
@@ -0,0 +1,61 @@
+package shard
+
+import (
+	"sync/atomic"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+)
+
+// SetMany stores tasks, which must all belong to this unit, under one lock
+func (s *Unit[T]) SetMany(tasks []T) {
+	taken := s.lock()
+	for _, task := range tasks {
+		s.tasks[task.GetID()] = task
+	}
+	s.unlock(taken)
+}
+
+// bulkLoad partitions tasks by the unit shardOf picks, stores each
+// partition with a single lock acquisition and raises nextID, the last ID
+// handed out, to the highest ID loaded. Tasks are checked before any is
+// stored, so a bad batch changes nothing.
+func bulkLoad(units []*ShardUnit, nextID *int64, tasks []*entities.Task, shardOf func(id int) int) *apperrors.AppError {
+	groups := make([][]*entities.Task, len(units))
+	maxID := 0
+	for _, task := range tasks {
+		if task == nil {
+			return apperrors.ErrTaskCannotBeNil
+		}
+		if task.ID <= 0 {
+			return apperrors.ErrInvalidID.WithMessage("bulk-loaded tasks need a positive ID")
+		}
+		shard := shardOf(task.ID)
+		groups[shard] = append(groups[shard], task)
+		maxID = max(maxID, task.ID)
+	}
+
+	for shard, group := range groups {
+		if len(group) > 0 {
+			units[shard].SetMany(group)
+		}
+	}
+	for {
+		last := atomic.LoadInt64(nextID)
+		if int64(maxID) <= last || atomic.CompareAndSwapInt64(nextID, last, int64(maxID)) {
+			return nil
+		}
+	}
+}
+
+// BulkLoad stores tasks under the IDs they carry, locking each shard once,
+// and moves the ID counter past the highest of them
+func (s *ShardStore) BulkLoad(tasks []*entities.Task) *apperrors.AppError {
+	return bulkLoad(s.shards, &s.nextID, tasks, s.getShardByID)
+}
+
+// BulkLoad stores tasks under the IDs they carry, locking each shard once,
+// and moves the ID counter past the highest of them
+func (s *ShardStoreGopool) BulkLoad(tasks []*entities.Task) *apperrors.AppError {
+	return bulkLoad(s.shards, &s.nextID, tasks, s.getShardByID)
+}
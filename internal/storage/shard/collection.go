@@ -0,0 +1,109 @@
+package shard
+
+import (
+	"sync/atomic"
+
+	"tasks-service-demo/internal/entities"
+)
+
+// Collection is a sharded in-memory collection of any entity type: the
+// units, ID counter and power-of-2 shard selection of ShardStore, for
+// resources other than tasks. It reports "not found" with false, leaving
+// the error to the resource's service.
+type Collection[T entities.Entity] struct {
+	units  []*Unit[T]
+	mask   int
+	nextID int64 // Last ID handed out
+}
+
+// NewCollection creates a collection with numShards shards, rounded up to a
+// power of 2
+func NewCollection[T entities.Entity](numShards int) *Collection[T] {
+	numShards = nextPowerOfTwo(max(numShards, 1))
+	units := make([]*Unit[T], numShards)
+	for i := range units {
+		units[i] = NewUnit[T](0)
+	}
+	return &Collection[T]{units: units, mask: numShards - 1}
+}
+
+// ShardOf returns the index of the shard holding id
+func (c *Collection[T]) ShardOf(id int) int {
+	return id & c.mask
+}
+
+// Create stores item under the next ID, which it sets on item and returns
+func (c *Collection[T]) Create(item T) int {
+	id := int(atomic.AddInt64(&c.nextID, 1))
+	item.SetID(id)
+	c.units[c.ShardOf(id)].Set(id, item)
+	return id
+}
+
+// Get returns the item with id
+func (c *Collection[T]) Get(id int) (T, bool) {
+	return c.units[c.ShardOf(id)].Get(id)
+}
+
+// Update replaces the item with id, reporting whether it existed
+func (c *Collection[T]) Update(id int, item T) bool {
+	item.SetID(id)
+	return c.units[c.ShardOf(id)].Update(id, item)
+}
+
+// Delete removes the item with id, reporting whether it existed
+func (c *Collection[T]) Delete(id int) bool {
+	return c.units[c.ShardOf(id)].Delete(id)
+}
+
+// All returns every item, in no particular order. Shards are read one after
+// another; use Snapshot for a consistent copy.
+func (c *Collection[T]) All() []T {
+	items := make([]T, 0, c.Len())
+	for _, u := range c.units {
+		items = append(items, u.GetAll()...)
+	}
+	return items
+}
+
+// Snapshot returns every item as of a single instant
+func (c *Collection[T]) Snapshot() []T {
+	return snapshot(c.units)
+}
+
+// Range returns the items with fromID <= ID <= toID in ID order, at most
+// limit of them (limit <= 0 means no limit)
+func (c *Collection[T]) Range(fromID, toID, limit int) []T {
+	items := make([]T, 0)
+	for _, u := range c.units {
+		items = append(items, u.GetRange(fromID, toID, limit)...)
+	}
+	return sortAndLimit(items, limit)
+}
+
+// GetMany returns the items with ids found and the IDs missing, both in the
+// order of ids
+func (c *Collection[T]) GetMany(ids []int) ([]T, []int) {
+	return getMany(c.units, ids, c.ShardOf, func(_ int, fn func()) { go fn() })
+}
+
+// Len returns the number of items
+func (c *Collection[T]) Len() int {
+	n := 0
+	for _, u := range c.units {
+		n += u.Count()
+	}
+	return n
+}
+
+// SampleLocks times one in every lock acquisitions of each shard
+func (c *Collection[T]) SampleLocks(every int) {
+	for _, u := range c.units {
+		u.SampleLocks(every)
+	}
+}
+
+// ShardStats returns the item count and lock statistics of each shard
+func (c *Collection[T]) ShardStats() []Stats {
+	return unitStats(c.units)
+}
@@ -0,0 +1,30 @@
+package shard
+
+import (
+	"testing"
+
+	"tasks-service-demo/internal/entities"
+)
+
+func TestCollection(t *testing.T) {
+	c := NewCollection[*entities.Task](3)
+	if len(c.units) != 4 {
+		t.Fatalf("Expected the shard count rounded up to 4, got %d", len(c.units))
+	}
+	c.SampleLocks(1)
+	for i := 0; i < 10; i++ {
+		c.Create(&entities.Task{Name: "t"})
+	}
+
+	stats := c.ShardStats()
+	if len(stats) != 4 || stats[1].Tasks != 3 || stats[0].Tasks != 2 || stats[0].Sampled == 0 {
+		t.Errorf("Expected IDs spread by their low bits with sampled locks, got %+v", stats)
+	}
+	if snap := c.Snapshot(); len(snap) != 10 {
+		t.Errorf("Expected a snapshot of 10 tasks, got %d", len(snap))
+	}
+	found, missing := c.GetMany([]int{3, 42, 7})
+	if len(found) != 2 || found[0].ID != 3 || found[1].ID != 7 || len(missing) != 1 || missing[0] != 42 {
+		t.Errorf("Expected tasks 3 and 7 and 42 missing, got %v, %v", found, missing)
+	}
+}
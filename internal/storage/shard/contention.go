@@ -0,0 +1,167 @@
+package shard
+
+import (
+	"sync/atomic"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+)
+
+// DefaultLockSampling times one in this many lock acquisitions of a unit
+const DefaultLockSampling = 64
+
+// Stats is the load and lock contention of one shard. Only a sample of the
+// lock acquisitions is timed: Wait and Hold are totals over the Sampled ones.
+type Stats struct {
+	Shard        int
+	Tasks        int
+	Acquisitions int64 // All lock acquisitions, counted while sampling is on
+	Sampled      int64
+	Wait         time.Duration // Time spent waiting for the lock
+	Hold         time.Duration // Time the lock was held
+	MaxWait      time.Duration
+	MaxHold      time.Duration
+}
+
+// Reporter is implemented by sharded stores that report per-shard stats
+type Reporter interface {
+	ShardStats() []Stats
+}
+
+// lockStats counts the lock acquisitions of a ShardUnit and times a sample
+// of them: how long the caller waited for the lock and how long it held it.
+// Timing every acquisition would cost more than the map access it guards.
+type lockStats struct {
+	every int64 // Time one in every acquisitions; 0 disables, set before use
+
+	acquisitions atomic.Int64
+	sampled      atomic.Int64
+	waitNanos    atomic.Int64
+	holdNanos    atomic.Int64
+	maxWait      atomic.Int64
+	maxHold      atomic.Int64
+}
+
+// begin counts an acquisition and returns the time it started when it is
+// sampled, the zero time otherwise
+func (l *lockStats) begin() time.Time {
+	if l.every <= 0 || l.acquisitions.Add(1)%l.every != 0 {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// acquired records the wait of an acquisition that began at start and
+// returns the time the lock was taken, zero when it is not sampled
+func (l *lockStats) acquired(start time.Time) time.Time {
+	if start.IsZero() {
+		return start
+	}
+	now := time.Now()
+	wait := int64(now.Sub(start))
+	l.waitNanos.Add(wait)
+	storeMax(&l.maxWait, wait)
+	return now
+}
+
+// released records the hold time of a lock taken at taken
+func (l *lockStats) released(taken time.Time) {
+	if taken.IsZero() {
+		return
+	}
+	hold := int64(time.Since(taken))
+	l.holdNanos.Add(hold)
+	storeMax(&l.maxHold, hold)
+	l.sampled.Add(1)
+}
+
+// storeMax raises m to v if v is larger
+func storeMax(m *atomic.Int64, v int64) {
+	for {
+		cur := m.Load()
+		if v <= cur || m.CompareAndSwap(cur, v) {
+			return
+		}
+	}
+}
+
+// SampleLocks times one in every lock acquisitions of the unit; 0 disables
+// sampling. Call it before the unit is used.
+func (s *Unit[T]) SampleLocks(every int) {
+	s.locks.every = int64(every)
+}
+
+// lock write-locks the unit and returns the time it was taken if sampled
+func (s *Unit[T]) lock() time.Time {
+	start := s.locks.begin()
+	s.mu.Lock()
+	return s.locks.acquired(start)
+}
+
+// unlock releases a write lock taken at taken
+func (s *Unit[T]) unlock(taken time.Time) {
+	s.locks.released(taken)
+	s.mu.Unlock()
+}
+
+// rlock read-locks the unit and returns the time it was taken if sampled
+func (s *Unit[T]) rlock() time.Time {
+	start := s.locks.begin()
+	s.mu.RLock()
+	return s.locks.acquired(start)
+}
+
+// runlock releases a read lock taken at taken
+func (s *Unit[T]) runlock(taken time.Time) {
+	s.locks.released(taken)
+	s.mu.RUnlock()
+}
+
+// Stats returns the task count and lock statistics of the unit
+func (s *Unit[T]) Stats() Stats {
+	return Stats{
+		Tasks:        s.Count(),
+		Acquisitions: s.locks.acquisitions.Load(),
+		Sampled:      s.locks.sampled.Load(),
+		Wait:         time.Duration(s.locks.waitNanos.Load()),
+		Hold:         time.Duration(s.locks.holdNanos.Load()),
+		MaxWait:      time.Duration(s.locks.maxWait.Load()),
+		MaxHold:      time.Duration(s.locks.maxHold.Load()),
+	}
+}
+
+// unitStats returns the stats of each unit, indexed by shard
+func unitStats[T entities.Entity](units []*Unit[T]) []Stats {
+	stats := make([]Stats, len(units))
+	for i, u := range units {
+		stats[i] = u.Stats()
+		stats[i].Shard = i
+	}
+	return stats
+}
+
+// SampleLocks times one in every lock acquisitions of each shard; 0
+// disables sampling. Call it before the store is used.
+func (s *ShardStore) SampleLocks(every int) {
+	for _, u := range s.shards {
+		u.SampleLocks(every)
+	}
+}
+
+// ShardStats returns the task count and lock statistics of each shard
+func (s *ShardStore) ShardStats() []Stats {
+	return unitStats(s.shards)
+}
+
+// SampleLocks times one in every lock acquisitions of each shard; 0
+// disables sampling. Call it before the store is used.
+func (s *ShardStoreGopool) SampleLocks(every int) {
+	for _, u := range s.shards {
+		u.SampleLocks(every)
+	}
+}
+
+// ShardStats returns the task count and lock statistics of each shard
+func (s *ShardStoreGopool) ShardStats() []Stats {
+	return unitStats(s.shards)
+}
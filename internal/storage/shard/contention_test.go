@@ -0,0 +1,78 @@
+package shard
+
+import (
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+)
+
+func TestShardUnit_LockSampling(t *testing.T) {
+	unit := NewShardUnit(0)
+	unit.Set(1, &entities.Task{ID: 1})
+	if stats := unit.Stats(); stats.Acquisitions != 0 || stats.Sampled != 0 || stats.Tasks != 1 {
+		t.Errorf("Expected no lock stats without sampling, got %+v", stats)
+	}
+
+	unit.SampleLocks(2)
+	for i := 0; i < 10; i++ {
+		unit.Get(1)
+	}
+	if stats := unit.Stats(); stats.Acquisitions != 10 || stats.Sampled != 5 {
+		t.Errorf("Expected 10 acquisitions with 5 sampled, got %+v", stats)
+	}
+}
+
+func TestShardUnit_LockWaitAndHold(t *testing.T) {
+	unit := NewShardUnit(0)
+	unit.SampleLocks(1)
+
+	unit.mu.Lock()
+	done := make(chan struct{})
+	go func() {
+		unit.Set(1, &entities.Task{ID: 1})
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	unit.mu.Unlock()
+	<-done
+
+	stats := unit.Stats()
+	if stats.Sampled != 1 || stats.MaxWait < 20*time.Millisecond || stats.Wait != stats.MaxWait {
+		t.Errorf("Expected one sampled wait of at least 20ms, got %+v", stats)
+	}
+	if stats.Hold <= 0 || stats.Hold > stats.Wait {
+		t.Errorf("Expected a short hold, got %+v", stats)
+	}
+}
+
+func TestShardStore_ShardStats(t *testing.T) {
+	for name, store := range map[string]interface {
+		Reporter
+		Create(task *entities.Task) *apperrors.AppError
+		GetByID(id int) (*entities.Task, *apperrors.AppError)
+		SampleLocks(every int)
+	}{
+		"shard":  NewShardStore(4),
+		"gopool": NewShardStoreGopool(4),
+	} {
+		store.SampleLocks(1)
+		for i := 0; i < 6; i++ {
+			store.Create(&entities.Task{Name: "task"})
+		}
+		store.GetByID(1)
+
+		stats := store.ShardStats()
+		if len(stats) != 4 {
+			t.Fatalf("%s: expected 4 shards, got %d", name, len(stats))
+		}
+		// IDs 1..6 land on shards 1, 2, 3, 0, 1, 2
+		if stats[1].Shard != 1 || stats[1].Tasks != 2 || stats[1].Acquisitions != 3 || stats[1].Sampled != 3 {
+			t.Errorf("%s: expected 2 tasks and 3 locks on shard 1, got %+v", name, stats[1])
+		}
+		if stats[0].Tasks != 1 || stats[0].Acquisitions != 1 {
+			t.Errorf("%s: expected 1 task and 1 lock on shard 0, got %+v", name, stats[0])
+		}
+	}
+}
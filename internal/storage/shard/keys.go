@@ -0,0 +1,49 @@
+package shard
+
+import (
+	"hash/fnv"
+	"sync"
+
+	apperrors "tasks-service-demo/internal/errors"
+)
+
+// keyIndex maps external keys to task IDs, sharded by key hash with a
+// mutex per shard. It is kept apart from the shard units: an upsert holds
+// its key's lock while it writes the task to whichever unit owns the ID,
+// and snapshots lock every unit, so sharing the units' locks could
+// deadlock.
+type keyIndex struct {
+	shards []keyShard
+	mask   int
+}
+
+type keyShard struct {
+	mu  sync.Mutex
+	ids map[string]int
+}
+
+// newKeyIndex creates a key index with numShards shards, a power of 2
+func newKeyIndex(numShards int) *keyIndex {
+	k := &keyIndex{shards: make([]keyShard, numShards), mask: numShards - 1}
+	for i := range k.shards {
+		k.shards[i].ids = make(map[string]int)
+	}
+	return k
+}
+
+// withKey runs write holding the lock of key's shard, so upserts of the
+// same key run one at a time and keys in other shards go on
+func (k *keyIndex) withKey(key string, write func(id int) (int, *apperrors.AppError)) *apperrors.AppError {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	shard := &k.shards[int(h.Sum32())&k.mask]
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	next, err := write(shard.ids[key])
+	if err != nil {
+		return err
+	}
+	shard.ids[key] = next
+	return nil
+}
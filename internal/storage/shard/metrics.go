@@ -0,0 +1,58 @@
+package shard
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collector exports per-shard load and lock contention as Prometheus metrics
+type collector struct {
+	reporter Reporter
+
+	tasks, acquisitions, sampled, wait, hold, maxWait, maxHold *prometheus.Desc
+}
+
+// NewCollector returns a Prometheus collector for the shards of reporter,
+// labelled by shard index. Register it once per store.
+func NewCollector(namespace string, reporter Reporter) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "shard", name), help, []string{"shard"}, nil)
+	}
+
+	return &collector{
+		reporter:     reporter,
+		tasks:        desc("tasks", "Tasks held by the shard."),
+		acquisitions: desc("lock_acquisitions_total", "Lock acquisitions of the shard while sampling is on."),
+		sampled:      desc("lock_samples_total", "Lock acquisitions whose wait and hold times were measured."),
+		wait:         desc("lock_wait_seconds_total", "Time sampled acquisitions waited for the shard lock."),
+		hold:         desc("lock_hold_seconds_total", "Time sampled acquisitions held the shard lock."),
+		maxWait:      desc("lock_wait_max_seconds", "Longest sampled wait for the shard lock."),
+		maxHold:      desc("lock_hold_max_seconds", "Longest sampled hold of the shard lock."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.tasks
+	ch <- c.acquisitions
+	ch <- c.sampled
+	ch <- c.wait
+	ch <- c.hold
+	ch <- c.maxWait
+	ch <- c.maxHold
+}
+
+// Collect implements prometheus.Collector
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range c.reporter.ShardStats() {
+		shard := strconv.Itoa(s.Shard)
+		ch <- prometheus.MustNewConstMetric(c.tasks, prometheus.GaugeValue, float64(s.Tasks), shard)
+		ch <- prometheus.MustNewConstMetric(c.acquisitions, prometheus.CounterValue, float64(s.Acquisitions), shard)
+		ch <- prometheus.MustNewConstMetric(c.sampled, prometheus.CounterValue, float64(s.Sampled), shard)
+		ch <- prometheus.MustNewConstMetric(c.wait, prometheus.CounterValue, s.Wait.Seconds(), shard)
+		ch <- prometheus.MustNewConstMetric(c.hold, prometheus.CounterValue, s.Hold.Seconds(), shard)
+		ch <- prometheus.MustNewConstMetric(c.maxWait, prometheus.GaugeValue, s.MaxWait.Seconds(), shard)
+		ch <- prometheus.MustNewConstMetric(c.maxHold, prometheus.GaugeValue, s.MaxHold.Seconds(), shard)
+	}
+}
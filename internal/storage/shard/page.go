@@ -0,0 +1,79 @@
+package shard
+
+import (
+	"sync/atomic"
+
+	"tasks-service-demo/internal/entities"
+)
+
+// page returns up to limit tasks of the unit with IDs after afterID, in ID
+// order, and the last ID returned (afterID if none). A unit holds the IDs
+// that are first modulo stride, so it looks them up one by one up to maxID;
+// when that would take more steps than it has tasks, e.g. after many
+// deletes, it sorts the rest of its map instead. done reports that no task
+// follows.
+func (s *Unit[T]) page(first, stride, afterID, maxID, limit int) (tasks []T, last int, done bool) {
+	taken := s.rlock()
+	defer s.runlock(taken)
+
+	id := first
+	if afterID >= first {
+		id = first + ((afterID-first)/stride+1)*stride
+	}
+	last = afterID
+	tasks = make([]T, 0, min(limit, len(s.tasks)))
+	for steps := 0; id <= maxID && len(tasks) < limit; steps++ {
+		if steps == len(s.tasks) {
+			// Sparse: scanning the map is cheaper than the rest of the walk
+			rest := make([]T, 0)
+			for taskID, task := range s.tasks {
+				if taskID > last {
+					rest = append(rest, task)
+				}
+			}
+			rest = sortAndLimit(rest, limit-len(tasks)+1)
+			done = len(rest) <= limit-len(tasks)
+			rest = rest[:min(len(rest), limit-len(tasks))]
+			tasks = append(tasks, rest...)
+			if len(tasks) > 0 {
+				last = tasks[len(tasks)-1].GetID()
+			}
+			return tasks, last, done
+		}
+		if task, ok := s.tasks[id]; ok {
+			tasks = append(tasks, task)
+			last = id
+		}
+		id += stride
+	}
+	return tasks, last, id > maxID
+}
+
+// getPage lists units one at a time from (shard, afterID), each in ID
+// order, so a page costs about its own size whatever the number of tasks.
+// The last page may be empty.
+func getPage[T entities.Entity](units []*Unit[T], nextID *int64, shard, afterID, limit int) (tasks []T, nextShard, nextAfterID int, done bool) {
+	maxID := int(atomic.LoadInt64(nextID)) // IDs handed out so far
+	tasks = make([]T, 0)
+	for shard < len(units) && len(tasks) < limit {
+		found, last, exhausted := units[shard].page(shard, len(units), afterID, maxID, limit-len(tasks))
+		tasks = append(tasks, found...)
+		if !exhausted {
+			return tasks, shard, last, false
+		}
+		shard, afterID = shard+1, 0
+	}
+	return tasks, shard, afterID, shard >= len(units)
+}
+
+// GetPage lists the tasks one shard at a time, each in ID order, with the
+// position of the next page encoding the shard and the last ID read from it
+func (s *ShardStore) GetPage(shard, afterID, limit int) ([]*entities.Task, int, int, bool) {
+	return getPage(s.shards, &s.nextID, shard, afterID, limit)
+}
+
+// GetPage lists the tasks one shard at a time, each in ID order, with the
+// position of the next page encoding the shard and the last ID read from it
+func (s *ShardStoreGopool) GetPage(shard, afterID, limit int) ([]*entities.Task, int, int, bool) {
+	return getPage(s.shards, &s.nextID, shard, afterID, limit)
+}
@@ -0,0 +1,103 @@
+package shard
+
+import (
+	"fmt"
+	"testing"
+
+	"tasks-service-demo/internal/entities"
+)
+
+// pageAll lists every task of store with pages of limit
+func pageAll(t *testing.T, store interface {
+	GetPage(shard, afterID, limit int) ([]*entities.Task, int, int, bool)
+}, limit int) []*entities.Task {
+	t.Helper()
+	var all []*entities.Task
+	shard, afterID := 0, 0
+	for pages := 0; ; pages++ {
+		if pages > 1000 {
+			t.Fatal("Expected the listing to end")
+		}
+		tasks, nextShard, nextAfterID, done := store.GetPage(shard, afterID, limit)
+		if len(tasks) > limit {
+			t.Fatalf("Expected at most %d tasks per page, got %d", limit, len(tasks))
+		}
+		all = append(all, tasks...)
+		if done {
+			return all
+		}
+		shard, afterID = nextShard, nextAfterID
+	}
+}
+
+func TestShardStore_GetPage(t *testing.T) {
+	store := NewShardStore(4)
+	for i := 0; i < 100; i++ {
+		store.Create(&entities.Task{Name: "t"})
+	}
+	// Empty one shard out and thin another, so pages cross gaps
+	for id := 1; id <= 100; id++ {
+		if id%4 == 2 || (id%4 == 3 && id > 10) {
+			store.Delete(id)
+		}
+	}
+
+	for _, limit := range []int{1, 7, 25, 1000} {
+		t.Run(fmt.Sprint(limit), func(t *testing.T) {
+			all := pageAll(t, store, limit)
+			if len(all) != 52 {
+				t.Fatalf("Expected 52 tasks, got %d", len(all))
+			}
+			seen := make(map[int]bool)
+			for i, task := range all {
+				if seen[task.ID] {
+					t.Fatalf("Task %d listed twice", task.ID)
+				}
+				seen[task.ID] = true
+				// Shard by shard, each in ID order
+				if i > 0 {
+					prev := all[i-1]
+					if prev.ID&3 > task.ID&3 || (prev.ID&3 == task.ID&3 && prev.ID >= task.ID) {
+						t.Fatalf("Expected shard order, got %d after %d", task.ID, prev.ID)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestShardStore_GetPageSparse(t *testing.T) {
+	store := NewShardStore(2)
+	for i := 0; i < 1000; i++ {
+		store.Create(&entities.Task{Name: "t"})
+	}
+	for id := 1; id <= 990; id++ {
+		store.Delete(id)
+	}
+
+	// Ten tasks left far past the start: the walk falls back to a scan
+	tasks, shard, afterID, done := store.GetPage(0, 0, 3)
+	if len(tasks) != 3 || tasks[0].ID != 992 || tasks[2].ID != 996 || shard != 0 || afterID != 996 || done {
+		t.Errorf("Expected tasks 992-996 of shard 0, got %v at (%d, %d), done %t", tasks, shard, afterID, done)
+	}
+	if all := pageAll(t, store, 3); len(all) != 10 {
+		t.Errorf("Expected the 10 remaining tasks, got %d", len(all))
+	}
+	if all := pageAll(t, NewShardStoreGopool(4), 3); len(all) != 0 {
+		t.Errorf("Expected an empty store listed in one page, got %v", all)
+	}
+}
+
+func BenchmarkShardStore_GetPage(b *testing.B) {
+	for _, n := range []int{10_000, 1_000_000} {
+		store := NewShardStore(16)
+		for i := 0; i < n; i++ {
+			store.Create(&entities.Task{Name: "t"})
+		}
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				store.GetPage(5, n/2, 100)
+			}
+		})
+	}
+}
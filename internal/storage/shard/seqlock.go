@@ -0,0 +1,231 @@
+package shard
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"tasks-service-demo/internal/entities"
+)
+
+// SeqShardUnit is an experimental ShardUnit whose reads take no lock, so
+// readers never hold up writers. Writers serialize on a mutex and bump a
+// sequence number before and after each change, leaving it odd while they
+// write. Readers read the sequence, look the task up with atomic loads only,
+// and retry when the sequence was odd or has moved, as the table may have
+// changed under them. After maxOptimisticReads retries a reader takes the
+// writers' mutex instead, so a flood of writes can't starve it.
+//
+// Tasks live in an open-addressing table with linear probing. Deletes shift
+// later entries back, which moves tasks between slots; that is the kind of
+// change a lock-free reader must not observe half-done.
+type SeqShardUnit struct {
+	seq   atomic.Uint64
+	mu    sync.Mutex // Serializes writers
+	table atomic.Pointer[seqTable]
+	count atomic.Int64
+}
+
+// maxOptimisticReads bounds the lock-free attempts of one read
+const maxOptimisticReads = 16
+
+type seqTable struct {
+	slots []seqSlot
+	mask  int
+}
+
+// seqSlot holds one task; id 0 marks an empty slot, as IDs are positive
+type seqSlot struct {
+	id   atomic.Int64
+	task atomic.Pointer[entities.Task]
+}
+
+// NewSeqShardUnit creates a seqlock shard unit with room for capacity tasks
+// before it grows
+func NewSeqShardUnit(capacity int) *SeqShardUnit {
+	if capacity <= 0 {
+		capacity = 64
+	}
+	u := &SeqShardUnit{}
+	u.table.Store(newSeqTable(nextPowerOfTwo(capacity * 2)))
+	return u
+}
+
+func newSeqTable(size int) *seqTable {
+	return &seqTable{slots: make([]seqSlot, size), mask: size - 1}
+}
+
+// home returns the slot id hashes to. The IDs of one shard share their low
+// bits, so they are mixed first.
+func (t *seqTable) home(id int) int {
+	h := uint64(id) * 0x9E3779B97F4A7C15
+	return int(h^h>>32) & t.mask
+}
+
+// find probes for id. Under a concurrent write it may return anything, so
+// optimistic callers must validate the sequence afterwards; the probe count
+// is bounded for the same reason.
+func (t *seqTable) find(id int) (int, bool) {
+	for i, n := t.home(id), 0; n < len(t.slots); i, n = (i+1)&t.mask, n+1 {
+		switch t.slots[i].id.Load() {
+		case int64(id):
+			return i, true
+		case 0:
+			return i, false
+		}
+	}
+	return -1, false
+}
+
+// read runs fn, which may only load from the table, until it completes
+// without a write in between; after maxOptimisticReads attempts it runs fn
+// under the writers' mutex
+func (u *SeqShardUnit) read(fn func(t *seqTable)) {
+	for attempt := 0; attempt < maxOptimisticReads; attempt++ {
+		before := u.seq.Load()
+		if before&1 == 1 {
+			runtime.Gosched() // A writer is mid-change
+			continue
+		}
+		fn(u.table.Load())
+		if u.seq.Load() == before {
+			return
+		}
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	fn(u.table.Load())
+}
+
+// write runs fn as one change that optimistic readers will retry around
+func (u *SeqShardUnit) write(fn func(t *seqTable)) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.seq.Add(1)
+	fn(u.table.Load())
+	u.seq.Add(1)
+}
+
+// Set stores a task with given ID
+func (u *SeqShardUnit) Set(id int, task *entities.Task) {
+	u.write(func(t *seqTable) {
+		i, exists := t.find(id)
+		if exists {
+			t.slots[i].task.Store(task)
+			return
+		}
+		if int(u.count.Load()+1)*2 > len(t.slots) {
+			t = u.grow(t)
+			i, _ = t.find(id)
+		}
+		t.slots[i].task.Store(task)
+		t.slots[i].id.Store(int64(id))
+		u.count.Add(1)
+	})
+}
+
+// grow publishes a table twice the size holding every task of t. Callers
+// are inside write.
+func (u *SeqShardUnit) grow(t *seqTable) *seqTable {
+	bigger := newSeqTable(len(t.slots) * 2)
+	for i := range t.slots {
+		if id := int(t.slots[i].id.Load()); id != 0 {
+			j, _ := bigger.find(id)
+			bigger.slots[j].task.Store(t.slots[i].task.Load())
+			bigger.slots[j].id.Store(int64(id))
+		}
+	}
+	u.table.Store(bigger)
+	return bigger
+}
+
+// get looks id up; see find
+func (t *seqTable) get(id int) (*entities.Task, bool) {
+	if i, ok := t.find(id); ok {
+		return t.slots[i].task.Load(), true
+	}
+	return nil, false
+}
+
+// Get retrieves a task by ID without locking
+func (u *SeqShardUnit) Get(id int) (*entities.Task, bool) {
+	var task *entities.Task
+	var exists bool
+	u.read(func(t *seqTable) { task, exists = t.get(id) })
+	return task, exists
+}
+
+// Update modifies an existing task
+func (u *SeqShardUnit) Update(id int, task *entities.Task) bool {
+	var exists bool
+	u.write(func(t *seqTable) {
+		var i int
+		if i, exists = t.find(id); exists {
+			t.slots[i].task.Store(task)
+		}
+	})
+	return exists
+}
+
+// Delete removes a task by ID, shifting the entries probed past it back so
+// lookups don't need tombstones
+func (u *SeqShardUnit) Delete(id int) bool {
+	var exists bool
+	u.write(func(t *seqTable) {
+		var hole int
+		if hole, exists = t.find(id); !exists {
+			return
+		}
+		for i := (hole + 1) & t.mask; ; i = (i + 1) & t.mask {
+			moved := int(t.slots[i].id.Load())
+			if moved == 0 {
+				break
+			}
+			// Leave entries whose home slot lies cyclically in (hole, i]
+			home := t.home(moved)
+			if (i > hole && home > hole && home <= i) || (i < hole && (home > hole || home <= i)) {
+				continue
+			}
+			t.slots[hole].task.Store(t.slots[i].task.Load())
+			t.slots[hole].id.Store(int64(moved))
+			hole = i
+		}
+		t.slots[hole].id.Store(0)
+		t.slots[hole].task.Store(nil)
+		u.count.Add(-1)
+	})
+	return exists
+}
+
+// GetAll returns all tasks in this unit as of one instant
+func (u *SeqShardUnit) GetAll() []*entities.Task {
+	var tasks []*entities.Task
+	u.read(func(t *seqTable) {
+		tasks = make([]*entities.Task, 0, u.count.Load())
+		for i := range t.slots {
+			if t.slots[i].id.Load() != 0 {
+				tasks = append(tasks, t.slots[i].task.Load())
+			}
+		}
+	})
+	return tasks
+}
+
+// GetRange returns this unit's tasks with fromID <= ID <= toID in ID order,
+// at most limit of them (limit <= 0 means no limit)
+func (u *SeqShardUnit) GetRange(fromID, toID, limit int) []*entities.Task {
+	var tasks []*entities.Task
+	u.read(func(t *seqTable) {
+		tasks = make([]*entities.Task, 0)
+		for i := range t.slots {
+			if id := int(t.slots[i].id.Load()); id != 0 && id >= fromID && id <= toID {
+				tasks = append(tasks, t.slots[i].task.Load())
+			}
+		}
+	})
+	return sortAndLimit(tasks, limit)
+}
+
+// Count returns the number of tasks in this unit
+func (u *SeqShardUnit) Count() int {
+	return int(u.count.Load())
+}
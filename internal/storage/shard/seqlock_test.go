@@ -0,0 +1,152 @@
+package shard
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"tasks-service-demo/internal/entities"
+	"testing"
+)
+
+func seqTask(id int) *entities.Task {
+	return &entities.Task{ID: id, Name: strconv.Itoa(id)}
+}
+
+func TestSeqShardUnit(t *testing.T) {
+	u := NewSeqShardUnit(4) // Small, so it grows several times
+	for id := 1; id <= 100; id++ {
+		u.Set(id*32+5, seqTask(id*32+5)) // IDs of one shard share their low bits
+	}
+	if u.Count() != 100 {
+		t.Fatalf("Expected 100 tasks, got %d", u.Count())
+	}
+
+	for id := 2; id <= 100; id += 2 {
+		if !u.Delete(id*32 + 5) {
+			t.Errorf("Expected task %d to be deleted", id*32+5)
+		}
+	}
+	if u.Delete(2*32+5) || u.Update(2*32+5, seqTask(2*32+5)) {
+		t.Error("Expected deleting and updating a deleted task to fail")
+	}
+	if !u.Update(37, &entities.Task{ID: 37, Name: "updated"}) {
+		t.Error("Expected task 37 to be updated")
+	}
+
+	for id := 1; id <= 100; id++ {
+		task, ok := u.Get(id*32 + 5)
+		if ok != (id%2 == 1) {
+			t.Errorf("Expected task %d present=%t, got %t", id*32+5, id%2 == 1, ok)
+		}
+		if ok && task.ID != id*32+5 {
+			t.Errorf("Expected task %d, got %+v", id*32+5, task)
+		}
+	}
+	if task, _ := u.Get(37); task.Name != "updated" {
+		t.Errorf("Expected the updated task 37, got %+v", task)
+	}
+	if u.Count() != 50 || len(u.GetAll()) != 50 {
+		t.Errorf("Expected 50 tasks, got %d counted and %d listed", u.Count(), len(u.GetAll()))
+	}
+	if tasks := u.GetRange(100, 200, 2); len(tasks) != 2 || tasks[0].ID != 101 || tasks[1].ID != 165 {
+		t.Errorf("Expected tasks 101 and 165, got %+v", tasks)
+	}
+}
+
+// TestSeqShardUnit_ReadRetriesAroundWrites interleaves writes with a read
+// deterministically: a read that overlaps a write is repeated, and one that
+// keeps overlapping falls back to the writers' mutex
+func TestSeqShardUnit_ReadRetriesAroundWrites(t *testing.T) {
+	u := NewSeqShardUnit(8)
+	u.Set(1, seqTask(1))
+
+	attempts := 0
+	var task *entities.Task
+	u.read(func(tbl *seqTable) {
+		attempts++
+		task, _ = tbl.get(1)
+		if attempts == 1 {
+			u.Update(1, &entities.Task{ID: 1, Name: "new"})
+		}
+	})
+	if attempts != 2 || task.Name != "new" {
+		t.Errorf("Expected one retry that sees the update, got %d attempts and %+v", attempts, task)
+	}
+
+	attempts = 0
+	u.read(func(tbl *seqTable) {
+		attempts++
+		if attempts <= maxOptimisticReads {
+			u.Set(attempts+1, seqTask(attempts+1))
+		}
+	})
+	if attempts != maxOptimisticReads+1 {
+		t.Errorf("Expected %d attempts ending under the mutex, got %d", maxOptimisticReads+1, attempts)
+	}
+}
+
+// TestSeqShardUnit_NoTornReads checks that lock-free readers never see a
+// write half-done. Stable tasks are always present; a writer keeps
+// inserting and deleting churn tasks around them, which shifts stable
+// tasks between slots, and keeps replacing the stable tasks. A torn read
+// would report a stable task missing, or return another slot's task. It
+// needs several CPUs to overlap reads and writes; run it with -race.
+func TestSeqShardUnit_NoTornReads(t *testing.T) {
+	const stable, churn = 64, 64
+	u := NewSeqShardUnit(stable + churn)
+	for id := 1; id <= stable; id++ {
+		u.Set(id, seqTask(id))
+	}
+
+	var done atomic.Bool
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer done.Store(true)
+		for round := 0; round < 2000; round++ {
+			for id := stable + 1; id <= stable+churn; id++ {
+				u.Set(id, seqTask(id))
+			}
+			u.Update(round%stable+1, seqTask(round%stable+1))
+			for id := stable + 1; id <= stable+churn; id++ {
+				u.Delete(id)
+			}
+		}
+	}()
+
+	var reads, torn atomic.Int64
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; !done.Load(); i++ {
+				id := i%stable + 1
+				task, ok := u.Get(id)
+				if !ok || task.ID != id {
+					torn.Add(1)
+				}
+				if i%64 == 0 {
+					seen := 0
+					for _, task := range u.GetAll() {
+						if task.ID <= stable {
+							seen++
+						}
+					}
+					if seen != stable {
+						torn.Add(1)
+					}
+				}
+				reads.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if torn.Load() != 0 {
+		t.Errorf("Expected no torn reads, got %d of %d", torn.Load(), reads.Load())
+	}
+	if u.Count() != stable {
+		t.Errorf("Expected %d tasks left, got %d", stable, u.Count())
+	}
+}
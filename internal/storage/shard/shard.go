@@ -1,6 +1,7 @@
 package shard
 
 import (
+	"math/bits"
 	"runtime"
 	"sync/atomic"
 	"tasks-service-demo/internal/entities"
@@ -13,6 +14,7 @@ type ShardStore struct {
 	numShards int          // Total number of shards
 	nextID    int64        // Atomic counter for lock-free ID generation
 	shardMask int          // Bitmask for power-of-2 optimization
+	keys      *keyIndex    // External key -> task ID, for upserts
 }
 
 
@@ -21,7 +23,10 @@ func isPowerOfTwo(n int) bool {
 	return n > 0 && (n&(n-1)) == 0
 }
 
-// nextPowerOfTwo returns the next power of 2 >= n
+// maxPowerOfTwo is the largest power of 2 representable as an int
+const maxPowerOfTwo = 1 << (bits.UintSize - 2)
+
+// nextPowerOfTwo returns the next power of 2 >= n, clamped to maxPowerOfTwo
 func nextPowerOfTwo(n int) int {
 	if n <= 1 {
 		return 1
@@ -30,6 +35,10 @@ func nextPowerOfTwo(n int) int {
 	if isPowerOfTwo(n) {
 		return n
 	}
+	// Avoid shifting past the sign bit, which would loop forever
+	if n > maxPowerOfTwo {
+		return maxPowerOfTwo
+	}
 
 	// Find next power of 2
 	power := 1
@@ -68,11 +77,17 @@ func NewShardStore(numShards int) *ShardStore {
 		numShards: numShards,
 		nextID:    0, // Start from 0 for atomic operations
 		shardMask: shardMask,
+		keys:      newKeyIndex(numShards),
 	}
 
 	return store
 }
 
+// ShardOf returns the index of the shard holding id
+func (s *ShardStore) ShardOf(id int) int {
+	return s.getShardByID(id)
+}
+
 // getShardByID returns the shard index for a given ID using bitwise AND
 // For power-of-2 shard counts, bitwise AND is faster than modulo
 func (s *ShardStore) getShardByID(id int) int {
@@ -144,6 +159,44 @@ func (s *ShardStore) GetAll() []*entities.Task {
 	return allTasks
 }
 
+// Snapshot retrieves all tasks as of a single instant. GetAll reads the
+// shards at different times, so a write racing it can show up in one shard
+// but not in another; Snapshot blocks writes for the duration of the copy
+// instead. Use it for exports and backups.
+func (s *ShardStore) Snapshot() []*entities.Task {
+	return snapshot(s.shards)
+}
+
+// GetMany retrieves a batch of tasks, reading each shard that holds one of
+// ids once and the shards in parallel
+func (s *ShardStore) GetMany(ids []int) ([]*entities.Task, []int, *apperrors.AppError) {
+	found, missing := getMany(s.shards, ids, s.getShardByID, func(_ int, fn func()) { go fn() })
+	return found, missing, nil
+}
+
+// WithKey runs write holding the lock of key's shard of the key index, so
+// upserts of the same key run one at a time
+func (s *ShardStore) WithKey(key string, write func(id int) (int, *apperrors.AppError)) *apperrors.AppError {
+	return s.keys.withKey(key, write)
+}
+
+// GetRange scans all shards in parallel for tasks with fromID <= ID <= toID.
+// Each shard returns at most limit tasks, so merging stays cheap.
+func (s *ShardStore) GetRange(fromID, toID, limit int) []*entities.Task {
+	results := make(chan []*entities.Task, s.numShards)
+	for _, shard := range s.shards {
+		go func(shard *ShardUnit) {
+			results <- shard.GetRange(fromID, toID, limit)
+		}(shard)
+	}
+
+	tasks := make([]*entities.Task, 0)
+	for i := 0; i < s.numShards; i++ {
+		tasks = append(tasks, <-results...)
+	}
+	return sortAndLimit(tasks, limit)
+}
+
 // Update modifies a task in the appropriate shard
 func (s *ShardStore) Update(id int, updatedTask *entities.Task) *apperrors.AppError {
 	shardIndex := s.getShardByID(id)
@@ -424,6 +424,25 @@ func TestShardStore_StatsAccuracy(t *testing.T) {
 	}
 }
 
+func TestShardCounts(t *testing.T) {
+	store := NewShardStore(4)
+	gopoolStore := NewShardStoreGopool(4)
+	defer gopoolStore.Close()
+
+	for i := 0; i < 10; i++ {
+		store.Create(&entities.Task{Name: "task"})
+		gopoolStore.Create(&entities.Task{Name: "task"})
+	}
+
+	// IDs 1..10 map to shards by id & 3
+	expected := []int{2, 3, 3, 2}
+	for name, counts := range map[string][]int{"ShardStore": store.ShardCounts(), "ShardStoreGopool": gopoolStore.ShardCounts()} {
+		if fmt.Sprint(counts) != fmt.Sprint(expected) {
+			t.Errorf("%s: expected %v, got %v", name, expected, counts)
+		}
+	}
+}
+
 func TestShardStoreGopool_EdgeCases(t *testing.T) {
 	store := NewShardStoreGopool(4)
 	defer store.Close()
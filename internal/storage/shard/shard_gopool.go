@@ -21,6 +21,8 @@ type ShardStoreGopool struct {
 	pools    []gopool.Pool // One pool per CPU core
 	numCores int
 	coreMask int // bitmask for core selection
+
+	keys *keyIndex // External key -> task ID, for upserts
 }
 
 // NewShardStoreGopool creates a new shard store with ByteDance gopool per-core workers
@@ -67,6 +69,7 @@ func NewShardStoreGopool(numShards int) *ShardStoreGopool {
 		pools:     pools,
 		numCores:  numCores,
 		coreMask:  coreMask,
+		keys:      newKeyIndex(numShards),
 	}
 }
 
@@ -75,6 +78,11 @@ func (s *ShardStoreGopool) getCoreIndex(shardIndex int) int {
 	return shardIndex & s.coreMask
 }
 
+// ShardOf returns the index of the shard holding id
+func (s *ShardStoreGopool) ShardOf(id int) int {
+	return s.getShardByID(id)
+}
+
 // getShardByID returns the shard index for a given ID using bitwise AND
 func (s *ShardStoreGopool) getShardByID(id int) int {
 	return id & s.shardMask
@@ -150,6 +158,51 @@ func (s *ShardStoreGopool) GetAll() []*entities.Task {
 	return allTasks
 }
 
+// Snapshot retrieves all tasks as of a single instant, blocking writes for
+// the duration of the copy (see ShardStore.Snapshot)
+func (s *ShardStoreGopool) Snapshot() []*entities.Task {
+	return snapshot(s.shards)
+}
+
+// GetMany retrieves a batch of tasks, reading each shard that holds one of
+// ids once on its core's pool
+func (s *ShardStoreGopool) GetMany(ids []int) ([]*entities.Task, []int, *apperrors.AppError) {
+	found, missing := getMany(s.shards, ids, s.getShardByID, func(shard int, fn func()) {
+		s.pools[s.getCoreIndex(shard)].Go(fn)
+	})
+	return found, missing, nil
+}
+
+// WithKey runs write holding the lock of key's shard of the key index, so
+// upserts of the same key run one at a time
+func (s *ShardStoreGopool) WithKey(key string, write func(id int) (int, *apperrors.AppError)) *apperrors.AppError {
+	return s.keys.withKey(key, write)
+}
+
+// GetRange scans all shards on the per-core pools for tasks with
+// fromID <= ID <= toID. Each shard returns at most limit tasks.
+func (s *ShardStoreGopool) GetRange(fromID, toID, limit int) []*entities.Task {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	tasks := make([]*entities.Task, 0)
+
+	for i, shard := range s.shards {
+		wg.Add(1)
+		shard := shard
+		s.pools[s.getCoreIndex(i)].Go(func() {
+			defer wg.Done()
+			found := shard.GetRange(fromID, toID, limit)
+
+			mu.Lock()
+			tasks = append(tasks, found...)
+			mu.Unlock()
+		})
+	}
+
+	wg.Wait()
+	return sortAndLimit(tasks, limit)
+}
+
 // Update modifies a task in the appropriate shard
 func (s *ShardStoreGopool) Update(id int, updatedTask *entities.Task) *apperrors.AppError {
 	shardIndex := s.getShardByID(id)
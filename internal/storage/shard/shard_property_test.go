@@ -0,0 +1,149 @@
+package shard
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"testing/quick"
+)
+
+// Property-based tests for the sharding math, using testing/quick.
+
+func quickConfig() *quick.Config {
+	return &quick.Config{
+		MaxCount: 10000,
+		Rand:     rand.New(rand.NewSource(42)),
+	}
+}
+
+// storeWithShards builds a ShardStore header without allocating shard units,
+// so properties can be checked for shard counts far beyond what NewShardStore allows.
+func storeWithShards(n int) *ShardStore {
+	n = nextPowerOfTwo(n)
+	return &ShardStore{numShards: n, shardMask: n - 1}
+}
+
+func TestProperty_NextPowerOfTwo(t *testing.T) {
+	property := func(n int) bool {
+		p := nextPowerOfTwo(n)
+		if !isPowerOfTwo(p) {
+			return false
+		}
+		switch {
+		case n <= 1:
+			return p == 1
+		case n > maxPowerOfTwo:
+			return p == maxPowerOfTwo
+		default:
+			// Smallest power of two that is >= n
+			return p >= n && p/2 < n
+		}
+	}
+	if err := quick.Check(property, quickConfig()); err != nil {
+		t.Error(err)
+	}
+
+	edgeCases := []int{
+		math.MinInt, math.MinInt + 1, -1, 0, 1, 2, 3,
+		maxPowerOfTwo - 1, maxPowerOfTwo, maxPowerOfTwo + 1,
+		math.MaxInt - 1, math.MaxInt,
+	}
+	for _, n := range edgeCases {
+		if !property(n) {
+			t.Errorf("nextPowerOfTwo(%d) = %d violates property", n, nextPowerOfTwo(n))
+		}
+	}
+}
+
+func TestProperty_NextPowerOfTwoIdempotent(t *testing.T) {
+	property := func(n int) bool {
+		p := nextPowerOfTwo(n)
+		return nextPowerOfTwo(p) == p
+	}
+	if err := quick.Check(property, quickConfig()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProperty_ShardMaskMatchesModulo(t *testing.T) {
+	property := func(shards uint16, id int) bool {
+		s := storeWithShards(int(shards))
+		if s.shardMask != s.numShards-1 {
+			return false
+		}
+		if id < 0 {
+			return true
+		}
+		return s.getShardByID(id) == id%s.numShards
+	}
+	if err := quick.Check(property, quickConfig()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProperty_GetShardByIDInRangeAndStable(t *testing.T) {
+	property := func(shards uint16, id int) bool {
+		s := storeWithShards(int(shards))
+		idx := s.getShardByID(id)
+		return idx >= 0 && idx < s.numShards && idx == s.getShardByID(id)
+	}
+	if err := quick.Check(property, quickConfig()); err != nil {
+		t.Error(err)
+	}
+
+	// Extremes of the int range must still map to a valid shard
+	s := storeWithShards(maxPowerOfTwo)
+	for _, id := range []int{math.MinInt, -1, 0, math.MaxInt} {
+		if idx := s.getShardByID(id); idx < 0 || idx >= s.numShards {
+			t.Errorf("getShardByID(%d) = %d, out of range [0, %d)", id, idx, s.numShards)
+		}
+	}
+}
+
+func TestProperty_GetShardByIDUniform(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+
+	for _, numShards := range []int{4, 16, 32, 64} {
+		s := storeWithShards(numShards)
+		const samples = 64000
+		counts := make([]int, numShards)
+		for i := 0; i < samples; i++ {
+			counts[s.getShardByID(rng.Int())]++
+		}
+
+		// Chi-squared goodness of fit against a uniform distribution.
+		// The bound is loose (well above the 99.9th percentile for df <= 63).
+		expected := float64(samples) / float64(numShards)
+		chi2 := 0.0
+		for _, c := range counts {
+			d := float64(c) - expected
+			chi2 += d * d / expected
+		}
+		if limit := 2.0*float64(numShards) + 50; chi2 > limit {
+			t.Errorf("shards=%d: chi2=%.1f exceeds %.1f, counts=%v", numShards, chi2, limit, counts)
+		}
+	}
+}
+
+func TestProperty_SequentialIDsPerfectlyBalanced(t *testing.T) {
+	property := func(shards uint8, start uint32, n uint16) bool {
+		s := storeWithShards(int(shards))
+		counts := make([]int, s.numShards)
+		for id := int(start); id < int(start)+int(n); id++ {
+			counts[s.getShardByID(id)]++
+		}
+		min, max := counts[0], counts[0]
+		for _, c := range counts {
+			if c < min {
+				min = c
+			}
+			if c > max {
+				max = c
+			}
+		}
+		return max-min <= 1
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 500, Rand: rand.New(rand.NewSource(1))}); err != nil {
+		t.Error(err)
+	}
+}
@@ -2,7 +2,11 @@ package shard
 
 import (
 	"fmt"
+	"math"
+	"strconv"
+	"sync"
 	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
 	"testing"
 )
 
@@ -295,3 +299,172 @@ func TestShardStore_GetShard(t *testing.T) {
 		t.Error("Expected nil for out-of-bounds index")
 	}
 }
+
+func TestShardStore_GetRange(t *testing.T) {
+	for name, store := range map[string]interface {
+		Create(*entities.Task) *apperrors.AppError
+		Delete(int) *apperrors.AppError
+		GetRange(int, int, int) []*entities.Task
+	}{
+		"shard":  NewShardStore(4),
+		"gopool": NewShardStoreGopool(4),
+	} {
+		for i := 0; i < 20; i++ {
+			store.Create(&entities.Task{Name: "task"})
+		}
+		store.Delete(5)
+
+		tasks := store.GetRange(3, 8, 0)
+		expected := []int{3, 4, 6, 7, 8}
+		if len(tasks) != len(expected) {
+			t.Fatalf("%s: expected %d tasks, got %d", name, len(expected), len(tasks))
+		}
+		for i, task := range tasks {
+			if task.ID != expected[i] {
+				t.Errorf("%s: expected ID %d at %d, got %d", name, expected[i], i, task.ID)
+			}
+		}
+
+		if tasks := store.GetRange(1, math.MaxInt, 3); len(tasks) != 3 || tasks[2].ID != 3 {
+			t.Errorf("%s: expected first 3 tasks across shards, got %d", name, len(tasks))
+		}
+	}
+}
+
+func TestShardStore_GetMany(t *testing.T) {
+	for name, store := range map[string]interface {
+		Create(*entities.Task) *apperrors.AppError
+		Delete(int) *apperrors.AppError
+		GetMany([]int) ([]*entities.Task, []int, *apperrors.AppError)
+	}{
+		"shard":  NewShardStore(4),
+		"gopool": NewShardStoreGopool(4),
+	} {
+		for i := 0; i < 10; i++ {
+			store.Create(&entities.Task{Name: "task"})
+		}
+		store.Delete(6)
+
+		found, missing, err := store.GetMany([]int{9, 2, 6, 3, 42, 8})
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", name, err)
+		}
+		if fmt.Sprint(ids(found)) != "[9 2 3 8]" || fmt.Sprint(missing) != "[6 42]" {
+			t.Errorf("%s: expected [9 2 3 8] found and [6 42] missing, got %v and %v", name, ids(found), missing)
+		}
+		if found, missing, _ := store.GetMany([]int{4, 8}); len(found) != 2 || len(missing) != 0 {
+			t.Errorf("%s: expected both tasks of one shard, got %v and %v", name, ids(found), missing)
+		}
+	}
+}
+
+func ids(tasks []*entities.Task) []int {
+	result := make([]int, len(tasks))
+	for i, task := range tasks {
+		result[i] = task.ID
+	}
+	return result
+}
+
+func TestShardStore_SnapshotIsConsistent(t *testing.T) {
+	// A writer bumps tasks 1 to 8, one per shard, always in ID order, so at
+	// any single instant no task is ahead of a lower ID. GetAll reads the
+	// shards at different times and can see a later task ahead; Snapshot
+	// must not.
+	for name, store := range map[string]interface {
+		Create(*entities.Task) *apperrors.AppError
+		Update(int, *entities.Task) *apperrors.AppError
+		Snapshot() []*entities.Task
+	}{"shard": NewShardStore(8), "gopool": NewShardStoreGopool(8)} {
+		for i := 0; i < 8; i++ {
+			store.Create(&entities.Task{Name: "0"})
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 1; i <= 2000; i++ {
+				for id := 1; id <= 8; id++ {
+					store.Update(id, &entities.Task{Name: strconv.Itoa(i)})
+				}
+			}
+		}()
+		for i := 0; i < 200; i++ {
+			values := make([]int, 9)
+			for _, task := range store.Snapshot() {
+				values[task.ID], _ = strconv.Atoi(task.Name)
+			}
+			for id := 2; id <= 8; id++ {
+				if values[id] > values[id-1] {
+					t.Fatalf("%s: task %d ahead of task %d in a snapshot: %v", name, id, id-1, values[1:])
+				}
+			}
+		}
+		wg.Wait()
+	}
+}
+
+func TestShardStore_Preallocate(t *testing.T) {
+	store := NewShardStore(4)
+	store.Create(&entities.Task{Name: "kept"})
+
+	store.Preallocate(1000)
+	for i, unit := range store.shards {
+		if unit.capacity < 250 {
+			t.Errorf("Expected room for 250 tasks in shard %d, got %d", i, unit.capacity)
+		}
+	}
+	if task, err := store.GetByID(1); err != nil || task.Name != "kept" {
+		t.Errorf("Expected existing tasks to survive, got %v, %v", task, err)
+	}
+
+	capacity := store.shards[1].capacity
+	store.Preallocate(4)
+	if store.shards[1].capacity != capacity {
+		t.Errorf("Expected no reallocation while there is room, got %d", store.shards[1].capacity)
+	}
+}
+
+func TestShardStore_BulkLoad(t *testing.T) {
+	for name, store := range map[string]interface {
+		Create(task *entities.Task) *apperrors.AppError
+		GetByID(id int) (*entities.Task, *apperrors.AppError)
+		BulkLoad(tasks []*entities.Task) *apperrors.AppError
+		ShardStats() []Stats
+		SampleLocks(every int)
+	}{
+		"shard":  NewShardStore(4),
+		"gopool": NewShardStoreGopool(4),
+	} {
+		store.SampleLocks(1)
+		tasks := make([]*entities.Task, 0, 20)
+		for id := 1; id <= 20; id++ {
+			tasks = append(tasks, &entities.Task{ID: id * 2, Name: strconv.Itoa(id * 2)})
+		}
+		if err := store.BulkLoad(tasks); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for i, stats := range store.ShardStats() {
+			if stats.Tasks > 0 && stats.Acquisitions != 1 {
+				t.Errorf("%s: expected shard %d to be locked once, got %d", name, i, stats.Acquisitions)
+			}
+		}
+		if task, err := store.GetByID(40); err != nil || task.Name != "40" {
+			t.Errorf("%s: expected task 40 under its own ID, got %+v, %v", name, task, err)
+		}
+
+		task := &entities.Task{Name: "next"}
+		store.Create(task)
+		if task.ID != 41 {
+			t.Errorf("%s: expected IDs to continue after the loaded ones, got %d", name, task.ID)
+		}
+
+		if err := store.BulkLoad([]*entities.Task{{ID: 50}, {ID: 0}}); err == nil {
+			t.Errorf("%s: expected a task without an ID to be rejected", name)
+		}
+		if _, err := store.GetByID(50); err == nil {
+			t.Errorf("%s: expected a rejected batch to store nothing", name)
+		}
+	}
+}
@@ -1,47 +1,83 @@
 package shard
 
 import (
+	"sort"
 	"sync"
+	"time"
+
 	"tasks-service-demo/internal/entities"
 )
 
-// ShardUnit is a lightweight, optimized storage unit for shard-based stores
-// Removes unnecessary overhead from MemoryStore when used within sharded architecture
-type ShardUnit struct {
-	tasks map[int]*entities.Task // Map to store tasks by ID
-	mu    sync.RWMutex           // Read-write mutex for thread safety
+// Unit is a lightweight, optimized storage unit for shard-based stores of
+// any entity type. Removes unnecessary overhead from MemoryStore when used
+// within sharded architecture.
+type Unit[T entities.Entity] struct {
+	tasks    map[int]T    // Map to store entities by ID
+	capacity int          // Size hint tasks was made with
+	mu       sync.RWMutex // Read-write mutex for thread safety
+	locks    lockStats    // Sampled wait and hold times of mu
 }
 
-// NewShardUnit creates a new shard unit with pre-allocated capacity
-func NewShardUnit(capacity int) *ShardUnit {
+// ShardUnit is the unit of the task stores
+type ShardUnit = Unit[*entities.Task]
+
+// NewUnit creates a new shard unit with pre-allocated capacity
+func NewUnit[T entities.Entity](capacity int) *Unit[T] {
 	if capacity <= 0 {
 		capacity = 64 // Default capacity for better memory layout
 	}
 
-	return &ShardUnit{
-		tasks: make(map[int]*entities.Task, capacity),
+	return &Unit[T]{
+		tasks:    make(map[int]T, capacity),
+		capacity: capacity,
 	}
 }
 
+// NewShardUnit creates a new task shard unit with pre-allocated capacity
+func NewShardUnit(capacity int) *ShardUnit {
+	return NewUnit[*entities.Task](capacity)
+}
+
+// Preallocate makes room for n more tasks. A map cannot grow in place, so
+// when the current one is too small it is copied into a bigger one, which
+// blocks the unit for the length of the copy.
+func (s *Unit[T]) Preallocate(n int) {
+	if n <= 0 {
+		return
+	}
+	taken := s.lock()
+	defer s.unlock(taken)
+
+	need := len(s.tasks) + n
+	if need <= s.capacity {
+		return
+	}
+	tasks := make(map[int]T, need)
+	for id, task := range s.tasks {
+		tasks[id] = task
+	}
+	s.tasks, s.capacity = tasks, need
+}
+
 // Set stores a task with given ID (ID generation handled by parent ShardStore)
-func (s *ShardUnit) Set(id int, task *entities.Task) {
-	s.mu.Lock()
+func (s *Unit[T]) Set(id int, task T) {
+	taken := s.lock()
 	s.tasks[id] = task
-	s.mu.Unlock()
+	s.unlock(taken)
 }
 
 // Get retrieves a task by ID
-func (s *ShardUnit) Get(id int) (*entities.Task, bool) {
-	s.mu.RLock()
+func (s *Unit[T]) Get(id int) (T, bool) {
+	taken := s.rlock()
 	task, exists := s.tasks[id]
-	s.mu.RUnlock()
+	s.runlock(taken)
 	return task, exists
 }
 
 // Update modifies an existing task
-func (s *ShardUnit) Update(id int, task *entities.Task) bool {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+func (s *Unit[T]) Update(id int, task T) bool {
+	taken := s.lock()
+	defer s.unlock(taken)
 
 	if _, exists := s.tasks[id]; !exists {
 		return false
@@ -52,9 +88,9 @@ func (s *ShardUnit) Update(id int, task *entities.Task) bool {
 }
 
 // Delete removes a task by ID
-func (s *ShardUnit) Delete(id int) bool {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+func (s *Unit[T]) Delete(id int) bool {
+	taken := s.lock()
+	defer s.unlock(taken)
 
 	if _, exists := s.tasks[id]; !exists {
 		return false
@@ -65,19 +101,129 @@ func (s *ShardUnit) Delete(id int) bool {
 }
 
 // GetAll returns all tasks in this shard unit (for bulk operations)
-func (s *ShardUnit) GetAll() []*entities.Task {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+func (s *Unit[T]) GetAll() []T {
+	taken := s.rlock()
+	defer s.runlock(taken)
 
-	tasks := make([]*entities.Task, 0, len(s.tasks))
+	tasks := make([]T, 0, len(s.tasks))
 	for _, task := range s.tasks {
 		tasks = append(tasks, task)
 	}
 	return tasks
 }
 
+// GetRange returns this unit's tasks with fromID <= ID <= toID in ID order,
+// at most limit of them (limit <= 0 means no limit)
+func (s *Unit[T]) GetRange(fromID, toID, limit int) []T {
+	taken := s.rlock()
+	tasks := make([]T, 0)
+	for id, task := range s.tasks {
+		if id >= fromID && id <= toID {
+			tasks = append(tasks, task)
+		}
+	}
+	s.runlock(taken)
+
+	return sortAndLimit(tasks, limit)
+}
+
+// GetMany returns the tasks with the given IDs under one read lock, in the
+// order of ids, with nil for IDs not found
+func (s *Unit[T]) GetMany(ids []int) []T {
+	tasks := make([]T, len(ids))
+	taken := s.rlock()
+	for i, id := range ids {
+		tasks[i] = s.tasks[id]
+	}
+	s.runlock(taken)
+	return tasks
+}
+
+// getMany groups ids by the unit shardOf picks and reads each unit involved
+// once, the units concurrently through spawn. It returns the tasks found and
+// the IDs missing, both in the order of ids.
+func getMany[T entities.Entity](units []*Unit[T], ids []int, shardOf func(id int) int, spawn func(shard int, fn func())) ([]T, []int) {
+	groups := make(map[int][]int) // Shard index -> positions in ids
+	for i, id := range ids {
+		shard := shardOf(id)
+		groups[shard] = append(groups[shard], i)
+	}
+
+	results := make([]T, len(ids))
+	read := func(shard int, positions []int) {
+		batch := make([]int, len(positions))
+		for i, pos := range positions {
+			batch[i] = ids[pos]
+		}
+		// Positions are disjoint across shards, so no lock is needed
+		for i, task := range units[shard].GetMany(batch) {
+			results[positions[i]] = task
+		}
+	}
+	if len(groups) == 1 {
+		for shard, positions := range groups {
+			read(shard, positions)
+		}
+	} else {
+		var wg sync.WaitGroup
+		for shard, positions := range groups {
+			wg.Add(1)
+			shard, positions := shard, positions
+			spawn(shard, func() {
+				defer wg.Done()
+				read(shard, positions)
+			})
+		}
+		wg.Wait()
+	}
+
+	var none T
+	found := make([]T, 0, len(ids))
+	missing := make([]int, 0)
+	for i, task := range results {
+		if task != none {
+			found = append(found, task)
+		} else {
+			missing = append(missing, ids[i])
+		}
+	}
+	return found, missing
+}
+
+// snapshot returns the tasks of all units as of one instant. It read-locks
+// every unit in order before reading any, so writes, which lock a single
+// unit, wait until the copy is done. Always locking in order keeps two
+// snapshots from deadlocking.
+func snapshot[T entities.Entity](units []*Unit[T]) []T {
+	count := 0
+	taken := make([]time.Time, len(units))
+	for i, u := range units {
+		taken[i] = u.rlock()
+		count += len(u.tasks)
+	}
+	tasks := make([]T, 0, count)
+	for _, u := range units {
+		for _, task := range u.tasks {
+			tasks = append(tasks, task)
+		}
+	}
+	for i, u := range units {
+		u.runlock(taken[i])
+	}
+	return tasks
+}
+
+// sortAndLimit orders tasks by ID and keeps the first limit
+func sortAndLimit[T entities.Entity](tasks []T, limit int) []T {
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].GetID() < tasks[j].GetID() })
+	if limit > 0 && len(tasks) > limit {
+		tasks = tasks[:limit]
+	}
+	return tasks
+}
+
 // Count returns the number of tasks in this shard unit
-func (s *ShardUnit) Count() int {
+func (s *Unit[T]) Count() int {
 	s.mu.RLock()
 	count := len(s.tasks)
 	s.mu.RUnlock()
@@ -85,6 +231,6 @@ func (s *ShardUnit) Count() int {
 }
 
 // GetTasksUnsafe returns tasks map without locking (for use when parent already holds lock)
-func (s *ShardUnit) GetTasksUnsafe() map[int]*entities.Task {
+func (s *Unit[T]) GetTasksUnsafe() map[int]T {
 	return s.tasks
 }
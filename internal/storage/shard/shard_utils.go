@@ -1,5 +1,7 @@
 package shard
 
+import "tasks-service-demo/internal/entities"
+
 // Utility functions for ShardStore - used for monitoring, debugging, and benchmarking
 // These functions are NOT needed for production operation
 
@@ -33,3 +35,43 @@ func (s *ShardStore) GetShard(index int) *ShardUnit {
 	// Access shard directly (no mutex needed)
 	return s.shards[index]
 }
+
+// ShardCounts returns the number of tasks in each shard
+func (s *ShardStore) ShardCounts() []int {
+	counts := make([]int, s.numShards)
+	for i, shard := range s.shards {
+		counts[i] = shard.Count()
+	}
+	return counts
+}
+
+// ShardCounts returns the number of tasks in each shard
+func (s *ShardStoreGopool) ShardCounts() []int {
+	counts := make([]int, s.numShards)
+	for i, shard := range s.shards {
+		counts[i] = shard.Count()
+	}
+	return counts
+}
+
+// preallocate spreads room for n more tasks evenly over units; IDs are
+// assigned sequentially, so each unit gets its share of them
+func preallocate[T entities.Entity](units []*Unit[T], n int) {
+	if n <= 0 {
+		return
+	}
+	perUnit := (n + len(units) - 1) / len(units)
+	for _, u := range units {
+		u.Preallocate(perUnit)
+	}
+}
+
+// Preallocate makes room for n more tasks across the shards
+func (s *ShardStore) Preallocate(n int) {
+	preallocate(s.shards, n)
+}
+
+// Preallocate makes room for n more tasks across the shards
+func (s *ShardStoreGopool) Preallocate(n int) {
+	preallocate(s.shards, n)
+}
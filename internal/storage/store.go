@@ -1,7 +1,11 @@
 package storage
 
 import (
+	"context"
+	"fmt"
 	"sync"
+	"time"
+
 	"tasks-service-demo/internal/entities"
 	apperrors "tasks-service-demo/internal/errors"
 )
@@ -11,6 +15,7 @@ type Store interface {
 	Create(task *entities.Task) *apperrors.AppError         // Creates a new task
 	GetByID(id int) (*entities.Task, *apperrors.AppError)   // Retrieves a task by ID
 	GetAll() []*entities.Task                               // Retrieves all tasks
+	GetRange(fromID, toID, limit int) []*entities.Task      // Retrieves tasks with fromID <= ID <= toID in ID order, at most limit (<= 0: no limit)
 	Update(id int, task *entities.Task) *apperrors.AppError // Updates an existing task
 	Delete(id int) *apperrors.AppError                      // Deletes a task by ID
 }
@@ -38,3 +43,275 @@ func ResetStore() {
 	instance = nil
 	once = sync.Once{}
 }
+
+// Close closes the first store in the decorator chain that holds resources.
+// Decorators expose their wrapped store through Inner(), so a durable backend
+// is still closed when it sits behind caches or guards.
+func Close(store Store) (closed bool, err error) {
+	if closer, ok := find[interface{ Close() error }](store); ok {
+		return true, closer.Close()
+	}
+	return false, nil
+}
+
+// inner returns the store that store decorates, if it is a decorator
+func inner(store Store) (Store, bool) {
+	wrapper, ok := store.(interface{ Inner() Store })
+	if !ok {
+		return nil, false
+	}
+	return wrapper.Inner(), true
+}
+
+// find returns the first store in the decorator chain that implements T.
+// Every FindX is a wrapper around it, so the chain is walked in one place.
+func find[T any](store Store) (T, bool) {
+	for ok := store != nil; ok; store, ok = inner(store) {
+		if t, ok := store.(T); ok {
+			return t, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// MetaStore is implemented by durable backends that can keep small named
+// records next to the tasks, such as job schedules
+type MetaStore interface {
+	GetMeta(key string) (value []byte, ok bool, err error)
+	PutMeta(key string, value []byte) error
+}
+
+// FindMeta returns the first store in the decorator chain that implements
+// MetaStore, so records survive restarts whenever the backend is durable
+func FindMeta(store Store) (MetaStore, bool) {
+	return find[MetaStore](store)
+}
+
+// KeyIndex is implemented by backends that map external keys, such as the
+// IDs of another system, to task IDs, so tasks can be upserted by key
+type KeyIndex interface {
+	// WithKey runs write while no other WithKey call for key is running.
+	// write gets the ID key maps to, 0 if none, and returns the ID key maps
+	// to from then on. When write fails the mapping stays as it was.
+	WithKey(key string, write func(id int) (int, *apperrors.AppError)) *apperrors.AppError
+}
+
+// FindKeyIndex returns the first store in the decorator chain that
+// implements KeyIndex. Only the key mapping lives there; upserts still
+// write through the whole chain, so no decorator needs to know about keys.
+func FindKeyIndex(store Store) (KeyIndex, bool) {
+	return find[KeyIndex](store)
+}
+
+// Snapshotter is implemented by stores whose GetAll may observe concurrent
+// writes part-way, but that can also read every task as of one instant at
+// a higher cost. Decorators that add to or filter the listing of the store
+// they wrap must implement it too, so Snapshot doesn't look past them.
+type Snapshotter interface {
+	Snapshot() []*entities.Task
+}
+
+// Snapshot returns every task as of one instant when the first Snapshotter
+// in the decorator chain allows it, and GetAll otherwise. Exports and
+// backups use it; request paths keep the cheaper GetAll.
+func Snapshot(store Store) []*entities.Task {
+	if snap, ok := find[Snapshotter](store); ok {
+		return snap.Snapshot()
+	}
+	return store.GetAll()
+}
+
+// Rekeyer is implemented by backends that encrypt tasks at rest. Rekey
+// rewrites the tasks not sealed under the active key, so a retired key can
+// be removed from the configuration afterwards.
+type Rekeyer interface {
+	Rekey(ctx context.Context) (int, error)
+}
+
+// FindRekeyer returns the first store in the decorator chain that
+// implements Rekeyer
+func FindRekeyer(store Store) (Rekeyer, bool) {
+	return find[Rekeyer](store)
+}
+
+// Flusher is implemented by decorators that buffer writes. Flush applies
+// the buffered writes to the backend.
+type Flusher interface {
+	Flush()
+}
+
+// FindFlusher returns the first store in the decorator chain that
+// implements Flusher
+func FindFlusher(store Store) (Flusher, bool) {
+	return find[Flusher](store)
+}
+
+// maxIntegrityProblems bounds the problems an Integrity lists; the rest are
+// only counted
+const maxIntegrityProblems = 20
+
+// Integrity is the outcome of a backend's integrity check
+type Integrity struct {
+	Records    int      `json:"records"`            // Task records read
+	BadRecords int      `json:"badRecords"`         // Records that fail their checksum or don't decode
+	MaxID      int      `json:"maxId"`              // Highest task ID found
+	Repairs    []string `json:"repairs,omitempty"`  // Inconsistencies that were fixed
+	Problems   []string `json:"problems,omitempty"` // Corruption that was not fixed, the first 20
+}
+
+// OK reports whether nothing is left unfixed
+func (i Integrity) OK() bool {
+	return i.BadRecords == 0 && len(i.Problems) == 0
+}
+
+// Problemf records a problem, listing it while fewer than 20 are listed
+func (i *Integrity) Problemf(format string, args ...any) {
+	if len(i.Problems) < maxIntegrityProblems {
+		i.Problems = append(i.Problems, fmt.Sprintf(format, args...))
+	}
+}
+
+// Repairf records an inconsistency that was fixed
+func (i *Integrity) Repairf(format string, args ...any) {
+	i.Repairs = append(i.Repairs, fmt.Sprintf(format, args...))
+}
+
+// Verifier is implemented by durable backends that can check their data
+// after a crash: record checksums, that the next ID is above every stored
+// ID, and that in-memory indexes match the records. Verify repairs what it
+// can fix without losing data and reports the rest; an error means the
+// check itself could not run. It is meant for startup, before the store
+// serves requests.
+type Verifier interface {
+	Verify(ctx context.Context) (Integrity, error)
+}
+
+// FindVerifier returns the first store in the decorator chain that
+// implements Verifier
+func FindVerifier(store Store) (Verifier, bool) {
+	return find[Verifier](store)
+}
+
+// DefaultCompactPause is the pause between compaction steps when none is given
+const DefaultCompactPause = 10 * time.Millisecond
+
+// CompactOptions throttle an online compaction
+type CompactOptions struct {
+	Pause    time.Duration           // Sleep between steps, so foreground requests get the lock and the disk
+	Progress func(done, total int64) // Called after each step; total is 0 when unknown
+}
+
+// CompactStats is the outcome of an online compaction
+type CompactStats struct {
+	SizeBefore     int64 `json:"sizeBefore"` // Bytes on disk
+	SizeAfter      int64 `json:"sizeAfter"`
+	ReclaimedBytes int64 `json:"reclaimedBytes"`
+	Steps          int   `json:"steps"`
+}
+
+// Compactor is implemented by durable backends whose files keep the space
+// of updated and deleted tasks until it is reclaimed. CompactOnline
+// reclaims it in small steps while the store keeps serving, and stops
+// between steps once ctx is done.
+type Compactor interface {
+	CompactOnline(ctx context.Context, opts CompactOptions) (CompactStats, error)
+}
+
+// FindCompactor returns the first store in the decorator chain that
+// implements Compactor
+func FindCompactor(store Store) (Compactor, bool) {
+	return find[Compactor](store)
+}
+
+// ShardLocator is implemented by sharded backends. ShardOf returns the
+// index of the shard holding id.
+type ShardLocator interface {
+	ShardOf(id int) int
+}
+
+// FindShardLocator returns the first store in the decorator chain that
+// implements ShardLocator
+func FindShardLocator(store Store) (ShardLocator, bool) {
+	return find[ShardLocator](store)
+}
+
+// Preallocator is implemented by in-memory backends whose maps rehash as
+// they grow. Preallocate makes room for n more tasks up front, so a bulk
+// load of n tasks does not rehash along the way.
+type Preallocator interface {
+	Preallocate(n int)
+}
+
+// BulkLoader is implemented by in-memory backends that can take a batch of
+// tasks under the IDs they already carry, e.g. to restore a backup, faster
+// than one Create per task: each lock is taken once per batch and the ID
+// counter is moved past the highest ID once at the end. Tasks with the same
+// ID as a stored one replace it. Decorators would not see a bulk load, so
+// there is no Find helper: call it on a backend before anything wraps it.
+type BulkLoader interface {
+	BulkLoad(tasks []*entities.Task) *apperrors.AppError
+}
+
+// BulkDeleter is implemented by backends that can delete many tasks in one
+// transaction. DeleteWhere deletes the tasks with ids that still satisfy
+// match when the transaction reads them, and returns the IDs it deleted;
+// on error nothing is deleted.
+type BulkDeleter interface {
+	DeleteWhere(ids []int, match func(*entities.Task) bool) ([]int, *apperrors.AppError)
+}
+
+// DeleteObserver is implemented by decorators that a BulkDeleter below them
+// may delete through. DeletedWhere gets the IDs it deleted, so the
+// decorator can catch up as if each had been deleted through it: a feed
+// records them, a filter drops them. Decorators with nothing to catch up
+// on implement it as a no-op.
+type DeleteObserver interface {
+	DeletedWhere(ids []int)
+}
+
+// FindBulkDeleter returns the first BulkDeleter in the decorator chain when
+// every decorator in front of it is a DeleteObserver. The returned
+// BulkDeleter passes the IDs it deleted to each of them, innermost first.
+// Any other decorator, such as a cache, would keep serving the deleted
+// tasks, so the chain then has no BulkDeleter.
+func FindBulkDeleter(store Store) (BulkDeleter, bool) {
+	var observers []DeleteObserver
+	for ok := store != nil; ok; store, ok = inner(store) {
+		if d, ok := store.(BulkDeleter); ok {
+			if len(observers) == 0 {
+				return d, true
+			}
+			return observedDeleter{d, observers}, true
+		}
+		observer, ok := store.(DeleteObserver)
+		if !ok {
+			return nil, false
+		}
+		observers = append(observers, observer)
+	}
+	return nil, false
+}
+
+// observedDeleter is a BulkDeleter behind DeleteObservers, outermost first
+type observedDeleter struct {
+	BulkDeleter
+	observers []DeleteObserver
+}
+
+func (d observedDeleter) DeleteWhere(ids []int, match func(*entities.Task) bool) ([]int, *apperrors.AppError) {
+	deleted, err := d.BulkDeleter.DeleteWhere(ids, match)
+	if err != nil || len(deleted) == 0 {
+		return deleted, err
+	}
+	for i := len(d.observers) - 1; i >= 0; i-- {
+		d.observers[i].DeletedWhere(deleted)
+	}
+	return deleted, nil
+}
+
+// FindPreallocator returns the first store in the decorator chain that
+// implements Preallocator
+func FindPreallocator(store Store) (Preallocator, bool) {
+	return find[Preallocator](store)
+}
@@ -1,6 +1,9 @@
 package storage
 
 import (
+	"fmt"
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
 	"tasks-service-demo/internal/storage/channel"
 	"tasks-service-demo/internal/storage/naive"
 	"tasks-service-demo/internal/storage/shard"
@@ -56,3 +59,221 @@ func Test_GetStore(t *testing.T) {
 		t.Error("Unexpected store init")
 	}
 }
+
+type closingStore struct {
+	Store
+	closed bool
+}
+
+func (s *closingStore) Close() error {
+	s.closed = true
+	return nil
+}
+
+type wrappingStore struct{ Store }
+
+func (s wrappingStore) Inner() Store { return s.Store }
+
+func Test_CloseUnwrapsDecorators(t *testing.T) {
+	backend := &closingStore{Store: naive.NewMemoryStore()}
+
+	closed, err := Close(wrappingStore{wrappingStore{backend}})
+	if !closed || err != nil || !backend.closed {
+		t.Errorf("Expected wrapped backend to be closed, got %v, %v", closed, err)
+	}
+
+	if closed, _ := Close(naive.NewMemoryStore()); closed {
+		t.Error("Expected nothing to close for a plain store")
+	}
+}
+
+func Test_FindTakesOutermostMatch(t *testing.T) {
+	backend := metaStore{naive.NewMemoryStore()}
+	outer := metaStore{wrappingStore{backend}}
+	if meta, ok := find[MetaStore](outer); !ok || meta != outer {
+		t.Errorf("Expected outermost MetaStore, got %v, %v", meta, ok)
+	}
+	if _, ok := find[MetaStore](wrappingStore{nil}); ok {
+		t.Error("Expected no MetaStore behind a decorator wrapping nothing")
+	}
+	if _, ok := find[MetaStore](nil); ok {
+		t.Error("Expected no MetaStore in an empty chain")
+	}
+}
+
+type metaStore struct{ Store }
+
+func (metaStore) GetMeta(key string) ([]byte, bool, error) { return nil, false, nil }
+func (metaStore) PutMeta(key string, value []byte) error   { return nil }
+
+func Test_FindMetaUnwrapsDecorators(t *testing.T) {
+	backend := metaStore{naive.NewMemoryStore()}
+	if meta, ok := FindMeta(wrappingStore{wrappingStore{backend}}); !ok || meta != backend {
+		t.Errorf("Expected wrapped backend, got %v, %v", meta, ok)
+	}
+	if _, ok := FindMeta(naive.NewMemoryStore()); ok {
+		t.Error("Expected no MetaStore for a plain store")
+	}
+}
+
+type keyedStore struct{ Store }
+
+func (keyedStore) WithKey(key string, write func(id int) (int, *apperrors.AppError)) *apperrors.AppError {
+	return nil
+}
+
+func Test_FindKeyIndexUnwrapsDecorators(t *testing.T) {
+	backend := keyedStore{naive.NewMemoryStore()}
+	if index, ok := FindKeyIndex(wrappingStore{wrappingStore{backend}}); !ok || index != backend {
+		t.Errorf("Expected wrapped backend, got %v, %v", index, ok)
+	}
+	if _, ok := FindKeyIndex(wrappingStore{channel.NewChannelStore(1)}); ok {
+		t.Error("Expected no KeyIndex for the channel store")
+	}
+}
+
+func Test_FindPreallocatorUnwrapsDecorators(t *testing.T) {
+	backend := shard.NewShardStore(4)
+	if prealloc, ok := FindPreallocator(wrappingStore{backend}); !ok || prealloc != backend {
+		t.Errorf("Expected wrapped backend, got %v, %v", prealloc, ok)
+	}
+	if _, ok := FindPreallocator(channel.NewChannelStore(1)); ok {
+		t.Error("Expected no Preallocator for the channel store")
+	}
+}
+
+type bulkStore struct {
+	Store
+	deleted []int
+}
+
+func (s *bulkStore) DeleteWhere(ids []int, match func(*entities.Task) bool) ([]int, *apperrors.AppError) {
+	s.deleted = ids
+	return ids, nil
+}
+
+// observingStore records the deletes it is told about in seen
+type observingStore struct {
+	wrappingStore
+	name string
+	seen *[]string
+}
+
+func (s observingStore) DeletedWhere(ids []int) {
+	*s.seen = append(*s.seen, fmt.Sprintf("%s:%v", s.name, ids))
+}
+
+func Test_FindBulkDeleterNotifiesDecorators(t *testing.T) {
+	backend := &bulkStore{Store: naive.NewMemoryStore()}
+	if bulk, ok := FindBulkDeleter(backend); !ok || bulk != backend {
+		t.Errorf("Expected the backend itself, got %v, %v", bulk, ok)
+	}
+
+	var seen []string
+	inner := observingStore{wrappingStore{backend}, "inner", &seen}
+	bulk, ok := FindBulkDeleter(observingStore{wrappingStore{inner}, "outer", &seen})
+	if !ok {
+		t.Fatal("Expected a BulkDeleter behind observing decorators")
+	}
+	if deleted, err := bulk.DeleteWhere([]int{1, 2}, nil); err != nil || len(deleted) != 2 {
+		t.Fatalf("Expected 2 deleted, got %v, %v", deleted, err)
+	}
+	if fmt.Sprint(seen) != "[inner:[1 2] outer:[1 2]]" {
+		t.Errorf("Expected each decorator told, innermost first, got %v", seen)
+	}
+
+	if _, ok := FindBulkDeleter(observingStore{wrappingStore{wrappingStore{backend}}, "outer", &seen}); ok {
+		t.Error("Expected no BulkDeleter behind a decorator that would miss the deletes")
+	}
+	if _, ok := FindBulkDeleter(naive.NewMemoryStore()); ok {
+		t.Error("Expected no BulkDeleter for a plain store")
+	}
+}
+
+type snapshotStore struct {
+	Store
+	snapshots int
+}
+
+func (s *snapshotStore) Snapshot() []*entities.Task {
+	s.snapshots++
+	return s.GetAll()
+}
+
+func Test_SnapshotUnwrapsDecorators(t *testing.T) {
+	backend := &snapshotStore{Store: naive.NewMemoryStore()}
+	backend.Create(&entities.Task{Name: "a"})
+	if tasks := Snapshot(wrappingStore{wrappingStore{backend}}); len(tasks) != 1 || backend.snapshots != 1 {
+		t.Errorf("Expected the wrapped backend's snapshot, got %v after %d snapshots", tasks, backend.snapshots)
+	}
+
+	plain := naive.NewMemoryStore()
+	plain.Create(&entities.Task{Name: "a"})
+	if tasks := Snapshot(wrappingStore{plain}); len(tasks) != 1 {
+		t.Errorf("Expected GetAll for a store without snapshots, got %v", tasks)
+	}
+}
+
+type manyStore struct {
+	Store
+	calls int
+}
+
+func (s *manyStore) GetMany(ids []int) ([]*entities.Task, []int, *apperrors.AppError) {
+	s.calls++
+	return nil, ids, nil
+}
+
+func Test_GetManyUnwrapsDecorators(t *testing.T) {
+	backend := &manyStore{Store: naive.NewMemoryStore()}
+	if _, missing, _ := GetMany(wrappingStore{wrappingStore{backend}}, []int{1, 2, 1}); backend.calls != 1 || len(missing) != 2 {
+		t.Errorf("Expected the wrapped backend to look up IDs 1 and 2 once, got %v after %d calls", missing, backend.calls)
+	}
+
+	plain := naive.NewMemoryStore()
+	plain.Create(&entities.Task{Name: "a"})
+	plain.Create(&entities.Task{Name: "b"})
+	found, missing, err := GetMany(wrappingStore{plain}, []int{2, 3, 1, 2})
+	if err != nil || len(found) != 2 || found[0].ID != 2 || found[1].ID != 1 {
+		t.Errorf("Expected tasks 2 and 1 by GetByID, got %v, %v", found, err)
+	}
+	if len(missing) != 1 || missing[0] != 3 {
+		t.Errorf("Expected 3 missing, got %v", missing)
+	}
+}
+
+func Test_GetPageUnwrapsDecorators(t *testing.T) {
+	backend := shard.NewShardStore(2)
+	for i := 0; i < 5; i++ {
+		backend.Create(&entities.Task{Name: "t"})
+	}
+	tasks, next, more := GetPage(wrappingStore{backend}, PageCursor{}, 2)
+	if len(tasks) != 2 || tasks[0].ID != 2 || tasks[1].ID != 4 || !more || next != (PageCursor{Shard: 1, AfterID: 0}) {
+		t.Errorf("Expected shard 0, holding the even IDs, first, got %v, %+v, %t", tasks, next, more)
+	}
+
+	// A decorator altering the listing is paged by ID with GetRange
+	tasks, next, more = GetPage(wrappingStore{&snapshotStore{Store: backend}}, PageCursor{AfterID: 1}, 3)
+	if len(tasks) != 3 || tasks[0].ID != 2 || tasks[2].ID != 4 || !more || next.AfterID != 4 {
+		t.Errorf("Expected tasks 2-4 by ID, got %v, %+v, %t", tasks, next, more)
+	}
+	if tasks, _, more := GetPage(wrappingStore{&snapshotStore{Store: backend}}, next, 3); len(tasks) != 1 || more {
+		t.Errorf("Expected the last page with task 5, got %v, %t", tasks, more)
+	}
+}
+
+func Test_PageCursor(t *testing.T) {
+	c := PageCursor{Shard: 3, AfterID: 42}
+	decoded, err := DecodePageCursor(c.Encode())
+	if err != nil || decoded != c {
+		t.Errorf("Expected %+v back, got %+v, %v", c, decoded, err)
+	}
+	if decoded, err := DecodePageCursor(""); err != nil || decoded != (PageCursor{}) {
+		t.Errorf("Expected the empty cursor to start, got %+v, %v", decoded, err)
+	}
+	for _, bad := range []string{"!!", "cDE6Mw", "cDE6LTE6Mg", "djE6NDQ"} { // "p1:3", "p1:-1:2", "v1:44"
+		if _, err := DecodePageCursor(bad); err != ErrInvalidPageCursor {
+			t.Errorf("%q: expected ErrInvalidPageCursor, got %v", bad, err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package storetest
+
+import (
+	"sync"
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/storage"
+
+	apperrors "tasks-service-demo/internal/errors"
+)
+
+// Package storetest provides test doubles for storage.Store so consumers can
+// be tested without the real backends or the global store singleton.
+
+// FakeStore is a thread-safe, map-backed storage.Store for tests.
+// It follows the same not-found semantics as the real stores.
+type FakeStore struct {
+	tasks  map[int]*entities.Task // Map to store tasks by ID
+	mu     sync.RWMutex           // Read-write mutex for thread safety
+	nextID int                    // Auto-incrementing ID counter
+}
+
+// NewFakeStore creates an empty FakeStore, optionally seeded with tasks.
+// Seeded tasks keep their IDs; new IDs continue after the highest seeded ID.
+func NewFakeStore(seed ...*entities.Task) *FakeStore {
+	s := &FakeStore{
+		tasks:  make(map[int]*entities.Task),
+		nextID: 1,
+	}
+	for _, task := range seed {
+		s.tasks[task.ID] = task
+		if task.ID >= s.nextID {
+			s.nextID = task.ID + 1
+		}
+	}
+	return s
+}
+
+// Create stores a new task with an auto-generated ID
+func (s *FakeStore) Create(task *entities.Task) *apperrors.AppError {
+	if task == nil {
+		return apperrors.ErrTaskCannotBeNil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task.ID = s.nextID
+	s.nextID++
+	s.tasks[task.ID] = task
+	return nil
+}
+
+// GetByID retrieves a task by its ID, returns error if not found
+func (s *FakeStore) GetByID(id int) (*entities.Task, *apperrors.AppError) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	task, exists := s.tasks[id]
+	if !exists {
+		return nil, apperrors.ErrTaskNotFound
+	}
+	return task, nil
+}
+
+// GetAll returns all tasks in the store
+func (s *FakeStore) GetAll() []*entities.Task {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tasks := make([]*entities.Task, 0, len(s.tasks))
+	for _, task := range s.tasks {
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
+
+// GetRange returns tasks with fromID <= ID <= toID in ID order
+func (s *FakeStore) GetRange(fromID, toID, limit int) []*entities.Task {
+	return storage.SelectRange(s.GetAll(), fromID, toID, limit)
+}
+
+// Update modifies an existing task by ID, returns error if not found
+func (s *FakeStore) Update(id int, updatedTask *entities.Task) *apperrors.AppError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.tasks[id]; !exists {
+		return apperrors.ErrTaskNotFound
+	}
+
+	updatedTask.ID = id
+	s.tasks[id] = updatedTask
+	return nil
+}
+
+// Delete removes a task by ID, returns error if not found
+func (s *FakeStore) Delete(id int) *apperrors.AppError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.tasks[id]; !exists {
+		return apperrors.ErrTaskNotFound
+	}
+
+	delete(s.tasks, id)
+	return nil
+}
+
+// Len returns the number of stored tasks
+func (s *FakeStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.tasks)
+}
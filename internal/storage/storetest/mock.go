@@ -0,0 +1,137 @@
+package storetest
+
+import (
+	"sync"
+	"tasks-service-demo/internal/entities"
+
+	apperrors "tasks-service-demo/internal/errors"
+)
+
+// Method names recorded by MockStore
+const (
+	MethodCreate   = "Create"
+	MethodGetByID  = "GetByID"
+	MethodGetAll   = "GetAll"
+	MethodGetRange = "GetRange"
+	MethodUpdate   = "Update"
+	MethodDelete   = "Delete"
+)
+
+// Call records a single invocation on a MockStore
+type Call struct {
+	Method string
+	ID     int            // Task ID argument (GetByID, Update, Delete), lower bound of GetRange
+	Task   *entities.Task // Task argument (Create, Update)
+}
+
+// MockStore is a storage.Store whose behaviour is scripted per method.
+// Unset functions fall back to benign defaults: writes succeed, GetByID
+// returns ErrTaskNotFound and GetAll and GetRange return an empty slice.
+// Every call is recorded and can be inspected with Calls and CallCount.
+type MockStore struct {
+	CreateFunc   func(task *entities.Task) *apperrors.AppError
+	GetByIDFunc  func(id int) (*entities.Task, *apperrors.AppError)
+	GetAllFunc   func() []*entities.Task
+	GetRangeFunc func(fromID, toID, limit int) []*entities.Task
+	UpdateFunc   func(id int, task *entities.Task) *apperrors.AppError
+	DeleteFunc   func(id int) *apperrors.AppError
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+// NewMockStore creates a MockStore with no scripted behaviour
+func NewMockStore() *MockStore {
+	return &MockStore{}
+}
+
+func (m *MockStore) record(call Call) {
+	m.mu.Lock()
+	m.calls = append(m.calls, call)
+	m.mu.Unlock()
+}
+
+// Create records the call and delegates to CreateFunc
+func (m *MockStore) Create(task *entities.Task) *apperrors.AppError {
+	m.record(Call{Method: MethodCreate, Task: task})
+	if m.CreateFunc != nil {
+		return m.CreateFunc(task)
+	}
+	return nil
+}
+
+// GetByID records the call and delegates to GetByIDFunc
+func (m *MockStore) GetByID(id int) (*entities.Task, *apperrors.AppError) {
+	m.record(Call{Method: MethodGetByID, ID: id})
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(id)
+	}
+	return nil, apperrors.ErrTaskNotFound
+}
+
+// GetAll records the call and delegates to GetAllFunc
+func (m *MockStore) GetAll() []*entities.Task {
+	m.record(Call{Method: MethodGetAll})
+	if m.GetAllFunc != nil {
+		return m.GetAllFunc()
+	}
+	return []*entities.Task{}
+}
+
+// GetRange records the call and delegates to GetRangeFunc
+func (m *MockStore) GetRange(fromID, toID, limit int) []*entities.Task {
+	m.record(Call{Method: MethodGetRange, ID: fromID})
+	if m.GetRangeFunc != nil {
+		return m.GetRangeFunc(fromID, toID, limit)
+	}
+	return []*entities.Task{}
+}
+
+// Update records the call and delegates to UpdateFunc
+func (m *MockStore) Update(id int, task *entities.Task) *apperrors.AppError {
+	m.record(Call{Method: MethodUpdate, ID: id, Task: task})
+	if m.UpdateFunc != nil {
+		return m.UpdateFunc(id, task)
+	}
+	return nil
+}
+
+// Delete records the call and delegates to DeleteFunc
+func (m *MockStore) Delete(id int) *apperrors.AppError {
+	m.record(Call{Method: MethodDelete, ID: id})
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(id)
+	}
+	return nil
+}
+
+// Calls returns a copy of all recorded calls in order
+func (m *MockStore) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	calls := make([]Call, len(m.calls))
+	copy(calls, m.calls)
+	return calls
+}
+
+// CallCount returns how many times the given method was called
+func (m *MockStore) CallCount(method string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := 0
+	for _, call := range m.calls {
+		if call.Method == method {
+			count++
+		}
+	}
+	return count
+}
+
+// Reset clears all recorded calls
+func (m *MockStore) Reset() {
+	m.mu.Lock()
+	m.calls = nil
+	m.mu.Unlock()
+}
@@ -0,0 +1,142 @@
+package storetest
+
+import (
+	"sync"
+	"testing"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage"
+)
+
+func TestFakeStore_ImplementsStore(t *testing.T) {
+	var _ storage.Store = NewFakeStore()
+	var _ storage.Store = NewMockStore()
+}
+
+func TestFakeStore_CRUD(t *testing.T) {
+	store := NewFakeStore()
+
+	task := &entities.Task{Name: "Test Task", Status: 0}
+	if err := store.Create(task); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if task.ID != 1 {
+		t.Errorf("Expected ID 1, got %d", task.ID)
+	}
+
+	got, err := store.GetByID(task.ID)
+	if err != nil || got.Name != "Test Task" {
+		t.Errorf("Expected to read back task, got %v, %v", got, err)
+	}
+
+	if err := store.Update(task.ID, &entities.Task{Name: "Updated", Status: 1}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	got, _ = store.GetByID(task.ID)
+	if got.Name != "Updated" || got.ID != task.ID {
+		t.Errorf("Expected updated task with ID %d, got %+v", task.ID, got)
+	}
+
+	if err := store.Delete(task.ID); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if _, err := store.GetByID(task.ID); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+	if err := store.Update(task.ID, &entities.Task{Name: "x"}); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected ErrTaskNotFound on update, got %v", err)
+	}
+	if err := store.Delete(task.ID); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected ErrTaskNotFound on delete, got %v", err)
+	}
+	if err := store.Create(nil); err != apperrors.ErrTaskCannotBeNil {
+		t.Errorf("Expected ErrTaskCannotBeNil, got %v", err)
+	}
+}
+
+func TestFakeStore_Seed(t *testing.T) {
+	store := NewFakeStore(
+		&entities.Task{ID: 3, Name: "three"},
+		&entities.Task{ID: 7, Name: "seven"},
+	)
+
+	if store.Len() != 2 {
+		t.Errorf("Expected 2 seeded tasks, got %d", store.Len())
+	}
+
+	task := &entities.Task{Name: "next"}
+	store.Create(task)
+	if task.ID != 8 {
+		t.Errorf("Expected new ID to follow highest seeded ID, got %d", task.ID)
+	}
+}
+
+func TestFakeStore_Concurrent(t *testing.T) {
+	store := NewFakeStore()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			task := &entities.Task{Name: "concurrent"}
+			store.Create(task)
+			store.GetByID(task.ID)
+			store.GetAll()
+		}()
+	}
+	wg.Wait()
+
+	if len(store.GetAll()) != 50 {
+		t.Errorf("Expected 50 tasks, got %d", len(store.GetAll()))
+	}
+}
+
+func TestMockStore_Defaults(t *testing.T) {
+	mock := NewMockStore()
+
+	if err := mock.Create(&entities.Task{}); err != nil {
+		t.Errorf("Expected default Create to succeed, got %v", err)
+	}
+	if _, err := mock.GetByID(1); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected default GetByID to return ErrTaskNotFound, got %v", err)
+	}
+	if tasks := mock.GetAll(); tasks == nil || len(tasks) != 0 {
+		t.Errorf("Expected default GetAll to return empty slice, got %v", tasks)
+	}
+	if err := mock.Update(1, &entities.Task{}); err != nil {
+		t.Errorf("Expected default Update to succeed, got %v", err)
+	}
+	if err := mock.Delete(1); err != nil {
+		t.Errorf("Expected default Delete to succeed, got %v", err)
+	}
+
+	if len(mock.Calls()) != 5 {
+		t.Errorf("Expected 5 recorded calls, got %d", len(mock.Calls()))
+	}
+}
+
+func TestMockStore_ScriptedAndRecorded(t *testing.T) {
+	mock := NewMockStore()
+	mock.GetByIDFunc = func(id int) (*entities.Task, *apperrors.AppError) {
+		return nil, apperrors.ErrStorageError
+	}
+
+	if _, err := mock.GetByID(42); err != apperrors.ErrStorageError {
+		t.Errorf("Expected scripted ErrStorageError, got %v", err)
+	}
+	mock.GetByID(43)
+
+	if mock.CallCount(MethodGetByID) != 2 {
+		t.Errorf("Expected 2 GetByID calls, got %d", mock.CallCount(MethodGetByID))
+	}
+	if calls := mock.Calls(); calls[0].ID != 42 || calls[1].ID != 43 {
+		t.Errorf("Expected recorded IDs 42 and 43, got %+v", calls)
+	}
+
+	mock.Reset()
+	if len(mock.Calls()) != 0 {
+		t.Errorf("Expected no calls after Reset, got %d", len(mock.Calls()))
+	}
+}
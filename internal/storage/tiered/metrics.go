@@ -0,0 +1,49 @@
+package tiered
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collector exports TieredStore stats as Prometheus metrics
+type collector struct {
+	store *TieredStore
+
+	hits, misses, promotions, demotions, hotTasks *prometheus.Desc
+}
+
+// NewCollector returns a Prometheus collector for the tier counters.
+// Register it once per TieredStore.
+func NewCollector(namespace string, store *TieredStore) prometheus.Collector {
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "tier", name), help, labels, nil)
+	}
+
+	return &collector{
+		store:      store,
+		hits:       desc("hits_total", "GetByID calls served by each tier.", "tier"),
+		misses:     desc("misses_total", "GetByID calls for tasks in neither tier."),
+		promotions: desc("promotions_total", "Tasks moved to the hot tier after a cold read."),
+		demotions:  desc("demotions_total", "Tasks dropped from the hot tier by size or age."),
+		hotTasks:   desc("hot_tasks", "Tasks currently in the hot tier."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.promotions
+	ch <- c.demotions
+	ch <- c.hotTasks
+}
+
+// Collect implements prometheus.Collector
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.store.Stats()
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.HotHits), "hot")
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.ColdHits), "cold")
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.promotions, prometheus.CounterValue, float64(stats.Promotions))
+	ch <- prometheus.MustNewConstMetric(c.demotions, prometheus.CounterValue, float64(stats.Demotions))
+	ch <- prometheus.MustNewConstMetric(c.hotTasks, prometheus.GaugeValue, float64(stats.HotTasks))
+}
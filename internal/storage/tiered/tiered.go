@@ -0,0 +1,263 @@
+package tiered
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/xsync"
+)
+
+// Package tiered provides a storage.Store decorator that keeps recently used
+// tasks in an in-memory XSyncStore (hot tier) in front of a persistent
+// backend (cold tier). Writes go through to the cold tier, which stays the
+// system of record, so demoting a task only drops its hot copy and a crash
+// never loses a hot-only write. The hot tier is a bounded XSyncStore, which
+// evicts the least recently used tasks without locking reads; its evictions
+// are the demotions.
+
+const (
+	DefaultHotSize       = 10000       // Hot tasks kept when Config.HotSize is not positive
+	DefaultSweepInterval = time.Minute // Age demotion period when Config.SweepInterval is not positive
+)
+
+// Config controls when tasks move between tiers
+type Config struct {
+	HotSize       int           // Hot tasks kept; the least recently used are demoted beyond it
+	MaxAge        time.Duration // Demote tasks not accessed for this long; 0 demotes by size only
+	SweepInterval time.Duration // How often MaxAge is enforced
+}
+
+// Stats counts tier activity since creation
+type Stats struct {
+	HotHits    uint64 `json:"hotHits"`
+	ColdHits   uint64 `json:"coldHits"`
+	Misses     uint64 `json:"misses"`
+	Promotions uint64 `json:"promotions"`
+	Demotions  uint64 `json:"demotions"`
+	HotTasks   int    `json:"hotTasks"`
+}
+
+// TieredStore serves reads from the hot tier and falls back to the cold tier,
+// promoting what it finds there
+type TieredStore struct {
+	hot  *xsync.XSyncStore
+	cold storage.Store
+	cfg  Config
+	now  func() time.Time
+
+	writeMu sync.Mutex // Serializes writes so both tiers apply them in the same order
+	mu      sync.Mutex // Orders promotions against writes; guards seq
+	seq     uint64     // Bumped by every write so racing promotions can be discarded
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+
+	hotHits, coldHits, misses, promotions, demotions atomic.Uint64
+}
+
+// NewTieredStore puts a hot tier configured by cfg in front of cold and
+// starts age-based demotion when cfg.MaxAge is set
+func NewTieredStore(cold storage.Store, cfg Config) *TieredStore {
+	if cfg.HotSize <= 0 {
+		cfg.HotSize = DefaultHotSize
+	}
+	if cfg.SweepInterval <= 0 {
+		cfg.SweepInterval = DefaultSweepInterval
+	}
+
+	s := &TieredStore{
+		cold: cold,
+		cfg:  cfg,
+		now:  time.Now,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	bound := xsync.Bound{
+		MaxEntries: cfg.HotSize,
+		OnEvict:    func(*entities.Task) { s.demotions.Add(1) },
+	}
+	if cfg.MaxAge > 0 {
+		bound.Now = func() time.Time { return s.now() }
+	}
+	s.hot = xsync.NewXSyncStoreBounded(bound)
+	if cfg.MaxAge > 0 {
+		go s.sweepLoop()
+	} else {
+		close(s.done)
+	}
+	return s
+}
+
+// Inner returns the cold tier
+func (s *TieredStore) Inner() storage.Store {
+	return s.cold
+}
+
+// admit puts task in the hot tier, which demotes the least recently used
+// tasks beyond HotSize. Callers hold mu.
+func (s *TieredStore) admit(task *entities.Task) {
+	s.hot.Put(task)
+}
+
+// forget drops a deleted task from the hot tier. Callers hold mu.
+func (s *TieredStore) forget(id int) {
+	s.hot.Delete(id)
+}
+
+// Create stores the task in the cold tier, which assigns its ID, and keeps it hot
+func (s *TieredStore) Create(task *entities.Task) *apperrors.AppError {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if err := s.cold.Create(task); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.seq++
+	s.admit(task)
+	s.mu.Unlock()
+	return nil
+}
+
+// GetByID serves hot tasks from memory without locking and promotes tasks
+// read from the cold tier
+func (s *TieredStore) GetByID(id int) (*entities.Task, *apperrors.AppError) {
+	if task, err := s.hot.GetByID(id); err == nil {
+		s.hotHits.Add(1)
+		return task, nil
+	}
+
+	s.mu.Lock()
+	seq := s.seq
+	s.mu.Unlock()
+
+	task, err := s.cold.GetByID(id)
+	if err != nil {
+		if err == apperrors.ErrTaskNotFound {
+			s.misses.Add(1)
+		}
+		return nil, err
+	}
+	s.coldHits.Add(1)
+
+	// A write since the cold read may have changed or deleted the task
+	s.mu.Lock()
+	if s.seq == seq {
+		s.admit(task)
+		s.promotions.Add(1)
+	}
+	s.mu.Unlock()
+	return task, nil
+}
+
+// GetByIDStrong reads the cold tier, which is the system of record, and
+// refreshes the hot copy with the result
+func (s *TieredStore) GetByIDStrong(id int) (*entities.Task, *apperrors.AppError) {
+	s.mu.Lock()
+	seq := s.seq
+	s.mu.Unlock()
+
+	task, err := storage.GetByIDStrong(s.cold, id)
+	if err != nil && err != apperrors.ErrTaskNotFound {
+		return nil, err
+	}
+
+	// A write since the cold read may have changed or deleted the task
+	s.mu.Lock()
+	if s.seq == seq {
+		if task != nil {
+			s.admit(task)
+		} else {
+			s.forget(id)
+		}
+	}
+	s.mu.Unlock()
+	return task, err
+}
+
+// GetAll reads the cold tier, which holds every task
+func (s *TieredStore) GetAll() []*entities.Task {
+	return s.cold.GetAll()
+}
+
+// GetRange reads the cold tier, which holds every task
+func (s *TieredStore) GetRange(fromID, toID, limit int) []*entities.Task {
+	return s.cold.GetRange(fromID, toID, limit)
+}
+
+// Update writes through to the cold tier and keeps the new version hot
+func (s *TieredStore) Update(id int, task *entities.Task) *apperrors.AppError {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if err := s.cold.Update(id, task); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.seq++
+	s.admit(task)
+	s.mu.Unlock()
+	return nil
+}
+
+// Delete removes the task from both tiers
+func (s *TieredStore) Delete(id int) *apperrors.AppError {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	err := s.cold.Delete(id)
+	s.mu.Lock()
+	s.seq++
+	s.forget(id)
+	s.mu.Unlock()
+	return err
+}
+
+// Sweep demotes hot tasks not accessed within MaxAge and returns how many
+func (s *TieredStore) Sweep() int {
+	if s.cfg.MaxAge <= 0 {
+		return 0
+	}
+	return s.hot.EvictIdle(s.now().Add(-s.cfg.MaxAge))
+}
+
+func (s *TieredStore) sweepLoop() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.cfg.SweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.Sweep()
+		}
+	}
+}
+
+// Close stops age demotion and closes the cold tier
+func (s *TieredStore) Close() error {
+	s.closeOnce.Do(func() { close(s.stop) })
+	<-s.done
+	_, err := storage.Close(s.cold)
+	return err
+}
+
+// Stats returns a snapshot of the tier counters
+func (s *TieredStore) Stats() Stats {
+	hot := s.hot.Len()
+	return Stats{
+		HotHits:    s.hotHits.Load(),
+		ColdHits:   s.coldHits.Load(),
+		Misses:     s.misses.Load(),
+		Promotions: s.promotions.Load(),
+		Demotions:  s.demotions.Load(),
+		HotTasks:   hot,
+	}
+}
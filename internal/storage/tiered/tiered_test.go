@@ -0,0 +1,179 @@
+package tiered
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage/storetest"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestTieredStore_PromotesColdReads(t *testing.T) {
+	cold := storetest.NewFakeStore(&entities.Task{ID: 1, Name: "archived"})
+	store := NewTieredStore(cold, Config{HotSize: 10})
+	defer store.Close()
+
+	for i := 0; i < 3; i++ {
+		if task, err := store.GetByID(1); err != nil || task.Name != "archived" {
+			t.Fatalf("Expected task 1, got %+v, %v", task, err)
+		}
+	}
+	if _, err := store.GetByID(99); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+
+	stats := store.Stats()
+	if stats.ColdHits != 1 || stats.HotHits != 2 || stats.Promotions != 1 || stats.Misses != 1 || stats.HotTasks != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestTieredStore_WritesGoThrough(t *testing.T) {
+	cold := storetest.NewFakeStore()
+	store := NewTieredStore(cold, Config{HotSize: 10})
+	defer store.Close()
+
+	task := &entities.Task{Name: "new"}
+	store.Create(task)
+	if got, err := cold.GetByID(task.ID); err != nil || got.Name != "new" {
+		t.Errorf("Expected create in the cold tier, got %+v, %v", got, err)
+	}
+
+	store.Update(task.ID, &entities.Task{Name: "renamed"})
+	if got, _ := cold.GetByID(task.ID); got.Name != "renamed" {
+		t.Errorf("Expected update in the cold tier, got %+v", got)
+	}
+	if got, _ := store.GetByID(task.ID); got.Name != "renamed" {
+		t.Errorf("Expected hot copy to be updated, got %+v", got)
+	}
+
+	if err := store.Update(99, &entities.Task{Name: "x"}); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+
+	store.Delete(task.ID)
+	if _, err := store.GetByID(task.ID); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected deleted task to be gone from both tiers, got %v", err)
+	}
+	if stats := store.Stats(); stats.HotHits != 1 || stats.HotTasks != 0 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestTieredStore_GetByIDStrong(t *testing.T) {
+	cold := storetest.NewFakeStore(&entities.Task{ID: 1, Name: "v1"})
+	store := NewTieredStore(cold, Config{HotSize: 10})
+	defer store.Close()
+	store.GetByID(1)
+
+	// Changes made past the hot tier, such as backend expiry, are only seen
+	// by strong reads, which refresh the hot copy
+	cold.Update(1, &entities.Task{ID: 1, Name: "v2"})
+	if got, _ := store.GetByID(1); got.Name != "v1" {
+		t.Errorf("Expected the hot 'v1', got '%s'", got.Name)
+	}
+	if got, err := store.GetByIDStrong(1); err != nil || got.Name != "v2" {
+		t.Errorf("Expected 'v2' from the cold tier, got %+v, %v", got, err)
+	}
+	if got, _ := store.GetByID(1); got.Name != "v2" {
+		t.Errorf("Expected the refreshed 'v2', got '%s'", got.Name)
+	}
+
+	cold.Delete(1)
+	if _, err := store.GetByIDStrong(1); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+	if stats := store.Stats(); stats.HotTasks != 0 {
+		t.Errorf("Expected the hot copy to be dropped, got %+v", stats)
+	}
+}
+
+func TestTieredStore_DemotesLeastRecentlyUsed(t *testing.T) {
+	store := NewTieredStore(storetest.NewFakeStore(), Config{HotSize: 2})
+	defer store.Close()
+
+	for i := 0; i < 3; i++ {
+		store.Create(&entities.Task{Name: "task"})
+	}
+	// Task 1 was demoted by task 3; reading it promotes it and demotes task 2
+	store.GetByID(1)
+	store.GetByID(3)
+	store.GetByID(2)
+
+	stats := store.Stats()
+	if stats.HotTasks != 2 || stats.Demotions != 3 || stats.ColdHits != 2 || stats.HotHits != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	if len(store.GetAll()) != 3 {
+		t.Errorf("Expected demoted tasks to stay listed, got %d", len(store.GetAll()))
+	}
+}
+
+func TestTieredStore_SweepDemotesByAge(t *testing.T) {
+	now := time.Unix(1000, 0)
+	store := NewTieredStore(storetest.NewFakeStore(), Config{HotSize: 10, MaxAge: time.Minute, SweepInterval: time.Hour})
+	store.now = func() time.Time { return now }
+	defer store.Close()
+
+	store.Create(&entities.Task{Name: "old"})
+	now = now.Add(45 * time.Second)
+	store.Create(&entities.Task{Name: "recent"})
+	now = now.Add(30 * time.Second)
+
+	if n := store.Sweep(); n != 1 {
+		t.Errorf("Expected 1 demotion, got %d", n)
+	}
+	store.GetByID(2)
+	store.GetByID(1)
+	if stats := store.Stats(); stats.HotHits != 1 || stats.ColdHits != 1 {
+		t.Errorf("Expected only the recent task to stay hot, got %+v", stats)
+	}
+}
+
+type closingStore struct {
+	*storetest.FakeStore
+	closed bool
+}
+
+func (s *closingStore) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestTieredStore_CloseClosesColdTier(t *testing.T) {
+	cold := &closingStore{FakeStore: storetest.NewFakeStore()}
+	store := NewTieredStore(cold, Config{MaxAge: time.Minute, SweepInterval: time.Millisecond})
+
+	time.Sleep(5 * time.Millisecond) // Let the sweeper tick
+	if err := store.Close(); err != nil || !cold.closed {
+		t.Errorf("Expected cold tier to be closed, got %v", err)
+	}
+	if store.Inner() != cold {
+		t.Error("Expected Inner to return the cold tier")
+	}
+}
+
+func TestCollector(t *testing.T) {
+	store := NewTieredStore(storetest.NewFakeStore(&entities.Task{ID: 1, Name: "a"}), Config{})
+	defer store.Close()
+	store.GetByID(1)
+	store.GetByID(1)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewCollector("test", store))
+
+	expected := `
+# HELP test_tier_hits_total GetByID calls served by each tier.
+# TYPE test_tier_hits_total counter
+test_tier_hits_total{tier="cold"} 1
+test_tier_hits_total{tier="hot"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "test_tier_hits_total"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,468 @@
+package writebehind
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/storage"
+)
+
+// Package writebehind provides a storage.Store decorator for slow backends
+// that acknowledges updates and deletes once they are in an in-memory
+// journal and applies them to the backend in batches. Creates stay
+// synchronous because the backend assigns task IDs.
+
+// Mode selects when a buffered write is acknowledged
+type Mode string
+
+const (
+	ModeOff   Mode = "off"   // No buffering; the decorator is not installed
+	ModeAsync Mode = "async" // Acknowledge on journal append; a crash loses up to FlushInterval of writes
+	ModeGroup Mode = "group" // Acknowledge once the batch holding the write is flushed (group commit)
+)
+
+// ParseMode converts a configuration string into a Mode
+func ParseMode(s string) (Mode, error) {
+	switch Mode(s) {
+	case ModeOff, ModeAsync, ModeGroup:
+		return Mode(s), nil
+	default:
+		return "", fmt.Errorf("unknown write-behind mode %q (want off, async or group)", s)
+	}
+}
+
+const (
+	DefaultFlushInterval = 100 * time.Millisecond // Staleness bound when Config.FlushInterval is not positive
+	DefaultBatchSize     = 500                    // Early-flush threshold when Config.BatchSize is not positive
+)
+
+// Config controls buffering
+type Config struct {
+	Mode          Mode
+	FlushInterval time.Duration // Longest a write waits in the journal before a flush starts
+	BatchSize     int           // Pending writes that trigger a flush before the interval
+}
+
+// Stats counts buffering activity since creation
+type Stats struct {
+	Unflushed     int           `json:"unflushed"`     // Acknowledged writes not yet in the backend
+	OldestPending time.Duration `json:"oldestPending"` // Age of the oldest unflushed write
+	Flushed       uint64        `json:"flushed"`
+	Coalesced     uint64        `json:"coalesced"` // Writes replaced by a later write to the same task
+	Batches       uint64        `json:"batches"`
+	Failed        uint64        `json:"failed"`
+}
+
+// op is the latest journaled write to one task; a nil task is a delete
+type op struct {
+	task *entities.Task
+}
+
+// batch is a generation of journaled writes, flushed together
+type batch struct {
+	ops     map[int]op
+	order   []int // First-write order of ops
+	started time.Time
+	done    chan struct{}               // Closed once the batch was applied
+	errs    map[int]*apperrors.AppError // Backend failures by task ID, set before done closes
+}
+
+func newBatch() *batch {
+	return &batch{ops: make(map[int]op), done: make(chan struct{})}
+}
+
+// WriteBehindStore buffers updates and deletes in front of a slow store
+type WriteBehindStore struct {
+	inner storage.Store
+	cfg   Config
+
+	mu       sync.Mutex // Guards pending and flushing
+	pending  *batch     // Accepting writes
+	flushing *batch     // Being applied; nil between flushes
+
+	flushMu sync.Mutex // Serializes flushes
+	kick    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+
+	flushed, coalesced, batches, failed atomic.Uint64
+}
+
+// NewWriteBehindStore wraps inner with a journal configured by cfg and
+// starts the background flusher
+func NewWriteBehindStore(inner storage.Store, cfg Config) *WriteBehindStore {
+	if cfg.Mode == "" || cfg.Mode == ModeOff {
+		cfg.Mode = ModeAsync
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultFlushInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+
+	s := &WriteBehindStore{
+		inner:   inner,
+		cfg:     cfg,
+		pending: newBatch(),
+		kick:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go s.flushLoop()
+	return s
+}
+
+// Inner returns the wrapped store
+func (s *WriteBehindStore) Inner() storage.Store {
+	return s.inner
+}
+
+// Mode returns the configured durability mode
+func (s *WriteBehindStore) Mode() Mode {
+	return s.cfg.Mode
+}
+
+// lookup returns the journaled state of id. Callers hold mu.
+func (s *WriteBehindStore) lookup(id int) (o op, ok bool) {
+	if o, ok = s.pending.ops[id]; ok {
+		return o, true
+	}
+	if s.flushing != nil {
+		o, ok = s.flushing.ops[id]
+	}
+	return o, ok
+}
+
+// exists reports whether id is live, consulting the journal before the backend
+func (s *WriteBehindStore) exists(id int) *apperrors.AppError {
+	s.mu.Lock()
+	o, ok := s.lookup(id)
+	s.mu.Unlock()
+	if ok {
+		if o.task == nil {
+			return apperrors.ErrTaskNotFound
+		}
+		return nil
+	}
+	_, err := s.inner.GetByID(id)
+	return err
+}
+
+// journal appends a write and, in group mode, waits until it is flushed
+func (s *WriteBehindStore) journal(id int, o op) *apperrors.AppError {
+	s.mu.Lock()
+	// A delete journaled since the existence check wins
+	if prev, ok := s.lookup(id); ok && prev.task == nil {
+		s.mu.Unlock()
+		return apperrors.ErrTaskNotFound
+	}
+	b := s.pending
+	if _, ok := b.ops[id]; ok {
+		s.coalesced.Add(1)
+	} else {
+		if len(b.order) == 0 {
+			b.started = time.Now()
+		}
+		b.order = append(b.order, id)
+	}
+	b.ops[id] = o
+	full := len(b.order) >= s.cfg.BatchSize
+	s.mu.Unlock()
+
+	if s.cfg.Mode != ModeGroup {
+		if full {
+			s.trigger()
+		}
+		return nil
+	}
+
+	// Group commit: writers arriving during a flush share the next one
+	s.trigger()
+	<-b.done
+	return b.errs[id]
+}
+
+func (s *WriteBehindStore) trigger() {
+	select {
+	case s.kick <- struct{}{}:
+	default:
+	}
+}
+
+// Create writes through so the backend can assign the ID
+func (s *WriteBehindStore) Create(task *entities.Task) *apperrors.AppError {
+	return s.inner.Create(task)
+}
+
+// GetByID returns the journaled version of a task if there is one
+func (s *WriteBehindStore) GetByID(id int) (*entities.Task, *apperrors.AppError) {
+	s.mu.Lock()
+	o, ok := s.lookup(id)
+	s.mu.Unlock()
+	if ok {
+		if o.task == nil {
+			return nil, apperrors.ErrTaskNotFound
+		}
+		return o.task, nil
+	}
+	return s.inner.GetByID(id)
+}
+
+// GetByIDStrong is GetByID, reading the backend strongly: the journal holds
+// the latest writes, so it is never stale
+func (s *WriteBehindStore) GetByIDStrong(id int) (*entities.Task, *apperrors.AppError) {
+	s.mu.Lock()
+	o, ok := s.lookup(id)
+	s.mu.Unlock()
+	if ok {
+		if o.task == nil {
+			return nil, apperrors.ErrTaskNotFound
+		}
+		return o.task, nil
+	}
+	return storage.GetByIDStrong(s.inner, id)
+}
+
+// GetMany returns the journaled versions of the tasks that have one and
+// looks the rest up in the backend
+func (s *WriteBehindStore) GetMany(ids []int) ([]*entities.Task, []int, *apperrors.AppError) {
+	journaled := make(map[int]*entities.Task)
+	rest := make([]int, 0, len(ids))
+	s.mu.Lock()
+	for _, id := range ids {
+		if o, ok := s.lookup(id); ok {
+			journaled[id] = o.task
+		} else {
+			rest = append(rest, id)
+		}
+	}
+	s.mu.Unlock()
+	if len(journaled) == 0 {
+		return storage.GetMany(s.inner, ids)
+	}
+
+	stored, _, err := storage.GetMany(s.inner, rest)
+	if err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[int]*entities.Task, len(ids))
+	for _, task := range stored {
+		byID[task.ID] = task
+	}
+	for id, task := range journaled {
+		if task != nil {
+			byID[id] = task
+		}
+	}
+
+	found := make([]*entities.Task, 0, len(ids))
+	missing := make([]int, 0)
+	for _, id := range ids {
+		if task, ok := byID[id]; ok {
+			found = append(found, task)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	return found, missing, nil
+}
+
+// GetAll overlays journaled writes on the backend listing
+func (s *WriteBehindStore) GetAll() []*entities.Task {
+	return s.overlay(s.inner.GetAll())
+}
+
+// Snapshot overlays journaled writes on a snapshot of the backend
+func (s *WriteBehindStore) Snapshot() []*entities.Task {
+	return s.overlay(storage.Snapshot(s.inner))
+}
+
+// overlay replaces the tasks that have journaled writes with their
+// journaled versions and drops journaled deletes
+func (s *WriteBehindStore) overlay(tasks []*entities.Task) []*entities.Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending.ops) == 0 && s.flushing == nil {
+		return tasks
+	}
+
+	result := make([]*entities.Task, 0, len(tasks))
+	for _, task := range tasks {
+		o, ok := s.lookup(task.ID)
+		switch {
+		case !ok:
+			result = append(result, task)
+		case o.task != nil:
+			result = append(result, o.task)
+		}
+	}
+	return result
+}
+
+// GetRange overlays journaled writes on the backend range. Journaled
+// deletes can hide backend tasks, so the backend is asked for that many
+// extra tasks to still fill limit.
+func (s *WriteBehindStore) GetRange(fromID, toID, limit int) []*entities.Task {
+	s.mu.Lock()
+	deletes := 0
+	for _, b := range []*batch{s.pending, s.flushing} {
+		if b == nil {
+			continue
+		}
+		for id, o := range b.ops {
+			if o.task == nil && id >= fromID && id <= toID {
+				deletes++
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	innerLimit := limit
+	if limit > 0 {
+		innerLimit += deletes
+	}
+	tasks := s.inner.GetRange(fromID, toID, innerLimit)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]*entities.Task, 0, len(tasks))
+	for _, task := range tasks {
+		o, ok := s.lookup(task.ID)
+		switch {
+		case !ok:
+			result = append(result, task)
+		case o.task != nil:
+			result = append(result, o.task)
+		}
+	}
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// Update journals the new version of an existing task
+func (s *WriteBehindStore) Update(id int, task *entities.Task) *apperrors.AppError {
+	if err := s.exists(id); err != nil {
+		return err
+	}
+	task.ID = id
+	return s.journal(id, op{task: task})
+}
+
+// Delete journals the removal of an existing task
+func (s *WriteBehindStore) Delete(id int) *apperrors.AppError {
+	if err := s.exists(id); err != nil {
+		return err
+	}
+	return s.journal(id, op{})
+}
+
+func (s *WriteBehindStore) flushLoop() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.cfg.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			s.Flush()
+			return
+		case <-ticker.C:
+		case <-s.kick:
+		}
+		s.Flush()
+	}
+}
+
+// Flush applies every journaled write to the backend. Failed writes are
+// logged and put back in the journal unless a newer write replaced them.
+func (s *WriteBehindStore) Flush() {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.Lock()
+	b := s.pending
+	if len(b.order) == 0 {
+		s.mu.Unlock()
+		return
+	}
+	s.flushing, s.pending = b, newBatch()
+	s.mu.Unlock()
+
+	b.errs = make(map[int]*apperrors.AppError)
+	for _, id := range b.order {
+		o := b.ops[id]
+		var err *apperrors.AppError
+		if o.task == nil {
+			err = s.inner.Delete(id)
+		} else {
+			err = s.inner.Update(id, o.task)
+		}
+		if err != nil {
+			b.errs[id] = err
+		}
+	}
+	s.batches.Add(1)
+	s.flushed.Add(uint64(len(b.order) - len(b.errs)))
+
+	s.mu.Lock()
+	s.flushing = nil
+	for id, err := range b.errs {
+		s.failed.Add(1)
+		logger.For(logger.ModuleStorage).Errorw("Write-behind flush failed", "taskId", id, "error", err)
+		// Not-found means the task is gone from the backend; nothing to retry.
+		// Group-mode writers get the error instead of a retry.
+		if err == apperrors.ErrTaskNotFound || s.cfg.Mode == ModeGroup {
+			continue
+		}
+		if _, newer := s.pending.ops[id]; !newer {
+			if len(s.pending.order) == 0 {
+				s.pending.started = b.started
+			}
+			s.pending.ops[id] = b.ops[id]
+			s.pending.order = append(s.pending.order, id)
+		}
+	}
+	s.mu.Unlock()
+	close(b.done)
+}
+
+// Close flushes the journal, stops the flusher and closes the backend
+func (s *WriteBehindStore) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	<-s.done
+	_, err := storage.Close(s.inner)
+	return err
+}
+
+// Stats returns a snapshot of the journal and flush counters
+func (s *WriteBehindStore) Stats() Stats {
+	s.mu.Lock()
+	unflushed := len(s.pending.order)
+	started := s.pending.started
+	if s.flushing != nil {
+		unflushed += len(s.flushing.order)
+		started = s.flushing.started
+	}
+	s.mu.Unlock()
+
+	stats := Stats{
+		Unflushed: unflushed,
+		Flushed:   s.flushed.Load(),
+		Coalesced: s.coalesced.Load(),
+		Batches:   s.batches.Load(),
+		Failed:    s.failed.Load(),
+	}
+	if unflushed > 0 {
+		stats.OldestPending = time.Since(started)
+	}
+	return stats
+}
@@ -0,0 +1,256 @@
+package writebehind
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage/storetest"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// slowStore counts backend writes
+type slowStore struct {
+	*storetest.FakeStore
+	mu      sync.Mutex
+	updates int
+	fail    bool
+}
+
+func (s *slowStore) Update(id int, task *entities.Task) *apperrors.AppError {
+	s.mu.Lock()
+	s.updates++
+	fail := s.fail
+	s.mu.Unlock()
+	if fail {
+		return apperrors.ErrStorageError
+	}
+	return s.FakeStore.Update(id, task)
+}
+
+func newSlowStore(seed ...*entities.Task) *slowStore {
+	return &slowStore{FakeStore: storetest.NewFakeStore(seed...)}
+}
+
+func TestParseMode(t *testing.T) {
+	for _, s := range []string{"off", "async", "group"} {
+		if m, err := ParseMode(s); err != nil || string(m) != s {
+			t.Errorf("Expected %s, got %s, %v", s, m, err)
+		}
+	}
+	if _, err := ParseMode("sync"); err == nil {
+		t.Error("Expected unknown mode to be rejected")
+	}
+}
+
+func TestWriteBehindStore_AsyncBuffersAndCoalesces(t *testing.T) {
+	inner := newSlowStore(&entities.Task{ID: 1, Name: "a"}, &entities.Task{ID: 2, Name: "b"})
+	store := NewWriteBehindStore(inner, Config{Mode: ModeAsync, FlushInterval: time.Hour})
+	defer store.Close()
+
+	store.Update(1, &entities.Task{Name: "a2"})
+	store.Update(1, &entities.Task{Name: "a3"})
+	store.Delete(2)
+
+	// Acknowledged but not yet in the backend; reads see the journal
+	if got, _ := inner.FakeStore.GetByID(1); got.Name != "a" {
+		t.Errorf("Expected backend to be untouched before flush, got %+v", got)
+	}
+	if got, err := store.GetByID(1); err != nil || got.Name != "a3" {
+		t.Errorf("Expected journaled version, got %+v, %v", got, err)
+	}
+	if _, err := store.GetByID(2); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected journaled delete, got %v", err)
+	}
+	if all := store.GetAll(); len(all) != 1 || all[0].Name != "a3" {
+		t.Errorf("Expected listing with journal applied, got %+v", all)
+	}
+	if stats := store.Stats(); stats.Unflushed != 2 || stats.Coalesced != 1 || stats.OldestPending <= 0 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	store.Flush()
+	if got, _ := inner.FakeStore.GetByID(1); got.Name != "a3" {
+		t.Errorf("Expected flushed update, got %+v", got)
+	}
+	if _, err := inner.FakeStore.GetByID(2); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected flushed delete, got %v", err)
+	}
+	if inner.updates != 1 {
+		t.Errorf("Expected coalesced updates to reach the backend once, got %d", inner.updates)
+	}
+	if stats := store.Stats(); stats.Unflushed != 0 || stats.Flushed != 2 || stats.Batches != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestWriteBehindStore_RejectsMissingTasks(t *testing.T) {
+	store := NewWriteBehindStore(newSlowStore(&entities.Task{ID: 1, Name: "a"}), Config{FlushInterval: time.Hour})
+	defer store.Close()
+
+	if err := store.Update(99, &entities.Task{Name: "x"}); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+	store.Delete(1)
+	if err := store.Update(1, &entities.Task{Name: "x"}); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected journaled delete to hide the task, got %v", err)
+	}
+	if err := store.Delete(1); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected second delete to fail, got %v", err)
+	}
+}
+
+func TestWriteBehindStore_FlushesByBatchSizeAndInterval(t *testing.T) {
+	inner := newSlowStore(&entities.Task{ID: 1, Name: "a"}, &entities.Task{ID: 2, Name: "b"})
+	store := NewWriteBehindStore(inner, Config{FlushInterval: 20 * time.Millisecond, BatchSize: 2})
+	defer store.Close()
+
+	store.Update(1, &entities.Task{Name: "a2"})
+	store.Update(2, &entities.Task{Name: "b2"})
+
+	deadline := time.Now().Add(time.Second)
+	for store.Stats().Unflushed > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got, _ := inner.FakeStore.GetByID(2); got.Name != "b2" {
+		t.Errorf("Expected background flush, got %+v", got)
+	}
+}
+
+func TestWriteBehindStore_GroupWaitsForFlush(t *testing.T) {
+	inner := newSlowStore(&entities.Task{ID: 1, Name: "a"})
+	store := NewWriteBehindStore(inner, Config{Mode: ModeGroup, FlushInterval: time.Hour})
+	defer store.Close()
+
+	if err := store.Update(1, &entities.Task{Name: "a2"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got, _ := inner.FakeStore.GetByID(1); got.Name != "a2" {
+		t.Errorf("Expected write to be in the backend when acknowledged, got %+v", got)
+	}
+
+	inner.fail = true
+	if err := store.Update(1, &entities.Task{Name: "a3"}); err != apperrors.ErrStorageError {
+		t.Errorf("Expected backend error to reach the writer, got %v", err)
+	}
+	if stats := store.Stats(); stats.Failed != 1 || stats.Unflushed != 0 {
+		t.Errorf("Expected failed write to be reported, not retried, got %+v", stats)
+	}
+}
+
+func TestWriteBehindStore_RetriesFailedAsyncWrites(t *testing.T) {
+	inner := newSlowStore(&entities.Task{ID: 1, Name: "a"})
+	inner.fail = true
+	store := NewWriteBehindStore(inner, Config{FlushInterval: time.Hour})
+	defer store.Close()
+
+	store.Update(1, &entities.Task{Name: "a2"})
+	store.Flush()
+	if stats := store.Stats(); stats.Failed != 1 || stats.Unflushed != 1 {
+		t.Fatalf("Expected failed write back in the journal, got %+v", stats)
+	}
+
+	inner.fail = false
+	store.Flush()
+	if got, _ := inner.FakeStore.GetByID(1); got.Name != "a2" {
+		t.Errorf("Expected retried write, got %+v", got)
+	}
+}
+
+func TestWriteBehindStore_CloseFlushes(t *testing.T) {
+	inner := newSlowStore(&entities.Task{ID: 1, Name: "a"})
+	store := NewWriteBehindStore(inner, Config{FlushInterval: time.Hour})
+
+	store.Update(1, &entities.Task{Name: "a2"})
+	store.Close()
+	if got, _ := inner.FakeStore.GetByID(1); got.Name != "a2" {
+		t.Errorf("Expected pending writes flushed on close, got %+v", got)
+	}
+}
+
+func TestCollector(t *testing.T) {
+	store := NewWriteBehindStore(newSlowStore(&entities.Task{ID: 1, Name: "a"}), Config{FlushInterval: time.Hour})
+	defer store.Close()
+	store.Update(1, &entities.Task{Name: "a2"})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewCollector("test", store))
+
+	expected := `
+# HELP test_write_behind_unflushed_writes Acknowledged writes not yet applied to the backend; lost if the process crashes.
+# TYPE test_write_behind_unflushed_writes gauge
+test_write_behind_unflushed_writes{mode="async"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "test_write_behind_unflushed_writes"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWriteBehindStore_GetRangeOverlaysJournal(t *testing.T) {
+	inner := newSlowStore(
+		&entities.Task{ID: 1, Name: "a"},
+		&entities.Task{ID: 2, Name: "b"},
+		&entities.Task{ID: 3, Name: "c"},
+		&entities.Task{ID: 4, Name: "d"},
+	)
+	store := NewWriteBehindStore(inner, Config{Mode: ModeAsync, FlushInterval: time.Hour})
+	defer store.Close()
+
+	store.Delete(1)
+	store.Update(3, &entities.Task{Name: "c2"})
+
+	// The journaled delete must not shrink the page below limit
+	tasks := store.GetRange(1, math.MaxInt, 2)
+	if len(tasks) != 2 || tasks[0].ID != 2 || tasks[1].ID != 3 || tasks[1].Name != "c2" {
+		t.Errorf("Expected tasks 2 and 3 with the journal applied, got %+v", tasks)
+	}
+}
+
+func TestWriteBehindStore_SnapshotOverlaysJournal(t *testing.T) {
+	inner := newSlowStore(&entities.Task{ID: 1, Name: "a"}, &entities.Task{ID: 2, Name: "b"})
+	store := NewWriteBehindStore(inner, Config{Mode: ModeAsync, FlushInterval: time.Hour})
+	defer store.Close()
+
+	store.Delete(1)
+	store.Update(2, &entities.Task{Name: "b2"})
+	if tasks := store.Snapshot(); len(tasks) != 1 || tasks[0].ID != 2 || tasks[0].Name != "b2" {
+		t.Errorf("Expected task 2 with the journal applied, got %+v", tasks)
+	}
+}
+
+func TestWriteBehindStore_GetManyOverlaysJournal(t *testing.T) {
+	inner := newSlowStore(&entities.Task{ID: 1, Name: "a"}, &entities.Task{ID: 2, Name: "b"}, &entities.Task{ID: 3, Name: "c"})
+	store := NewWriteBehindStore(inner, Config{Mode: ModeAsync, FlushInterval: time.Hour})
+	defer store.Close()
+
+	store.Delete(1)
+	store.Update(3, &entities.Task{Name: "c2"})
+	found, missing, err := store.GetMany([]int{3, 1, 2, 4})
+	if err != nil || len(found) != 2 || found[0].Name != "c2" || found[1].Name != "b" {
+		t.Errorf("Expected tasks 3 and 2 with the journal applied, got %+v, %v", found, err)
+	}
+	if len(missing) != 2 || missing[0] != 1 || missing[1] != 4 {
+		t.Errorf("Expected 1 and 4 missing, got %v", missing)
+	}
+}
+
+func TestWriteBehindStore_GetByIDStrongOverlaysJournal(t *testing.T) {
+	inner := newSlowStore(&entities.Task{ID: 1, Name: "a"}, &entities.Task{ID: 2, Name: "b"})
+	store := NewWriteBehindStore(inner, Config{Mode: ModeAsync, FlushInterval: time.Hour})
+	defer store.Close()
+
+	store.Delete(1)
+	store.Update(2, &entities.Task{Name: "b2"})
+	if _, err := store.GetByIDStrong(1); err != apperrors.ErrTaskNotFound {
+		t.Errorf("Expected the journaled delete, got %v", err)
+	}
+	if task, err := store.GetByIDStrong(2); err != nil || task.Name != "b2" {
+		t.Errorf("Expected the journaled update, got %+v, %v", task, err)
+	}
+}
@@ -0,0 +1,132 @@
+package xsync
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+
+	"github.com/puzpuzpuz/xsync/v3"
+)
+
+// Bound limits how many tasks an XSyncStore keeps. Past MaxEntries the store
+// evicts tasks with the CLOCK algorithm, an approximation of LRU: reads only
+// set a flag on the task, so they stay lock-free, and eviction passes over
+// flagged tasks once, clearing the flag, before it takes them.
+type Bound struct {
+	MaxEntries int                  // Tasks kept; 0 leaves the size unbounded
+	OnEvict    func(*entities.Task) // Called with each evicted task, after the write that evicted it
+	Now        func() time.Time     // Records access times for EvictIdle; nil skips them, saving a clock read per access
+}
+
+// clockEntry is the eviction state of one stored task
+type clockEntry struct {
+	id         int
+	referenced atomic.Bool  // Accessed since the hand last passed
+	lastAccess atomic.Int64 // Unix nanoseconds, with Bound.Now
+	el         *list.Element
+}
+
+// bound tracks the tasks of a bounded XSyncStore in a ring swept by a clock
+// hand. Writes to the store hold mu; reads only load entries.
+type bound struct {
+	Bound
+	entries *xsync.MapOf[int, *clockEntry]
+
+	mu   sync.Mutex
+	ring *list.List    // Tasks in insertion order, read circularly
+	hand *list.Element // Next entry to consider for eviction; nil is the front
+}
+
+func newBound(b Bound) *bound {
+	return &bound{Bound: b, entries: xsync.NewMapOf[int, *clockEntry](), ring: list.New()}
+}
+
+// touch records an access to the task with id
+func (b *bound) touch(id int) {
+	if e, ok := b.entries.Load(id); ok {
+		b.access(e)
+	}
+}
+
+func (b *bound) access(e *clockEntry) {
+	if !e.referenced.Load() { // Hot tasks are read far more often than the hand passes
+		e.referenced.Store(true)
+	}
+	if b.Now != nil {
+		e.lastAccess.Store(b.Now().UnixNano())
+	}
+}
+
+// add tracks a stored task; storing over a tracked one counts as an access.
+// New tasks go just behind the hand, so they are considered last. Callers
+// hold mu.
+func (b *bound) add(id int) {
+	if e, ok := b.entries.Load(id); ok {
+		b.access(e)
+		return
+	}
+	e := &clockEntry{id: id}
+	if b.Now != nil {
+		e.lastAccess.Store(b.Now().UnixNano())
+	}
+	if b.hand == nil {
+		e.el = b.ring.PushBack(e)
+	} else {
+		e.el = b.ring.InsertBefore(e, b.hand)
+	}
+	b.entries.Store(id, e)
+}
+
+// remove stops tracking e. Callers hold mu.
+func (b *bound) remove(e *clockEntry) {
+	if b.hand == e.el {
+		b.hand = e.el.Next()
+	}
+	b.ring.Remove(e.el)
+	b.entries.Delete(e.id)
+}
+
+// forget stops tracking the task with id, if it is tracked. Callers hold mu.
+func (b *bound) forget(id int) {
+	if e, ok := b.entries.Load(id); ok {
+		b.remove(e)
+	}
+}
+
+// victims advances the hand until the ring fits MaxEntries, and returns the
+// IDs it stopped tracking. Callers hold mu.
+func (b *bound) victims() []int {
+	var ids []int
+	for b.MaxEntries > 0 && b.ring.Len() > b.MaxEntries {
+		if b.hand == nil {
+			b.hand = b.ring.Front()
+		}
+		e := b.hand.Value.(*clockEntry)
+		if e.referenced.Swap(false) {
+			b.hand = b.hand.Next() // Second chance
+			continue
+		}
+		b.remove(e)
+		ids = append(ids, e.id)
+	}
+	return ids
+}
+
+// idle stops tracking the tasks not accessed since before, and returns their
+// IDs. Callers hold mu.
+func (b *bound) idle(before time.Time) []int {
+	var ids []int
+	cutoff := before.UnixNano()
+	for el := b.ring.Front(); el != nil; {
+		e := el.Value.(*clockEntry)
+		el = el.Next()
+		if e.lastAccess.Load() < cutoff {
+			b.remove(e)
+			ids = append(ids, e.id)
+		}
+	}
+	return ids
+}
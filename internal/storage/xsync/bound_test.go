@@ -0,0 +1,102 @@
+package xsync
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXSyncStoreBounded_EvictsLeastRecentlyUsed(t *testing.T) {
+	var evicted []int
+	store := NewXSyncStoreBounded(Bound{MaxEntries: 3, OnEvict: func(task *entities.Task) {
+		evicted = append(evicted, task.ID)
+	}})
+
+	for i := 0; i < 3; i++ {
+		store.Create(&entities.Task{Name: "t"})
+	}
+	store.GetByID(1) // Task 1 gets a second chance, task 2 goes
+	store.Create(&entities.Task{Name: "t"})
+	assert.Equal(t, []int{2}, evicted)
+
+	// Task 3 was updated, so the unread task 4 goes; task 1 spent its
+	// second chance and goes next
+	store.Update(3, &entities.Task{Name: "renamed"})
+	store.Put(&entities.Task{ID: 10, Name: "put"})
+	store.Create(&entities.Task{Name: "t"})
+	assert.Equal(t, []int{2, 4, 1}, evicted)
+
+	assert.Equal(t, 3, store.Len())
+	_, err := store.GetByID(2)
+	assert.Equal(t, apperrors.ErrTaskNotFound, err)
+	for _, id := range []int{3, 10, 11} {
+		_, err := store.GetByID(id)
+		assert.Nil(t, err, "task %d", id)
+	}
+}
+
+func TestXSyncStoreBounded_DeleteAndBulkLoad(t *testing.T) {
+	evictions := 0
+	store := NewXSyncStoreBounded(Bound{MaxEntries: 2, OnEvict: func(*entities.Task) { evictions++ }})
+
+	store.Create(&entities.Task{Name: "a"})
+	store.Create(&entities.Task{Name: "b"})
+	require.Nil(t, store.Delete(1))
+	assert.Equal(t, apperrors.ErrTaskNotFound, store.Delete(1))
+	store.Create(&entities.Task{Name: "c"})
+	assert.Equal(t, 0, evictions, "a deleted task frees its place")
+
+	require.Nil(t, store.BulkLoad([]*entities.Task{{ID: 20}, {ID: 21}, {ID: 22}}))
+	assert.Equal(t, 3, evictions)
+	assert.Equal(t, 2, store.Len())
+}
+
+func TestXSyncStoreBounded_EvictIdle(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var evicted []int
+	store := NewXSyncStoreBounded(Bound{
+		Now:     func() time.Time { return now },
+		OnEvict: func(task *entities.Task) { evicted = append(evicted, task.ID) },
+	})
+
+	for i := 0; i < 3; i++ {
+		store.Create(&entities.Task{Name: "t"})
+	}
+	now = now.Add(time.Minute)
+	store.GetByID(2)
+
+	assert.Equal(t, 2, store.EvictIdle(now.Add(-time.Second)))
+	assert.Equal(t, []int{1, 3}, evicted)
+	assert.Equal(t, 1, store.Len())
+	assert.Equal(t, 0, NewXSyncStore().EvictIdle(now), "unbounded stores keep no access times")
+}
+
+func TestXSyncStoreBounded_Concurrent(t *testing.T) {
+	store := NewXSyncStoreBounded(Bound{MaxEntries: 100})
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				task := &entities.Task{Name: "t"}
+				store.Create(task)
+				store.GetByID(task.ID - w)
+				if i%5 == 0 {
+					store.Delete(task.ID)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, store.Len(), 100)
+	assert.Equal(t, store.Len(), store.bound.ring.Len(), "every stored task is tracked once")
+}
@@ -0,0 +1,163 @@
+package xsync
+
+import (
+	"sort"
+	"sync/atomic"
+
+	"tasks-service-demo/internal/entities"
+
+	"github.com/puzpuzpuz/xsync/v3"
+)
+
+// Collection is a lock-free in-memory collection of any entity type on an
+// xsync.MapOf, with IDs from an atomic counter. XSyncStore keeps its tasks
+// in one; other resources use it directly. It reports "not found" with
+// false, leaving the error to the resource's service.
+type Collection[T entities.Entity] struct {
+	items  *xsync.MapOf[int, T]
+	nextID int64 // Next ID to hand out
+}
+
+// NewCollection creates an empty collection
+func NewCollection[T entities.Entity]() *Collection[T] {
+	return &Collection[T]{items: xsync.NewMapOf[int, T](), nextID: 1}
+}
+
+// NewCollectionPresized creates a collection whose map holds expected items
+// before it first grows
+func NewCollectionPresized[T entities.Entity](expected int) *Collection[T] {
+	return &Collection[T]{items: xsync.NewMapOfPresized[int, T](expected), nextID: 1}
+}
+
+// Create stores item under the next ID, which it sets on item and returns
+func (c *Collection[T]) Create(item T) int {
+	id := int(atomic.AddInt64(&c.nextID, 1) - 1)
+	item.SetID(id)
+	c.items.Store(id, item)
+	return id
+}
+
+// Get returns the item with id
+func (c *Collection[T]) Get(id int) (T, bool) {
+	return c.items.Load(id)
+}
+
+// Update replaces the item with id, reporting whether it existed. The
+// existence check and the store happen in one Compute, so an Update racing
+// a Delete can't bring the item back.
+func (c *Collection[T]) Update(id int, item T) bool {
+	found := false
+	c.items.Compute(id, func(old T, loaded bool) (T, bool) {
+		if !loaded {
+			return old, true // Nothing to delete; leaves the key absent
+		}
+		found = true
+		item.SetID(id)
+		return item, false
+	})
+	return found
+}
+
+// Delete removes the item with id, reporting whether it existed
+func (c *Collection[T]) Delete(id int) bool {
+	_, found := c.items.LoadAndDelete(id)
+	return found
+}
+
+// take removes the item with id and returns it
+func (c *Collection[T]) take(id int) (T, bool) {
+	return c.items.LoadAndDelete(id)
+}
+
+// All returns every item, in no particular order
+func (c *Collection[T]) All() []T {
+	items := make([]T, 0)
+	c.items.Range(func(_ int, item T) bool {
+		items = append(items, item)
+		return true
+	})
+	return items
+}
+
+// Range returns the items with fromID <= ID <= toID in ID order, at most
+// limit of them (limit <= 0 means no limit). IDs are assigned sequentially,
+// so it walks the IDs instead of sorting the map.
+func (c *Collection[T]) Range(fromID, toID, limit int) []T {
+	items := make([]T, 0)
+	last := int(atomic.LoadInt64(&c.nextID) - 1)
+	for id := max(fromID, 1); id <= min(toID, last); id++ {
+		if limit > 0 && len(items) == limit {
+			break
+		}
+		if item, ok := c.items.Load(id); ok {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Page returns up to limit items with IDs after afterID in ID order, and
+// whether no item follows. It walks the IDs like Range, but when the walk
+// would take more steps than there are items, e.g. after many deletes, it
+// sorts the rest of the map instead, so a page never costs more than a
+// listing.
+func (c *Collection[T]) Page(afterID, limit int) (items []T, done bool) {
+	last := int(atomic.LoadInt64(&c.nextID) - 1)
+	budget := c.items.Size()
+	items = make([]T, 0, min(limit, budget))
+	id := afterID + 1
+	for steps := 0; id <= last && len(items) < limit; steps++ {
+		if steps == budget {
+			rest := make([]T, 0)
+			after := id - 1
+			c.items.Range(func(itemID int, item T) bool {
+				if itemID > after {
+					rest = append(rest, item)
+				}
+				return true
+			})
+			sort.Slice(rest, func(i, j int) bool { return rest[i].GetID() < rest[j].GetID() })
+			need := limit - len(items)
+			done = len(rest) <= need
+			return append(items, rest[:min(len(rest), need)]...), done
+		}
+		if item, ok := c.items.Load(id); ok {
+			items = append(items, item)
+		}
+		id++
+	}
+	return items, id > last
+}
+
+// Put stores item under its existing ID, for callers that allocate IDs
+// elsewhere. Later Creates continue after the highest ID put.
+func (c *Collection[T]) Put(item T) {
+	c.raise(item.GetID())
+	c.items.Store(item.GetID(), item)
+}
+
+// PutMany stores items under their existing IDs and moves the ID counter
+// past the highest of them once, instead of once per item as Put does
+func (c *Collection[T]) PutMany(items []T) {
+	maxID := 0
+	for _, item := range items {
+		c.items.Store(item.GetID(), item)
+		maxID = max(maxID, item.GetID())
+	}
+	c.raise(maxID)
+}
+
+// raise moves the ID counter past id
+func (c *Collection[T]) raise(id int) {
+	for {
+		next := atomic.LoadInt64(&c.nextID)
+		if int64(id) < next || atomic.CompareAndSwapInt64(&c.nextID, next, int64(id)+1) {
+			return
+		}
+	}
+}
+
+// Len returns the number of items
+func (c *Collection[T]) Len() int {
+	return c.items.Size()
+}
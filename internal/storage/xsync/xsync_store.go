@@ -1,76 +1,217 @@
 package xsync
 
 import (
-	"sync/atomic"
-	"tasks-service-demo/internal/entities"
+	"time"
 
+	"tasks-service-demo/internal/entities"
 	apperrors "tasks-service-demo/internal/errors"
+
 	"github.com/puzpuzpuz/xsync/v3"
 )
 
 // XSyncStore provides an in-memory storage implementation using xsync.Map
 type XSyncStore struct {
-	tasks  *xsync.MapOf[int, *entities.Task] // Concurrent map to store tasks by ID
-	nextID int64                             // Atomic counter for ID generation
+	tasks *Collection[*entities.Task] // Lock-free map of tasks by ID, with the ID counter
+	keys  *xsync.MapOf[string, int]   // External key -> task ID, for upserts
+	bound *bound                      // Nil when the store is unbounded
 }
 
 func NewXSyncStore() *XSyncStore {
 	return &XSyncStore{
-		tasks:  xsync.NewMapOf[int, *entities.Task](),
-		nextID: 1,
+		tasks: NewCollection[*entities.Task](),
+		keys:  xsync.NewMapOf[string, int](),
+	}
+}
+
+// NewXSyncStorePresized creates a store whose map holds expected tasks
+// before it first grows. The map resizes without blocking readers, so the
+// store does not implement storage.Preallocator; the hint only saves the
+// early resizes of a known dataset size.
+func NewXSyncStorePresized(expected int) *XSyncStore {
+	return &XSyncStore{
+		tasks: NewCollectionPresized[*entities.Task](expected),
+		keys:  xsync.NewMapOf[string, int](),
 	}
 }
 
+// NewXSyncStoreBounded creates a store that keeps at most b.MaxEntries
+// tasks, evicting the least recently used ones (see Bound). Reads stay
+// lock-free; writes that add or remove tasks are serialized.
+func NewXSyncStoreBounded(b Bound) *XSyncStore {
+	s := NewXSyncStore()
+	s.bound = newBound(b)
+	return s
+}
+
+// write runs fn, which adds or removes tasks and returns the IDs of any to
+// evict, under the lock of the bound. Tasks past MaxEntries are evicted too,
+// and all of them are reported to OnEvict once the lock is released. It
+// returns how many tasks were evicted.
+func (s *XSyncStore) write(fn func() []int) int {
+	b := s.bound
+	b.mu.Lock()
+	var evicted []*entities.Task
+	for _, id := range append(fn(), b.victims()...) {
+		if task, ok := s.tasks.take(id); ok {
+			evicted = append(evicted, task)
+		}
+	}
+	b.mu.Unlock()
+
+	if b.OnEvict != nil {
+		for _, task := range evicted {
+			b.OnEvict(task)
+		}
+	}
+	return len(evicted)
+}
+
 // Create stores a new task with an auto-generated ID
 func (s *XSyncStore) Create(task *entities.Task) *apperrors.AppError {
-	// Generate unique ID atomically
-	id := int(atomic.AddInt64(&s.nextID, 1) - 1)
-	task.ID = id
-	
-	s.tasks.Store(id, task)
+	if s.bound == nil {
+		s.tasks.Create(task)
+		return nil
+	}
+	s.write(func() []int {
+		s.bound.add(s.tasks.Create(task))
+		return nil
+	})
 	return nil
 }
 
 // GetByID retrieves a task by its ID, returns error if not found
 func (s *XSyncStore) GetByID(id int) (*entities.Task, *apperrors.AppError) {
-	task, ok := s.tasks.Load(id)
+	task, ok := s.tasks.Get(id)
 	if !ok {
 		return nil, apperrors.ErrTaskNotFound
 	}
+	if s.bound != nil {
+		s.bound.touch(id)
+	}
 	return task, nil
 }
 
 // GetAll returns all tasks in the store
 func (s *XSyncStore) GetAll() []*entities.Task {
-	tasks := make([]*entities.Task, 0)
-	
-	s.tasks.Range(func(key int, value *entities.Task) bool {
-		tasks = append(tasks, value)
-		return true // Continue iteration
-	})
-	
-	return tasks
+	return s.tasks.All()
+}
+
+// GetRange returns tasks with fromID <= ID <= toID in ID order. IDs are
+// assigned sequentially, so it walks the IDs instead of sorting the map.
+func (s *XSyncStore) GetRange(fromID, toID, limit int) []*entities.Task {
+	return s.tasks.Range(fromID, toID, limit)
+}
+
+// GetPage returns the tasks after afterID in ID order. The store has no
+// shards, so the position of the next page is always shard 0.
+func (s *XSyncStore) GetPage(shard, afterID, limit int) ([]*entities.Task, int, int, bool) {
+	tasks, done := s.tasks.Page(afterID, limit)
+	if len(tasks) > 0 {
+		afterID = tasks[len(tasks)-1].ID
+	}
+	return tasks, 0, afterID, done
 }
 
-// Update modifies an existing task by ID, returns error if not found
+// Update modifies an existing task by ID, returns error if not found. The
+// existence check and the store happen in one Compute, so an Update racing
+// a Delete can't bring the task back.
 func (s *XSyncStore) Update(id int, updatedTask *entities.Task) *apperrors.AppError {
-	// Check if task exists first
-	if _, ok := s.tasks.Load(id); !ok {
+	if !s.tasks.Update(id, updatedTask) {
 		return apperrors.ErrTaskNotFound
 	}
-	
-	updatedTask.ID = id
-	s.tasks.Store(id, updatedTask)
+	if s.bound != nil {
+		s.bound.touch(id)
+	}
 	return nil
 }
 
 // Delete removes a task by ID, returns error if not found
 func (s *XSyncStore) Delete(id int) *apperrors.AppError {
-	// Check if task exists first
-	if _, ok := s.tasks.Load(id); !ok {
+	found := false
+	if s.bound == nil {
+		found = s.tasks.Delete(id)
+	} else {
+		s.write(func() []int {
+			found = s.tasks.Delete(id)
+			s.bound.forget(id)
+			return nil
+		})
+	}
+	if !found {
 		return apperrors.ErrTaskNotFound
 	}
-	
-	s.tasks.Delete(id)
 	return nil
-}
\ No newline at end of file
+}
+
+// WithKey runs write inside Compute on key's entry of the key index. Compute
+// holds the lock of one bucket of the index, so upserts of the same key run
+// one at a time while other keys and all plain reads and writes go on.
+func (s *XSyncStore) WithKey(key string, write func(id int) (int, *apperrors.AppError)) *apperrors.AppError {
+	var err *apperrors.AppError
+	s.keys.Compute(key, func(id int, loaded bool) (int, bool) {
+		next, writeErr := write(id)
+		if writeErr != nil {
+			err = writeErr
+			return id, !loaded // Keep the old mapping, or add none
+		}
+		return next, false
+	})
+	return err
+}
+
+// Put stores task under its existing ID, for callers that allocate IDs elsewhere.
+// Later Creates continue after the highest ID put.
+func (s *XSyncStore) Put(task *entities.Task) {
+	if s.bound == nil {
+		s.tasks.Put(task)
+		return
+	}
+	s.write(func() []int {
+		s.tasks.Put(task)
+		s.bound.add(task.ID)
+		return nil
+	})
+}
+
+// BulkLoad stores tasks under the IDs they carry and moves the ID counter
+// past the highest of them once, instead of once per task as Put does.
+// Tasks are checked before any is stored, so a bad batch changes nothing.
+func (s *XSyncStore) BulkLoad(tasks []*entities.Task) *apperrors.AppError {
+	for _, task := range tasks {
+		if task == nil {
+			return apperrors.ErrTaskCannotBeNil
+		}
+		if task.ID <= 0 {
+			return apperrors.ErrInvalidID.WithMessage("bulk-loaded tasks need a positive ID")
+		}
+	}
+	if s.bound == nil {
+		s.tasks.PutMany(tasks)
+		return nil
+	}
+	s.write(func() []int {
+		s.tasks.PutMany(tasks)
+		for _, task := range tasks {
+			s.bound.add(task.ID)
+		}
+		return nil
+	})
+	return nil
+}
+
+// EvictIdle evicts the tasks not accessed since before and returns how many
+// were evicted. Access times are only kept with Bound.Now, so other stores
+// evict nothing.
+func (s *XSyncStore) EvictIdle(before time.Time) int {
+	if s.bound == nil || s.bound.Now == nil {
+		return 0
+	}
+	return s.write(func() []int {
+		return s.bound.idle(before)
+	})
+}
+
+// Len returns the number of stored tasks
+func (s *XSyncStore) Len() int {
+	return s.tasks.Len()
+}
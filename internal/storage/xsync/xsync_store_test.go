@@ -1,6 +1,7 @@
 package xsync
 
 import (
+	"math"
 	"sync"
 	"testing"
 	"tasks-service-demo/internal/entities"
@@ -214,4 +215,82 @@ func TestXSyncStore_AtomicIDGeneration(t *testing.T) {
 	}
 	
 	assert.Len(t, idSet, numGoroutines)
-}
\ No newline at end of file
+}
+func TestXSyncStore_GetRange(t *testing.T) {
+	store := NewXSyncStore()
+	for i := 0; i < 6; i++ {
+		require.Nil(t, store.Create(&entities.Task{Name: "task"}))
+	}
+	store.Delete(1)
+	store.Delete(3)
+
+	ids := func(tasks []*entities.Task) []int {
+		out := make([]int, 0, len(tasks))
+		for _, task := range tasks {
+			out = append(out, task.ID)
+		}
+		return out
+	}
+	assert.Equal(t, []int{2, 4, 5}, ids(store.GetRange(2, 5, 0)))
+	assert.Equal(t, []int{2, 4}, ids(store.GetRange(1, math.MaxInt, 2)))
+	assert.Empty(t, store.GetRange(7, 100, 0))
+
+	// Tasks placed by Put are reachable by the ID walk
+	store.Put(&entities.Task{ID: 10, Name: "put"})
+	assert.Equal(t, []int{6, 10}, ids(store.GetRange(6, math.MaxInt, 0)))
+}
+
+func TestXSyncStore_BulkLoad(t *testing.T) {
+	store := NewXSyncStore()
+	require.Nil(t, store.BulkLoad([]*entities.Task{{ID: 5, Name: "five"}, {ID: 2, Name: "two"}}))
+
+	task, err := store.GetByID(5)
+	require.Nil(t, err)
+	assert.Equal(t, "five", task.Name)
+
+	next := &entities.Task{Name: "next"}
+	store.Create(next)
+	assert.Equal(t, 6, next.ID, "IDs continue after the loaded ones")
+
+	assert.NotNil(t, store.BulkLoad([]*entities.Task{{ID: 9}, {ID: -1}}))
+	_, err = store.GetByID(9)
+	assert.Equal(t, apperrors.ErrTaskNotFound, err, "a rejected batch stores nothing")
+}
+
+func TestCollection_Put(t *testing.T) {
+	c := NewCollection[*entities.Task]()
+	c.Put(&entities.Task{ID: 5, Name: "put"})
+	c.PutMany([]*entities.Task{{ID: 9}, {ID: 7}})
+	if id := c.Create(&entities.Task{Name: "next"}); id != 10 {
+		t.Errorf("Expected creates to continue after the highest ID put, got %d", id)
+	}
+	if c.Len() != 4 {
+		t.Errorf("Expected 4 tasks, got %d", c.Len())
+	}
+	if got := c.Range(1, 100, 0); len(got) != 4 || got[0].ID != 5 || got[3].ID != 10 {
+		t.Errorf("Expected the tasks in ID order, got %+v", got)
+	}
+}
+
+func TestXSyncStore_GetPage(t *testing.T) {
+	store := NewXSyncStore()
+	for i := 0; i < 50; i++ {
+		store.Create(&entities.Task{Name: "t"})
+	}
+	for id := 1; id <= 45; id++ {
+		store.Delete(id)
+	}
+
+	// Five tasks left after 45 gaps: the walk falls back to sorting the map
+	tasks, shard, afterID, done := store.GetPage(0, 0, 3)
+	require.Len(t, tasks, 3)
+	assert.Equal(t, 46, tasks[0].ID)
+	assert.Equal(t, 0, shard)
+	assert.Equal(t, 48, afterID)
+	assert.False(t, done)
+
+	tasks, _, _, done = store.GetPage(0, afterID, 3)
+	require.Len(t, tasks, 2)
+	assert.Equal(t, 50, tasks[1].ID)
+	assert.True(t, done)
+}
@@ -0,0 +1,41 @@
+package streaming
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collector exports the counters of the process-wide Guard
+type collector struct {
+	active, disconnects, dropped *prometheus.Desc
+}
+
+// NewCollector returns a Prometheus collector for streamed responses and
+// the slow clients they dropped. Register it once.
+func NewCollector(namespace string) prometheus.Collector {
+	return &collector{
+		active: prometheus.NewDesc(prometheus.BuildFQName(namespace, "stream", "active"),
+			"Streamed responses being written.", []string{"stream"}, nil),
+		disconnects: prometheus.NewDesc(prometheus.BuildFQName(namespace, "stream", "disconnects_total"),
+			"Streams ended early, by reason: timeout (slow client), lag (behind the change feed) or closed.", []string{"stream", "reason"}, nil),
+		dropped: prometheus.NewDesc(prometheus.BuildFQName(namespace, "stream", "dropped_total"),
+			"Changes skipped by slow followers under the drop-oldest policy.", []string{"stream"}, nil),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.active
+	ch <- c.disconnects
+	ch <- c.dropped
+}
+
+// Collect implements prometheus.Collector
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	for name, s := range Get().Stats() {
+		ch <- prometheus.MustNewConstMetric(c.active, prometheus.GaugeValue, float64(s.Active), name)
+		for reason, n := range s.Disconnects {
+			ch <- prometheus.MustNewConstMetric(c.disconnects, prometheus.CounterValue, float64(n), name, reason)
+		}
+		ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(s.Dropped), name)
+	}
+}
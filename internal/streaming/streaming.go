@@ -0,0 +1,241 @@
+package streaming
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Package streaming bounds what a slow client can cost a streamed response
+// (GET /tasks as NDJSON, GET /cdc). Streams write through a small pipe,
+// so a client that stops reading blocks the stream rather than growing a
+// buffer; each write then gets a deadline, after which the connection is
+// dropped. Streams that follow live changes can also fall behind the change
+// feed; the Policy decides whether they are disconnected or skip ahead.
+
+// DefaultWriteTimeout bounds each chunk written to a client when none is
+// configured
+const DefaultWriteTimeout = 30 * time.Second
+
+// Policy is what a following stream does when its client falls further
+// behind the writes than the Guard allows
+type Policy string
+
+const (
+	Disconnect Policy = "disconnect"  // End the stream; the client resumes from its last offset
+	DropOldest Policy = "drop-oldest" // Skip the oldest changes, announcing the gap, and carry on
+)
+
+// ParsePolicy parses "disconnect" or "drop-oldest"
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(strings.ToLower(strings.TrimSpace(s))); p {
+	case Disconnect, DropOldest:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid slow client policy %q (want disconnect or drop-oldest)", s)
+}
+
+// Disconnect reasons, for metrics
+const (
+	ReasonTimeout = "timeout" // A write waited longer than the write timeout
+	ReasonLag     = "lag"     // The client fell behind with Policy Disconnect
+	ReasonClosed  = "closed"  // The client went away
+)
+
+// Config configures a Guard
+type Config struct {
+	WriteTimeout time.Duration // Per chunk written; 0 waits forever
+	MaxLag       int           // Changes a follower may trail the feed by; 0 allows the whole history
+	Policy       Policy        // Disconnect when empty
+}
+
+// Stats counts the streams of one kind, e.g. "cdc"
+type Stats struct {
+	Active      int64            `json:"active"`
+	Disconnects map[string]int64 `json:"disconnects"` // By reason
+	Dropped     int64            `json:"dropped"`     // Changes skipped under DropOldest
+}
+
+// streamStats is the live form of Stats
+type streamStats struct {
+	active  atomic.Int64
+	timeout atomic.Int64
+	lag     atomic.Int64
+	closed  atomic.Int64
+	dropped atomic.Int64
+}
+
+// Guard applies a Config to the streams of the process and counts them
+type Guard struct {
+	cfg Config
+
+	mu      sync.Mutex
+	streams map[string]*streamStats
+}
+
+// New creates a Guard
+func New(cfg Config) *Guard {
+	if cfg.Policy == "" {
+		cfg.Policy = Disconnect
+	}
+	return &Guard{cfg: cfg, streams: make(map[string]*streamStats)}
+}
+
+// Config returns the configuration of the Guard
+func (g *Guard) Config() Config {
+	return g.cfg
+}
+
+func (g *Guard) stats(stream string) *streamStats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	s, ok := g.streams[stream]
+	if !ok {
+		s = &streamStats{}
+		g.streams[stream] = s
+	}
+	return s
+}
+
+// Lagging reports whether a follower behind the feed by behind changes has
+// fallen too far, given the oldest change the feed still holds is complete
+func (g *Guard) Lagging(behind uint64, complete bool) bool {
+	return !complete || (g.cfg.MaxLag > 0 && behind > uint64(g.cfg.MaxLag))
+}
+
+// Dropped counts changes a stream skipped under DropOldest
+func (g *Guard) Dropped(stream string, n uint64) {
+	g.stats(stream).dropped.Add(int64(n))
+}
+
+// Disconnected counts a stream ended early for reason
+func (g *Guard) Disconnected(stream, reason string) {
+	s := g.stats(stream)
+	switch reason {
+	case ReasonTimeout:
+		s.timeout.Add(1)
+	case ReasonLag:
+		s.lag.Add(1)
+	default:
+		s.closed.Add(1)
+	}
+}
+
+// Stats returns the counters of every stream kind seen so far
+func (g *Guard) Stats() map[string]Stats {
+	g.mu.Lock()
+	names := make([]string, 0, len(g.streams))
+	for name := range g.streams {
+		names = append(names, name)
+	}
+	g.mu.Unlock()
+	sort.Strings(names)
+
+	out := make(map[string]Stats, len(names))
+	for _, name := range names {
+		s := g.stats(name)
+		out[name] = Stats{
+			Active: s.active.Load(),
+			Disconnects: map[string]int64{
+				ReasonTimeout: s.timeout.Load(),
+				ReasonLag:     s.lag.Load(),
+				ReasonClosed:  s.closed.Load(),
+			},
+			Dropped: s.dropped.Load(),
+		}
+	}
+	return out
+}
+
+// Writer is the body of one streamed response. Each Flush must reach the
+// client within the write timeout.
+type Writer struct {
+	g      *Guard
+	stream string
+	w      *bufio.Writer
+	conn   net.Conn // nil when the connection is unknown, e.g. in tests
+	stats  *streamStats
+	done   bool
+}
+
+// Writer starts a stream of kind stream writing to w over conn. Callers
+// Close it when the stream ends.
+func (g *Guard) Writer(stream string, w *bufio.Writer, conn net.Conn) *Writer {
+	s := g.stats(stream)
+	s.active.Add(1)
+	return &Writer{g: g, stream: stream, w: w, conn: conn, stats: s}
+}
+
+// Write buffers p; Flush sends it
+func (w *Writer) Write(p []byte) (int, error) {
+	return w.w.Write(p)
+}
+
+// Flush sends the buffered data. The connection gets the write timeout as
+// its deadline, so a client that stops reading fails the server's write
+// and with it this one, and the stream ends. A deadline that can't be set
+// ends the stream too, rather than risk a write that waits forever.
+func (w *Writer) Flush() error {
+	start := time.Now()
+	if w.conn != nil && w.g.cfg.WriteTimeout > 0 {
+		if err := w.conn.SetWriteDeadline(start.Add(w.g.cfg.WriteTimeout)); err != nil {
+			w.disconnect(ReasonClosed)
+			return fmt.Errorf("set write deadline: %w", err)
+		}
+	}
+	err := w.w.Flush()
+	if err != nil {
+		reason := ReasonClosed
+		if w.g.cfg.WriteTimeout > 0 && time.Since(start) >= w.g.cfg.WriteTimeout {
+			reason = ReasonTimeout
+		}
+		w.disconnect(reason)
+	}
+	return err
+}
+
+// disconnect counts the stream ended early, once
+func (w *Writer) disconnect(reason string) {
+	if !w.done {
+		w.done = true
+		w.g.Disconnected(w.stream, reason)
+	}
+}
+
+// Close ends the stream. The connection outlives it for keep-alive
+// requests, so its deadline is cleared; when that fails the connection is
+// closed instead, so the next request on it can't inherit the deadline.
+func (w *Writer) Close() {
+	if w.conn != nil && w.g.cfg.WriteTimeout > 0 {
+		if err := w.conn.SetWriteDeadline(time.Time{}); err != nil {
+			w.conn.Close()
+		}
+	}
+	w.stats.active.Add(-1)
+}
+
+var current atomic.Pointer[Guard]
+
+func init() {
+	Reset()
+}
+
+// Get returns the process-wide Guard
+func Get() *Guard {
+	return current.Load()
+}
+
+// Init replaces the process-wide Guard
+func Init(g *Guard) {
+	current.Store(g)
+}
+
+// Reset installs a Guard with the default write timeout
+func Reset() {
+	current.Store(New(Config{WriteTimeout: DefaultWriteTimeout}))
+}
@@ -0,0 +1,144 @@
+package streaming
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// deadlineConn records the write deadlines set on it, failing to set them
+// with err
+type deadlineConn struct {
+	net.Conn
+	deadlines []time.Time
+	err       error
+	closed    bool
+}
+
+func (c *deadlineConn) SetWriteDeadline(t time.Time) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.deadlines = append(c.deadlines, t)
+	return nil
+}
+
+func (c *deadlineConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+// stallWriter waits, then fails, like a pipe closed by a timed out server write
+type stallWriter struct {
+	wait time.Duration
+}
+
+func (w stallWriter) Write(p []byte) (int, error) {
+	time.Sleep(w.wait)
+	return 0, errors.New("connection closed")
+}
+
+func TestParsePolicy(t *testing.T) {
+	for s, expected := range map[string]Policy{"disconnect": Disconnect, " Drop-Oldest ": DropOldest} {
+		if got, err := ParsePolicy(s); err != nil || got != expected {
+			t.Errorf("ParsePolicy(%q) = %q, %v", s, got, err)
+		}
+	}
+	if _, err := ParsePolicy("buffer"); err == nil {
+		t.Error("Expected an error for an unknown policy")
+	}
+}
+
+func TestGuard_Lagging(t *testing.T) {
+	g := New(Config{MaxLag: 10})
+	if g.Lagging(10, true) || !g.Lagging(11, true) || !g.Lagging(0, false) {
+		t.Error("Expected followers lagging past MaxLag or the history")
+	}
+	if New(Config{}).Lagging(1<<40, true) {
+		t.Error("Expected no lag limit without MaxLag")
+	}
+	if New(Config{}).Config().Policy != Disconnect {
+		t.Error("Expected Disconnect by default")
+	}
+}
+
+func TestWriter_Deadlines(t *testing.T) {
+	g := New(Config{WriteTimeout: time.Minute})
+	conn := &deadlineConn{}
+	w := g.Writer("tasks", bufio.NewWriter(discard{}), conn)
+	if g.Stats()["tasks"].Active != 1 {
+		t.Errorf("Expected one active stream, got %+v", g.Stats())
+	}
+
+	w.Write([]byte("line\n"))
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	if len(conn.deadlines) != 2 || time.Until(conn.deadlines[0]) < 50*time.Second || !conn.deadlines[1].IsZero() {
+		t.Errorf("Expected a deadline per flush, cleared on close, got %v", conn.deadlines)
+	}
+	if s := g.Stats()["tasks"]; s.Active != 0 || s.Disconnects[ReasonClosed]+s.Disconnects[ReasonTimeout] != 0 {
+		t.Errorf("Expected the stream ended cleanly, got %+v", s)
+	}
+}
+
+func TestWriter_DeadlineFails(t *testing.T) {
+	g := New(Config{WriteTimeout: time.Minute})
+	conn := &deadlineConn{err: errors.New("use of closed network connection")}
+	sink := &countingWriter{}
+	w := g.Writer("tasks", bufio.NewWriter(sink), conn)
+
+	w.Write([]byte("line\n"))
+	if err := w.Flush(); err == nil {
+		t.Fatal("Expected the flush to fail without a deadline")
+	}
+	w.Flush() // Counted once
+	w.Close()
+	if sink.n != 0 {
+		t.Errorf("Expected nothing written without a deadline, got %d bytes", sink.n)
+	}
+	if !conn.closed {
+		t.Error("Expected the connection closed when its deadline can't be cleared")
+	}
+	if s := g.Stats()["tasks"]; s.Disconnects[ReasonClosed] != 1 || s.Active != 0 {
+		t.Errorf("Expected one disconnect, got %+v", s)
+	}
+}
+
+func TestWriter_SlowClient(t *testing.T) {
+	g := New(Config{WriteTimeout: 20 * time.Millisecond})
+
+	slow := g.Writer("cdc", bufio.NewWriter(stallWriter{wait: 30 * time.Millisecond}), nil)
+	slow.Write([]byte("line\n"))
+	if err := slow.Flush(); err == nil {
+		t.Fatal("Expected the flush to fail")
+	}
+	slow.Flush() // Counted once
+	slow.Close()
+
+	gone := g.Writer("cdc", bufio.NewWriter(stallWriter{}), nil)
+	gone.Write([]byte("line\n"))
+	gone.Flush()
+	gone.Close()
+
+	g.Dropped("cdc", 5)
+	g.Disconnected("cdc", ReasonLag)
+	s := g.Stats()["cdc"]
+	if s.Disconnects[ReasonTimeout] != 1 || s.Disconnects[ReasonClosed] != 1 || s.Disconnects[ReasonLag] != 1 || s.Dropped != 5 || s.Active != 0 {
+		t.Errorf("Unexpected stats: %+v", s)
+	}
+}
+
+type discard struct{}
+
+type countingWriter struct{ n int }
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += len(p)
+	return len(p), nil
+}
+
+func (discard) Write(p []byte) (int, error) { return len(p), nil }
@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"embed"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/middleware"
+	"tasks-service-demo/internal/requests"
+	"tasks-service-demo/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/template/html/v2"
+)
+
+// Package ui serves a minimal server-rendered web UI for browsing and
+// editing tasks without an API client.
+
+// invalidID is shown when a form posts to a non-numeric task path
+const invalidID = "Invalid task ID"
+
+//go:embed views/*.html
+var views embed.FS
+
+// page is the data passed to the index template
+type page struct {
+	Title     string
+	Tasks     []*entities.Task
+	Open      int
+	Done      int
+	Error     string
+	Name      string // Echoed back into the create form after a failed submit
+	CSRFToken string
+	CSRFField string
+	Base      string // Mount path, for form actions
+}
+
+// Handler renders the UI pages using the TaskService directly
+type Handler struct {
+	service *services.TaskService
+}
+
+// New returns a Fiber app serving the UI, to be mounted at
+// middleware.UIPathPrefix. It has its own template engine so the API app
+// stays JSON-only.
+func New(service *services.TaskService) *fiber.App {
+	engine := html.NewFileSystem(http.FS(views), ".html")
+	app := fiber.New(fiber.Config{Views: engine, ViewsLayout: "views/layout"})
+
+	h := &Handler{service: service}
+	app.Get("/", h.Index)
+	app.Post("/tasks", h.Create)
+	app.Post("/tasks/:id/toggle", h.Toggle)
+	app.Post("/tasks/:id/delete", h.Delete)
+	return app
+}
+
+// Index renders the task list with the create form
+func (h *Handler) Index(c *fiber.Ctx) error {
+	return h.render(c, fiber.StatusOK, "", "")
+}
+
+// Create handles the create form and redirects back to the list
+func (h *Handler) Create(c *fiber.Ctx) error {
+	req := requests.CreateTaskRequest{Name: c.FormValue("name")}
+	if err := req.Validate(); err != nil {
+		return h.render(c, fiber.StatusBadRequest, err.Message, req.Name)
+	}
+	if _, err := h.service.CreateTask(&req); err != nil {
+		return h.renderError(c, err)
+	}
+	return h.redirect(c)
+}
+
+// Toggle flips a task between incomplete and complete
+func (h *Handler) Toggle(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return h.render(c, fiber.StatusBadRequest, invalidID, "")
+	}
+	task, appErr := h.service.GetTaskByID(id)
+	if appErr != nil {
+		return h.renderError(c, appErr)
+	}
+
+	req := requests.UpdateTaskRequest{Name: task.Name, Status: 1 - task.Status}
+	if _, appErr := h.service.UpdateTask(id, &req); appErr != nil {
+		return h.renderError(c, appErr)
+	}
+	return h.redirect(c)
+}
+
+// Delete removes a task
+func (h *Handler) Delete(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return h.render(c, fiber.StatusBadRequest, invalidID, "")
+	}
+	if appErr := h.service.DeleteTask(id); appErr != nil {
+		return h.renderError(c, appErr)
+	}
+	return h.redirect(c)
+}
+
+// redirect sends the browser back to the list (Post/Redirect/Get)
+func (h *Handler) redirect(c *fiber.Ctx) error {
+	return c.Redirect(middleware.UIPathPrefix, fiber.StatusSeeOther)
+}
+
+func (h *Handler) renderError(c *fiber.Ctx, err *apperrors.AppError) error {
+	status := fiber.StatusInternalServerError
+	switch err.Code {
+	case apperrors.ErrCodeTaskNotFound:
+		status = fiber.StatusNotFound
+	case apperrors.ErrCodeStorageFull:
+		status = fiber.StatusInsufficientStorage
+	}
+	return h.render(c, status, err.Message, "")
+}
+
+func (h *Handler) render(c *fiber.Ctx, status int, errMsg, name string) error {
+	tasks := h.service.GetAllTasks()
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+
+	p := page{
+		Title:     "Tasks",
+		Tasks:     tasks,
+		Error:     errMsg,
+		Name:      name,
+		CSRFField: middleware.CSRFFormField,
+		Base:      middleware.UIPathPrefix,
+	}
+	if token, ok := c.Locals(middleware.CSRFContextKey).(string); ok {
+		p.CSRFToken = token
+	}
+	for _, task := range tasks {
+		if task.Status == 1 {
+			p.Done++
+		} else {
+			p.Open++
+		}
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(status).Render("views/index", p)
+}
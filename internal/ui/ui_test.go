@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"tasks-service-demo/internal/entities"
+	"tasks-service-demo/internal/middleware"
+	"tasks-service-demo/internal/services"
+	"tasks-service-demo/internal/storage/storetest"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func setupUI(t *testing.T, handlers ...fiber.Handler) (*fiber.App, *storetest.FakeStore) {
+	t.Helper()
+	store := storetest.NewFakeStore(
+		&entities.Task{ID: 1, Name: "Open <task>"},
+		&entities.Task{ID: 2, Name: "Done task", Status: 1},
+	)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	for _, h := range handlers {
+		app.Use(h)
+	}
+	app.Mount(middleware.UIPathPrefix, New(services.NewTaskServiceWithStore(store)))
+	return app, store
+}
+
+func do(t *testing.T, app *fiber.App, req *http.Request) (*http.Response, string) {
+	t.Helper()
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	return resp, string(body)
+}
+
+func postForm(path string, form url.Values) *http.Request {
+	req := httptest.NewRequest("POST", path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", fiber.MIMEApplicationForm)
+	return req
+}
+
+func TestIndex(t *testing.T) {
+	app, _ := setupUI(t)
+
+	resp, body := do(t, app, httptest.NewRequest("GET", "/ui", nil))
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status %d, got %d", fiber.StatusOK, resp.StatusCode)
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("Expected HTML, got %s", resp.Header.Get("Content-Type"))
+	}
+	for _, want := range []string{"<title>Tasks</title>", "1 open, 1 done", "Open &lt;task&gt;", `class="done"`, `action="/ui/tasks/2/toggle"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected page to contain %q", want)
+		}
+	}
+	if strings.Contains(body, `name="_csrf"`) {
+		t.Error("Expected no CSRF field when CSRF is disabled")
+	}
+}
+
+func TestForms(t *testing.T) {
+	app, store := setupUI(t)
+
+	resp, _ := do(t, app, postForm("/ui/tasks", url.Values{"name": {"From the browser"}}))
+	if resp.StatusCode != fiber.StatusSeeOther || resp.Header.Get("Location") != "/ui" {
+		t.Errorf("Expected redirect to /ui, got %d %s", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if task, err := store.GetByID(3); err != nil || task.Name != "From the browser" {
+		t.Errorf("Expected created task, got %+v, %v", task, err)
+	}
+
+	do(t, app, postForm("/ui/tasks/1/toggle", nil))
+	if task, _ := store.GetByID(1); task.Status != 1 {
+		t.Errorf("Expected task 1 completed, got %+v", task)
+	}
+
+	do(t, app, postForm("/ui/tasks/2/delete", nil))
+	if _, err := store.GetByID(2); err == nil {
+		t.Error("Expected task 2 deleted")
+	}
+}
+
+func TestForms_Errors(t *testing.T) {
+	app, _ := setupUI(t)
+
+	resp, body := do(t, app, postForm("/ui/tasks", url.Values{"name": {strings.Repeat("x", 101)}}))
+	if resp.StatusCode != fiber.StatusBadRequest || !strings.Contains(body, `role="alert"`) {
+		t.Errorf("Expected form error, got %d", resp.StatusCode)
+	}
+
+	if resp, _ := do(t, app, postForm("/ui/tasks/99/toggle", nil)); resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("Expected status %d for unknown task, got %d", fiber.StatusNotFound, resp.StatusCode)
+	}
+	if resp, _ := do(t, app, postForm("/ui/tasks/abc/delete", nil)); resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("Expected status %d for invalid ID, got %d", fiber.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+func TestForms_CSRF(t *testing.T) {
+	app, store := setupUI(t, middleware.CSRF(false))
+
+	resp, body := do(t, app, httptest.NewRequest("GET", "/ui", nil))
+	var cookie *http.Cookie
+	for _, c := range resp.Cookies() {
+		if c.Name == "csrf_" {
+			cookie = c
+		}
+	}
+	if cookie == nil || !strings.Contains(body, `name="_csrf" value="`+cookie.Value+`"`) {
+		t.Fatalf("Expected CSRF token in cookie and form, got cookie %v", cookie)
+	}
+
+	req := postForm("/ui/tasks", url.Values{"name": {"no token"}})
+	req.AddCookie(cookie)
+	if resp, _ := do(t, app, req); resp.StatusCode != fiber.StatusForbidden {
+		t.Errorf("Expected status %d without form token, got %d", fiber.StatusForbidden, resp.StatusCode)
+	}
+
+	req = postForm("/ui/tasks", url.Values{"name": {"with token"}, "_csrf": {cookie.Value}})
+	req.AddCookie(cookie)
+	if resp, _ := do(t, app, req); resp.StatusCode != fiber.StatusSeeOther || store.Len() != 3 {
+		t.Errorf("Expected create with form token, got %d", resp.StatusCode)
+	}
+}
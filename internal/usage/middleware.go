@@ -0,0 +1,46 @@
+package usage
+
+import (
+	"strings"
+
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Middleware accounts every request made with an API key to the
+// process-wide Tracker, and rejects those of keys past their monthly quota
+// with 429 and ErrQuotaExceeded. It must run after middleware.Authenticate.
+// Requests under any of the skip prefixes are neither limited nor counted.
+func Middleware(skip ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		t := Get()
+		if !t.Enabled() {
+			return c.Next()
+		}
+		path := c.Path()
+		for _, prefix := range skip {
+			if strings.HasPrefix(path, prefix) {
+				return c.Next()
+			}
+		}
+		principal, ok := middleware.GetPrincipal(c)
+		if !ok {
+			return c.Next()
+		}
+		key, ok := principal.APIKeyID()
+		if !ok {
+			return c.Next()
+		}
+
+		if allowed, renewsIn := t.Allow(key); !allowed {
+			middleware.SetRetryAfter(c, renewsIn)
+			return apperrors.ErrQuotaExceeded
+		}
+		err := c.Next()
+		// The route is known only once routing is done
+		t.Record(key, c.Method()+" "+c.Route().Path, len(c.Request().Body()), len(c.Response().Body()))
+		return err
+	}
+}
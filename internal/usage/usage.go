@@ -0,0 +1,306 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tasks-service-demo/internal/jobs"
+	"tasks-service-demo/internal/storage"
+)
+
+// Package usage accounts the requests of each API key: request counts, bytes
+// and a per-operation breakdown over a rolling window, and the requests of
+// the calendar month, which an optional quota caps. Monthly counts are saved
+// in the backend's MetaStore when it has one, so quotas hold across
+// restarts; the rolling window is kept in memory only.
+
+const (
+	DefaultWindow        = 24 * time.Hour // Rolling window of the report
+	DefaultFlushInterval = time.Minute    // Period of the job saving monthly counts
+	buckets              = 60             // Slices of the window, dropped one at a time as it rolls
+)
+
+// metaKey is the storage.MetaStore record holding the monthly counts
+const metaKey = "usage-month"
+
+// Config configures a Tracker
+type Config struct {
+	Window        time.Duration // 0 disables accounting
+	MonthlyQuota  int64         // Requests per API key per calendar month (UTC); 0 is unlimited
+	FlushInterval time.Duration // Period of the job saving monthly counts
+}
+
+// Counts are the requests of one API key
+type Counts struct {
+	Requests int64            `json:"requests"`
+	BytesIn  int64            `json:"bytesIn"`
+	BytesOut int64            `json:"bytesOut"`
+	Ops      map[string]int64 `json:"ops"` // Requests by method and route, e.g. "GET /tasks/:id"
+}
+
+// add records one request
+func (c *Counts) add(op string, bytesIn, bytesOut int) {
+	if c.Ops == nil {
+		c.Ops = make(map[string]int64)
+	}
+	c.Requests++
+	c.BytesIn += int64(bytesIn)
+	c.BytesOut += int64(bytesOut)
+	c.Ops[op]++
+}
+
+// merge adds other to c
+func (c *Counts) merge(other Counts) {
+	if c.Ops == nil {
+		c.Ops = make(map[string]int64)
+	}
+	c.Requests += other.Requests
+	c.BytesIn += other.BytesIn
+	c.BytesOut += other.BytesOut
+	for op, n := range other.Ops {
+		c.Ops[op] += n
+	}
+}
+
+// bucket holds the counts of one slice of the window
+type bucket struct {
+	slot   int64 // Slice index since the epoch; stale buckets are reset on reuse
+	counts Counts
+}
+
+// keyUsage is the accounting of one API key
+type keyUsage struct {
+	window [buckets]bucket
+	month  int64 // Requests in the current month
+}
+
+// Month is the monthly count of an API key
+type Month struct {
+	Period   string `json:"period"` // e.g. 2026-10
+	Requests int64  `json:"requests"`
+	Quota    int64  `json:"quota,omitempty"`
+}
+
+// KeyReport is the usage of one API key
+type KeyReport struct {
+	Key    string `json:"key"` // Non-secret API key identifier
+	Window Counts `json:"window"`
+	Month  Month  `json:"month"`
+}
+
+// saved is the MetaStore record of the monthly counts
+type saved struct {
+	Period   string           `json:"period"`
+	Requests map[string]int64 `json:"requests"`
+}
+
+// Tracker accounts requests per API key
+type Tracker struct {
+	cfg   Config
+	meta  storage.MetaStore // Nil keeps monthly counts in memory only
+	now   func() time.Time
+	width time.Duration // Of one bucket
+
+	mu     sync.Mutex
+	period string // Month the monthly counts belong to
+	keys   map[string]*keyUsage
+}
+
+// New creates a Tracker; a nil meta keeps monthly counts in memory only
+func New(cfg Config, meta storage.MetaStore) *Tracker {
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultFlushInterval
+	}
+	t := &Tracker{cfg: cfg, meta: meta, now: time.Now, keys: make(map[string]*keyUsage)}
+	t.width = max(cfg.Window/buckets, time.Second)
+	t.period = period(t.now())
+	return t
+}
+
+// Enabled reports whether requests are accounted
+func (t *Tracker) Enabled() bool {
+	return t.cfg.Window > 0
+}
+
+// Config returns the configuration of the tracker
+func (t *Tracker) Config() Config {
+	return t.cfg
+}
+
+// Persistent reports whether monthly counts are saved in the backend
+func (t *Tracker) Persistent() bool {
+	return t.meta != nil
+}
+
+// period returns the month now falls in, in UTC
+func period(now time.Time) string {
+	return now.UTC().Format("2006-01")
+}
+
+// nextMonth returns the start of the month after now, in UTC
+func nextMonth(now time.Time) time.Time {
+	y, m, _ := now.UTC().Date()
+	return time.Date(y, m+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// rollLocked starts new monthly counts when the month has changed
+func (t *Tracker) rollLocked(now time.Time) {
+	if p := period(now); p != t.period {
+		t.period = p
+		for _, u := range t.keys {
+			u.month = 0
+		}
+	}
+}
+
+// Allow reports whether key may make another request this month, and when
+// not, how long until its quota renews
+func (t *Tracker) Allow(key string) (bool, time.Duration) {
+	if t.cfg.MonthlyQuota <= 0 {
+		return true, 0
+	}
+	now := t.now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollLocked(now)
+	if u, ok := t.keys[key]; ok && u.month >= t.cfg.MonthlyQuota {
+		return false, nextMonth(now).Sub(now)
+	}
+	return true, 0
+}
+
+// Record accounts one request of key to operation op
+func (t *Tracker) Record(key, op string, bytesIn, bytesOut int) {
+	if !t.Enabled() {
+		return
+	}
+	now := t.now()
+	slot := now.UnixNano() / int64(t.width)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollLocked(now)
+	u, ok := t.keys[key]
+	if !ok {
+		u = &keyUsage{}
+		t.keys[key] = u
+	}
+	b := &u.window[slot%buckets]
+	if b.slot != slot {
+		*b = bucket{slot: slot}
+	}
+	b.counts.add(op, bytesIn, bytesOut)
+	u.month++
+}
+
+// Report returns the usage of every API key seen, sorted by key
+func (t *Tracker) Report() []KeyReport {
+	now := t.now()
+	oldest := now.UnixNano()/int64(t.width) - buckets + 1
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollLocked(now)
+	reports := make([]KeyReport, 0, len(t.keys))
+	for key, u := range t.keys {
+		r := KeyReport{
+			Key:    key,
+			Window: Counts{Ops: make(map[string]int64)},
+			Month:  Month{Period: t.period, Requests: u.month, Quota: t.cfg.MonthlyQuota},
+		}
+		for _, b := range u.window {
+			if b.slot >= oldest {
+				r.Window.merge(b.counts)
+			}
+		}
+		reports = append(reports, r)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Key < reports[j].Key })
+	return reports
+}
+
+// Load restores the monthly counts saved for the current month
+func (t *Tracker) Load() error {
+	if t.meta == nil {
+		return nil
+	}
+	data, ok, err := t.meta.GetMeta(metaKey)
+	if err != nil || !ok {
+		return err
+	}
+	var s saved
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("parse saved usage: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollLocked(t.now())
+	if s.Period != t.period {
+		return nil // Counts of a past month
+	}
+	for key, n := range s.Requests {
+		u, ok := t.keys[key]
+		if !ok {
+			u = &keyUsage{}
+			t.keys[key] = u
+		}
+		u.month += n
+	}
+	return nil
+}
+
+// Save writes the monthly counts to the backend
+func (t *Tracker) Save() error {
+	if t.meta == nil {
+		return nil
+	}
+	t.mu.Lock()
+	t.rollLocked(t.now())
+	s := saved{Period: t.period, Requests: make(map[string]int64, len(t.keys))}
+	for key, u := range t.keys {
+		if u.month > 0 {
+			s.Requests[key] = u.month
+		}
+	}
+	t.mu.Unlock()
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return t.meta.PutMeta(metaKey, data)
+}
+
+// Job returns the job saving the monthly counts
+func (t *Tracker) Job() jobs.Func {
+	return func(ctx context.Context, p *jobs.Progress) error {
+		return t.Save()
+	}
+}
+
+var current atomic.Pointer[Tracker]
+
+func init() {
+	Reset()
+}
+
+// Get returns the process-wide Tracker
+func Get() *Tracker {
+	return current.Load()
+}
+
+// Init replaces the process-wide Tracker
+func Init(t *Tracker) {
+	current.Store(t)
+}
+
+// Reset installs a disabled Tracker
+func Reset() {
+	current.Store(New(Config{}, nil))
+}
@@ -0,0 +1,141 @@
+package usage
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"tasks-service-demo/internal/auth"
+	"tasks-service-demo/internal/middleware"
+	"tasks-service-demo/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type memMeta struct {
+	records map[string][]byte
+}
+
+func (m *memMeta) GetMeta(key string) ([]byte, bool, error) {
+	value, ok := m.records[key]
+	return value, ok, nil
+}
+
+func (m *memMeta) PutMeta(key string, value []byte) error {
+	m.records[key] = value
+	return nil
+}
+
+// newTracker returns a Tracker whose clock reads *now
+func newTracker(cfg Config, meta storage.MetaStore, now *time.Time) *Tracker {
+	t := New(cfg, meta)
+	t.now = func() time.Time { return *now }
+	t.period = period(*now)
+	return t
+}
+
+func TestTracker_RollingWindow(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	tracker := newTracker(Config{Window: time.Hour}, nil, &now)
+
+	tracker.Record("a", "GET /tasks", 0, 100)
+	now = now.Add(30 * time.Minute)
+	tracker.Record("a", "POST /tasks", 20, 50)
+	tracker.Record("b", "GET /tasks/:id", 0, 30)
+
+	reports := tracker.Report()
+	if len(reports) != 2 || reports[0].Key != "a" {
+		t.Fatalf("Expected reports of a and b, got %+v", reports)
+	}
+	a := reports[0]
+	if a.Window.Requests != 2 || a.Window.BytesIn != 20 || a.Window.BytesOut != 150 || a.Window.Ops["POST /tasks"] != 1 {
+		t.Errorf("Expected both requests of a in the window, got %+v", a.Window)
+	}
+
+	now = now.Add(45 * time.Minute)
+	a = tracker.Report()[0]
+	if a.Window.Requests != 1 || a.Window.Ops["GET /tasks"] != 0 {
+		t.Errorf("Expected the first request rolled out of the window, got %+v", a.Window)
+	}
+	if a.Month.Requests != 2 || a.Month.Period != "2026-10" {
+		t.Errorf("Expected the month to keep every request, got %+v", a.Month)
+	}
+}
+
+func TestTracker_MonthlyQuota(t *testing.T) {
+	now := time.Date(2026, 10, 31, 23, 0, 0, 0, time.UTC)
+	meta := &memMeta{records: make(map[string][]byte)}
+	tracker := newTracker(Config{Window: time.Hour, MonthlyQuota: 2}, meta, &now)
+
+	tracker.Record("a", "GET /tasks", 0, 0)
+	tracker.Record("a", "GET /tasks", 0, 0)
+	allowed, renewsIn := tracker.Allow("a")
+	if allowed || renewsIn != time.Hour {
+		t.Errorf("Expected a over its quota until November, got %t, %s", allowed, renewsIn)
+	}
+	if allowed, _ := tracker.Allow("b"); !allowed {
+		t.Error("Expected b within its quota")
+	}
+
+	// A restart within the month keeps the count
+	if err := tracker.Save(); err != nil {
+		t.Fatal(err)
+	}
+	restarted := newTracker(Config{Window: time.Hour, MonthlyQuota: 2}, meta, &now)
+	if err := restarted.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if allowed, _ := restarted.Allow("a"); allowed {
+		t.Error("Expected the saved count to hold across a restart")
+	}
+
+	now = now.Add(2 * time.Hour)
+	if allowed, _ := restarted.Allow("a"); !allowed {
+		t.Error("Expected the quota renewed in a new month")
+	}
+	restarted = newTracker(Config{Window: time.Hour, MonthlyQuota: 2}, meta, &now)
+	restarted.Load()
+	if reports := restarted.Report(); len(reports) != 0 {
+		t.Errorf("Expected counts of a past month ignored, got %+v", reports)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	auth.Init(auth.New(auth.Config{APIKeys: map[string]auth.Role{"key-1": auth.RoleReader}, JWTSecret: "secret"}))
+	t.Cleanup(auth.Reset)
+	Init(New(Config{Window: time.Hour, MonthlyQuota: 2}, nil))
+	t.Cleanup(Reset)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	app.Use(middleware.Authenticate())
+	app.Use(Middleware("/health"))
+	ok := func(c *fiber.Ctx) error { return c.SendString("ok") }
+	app.Get("/tasks/:id", ok)
+	app.Get("/health", ok)
+
+	send := func(path string) int {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set(middleware.APIKeyHeader, "key-1")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+	for _, path := range []string{"/tasks/1", "/health", "/tasks/2"} {
+		if status := send(path); status != fiber.StatusOK {
+			t.Fatalf("GET %s: Expected status %d, got %d", path, fiber.StatusOK, status)
+		}
+	}
+	if status := send("/tasks/3"); status != fiber.StatusTooManyRequests {
+		t.Errorf("Expected the third counted request over quota, got %d", status)
+	}
+
+	reports := Get().Report()
+	if len(reports) != 1 {
+		t.Fatalf("Expected one API key accounted, got %+v", reports)
+	}
+	if r := reports[0]; r.Window.Requests != 2 || r.Window.Ops["GET /tasks/:id"] != 2 || r.Window.BytesOut != 4 {
+		t.Errorf("Expected two GET /tasks/:id requests, got %+v", r.Window)
+	}
+}
@@ -0,0 +1,125 @@
+package warmup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http/httptest"
+	"os"
+	"strconv"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/logger"
+	"tasks-service-demo/internal/requests"
+	"tasks-service-demo/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// preloadKeyPrefix namespaces the storage.KeyIndex keys of preloaded tasks,
+// followed by the position of the task in the file
+const preloadKeyPrefix = "preload/"
+
+// PreloadFile loads a JSON array of tasks into the global store.
+// Each task is validated and created through the store, so IDs are reassigned.
+// A durable backend keeps the tasks across restarts, so they are created once:
+// with a storage.KeyIndex each position in the file maps to the task created
+// for it, and a task that already has one is skipped, even if it was since
+// changed or deleted. Without a KeyIndex the file is only loaded into an
+// empty store.
+func PreloadFile(path string) Hook {
+	return func(ctx context.Context) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		var tasks []entities.Task
+		if err := json.Unmarshal(data, &tasks); err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+
+		store := storage.GetStore()
+		index, keyed := storage.FindKeyIndex(store)
+		if !keyed && len(store.GetRange(1, math.MaxInt, 1)) > 0 {
+			logger.For(logger.ModuleStorage).Infof("Store already holds tasks, skipped preloading %s", path)
+			return nil
+		}
+
+		created := 0
+		for i := range tasks {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			task := tasks[i]
+			req := requests.CreateTaskRequest{Name: task.Name, Status: task.Status}
+			if appErr := req.Validate(); appErr != nil {
+				return fmt.Errorf("task %d in %s: %w", i, path, appErr)
+			}
+
+			if !keyed {
+				if appErr := store.Create(&task); appErr != nil {
+					return fmt.Errorf("task %d in %s: %w", i, path, appErr)
+				}
+				created++
+				continue
+			}
+			appErr := index.WithKey(preloadKeyPrefix+strconv.Itoa(i), func(id int) (int, *apperrors.AppError) {
+				if id != 0 {
+					return id, nil // Preloaded by an earlier start
+				}
+				if appErr := store.Create(&task); appErr != nil {
+					return 0, appErr
+				}
+				created++
+				return task.ID, nil
+			})
+			if appErr != nil {
+				return fmt.Errorf("task %d in %s: %w", i, path, appErr)
+			}
+		}
+
+		logger.For(logger.ModuleStorage).Infof("Preloaded %d of %d tasks from %s", created, len(tasks), path)
+		return nil
+	}
+}
+
+// PrimeKeys reads IDs 1..n through the global store so read paths (and any
+// caching decorator wrapping the store) are hot before traffic arrives.
+func PrimeKeys(n int) Hook {
+	return func(ctx context.Context) error {
+		store := storage.GetStore()
+		for id := 1; id <= n; id++ {
+			if id%1024 == 0 {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+			}
+			store.GetByID(id)
+		}
+		return nil
+	}
+}
+
+// WarmRoutes issues an in-process GET to each path so handlers, middleware
+// and JSON encoders are exercised before the listener accepts traffic.
+func WarmRoutes(app *fiber.App, paths ...string) Hook {
+	return func(ctx context.Context) error {
+		for _, path := range paths {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			resp, err := app.Test(httptest.NewRequest("GET", path, nil), -1)
+			if err != nil {
+				return fmt.Errorf("GET %s: %w", path, err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode >= fiber.StatusInternalServerError {
+				return fmt.Errorf("GET %s: status %d", path, resp.StatusCode)
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,60 @@
+package warmup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"tasks-service-demo/internal/logger"
+)
+
+// Package warmup runs startup hooks (data preload, cache priming, route
+// warm-up) before the server is marked ready.
+
+// Hook is a single warm-up step
+type Hook func(ctx context.Context) error
+
+type namedHook struct {
+	name string
+	hook Hook
+}
+
+// Warmer runs registered hooks sequentially in registration order
+type Warmer struct {
+	hooks []namedHook
+}
+
+// New creates an empty Warmer
+func New() *Warmer {
+	return &Warmer{}
+}
+
+// Add registers a named hook
+func (w *Warmer) Add(name string, hook Hook) {
+	w.hooks = append(w.hooks, namedHook{name: name, hook: hook})
+}
+
+// Len returns the number of registered hooks
+func (w *Warmer) Len() int {
+	return len(w.hooks)
+}
+
+// Run executes every hook, stopping at the first failure or when ctx is done
+func (w *Warmer) Run(ctx context.Context) error {
+	start := time.Now()
+
+	for _, h := range w.hooks {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("warm-up %s: %w", h.name, err)
+		}
+
+		hookStart := time.Now()
+		if err := h.hook(ctx); err != nil {
+			return fmt.Errorf("warm-up %s: %w", h.name, err)
+		}
+		logger.For(logger.ModuleStorage).Infof("Warm-up step '%s' completed in %s", h.name, time.Since(hookStart))
+	}
+
+	logger.For(logger.ModuleStorage).Infof("Warm-up completed: %d steps in %s", len(w.hooks), time.Since(start))
+	return nil
+}
@@ -0,0 +1,166 @@
+package warmup
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tasks-service-demo/internal/entities"
+	apperrors "tasks-service-demo/internal/errors"
+	"tasks-service-demo/internal/storage"
+	"tasks-service-demo/internal/storage/storetest"
+	"tasks-service-demo/internal/storage/xsync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestWarmer_RunsInOrder(t *testing.T) {
+	w := New()
+	var order []string
+	w.Add("first", func(ctx context.Context) error { order = append(order, "first"); return nil })
+	w.Add("second", func(ctx context.Context) error { order = append(order, "second"); return nil })
+
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+		t.Errorf("Expected hooks to run in order, got %v", order)
+	}
+}
+
+func TestWarmer_StopsOnError(t *testing.T) {
+	w := New()
+	ran := false
+	w.Add("broken", func(ctx context.Context) error { return errors.New("boom") })
+	w.Add("after", func(ctx context.Context) error { ran = true; return nil })
+
+	err := w.Run(context.Background())
+	if err == nil || err.Error() != "warm-up broken: boom" {
+		t.Errorf("Expected wrapped hook error, got %v", err)
+	}
+	if ran {
+		t.Error("Expected later hooks to be skipped after a failure")
+	}
+}
+
+func TestWarmer_CancelledContext(t *testing.T) {
+	w := New()
+	w.Add("noop", func(ctx context.Context) error { return nil })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := w.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestPreloadFile(t *testing.T) {
+	store := storetest.NewFakeStore()
+	storage.ResetStore()
+	storage.InitStore(store)
+	defer storage.ResetStore()
+
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	os.WriteFile(path, []byte(`[{"name":"one","status":0},{"name":"two","status":1}]`), 0o644)
+
+	if err := PreloadFile(path)(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if store.Len() != 2 {
+		t.Errorf("Expected 2 preloaded tasks, got %d", store.Len())
+	}
+}
+
+func TestPreloadFile_OnceAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	os.WriteFile(path, []byte(`[{"name":"one","status":0},{"name":"two","status":1}]`), 0o644)
+	defer storage.ResetStore()
+
+	// A backend with a key index creates each task of the file once, and
+	// does not bring back a task deleted since
+	keyed := xsync.NewXSyncStore()
+	storage.ResetStore()
+	storage.InitStore(keyed)
+	for i := 0; i < 2; i++ {
+		if err := PreloadFile(path)(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if n := len(keyed.GetAll()); n != 2 {
+		t.Errorf("Expected 2 preloaded tasks, got %d", n)
+	}
+	keyed.Delete(1)
+	PreloadFile(path)(context.Background())
+	if n := len(keyed.GetAll()); n != 1 {
+		t.Errorf("Expected the deleted task to stay deleted, got %d tasks", n)
+	}
+
+	// Without one, a store that already holds tasks is left as it is
+	plain := storetest.NewFakeStore()
+	storage.ResetStore()
+	storage.InitStore(plain)
+	for i := 0; i < 2; i++ {
+		if err := PreloadFile(path)(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if plain.Len() != 2 {
+		t.Errorf("Expected 2 preloaded tasks, got %d", plain.Len())
+	}
+}
+
+func TestPreloadFile_Errors(t *testing.T) {
+	storage.ResetStore()
+	storage.InitStore(storetest.NewFakeStore())
+	defer storage.ResetStore()
+
+	dir := t.TempDir()
+	invalidJSON := filepath.Join(dir, "invalid.json")
+	os.WriteFile(invalidJSON, []byte(`{not json`), 0o644)
+	invalidTask := filepath.Join(dir, "invalid-task.json")
+	os.WriteFile(invalidTask, []byte(`[{"name":"","status":0}]`), 0o644)
+
+	for _, path := range []string{filepath.Join(dir, "missing.json"), invalidJSON, invalidTask} {
+		if err := PreloadFile(path)(context.Background()); err == nil {
+			t.Errorf("Expected error preloading %s", filepath.Base(path))
+		}
+	}
+}
+
+func TestPrimeKeys(t *testing.T) {
+	mock := storetest.NewMockStore()
+	mock.GetByIDFunc = func(id int) (*entities.Task, *apperrors.AppError) {
+		return &entities.Task{ID: id}, nil
+	}
+	storage.ResetStore()
+	storage.InitStore(mock)
+	defer storage.ResetStore()
+
+	if err := PrimeKeys(10)(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if mock.CallCount(storetest.MethodGetByID) != 10 {
+		t.Errorf("Expected 10 primed keys, got %d", mock.CallCount(storetest.MethodGetByID))
+	}
+}
+
+func TestWarmRoutes(t *testing.T) {
+	app := fiber.New()
+	hits := 0
+	app.Get("/ok", func(c *fiber.Ctx) error { hits++; return c.SendStatus(fiber.StatusOK) })
+	app.Get("/broken", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusInternalServerError) })
+
+	if err := WarmRoutes(app, "/ok", "/ok")(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if hits != 2 {
+		t.Errorf("Expected 2 warm-up hits, got %d", hits)
+	}
+
+	if err := WarmRoutes(app, "/broken")(context.Background()); err == nil {
+		t.Error("Expected error for route returning 500")
+	}
+}
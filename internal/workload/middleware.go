@@ -0,0 +1,67 @@
+package workload
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Middleware records sampled task operations of the API to r. Errors are
+// rendered through the app's ErrorHandler first so the recorded status is
+// the one the client receives. Requests to other routes are not recorded.
+func Middleware(r *Recorder) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !r.Sample() {
+			return c.Next()
+		}
+
+		start := time.Now()
+		if err := c.Next(); err != nil {
+			if herr := c.App().Config().ErrorHandler(c, err); herr != nil {
+				return herr
+			}
+		}
+		op := opOf(c.Method(), c.Route().Path)
+		if op == "" {
+			return nil
+		}
+		rec := Record{
+			Time:          start,
+			Op:            op,
+			Status:        c.Response().StatusCode(),
+			RequestBytes:  len(c.Body()),
+			ResponseBytes: len(c.Response().Body()),
+			LatencyMicros: time.Since(start).Microseconds(),
+		}
+		if op != OpCreate && op != OpList {
+			rec.ID, _ = strconv.Atoi(c.Params("id"))
+		}
+		r.Record(rec)
+		return nil
+	}
+}
+
+// opOf returns the operation of the task route matched by a request, empty
+// for other routes
+func opOf(method, route string) string {
+	switch route {
+	case "/tasks":
+		switch method {
+		case fiber.MethodGet:
+			return OpList
+		case fiber.MethodPost:
+			return OpCreate
+		}
+	case "/tasks/:id":
+		switch method {
+		case fiber.MethodGet:
+			return OpGet
+		case fiber.MethodPut:
+			return OpUpdate
+		case fiber.MethodDelete:
+			return OpDelete
+		}
+	}
+	return ""
+}
@@ -0,0 +1,194 @@
+package workload
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Package workload records a sampled trace of the task operations the API
+// serves to a file, one JSON record per line, so the bench package can
+// replay real traffic against any backend. Records hold the shape of each
+// operation, never task contents.
+
+// Operations as named in records
+const (
+	OpCreate = "create"
+	OpGet    = "get"
+	OpList   = "list"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+const (
+	DefaultSampleRate = 0.1     // Fraction of task operations recorded
+	DefaultMaxRecords = 1000000 // Records written before the recorder stops
+	flushInterval     = time.Second
+)
+
+// Config configures a Recorder
+type Config struct {
+	File       string  // Trace file, truncated on open; empty disables recording
+	SampleRate float64 // 0..1
+	MaxRecords int     // 0 records without limit
+}
+
+// Record is one recorded task operation
+type Record struct {
+	Time          time.Time `json:"time"`
+	Op            string    `json:"op"`
+	ID            int       `json:"id,omitempty"` // Task ID of get, update and delete
+	Status        int       `json:"status"`
+	RequestBytes  int       `json:"requestBytes"`
+	ResponseBytes int       `json:"responseBytes"`
+	LatencyMicros int64     `json:"latencyMicros"`
+}
+
+// Recorder appends sampled records to a trace file. Writes are buffered and
+// flushed every second and on Close.
+type Recorder struct {
+	cfg Config
+
+	mu   sync.Mutex
+	file *os.File
+	w    *bufio.Writer
+	enc  *json.Encoder
+
+	recorded atomic.Int64
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// Open creates the trace file of cfg and starts recording into it
+func Open(cfg Config) (*Recorder, error) {
+	if cfg.File == "" {
+		return nil, fmt.Errorf("no trace file")
+	}
+	file, err := os.Create(cfg.File)
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(file)
+	r := &Recorder{
+		cfg:  cfg,
+		file: file,
+		w:    w,
+		enc:  json.NewEncoder(w),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go r.flushLoop()
+	return r, nil
+}
+
+// Config returns the configuration of the recorder
+func (r *Recorder) Config() Config {
+	return r.cfg
+}
+
+// Sample reports whether the next operation should be recorded
+func (r *Recorder) Sample() bool {
+	if r.cfg.MaxRecords > 0 && r.recorded.Load() >= int64(r.cfg.MaxRecords) {
+		return false
+	}
+	return r.cfg.SampleRate >= 1 || rand.Float64() < r.cfg.SampleRate
+}
+
+// Record appends rec to the trace, unless MaxRecords are written or the
+// recorder is closed
+func (r *Recorder) Record(rec Record) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil || (r.cfg.MaxRecords > 0 && r.recorded.Load() >= int64(r.cfg.MaxRecords)) {
+		return
+	}
+	if err := r.enc.Encode(rec); err == nil {
+		r.recorded.Add(1)
+	}
+}
+
+// Recorded returns the number of records written
+func (r *Recorder) Recorded() int64 {
+	return r.recorded.Load()
+}
+
+// flushLoop flushes the buffer every flushInterval until Close
+func (r *Recorder) flushLoop() {
+	defer close(r.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.mu.Lock()
+			if r.file != nil {
+				r.w.Flush()
+			}
+			r.mu.Unlock()
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// Close flushes the trace and closes its file
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	if r.file == nil {
+		r.mu.Unlock()
+		return nil
+	}
+	close(r.stop)
+	err := r.w.Flush()
+	if cerr := r.file.Close(); err == nil {
+		err = cerr
+	}
+	r.file = nil
+	r.mu.Unlock()
+	<-r.done
+	return err
+}
+
+// ReadTrace parses records written by a Recorder, ordered by time
+func ReadTrace(rd io.Reader) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(rd)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		switch rec.Op {
+		case OpCreate, OpGet, OpList, OpUpdate, OpDelete:
+		default:
+			return nil, fmt.Errorf("line %d: unknown op %q", line, rec.Op)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	// Concurrent requests finish, and so are written, slightly out of order
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Time.Before(records[j].Time) })
+	return records, nil
+}
+
+// LoadTrace reads the trace file at path
+func LoadTrace(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadTrace(f)
+}
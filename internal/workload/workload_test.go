@@ -0,0 +1,126 @@
+package workload
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func newRecordingApp(t *testing.T, cfg Config) (*fiber.App, *Recorder) {
+	t.Helper()
+	cfg.File = filepath.Join(t.TempDir(), "trace.jsonl")
+	r, err := Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := fiber.New()
+	app.Use(Middleware(r))
+	app.Get("/tasks", func(c *fiber.Ctx) error { return c.SendString(`[{"id":1}]`) })
+	app.Post("/tasks", func(c *fiber.Ctx) error { return c.Status(fiber.StatusCreated).SendString(`{"id":3}`) })
+	app.Get("/tasks/:id", func(c *fiber.Ctx) error {
+		if c.Params("id") == "404" {
+			return fiber.ErrNotFound
+		}
+		return c.SendString(`{"id":1}`)
+	})
+	app.Put("/tasks/:id", func(c *fiber.Ctx) error { return c.SendString(`{}`) })
+	app.Delete("/tasks/:id", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) })
+	app.Get("/health", func(c *fiber.Ctx) error { return c.SendString("ok") })
+	return app, r
+}
+
+func send(t *testing.T, app *fiber.App, method, path, body string) {
+	t.Helper()
+	if _, err := app.Test(httptest.NewRequest(method, path, strings.NewReader(body))); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	app, r := newRecordingApp(t, Config{SampleRate: 1})
+	send(t, app, "POST", "/tasks", `{"name":"a","status":0}`)
+	send(t, app, "GET", "/tasks/7", "")
+	send(t, app, "GET", "/tasks/404", "")
+	send(t, app, "PUT", "/tasks/7", `{"name":"b","status":1}`)
+	send(t, app, "DELETE", "/tasks/7", "")
+	send(t, app, "GET", "/tasks", "")
+	send(t, app, "GET", "/health", "")
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := LoadTrace(r.Config().File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 6 || r.Recorded() != 6 {
+		t.Fatalf("Expected the 6 task operations, got %d: %+v", len(records), records)
+	}
+	want := []Record{
+		{Op: OpCreate, Status: 201, RequestBytes: 23, ResponseBytes: 8},
+		{Op: OpGet, ID: 7, Status: 200, ResponseBytes: 8},
+		{Op: OpGet, ID: 404, Status: 404},
+		{Op: OpUpdate, ID: 7, Status: 200, RequestBytes: 23, ResponseBytes: 2},
+		{Op: OpDelete, ID: 7, Status: 204},
+		{Op: OpList, Status: 200, ResponseBytes: 10},
+	}
+	for i, w := range want {
+		got := records[i]
+		if got.Op != w.Op || got.ID != w.ID || got.Status != w.Status || got.RequestBytes != w.RequestBytes ||
+			(w.ResponseBytes > 0 && got.ResponseBytes != w.ResponseBytes) || got.Time.IsZero() {
+			t.Errorf("Record %d: expected %+v, got %+v", i, w, got)
+		}
+	}
+}
+
+func TestRecorder_Limits(t *testing.T) {
+	app, r := newRecordingApp(t, Config{SampleRate: 0})
+	send(t, app, "GET", "/tasks/1", "")
+	if r.Recorded() != 0 {
+		t.Errorf("Expected a sample rate of 0 to record nothing, got %d", r.Recorded())
+	}
+	r.Close()
+
+	app, r = newRecordingApp(t, Config{SampleRate: 1, MaxRecords: 2})
+	for i := 0; i < 5; i++ {
+		send(t, app, "GET", "/tasks/1", "")
+	}
+	r.Close()
+	if records, _ := LoadTrace(r.Config().File); len(records) != 2 {
+		t.Errorf("Expected recording to stop at 2 records, got %d", len(records))
+	}
+	r.Record(Record{Op: OpGet})
+	if r.Recorded() != 2 {
+		t.Error("Expected a closed recorder to drop records")
+	}
+
+	if _, err := Open(Config{}); err == nil {
+		t.Error("Expected a recorder without a file to be rejected")
+	}
+}
+
+func TestReadTrace(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	trace := `{"time":"` + base.Add(time.Second).Format(time.RFC3339Nano) + `","op":"get","id":2}
+{"time":"` + base.Format(time.RFC3339Nano) + `","op":"create"}
+
+`
+	records, err := ReadTrace(strings.NewReader(trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Op != OpCreate || records[1].ID != 2 {
+		t.Errorf("Expected the records ordered by time, got %+v", records)
+	}
+
+	if _, err := ReadTrace(strings.NewReader(`{"op":"patch"}`)); err == nil {
+		t.Error("Expected an unknown op to be rejected")
+	}
+	if _, err := ReadTrace(strings.NewReader(`{`)); err == nil {
+		t.Error("Expected malformed JSON to be rejected")
+	}
+}